@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.0;
+
+import "./interfaces/IAuthMessage.sol";
+import "./interfaces/ISubProtocol.sol";
+import "./interfaces/IContractCallResult.sol";
+import "./lib/utils/Ownable.sol";
+
+/**
+ * @dev {ContractCall} is an upper protocol beyond AM (protocol type 3) for calling a method of a
+ * contract on another blockchain with typed arguments, and receiving the result.
+ *
+ * Messages are abi encoded:
+ *   - CALL:   abi.encode(uint8 0, bytes32 callId, string target, string signature, bytes args, string returnTypes)
+ *   - RESULT: abi.encode(uint8 1, bytes32 callId, bool success, bytes result, string errorMsg)
+ *
+ * For example, a contract calls `mint(address,uint256)` of a Fabric chaincode by
+ * `callRemote(domain, "tokencc", "mint(address,uint256)", abi.encode(account, amount), "(uint256)")`,
+ * and the result would be delivered to its {IContractCallResult-onCallResult} with `result` being
+ * `abi.encode(uint256)`. The author of the CALL is the calling contract.
+ *
+ * Results are only accepted from the protocol registered by {setRemoteProtocol} for the domain
+ * the call sent to.
+ */
+contract ContractCall is ISubProtocol, Ownable {
+
+    uint32 constant public PROTOCOL_TYPE = 3;
+
+    uint8 constant public MSG_TYPE_CALL = 0;
+
+    uint8 constant public MSG_TYPE_RESULT = 1;
+
+    struct PendingCall {
+        address caller;
+        bytes32 destDomainHash;
+    }
+
+    address public amAddress;
+
+    uint256 public callNonce;
+
+    // keccak256(domain) => cross-chain id of the protocol on that blockchain, e.g. sha256(chaincode name) on Fabric
+    mapping(bytes32 => bytes32) public remoteProtocols;
+
+    mapping(bytes32 => PendingCall) public pendingCalls;
+
+    event CallSent(bytes32 indexed callId, address indexed caller, string destDomain, string target, string signature);
+
+    event CallResult(bytes32 indexed callId, address indexed caller, bool success, string errorMsg);
+
+    modifier onlyAM() {
+        require(
+            amAddress == msg.sender,
+            "ContractCall: not valid am contract"
+        );
+        _;
+    }
+
+    function setAmContract(address newAmContract) override external onlyOwner {
+        require(newAmContract != address(0), "ContractCall: invalid am contract");
+        amAddress = newAmContract;
+    }
+
+    function setRemoteProtocol(string calldata domain, bytes32 protocolID) external onlyOwner {
+        remoteProtocols[keccak256(abi.encodePacked(domain))] = protocolID;
+    }
+
+    /**
+     * @dev Call a method of the target contract on another blockchain.
+     *
+     * @param destDomain the domain name of the receiving blockchain.
+     * @param target the target contract, chaincode name on Fabric.
+     * @param signature the method signature, e.g. "transfer(address,uint256)".
+     * @param args abi encoded arguments of the method.
+     * @param returnTypes the return types, e.g. "(uint256)", empty for the raw result.
+     * @return callId the id to correlate the result.
+     */
+    function callRemote(string calldata destDomain, string calldata target, string calldata signature, bytes calldata args, string calldata returnTypes) external returns (bytes32 callId) {
+        bytes32 destDomainHash = keccak256(abi.encodePacked(destDomain));
+        require(remoteProtocols[destDomainHash] != bytes32(0), "ContractCall: remote protocol not set");
+
+        callId = keccak256(abi.encodePacked(address(this), block.chainid, callNonce));
+        callNonce++;
+        pendingCalls[callId] = PendingCall({caller: msg.sender, destDomainHash: destDomainHash});
+
+        IAuthMessage(amAddress).recvFromProtocol(
+            msg.sender,
+            abi.encode(MSG_TYPE_CALL, callId, target, signature, args, returnTypes)
+        );
+
+        emit CallSent(callId, msg.sender, destDomain, target, signature);
+    }
+
+    function recvMessage(string calldata senderDomain, bytes32 senderID, bytes calldata pkg) override external onlyAM {
+        require(abi.decode(pkg, (uint8)) == MSG_TYPE_RESULT, "ContractCall: only results accepted");
+        (, bytes32 callId, bool success, bytes memory result, string memory errorMsg) =
+            abi.decode(pkg, (uint8, bytes32, bool, bytes, string));
+
+        PendingCall memory call = pendingCalls[callId];
+        require(call.caller != address(0), "ContractCall: unknown call");
+        bytes32 senderDomainHash = keccak256(abi.encodePacked(senderDomain));
+        require(
+            call.destDomainHash == senderDomainHash && remoteProtocols[senderDomainHash] == senderID,
+            "ContractCall: unexpected responder"
+        );
+        delete pendingCalls[callId];
+
+        if (call.caller.code.length > 0) {
+            IContractCallResult(call.caller).onCallResult(callId, success, result, errorMsg);
+        }
+
+        emit CallResult(callId, call.caller, success, errorMsg);
+    }
+
+    /**
+     * @dev This empty reserved space is put in place to allow future versions to add new
+     * variables without shifting down storage in the inheritance chain.
+     * See https://docs.openzeppelin.com/contracts/4.x/upgradeable#storage_gaps
+     */
+    uint256[50] private __gap;
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.0;
+
+interface IContractCallResult {
+
+    /**
+     * @dev ContractCall contract would call this function to deliver the result of a cross-chain
+     * contract call sent by {ContractCall-callRemote}.
+     *
+     * @param callId the id returned by callRemote.
+     * @param success whether the target method is executed successfully on the receiving blockchain.
+     * @param result abi encoded return values if return types are given, otherwise the raw result.
+     * @param errorMsg the error msg from receiving blockchain if failed.
+     */
+    function onCallResult(bytes32 callId, bool success, bytes calldata result, string calldata errorMsg) external;
+}
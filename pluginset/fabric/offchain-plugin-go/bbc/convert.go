@@ -0,0 +1,136 @@
+package bbc
+
+import (
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// bbc类型与协议消息的转换，枚举按名字对应
+
+func contractToPB(c *Contract) *pb.Contract {
+	if c == nil {
+		return nil
+	}
+	return &pb.Contract{ContractAddress: c.ContractAddress, Status: pb.ContractStatus(pb.ContractStatus_value[string(c.Status)])}
+}
+
+func contractFromPB(c *pb.Contract) *Contract {
+	if c == nil {
+		return nil
+	}
+	return &Contract{ContractAddress: c.ContractAddress, Status: ContractStatus(c.Status.String())}
+}
+
+func contextToPB(ctx *BBCContext) *pb.BBCContext {
+	if ctx == nil {
+		return nil
+	}
+	return &pb.BBCContext{
+		AmContract:  contractToPB(ctx.AuthMessageContract),
+		PtcContract: contractToPB(ctx.PTCContract),
+		SdpContract: contractToPB(ctx.SDPContract),
+		RawConf:     ctx.RawConf,
+	}
+}
+
+func contextFromPB(ctx *pb.BBCContext) *BBCContext {
+	if ctx == nil {
+		return nil
+	}
+	return &BBCContext{
+		AuthMessageContract: contractFromPB(ctx.AmContract),
+		PTCContract:         contractFromPB(ctx.PtcContract),
+		SDPContract:         contractFromPB(ctx.SdpContract),
+		RawConf:             ctx.RawConf,
+	}
+}
+
+func receiptToPB(r *CrossChainMessageReceipt) *pb.CrossChainMessageReceipt {
+	if r == nil {
+		return nil
+	}
+	return &pb.CrossChainMessageReceipt{TxHash: r.TxHash, Confirmed: r.Confirmed, Successful: r.Successful, ErrorMsg: r.ErrorMsg}
+}
+
+func receiptFromPB(r *pb.CrossChainMessageReceipt) *CrossChainMessageReceipt {
+	if r == nil {
+		return nil
+	}
+	return &CrossChainMessageReceipt{TxHash: r.TxHash, Confirmed: r.Confirmed, Successful: r.Successful, ErrorMsg: r.ErrorMsg}
+}
+
+func relayStatusToPB(s *RelayStatus) *pb.RelayStatus {
+	if s == nil {
+		return nil
+	}
+	return &pb.RelayStatus{
+		PacketHash: s.PacketHash,
+		State:      pb.RelayState(pb.RelayState_value[string(s.State)]),
+		Receipt:    receiptToPB(s.Receipt),
+		TxHashes:   s.TxHashes,
+	}
+}
+
+func relayStatusFromPB(s *pb.RelayStatus) *RelayStatus {
+	if s == nil {
+		return nil
+	}
+	return &RelayStatus{
+		PacketHash: s.PacketHash,
+		State:      RelayState(s.State.String()),
+		Receipt:    receiptFromPB(s.Receipt),
+		TxHashes:   s.TxHashes,
+	}
+}
+
+// 跨链消息与协议消息的转换，HCDVS协议也使用
+func MessageToPB(m *CrossChainMessage) *pb.CrossChainMessage {
+	if m == nil {
+		return nil
+	}
+	msg := &pb.CrossChainMessage{Type: pb.CrossChainMessageType(pb.CrossChainMessageType_value[string(m.Type)]), Message: m.Message}
+	if d := m.ProvableData; d != nil {
+		msg.ProvableData = &pb.ProvableLedgerData{
+			Height:     d.Height,
+			BlockHash:  d.BlockHash,
+			Timestamp:  d.Timestamp,
+			LedgerData: d.LedgerData,
+			Proof:      d.Proof,
+			TxHash:     d.TxHash,
+		}
+	}
+	return msg
+}
+
+func MessageFromPB(m *pb.CrossChainMessage) *CrossChainMessage {
+	if m == nil {
+		return nil
+	}
+	msg := &CrossChainMessage{Type: CrossChainMessageType(m.Type.String()), Message: m.Message}
+	if d := m.ProvableData; d != nil {
+		msg.ProvableData = &ProvableLedgerData{
+			Height:     d.Height,
+			BlockHash:  d.BlockHash,
+			Timestamp:  d.Timestamp,
+			LedgerData: d.LedgerData,
+			Proof:      d.Proof,
+			TxHash:     d.TxHash,
+		}
+	}
+	return msg
+}
+
+func messagesToPB(msgs []*CrossChainMessage) []*pb.CrossChainMessage {
+	out := make([]*pb.CrossChainMessage, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, MessageToPB(m))
+	}
+	return out
+}
+
+func messagesFromPB(msgs []*pb.CrossChainMessage) []*CrossChainMessage {
+	out := make([]*CrossChainMessage, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, MessageFromPB(m))
+	}
+	return out
+}
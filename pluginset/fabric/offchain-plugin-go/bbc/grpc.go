@@ -0,0 +1,263 @@
+package bbc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// gRPC协议
+// BBCService以proto/bbc.proto中的服务提供，可以由中继通过go-plugin启动(本文件的BBCPlugin)，
+// 也可以作为独立进程注册到插件服务(见pluginserver包)。BBCService返回的错误以UNKNOWN状态码传递，
+// 客户端还原为原始的错误信息。
+
+// go-plugin插件集合中BBCService的名字
+const PLUGIN_NAME = "bbc"
+
+// 中继与插件握手的配置，双方不一致时插件拒绝启动
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  2,
+	MagicCookieKey:   "ANTCHAIN_BRIDGE_BBC_PLUGIN",
+	MagicCookieValue: "bbc",
+}
+
+// 中继侧Dispense(PLUGIN_NAME)得到BBCService
+var PluginMap = map[string]plugin.Plugin{
+	PLUGIN_NAME: &BBCPlugin{},
+}
+
+// BBCPlugin 实现plugin.GRPCPlugin，插件侧设置Impl
+type BBCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl BBCService
+}
+
+func (p *BBCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	RegisterGRPCServer(s, p.Impl)
+	return nil
+}
+
+func (p *BBCPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return NewGRPCClient(conn), nil
+}
+
+// 在gRPC服务中注册BBCService
+func RegisterGRPCServer(s *grpc.Server, impl BBCService) {
+	pb.RegisterBBCServiceServer(s, &grpcServer{impl: impl})
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+type grpcServer struct {
+	impl BBCService
+}
+
+func (s *grpcServer) Startup(ctx context.Context, req *pb.StartupRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.Startup(contextFromPB(req.Context)))
+}
+
+func (s *grpcServer) Shutdown(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.Shutdown())
+}
+
+func (s *grpcServer) GetContext(ctx context.Context, req *pb.Empty) (*pb.ContextResponse, error) {
+	c, err := s.impl.GetContext()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ContextResponse{Context: contextToPB(c)}, nil
+}
+
+func (s *grpcServer) SetupAuthMessageContract(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.SetupAuthMessageContract())
+}
+
+func (s *grpcServer) SetupSDPMessageContract(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.SetupSDPMessageContract())
+}
+
+func (s *grpcServer) SetProtocol(ctx context.Context, req *pb.SetProtocolRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.SetProtocol(req.ProtocolAddress, req.ProtocolType))
+}
+
+func (s *grpcServer) SetAmContract(ctx context.Context, req *pb.SetAmContractRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.SetAmContract(req.ContractAddress))
+}
+
+func (s *grpcServer) SetLocalDomain(ctx context.Context, req *pb.SetLocalDomainRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, toStatus(s.impl.SetLocalDomain(req.Domain))
+}
+
+func (s *grpcServer) QuerySDPMessageSeq(ctx context.Context, req *pb.QuerySDPMessageSeqRequest) (*pb.SeqResponse, error) {
+	seq, err := s.impl.QuerySDPMessageSeq(req.SenderDomain, req.FromAddress, req.ReceiverDomain, req.ToAddress)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.SeqResponse{Seq: seq}, nil
+}
+
+func (s *grpcServer) RelayAuthMessage(ctx context.Context, req *pb.RelayAuthMessageRequest) (*pb.ReceiptResponse, error) {
+	receipt, err := s.impl.RelayAuthMessage(req.RawMessage)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReceiptResponse{Receipt: receiptToPB(receipt)}, nil
+}
+
+func (s *grpcServer) QueryRelayStatus(ctx context.Context, req *pb.QueryRelayStatusRequest) (*pb.RelayStatusResponse, error) {
+	st, err := s.impl.QueryRelayStatus(req.PacketHash)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RelayStatusResponse{Status: relayStatusToPB(st)}, nil
+}
+
+func (s *grpcServer) ReadCrossChainMessageReceipt(ctx context.Context, req *pb.ReadReceiptRequest) (*pb.ReceiptResponse, error) {
+	receipt, err := s.impl.ReadCrossChainMessageReceipt(req.TxHash)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReceiptResponse{Receipt: receiptToPB(receipt)}, nil
+}
+
+func (s *grpcServer) ReadCrossChainMessagesByHeight(ctx context.Context, req *pb.HeightRequest) (*pb.MessagesResponse, error) {
+	msgs, err := s.impl.ReadCrossChainMessagesByHeight(req.Height)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.MessagesResponse{Messages: messagesToPB(msgs)}, nil
+}
+
+func (s *grpcServer) QueryLatestHeight(ctx context.Context, req *pb.Empty) (*pb.HeightResponse, error) {
+	height, err := s.impl.QueryLatestHeight()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.HeightResponse{Height: height}, nil
+}
+
+// GRPCClient 中继侧的BBCService实现
+type GRPCClient struct {
+	client pb.BBCServiceClient
+}
+
+func NewGRPCClient(conn *grpc.ClientConn) *GRPCClient {
+	return &GRPCClient{client: pb.NewBBCServiceClient(conn)}
+}
+
+var _ BBCService = (*GRPCClient)(nil)
+
+// 还原插件返回的错误信息
+func fromStatus(err error) error {
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+		return errors.New(s.Message())
+	}
+	return err
+}
+
+func (c *GRPCClient) Startup(ctx *BBCContext) error {
+	_, err := c.client.Startup(context.Background(), &pb.StartupRequest{Context: contextToPB(ctx)})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) Shutdown() error {
+	_, err := c.client.Shutdown(context.Background(), &pb.Empty{})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) GetContext() (*BBCContext, error) {
+	resp, err := c.client.GetContext(context.Background(), &pb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return contextFromPB(resp.Context), nil
+}
+
+func (c *GRPCClient) SetupAuthMessageContract() error {
+	_, err := c.client.SetupAuthMessageContract(context.Background(), &pb.Empty{})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) SetupSDPMessageContract() error {
+	_, err := c.client.SetupSDPMessageContract(context.Background(), &pb.Empty{})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) SetProtocol(protocolAddress, protocolType string) error {
+	_, err := c.client.SetProtocol(context.Background(), &pb.SetProtocolRequest{ProtocolAddress: protocolAddress, ProtocolType: protocolType})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) SetAmContract(contractAddress string) error {
+	_, err := c.client.SetAmContract(context.Background(), &pb.SetAmContractRequest{ContractAddress: contractAddress})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) SetLocalDomain(domain string) error {
+	_, err := c.client.SetLocalDomain(context.Background(), &pb.SetLocalDomainRequest{Domain: domain})
+	return fromStatus(err)
+}
+
+func (c *GRPCClient) QuerySDPMessageSeq(senderDomain, fromAddress, receiverDomain, toAddress string) (uint64, error) {
+	resp, err := c.client.QuerySDPMessageSeq(context.Background(), &pb.QuerySDPMessageSeqRequest{
+		SenderDomain:   senderDomain,
+		FromAddress:    fromAddress,
+		ReceiverDomain: receiverDomain,
+		ToAddress:      toAddress,
+	})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.Seq, nil
+}
+
+func (c *GRPCClient) RelayAuthMessage(rawMessage []byte) (*CrossChainMessageReceipt, error) {
+	resp, err := c.client.RelayAuthMessage(context.Background(), &pb.RelayAuthMessageRequest{RawMessage: rawMessage})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return receiptFromPB(resp.Receipt), nil
+}
+
+func (c *GRPCClient) QueryRelayStatus(packetHash string) (*RelayStatus, error) {
+	resp, err := c.client.QueryRelayStatus(context.Background(), &pb.QueryRelayStatusRequest{PacketHash: packetHash})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return relayStatusFromPB(resp.Status), nil
+}
+
+func (c *GRPCClient) ReadCrossChainMessageReceipt(txHash string) (*CrossChainMessageReceipt, error) {
+	resp, err := c.client.ReadCrossChainMessageReceipt(context.Background(), &pb.ReadReceiptRequest{TxHash: txHash})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return receiptFromPB(resp.Receipt), nil
+}
+
+func (c *GRPCClient) ReadCrossChainMessagesByHeight(height uint64) ([]*CrossChainMessage, error) {
+	resp, err := c.client.ReadCrossChainMessagesByHeight(context.Background(), &pb.HeightRequest{Height: height})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return messagesFromPB(resp.Messages), nil
+}
+
+func (c *GRPCClient) QueryLatestHeight() (uint64, error) {
+	resp, err := c.client.QueryLatestHeight(context.Background(), &pb.Empty{})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.Height, nil
+}
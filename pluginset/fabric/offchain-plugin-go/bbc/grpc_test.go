@@ -0,0 +1,130 @@
+package bbc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+type fakeService struct {
+	ctx      *BBCContext
+	domain   string
+	relayed  []byte
+	seqQuery []string
+}
+
+func (s *fakeService) Startup(ctx *BBCContext) error {
+	s.ctx = ctx
+	return nil
+}
+
+func (s *fakeService) Shutdown() error { return nil }
+
+func (s *fakeService) GetContext() (*BBCContext, error) { return s.ctx, nil }
+
+func (s *fakeService) SetupAuthMessageContract() error {
+	s.ctx.AuthMessageContract = &Contract{ContractAddress: "cross", Status: CONTRACT_READY}
+	return nil
+}
+
+func (s *fakeService) SetupSDPMessageContract() error {
+	return errors.New("sdp contract not deployed")
+}
+
+func (s *fakeService) SetProtocol(protocolAddress, protocolType string) error { return nil }
+
+func (s *fakeService) SetAmContract(contractAddress string) error { return nil }
+
+func (s *fakeService) SetLocalDomain(domain string) error {
+	s.domain = domain
+	return nil
+}
+
+func (s *fakeService) QuerySDPMessageSeq(senderDomain, fromAddress, receiverDomain, toAddress string) (uint64, error) {
+	s.seqQuery = []string{senderDomain, fromAddress, receiverDomain, toAddress}
+	return 7, nil
+}
+
+func (s *fakeService) RelayAuthMessage(rawMessage []byte) (*CrossChainMessageReceipt, error) {
+	s.relayed = rawMessage
+	return &CrossChainMessageReceipt{TxHash: "tx1", Confirmed: true, Successful: true}, nil
+}
+
+func (s *fakeService) QueryRelayStatus(packetHash string) (*RelayStatus, error) {
+	return &RelayStatus{
+		PacketHash: packetHash,
+		State:      RELAY_PENDING,
+		Receipt:    &CrossChainMessageReceipt{TxHash: "tx1"},
+		TxHashes:   []string{"tx0", "tx1"},
+	}, nil
+}
+
+func (s *fakeService) ReadCrossChainMessageReceipt(txHash string) (*CrossChainMessageReceipt, error) {
+	return &CrossChainMessageReceipt{TxHash: txHash, ErrorMsg: "not found"}, nil
+}
+
+func (s *fakeService) ReadCrossChainMessagesByHeight(height uint64) ([]*CrossChainMessage, error) {
+	return []*CrossChainMessage{{
+		Type:         AUTH_MSG,
+		Message:      []byte{0, 1, 2},
+		ProvableData: &ProvableLedgerData{Height: height, TxHash: []byte{0xab}},
+	}}, nil
+}
+
+func (s *fakeService) QueryLatestHeight() (uint64, error) { return 100, nil }
+
+func TestGRPCPlugin(t *testing.T) {
+	impl := &fakeService{}
+	client, _ := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{PLUGIN_NAME: &BBCPlugin{Impl: impl}})
+	defer client.Close()
+	raw, err := client.Dispense(PLUGIN_NAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := raw.(BBCService)
+
+	if err := service.Startup(&BBCContext{RawConf: []byte(`{"channel":"mychannel"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.SetupAuthMessageContract(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := service.GetContext()
+	if err != nil || string(ctx.RawConf) != `{"channel":"mychannel"}` || ctx.AuthMessageContract.Status != CONTRACT_READY {
+		t.Fatalf("unexpected context: %+v %v", ctx, err)
+	}
+	// 插件的错误信息原样返回
+	if err := service.SetupSDPMessageContract(); err == nil || err.Error() != "sdp contract not deployed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.SetLocalDomain("fabric.com"); err != nil || impl.domain != "fabric.com" {
+		t.Fatalf("unexpected domain: %s %v", impl.domain, err)
+	}
+	if seq, err := service.QuerySDPMessageSeq("a.com", "from", "b.com", "to"); err != nil || seq != 7 || impl.seqQuery[3] != "to" {
+		t.Fatalf("unexpected seq: %d %v %v", seq, impl.seqQuery, err)
+	}
+	receipt, err := service.RelayAuthMessage([]byte{0, 0, 0, 0, 1})
+	if err != nil || !receipt.Successful || receipt.TxHash != "tx1" || !bytes.Equal(impl.relayed, []byte{0, 0, 0, 0, 1}) {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	st, err := service.QueryRelayStatus("abcd")
+	if err != nil || st.PacketHash != "abcd" || st.State != RELAY_PENDING || st.Receipt.TxHash != "tx1" || len(st.TxHashes) != 2 {
+		t.Fatalf("unexpected relay status: %+v %v", st, err)
+	}
+	if receipt, err := service.ReadCrossChainMessageReceipt("tx2"); err != nil || receipt.Confirmed || receipt.ErrorMsg != "not found" {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	msgs, err := service.ReadCrossChainMessagesByHeight(9)
+	if err != nil || len(msgs) != 1 || msgs[0].Type != AUTH_MSG || msgs[0].ProvableData.Height != 9 || !bytes.Equal(msgs[0].Message, []byte{0, 1, 2}) {
+		t.Fatalf("unexpected messages: %+v %v", msgs, err)
+	}
+	if height, err := service.QueryLatestHeight(); err != nil || height != 100 {
+		t.Fatalf("unexpected height: %d %v", height, err)
+	}
+	if err := service.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
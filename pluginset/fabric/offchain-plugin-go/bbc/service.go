@@ -0,0 +1,37 @@
+package bbc
+
+// BBCService 对应antchain-bridge-spi中的IBBCService，是中继访问异构链的接口。
+// 方法返回error时中继按调用失败处理；relayAuthMessage的链上执行失败通过回执的successful和errorMsg返回。
+type BBCService interface {
+	// 启动插件，context中raw_conf为链客户端配置，系统合约为中继记录的状态
+	Startup(ctx *BBCContext) error
+	Shutdown() error
+	GetContext() (*BBCContext, error)
+
+	// 部署或准备AM、SDP合约
+	SetupAuthMessageContract() error
+	SetupSDPMessageContract() error
+	// 在AM合约中设置上层协议合约
+	SetProtocol(protocolAddress, protocolType string) error
+	// 在SDP合约中设置AM合约
+	SetAmContract(contractAddress string) error
+	// 设置本链的域名
+	SetLocalDomain(domain string) error
+
+	// 查询有序消息通道的下一个序号
+	QuerySDPMessageSeq(senderDomain, fromAddress, receiverDomain, toAddress string) (uint64, error)
+	// 向本链提交跨链消息
+	RelayAuthMessage(rawMessage []byte) (*CrossChainMessageReceipt, error)
+	// 按消息的sha256查询提交状态，插件不记录提交时返回错误
+	QueryRelayStatus(packetHash string) (*RelayStatus, error)
+	// 查询提交跨链消息的交易回执
+	ReadCrossChainMessageReceipt(txHash string) (*CrossChainMessageReceipt, error)
+	// 读取指定高度区块中发出的跨链消息
+	ReadCrossChainMessagesByHeight(height uint64) ([]*CrossChainMessage, error)
+	QueryLatestHeight() (uint64, error)
+}
+
+// StatusReporter 可以报告链连接状态的BBCService，独立进程的插件据此提供健康检查和状态查询
+type StatusReporter interface {
+	ChainStatus() *ChainStatus
+}
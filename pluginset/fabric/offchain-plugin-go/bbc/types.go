@@ -0,0 +1,97 @@
+package bbc
+
+// 与antchain-bridge-commons中的同名类型对应，json字段名与Java侧fastjson序列化的结果一致，
+// 中继可以直接转换插件返回的结构。[]byte在json中为base64，与fastjson对byte[]的处理相同。
+
+type ContractStatus string
+
+const (
+	INIT              ContractStatus = "INIT"
+	CONTRACT_DEPLOYED ContractStatus = "CONTRACT_DEPLOYED"
+	CONTRACT_READY    ContractStatus = "CONTRACT_READY"
+	CONTRACT_FREEZE   ContractStatus = "CONTRACT_FREEZE"
+)
+
+// 系统合约，AM/SDP/PTC合约的结构相同
+type Contract struct {
+	ContractAddress string         `json:"contractAddress"`
+	Status          ContractStatus `json:"status"`
+}
+
+type BBCContext struct {
+	AuthMessageContract *Contract `json:"am_contract,omitempty"`
+	PTCContract         *Contract `json:"ptc_contract,omitempty"`
+	SDPContract         *Contract `json:"sdp_contract,omitempty"`
+	// 链客户端的配置，由插件自行解析
+	RawConf []byte `json:"raw_conf"`
+}
+
+type CrossChainMessageType string
+
+const (
+	AUTH_MSG         CrossChainMessageType = "AUTH_MSG"
+	DEVELOPER_DESIGN CrossChainMessageType = "DEVELOPER_DESIGN"
+)
+
+type ProvableLedgerData struct {
+	Height     uint64 `json:"height"`
+	BlockHash  []byte `json:"blockHash"`
+	Timestamp  int64  `json:"timestamp"`
+	LedgerData []byte `json:"ledgerData,omitempty"`
+	Proof      []byte `json:"proof,omitempty"`
+	TxHash     []byte `json:"txHash"`
+}
+
+type CrossChainMessage struct {
+	Type         CrossChainMessageType `json:"type"`
+	Message      []byte                `json:"message"`
+	ProvableData *ProvableLedgerData   `json:"provableData"`
+}
+
+type CrossChainMessageReceipt struct {
+	TxHash     string `json:"txhash"`
+	Confirmed  bool   `json:"confirmed"`
+	Successful bool   `json:"successful"`
+	ErrorMsg   string `json:"errorMsg"`
+	// 插件解析链码错误信息得到的错误码(如ERR_SEQ_MISMATCH)和是否可以重新提交，不在antchain-bridge-commons中
+	ErrorCode string `json:"errorCode,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// 跨链消息在本链的提交状态，由插件记录，不在antchain-bridge-commons中
+type RelayState string
+
+const (
+	// 没有提交记录
+	RELAY_UNKNOWN RelayState = "RELAY_UNKNOWN"
+	// 交易已发送，还未确认上链
+	RELAY_PENDING RelayState = "RELAY_PENDING"
+	RELAY_SUCCESS RelayState = "RELAY_SUCCESS"
+	// 交易校验失败或未能发送，再次提交时重新发送
+	RELAY_FAILED RelayState = "RELAY_FAILED"
+)
+
+type RelayStatus struct {
+	// 消息的sha256，hex
+	PacketHash string     `json:"packetHash"`
+	State      RelayState `json:"state"`
+	// 最后一次提交的回执
+	Receipt *CrossChainMessageReceipt `json:"receipt,omitempty"`
+	// 发送过的交易，按发送顺序
+	TxHashes []string `json:"txHashes,omitempty"`
+}
+
+// 插件连接的链的状态，用于健康检查，不在antchain-bridge-commons中
+type ChainStatus struct {
+	// 已调用startup
+	Started bool `json:"started"`
+	// 已启动且最近一次查询链成功
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"`
+	// 通道最新区块
+	ChainHeight uint64 `json:"chainHeight"`
+	// 区块监听已处理的最高区块
+	LastSyncedHeight uint64 `json:"lastSyncedHeight"`
+	// 已收到还没有提交完成的跨链消息数
+	PendingMessages uint64 `json:"pendingMessages"`
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/codec"
+)
+
+// 跨链报文的调试工具
+//
+//	acb-codec decode <am|sdp|proof|tlv> [hex]   解码hex报文，输出json
+//	acb-codec encode <am|sdp|proof> [json文件]   按json构造报文，输出hex
+//
+// 没有给出参数时从标准输入读取。am的上层协议为SDP时同时展开SDP报文，proof为委员会背书，
+// tlv按字段展开任意TLV报文。encode的json与decode的输出格式相同。
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+const usage = "usage: acb-codec decode <am|sdp|proof|tlv> [hex] | acb-codec encode <am|sdp|proof> [json file]"
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf(usage)
+	}
+	var input []byte
+	var err error
+	switch {
+	case len(args) == 3 && args[0] == "decode":
+		input = []byte(args[2])
+	case len(args) == 3:
+		input, err = ioutil.ReadFile(args[2])
+	default:
+		input, err = ioutil.ReadAll(stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %v", err)
+	}
+
+	switch args[0] {
+	case "decode":
+		raw, err := codec.DecodeHex(string(input))
+		if err != nil {
+			return err
+		}
+		v, err := decode(args[1], raw)
+		if err != nil {
+			return err
+		}
+		out, _ := json.MarshalIndent(v, "", "  ")
+		_, err = fmt.Fprintln(stdout, string(out))
+		return err
+	case "encode":
+		raw, err := encode(args[1], input)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%x\n", raw)
+		return err
+	}
+	return fmt.Errorf(usage)
+}
+
+func decode(kind string, raw []byte) (interface{}, error) {
+	switch kind {
+	case "am":
+		return codec.DecodeAuthMessage(raw)
+	case "sdp":
+		return codec.DecodeSDPMessage(raw)
+	case "proof":
+		return codec.DecodeEndorsement(raw)
+	case "tlv":
+		return codec.DecodeTLVPacket(raw)
+	}
+	return nil, fmt.Errorf("unknown blob type %s", kind)
+}
+
+type encoder interface {
+	Encode() ([]byte, error)
+}
+
+func encode(kind string, input []byte) ([]byte, error) {
+	var v encoder
+	switch kind {
+	case "am":
+		v = &codec.AuthMessage{}
+	case "sdp":
+		v = &codec.SDPMessage{}
+	case "proof":
+		v = &codec.Endorsement{}
+	default:
+		return nil, fmt.Errorf("unknown blob type %s", kind)
+	}
+	d := json.NewDecoder(strings.NewReader(string(input)))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return nil, fmt.Errorf("invalid %s json: %v", kind, err)
+	}
+	return v.Encode()
+}
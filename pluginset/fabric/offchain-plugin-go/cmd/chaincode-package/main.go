@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 链码的打包工具，代替手工合并链码目录和公共vendor
+//
+//	chaincode-package -path ../onchain-plugin/cross -label cross_1.6.0 -out cross.tar.gz
+//	chaincode-package -path ../onchain-plugin/bizcc -dir /tmp/bizcc
+//
+// -path为onchain-plugin/cross或bizcc，按fabric.StageChaincode把v2.2与公共的vendor、go.mod合并。
+// 配置-out时写入与peer lifecycle chaincode package相同格式的链码包，标签为-label；
+// 配置-dir时把合并后的目录写入该目录，供peer lifecycle chaincode package --path或docker构建使用，目录中已有的文件保留。
+func main() {
+	path := flag.String("path", "", "chaincode directory, onchain-plugin/cross or bizcc")
+	label := flag.String("label", "", "chaincode package label")
+	out := flag.String("out", "", "chaincode package file")
+	dir := flag.String("dir", "", "directory to write the merged chaincode")
+	flag.Parse()
+
+	if err := run(*path, *label, *out, *dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path, label, out, dir string) error {
+	switch {
+	case path == "":
+		return fmt.Errorf("-path is required")
+	case out == "" && dir == "":
+		return fmt.Errorf("-out or -dir is required")
+	case out != "" && label == "":
+		return fmt.Errorf("-label is required to package chaincode")
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := fabric.StageChaincode(path, dir); err != nil {
+			return err
+		}
+	}
+	if out == "" {
+		return nil
+	}
+	pkg, err := fabric.PackageChaincode(path, label)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(out, pkg, 0644); err != nil {
+		return fmt.Errorf("failed to write chaincode package: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/committee"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/spv"
+)
+
+// 委员会节点进程入口，为来源Fabric链的跨链消息签名
+//
+//	committee-node keygen [ecdsa|sm2]  生成节点私钥，输出PEM
+//	committee-node pubkey              输出节点密钥的公钥，用于登记委员会
+//	committee-node                     启动签名服务
+//
+// 节点密钥为COMMITTEE_KEY_FILE中的PEM私钥，或COMMITTEE_SIGNER_FILE中signer.Config的json，
+// 后者用于HSM或KMS中的密钥。
+// COMMITTEE_NODE_ID为节点id，COMMITTEE_FILE为委员会的json(与链码queryPTCCommittee的返回一致)，
+// 收到SIGHUP时重新读取，用于委员会轮换。FABRIC_HCDVS_CHAINCODE为来源链的跨链链码名。
+func main() {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       "committee-node",
+		Level:      hclog.LevelFromString(os.Getenv("COMMITTEE_LOG_LEVEL")),
+		Output:     os.Stderr,
+		JSONFormat: true,
+	})
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := serve(logger); err != nil {
+		logger.Error("failed to serve committee node", "error", err)
+		os.Exit(1)
+	}
+}
+
+func runCommand(args []string) error {
+	switch args[0] {
+	case "keygen":
+		algo := signer.ECDSA_P256_SHA256
+		if len(args) > 1 && args[1] == "sm2" {
+			algo = signer.SM2_SM3
+		} else if len(args) > 1 && args[1] != "ecdsa" {
+			return fmt.Errorf("unknown algorithm %s", args[1])
+		}
+		key, err := signer.Generate(algo)
+		if err != nil {
+			return err
+		}
+		raw, err := signer.MarshalPrivateKeyPEM(key)
+		if err != nil {
+			return err
+		}
+		fmt.Print(raw)
+	case "pubkey":
+		key, err := loadSigner()
+		if err != nil {
+			return err
+		}
+		defer signer.Close(key)
+		raw, err := signer.MarshalPublicKeyPEM(key.Public())
+		if err != nil {
+			return err
+		}
+		fmt.Print(raw)
+	default:
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+	return nil
+}
+
+func loadSigner() (signer.Signer, error) {
+	path := os.Getenv("COMMITTEE_SIGNER_FILE")
+	if path == "" {
+		return signer.LoadKeyFile(os.Getenv("COMMITTEE_KEY_FILE"))
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signer file: %v", err)
+	}
+	var conf signer.Config
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		return nil, fmt.Errorf("invalid signer file: %v", err)
+	}
+	return signer.New(&conf)
+}
+
+func loadCommittee(path string) (*committee.Committee, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read committee file: %v", err)
+	}
+	var c committee.Committee
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid committee file: %v", err)
+	}
+	return &c, nil
+}
+
+func serve(logger hclog.Logger) error {
+	chaincode := os.Getenv("FABRIC_HCDVS_CHAINCODE")
+	if chaincode == "" {
+		return fmt.Errorf("FABRIC_HCDVS_CHAINCODE is required")
+	}
+	key, err := loadSigner()
+	if err != nil {
+		return err
+	}
+	defer signer.Close(key)
+	committeeFile := os.Getenv("COMMITTEE_FILE")
+	c, err := loadCommittee(committeeFile)
+	if err != nil {
+		return err
+	}
+	node, err := committee.NewNode(os.Getenv("COMMITTEE_NODE_ID"), c, key, spv.NewFabricHCDVS(chaincode))
+	if err != nil {
+		return err
+	}
+
+	listen := os.Getenv("COMMITTEE_LISTEN_ADDRESS")
+	if listen == "" {
+		listen = "127.0.0.1:8090"
+	}
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", listen, err)
+	}
+	s := grpc.NewServer()
+	committee.RegisterGRPCServer(s, node)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if sig != syscall.SIGHUP {
+				s.GracefulStop()
+				return
+			}
+			c, err := loadCommittee(committeeFile)
+			if err == nil {
+				err = node.UpdateCommittee(c)
+			}
+			if err != nil {
+				logger.Error("failed to reload committee", "error", err)
+				continue
+			}
+			logger.Info("committee reloaded", "committee", c.CommitteeId, "epoch", c.Epoch)
+		}
+	}()
+	logger.Info("committee node started", "address", lis.Addr().String(), "committee", c.CommitteeId, "epoch", c.Epoch, "signAlgo", node.SignAlgo())
+	return s.Serve(lis)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pluginserver"
+)
+
+const (
+	PRODUCT = "fabric"
+	VERSION = "0.2.0"
+)
+
+// 插件进程入口
+// 设置了PLUGIN_SERVER_ADDRESS时作为独立进程注册到插件服务，否则由中继通过go-plugin启动。
+// 设置了BBC_PLUGIN_METRICS_ADDRESS时在该地址的/metrics提供Prometheus指标，
+// 设置了OTEL_EXPORTER_OTLP_ENDPOINT时以OTLP导出跨链消息的trace
+func main() {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       "fabric-bbc",
+		Level:      hclog.LevelFromString(os.Getenv("BBC_PLUGIN_LOG_LEVEL")),
+		Output:     os.Stderr,
+		JSONFormat: true,
+	})
+	shutdownTracing, err := fabric.SetupTracing(context.Background())
+	if err != nil {
+		logger.Error("failed to setup tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	impl := fabric.NewFabricBBCService(logger)
+	if addr := os.Getenv("BBC_PLUGIN_METRICS_ADDRESS"); addr != "" {
+		go func() {
+			if err := fabric.ServeMetrics(context.Background(), addr, logger); err != nil {
+				logger.Error("failed to serve metrics", "error", err)
+			}
+		}()
+	}
+
+	if addr := os.Getenv("PLUGIN_SERVER_ADDRESS"); addr != "" {
+		listen := os.Getenv("BBC_PLUGIN_LISTEN_ADDRESS")
+		if listen == "" {
+			listen = "127.0.0.1:0"
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		err = pluginserver.ServePlugin(ctx, &pluginserver.PluginConfig{
+			ServerAddress:    addr,
+			Product:          PRODUCT,
+			Domain:           os.Getenv("BBC_PLUGIN_DOMAIN"),
+			Version:          VERSION,
+			ListenAddress:    listen,
+			AdvertiseAddress: os.Getenv("BBC_PLUGIN_ADVERTISE_ADDRESS"),
+			Impl:             impl,
+			Logger:           logger,
+		})
+		if err != nil {
+			logger.Error("failed to serve plugin", "error", err)
+			shutdownTracing(context.Background())
+			os.Exit(1)
+		}
+		return
+	}
+
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: bbc.Handshake,
+		Plugins: map[string]plugin.Plugin{
+			bbc.PLUGIN_NAME: &bbc.BBCPlugin{Impl: impl},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+		Logger:     logger,
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 跨链链码的部署工具，代替手工执行peer lifecycle和初始化调用
+//
+//	fabric-bridge-setup -config setup.json [-steps package,install,approve,commit,wire,verify] [-package cross.tar.gz]
+//
+// 配置为插件的配置加上fabric.DeployConfig中的部署字段。-steps选择执行的步骤，默认全部：
+// package打包链码，配置了ccaas时打包链码服务的连接信息，配置-package时写入该文件；不打包时install从-package读取链码包。
+// approve使用本组织节点上按标签安装的包。多个组织时各组织执行install,approve，最后由一个组织执行commit,wire,verify。
+var steps = []string{"package", "install", "approve", "commit", "wire", "verify"}
+
+func main() {
+	configPath := flag.String("config", "", "setup config file")
+	stepList := flag.String("steps", strings.Join(steps, ","), "steps to run")
+	packagePath := flag.String("package", "", "chaincode package file")
+	flag.Parse()
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:   "fabric-bridge-setup",
+		Level:  hclog.LevelFromString(os.Getenv("FABRIC_SETUP_LOG_LEVEL")),
+		Output: os.Stderr,
+	})
+	if err := run(*configPath, *stepList, *packagePath, logger); err != nil {
+		logger.Error("failed to set up cross chaincode", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, stepList, packagePath string, logger hclog.Logger) error {
+	selected := map[string]bool{}
+	for _, step := range strings.Split(stepList, ",") {
+		if !contains(steps, step) {
+			return fmt.Errorf("unknown step %s", step)
+		}
+		selected[step] = true
+	}
+	if configPath == "" {
+		return fmt.Errorf("-config is required")
+	}
+	raw, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	conf, err := fabric.ParseDeployConfig(raw)
+	if err != nil {
+		return err
+	}
+
+	var pkg []byte
+	if selected["package"] {
+		if conf.CCaaS != nil {
+			logger.Info("package cross chaincode as a service", "address", conf.CCaaS.Address)
+			if pkg, err = fabric.PackageCCaaS(conf.CCaaS, conf.Label()); err != nil {
+				return err
+			}
+		} else {
+			if conf.CrossPath == "" {
+				return fmt.Errorf("crossPath is required to package chaincode")
+			}
+			logger.Info("package cross chaincode", "path", conf.CrossPath)
+			if pkg, err = fabric.PackageChaincode(conf.CrossPath, conf.Label()); err != nil {
+				return err
+			}
+		}
+		if packagePath != "" {
+			if err := ioutil.WriteFile(packagePath, pkg, 0644); err != nil {
+				return fmt.Errorf("failed to write chaincode package: %v", err)
+			}
+		}
+	} else if selected["install"] {
+		if packagePath == "" {
+			return fmt.Errorf("-package is required to install chaincode without packaging")
+		}
+		if pkg, err = ioutil.ReadFile(packagePath); err != nil {
+			return fmt.Errorf("failed to read chaincode package: %v", err)
+		}
+	}
+	if !selected["install"] && !selected["approve"] && !selected["commit"] && !selected["wire"] && !selected["verify"] {
+		return nil
+	}
+
+	d, err := fabric.NewDeployer(conf, logger)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	var packageID string
+	if selected["install"] {
+		if packageID, err = d.Install(pkg); err != nil {
+			return err
+		}
+	}
+	if selected["approve"] {
+		if packageID == "" {
+			if packageID, err = d.InstalledPackage(); err != nil {
+				return err
+			}
+		}
+		if err := d.Approve(packageID); err != nil {
+			return err
+		}
+	}
+	if selected["commit"] {
+		if err := d.Commit(); err != nil {
+			return err
+		}
+	}
+	if selected["wire"] {
+		if err := d.Wire(); err != nil {
+			return err
+		}
+	}
+	if selected["verify"] {
+		return d.Verify()
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
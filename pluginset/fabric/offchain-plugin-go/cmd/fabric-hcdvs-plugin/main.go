@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/hcdvs"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/spv"
+)
+
+// HCDVS插件进程入口，由PTC通过go-plugin启动
+// FABRIC_HCDVS_CHAINCODE为跨链链码名，只接受该链码写入的跨链消息
+func main() {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       "fabric-hcdvs",
+		Level:      hclog.LevelFromString(os.Getenv("HCDVS_PLUGIN_LOG_LEVEL")),
+		Output:     os.Stderr,
+		JSONFormat: true,
+	})
+	chaincode := os.Getenv("FABRIC_HCDVS_CHAINCODE")
+	if chaincode == "" {
+		logger.Error("FABRIC_HCDVS_CHAINCODE is required")
+		os.Exit(1)
+	}
+
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: hcdvs.Handshake,
+		Plugins: map[string]plugin.Plugin{
+			hcdvs.PLUGIN_NAME: &hcdvs.HCDVSPlugin{Impl: spv.NewFabricHCDVS(chaincode)},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+		Logger:     logger,
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/relayqueue"
+)
+
+// 中继队列的运维工具
+//
+//	relay-queue -db <队列文件> pending                列出未确认的消息
+//	relay-queue -db <队列文件> dead                   列出死信
+//	relay-queue -db <队列文件> requeue <seq|all>...   把死信重新放到队尾
+//	relay-queue -db <队列文件> drop <seq|all>...      删除死信
+//
+// 队列文件被中继打开时无法访问，需要先停止中继。消息以json输出，payload为AM报文的hex，
+// 可以用acb-codec decode am解码，traceId与插件和链码日志中的相同。
+func main() {
+	db := flag.String("db", "", "relay queue file")
+	flag.Parse()
+	if err := run(*db, flag.Args(), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+const usage = "usage: relay-queue -db <file> pending | dead | requeue <seq|all>... | drop <seq|all>..."
+
+type messageView struct {
+	*relayqueue.Message
+	Payload string `json:"payload"`
+	TraceId string `json:"traceId"`
+}
+
+func printMessages(w io.Writer, msgs []*relayqueue.Message) error {
+	views := make([]*messageView, 0, len(msgs))
+	for _, msg := range msgs {
+		hash := sha256.Sum256(msg.Payload)
+		views = append(views, &messageView{Message: msg, Payload: hex.EncodeToString(msg.Payload), TraceId: hex.EncodeToString(hash[:16])})
+	}
+	out, _ := json.MarshalIndent(views, "", "  ")
+	_, err := fmt.Fprintln(w, string(out))
+	return err
+}
+
+// 解析序号，all为全部死信
+func deadSeqs(q *relayqueue.Queue, args []string) ([]uint64, error) {
+	if len(args) == 1 && args[0] == "all" {
+		dead, err := q.Dead(0)
+		if err != nil {
+			return nil, err
+		}
+		seqs := make([]uint64, 0, len(dead))
+		for _, msg := range dead {
+			seqs = append(seqs, msg.Seq)
+		}
+		return seqs, nil
+	}
+	var seqs []uint64
+	for _, arg := range args {
+		seq, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seq %s", arg)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, nil
+}
+
+func run(db string, args []string, stdout io.Writer) error {
+	if db == "" || len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	q, err := relayqueue.Open(db)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	switch {
+	case args[0] == "pending" && len(args) == 1:
+		msgs, err := q.Pending(0)
+		if err != nil {
+			return err
+		}
+		return printMessages(stdout, msgs)
+	case args[0] == "dead" && len(args) == 1:
+		msgs, err := q.Dead(0)
+		if err != nil {
+			return err
+		}
+		return printMessages(stdout, msgs)
+	case (args[0] == "requeue" || args[0] == "drop") && len(args) > 1:
+		seqs, err := deadSeqs(q, args[1:])
+		if err != nil {
+			return err
+		}
+		for _, seq := range seqs {
+			if args[0] == "drop" {
+				if err := q.Drop(seq); err != nil {
+					return err
+				}
+				fmt.Fprintf(stdout, "dropped %d\n", seq)
+				continue
+			}
+			next, err := q.Requeue(seq)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "requeued %d as %d\n", seq, next)
+		}
+		return nil
+	}
+	return fmt.Errorf(usage)
+}
@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// AM报文，从报文末尾向前解析，整数均为大端序：
+//
+//	v1: payload(EVM bytes) || upperProtocol(4) || author(32) || version(4)
+//	v2: payload || payloadLength(4) || trustLevel(1) || upperProtocol(4) || author(32) || version(4)
+const (
+	AM_VERSION_1 = uint32(1)
+	AM_VERSION_2 = uint32(2)
+
+	// 上层协议号，0为SDP消息
+	PROTOCOL_TYPE_SDP = uint32(0)
+
+	amV1MinSize = 72
+	amV2MinSize = 45
+)
+
+type AuthMessage struct {
+	Version uint32 `json:"version"`
+	// 发送链码名的sha256
+	Author       HexBytes `json:"author"`
+	ProtocolType uint32   `json:"protocolType"`
+	// 仅v2
+	TrustLevel uint8    `json:"trustLevel,omitempty"`
+	Payload    HexBytes `json:"payload"`
+	// 上层协议为SDP时解码的报文；编码时不为空则由其生成payload
+	SDP *SDPMessage `json:"sdp,omitempty"`
+}
+
+func DecodeAuthMessage(raw []byte) (*AuthMessage, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("am message too short: %d bytes", len(raw))
+	}
+	m := &AuthMessage{Version: binary.BigEndian.Uint32(raw[len(raw)-4:])}
+	minSize := amV1MinSize
+	switch m.Version {
+	case AM_VERSION_1:
+	case AM_VERSION_2:
+		minSize = amV2MinSize
+	default:
+		return nil, fmt.Errorf("unsupported am version %d", m.Version)
+	}
+	if len(raw) < minSize {
+		return nil, fmt.Errorf("am v%d message should be at least %d bytes, got %d", m.Version, minSize, len(raw))
+	}
+	r := &backwardReader{raw: raw, offset: len(raw), prefix: fmt.Sprintf("am v%d message", m.Version)}
+	r.next(4, "version")
+	m.Author = append(HexBytes{}, r.next(32, "author")...)
+	m.ProtocolType = r.uint32("protocol type")
+	if m.Version == AM_VERSION_1 {
+		m.Payload = r.evmBytes("payload")
+	} else {
+		if b := r.next(1, "trust level"); b != nil {
+			m.TrustLevel = b[0]
+		}
+		if m.TrustLevel > 2 {
+			return nil, fmt.Errorf("invalid am trust level %d", m.TrustLevel)
+		}
+		m.Payload = r.varBytes("payload")
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if m.ProtocolType == PROTOCOL_TYPE_SDP {
+		// 不是合法的SDP报文时只保留payload
+		m.SDP, _ = DecodeSDPMessage(m.Payload)
+	}
+	return m, nil
+}
+
+func (m *AuthMessage) Encode() ([]byte, error) {
+	if len(m.Author) != 32 {
+		return nil, fmt.Errorf("am author should be 32 bytes, got %d", len(m.Author))
+	}
+	payload := []byte(m.Payload)
+	if m.SDP != nil {
+		var err error
+		if payload, err = m.SDP.Encode(); err != nil {
+			return nil, err
+		}
+	}
+	var buf []byte
+	switch m.Version {
+	case AM_VERSION_1:
+		buf = appendEVMBytes(nil, payload)
+	case AM_VERSION_2:
+		buf = append(append([]byte{}, payload...), 0, 0, 0, 0, m.TrustLevel)
+		binary.BigEndian.PutUint32(buf[len(payload):], uint32(len(payload)))
+	default:
+		return nil, fmt.Errorf("unsupported am version %d", m.Version)
+	}
+	var tail [40]byte
+	binary.BigEndian.PutUint32(tail[:], m.ProtocolType)
+	copy(tail[4:], m.Author)
+	binary.BigEndian.PutUint32(tail[36:], m.Version)
+	return append(buf, tail[:]...), nil
+}
@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/committee"
+)
+
+// 委员会背书，即recvPTCMessage的第三个参数
+type Endorsement struct {
+	CommitteeId string           `json:"committeeId"`
+	Epoch       uint64           `json:"epoch"`
+	Signatures  []*NodeSignature `json:"signatures"`
+}
+
+type NodeSignature struct {
+	NodeId    string   `json:"nodeId"`
+	Signature HexBytes `json:"signature"`
+	// 0为SHA-256加ECDSA，2为SM3加SM2
+	SignAlgo uint8 `json:"signAlgo"`
+}
+
+func DecodeEndorsement(raw []byte) (*Endorsement, error) {
+	e, err := committee.DecodeEndorsement(raw)
+	if err != nil {
+		return nil, err
+	}
+	out := &Endorsement{CommitteeId: e.CommitteeId, Epoch: e.Epoch}
+	for _, sig := range e.Signatures {
+		out.Signatures = append(out.Signatures, &NodeSignature{NodeId: sig.NodeId, Signature: HexBytes(sig.Signature), SignAlgo: sig.SignAlgo})
+	}
+	return out, nil
+}
+
+func (e *Endorsement) Encode() ([]byte, error) {
+	out := &committee.Endorsement{CommitteeId: e.CommitteeId, Epoch: e.Epoch}
+	for _, sig := range e.Signatures {
+		if sig == nil {
+			return nil, fmt.Errorf("nil signature")
+		}
+		out.Signatures = append(out.Signatures, &committee.NodeSignature{NodeId: sig.NodeId, Signature: sig.Signature, SignAlgo: sig.SignAlgo})
+	}
+	return out.Encode(), nil
+}
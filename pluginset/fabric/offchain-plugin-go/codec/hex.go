@@ -0,0 +1,42 @@
+package codec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 跨链报文的编解码，用于调试和构造测试报文
+// AM、SDP报文与跨链链码的am和oraclelogic包一致，委员会背书与committee包一致。
+// 报文的json中字节串均为hex，可以带0x前缀。
+
+type HexBytes []byte
+
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	raw, err := DecodeHex(s)
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+// 解码hex，忽略0x前缀和空白
+func DecodeHex(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %v", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,85 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// 从尾部向前读取
+type backwardReader struct {
+	raw    []byte
+	offset int
+	err    error
+	prefix string
+}
+
+func (r *backwardReader) next(n int, field string) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > r.offset {
+		r.err = fmt.Errorf("%s truncated at %s", r.prefix, field)
+		return nil
+	}
+	r.offset -= n
+	return r.raw[r.offset : r.offset+n]
+}
+
+func (r *backwardReader) uint32(field string) uint32 {
+	if b := r.next(4, field); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// 长度在后、内容在前的变长字段
+func (r *backwardReader) varBytes(field string) []byte {
+	l := r.uint32(field)
+	if l > uint32(r.offset) {
+		r.next(-1, field)
+		return nil
+	}
+	return append([]byte{}, r.next(int(l), field)...)
+}
+
+// EVM bytes编码的变长字段：32字节长度之前为按32字节分块倒序排列的内容，最后一块左对齐补零
+func (r *backwardReader) evmBytes(field string) []byte {
+	word := r.next(32, field)
+	if word == nil {
+		return nil
+	}
+	for _, b := range word[:28] {
+		if b != 0 {
+			r.err = fmt.Errorf("%s length overflow at %s", r.prefix, field)
+			return nil
+		}
+	}
+	l := uint64(binary.BigEndian.Uint32(word[28:]))
+	if (l+31)/32*32 > uint64(r.offset) {
+		r.next(-1, field)
+		return nil
+	}
+	out := make([]byte, l)
+	for i := 0; i < len(out); i += 32 {
+		copy(out[i:], r.next(32, field))
+	}
+	return out
+}
+
+func appendEVMBytes(buf, value []byte) []byte {
+	n := (len(value) + 31) / 32
+	for i := n - 1; i >= 0; i-- {
+		var chunk [32]byte
+		copy(chunk[:], value[i*32:])
+		buf = append(buf, chunk[:]...)
+	}
+	var word [32]byte
+	binary.BigEndian.PutUint32(word[28:], uint32(len(value)))
+	return append(buf, word[:]...)
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
@@ -0,0 +1,226 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+)
+
+// SDP报文，所有字段从报文尾部向前排列：
+//
+//	v1: 目标域名(32+N) || 目标身份(32) || 序号(4) || 消息内容(32+N)   变长字段为32字节长度后接按32字节对齐的内容
+//	v2: 版本号(4) || 消息id(32) || 目标域名长度(4) || 目标域名(N) || 目标身份(32) || 原子标志(1) ||
+//	    nonce(8) || 序号(4) || 消息内容长度(4) || 消息内容(N) [|| 错误信息长度(4) || 错误信息(N)]
+//
+// 报文最后4个字节的最高字节为0xff时按其余3个字节取版本号，否则为v1。
+//
+// v1、v2报文都可以带扩展，最后4个字节为全1：
+//
+//	内层SDP报文 || 扩展(TLV packet，tag 0为W3C traceparent) || 扩展长度(4) || 0xffffffff
+const (
+	SDP_VERSION_1 = uint32(1)
+	SDP_VERSION_2 = uint32(2)
+
+	SDP_VERSION_MAGIC = 0xff
+	// 带扩展的报文
+	SDP_VERSION_EXTENDED = uint32(0x00ffffff)
+
+	sdpExtTraceParent = uint16(0)
+
+	SDP_ATOMIC_NONE                  = uint8(0)
+	SDP_ATOMIC_REQUEST               = uint8(1)
+	SDP_ATOMIC_ACK_SUCCESS           = uint8(2)
+	SDP_ATOMIC_ACK_ERROR             = uint8(3)
+	SDP_ATOMIC_ACK_RECEIVE_TX_FAILED = uint8(4)
+	SDP_ATOMIC_ACK_UNKNOWN_EXCEPTION = uint8(5)
+
+	// 无序消息的序号
+	SDP_UNORDERED_SEQ = uint32(0xffffffff)
+
+	sdpV2FixedSize = 89
+)
+
+type SDPMessage struct {
+	Version        uint32   `json:"version"`
+	TargetDomain   string   `json:"targetDomain"`
+	TargetIdentity HexBytes `json:"targetIdentity"`
+	Sequence       uint32   `json:"sequence"`
+	Payload        HexBytes `json:"payload"`
+	// payload为可读文本时解码填写；编码时payload为空则使用该文本
+	PayloadText string `json:"payloadText,omitempty"`
+
+	// 以下字段仅v2
+	MessageId  HexBytes `json:"messageId,omitempty"`
+	AtomicFlag uint8    `json:"atomicFlag,omitempty"`
+	Nonce      uint64   `json:"nonce,omitempty"`
+	ErrorMsg   string   `json:"errorMsg,omitempty"`
+
+	// 扩展中的traceparent，不为空时编码为带扩展的报文
+	TraceParent string `json:"traceParent,omitempty"`
+}
+
+// 原子标志为回执失败时报文带有错误信息
+func sdpWithErrorMsg(flag uint8) bool {
+	return flag > SDP_ATOMIC_ACK_SUCCESS
+}
+
+func DecodeSDPMessage(raw []byte) (*SDPMessage, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("sdp message too short: %d bytes", len(raw))
+	}
+	version := SDP_VERSION_1
+	if tail := raw[len(raw)-4:]; tail[0] == SDP_VERSION_MAGIC {
+		version = binary.BigEndian.Uint32(tail) & 0x00ffffff
+	}
+	if version == SDP_VERSION_EXTENDED {
+		return decodeExtendedSDPMessage(raw)
+	}
+	m := &SDPMessage{Version: version}
+	switch version {
+	case SDP_VERSION_1:
+		r := &backwardReader{raw: raw, offset: len(raw), prefix: "sdp v1 message"}
+		m.TargetDomain = string(r.evmBytes("target domain"))
+		m.TargetIdentity = r.next(32, "target identity")
+		m.Sequence = r.uint32("sequence")
+		m.Payload = r.evmBytes("payload")
+		if r.err != nil {
+			return nil, r.err
+		}
+	case SDP_VERSION_2:
+		if len(raw) < sdpV2FixedSize {
+			return nil, fmt.Errorf("sdp v2 message should be at least %d bytes, got %d", sdpV2FixedSize, len(raw))
+		}
+		r := &backwardReader{raw: raw, offset: len(raw), prefix: "sdp v2 message"}
+		r.next(4, "version")
+		m.MessageId = r.next(32, "message id")
+		m.TargetDomain = string(r.varBytes("target domain"))
+		m.TargetIdentity = r.next(32, "target identity")
+		if b := r.next(1, "atomic flag"); b != nil {
+			m.AtomicFlag = b[0]
+		}
+		if b := r.next(8, "nonce"); b != nil {
+			m.Nonce = binary.BigEndian.Uint64(b)
+		}
+		m.Sequence = r.uint32("sequence")
+		m.Payload = r.varBytes("payload")
+		if r.err == nil && m.AtomicFlag > SDP_ATOMIC_ACK_UNKNOWN_EXCEPTION {
+			return nil, fmt.Errorf("unknown sdp atomic flag %d", m.AtomicFlag)
+		}
+		if sdpWithErrorMsg(m.AtomicFlag) {
+			m.ErrorMsg = string(r.varBytes("error message"))
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		m.MessageId = append(HexBytes{}, m.MessageId...)
+	default:
+		return nil, fmt.Errorf("unsupported sdp version %d", version)
+	}
+	m.TargetIdentity = append(HexBytes{}, m.TargetIdentity...)
+	if printable(m.Payload) {
+		m.PayloadText = string(m.Payload)
+	}
+	return m, nil
+}
+
+func decodeExtendedSDPMessage(raw []byte) (*SDPMessage, error) {
+	r := &backwardReader{raw: raw, offset: len(raw), prefix: "sdp extension"}
+	r.next(4, "version")
+	ext := r.varBytes("extension")
+	if r.err != nil {
+		return nil, r.err
+	}
+	inner := raw[:r.offset]
+	if len(inner) >= 4 && binary.BigEndian.Uint32(inner[len(inner)-4:]) == SDP_VERSION_MAGIC<<24|SDP_VERSION_EXTENDED {
+		return nil, fmt.Errorf("nested sdp extension")
+	}
+	if _, err := DecodeTLVPacket(ext); err != nil {
+		return nil, fmt.Errorf("invalid sdp extension: %v", err)
+	}
+	m, err := DecodeSDPMessage(inner)
+	if err != nil {
+		return nil, err
+	}
+	m.TraceParent = string(tlvItemValue(ext, sdpExtTraceParent))
+	return m, nil
+}
+
+// TLV packet中某个tag的原始值，packet已校验过格式
+func tlvItemValue(raw []byte, tag uint16) []byte {
+	for offset := 6; offset+6 <= len(raw); {
+		l := int(binary.LittleEndian.Uint32(raw[offset+2:]))
+		if binary.LittleEndian.Uint16(raw[offset:]) == tag {
+			return raw[offset+6 : offset+6+l]
+		}
+		offset += 6 + l
+	}
+	return nil
+}
+
+func (m *SDPMessage) Encode() ([]byte, error) {
+	buf, err := m.encodeInner()
+	if err != nil || m.TraceParent == "" {
+		return buf, err
+	}
+	// packet头(version为0)和tag 0的item头
+	ext := make([]byte, 12, 12+len(m.TraceParent))
+	binary.LittleEndian.PutUint32(ext[2:], uint32(6+len(m.TraceParent)))
+	binary.LittleEndian.PutUint16(ext[6:], sdpExtTraceParent)
+	binary.LittleEndian.PutUint32(ext[8:], uint32(len(m.TraceParent)))
+	ext = append(ext, m.TraceParent...)
+	buf = appendUint32(append(buf, ext...), uint32(len(ext)))
+	return appendUint32(buf, SDP_VERSION_MAGIC<<24|SDP_VERSION_EXTENDED), nil
+}
+
+func (m *SDPMessage) encodeInner() ([]byte, error) {
+	if len(m.TargetIdentity) != 32 {
+		return nil, fmt.Errorf("sdp target identity should be 32 bytes, got %d", len(m.TargetIdentity))
+	}
+	payload := []byte(m.Payload)
+	if len(payload) == 0 {
+		payload = []byte(m.PayloadText)
+	}
+	var buf []byte
+	switch m.Version {
+	case SDP_VERSION_1:
+		buf = appendEVMBytes(buf, payload)
+		buf = appendUint32(buf, m.Sequence)
+		buf = append(buf, m.TargetIdentity...)
+		buf = appendEVMBytes(buf, []byte(m.TargetDomain))
+	case SDP_VERSION_2:
+		if len(m.MessageId) != 32 {
+			return nil, fmt.Errorf("sdp message id should be 32 bytes, got %d", len(m.MessageId))
+		}
+		if sdpWithErrorMsg(m.AtomicFlag) {
+			buf = append(buf, m.ErrorMsg...)
+			buf = appendUint32(buf, uint32(len(m.ErrorMsg)))
+		}
+		buf = append(buf, payload...)
+		buf = appendUint32(buf, uint32(len(payload)))
+		buf = appendUint32(buf, m.Sequence)
+		var nonce [8]byte
+		binary.BigEndian.PutUint64(nonce[:], m.Nonce)
+		buf = append(buf, nonce[:]...)
+		buf = append(buf, m.AtomicFlag)
+		buf = append(buf, m.TargetIdentity...)
+		buf = append(buf, m.TargetDomain...)
+		buf = appendUint32(buf, uint32(len(m.TargetDomain)))
+		buf = append(buf, m.MessageId...)
+		buf = appendUint32(buf, SDP_VERSION_MAGIC<<24|m.Version)
+	default:
+		return nil, fmt.Errorf("unsupported sdp version %d", m.Version)
+	}
+	return buf, nil
+}
+
+func printable(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	// 链码oraclelogic.SDPMessage{v1, "dest.com", sha256("bizcc"), 3, "x"*32+"yz"}.Encode()的结果
+	testSDPV1Hex = "797a000000000000000000000000000000000000000000000000000000000000" +
+		"7878787878787878787878787878787878787878787878787878787878787878" +
+		"0000000000000000000000000000000000000000000000000000000000000022" +
+		"00000003" +
+		"eb09b3c59f85ecf36b441b91f25813e9618460768d989c4185a32848b106544e" +
+		"646573742e636f6d000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000008"
+)
+
+func sum(s string) HexBytes {
+	h := sha256.Sum256([]byte(s))
+	return h[:]
+}
+
+func be32(v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return b[:]
+}
+
+func TestSDPV1(t *testing.T) {
+	raw, _ := hex.DecodeString(testSDPV1Hex)
+	m, err := DecodeSDPMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Repeat("x", 32) + "yz"
+	expected := &SDPMessage{Version: SDP_VERSION_1, TargetDomain: "dest.com", TargetIdentity: sum("bizcc"), Sequence: 3, Payload: HexBytes(payload), PayloadText: payload}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected sdp v1 message: %+v", m)
+	}
+	if encoded, err := m.Encode(); err != nil || hex.EncodeToString(encoded) != testSDPV1Hex {
+		t.Fatalf("unexpected sdp v1 encoding: %x %v", encoded, err)
+	}
+}
+
+func TestSDPV2(t *testing.T) {
+	// 与链码TestSDPCodec的逐字段拼接一致
+	m := &SDPMessage{
+		Version:        SDP_VERSION_2,
+		TargetDomain:   "fabric.test",
+		TargetIdentity: sum("bizcc"),
+		Sequence:       7,
+		PayloadText:    "hello",
+		MessageId:      sum("hello"),
+		AtomicFlag:     SDP_ATOMIC_ACK_ERROR,
+		Nonce:          42,
+		ErrorMsg:       "revert",
+	}
+	expected := bytes.Join([][]byte{
+		[]byte("revert"), be32(6),
+		[]byte("hello"), be32(5),
+		be32(7),
+		{0, 0, 0, 0, 0, 0, 0, 42},
+		{SDP_ATOMIC_ACK_ERROR},
+		m.TargetIdentity,
+		[]byte("fabric.test"), be32(11),
+		m.MessageId,
+		{0xff, 0, 0, 2},
+	}, nil)
+	raw, err := m.Encode()
+	if err != nil || !bytes.Equal(raw, expected) {
+		t.Fatalf("unexpected sdp v2 encoding: %x %v", raw, err)
+	}
+	decoded, err := DecodeSDPMessage(raw)
+	m.Payload = HexBytes("hello")
+	if err != nil || !reflect.DeepEqual(decoded, m) {
+		t.Fatalf("unexpected sdp v2 message: %+v %v", decoded, err)
+	}
+
+	// 成功回执不带错误信息
+	m.AtomicFlag, m.ErrorMsg = SDP_ATOMIC_ACK_SUCCESS, ""
+	raw, _ = m.Encode()
+	if len(raw) != sdpV2FixedSize+len("fabric.test")+len("hello") {
+		t.Fatalf("unexpected sdp v2 size %d", len(raw))
+	}
+	if decoded, err := DecodeSDPMessage(raw); err != nil || !reflect.DeepEqual(decoded, m) {
+		t.Fatalf("unexpected sdp v2 message: %+v %v", decoded, err)
+	}
+}
+
+func TestSDPMalformed(t *testing.T) {
+	v1, _ := hex.DecodeString(testSDPV1Hex)
+	v2, _ := (&SDPMessage{Version: SDP_VERSION_2, TargetIdentity: sum("bizcc"), MessageId: sum("id"), Payload: HexBytes("hello")}).Encode()
+	withByte := func(raw []byte, i int, b byte) []byte {
+		raw = append([]byte{}, raw...)
+		raw[i] = b
+		return raw
+	}
+	for _, bad := range [][]byte{
+		nil,
+		v1[1:],
+		v1[len(v1)-40:],
+		withByte(v1, len(v1)-32, 1),
+		withByte(v1, len(v1)-1, 0xff),
+		v2[1:],
+		v2[len(v2)-40:],
+		withByte(v2, len(v2)-1, 3),
+		withByte(v2, len(v2)-73, 9),
+	} {
+		if m, err := DecodeSDPMessage(bad); err == nil {
+			t.Fatalf("malformed sdp message %x decoded: %+v", bad, m)
+		}
+	}
+	if _, err := (&SDPMessage{Version: SDP_VERSION_2, TargetIdentity: sum("bizcc")}).Encode(); err == nil {
+		t.Fatal("sdp v2 message without message id encoded")
+	}
+	if _, err := (&SDPMessage{Version: SDP_VERSION_1}).Encode(); err == nil {
+		t.Fatal("sdp message without target identity encoded")
+	}
+}
+
+func TestSDPExtension(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	v1, _ := hex.DecodeString(testSDPV1Hex)
+	m, _ := DecodeSDPMessage(v1)
+	m.TraceParent = traceParent
+	raw, err := m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与链码tlv.Marshal(&SDPExtension{TraceParent})的结果一致
+	ext := bytes.Join([][]byte{{0, 0, 61, 0, 0, 0}, {0, 0, 55, 0, 0, 0}, []byte(traceParent)}, nil)
+	if expected := bytes.Join([][]byte{v1, ext, be32(uint32(len(ext))), {0xff, 0xff, 0xff, 0xff}}, nil); !bytes.Equal(raw, expected) {
+		t.Fatalf("unexpected extended sdp encoding: %x", raw)
+	}
+	if decoded, err := DecodeSDPMessage(raw); err != nil || !reflect.DeepEqual(decoded, m) {
+		t.Fatalf("unexpected extended sdp message: %+v %v", decoded, err)
+	}
+	for _, bad := range [][]byte{
+		raw[len(raw)-8:],
+		append(append(append([]byte{}, raw...), ext...), raw[len(raw)-8:]...),
+		append(append([]byte{}, v1...), 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff),
+	} {
+		if _, err := DecodeSDPMessage(bad); err == nil {
+			t.Fatalf("malformed extended sdp message %x decoded", bad)
+		}
+	}
+}
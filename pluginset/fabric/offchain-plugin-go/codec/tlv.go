@@ -0,0 +1,81 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TLV报文，与链码tlv包的格式一致，整数均为小端序：
+//
+//	packet: version(2) || length(4) || item...
+//	item:   tag(2) || length(4) || value
+//	数组:   (length(4) || value)...
+//
+// 不知道报文结构时按字段展开：value本身是完整的packet时展开为packet，是元素均为packet的数组时展开为数组。
+
+type TLVPacket struct {
+	Version uint16     `json:"version"`
+	Items   []*TLVItem `json:"items"`
+}
+
+type TLVItem struct {
+	Tag    uint16       `json:"tag"`
+	Length int          `json:"length"`
+	Value  HexBytes     `json:"value,omitempty"`
+	Packet *TLVPacket   `json:"packet,omitempty"`
+	Array  []*TLVPacket `json:"array,omitempty"`
+}
+
+func DecodeTLVPacket(raw []byte) (*TLVPacket, error) {
+	if len(raw) < 6 {
+		return nil, fmt.Errorf("tlv packet too short: %d bytes", len(raw))
+	}
+	if l := uint64(binary.LittleEndian.Uint32(raw[2:])); l != uint64(len(raw)-6) {
+		return nil, fmt.Errorf("tlv packet length %d mismatches %d", l, len(raw)-6)
+	}
+	p := &TLVPacket{Version: binary.LittleEndian.Uint16(raw)}
+	for offset := 6; offset < len(raw); {
+		if len(raw)-offset < 6 {
+			return nil, fmt.Errorf("truncated tlv item at %d", offset)
+		}
+		item := &TLVItem{Tag: binary.LittleEndian.Uint16(raw[offset:])}
+		l := uint64(binary.LittleEndian.Uint32(raw[offset+2:]))
+		offset += 6
+		if l > uint64(len(raw)-offset) {
+			return nil, fmt.Errorf("tlv item %d length %d exceeds packet", item.Tag, l)
+		}
+		item.Length = int(l)
+		value := raw[offset : offset+item.Length]
+		offset += item.Length
+		if packet, err := DecodeTLVPacket(value); err == nil {
+			item.Packet = packet
+		} else if array, ok := decodePacketArray(value); ok {
+			item.Array = array
+		} else {
+			item.Value = append(HexBytes{}, value...)
+		}
+		p.Items = append(p.Items, item)
+	}
+	return p, nil
+}
+
+func decodePacketArray(raw []byte) ([]*TLVPacket, bool) {
+	var array []*TLVPacket
+	for offset := 0; offset < len(raw); {
+		if len(raw)-offset < 4 {
+			return nil, false
+		}
+		l := uint64(binary.LittleEndian.Uint32(raw[offset:]))
+		offset += 4
+		if l > uint64(len(raw)-offset) {
+			return nil, false
+		}
+		packet, err := DecodeTLVPacket(raw[offset : offset+int(l)])
+		if err != nil {
+			return nil, false
+		}
+		array = append(array, packet)
+		offset += int(l)
+	}
+	return array, len(array) > 0
+}
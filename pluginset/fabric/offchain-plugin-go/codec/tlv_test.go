@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+// 与committee包的测试背书一致
+const testEndorsementHex = "00005f000000000009000000636f6d6d6974746565010008000000030000000000000002003c000000170000000000110000000000020000006e310100030000000102031d0000000000170000000000020000006e32010002000000040502000100000002"
+
+func TestEndorsement(t *testing.T) {
+	raw, _ := hex.DecodeString(testEndorsementHex)
+	e, err := DecodeEndorsement(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(e)
+	if string(out) != `{"committeeId":"committee","epoch":3,"signatures":[{"nodeId":"n1","signature":"010203","signAlgo":0},{"nodeId":"n2","signature":"0405","signAlgo":2}]}` {
+		t.Fatalf("unexpected endorsement: %s", out)
+	}
+	var parsed Endorsement
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if encoded, err := parsed.Encode(); err != nil || hex.EncodeToString(encoded) != testEndorsementHex {
+		t.Fatalf("unexpected endorsement encoding: %x %v", encoded, err)
+	}
+}
+
+func TestTLVPacket(t *testing.T) {
+	raw, _ := hex.DecodeString(testEndorsementHex)
+	p, err := DecodeTLVPacket(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Items) != 3 || string(p.Items[0].Value) != "committee" || p.Items[1].Length != 8 {
+		t.Fatalf("unexpected tlv packet: %+v", p)
+	}
+	// 签名数组展开为packet
+	sigs := p.Items[2].Array
+	if len(sigs) != 2 || string(sigs[0].Items[0].Value) != "n1" || hex.EncodeToString(sigs[1].Items[1].Value) != "0405" {
+		t.Fatalf("unexpected signatures: %+v", p.Items[2])
+	}
+	for _, bad := range []string{"", "0000", "000001000000", "0000050000000000010000", "000006000000000002000000"} {
+		b, _ := hex.DecodeString(bad)
+		if _, err := DecodeTLVPacket(b); err == nil {
+			t.Fatalf("malformed tlv packet %s decoded", bad)
+		}
+	}
+}
+
+func TestDecodeHex(t *testing.T) {
+	if b, err := DecodeHex(" 0x01 02\n0a "); err != nil || hex.EncodeToString(b) != "01020a" {
+		t.Fatalf("unexpected bytes %x %v", b, err)
+	}
+	if _, err := DecodeHex("0xzz"); err == nil {
+		t.Fatal("invalid hex decoded")
+	}
+}
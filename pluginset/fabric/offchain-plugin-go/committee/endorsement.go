@@ -0,0 +1,182 @@
+package committee
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// PTC委员会背书
+// 与跨链链码ptc_committee.go一致：节点对TLV编码的EndorseBody签名，绑定来源域名、委员会id、轮次和AM报文的sha256，
+// 达到门限的节点签名聚合为TLV编码的Endorsement，中继以hex作为recvPTCMessage的第三个参数提交。
+
+// 签名算法字节，与链码sigverify包一致
+const (
+	// SHA-256加ECDSA P-256，签名为ASN.1编码
+	SIGN_ALGO_DEFAULT = uint8(0)
+	// SM3加SM2(默认用户标识)，签名为ASN.1编码
+	SIGN_ALGO_SM3_WITH_SM2 = uint8(2)
+)
+
+// 委员会成员，与链码setPTCCommittee的节点列表一致
+type CommitteeNode struct {
+	NodeId string `json:"nodeId"`
+	// PEM格式的公钥
+	PublicKey string `json:"publicKey"`
+}
+
+// 来源域名的委员会，与链码queryPTCCommittee的返回一致
+type Committee struct {
+	Domain      string          `json:"domain"`
+	CommitteeId string          `json:"committeeId"`
+	Epoch       uint64          `json:"epoch"`
+	Threshold   int             `json:"threshold"`
+	Nodes       []CommitteeNode `json:"nodes"`
+}
+
+type NodeSignature struct {
+	NodeId    string
+	Signature []byte
+	SignAlgo  uint8
+}
+
+type Endorsement struct {
+	CommitteeId string
+	Epoch       uint64
+	Signatures  []*NodeSignature
+}
+
+// 节点签名的内容
+func (c *Committee) EndorseBody(pkg []byte) []byte {
+	amHash := sha256.Sum256(pkg)
+	return encodePacket([]tlvItem{
+		{0, []byte(c.Domain)},
+		{1, []byte(c.CommitteeId)},
+		{2, uint64Value(c.Epoch)},
+		{3, amHash[:]},
+	})
+}
+
+func (c *Committee) node(nodeID string) *CommitteeNode {
+	for i := range c.Nodes {
+		if c.Nodes[i].NodeId == nodeID {
+			return &c.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (c *Committee) Validate() error {
+	if c.Domain == "" || c.CommitteeId == "" {
+		return fmt.Errorf("empty domain or committee id")
+	}
+	if c.Threshold <= 0 || c.Threshold > len(c.Nodes) {
+		return fmt.Errorf("invalid threshold %d of %d nodes", c.Threshold, len(c.Nodes))
+	}
+	seen := make(map[string]bool)
+	for _, node := range c.Nodes {
+		if node.NodeId == "" || seen[node.NodeId] {
+			return fmt.Errorf("invalid or duplicated node id: %s", node.NodeId)
+		}
+		seen[node.NodeId] = true
+	}
+	return nil
+}
+
+// 聚合节点对pkg的签名
+// 重复的签名只保留一个，不在委员会中或验签失败的签名被忽略，签名按委员会的节点顺序排列。
+// 只能验证ECDSA P-256和SM2节点的签名，其他类型的节点不计入门限。
+// 有效签名不足门限时返回错误。
+func (c *Committee) Aggregate(pkg []byte, sigs []*NodeSignature) (*Endorsement, error) {
+	body := c.EndorseBody(pkg)
+	valid := make(map[string]*NodeSignature)
+	var rejected []string
+	for _, sig := range sigs {
+		if sig == nil || valid[sig.NodeId] != nil {
+			continue
+		}
+		node := c.node(sig.NodeId)
+		if node == nil {
+			rejected = append(rejected, fmt.Sprintf("node %s is not in committee", sig.NodeId))
+			continue
+		}
+		if err := VerifySignature(sig.SignAlgo, node.PublicKey, body, sig.Signature); err != nil {
+			rejected = append(rejected, fmt.Sprintf("node %s: %v", sig.NodeId, err))
+			continue
+		}
+		valid[sig.NodeId] = sig
+	}
+	if len(valid) < c.Threshold {
+		return nil, fmt.Errorf("endorsed by %d nodes, %d required, rejected: %v", len(valid), c.Threshold, rejected)
+	}
+	endorsement := &Endorsement{CommitteeId: c.CommitteeId, Epoch: c.Epoch}
+	for _, node := range c.Nodes {
+		if sig, ok := valid[node.NodeId]; ok {
+			endorsement.Signatures = append(endorsement.Signatures, sig)
+		}
+	}
+	return endorsement, nil
+}
+
+func (s *NodeSignature) Encode() []byte {
+	items := []tlvItem{{0, []byte(s.NodeId)}, {1, s.Signature}}
+	// 链码中SignAlgo带omitempty
+	if s.SignAlgo != SIGN_ALGO_DEFAULT {
+		items = append(items, tlvItem{2, []byte{s.SignAlgo}})
+	}
+	return encodePacket(items)
+}
+
+func DecodeNodeSignature(raw []byte) (*NodeSignature, error) {
+	items, err := decodePacket(raw)
+	if err != nil {
+		return nil, err
+	}
+	sig := &NodeSignature{NodeId: string(items[0]), Signature: append([]byte(nil), items[1]...)}
+	if algo, ok := items[2]; ok {
+		if len(algo) != 1 {
+			return nil, fmt.Errorf("invalid sign algorithm of node %s", sig.NodeId)
+		}
+		sig.SignAlgo = algo[0]
+	}
+	return sig, nil
+}
+
+// TLV编码，与链码的PTCEndorsement一致
+func (e *Endorsement) Encode() []byte {
+	items := []tlvItem{{0, []byte(e.CommitteeId)}, {1, uint64Value(e.Epoch)}}
+	if e.Signatures != nil {
+		sigs := make([][]byte, len(e.Signatures))
+		for i, sig := range e.Signatures {
+			sigs[i] = sig.Encode()
+		}
+		items = append(items, tlvItem{2, encodeLV(sigs)})
+	}
+	return encodePacket(items)
+}
+
+func DecodeEndorsement(raw []byte) (*Endorsement, error) {
+	items, err := decodePacket(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endorsement: %v", err)
+	}
+	e := &Endorsement{CommitteeId: string(items[0])}
+	if epoch, ok := items[1]; ok {
+		if e.Epoch, err = decodeUint64(epoch); err != nil {
+			return nil, fmt.Errorf("invalid endorsement epoch: %v", err)
+		}
+	}
+	if value, ok := items[2]; ok {
+		sigs, err := decodeLV(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endorsement signatures: %v", err)
+		}
+		for i, raw := range sigs {
+			sig, err := DecodeNodeSignature(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid signature %d: %v", i, err)
+			}
+			e.Signatures = append(e.Signatures, sig)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,131 @@
+package committee
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 以下编码由链码tlv包生成
+const (
+	// PTCEndorseBody{"src.com", "committee", 3, sha256("am")}
+	testBodyHex = "0000500000000000070000007372632e636f6d010009000000636f6d6d69747465650200080000000300000000000000030020000000ab6db599234d2636659cba1aa191bd014c3867d5cfade98ff694785c20c28fc6"
+	// PTCEndorsement{"committee", 3, [{"n1", 010203, 0}, {"n2", 0405, 2}]}
+	testEndorsementHex = "00005f000000000009000000636f6d6d6974746565010008000000030000000000000002003c000000170000000000110000000000020000006e310100030000000102031d0000000000170000000000020000006e32010002000000040502000100000002"
+)
+
+func TestEndorsementEncoding(t *testing.T) {
+	c := &Committee{Domain: "src.com", CommitteeId: "committee", Epoch: 3}
+	if body := hex.EncodeToString(c.EndorseBody([]byte("am"))); body != testBodyHex {
+		t.Fatalf("unexpected body %s", body)
+	}
+	e := &Endorsement{CommitteeId: "committee", Epoch: 3, Signatures: []*NodeSignature{
+		{NodeId: "n1", Signature: []byte{1, 2, 3}},
+		{NodeId: "n2", Signature: []byte{4, 5}, SignAlgo: SIGN_ALGO_SM3_WITH_SM2},
+	}}
+	if raw := hex.EncodeToString(e.Encode()); raw != testEndorsementHex {
+		t.Fatalf("unexpected endorsement %s", raw)
+	}
+	raw, _ := hex.DecodeString(testEndorsementHex)
+	decoded, err := DecodeEndorsement(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.CommitteeId != "committee" || decoded.Epoch != 3 || len(decoded.Signatures) != 2 ||
+		decoded.Signatures[1].NodeId != "n2" || decoded.Signatures[1].SignAlgo != SIGN_ALGO_SM3_WITH_SM2 || decoded.Signatures[0].SignAlgo != SIGN_ALGO_DEFAULT {
+		t.Fatalf("unexpected endorsement: %+v", decoded)
+	}
+	if _, err := DecodeEndorsement(raw[:len(raw)-1]); err == nil {
+		t.Fatal("truncated endorsement accepted")
+	}
+}
+
+func testCommittee(t *testing.T, algos ...uint8) (*Committee, []signer.Signer) {
+	c := &Committee{Domain: "src.com", CommitteeId: "committee", Epoch: 1, Threshold: 2}
+	var keys []signer.Signer
+	for i, signAlgo := range algos {
+		algo, err := AlgorithmOf(signAlgo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := signer.Generate(algo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := signer.MarshalPublicKeyPEM(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		c.Nodes = append(c.Nodes, CommitteeNode{NodeId: "node" + string(rune('0'+i)), PublicKey: pub})
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return c, keys
+}
+
+func TestAggregate(t *testing.T) {
+	c, keys := testCommittee(t, SIGN_ALGO_DEFAULT, SIGN_ALGO_SM3_WITH_SM2, SIGN_ALGO_DEFAULT)
+	pkg := []byte("am package")
+	sign := func(i int, pkg []byte) *NodeSignature {
+		sig, err := signer.Sign(keys[i], c.EndorseBody(pkg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signAlgo, _ := SignAlgoOf(keys[i].Public())
+		return &NodeSignature{NodeId: c.Nodes[i].NodeId, Signature: sig, SignAlgo: signAlgo}
+	}
+
+	// 重复、非成员和其他报文的签名不计入
+	other := sign(0, []byte("other package"))
+	other.NodeId = "node2"
+	stranger := sign(0, pkg)
+	stranger.NodeId = "node9"
+	_, err := c.Aggregate(pkg, []*NodeSignature{sign(0, pkg), sign(0, pkg), other, stranger, nil})
+	if err == nil || !strings.Contains(err.Error(), "endorsed by 1 nodes, 2 required") || !strings.Contains(err.Error(), "node9 is not in committee") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 算法与公钥不一致
+	mismatched := sign(1, pkg)
+	mismatched.SignAlgo = SIGN_ALGO_DEFAULT
+	if _, err := c.Aggregate(pkg, []*NodeSignature{sign(0, pkg), mismatched}); err == nil || !strings.Contains(err.Error(), "does not match sign algorithm") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e, err := c.Aggregate(pkg, []*NodeSignature{sign(2, pkg), sign(1, pkg), sign(0, pkg)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.CommitteeId != "committee" || e.Epoch != 1 || len(e.Signatures) != 3 || e.Signatures[0].NodeId != "node0" || e.Signatures[1].SignAlgo != SIGN_ALGO_SM3_WITH_SM2 {
+		t.Fatalf("unexpected endorsement: %+v", e)
+	}
+	decoded, err := DecodeEndorsement(e.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sig := range decoded.Signatures {
+		if err := VerifySignature(sig.SignAlgo, c.Nodes[i].PublicKey, c.EndorseBody(pkg), sig.Signature); err != nil {
+			t.Fatalf("node %d: %v", i, err)
+		}
+	}
+
+	// 轮换后旧轮次的签名失效
+	rotated := *c
+	rotated.Epoch = 2
+	if _, err := rotated.Aggregate(pkg, []*NodeSignature{sign(0, pkg), sign(1, pkg)}); err == nil {
+		t.Fatal("signatures of old epoch accepted")
+	}
+
+	for _, invalid := range []Committee{
+		{Domain: "src.com", CommitteeId: "committee", Threshold: 4, Nodes: c.Nodes},
+		{Domain: "src.com", CommitteeId: "committee", Threshold: 1, Nodes: []CommitteeNode{c.Nodes[0], c.Nodes[0]}},
+		{CommitteeId: "committee", Threshold: 1, Nodes: c.Nodes},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Fatalf("invalid committee accepted: %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package committee
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/hcdvs"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// gRPC协议
+// CommitteeService以proto/committee.proto中的服务提供，调用失败以UNKNOWN状态码传递，客户端还原为原始的错误信息。
+
+// 在gRPC服务中注册CommitteeService
+func RegisterGRPCServer(s *grpc.Server, impl CommitteeService) {
+	pb.RegisterCommitteeServiceServer(s, &grpcServer{impl: impl})
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func signatureToPB(s *NodeSignature) *pb.NodeSignature {
+	if s == nil {
+		return nil
+	}
+	return &pb.NodeSignature{NodeId: s.NodeId, Signature: s.Signature, SignAlgo: uint32(s.SignAlgo)}
+}
+
+func signatureFromPB(s *pb.NodeSignature) *NodeSignature {
+	if s == nil || s.SignAlgo > 0xff {
+		return nil
+	}
+	return &NodeSignature{NodeId: s.NodeId, Signature: s.Signature, SignAlgo: uint8(s.SignAlgo)}
+}
+
+type grpcServer struct {
+	impl CommitteeService
+}
+
+func (s *grpcServer) Endorse(ctx context.Context, req *pb.EndorseRequest) (*pb.EndorseResponse, error) {
+	sig, err := s.impl.Endorse(bbc.MessageFromPB(req.Message), hcdvs.StateFromPB(req.Anchor))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.EndorseResponse{Signature: signatureToPB(sig)}, nil
+}
+
+func (s *grpcServer) Aggregate(ctx context.Context, req *pb.AggregateRequest) (*pb.AggregateResponse, error) {
+	sigs := make([]*NodeSignature, 0, len(req.Signatures))
+	for _, sig := range req.Signatures {
+		sigs = append(sigs, signatureFromPB(sig))
+	}
+	endorsement, err := s.impl.Aggregate(req.Package, sigs)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AggregateResponse{Endorsement: endorsement}, nil
+}
+
+// GRPCClient 中继或其他节点侧的CommitteeService实现
+type GRPCClient struct {
+	client pb.CommitteeServiceClient
+}
+
+func NewGRPCClient(conn *grpc.ClientConn) *GRPCClient {
+	return &GRPCClient{client: pb.NewCommitteeServiceClient(conn)}
+}
+
+var _ CommitteeService = (*GRPCClient)(nil)
+
+// 还原服务返回的错误信息
+func fromStatus(err error) error {
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+		return errors.New(s.Message())
+	}
+	return err
+}
+
+func (c *GRPCClient) Endorse(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*NodeSignature, error) {
+	resp, err := c.client.Endorse(context.Background(), &pb.EndorseRequest{Message: bbc.MessageToPB(msg), Anchor: hcdvs.StateToPB(anchor)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	if sig := signatureFromPB(resp.Signature); sig != nil {
+		return sig, nil
+	}
+	return nil, errors.New("no signature in response")
+}
+
+func (c *GRPCClient) Aggregate(pkg []byte, sigs []*NodeSignature) ([]byte, error) {
+	req := &pb.AggregateRequest{Package: pkg}
+	for _, sig := range sigs {
+		req.Signatures = append(req.Signatures, signatureToPB(sig))
+	}
+	resp, err := c.client.Aggregate(context.Background(), req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp.Endorsement, nil
+}
+
+// 向各节点收集签名后聚合，节点签名失败时跳过，由聚合检查门限
+func CollectEndorsement(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState, aggregator CommitteeService, nodes ...CommitteeService) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("no message")
+	}
+	var sigs []*NodeSignature
+	for _, node := range nodes {
+		if sig, err := node.Endorse(msg, anchor); err == nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	return aggregator.Aggregate(msg.Message, sigs)
+}
@@ -0,0 +1,51 @@
+package committee
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 节点密钥
+// 节点通过signer.Signer签名，私钥可以在文件、HSM或KMS中。签名算法由公钥决定：
+// P-256为SIGN_ALGO_DEFAULT，SM2曲线为SIGN_ALGO_SM3_WITH_SM2。公钥为PEM格式的SubjectPublicKeyInfo，
+// 可以直接登记到链码的委员会中。
+
+// 公钥对应的签名算法
+func SignAlgoOf(pub crypto.PublicKey) (uint8, error) {
+	algo, err := signer.AlgorithmOf(pub)
+	if err != nil {
+		return 0, err
+	}
+	switch algo {
+	case signer.ECDSA_P256_SHA256:
+		return SIGN_ALGO_DEFAULT, nil
+	case signer.SM2_SM3:
+		return SIGN_ALGO_SM3_WITH_SM2, nil
+	}
+	return 0, fmt.Errorf("unsupported algorithm %s", algo)
+}
+
+// 签名算法对应的密钥算法
+func AlgorithmOf(signAlgo uint8) (signer.Algorithm, error) {
+	switch signAlgo {
+	case SIGN_ALGO_DEFAULT:
+		return signer.ECDSA_P256_SHA256, nil
+	case SIGN_ALGO_SM3_WITH_SM2:
+		return signer.SM2_SM3, nil
+	}
+	return "", fmt.Errorf("unsupported sign algorithm %d", signAlgo)
+}
+
+// 按算法校验对msg的签名，与链码的验签一致
+func VerifySignature(signAlgo uint8, pemKey string, msg, sig []byte) error {
+	pub, err := signer.ParsePublicKeyPEM(pemKey)
+	if err != nil {
+		return err
+	}
+	if algo, err := SignAlgoOf(pub); err != nil || algo != signAlgo {
+		return fmt.Errorf("public key %T does not match sign algorithm %d", pub, signAlgo)
+	}
+	return signer.Verify(pub, msg, sig)
+}
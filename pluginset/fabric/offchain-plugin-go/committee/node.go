@@ -0,0 +1,107 @@
+package committee
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/hcdvs"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 委员会节点的签名服务
+// 节点收到跨链消息后先用来源链的HCDVSService验证，验证通过才用节点密钥签名，签名绑定节点所在委员会的当前轮次。
+// 中继从各节点收集签名，由任一节点聚合为链码接受的背书。
+
+type CommitteeService interface {
+	// 验证来源链的AUTH_MSG消息并签名，anchor为消息所在区块适用的共识状态
+	Endorse(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*NodeSignature, error)
+	// 聚合各节点对AM报文的签名，返回TLV编码的背书
+	Aggregate(pkg []byte, sigs []*NodeSignature) ([]byte, error)
+}
+
+type Node struct {
+	nodeID   string
+	key      signer.Signer
+	signAlgo uint8
+	verifier hcdvs.HCDVSService
+
+	mu        sync.RWMutex
+	committee *Committee
+}
+
+var _ CommitteeService = (*Node)(nil)
+
+// 节点须在委员会中，登记的公钥与密钥一致
+func NewNode(nodeID string, committee *Committee, key signer.Signer, verifier hcdvs.HCDVSService) (*Node, error) {
+	signAlgo, err := SignAlgoOf(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{nodeID: nodeID, key: key, signAlgo: signAlgo, verifier: verifier}
+	if err := n.UpdateCommittee(committee); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// 委员会轮换后更新，旧轮次的签名随之失效
+func (n *Node) UpdateCommittee(committee *Committee) error {
+	if err := committee.Validate(); err != nil {
+		return fmt.Errorf("invalid committee: %v", err)
+	}
+	node := committee.node(n.nodeID)
+	if node == nil {
+		return fmt.Errorf("node %s is not in committee %s", n.nodeID, committee.CommitteeId)
+	}
+	// 比较解析后的公钥，PEM的换行等格式可能不同
+	registered, err := signer.ParsePublicKeyPEM(node.PublicKey)
+	if err != nil {
+		return fmt.Errorf("node %s: %v", n.nodeID, err)
+	}
+	if !signer.SamePublicKey(registered, n.key.Public()) {
+		return fmt.Errorf("public key of node %s mismatches the registered one", n.nodeID)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.committee = committee
+	return nil
+}
+
+func (n *Node) Committee() *Committee {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.committee
+}
+
+func (n *Node) SignAlgo() uint8 {
+	return n.signAlgo
+}
+
+func (n *Node) Endorse(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*NodeSignature, error) {
+	if msg == nil || msg.Type != bbc.AUTH_MSG {
+		return nil, fmt.Errorf("only AUTH_MSG can be endorsed")
+	}
+	result, err := n.verifier.VerifyCrossChainMessage(msg, anchor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify message: %v", err)
+	}
+	if !result.Verified {
+		return nil, fmt.Errorf("message verify failed: %s", result.ErrorMsg)
+	}
+	committee := n.Committee()
+	sig, err := signer.Sign(n.key, committee.EndorseBody(msg.Message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+	return &NodeSignature{NodeId: n.nodeID, Signature: sig, SignAlgo: n.signAlgo}, nil
+}
+
+func (n *Node) Aggregate(pkg []byte, sigs []*NodeSignature) ([]byte, error) {
+	endorsement, err := n.Committee().Aggregate(pkg, sigs)
+	if err != nil {
+		return nil, err
+	}
+	return endorsement.Encode(), nil
+}
@@ -0,0 +1,112 @@
+package committee
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/hcdvs"
+)
+
+// 只接受高度不小于锚定状态的消息
+type fakeVerifier struct{}
+
+func (v *fakeVerifier) VerifyAnchorConsensusState(anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	return hcdvs.ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) VerifyConsensusState(state, anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	return hcdvs.ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) VerifyCrossChainMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	if anchor == nil {
+		return nil, errors.New("no anchor")
+	}
+	if msg.ProvableData == nil || msg.ProvableData.Height < anchor.Height {
+		return hcdvs.ResultOf(errors.New("message before anchor")), nil
+	}
+	return hcdvs.ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) ParseMessageFromLedgerData(ledgerData []byte) ([]byte, error) {
+	return nil, errors.New("unsupported ledger data")
+}
+
+func serveNode(t *testing.T, node *Node) *GRPCClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	RegisterGRPCServer(s, node)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewGRPCClient(conn)
+}
+
+func TestNode(t *testing.T) {
+	c, keys := testCommittee(t, SIGN_ALGO_DEFAULT, SIGN_ALGO_SM3_WITH_SM2, SIGN_ALGO_DEFAULT)
+	var clients []CommitteeService
+	for i, key := range keys {
+		node, err := NewNode(c.Nodes[i].NodeId, c, key, &fakeVerifier{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, serveNode(t, node))
+	}
+	if _, err := NewNode("node0", c, keys[1], &fakeVerifier{}); err == nil || !strings.Contains(err.Error(), "mismatches") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewNode("node9", c, keys[0], &fakeVerifier{}); err == nil || !strings.Contains(err.Error(), "not in committee") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	anchor := &consensus.ConsensusState{Product: "fabric", Height: 5}
+	msg := &bbc.CrossChainMessage{Type: bbc.AUTH_MSG, Message: []byte("am package"), ProvableData: &bbc.ProvableLedgerData{Height: 8}}
+	sig, err := clients[1].Endorse(msg, anchor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.NodeId != "node1" || sig.SignAlgo != SIGN_ALGO_SM3_WITH_SM2 {
+		t.Fatalf("unexpected signature: %+v", sig)
+	}
+	if err := VerifySignature(sig.SignAlgo, c.Nodes[1].PublicKey, c.EndorseBody(msg.Message), sig.Signature); err != nil {
+		t.Fatal(err)
+	}
+
+	// 验证不通过或调用失败时不签名
+	stale := &bbc.CrossChainMessage{Type: bbc.AUTH_MSG, Message: []byte("am package"), ProvableData: &bbc.ProvableLedgerData{Height: 3}}
+	if _, err := clients[0].Endorse(stale, anchor); err == nil || err.Error() != "message verify failed: message before anchor" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := clients[0].Endorse(msg, nil); err == nil || !strings.Contains(err.Error(), "no anchor") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := clients[0].Endorse(&bbc.CrossChainMessage{Type: bbc.DEVELOPER_DESIGN, Message: []byte("am package")}, anchor); err == nil {
+		t.Fatal("non AUTH_MSG endorsed")
+	}
+
+	// 任一节点聚合
+	raw, err := CollectEndorsement(msg, anchor, clients[2], clients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := DecodeEndorsement(raw)
+	if err != nil || e.CommitteeId != "committee" || len(e.Signatures) != 3 {
+		t.Fatalf("unexpected endorsement: %+v %v", e, err)
+	}
+	if _, err := CollectEndorsement(stale, anchor, clients[2], clients...); err == nil || !strings.Contains(err.Error(), "endorsed by 0 nodes, 2 required") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package committee
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// AntChain Bridge TLV编码，与链码tlv包的格式一致，整数均为小端序：
+//
+//	packet: version(2) || length(4) || item...   length为全部item的字节数
+//	item:   tag(2) || length(4) || value
+//	数组:   (length(4) || value)...
+//
+// 只实现背书需要的部分，packet版本号为0。
+
+type tlvItem struct {
+	tag   uint16
+	value []byte
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func uint64Value(v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return b[:]
+}
+
+func encodePacket(items []tlvItem) []byte {
+	var body []byte
+	for _, item := range items {
+		body = appendUint16(body, item.tag)
+		body = appendUint32(body, uint32(len(item.value)))
+		body = append(body, item.value...)
+	}
+	buf := make([]byte, 0, 6+len(body))
+	buf = appendUint16(buf, 0)
+	buf = appendUint32(buf, uint32(len(body)))
+	return append(buf, body...)
+}
+
+// 解码packet，同一tag出现多次时取第一个
+func decodePacket(data []byte) (map[uint16][]byte, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("tlv packet too short: %d", len(data))
+	}
+	if l := uint64(binary.LittleEndian.Uint32(data[2:])); l != uint64(len(data)-6) {
+		return nil, fmt.Errorf("tlv packet length %d mismatches %d", l, len(data)-6)
+	}
+	items := make(map[uint16][]byte)
+	for offset := 6; offset < len(data); {
+		if len(data)-offset < 6 {
+			return nil, fmt.Errorf("truncated tlv item")
+		}
+		tag := binary.LittleEndian.Uint16(data[offset:])
+		l := uint64(binary.LittleEndian.Uint32(data[offset+2:]))
+		offset += 6
+		if l > uint64(len(data)-offset) {
+			return nil, fmt.Errorf("tlv item %d length %d exceeds packet", tag, l)
+		}
+		if _, ok := items[tag]; !ok {
+			items[tag] = data[offset : offset+int(l)]
+		}
+		offset += int(l)
+	}
+	return items, nil
+}
+
+func encodeLV(values [][]byte) []byte {
+	var buf []byte
+	for _, v := range values {
+		buf = appendUint32(buf, uint32(len(v)))
+		buf = append(buf, v...)
+	}
+	return buf
+}
+
+func decodeLV(data []byte) ([][]byte, error) {
+	var values [][]byte
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 4 {
+			return nil, fmt.Errorf("truncated tlv array")
+		}
+		l := uint64(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		if l > uint64(len(data)-offset) {
+			return nil, fmt.Errorf("tlv array element length %d exceeds value", l)
+		}
+		values = append(values, data[offset:offset+int(l)])
+		offset += int(l)
+	}
+	return values, nil
+}
+
+func decodeUint64(data []byte) (uint64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("expects 8 bytes, got %d", len(data))
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
@@ -0,0 +1,90 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// 共识状态的同步
+// 与AntChain Bridge的HCDVS模型一致：PTC在锚定高度链外确认一个共识状态，之后用已验证的状态验证下一个状态，
+// 再用对应的状态验证跨链消息。各链实现ConsensusStateSyncer，从链上读取锚定状态之后的共识状态变更并逐个验证，
+// PTC按顺序保存。实现通过Register按链的类型注册，PTC按配置的类型创建。
+
+type ConsensusState struct {
+	// 链的类型，与Register的product一致
+	Product string `json:"product"`
+	// 状态所在区块的高度
+	Height uint64 `json:"height"`
+	// 状态所在区块的hash
+	Hash []byte `json:"hash"`
+	// 共识节点的标识，对应antchain-bridge-commons ConsensusState的consensusNode
+	ConsensusNodes []string `json:"consensusNodes"`
+	// 链相关的状态数据，验证下一个状态和跨链消息时使用
+	StateData []byte `json:"stateData"`
+}
+
+func (s *ConsensusState) Encode() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func DecodeConsensusState(raw []byte) (*ConsensusState, error) {
+	var s ConsensusState
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid consensus state: %v", err)
+	}
+	return &s, nil
+}
+
+type ConsensusStateSyncer interface {
+	// 检查锚定状态的格式，锚定状态本身由PTC链外确认
+	VerifyAnchorConsensusState(anchor *ConsensusState) error
+	// 用已验证的anchor验证下一个状态
+	VerifyConsensusState(state, anchor *ConsensusState) error
+	// 读取anchor之后链上的共识状态变更，按高度返回已验证的状态，没有变更时返回空
+	SyncConsensusState(anchor *ConsensusState) ([]*ConsensusState, error)
+	Close() error
+}
+
+// 按链的配置创建syncer，配置由实现自行解析
+type Factory func(rawConf []byte, logger hclog.Logger) (ConsensusStateSyncer, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// 注册链的实现，一般在实现的包初始化时调用，重复注册时panic
+func Register(product string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := factories[product]; ok {
+		panic(fmt.Sprintf("consensus state syncer %s is already registered", product))
+	}
+	factories[product] = factory
+}
+
+// 已注册的链的类型
+func Products() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var products []string
+	for product := range factories {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	return products
+}
+
+func NewSyncer(product string, rawConf []byte, logger hclog.Logger) (ConsensusStateSyncer, error) {
+	mu.RLock()
+	factory := factories[product]
+	mu.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("no consensus state syncer for %s", product)
+	}
+	return factory(rawConf, logger)
+}
@@ -0,0 +1,64 @@
+package consensus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+type fakeSyncer struct {
+	rawConf []byte
+}
+
+func (s *fakeSyncer) VerifyAnchorConsensusState(anchor *ConsensusState) error { return nil }
+
+func (s *fakeSyncer) VerifyConsensusState(state, anchor *ConsensusState) error { return nil }
+
+func (s *fakeSyncer) SyncConsensusState(anchor *ConsensusState) ([]*ConsensusState, error) {
+	return []*ConsensusState{{Product: anchor.Product, Height: anchor.Height + 1}}, nil
+}
+
+func (s *fakeSyncer) Close() error { return nil }
+
+func TestRegister(t *testing.T) {
+	Register("fake", func(rawConf []byte, logger hclog.Logger) (ConsensusStateSyncer, error) {
+		return &fakeSyncer{rawConf: rawConf}, nil
+	})
+	if products := Products(); len(products) != 1 || products[0] != "fake" {
+		t.Fatalf("unexpected products: %v", products)
+	}
+	syncer, err := NewSyncer("fake", []byte("conf"), hclog.NewNullLogger())
+	if err != nil || string(syncer.(*fakeSyncer).rawConf) != "conf" {
+		t.Fatalf("unexpected syncer: %+v %v", syncer, err)
+	}
+	states, err := syncer.SyncConsensusState(&ConsensusState{Product: "fake", Height: 1})
+	if err != nil || len(states) != 1 || states[0].Height != 2 {
+		t.Fatalf("unexpected states: %+v %v", states, err)
+	}
+	if _, err := NewSyncer("unknown", nil, hclog.NewNullLogger()); err == nil || !strings.Contains(err.Error(), "no consensus state syncer") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicated registration should panic")
+		}
+	}()
+	Register("fake", nil)
+}
+
+func TestConsensusStateEncoding(t *testing.T) {
+	state := &ConsensusState{Product: "fabric", Height: 5, Hash: []byte("hash"), ConsensusNodes: []string{"OrdererMSP"}, StateData: []byte("data")}
+	raw, err := state.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeConsensusState(raw)
+	if err != nil || decoded.Height != 5 || string(decoded.Hash) != "hash" || decoded.ConsensusNodes[0] != "OrdererMSP" || string(decoded.StateData) != "data" {
+		t.Fatalf("unexpected state: %+v %v", decoded, err)
+	}
+	if _, err := DecodeConsensusState([]byte("garbage")); err == nil {
+		t.Fatal("garbage should be rejected")
+	}
+}
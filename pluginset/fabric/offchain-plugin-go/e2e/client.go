@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	mspclient "github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+)
+
+// 以User1调用业务链码，测试中发送和查询跨链消息
+type Client struct {
+	sdk       *fabsdk.FabricSDK
+	channel   *channel.Client
+	chaincode string
+	// 链码在交易中调用的其它链码，背书节点需同时满足它们的背书策略
+	callees []string
+}
+
+func (n *Network) Client(chaincode string, callees ...string) (*Client, error) {
+	sdk, err := fabsdk.New(config.FromFile(n.ProfilePath()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fabric sdk: %v", err)
+	}
+	c, err := func() (*Client, error) {
+		mspClient, err := mspclient.New(sdk.Context(), mspclient.WithOrg(ORG))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create msp client: %v", err)
+		}
+		user := n.User(RELAYER_USER)
+		cert, err := ioutil.ReadFile(user.CertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user cert: %v", err)
+		}
+		key, err := ioutil.ReadFile(user.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user key: %v", err)
+		}
+		identity, err := mspClient.CreateSigningIdentity(msp.WithCert(cert), msp.WithPrivateKey(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create signing identity: %v", err)
+		}
+		ch, err := channel.New(sdk.ChannelContext(CHANNEL, fabsdk.WithIdentity(identity)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create channel client of %s: %v", CHANNEL, err)
+		}
+		return &Client{sdk: sdk, channel: ch, chaincode: chaincode, callees: callees}, nil
+	}()
+	if err != nil {
+		sdk.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Close() {
+	c.sdk.Close()
+}
+
+func (c *Client) request(fcn string, args []string) channel.Request {
+	req := channel.Request{ChaincodeID: c.chaincode, Fcn: fcn}
+	for _, arg := range args {
+		req.Args = append(req.Args, []byte(arg))
+	}
+	for _, callee := range c.callees {
+		req.InvocationChain = append(req.InvocationChain, &fab.ChaincodeCall{ID: callee})
+	}
+	return req
+}
+
+// 提交交易并等待上链，返回交易id
+func (c *Client) Invoke(fcn string, args ...string) (string, error) {
+	resp, err := c.channel.Execute(c.request(fcn, args))
+	if err != nil {
+		return "", fmt.Errorf("failed to invoke %s.%s: %v", c.chaincode, fcn, err)
+	}
+	if resp.TxValidationCode != pb.TxValidationCode_VALID {
+		return "", fmt.Errorf("tx %s of %s.%s is %s", resp.TransactionID, c.chaincode, fcn, resp.TxValidationCode)
+	}
+	return string(resp.TransactionID), nil
+}
+
+func (c *Client) Query(fcn string, args ...string) ([]byte, error) {
+	resp, err := c.channel.Query(c.request(fcn, args))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s.%s: %v", c.chaincode, fcn, err)
+	}
+	return resp.Payload, nil
+}
@@ -0,0 +1,140 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/relayqueue"
+)
+
+// 两个Fabric网络之间经回环中继收发有序、无序消息。需要docker和compose插件，-short时跳过。
+// 首次运行需要拉取Fabric镜像和构建链码镜像，耗时较长：go test -timeout 30m ./e2e/...
+
+const (
+	DOMAIN_A = "chain-a.e2e"
+	DOMAIN_B = "chain-b.e2e"
+)
+
+type domain struct {
+	network *Network
+	service *fabric.FabricBBCService
+	bizcc   *Client
+}
+
+func startDomain(t *testing.T, network *Network, name string, logger hclog.Logger) *domain {
+	t.Cleanup(func() {
+		if os.Getenv("E2E_KEEP_NETWORK") != "" {
+			t.Logf("keep network %s in %s", network.Name, network.Dir)
+			return
+		}
+		if err := network.Down(); err != nil {
+			t.Errorf("failed to stop network %s: %v", network.Name, err)
+		}
+	})
+	if err := network.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Deploy(name); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := json.Marshal(network.Config(CROSS_CHAINCODE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := fabric.NewFabricBBCService(logger.Named(name))
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatalf("failed to start plugin of %s: %v", name, err)
+	}
+	t.Cleanup(func() { service.Shutdown() })
+	bizcc, err := network.Client(BIZ_CHAINCODE, CROSS_CHAINCODE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bizcc.Close)
+	return &domain{network: network, service: service, bizcc: bizcc}
+}
+
+// 中继直到有消息投递，要求全部投递成功
+func relayUntilDelivered(t *testing.T, r *Relayer) {
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		receipts, err := r.Relay()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, receipt := range receipts {
+			if !receipt.Successful {
+				t.Fatalf("failed to relay message: %+v", receipt)
+			}
+		}
+		if len(receipts) > 0 {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	t.Fatal("no cross chain message to relay")
+}
+
+func TestCrossChainMessages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("e2e test is skipped in short mode")
+	}
+	if err := DockerAvailable(); err != nil {
+		t.Skip(err)
+	}
+	logger := hclog.New(&hclog.LoggerOptions{Name: "e2e", Level: hclog.LevelFromString(os.Getenv("E2E_LOG_LEVEL"))})
+	a := startDomain(t, NewNetwork("e2e-a", 17050, 17051, "../../onchain-plugin", logger), DOMAIN_A, logger)
+	b := startDomain(t, NewNetwork("e2e-b", 27050, 27051, "../../onchain-plugin", logger), DOMAIN_B, logger)
+
+	receiver := sha256.Sum256([]byte(BIZ_CHAINCODE))
+	for _, c := range []struct {
+		name, send, query string
+	}{
+		{"ordered", "testSendMessage", "getLastMsg"},
+		{"unordered", "testSendUnorderedMessage", "getLastUnorderedMsg"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			for _, dir := range []struct {
+				from, to             *domain
+				fromDomain, toDomain string
+			}{
+				{a, b, DOMAIN_A, DOMAIN_B},
+				{b, a, DOMAIN_B, DOMAIN_A},
+			} {
+				queue, err := relayqueue.Open(filepath.Join(t.TempDir(), "queue.db"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer queue.Close()
+				r, err := NewRelayer(dir.from.service, dir.fromDomain, dir.to.service, queue, logger)
+				if err != nil {
+					t.Fatal(err)
+				}
+				msg := c.name + " message from " + dir.fromDomain
+				if _, err := dir.from.bizcc.Invoke(c.send, CROSS_CHAINCODE, dir.toDomain, hex.EncodeToString(receiver[:]), msg, "1"); err != nil {
+					t.Fatal(err)
+				}
+				relayUntilDelivered(t, r)
+
+				got, err := dir.to.bizcc.Query(c.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(string(got), dir.fromDomain+"::") || !strings.HasSuffix(string(got), ":"+msg) {
+					t.Fatalf("unexpected message on %s: %s", dir.toDomain, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package e2e
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 本地端到端测试的Fabric网络
+// 每个网络由docker compose启动一个orderer和一个peer，network目录为compose文件和cryptogen、configtxgen的配置，
+// 启动时复制到临时目录，证书和创世区块也生成在该目录。两个网络的主机名相同，以compose项目名和宿主端口区分，
+// connection profile通过entityMatchers把服务发现返回的地址映射到宿主端口。
+
+//go:embed network
+var assets embed.FS
+
+const (
+	CHANNEL = "mychannel"
+	ORG     = "Org1"
+
+	CROSS_CHAINCODE = "cross"
+	BIZ_CHAINCODE   = "bizcc"
+	// 两个链码使用同一个版本
+	CHAINCODE_VERSION = "1.0"
+
+	ORG_DOMAIN = "org1.example.com"
+	PEER_HOST  = "peer0." + ORG_DOMAIN
+	// 组织管理员安装链码，User1为中继和业务链码的调用方
+	ADMIN_USER   = "Admin"
+	RELAYER_USER = "User1"
+)
+
+type Network struct {
+	// compose项目名，也是链码容器的前缀
+	Name        string
+	OrdererPort int
+	PeerPort    int
+	// onchain-plugin目录，部署cross和bizcc
+	OnchainPath string
+	// 网络的工作目录，Up时创建
+	Dir    string
+	logger hclog.Logger
+}
+
+func NewNetwork(name string, ordererPort, peerPort int, onchainPath string, logger hclog.Logger) *Network {
+	return &Network{Name: name, OrdererPort: ordererPort, PeerPort: peerPort, OnchainPath: onchainPath, logger: logger.Named(name)}
+}
+
+// docker和compose插件是否可用
+func DockerAvailable() error {
+	if out, err := exec.Command("docker", "compose", "version").CombinedOutput(); err != nil {
+		return fmt.Errorf("docker compose is not available: %v %s", err, bytes.TrimSpace(out))
+	}
+	if out, err := exec.Command("docker", "info").CombinedOutput(); err != nil {
+		return fmt.Errorf("docker daemon is not available: %v %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// 生成证书和创世区块，启动orderer和peer并加入通道
+func (n *Network) Up() error {
+	dir, err := ioutil.TempDir("", "fabric-"+n.Name)
+	if err != nil {
+		return err
+	}
+	n.Dir = dir
+	if err := writeAssets(dir); err != nil {
+		return fmt.Errorf("failed to write network assets: %v", err)
+	}
+	n.logger.Info("generate crypto material", "dir", dir)
+	if err := n.compose("run", "--rm", "tools", "crypto"); err != nil {
+		return err
+	}
+	n.logger.Info("start orderer and peer", "ordererPort", n.OrdererPort, "peerPort", n.PeerPort)
+	if err := n.compose("up", "-d", "orderer.example.com", PEER_HOST); err != nil {
+		return err
+	}
+	n.logger.Info("join channel", "channel", CHANNEL)
+	if err := n.compose("run", "--rm", "tools", "channel"); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(n.ProfilePath(), []byte(n.profile()), 0644)
+}
+
+func writeAssets(dir string) error {
+	return fs.WalkDir(assets, "network", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, strings.TrimPrefix(path, "network"))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		raw, err := assets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, raw, 0644)
+	})
+}
+
+// 停止网络，删除peer创建的链码容器、镜像和工作目录
+func (n *Network) Down() error {
+	if n.Dir == "" {
+		return nil
+	}
+	// 链码容器不属于compose项目，但连接在项目的网络上，需要先删除
+	prefix := n.Name + "-" + PEER_HOST
+	if ids := n.docker("ps", "-aq", "--filter", "name="+prefix); len(ids) > 0 {
+		n.docker(append([]string{"rm", "-f"}, ids...)...)
+	}
+	err := n.compose("down", "-v", "--remove-orphans")
+	if ids := n.docker("images", "-q", "--filter", "reference="+prefix+"*"); len(ids) > 0 {
+		n.docker(append([]string{"rmi", "-f"}, ids...)...)
+	}
+	if err == nil {
+		err = os.RemoveAll(n.Dir)
+	}
+	return err
+}
+
+func (n *Network) compose(args ...string) error {
+	cmd := exec.Command("docker", append([]string{"compose", "-p", n.Name, "-f", filepath.Join(n.Dir, "docker-compose.yaml")}, args...)...)
+	cmd.Dir = n.Dir
+	cmd.Env = append(os.Environ(),
+		"COMPOSE_PROJECT_NAME="+n.Name,
+		"E2E_ORDERER_PORT="+strconv.Itoa(n.OrdererPort),
+		"E2E_PEER_PORT="+strconv.Itoa(n.PeerPort),
+		"E2E_UID="+strconv.Itoa(os.Getuid()),
+		"E2E_GID="+strconv.Itoa(os.Getgid()),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker compose %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	n.logger.Debug("docker compose", "args", args, "output", string(out))
+	return nil
+}
+
+// 执行docker命令，返回输出的各行，清理时忽略错误
+func (n *Network) docker(args ...string) []string {
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		n.logger.Warn("docker command failed", "args", args, "error", err, "output", string(out))
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+func (n *Network) ProfilePath() string {
+	return filepath.Join(n.Dir, "connection.yaml")
+}
+
+func (n *Network) profile() string {
+	crypto := filepath.Join(n.Dir, "crypto")
+	return fmt.Sprintf(`version: 1.0.0
+client:
+  organization: %[1]s
+  logging:
+    level: warn
+  cryptoconfig:
+    path: %[2]s
+channels:
+  %[3]s:
+    peers:
+      %[4]s:
+        endorsingPeer: true
+        chaincodeQuery: true
+        ledgerQuery: true
+        eventSource: true
+organizations:
+  %[1]s:
+    mspid: Org1MSP
+    cryptoPath: peerOrganizations/%[5]s/users/{username}@%[5]s/msp
+    peers:
+      - %[4]s
+orderers:
+  orderer.example.com:
+    url: grpcs://localhost:%[6]d
+    grpcOptions:
+      ssl-target-name-override: orderer.example.com
+    tlsCACerts:
+      path: %[2]s/ordererOrganizations/example.com/tlsca/tlsca.example.com-cert.pem
+peers:
+  %[4]s:
+    url: grpcs://localhost:%[7]d
+    grpcOptions:
+      ssl-target-name-override: %[4]s
+    tlsCACerts:
+      path: %[2]s/peerOrganizations/%[5]s/tlsca/tlsca.%[5]s-cert.pem
+entityMatchers:
+  peer:
+    - pattern: %[4]s(:\d+)?
+      urlSubstitutionExp: grpcs://localhost:%[7]d
+      sslTargetOverrideUrlSubstitutionExp: %[4]s
+      mappedHost: %[4]s
+  orderer:
+    - pattern: orderer.example.com(:\d+)?
+      urlSubstitutionExp: grpcs://localhost:%[6]d
+      sslTargetOverrideUrlSubstitutionExp: orderer.example.com
+      mappedHost: orderer.example.com
+`, ORG, crypto, CHANNEL, PEER_HOST, ORG_DOMAIN, n.OrdererPort, n.PeerPort)
+}
+
+// cryptogen生成的用户证书和私钥
+func (n *Network) User(name string) fabric.UserConfig {
+	msp := filepath.Join(n.Dir, "crypto", "peerOrganizations", ORG_DOMAIN, "users", name+"@"+ORG_DOMAIN, "msp")
+	return fabric.UserConfig{
+		CertPath: filepath.Join(msp, "signcerts", name+"@"+ORG_DOMAIN+"-cert.pem"),
+		KeyPath:  filepath.Join(msp, "keystore", "priv_sk"),
+	}
+}
+
+// 插件的配置，中继为User1，接收方为bizcc
+func (n *Network) Config(chaincode string) fabric.Config {
+	return fabric.Config{
+		ConnectionProfilePath: n.ProfilePath(),
+		Channel:               CHANNEL,
+		Chaincode:             chaincode,
+		Org:                   ORG,
+		User:                  n.User(RELAYER_USER),
+		Receivers:             []string{BIZ_CHAINCODE},
+		ReloadInterval:        -1,
+	}
+}
+
+// 部署cross和bizcc，以中继身份初始化cross，本链域名为domain
+func (n *Network) Deploy(domain string) error {
+	for _, chaincode := range []string{CROSS_CHAINCODE, BIZ_CHAINCODE} {
+		if err := n.deploy(chaincode, domain); err != nil {
+			return fmt.Errorf("failed to deploy %s on %s: %v", chaincode, n.Name, err)
+		}
+	}
+	return nil
+}
+
+func (n *Network) deploy(chaincode, domain string) error {
+	raw, err := json.Marshal(&fabric.DeployConfig{
+		Config:    n.Config(chaincode),
+		Admin:     n.User(ADMIN_USER),
+		CrossPath: filepath.Join(n.OnchainPath, chaincode),
+		Version:   CHAINCODE_VERSION,
+		Domain:    domain,
+	})
+	if err != nil {
+		return err
+	}
+	conf, err := fabric.ParseDeployConfig(raw)
+	if err != nil {
+		return err
+	}
+	pkg, err := fabric.PackageChaincode(conf.CrossPath, conf.Label())
+	if err != nil {
+		return err
+	}
+	d, err := fabric.NewDeployer(conf, n.logger)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	packageID, err := d.Install(pkg)
+	if err != nil {
+		return err
+	}
+	if err := d.Approve(packageID); err != nil {
+		return err
+	}
+	if err := d.Commit(); err != nil {
+		return err
+	}
+	if chaincode != CROSS_CHAINCODE {
+		return nil
+	}
+	if err := d.Wire(); err != nil {
+		return err
+	}
+	return d.Verify()
+}
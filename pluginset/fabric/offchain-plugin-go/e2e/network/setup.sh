@@ -0,0 +1,40 @@
+#!/bin/sh
+# 在tools容器中执行：
+#   setup.sh crypto    生成证书和通道的创世区块
+#   setup.sh channel   orderer和peer加入通道
+set -e
+
+CHANNEL=${CHANNEL:-mychannel}
+ORDERER_TLS=/work/crypto/ordererOrganizations/example.com/orderers/orderer.example.com/tls
+ORG1=/work/crypto/peerOrganizations/org1.example.com
+
+case "$1" in
+crypto)
+  rm -rf /work/crypto /work/${CHANNEL}.block
+  cryptogen generate --config=/work/crypto-config.yaml --output=/work/crypto
+  configtxgen -profile E2EChannel -outputBlock /work/${CHANNEL}.block -channelID ${CHANNEL}
+  ;;
+channel)
+  osnadmin channel join --channelID ${CHANNEL} --config-block /work/${CHANNEL}.block \
+    -o orderer.example.com:7053 --ca-file ${ORDERER_TLS}/ca.crt \
+    --client-cert ${ORDERER_TLS}/server.crt --client-key ${ORDERER_TLS}/server.key
+  export FABRIC_CFG_PATH=/etc/hyperledger/fabric
+  export CORE_PEER_TLS_ENABLED=true
+  export CORE_PEER_LOCALMSPID=Org1MSP
+  export CORE_PEER_ADDRESS=peer0.org1.example.com:7051
+  export CORE_PEER_TLS_ROOTCERT_FILE=${ORG1}/peers/peer0.org1.example.com/tls/ca.crt
+  export CORE_PEER_MSPCONFIGPATH=${ORG1}/users/Admin@org1.example.com/msp
+  # peer启动后监听需要一点时间
+  for i in 1 2 3 4 5 6 7 8 9 10; do
+    if peer channel join -b /work/${CHANNEL}.block; then
+      exit 0
+    fi
+    sleep 2
+  done
+  exit 1
+  ;;
+*)
+  echo "usage: setup.sh crypto|channel" >&2
+  exit 2
+  ;;
+esac
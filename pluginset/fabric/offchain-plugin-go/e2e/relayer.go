@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/relayqueue"
+)
+
+// 本地回环中继：按高度读取来源链的跨链消息，打包为中继报文投递到目的链。
+// 报文不带hints，跨链链码不验证证明，只用于测试，不经过PTC。
+// 读出的消息先写入持久化队列，交易上链后才从队列删除，中继重启后从队列和读取进度继续，不丢失消息。
+// 投递失败的消息按重试策略退避重试或移入死信。
+
+type Relayer struct {
+	from       bbc.BBCService
+	fromDomain string
+	to         bbc.BBCService
+	queue      *relayqueue.Queue
+	// 投递失败时的重试策略，为nil时使用默认值
+	Policy *relayqueue.RetryPolicy
+	logger hclog.Logger
+	now    func() time.Time
+}
+
+// 从队列记录的高度继续中继，队列没有读取过时从来源链的当前高度之后开始
+func NewRelayer(from bbc.BBCService, fromDomain string, to bbc.BBCService, queue *relayqueue.Queue, logger hclog.Logger) (*Relayer, error) {
+	_, ok, err := queue.Cursor()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		height, err := from.QueryLatestHeight()
+		if err != nil {
+			return nil, err
+		}
+		if err := queue.Enqueue(height + 1); err != nil {
+			return nil, err
+		}
+	}
+	return &Relayer{from: from, fromDomain: fromDomain, to: to, queue: queue, logger: logger.Named("relayer").With("from", fromDomain), now: time.Now}, nil
+}
+
+// 读取新区块中的消息并投递队列中的消息，返回本次投递的回执
+func (r *Relayer) Relay() ([]*bbc.CrossChainMessageReceipt, error) {
+	if err := r.ingest(); err != nil {
+		return nil, err
+	}
+	return r.deliver()
+}
+
+// 把上次之后到最新高度的跨链消息写入队列，每个区块的消息与读取进度一起写入
+func (r *Relayer) ingest() error {
+	latest, err := r.from.QueryLatestHeight()
+	if err != nil {
+		return err
+	}
+	next, _, err := r.queue.Cursor()
+	if err != nil {
+		return err
+	}
+	for ; next <= latest; next++ {
+		msgs, err := r.from.ReadCrossChainMessagesByHeight(next)
+		if err != nil {
+			return err
+		}
+		var queued []*relayqueue.Message
+		for _, msg := range msgs {
+			if msg.Type == bbc.AUTH_MSG {
+				queued = append(queued, &relayqueue.Message{Domain: r.fromDomain, Height: next, Payload: msg.Message})
+			}
+		}
+		if err := r.queue.Enqueue(next+1, queued...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 按入队顺序投递，交易上链且校验成功后确认。可以重试的失败按退避时间等待，之后的消息也不投递，保持有序消息的顺序；
+// 不能重试或达到最多投递次数的消息移入死信，继续投递之后的消息
+func (r *Relayer) deliver() ([]*bbc.CrossChainMessageReceipt, error) {
+	msgs, err := r.queue.Pending(0)
+	if err != nil {
+		return nil, err
+	}
+	var receipts []*bbc.CrossChainMessageReceipt
+	for _, msg := range msgs {
+		now := r.now()
+		if msg.NextAttemptAt > now.UnixNano()/int64(time.Millisecond) {
+			break
+		}
+		receipt, err := r.to.RelayAuthMessage(fabric.EncodeRelayPackage(msg.Domain, msg.Payload))
+		if err == nil {
+			r.logger.Info("relay auth message", "height", msg.Height, "seq", msg.Seq, "txId", receipt.TxHash, "successful", receipt.Successful, "error", receipt.ErrorMsg)
+			receipts = append(receipts, receipt)
+			if receipt.Successful {
+				if err := r.queue.Ack(msg.Seq); err != nil {
+					return receipts, err
+				}
+				continue
+			}
+		}
+		reason, backoff, retry := r.Policy.Next(msg.Attempts+1, receipt, err)
+		if !retry {
+			r.logger.Warn("message moved to dead letters", "height", msg.Height, "seq", msg.Seq, "attempts", msg.Attempts+1, "error", reason)
+			if buryErr := r.queue.Bury(msg.Seq, reason); buryErr != nil {
+				return receipts, buryErr
+			}
+			continue
+		}
+		if failErr := r.queue.Fail(msg.Seq, reason, now.Add(backoff)); failErr != nil {
+			return receipts, failErr
+		}
+		if err != nil {
+			return receipts, fmt.Errorf("failed to relay message at height %d: %v", msg.Height, err)
+		}
+		return receipts, nil
+	}
+	return receipts, nil
+}
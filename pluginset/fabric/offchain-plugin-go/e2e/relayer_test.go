@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/relayqueue"
+)
+
+// 内存中的链，只实现中继用到的方法
+type memoryChain struct {
+	bbc.BBCService
+	blocks [][]*bbc.CrossChainMessage
+	// 收到的消息和下一次投递返回的错误、回执
+	relayed  [][]byte
+	err      error
+	receipts map[string]*bbc.CrossChainMessageReceipt
+}
+
+func (c *memoryChain) QueryLatestHeight() (uint64, error) {
+	return uint64(len(c.blocks) - 1), nil
+}
+
+func (c *memoryChain) ReadCrossChainMessagesByHeight(height uint64) ([]*bbc.CrossChainMessage, error) {
+	return c.blocks[height], nil
+}
+
+func (c *memoryChain) RelayAuthMessage(rawMessage []byte) (*bbc.CrossChainMessageReceipt, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	for msg, receipt := range c.receipts {
+		if bytes.Contains(rawMessage, []byte(msg)) {
+			return receipt, nil
+		}
+	}
+	c.relayed = append(c.relayed, rawMessage)
+	return &bbc.CrossChainMessageReceipt{Confirmed: true, Successful: true}, nil
+}
+
+func TestRelayerRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	from, to := &memoryChain{blocks: [][]*bbc.CrossChainMessage{nil}}, &memoryChain{}
+	queue, err := relayqueue.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	newRelayer := func() *Relayer {
+		r, err := NewRelayer(from, DOMAIN_A, to, queue, hclog.NewNullLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.now = func() time.Time { return now }
+		return r
+	}
+	r := newRelayer()
+	from.blocks = append(from.blocks, []*bbc.CrossChainMessage{
+		{Type: bbc.AUTH_MSG, Message: []byte("m1")},
+		{Type: bbc.DEVELOPER_DESIGN, Message: []byte("ignored")},
+		{Type: bbc.AUTH_MSG, Message: []byte("m2")},
+	})
+	// 目的链不可用时消息留在队列中，退避时间内不重新投递
+	to.err = errors.New("connection refused")
+	if _, err := r.Relay(); err == nil {
+		t.Fatal("relay error should be returned")
+	}
+	to.err, to.receipts = nil, map[string]*bbc.CrossChainMessageReceipt{"m1": {ErrorMsg: "timeout"}}
+	if receipts, err := r.Relay(); err != nil || len(receipts) != 0 {
+		t.Fatalf("message should wait for backoff: %v %v", receipts, err)
+	}
+	now = now.Add(time.Second)
+	if receipts, err := r.Relay(); err != nil || len(receipts) != 1 || receipts[0].Confirmed {
+		t.Fatalf("unexpected receipts: %v %v", receipts, err)
+	}
+
+	// 中继重启后投递之前读出的消息
+	queue.Close()
+	if queue, err = relayqueue.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+	r = newRelayer()
+	msgs, err := queue.Pending(0)
+	if err != nil || len(msgs) != 2 || msgs[0].Attempts != 2 {
+		t.Fatalf("unexpected pending messages: %+v %v", msgs, err)
+	}
+	now = now.Add(time.Minute)
+	to.receipts = nil
+	receipts, err := r.Relay()
+	if err != nil || len(receipts) != 2 || len(to.relayed) != 2 {
+		t.Fatalf("unexpected relay: %v %v", receipts, err)
+	}
+	if n, _ := queue.Len(); n != 0 {
+		t.Fatalf("delivered messages should be acked, %d left", n)
+	}
+	// 已读取的区块不再读取
+	if receipts, err := r.Relay(); err != nil || len(receipts) != 0 {
+		t.Fatalf("unexpected relay: %v %v", receipts, err)
+	}
+
+	// 不能重试的消息移入死信，之后的消息继续投递
+	from.blocks = append(from.blocks, []*bbc.CrossChainMessage{
+		{Type: bbc.AUTH_MSG, Message: []byte("m3")},
+		{Type: bbc.AUTH_MSG, Message: []byte("m4")},
+	})
+	to.receipts = map[string]*bbc.CrossChainMessageReceipt{"m3": {ErrorCode: "ERR_PROOF_INVALID", ErrorMsg: "invalid proof"}}
+	if receipts, err := r.Relay(); err != nil || len(receipts) != 2 || !receipts[1].Successful {
+		t.Fatalf("unexpected relay: %v %v", receipts, err)
+	}
+	dead, err := queue.Dead(0)
+	if err != nil || len(dead) != 1 || string(dead[0].Payload) != "m3" || dead[0].LastError != "ERR_PROOF_INVALID: invalid proof" {
+		t.Fatalf("unexpected dead letters: %+v %v", dead, err)
+	}
+}
@@ -0,0 +1,194 @@
+package fabric
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 跨链链码发送消息时写入的AM消息key前缀，与Java插件一致
+const AM_MESSAGE_KEY_PREFIX = "oraclelogic_crosschain_msg_"
+
+// 链码事件，只记录跨链链码作为最外层链码发出的事件
+type ChaincodeEvent struct {
+	Height    uint64 `json:"height"`
+	TxID      string `json:"txId"`
+	EventName string `json:"eventName"`
+	Payload   []byte `json:"payload"`
+}
+
+// 一个区块中与跨链链码相关的内容
+type BlockRecord struct {
+	Height   uint64                   `json:"height"`
+	Messages []*bbc.CrossChainMessage `json:"messages,omitempty"`
+	Events   []*ChaincodeEvent        `json:"events,omitempty"`
+}
+
+// 背书交易中跨链链码的写集合和事件
+type endorserTx struct {
+	txID string
+	// 毫秒
+	timestamp int64
+	writes    []*kvrwset.KVWrite
+	events    []*pb.ChaincodeEvent
+}
+
+// 从区块中读取跨链链码发出的AM消息
+// 只处理校验通过的背书交易，按交易在区块中的顺序、写集合中的顺序返回
+func readCrossChainMessages(block *common.Block, chaincode string) ([]*bbc.CrossChainMessage, error) {
+	record, err := readBlock(block, chaincode, nil)
+	if err != nil {
+		return nil, err
+	}
+	return record.Messages, nil
+}
+
+// 读取区块中的AM消息和跨链链码的事件，eventNames为空时不读取事件
+func readBlock(block *common.Block, chaincode string, eventNames map[string]bool) (*BlockRecord, error) {
+	txs, err := filterBlock(block, chaincode)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(block.Header, txs, chaincode, eventNames)
+}
+
+// 筛选区块中校验通过、调用了跨链链码的背书交易
+func filterBlock(block *common.Block, chaincode string) ([]*endorserTx, error) {
+	if block.Header == nil || block.Data == nil {
+		return nil, fmt.Errorf("incomplete block")
+	}
+	var filter []byte
+	if md := block.Metadata; md != nil && len(md.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		filter = md.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+	var txs []*endorserTx
+	for i, data := range block.Data.Data {
+		if i < len(filter) && pb.TxValidationCode(filter[i]) != pb.TxValidationCode_VALID {
+			continue
+		}
+		tx, err := parseEndorserTx(data, chaincode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tx %d of block %d: %v", i, block.Header.Number, err)
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+// 从筛选出的交易中提取AM消息和关注的事件
+func decodeBlock(header *common.BlockHeader, txs []*endorserTx, chaincode string, eventNames map[string]bool) (*BlockRecord, error) {
+	record := &BlockRecord{Height: header.Number}
+	for _, tx := range txs {
+		for _, w := range tx.writes {
+			if w.IsDelete || !strings.HasPrefix(w.Key, AM_MESSAGE_KEY_PREFIX) {
+				continue
+			}
+			txHash, err := hex.DecodeString(tx.txID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid txid %s: %v", tx.txID, err)
+			}
+			record.Messages = append(record.Messages, &bbc.CrossChainMessage{
+				Type:    bbc.AUTH_MSG,
+				Message: w.Value,
+				ProvableData: &bbc.ProvableLedgerData{
+					Height:    header.Number,
+					BlockHash: header.DataHash,
+					Timestamp: tx.timestamp,
+					TxHash:    txHash,
+				},
+			})
+		}
+		for _, e := range tx.events {
+			if e.ChaincodeId != chaincode || !eventNames[e.EventName] {
+				continue
+			}
+			record.Events = append(record.Events, &ChaincodeEvent{
+				Height:    header.Number,
+				TxID:      tx.txID,
+				EventName: e.EventName,
+				Payload:   e.Payload,
+			})
+		}
+	}
+	return record, nil
+}
+
+// 解析背书交易，其他类型的交易返回nil
+func parseEndorserTx(data []byte, chaincode string) (*endorserTx, error) {
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(data, env); err != nil {
+		return nil, err
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, fmt.Errorf("no payload header")
+	}
+	chdr := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, chdr); err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+	tx := &endorserTx{txID: chdr.TxId}
+	if chdr.Timestamp != nil {
+		tx.timestamp = chdr.Timestamp.Seconds*1000 + int64(chdr.Timestamp.Nanos)/1e6
+	}
+
+	ptx := &pb.Transaction{}
+	if err := proto.Unmarshal(payload.Data, ptx); err != nil {
+		return nil, err
+	}
+	for _, action := range ptx.Actions {
+		ccap := &pb.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.Payload, ccap); err != nil {
+			return nil, err
+		}
+		if ccap.Action == nil {
+			continue
+		}
+		prp := &pb.ProposalResponsePayload{}
+		if err := proto.Unmarshal(ccap.Action.ProposalResponsePayload, prp); err != nil {
+			return nil, err
+		}
+		cca := &pb.ChaincodeAction{}
+		if err := proto.Unmarshal(prp.Extension, cca); err != nil {
+			return nil, err
+		}
+		if len(cca.Events) > 0 {
+			event := &pb.ChaincodeEvent{}
+			if err := proto.Unmarshal(cca.Events, event); err != nil {
+				return nil, err
+			}
+			tx.events = append(tx.events, event)
+		}
+		txrw := &rwset.TxReadWriteSet{}
+		if err := proto.Unmarshal(cca.Results, txrw); err != nil {
+			return nil, err
+		}
+		for _, ns := range txrw.NsRwset {
+			if ns.Namespace != chaincode {
+				continue
+			}
+			kv := &kvrwset.KVRWSet{}
+			if err := proto.Unmarshal(ns.Rwset, kv); err != nil {
+				return nil, err
+			}
+			tx.writes = append(tx.writes, kv.Writes...)
+		}
+	}
+	return tx, nil
+}
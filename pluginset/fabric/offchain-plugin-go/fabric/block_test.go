@@ -0,0 +1,103 @@
+package fabric
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func mustMarshal(m proto.Message) []byte {
+	raw, err := proto.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// 构造背书交易，writes为各链码的写集合，events为链码事件
+func newEndorserTx(txID string, seconds int64, writes map[string][]*kvrwset.KVWrite, events ...*pb.ChaincodeEvent) []byte {
+	txrw := &rwset.TxReadWriteSet{DataModel: rwset.TxReadWriteSet_KV}
+	for ns, w := range writes {
+		txrw.NsRwset = append(txrw.NsRwset, &rwset.NsReadWriteSet{Namespace: ns, Rwset: mustMarshal(&kvrwset.KVRWSet{Writes: w})})
+	}
+	cca := &pb.ChaincodeAction{Results: mustMarshal(txrw)}
+	// 一笔交易只有一个链码事件
+	if len(events) > 0 {
+		cca.Events = mustMarshal(events[0])
+	}
+	prp := &pb.ProposalResponsePayload{Extension: mustMarshal(cca)}
+	ccap := &pb.ChaincodeActionPayload{Action: &pb.ChaincodeEndorsedAction{ProposalResponsePayload: mustMarshal(prp)}}
+	tx := &pb.Transaction{Actions: []*pb.TransactionAction{{Payload: mustMarshal(ccap)}}}
+	chdr := &common.ChannelHeader{
+		Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+		TxId:      txID,
+		Timestamp: &timestamp.Timestamp{Seconds: seconds, Nanos: 5e8},
+	}
+	payload := &common.Payload{Header: &common.Header{ChannelHeader: mustMarshal(chdr)}, Data: mustMarshal(tx)}
+	return mustMarshal(&common.Envelope{Payload: mustMarshal(payload)})
+}
+
+func newBlock(number uint64, filter []byte, txs ...[]byte) *common.Block {
+	metadata := make([][]byte, len(common.BlockMetadataIndex_name))
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = filter
+	return &common.Block{
+		Header:   &common.BlockHeader{Number: number, DataHash: []byte("datahash")},
+		Data:     &common.BlockData{Data: txs},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}
+}
+
+func TestReadCrossChainMessages(t *testing.T) {
+	txID1, txID2, txID3 := hex.EncodeToString([]byte("tx1")), hex.EncodeToString([]byte("tx2")), hex.EncodeToString([]byte("tx3"))
+	config := mustMarshal(&common.Envelope{Payload: mustMarshal(&common.Payload{
+		Header: &common.Header{ChannelHeader: mustMarshal(&common.ChannelHeader{Type: int32(common.HeaderType_CONFIG)})},
+	})})
+	block := newBlock(12, []byte{byte(pb.TxValidationCode_VALID), byte(pb.TxValidationCode_VALID), byte(pb.TxValidationCode_MVCC_READ_CONFLICT), byte(pb.TxValidationCode_VALID)},
+		config,
+		newEndorserTx(txID1, 1000, map[string][]*kvrwset.KVWrite{
+			"cross": {
+				{Key: AM_MESSAGE_KEY_PREFIX + "1", Value: []byte("am1")},
+				{Key: "other", Value: []byte("x")},
+				{Key: AM_MESSAGE_KEY_PREFIX + "2", IsDelete: true},
+				{Key: AM_MESSAGE_KEY_PREFIX + "3", Value: []byte("am3")},
+			},
+			// 其他链码写入的同名key不是跨链消息
+			"bizcc": {{Key: AM_MESSAGE_KEY_PREFIX + "4", Value: []byte("fake")}},
+		}),
+		// 校验失败的交易
+		newEndorserTx(txID2, 1001, map[string][]*kvrwset.KVWrite{"cross": {{Key: AM_MESSAGE_KEY_PREFIX + "5", Value: []byte("invalid")}}}),
+		newEndorserTx(txID3, 1002, map[string][]*kvrwset.KVWrite{"cross": {{Key: AM_MESSAGE_KEY_PREFIX + "6", Value: []byte("am6")}}}),
+	)
+
+	msgs, err := readCrossChainMessages(block, "cross")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("unexpected messages: %d", len(msgs))
+	}
+	for i, expect := range []struct {
+		message string
+		txHash  string
+		ts      int64
+	}{{"am1", "tx1", 1000500}, {"am3", "tx1", 1000500}, {"am6", "tx3", 1002500}} {
+		msg := msgs[i]
+		if msg.Type != bbc.AUTH_MSG || string(msg.Message) != expect.message || string(msg.ProvableData.TxHash) != expect.txHash ||
+			msg.ProvableData.Height != 12 || msg.ProvableData.Timestamp != expect.ts || string(msg.ProvableData.BlockHash) != "datahash" {
+			t.Fatalf("unexpected message %d: %+v %+v", i, msg, msg.ProvableData)
+		}
+	}
+
+	block.Data.Data = append(block.Data.Data, []byte("garbage"))
+	if _, err := readCrossChainMessages(block, "cross"); err == nil {
+		t.Fatal("malformed tx should be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// 监听进度和区块记录的本地存储
+// 目录下checkpoint.json记录已处理的最高区块，blocks目录下只保存含有AM消息或事件的区块记录。
+// 先写区块记录再更新checkpoint，中途退出时重放会覆盖checkpoint之后残留的记录。
+type checkpointStore struct {
+	dir string
+}
+
+type checkpoint struct {
+	// 第一个处理的区块，更早的区块没有记录
+	Start  uint64 `json:"start"`
+	Height uint64 `json:"height"`
+}
+
+const (
+	CHECKPOINT_FILE = "checkpoint.json"
+	BLOCKS_DIR      = "blocks"
+)
+
+func openCheckpointStore(dir string) (*checkpointStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, BLOCKS_DIR), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint dir %s: %v", dir, err)
+	}
+	return &checkpointStore{dir: dir}, nil
+}
+
+// 写临时文件后改名，避免中途退出留下不完整的文件
+func writeFileAtomic(path string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *checkpointStore) blockPath(height uint64) string {
+	return filepath.Join(s.dir, BLOCKS_DIR, fmt.Sprintf("%020d.json", height))
+}
+
+// 读取checkpoint，没有时返回nil
+func (s *checkpointStore) checkpoint() (*checkpoint, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(s.dir, CHECKPOINT_FILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	return &cp, nil
+}
+
+// 保存区块记录，没有消息和事件的区块不写文件
+func (s *checkpointStore) saveRecord(record *BlockRecord) error {
+	if len(record.Messages) > 0 || len(record.Events) > 0 {
+		if err := writeFileAtomic(s.blockPath(record.Height), record); err != nil {
+			return fmt.Errorf("failed to save block %d: %v", record.Height, err)
+		}
+	}
+	return nil
+}
+
+func (s *checkpointStore) saveCheckpoint(height, start uint64) error {
+	if err := writeFileAtomic(filepath.Join(s.dir, CHECKPOINT_FILE), &checkpoint{Start: start, Height: height}); err != nil {
+		return fmt.Errorf("failed to save checkpoint %d: %v", height, err)
+	}
+	return nil
+}
+
+// 读取区块记录，没有记录时返回空记录，调用方确认height不超过checkpoint
+func (s *checkpointStore) load(height uint64) (*BlockRecord, error) {
+	raw, err := ioutil.ReadFile(s.blockPath(height))
+	if os.IsNotExist(err) {
+		return &BlockRecord{Height: height}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var record BlockRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fmt.Errorf("invalid record of block %d: %v", height, err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,266 @@
+package fabric
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	mspclient "github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 插件访问Fabric通道的操作，测试中替换为内存实现
+type chainClient interface {
+	// 查询跨链链码
+	Query(fcn string, args ...string) ([]byte, error)
+	// 调用跨链链码并等待交易上链，返回交易id和校验结果
+	Invoke(req *txRequest) (string, pb.TxValidationCode, error)
+	// 通道的区块数
+	Height() (uint64, error)
+	BlockByNumber(number uint64) (*common.Block, error)
+	TransactionValidationCode(txID string) (pb.TxValidationCode, error)
+	// 交易所在区块的高度
+	BlockNumberByTxID(txID string) (uint64, error)
+	// 通过deliver服务从指定高度订阅区块，cancel取消订阅并关闭返回的channel
+	BlockEvents(from uint64) (<-chan *common.Block, func(), error)
+	Close()
+}
+
+type sdkClient struct {
+	sdk       *fabsdk.FabricSDK
+	ctx       context.ChannelProvider
+	channel   *channel.Client
+	ledger    *ledger.Client
+	chaincode string
+	submitter *submitter
+	// 配置了user.signer时的签名密钥
+	signer signer.Signer
+}
+
+func newSDKClient(conf *Config, logger hclog.Logger) (chainClient, error) {
+	profile, err := loadProfile(conf)
+	if err != nil {
+		return nil, err
+	}
+	sdk, err := fabsdk.New(config.FromRaw(profile, profileType(profile)), sdkOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fabric sdk: %v", err)
+	}
+	identity, s, err := newSigningIdentity(sdk, conf.Org, &conf.User)
+	if err != nil {
+		sdk.Close()
+		return nil, err
+	}
+	c, err := func() (*sdkClient, error) {
+		ctx := sdk.ChannelContext(conf.Channel, fabsdk.WithIdentity(identity))
+		ch, err := channel.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create channel client of %s: %v", conf.Channel, err)
+		}
+		lg, err := ledger.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ledger client of %s: %v", conf.Channel, err)
+		}
+		c := &sdkClient{sdk: sdk, ctx: ctx, channel: ch, ledger: lg, chaincode: conf.Chaincode, signer: s}
+		if c.submitter, err = newSubmitter(conf.Submit, c.execute, logger); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}()
+	if err != nil {
+		sdk.Close()
+		if s != nil {
+			signer.Close(s)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// 用户在组织中的签名身份，配置了signer时同时返回Signer，由调用方关闭
+func newSigningIdentity(sdk *fabsdk.FabricSDK, org string, user *UserConfig) (msp.SigningIdentity, signer.Signer, error) {
+	id, err := loadIdentity(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Signer != nil {
+		s, err := signer.New(user.Signer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user signer: %v", err)
+		}
+		identity, err := newSignerIdentity(sdk, org, id.cert, s)
+		if err != nil {
+			signer.Close(s)
+			return nil, nil, fmt.Errorf("failed to create signing identity: %v", err)
+		}
+		return identity, s, nil
+	}
+	mspClient, err := mspclient.New(sdk.Context(), mspclient.WithOrg(org))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create msp client of %s: %v", org, err)
+	}
+	opts := []msp.SigningIdentityOption{msp.WithCert(id.cert)}
+	if len(id.key) > 0 {
+		opts = append(opts, msp.WithPrivateKey(id.key))
+	}
+	identity, err := mspClient.CreateSigningIdentity(opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create signing identity: %v", err)
+	}
+	return identity, nil, nil
+}
+
+func (c *sdkClient) request(fcn string, args []string) channel.Request {
+	req := channel.Request{ChaincodeID: c.chaincode, Fcn: fcn}
+	for _, arg := range args {
+		req.Args = append(req.Args, []byte(arg))
+	}
+	return req
+}
+
+func (c *sdkClient) Query(fcn string, args ...string) ([]byte, error) {
+	resp, err := c.channel.Query(c.request(fcn, args))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Payload, nil
+}
+
+func (c *sdkClient) Invoke(req *txRequest) (string, pb.TxValidationCode, error) {
+	return c.submitter.submit(req)
+}
+
+// 在提交步骤之前回调beforeSend
+type beforeSendHandler struct {
+	beforeSend func(txID string) error
+	next       invoke.Handler
+}
+
+func (h *beforeSendHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	if err := h.beforeSend(string(requestContext.Response.TransactionID)); err != nil {
+		requestContext.Error = err
+		return
+	}
+	h.next.Handle(requestContext, clientContext)
+}
+
+// 由服务发现按调用链选择背书节点，重试由submitter处理
+func (c *sdkClient) execute(req *txRequest) (string, pb.TxValidationCode, error) {
+	request := c.request(req.fcn, req.args)
+	request.TransientMap = req.transient
+	request.InvocationChain = []*fab.ChaincodeCall{{ID: c.chaincode}}
+	for _, callee := range req.callees {
+		request.InvocationChain = append(request.InvocationChain, &fab.ChaincodeCall{ID: callee})
+	}
+	// 与channel.Client.Execute相同的处理链
+	var commit invoke.Handler = invoke.NewCommitHandler()
+	if req.beforeSend != nil {
+		commit = &beforeSendHandler{beforeSend: req.beforeSend, next: commit}
+	}
+	handler := invoke.NewSelectAndEndorseHandler(
+		invoke.NewEndorsementValidationHandler(
+			invoke.NewSignatureValidationHandler(commit),
+		),
+	)
+	resp, err := c.channel.InvokeHandler(handler, request, channel.WithRetry(retry.Opts{}))
+	if err != nil {
+		// 交易已上链但校验失败
+		if s, ok := status.FromError(err); ok && s.Group == status.EventServerStatus {
+			return string(resp.TransactionID), pb.TxValidationCode(s.Code), nil
+		}
+		return string(resp.TransactionID), 0, err
+	}
+	return string(resp.TransactionID), resp.TxValidationCode, nil
+}
+
+func (c *sdkClient) Height() (uint64, error) {
+	info, err := c.ledger.QueryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.BCI.Height, nil
+}
+
+func (c *sdkClient) BlockByNumber(number uint64) (*common.Block, error) {
+	return c.ledger.QueryBlock(number)
+}
+
+func (c *sdkClient) TransactionValidationCode(txID string) (pb.TxValidationCode, error) {
+	tx, err := c.ledger.QueryTransaction(fab.TransactionID(txID))
+	if err != nil {
+		return 0, err
+	}
+	return pb.TxValidationCode(tx.ValidationCode), nil
+}
+
+func (c *sdkClient) BlockNumberByTxID(txID string) (uint64, error) {
+	block, err := c.ledger.QueryBlockByTxID(fab.TransactionID(txID))
+	if err != nil {
+		return 0, err
+	}
+	if block.Header == nil {
+		return 0, fmt.Errorf("block of tx %s has no header", txID)
+	}
+	return block.Header.Number, nil
+}
+
+func (c *sdkClient) BlockEvents(from uint64) (<-chan *common.Block, func(), error) {
+	client, err := event.New(c.ctx, event.WithBlockEvents(), event.WithSeekType(seek.FromBlock), event.WithBlockNum(from))
+	if err != nil {
+		return nil, nil, err
+	}
+	reg, events, err := client.RegisterBlockEvent()
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks := make(chan *common.Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(blocks)
+		for {
+			select {
+			case <-done:
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case blocks <- e.Block:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(done)
+			client.Unregister(reg)
+		})
+	}
+	return blocks, cancel, nil
+}
+
+func (c *sdkClient) Close() {
+	c.sdk.Close()
+	if c.signer != nil {
+		signer.Close(c.signer)
+	}
+}
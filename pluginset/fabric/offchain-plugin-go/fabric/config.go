@@ -0,0 +1,124 @@
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// BBCContext中raw_conf的内容
+// 连接配置使用fabric-sdk-go的connection profile，身份为中继在通道中的用户证书和私钥(PEM)，
+// 也可以使用fabric-sdk-go应用的profile文件和钱包，文件变化时重新连接
+type Config struct {
+	// connection profile的内容，yaml或json
+	ConnectionProfile string `json:"connectionProfile"`
+	// connection profile的文件路径，与connectionProfile二选一
+	ConnectionProfilePath string `json:"connectionProfilePath"`
+	Channel               string `json:"channel"`
+	// 部署的跨链链码名
+	Chaincode string     `json:"chaincode"`
+	Org       string     `json:"org"`
+	User      UserConfig `json:"user"`
+	// 可以接收跨链消息的业务链码，首次向其投递消息时在跨链链码中登记sha256反查
+	Receivers []string `json:"receivers"`
+	// 区块监听，配置后按高度读取跨链消息时使用监听保存的记录
+	Listener *ListenerConfig `json:"listener,omitempty"`
+	// 跨链消息的提交记录，配置后重复提交同一消息不会重复发送交易
+	RelayCache *RelayCacheConfig `json:"relayCache,omitempty"`
+	// 交易提交的重试，未配置时使用默认值
+	Submit *SubmitConfig `json:"submit,omitempty"`
+	// 检查profile和身份文件变化的间隔(毫秒)，默认DEFAULT_RELOAD_INTERVAL，负数不检查
+	ReloadInterval int64 `json:"reloadInterval"`
+	// 按高度读取跨链消息时在ProvableData.Proof中附带区块的BlockProof，供HCDVS验证
+	AttachProof bool `json:"attachProof"`
+	// 最新高度取跨链链码高度信标所在的区块，即跨链链码最近一笔跨链交易的高度，而不是通道的区块数
+	HeightBeacon bool `json:"heightBeacon"`
+}
+
+// 证书和私钥可以直接配置、配置文件路径或从钱包读取。没有配置私钥时，
+// 由SDK按证书的SKI从profile配置的keystore或HSM(BCCSP为PKCS11)中查找
+type UserConfig struct {
+	Cert     string `json:"cert"`
+	Key      string `json:"key"`
+	CertPath string `json:"certPath"`
+	KeyPath  string `json:"keyPath"`
+	// fabric-sdk-go gateway的文件钱包目录和身份标签
+	Wallet string `json:"wallet"`
+	Label  string `json:"label"`
+	// 由Signer签名，私钥在文件、HSM或KMS中，与key、keyPath和wallet互斥
+	Signer *signer.Config `json:"signer,omitempty"`
+}
+
+func parseConfig(raw []byte) (*Config, error) {
+	var conf Config
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		return nil, fmt.Errorf("invalid fabric config: %v", err)
+	}
+	switch {
+	case conf.ConnectionProfile == "" && conf.ConnectionProfilePath == "":
+		return nil, fmt.Errorf("connectionProfile or connectionProfilePath is required")
+	case conf.ConnectionProfile != "" && conf.ConnectionProfilePath != "":
+		return nil, fmt.Errorf("connectionProfile and connectionProfilePath are exclusive")
+	case conf.Channel == "":
+		return nil, fmt.Errorf("channel is required")
+	case conf.Chaincode == "":
+		return nil, fmt.Errorf("chaincode is required")
+	case conf.Org == "":
+		return nil, fmt.Errorf("org is required")
+	}
+	if err := conf.User.validate(); err != nil {
+		return nil, err
+	}
+	if conf.Listener != nil && conf.Listener.Dir == "" {
+		return nil, fmt.Errorf("listener dir is required")
+	}
+	if conf.RelayCache != nil && conf.RelayCache.Path == "" {
+		return nil, fmt.Errorf("relayCache path is required")
+	}
+	return &conf, nil
+}
+
+func (u *UserConfig) validate() error {
+	sources := 0
+	for _, set := range []bool{u.Cert != "", u.CertPath != "", u.Wallet != ""} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return fmt.Errorf("user cert, certPath or wallet is required")
+	case sources > 1:
+		return fmt.Errorf("user cert, certPath and wallet are exclusive")
+	case u.Key != "" && u.KeyPath != "":
+		return fmt.Errorf("user key and keyPath are exclusive")
+	case u.Wallet != "" && u.Label == "":
+		return fmt.Errorf("user label is required for wallet")
+	case u.Wallet != "" && (u.Key != "" || u.KeyPath != ""):
+		return fmt.Errorf("user key is read from wallet")
+	case u.Signer != nil && (u.Key != "" || u.KeyPath != "" || u.Wallet != ""):
+		return fmt.Errorf("user signer is exclusive with key, keyPath and wallet")
+	}
+	return nil
+}
+
+func (c *Config) reloadInterval() time.Duration {
+	switch {
+	case c.ReloadInterval < 0:
+		return 0
+	case c.ReloadInterval == 0:
+		return DEFAULT_RELOAD_INTERVAL
+	}
+	return time.Duration(c.ReloadInterval) * time.Millisecond
+}
+
+// connection profile的格式
+func profileType(profile []byte) string {
+	if strings.HasPrefix(strings.TrimSpace(string(profile)), "{") {
+		return "json"
+	}
+	return "yaml"
+}
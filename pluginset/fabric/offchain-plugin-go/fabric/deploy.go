@@ -0,0 +1,689 @@
+package fabric
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	lcpackager "github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/lifecycle"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/policydsl"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 跨链链码的部署，供fabric-bridge-setup使用
+// 按Fabric 2.x的链码生命周期打包、安装、批准和提交跨链链码，再以中继身份初始化：把中继设为链码管理员、
+// 设置本链域名、登记业务链码的sha256反查。跨链链码同时承担AM和SDP合约，两者在链码内部关联，
+// 不需要setProtocol和setAmContract。多个组织时各组织分别执行install和approve，再由一个组织commit。
+// 每一步都先查询链上状态，已完成的步骤跳过，中断后可以重新执行。
+
+const (
+	// onchain-plugin/cross、bizcc下Fabric 2.x的链码目录
+	CROSS_CHAINCODE_DIR = "v2.2"
+
+	FN_SET_ADMIN   = "setAdmin"
+	FN_CHECK_ADMIN = "checkAdmin"
+)
+
+// 公共vendor中只有v1.4链码使用的包，依赖已废弃的fabric/core/chaincode/shim，合并v2.2时跳过
+var LEGACY_VENDOR = []string{
+	"github.com/hyperledger/fabric",
+	"github.com/hyperledger/fabric-amcl",
+	"oraclelogic/oraclelogic.go",
+	"wrapstub/mockwrapstub.go",
+	"wrapstub/wrapstub.go",
+}
+
+// 部署配置，在插件配置的基础上增加链码定义和组织管理员
+type DeployConfig struct {
+	Config
+	// 组织管理员，用于安装和批准链码
+	Admin UserConfig `json:"admin"`
+	// onchain-plugin/cross目录
+	CrossPath string `json:"crossPath"`
+	Version   string `json:"version"`
+	// 链码定义的序号，为0时按已提交的定义推算：未提交为1，版本相同沿用，版本变化加一
+	Sequence int64 `json:"sequence"`
+	// 背书策略，如OR('Org1MSP.peer','Org2MSP.peer')，为空时使用通道的默认策略
+	Policy string `json:"policy"`
+	// 本组织安装链码的节点，为空时为profile中组织的全部节点
+	Peers []string `json:"peers"`
+	// 本链的域名，配置后设置到跨链链码
+	Domain string `json:"domain"`
+	// 配置后以链码即服务方式打包，链码包中只有连接信息，链码由运维自行部署，见onchain-plugin/cross/ccaas
+	CCaaS *CCaaSConfig `json:"ccaas,omitempty"`
+}
+
+// peer的ccaas外部builder连接链码服务的配置，即链码包中connection.json的内容。
+// 证书和私钥为PEM，也可以用*Path从文件读取
+type CCaaSConfig struct {
+	Address            string `json:"address"`
+	DialTimeout        string `json:"dialTimeout"`
+	TLSRequired        bool   `json:"tlsRequired"`
+	ClientAuthRequired bool   `json:"clientAuthRequired"`
+	ClientKey          string `json:"clientKey"`
+	ClientKeyPath      string `json:"clientKeyPath"`
+	ClientCert         string `json:"clientCert"`
+	ClientCertPath     string `json:"clientCertPath"`
+	RootCert           string `json:"rootCert"`
+	RootCertPath       string `json:"rootCertPath"`
+}
+
+func (c *CCaaSConfig) validate() error {
+	switch {
+	case c.Address == "":
+		return fmt.Errorf("ccaas address is required")
+	case c.TLSRequired && c.RootCert == "" && c.RootCertPath == "":
+		return fmt.Errorf("ccaas rootCert is required when TLS is required")
+	case c.ClientAuthRequired && !c.TLSRequired:
+		return fmt.Errorf("ccaas client auth requires TLS")
+	case c.ClientAuthRequired && (c.ClientKey == "" && c.ClientKeyPath == "" || c.ClientCert == "" && c.ClientCertPath == ""):
+		return fmt.Errorf("ccaas clientKey and clientCert are required when client auth is required")
+	}
+	return nil
+}
+
+func ParseDeployConfig(raw []byte) (*DeployConfig, error) {
+	base, err := parseConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+	var conf DeployConfig
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		return nil, fmt.Errorf("invalid deploy config: %v", err)
+	}
+	conf.Config = *base
+	switch {
+	case conf.Version == "":
+		return nil, fmt.Errorf("version is required")
+	case conf.Sequence < 0:
+		return nil, fmt.Errorf("invalid sequence %d", conf.Sequence)
+	}
+	if err := conf.Admin.validate(); err != nil {
+		return nil, fmt.Errorf("invalid admin: %v", err)
+	}
+	if _, err := conf.policy(); err != nil {
+		return nil, err
+	}
+	if conf.CCaaS != nil {
+		if err := conf.CCaaS.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &conf, nil
+}
+
+// 包标签，与peer lifecycle chaincode package的--label一致
+func (c *DeployConfig) Label() string {
+	return c.Chaincode + "_" + c.Version
+}
+
+func (c *DeployConfig) policy() (*common.SignaturePolicyEnvelope, error) {
+	if c.Policy == "" {
+		return nil, nil
+	}
+	policy, err := policydsl.FromString(c.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %v", c.Policy, err)
+	}
+	return policy, nil
+}
+
+// 把链码目录(onchain-plugin/cross或bizcc)下的v2.2与公共的vendor、go.mod合并到dir，合并后可以直接以GOPATH方式构建。
+// 链码目录中已有的文件优先，与GOPATH下就近的vendor优先一致；v1.4专用的包不合并，见LEGACY_VENDOR
+func StageChaincode(chaincodePath, dir string) error {
+	if err := copyTree(filepath.Join(chaincodePath, CROSS_CHAINCODE_DIR), dir); err != nil {
+		return fmt.Errorf("failed to copy chaincode: %v", err)
+	}
+	if err := copyTree(filepath.Join(chaincodePath, "vendor"), filepath.Join(dir, "vendor"), LEGACY_VENDOR...); err != nil {
+		return fmt.Errorf("failed to copy vendor: %v", err)
+	}
+	if err := copyFile(filepath.Join(chaincodePath, "go.mod"), filepath.Join(dir, "go.mod")); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to copy go.mod: %v", err)
+	}
+	return nil
+}
+
+// 按StageChaincode合并后打包
+func PackageChaincode(chaincodePath, label string) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "cross-chaincode")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := StageChaincode(chaincodePath, dir); err != nil {
+		return nil, err
+	}
+	pkg, err := lcpackager.NewCCPackage(&lcpackager.Descriptor{Path: dir, Type: pb.ChaincodeSpec_GOLANG, Label: label})
+	if err != nil {
+		return nil, fmt.Errorf("failed to package chaincode: %v", err)
+	}
+	return pkg, nil
+}
+
+// 链码即服务的链码包，code.tar.gz中只有connection.json，与peer自带的ccaas外部builder的格式一致
+func PackageCCaaS(conf *CCaaSConfig, label string) ([]byte, error) {
+	pemField := func(value, path string) (string, error) {
+		if value != "" || path == "" {
+			return value, nil
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	}
+	conn := struct {
+		Address            string `json:"address"`
+		DialTimeout        string `json:"dial_timeout,omitempty"`
+		TLSRequired        bool   `json:"tls_required"`
+		ClientAuthRequired bool   `json:"client_auth_required"`
+		ClientKey          string `json:"client_key,omitempty"`
+		ClientCert         string `json:"client_cert,omitempty"`
+		RootCert           string `json:"root_cert,omitempty"`
+	}{Address: conf.Address, DialTimeout: conf.DialTimeout, TLSRequired: conf.TLSRequired, ClientAuthRequired: conf.ClientAuthRequired}
+	var err error
+	if conn.ClientKey, err = pemField(conf.ClientKey, conf.ClientKeyPath); err != nil {
+		return nil, fmt.Errorf("failed to read ccaas client key: %v", err)
+	}
+	if conn.ClientCert, err = pemField(conf.ClientCert, conf.ClientCertPath); err != nil {
+		return nil, fmt.Errorf("failed to read ccaas client cert: %v", err)
+	}
+	if conn.RootCert, err = pemField(conf.RootCert, conf.RootCertPath); err != nil {
+		return nil, fmt.Errorf("failed to read ccaas root cert: %v", err)
+	}
+	rawConn, _ := json.Marshal(&conn)
+	code, err := tarGz(map[string][]byte{"connection.json": rawConn})
+	if err != nil {
+		return nil, err
+	}
+	metadata, _ := json.Marshal(map[string]string{"type": "ccaas", "label": label})
+	return tarGz(map[string][]byte{"metadata.json": metadata, "code.tar.gz": code})
+}
+
+func tarGz(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 复制目录，已存在的文件保留，skip为相对src的路径
+func copyTree(src, dst string, skip ...string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		for _, s := range skip {
+			if rel != filepath.FromSlash(s) {
+				continue
+			}
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if err := copyFile(path, target); err != nil && !os.IsExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// 链码生命周期的操作，目标节点由调用方指定，测试中替换为内存实现
+type lifecycleClient interface {
+	Install(req resmgmt.LifecycleInstallCCRequest, peers []string) error
+	QueryInstalled(peer string) ([]resmgmt.LifecycleInstalledCC, error)
+	Approve(channel string, req resmgmt.LifecycleApproveCCRequest, peers []string) (fab.TransactionID, error)
+	QueryApproved(channel string, req resmgmt.LifecycleQueryApprovedCCRequest, peer string) (resmgmt.LifecycleApprovedChaincodeDefinition, error)
+	CheckCommitReadiness(channel string, req resmgmt.LifecycleCheckCCCommitReadinessRequest, peer string) (resmgmt.LifecycleCheckCCCommitReadinessResponse, error)
+	// 由服务发现选择各组织的背书节点
+	Commit(channel string, req resmgmt.LifecycleCommitCCRequest) (fab.TransactionID, error)
+	QueryCommitted(channel string, req resmgmt.LifecycleQueryCommittedCCRequest, peer string) ([]resmgmt.LifecycleChaincodeDefinition, error)
+}
+
+type sdkLifecycle struct {
+	client *resmgmt.Client
+}
+
+func (l *sdkLifecycle) Install(req resmgmt.LifecycleInstallCCRequest, peers []string) error {
+	_, err := l.client.LifecycleInstallCC(req, resmgmt.WithTargetEndpoints(peers...), resmgmt.WithRetry(retry.DefaultResMgmtOpts))
+	return err
+}
+
+func (l *sdkLifecycle) QueryInstalled(peer string) ([]resmgmt.LifecycleInstalledCC, error) {
+	return l.client.LifecycleQueryInstalledCC(resmgmt.WithTargetEndpoints(peer))
+}
+
+func (l *sdkLifecycle) Approve(channel string, req resmgmt.LifecycleApproveCCRequest, peers []string) (fab.TransactionID, error) {
+	return l.client.LifecycleApproveCC(channel, req, resmgmt.WithTargetEndpoints(peers...), resmgmt.WithRetry(retry.DefaultResMgmtOpts))
+}
+
+func (l *sdkLifecycle) QueryApproved(channel string, req resmgmt.LifecycleQueryApprovedCCRequest, peer string) (resmgmt.LifecycleApprovedChaincodeDefinition, error) {
+	return l.client.LifecycleQueryApprovedCC(channel, req, resmgmt.WithTargetEndpoints(peer))
+}
+
+func (l *sdkLifecycle) CheckCommitReadiness(channel string, req resmgmt.LifecycleCheckCCCommitReadinessRequest, peer string) (resmgmt.LifecycleCheckCCCommitReadinessResponse, error) {
+	return l.client.LifecycleCheckCCCommitReadiness(channel, req, resmgmt.WithTargetEndpoints(peer))
+}
+
+func (l *sdkLifecycle) Commit(channel string, req resmgmt.LifecycleCommitCCRequest) (fab.TransactionID, error) {
+	return l.client.LifecycleCommitCC(channel, req, resmgmt.WithRetry(retry.DefaultResMgmtOpts))
+}
+
+func (l *sdkLifecycle) QueryCommitted(channel string, req resmgmt.LifecycleQueryCommittedCCRequest, peer string) ([]resmgmt.LifecycleChaincodeDefinition, error) {
+	return l.client.LifecycleQueryCommittedCC(channel, req, resmgmt.WithTargetEndpoints(peer))
+}
+
+type Deployer struct {
+	conf      *DeployConfig
+	logger    hclog.Logger
+	lifecycle lifecycleClient
+	// 本组织的节点，查询使用第一个
+	peers []string
+	// 中继身份的通道客户端，初始化时创建
+	newClient func(*Config, hclog.Logger) (chainClient, error)
+	client    chainClient
+	close     func()
+}
+
+func NewDeployer(conf *DeployConfig, logger hclog.Logger) (*Deployer, error) {
+	profile, err := loadProfile(&conf.Config)
+	if err != nil {
+		return nil, err
+	}
+	sdk, err := fabsdk.New(config.FromRaw(profile, profileType(profile)), sdkOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fabric sdk: %v", err)
+	}
+	identity, s, err := newSigningIdentity(sdk, conf.Org, &conf.Admin)
+	if err != nil {
+		sdk.Close()
+		return nil, err
+	}
+	d := &Deployer{conf: conf, logger: logger, peers: conf.Peers, newClient: newSDKClient}
+	d.close = func() {
+		sdk.Close()
+		if s != nil {
+			signer.Close(s)
+		}
+	}
+	client, err := resmgmt.New(sdk.Context(fabsdk.WithIdentity(identity)))
+	if err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to create resource management client: %v", err)
+	}
+	d.lifecycle = &sdkLifecycle{client}
+	if len(d.peers) == 0 {
+		ctx, err := sdk.Context()()
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to create sdk context: %v", err)
+		}
+		d.peers = ctx.EndpointConfig().NetworkConfig().Organizations[strings.ToLower(conf.Org)].Peers
+	}
+	if len(d.peers) == 0 {
+		d.Close()
+		return nil, fmt.Errorf("no peers of org %s", conf.Org)
+	}
+	return d, nil
+}
+
+func (d *Deployer) Close() {
+	if d.client != nil {
+		d.client.Close()
+		d.client = nil
+	}
+	if d.close != nil {
+		d.close()
+		d.close = nil
+	}
+}
+
+// 在本组织的节点上安装链码包，已安装的节点跳过，返回包id
+func (d *Deployer) Install(pkg []byte) (string, error) {
+	label := d.conf.Label()
+	packageID := lcpackager.ComputePackageID(label, pkg)
+	var targets []string
+	for _, peer := range d.peers {
+		installed, err := d.lifecycle.QueryInstalled(peer)
+		if err != nil {
+			return "", fmt.Errorf("failed to query installed chaincodes of %s: %v", peer, err)
+		}
+		if findPackage(installed, func(cc resmgmt.LifecycleInstalledCC) bool { return cc.PackageID == packageID }) == nil {
+			targets = append(targets, peer)
+		}
+	}
+	if len(targets) == 0 {
+		d.logger.Info("chaincode package is installed already", "packageId", packageID)
+		return packageID, nil
+	}
+	d.logger.Info("install chaincode package", "packageId", packageID, "peers", targets)
+	if err := d.lifecycle.Install(resmgmt.LifecycleInstallCCRequest{Label: label, Package: pkg}, targets); err != nil {
+		return "", fmt.Errorf("failed to install chaincode package %s: %v", packageID, err)
+	}
+	return packageID, nil
+}
+
+// 按标签查找本组织节点上已安装的包，用于单独执行approve
+func (d *Deployer) InstalledPackage() (string, error) {
+	installed, err := d.lifecycle.QueryInstalled(d.peers[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to query installed chaincodes of %s: %v", d.peers[0], err)
+	}
+	var found []string
+	for _, cc := range installed {
+		if cc.Label == d.conf.Label() {
+			found = append(found, cc.PackageID)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("chaincode package %s is not installed on %s", d.conf.Label(), d.peers[0])
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("multiple chaincode packages labeled %s on %s: %s", d.conf.Label(), d.peers[0], strings.Join(found, ", "))
+}
+
+func findPackage(installed []resmgmt.LifecycleInstalledCC, match func(resmgmt.LifecycleInstalledCC) bool) *resmgmt.LifecycleInstalledCC {
+	for i := range installed {
+		if match(installed[i]) {
+			return &installed[i]
+		}
+	}
+	return nil
+}
+
+// 通道上已提交的链码定义，未提交时返回nil
+func (d *Deployer) committed() (*resmgmt.LifecycleChaincodeDefinition, error) {
+	defs, err := d.lifecycle.QueryCommitted(d.conf.Channel, resmgmt.LifecycleQueryCommittedCCRequest{Name: d.conf.Chaincode}, d.peers[0])
+	if err != nil {
+		// 链码未提交时节点返回namespace not defined
+		if strings.Contains(err.Error(), "is not defined") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query committed chaincode %s: %v", d.conf.Chaincode, err)
+	}
+	for i := range defs {
+		if defs[i].Name == d.conf.Chaincode {
+			return &defs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// 待批准和提交的链码定义序号
+func (d *Deployer) sequence() (int64, *resmgmt.LifecycleChaincodeDefinition, error) {
+	committed, err := d.committed()
+	if err != nil {
+		return 0, nil, err
+	}
+	switch {
+	case d.conf.Sequence > 0:
+		return d.conf.Sequence, committed, nil
+	case committed == nil:
+		return 1, nil, nil
+	case committed.Version == d.conf.Version:
+		return committed.Sequence, committed, nil
+	}
+	return committed.Sequence + 1, committed, nil
+}
+
+// 本组织批准链码定义，已批准同一个包时跳过
+func (d *Deployer) Approve(packageID string) error {
+	seq, _, err := d.sequence()
+	if err != nil {
+		return err
+	}
+	policy, _ := d.conf.policy()
+	approved, err := d.lifecycle.QueryApproved(d.conf.Channel, resmgmt.LifecycleQueryApprovedCCRequest{Name: d.conf.Chaincode, Sequence: seq}, d.peers[0])
+	if err == nil && approved.PackageID == packageID && approved.Version == d.conf.Version {
+		d.logger.Info("chaincode definition is approved already", "sequence", seq, "packageId", packageID)
+		return nil
+	}
+	d.logger.Info("approve chaincode definition", "version", d.conf.Version, "sequence", seq, "packageId", packageID)
+	txID, err := d.lifecycle.Approve(d.conf.Channel, resmgmt.LifecycleApproveCCRequest{
+		Name:            d.conf.Chaincode,
+		Version:         d.conf.Version,
+		PackageID:       packageID,
+		Sequence:        seq,
+		SignaturePolicy: policy,
+	}, d.peers)
+	if err != nil {
+		return fmt.Errorf("failed to approve chaincode %s sequence %d: %v", d.conf.Chaincode, seq, err)
+	}
+	d.logger.Info("chaincode definition approved", "txId", txID)
+	return nil
+}
+
+// 所有组织都批准后提交链码定义，已提交时跳过
+func (d *Deployer) Commit() error {
+	seq, committed, err := d.sequence()
+	if err != nil {
+		return err
+	}
+	if committed != nil && committed.Sequence == seq {
+		d.logger.Info("chaincode definition is committed already", "version", committed.Version, "sequence", seq)
+		return nil
+	}
+	policy, _ := d.conf.policy()
+	readiness, err := d.lifecycle.CheckCommitReadiness(d.conf.Channel, resmgmt.LifecycleCheckCCCommitReadinessRequest{
+		Name:            d.conf.Chaincode,
+		Version:         d.conf.Version,
+		Sequence:        seq,
+		SignaturePolicy: policy,
+	}, d.peers[0])
+	if err != nil {
+		return fmt.Errorf("failed to check commit readiness of chaincode %s: %v", d.conf.Chaincode, err)
+	}
+	if missing := unapproved(readiness.Approvals); len(missing) > 0 {
+		return fmt.Errorf("chaincode %s sequence %d is not approved by %s", d.conf.Chaincode, seq, strings.Join(missing, ", "))
+	}
+	d.logger.Info("commit chaincode definition", "version", d.conf.Version, "sequence", seq)
+	txID, err := d.lifecycle.Commit(d.conf.Channel, resmgmt.LifecycleCommitCCRequest{
+		Name:            d.conf.Chaincode,
+		Version:         d.conf.Version,
+		Sequence:        seq,
+		SignaturePolicy: policy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit chaincode %s sequence %d: %v", d.conf.Chaincode, seq, err)
+	}
+	d.logger.Info("chaincode definition committed", "txId", txID)
+	return nil
+}
+
+func unapproved(approvals map[string]bool) []string {
+	var missing []string
+	for msp, ok := range approvals {
+		if !ok {
+			missing = append(missing, msp)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (d *Deployer) chainClient() (chainClient, error) {
+	if d.client == nil {
+		client, err := d.newClient(&d.conf.Config, d.logger)
+		if err != nil {
+			return nil, err
+		}
+		d.client = client
+	}
+	return d.client, nil
+}
+
+// 跨链链码已设置管理员时hasNotSetAdmin返回错误
+func adminSet(client chainClient) (bool, error) {
+	_, err := client.Query(FN_HAS_NOT_SET_ADMIN)
+	if err == nil {
+		return false, nil
+	}
+	if strings.Contains(err.Error(), "set already") {
+		return true, nil
+	}
+	return false, fmt.Errorf("failed to query admin of cross chaincode: %v", err)
+}
+
+func invokeValid(client chainClient, fcn string, args ...string) error {
+	txID, code, err := client.Invoke(&txRequest{fcn: fcn, args: args})
+	if err != nil {
+		return err
+	}
+	if code != pb.TxValidationCode_VALID {
+		return fmt.Errorf("tx %s is %s", txID, code)
+	}
+	return nil
+}
+
+// 以中继身份初始化跨链链码：设置管理员、本链域名，登记业务链码
+func (d *Deployer) Wire() error {
+	client, err := d.chainClient()
+	if err != nil {
+		return err
+	}
+	set, err := adminSet(client)
+	if err != nil {
+		return err
+	}
+	if set {
+		d.logger.Info("admin of cross chaincode is set already")
+	} else {
+		id, err := loadIdentity(&d.conf.User)
+		if err != nil {
+			return err
+		}
+		d.logger.Info("set relayer as admin of cross chaincode")
+		if err := invokeValid(client, FN_SET_ADMIN, string(id.cert)); err != nil {
+			return fmt.Errorf("failed to set admin of cross chaincode: %v", err)
+		}
+	}
+	if d.conf.Domain != "" {
+		d.logger.Info("set local domain", "domain", d.conf.Domain)
+		if err := invokeValid(client, FN_ADMIN_MANAGE, FN_SET_EXPECTED_DOMAIN, d.conf.Domain); err != nil {
+			return fmt.Errorf("failed to set local domain %s: %v", d.conf.Domain, err)
+		}
+	}
+	for _, name := range d.conf.Receivers {
+		if receiverRegistered(client, name) {
+			continue
+		}
+		d.logger.Info("register receiver chaincode", "chaincode", name)
+		if err := invokeValid(client, FN_ADMIN_MANAGE, FN_REGISTER_SHA256_INVERT, name); err != nil {
+			return fmt.Errorf("failed to register receiver chaincode %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func receiverRegistered(client chainClient, name string) bool {
+	hash := sha256.Sum256([]byte(name))
+	image, err := client.Query(FN_ADMIN_MANAGE, FN_QUERY_SHA256_INVERT, hex.EncodeToString(hash[:]))
+	return err == nil && string(image) == name
+}
+
+// 确认链码定义已提交，中继是链码管理员，业务链码已登记。本链域名没有查询接口，不做检查
+func (d *Deployer) Verify() error {
+	committed, err := d.committed()
+	if err != nil {
+		return err
+	}
+	if committed == nil {
+		return fmt.Errorf("chaincode %s is not committed on channel %s", d.conf.Chaincode, d.conf.Channel)
+	}
+	if committed.Version != d.conf.Version {
+		return fmt.Errorf("committed version of chaincode %s is %s, expected %s", d.conf.Chaincode, committed.Version, d.conf.Version)
+	}
+	if missing := unapproved(committed.Approvals); len(missing) > 0 {
+		d.logger.Warn("chaincode definition is not approved by all orgs", "orgs", missing)
+	}
+	client, err := d.chainClient()
+	if err != nil {
+		return err
+	}
+	set, err := adminSet(client)
+	if err != nil {
+		return err
+	}
+	if !set {
+		return fmt.Errorf("admin of cross chaincode is not set")
+	}
+	if _, err := client.Query(FN_ADMIN_MANAGE, FN_CHECK_ADMIN); err != nil {
+		return fmt.Errorf("relayer is not admin of cross chaincode: %v", err)
+	}
+	for _, name := range d.conf.Receivers {
+		if !receiverRegistered(client, name) {
+			return fmt.Errorf("receiver chaincode %s is not registered", name)
+		}
+	}
+	d.logger.Info("cross chaincode is ready", "chaincode", d.conf.Chaincode, "version", committed.Version, "sequence", committed.Sequence)
+	return nil
+}
@@ -0,0 +1,379 @@
+package fabric
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// 内存中的链码生命周期，两个组织，Org2是否批准由org2设置
+type fakeLifecycle struct {
+	installed map[string][]resmgmt.LifecycleInstalledCC
+	approved  map[int64]resmgmt.LifecycleApproveCCRequest
+	committed *resmgmt.LifecycleChaincodeDefinition
+	org2      bool
+	calls     []string
+}
+
+func newFakeLifecycle() *fakeLifecycle {
+	return &fakeLifecycle{installed: map[string][]resmgmt.LifecycleInstalledCC{}, approved: map[int64]resmgmt.LifecycleApproveCCRequest{}}
+}
+
+func testPackageID(label string, pkg []byte) string {
+	return fmt.Sprintf("%s:%x", label, sha256.Sum256(pkg))
+}
+
+func (l *fakeLifecycle) Install(req resmgmt.LifecycleInstallCCRequest, peers []string) error {
+	l.calls = append(l.calls, "install "+strings.Join(peers, ","))
+	for _, peer := range peers {
+		l.installed[peer] = append(l.installed[peer], resmgmt.LifecycleInstalledCC{PackageID: testPackageID(req.Label, req.Package), Label: req.Label})
+	}
+	return nil
+}
+
+func (l *fakeLifecycle) QueryInstalled(peer string) ([]resmgmt.LifecycleInstalledCC, error) {
+	return l.installed[peer], nil
+}
+
+func (l *fakeLifecycle) Approve(channel string, req resmgmt.LifecycleApproveCCRequest, peers []string) (fab.TransactionID, error) {
+	l.calls = append(l.calls, fmt.Sprintf("approve %d", req.Sequence))
+	l.approved[req.Sequence] = req
+	return "approveTx", nil
+}
+
+func (l *fakeLifecycle) QueryApproved(channel string, req resmgmt.LifecycleQueryApprovedCCRequest, peer string) (resmgmt.LifecycleApprovedChaincodeDefinition, error) {
+	approved, ok := l.approved[req.Sequence]
+	if !ok {
+		return resmgmt.LifecycleApprovedChaincodeDefinition{}, fmt.Errorf("could not fetch approved chaincode definition")
+	}
+	return resmgmt.LifecycleApprovedChaincodeDefinition{Name: approved.Name, Version: approved.Version, Sequence: approved.Sequence, PackageID: approved.PackageID}, nil
+}
+
+func (l *fakeLifecycle) approvals(seq int64, version string, policy *common.SignaturePolicyEnvelope) map[string]bool {
+	approved, ok := l.approved[seq]
+	return map[string]bool{
+		"Org1MSP": ok && approved.Version == version && reflect.DeepEqual(approved.SignaturePolicy, policy),
+		"Org2MSP": l.org2,
+	}
+}
+
+func (l *fakeLifecycle) CheckCommitReadiness(channel string, req resmgmt.LifecycleCheckCCCommitReadinessRequest, peer string) (resmgmt.LifecycleCheckCCCommitReadinessResponse, error) {
+	return resmgmt.LifecycleCheckCCCommitReadinessResponse{Approvals: l.approvals(req.Sequence, req.Version, req.SignaturePolicy)}, nil
+}
+
+func (l *fakeLifecycle) Commit(channel string, req resmgmt.LifecycleCommitCCRequest) (fab.TransactionID, error) {
+	l.calls = append(l.calls, fmt.Sprintf("commit %d", req.Sequence))
+	l.committed = &resmgmt.LifecycleChaincodeDefinition{Name: req.Name, Version: req.Version, Sequence: req.Sequence,
+		Approvals: l.approvals(req.Sequence, req.Version, req.SignaturePolicy)}
+	return "commitTx", nil
+}
+
+func (l *fakeLifecycle) QueryCommitted(channel string, req resmgmt.LifecycleQueryCommittedCCRequest, peer string) ([]resmgmt.LifecycleChaincodeDefinition, error) {
+	if l.committed == nil {
+		return nil, fmt.Errorf("namespace %s is not defined", req.Name)
+	}
+	return []resmgmt.LifecycleChaincodeDefinition{*l.committed}, nil
+}
+
+// 记录管理员的跨链链码
+type adminChain struct {
+	*fakeChain
+	admin string
+}
+
+func (c *adminChain) Query(fcn string, args ...string) ([]byte, error) {
+	switch {
+	case fcn == FN_HAS_NOT_SET_ADMIN && c.admin == "":
+		return []byte("yes"), nil
+	case fcn == FN_HAS_NOT_SET_ADMIN:
+		return nil, fmt.Errorf("oracle chaincode is set already")
+	case fcn == FN_ADMIN_MANAGE && args[0] == FN_CHECK_ADMIN && c.admin != testRelayerCert:
+		return nil, fmt.Errorf("current user is not oracle service admin")
+	case fcn == FN_ADMIN_MANAGE && args[0] == FN_CHECK_ADMIN:
+		return nil, nil
+	}
+	return c.fakeChain.Query(fcn, args...)
+}
+
+func (c *adminChain) Invoke(req *txRequest) (string, pb.TxValidationCode, error) {
+	if req.fcn == FN_SET_ADMIN {
+		c.admin = req.args[0]
+		c.invokes = append(c.invokes, append([]string{req.fcn}, req.args...))
+		return "adminTx", pb.TxValidationCode_VALID, nil
+	}
+	return c.fakeChain.Invoke(req)
+}
+
+const testRelayerCert = "-----BEGIN CERTIFICATE-----\nrelayer\n-----END CERTIFICATE-----\n"
+
+func testDeployer(lifecycle *fakeLifecycle, chain *adminChain) *Deployer {
+	conf := &DeployConfig{
+		Config:  Config{Channel: "mychannel", Chaincode: "cross", Org: "Org1", User: UserConfig{Cert: testRelayerCert}, Receivers: []string{"bizcc"}},
+		Version: "1.0",
+		Domain:  "fabric.test",
+	}
+	return &Deployer{
+		conf:      conf,
+		logger:    hclog.NewNullLogger(),
+		lifecycle: lifecycle,
+		peers:     []string{"peer0.org1", "peer1.org1"},
+		newClient: func(*Config, hclog.Logger) (chainClient, error) { return chain, nil },
+	}
+}
+
+func TestDeployer(t *testing.T) {
+	lifecycle := newFakeLifecycle()
+	chain := &adminChain{fakeChain: newFakeChain()}
+	d := testDeployer(lifecycle, chain)
+
+	pkg := []byte("package")
+	lifecycle.installed["peer1.org1"] = []resmgmt.LifecycleInstalledCC{{PackageID: testPackageID("cross_1.0", pkg), Label: "cross_1.0"}}
+	packageID, err := d.Install(pkg)
+	if err != nil || packageID != testPackageID("cross_1.0", pkg) {
+		t.Fatalf("unexpected package id %s: %v", packageID, err)
+	}
+	if found, err := d.InstalledPackage(); err != nil || found != packageID {
+		t.Fatalf("unexpected installed package %s: %v", found, err)
+	}
+	if err := d.Approve(packageID); err != nil {
+		t.Fatal(err)
+	}
+	// Org2未批准
+	if err := d.Commit(); err == nil || !strings.Contains(err.Error(), "not approved by Org2MSP") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Verify(); err == nil || !strings.Contains(err.Error(), "not committed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lifecycle.org2 = true
+	if err := d.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Verify(); err == nil || !strings.Contains(err.Error(), "admin of cross chaincode is not set") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Wire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{FN_SET_ADMIN, testRelayerCert},
+		{FN_ADMIN_MANAGE, FN_SET_EXPECTED_DOMAIN, "fabric.test"},
+		{FN_ADMIN_MANAGE, FN_REGISTER_SHA256_INVERT, "bizcc"},
+	}
+	if !reflect.DeepEqual(chain.invokes, expected) {
+		t.Fatalf("unexpected invokes: %v", chain.invokes)
+	}
+
+	// 重新执行时跳过已完成的步骤，域名总是重新设置
+	if _, err := d.Install(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Approve(packageID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Wire(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := strings.Join(lifecycle.calls, "; "); calls != "install peer0.org1; approve 1; commit 1" {
+		t.Fatalf("unexpected lifecycle calls: %s", calls)
+	}
+	if len(chain.invokes) != 4 {
+		t.Fatalf("unexpected invokes: %v", chain.invokes)
+	}
+
+	// 升级版本时序号加一
+	d.conf.Version = "1.1"
+	if err := d.Approve(packageID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if lifecycle.committed.Sequence != 2 || lifecycle.committed.Version != "1.1" {
+		t.Fatalf("unexpected committed definition: %+v", lifecycle.committed)
+	}
+	if _, err := d.InstalledPackage(); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 管理员是其他身份
+	chain.admin = "other"
+	if err := d.Verify(); err == nil || !strings.Contains(err.Error(), "relayer is not admin") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseDeployConfig(t *testing.T) {
+	base := `"connectionProfile":"p","channel":"mychannel","chaincode":"cross","org":"Org1","user":{"cert":"c"}`
+	conf, err := ParseDeployConfig([]byte(`{` + base + `,"admin":{"certPath":"admin.pem"},"version":"1.0","policy":"OR('Org1MSP.peer')","peers":["peer0"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Channel != "mychannel" || conf.Admin.CertPath != "admin.pem" || conf.Label() != "cross_1.0" || !reflect.DeepEqual(conf.Peers, []string{"peer0"}) {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+	for _, c := range []struct {
+		raw string
+		err string
+	}{
+		{`{"admin":{"cert":"c"},"version":"1.0"}`, "connectionProfile"},
+		{`{` + base + `,"admin":{"cert":"c"}}`, "version is required"},
+		{`{` + base + `,"version":"1.0"}`, "invalid admin"},
+		{`{` + base + `,"admin":{"cert":"c"},"version":"1.0","sequence":-1}`, "invalid sequence"},
+		{`{` + base + `,"admin":{"cert":"c"},"version":"1.0","policy":"ANY('Org1MSP.peer')"}`, "invalid policy"},
+	} {
+		if _, err := ParseDeployConfig([]byte(c.raw)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%s: unexpected error: %v", c.raw, err)
+		}
+	}
+}
+
+func TestPackageChaincode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cross")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for path, content := range map[string]string{
+		"go.mod":                  "module cross\n\ngo 1.16\n",
+		"v2.2/go.mod":             "module cross\n\ngo 1.15\n",
+		"v2.2/main.go":            "package main\n\nimport \"am\"\n\nfunc main() { am.Run() }\n",
+		"v2.2/vendor/tlv/tlv.go":  "package tlv\n",
+		"vendor/am/am.go":         "package am\n\nfunc Run() {}\n",
+		"vendor/tlv/tlv.go":       "package tlv\n\nconst V = 1\n",
+		"vendor/unused/unused.go": "package unused\n",
+		// v1.4专用的包不合并
+		"vendor/github.com/hyperledger/fabric/core/chaincode/shim/shim.go": "package shim\n",
+		"vendor/wrapstub/wrapstub.go":                                      "package wrapstub\n",
+		"vendor/wrapstub/v2.2/wrapstub.go":                                 "package wrapstub\n",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := PackageChaincode(dir, "cross_1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := untar(t, pkg)
+	if string(files["metadata.json"]) != `{"path":"cross","type":"GOLANG","label":"cross_1.0"}` {
+		t.Fatalf("unexpected metadata %s", files["metadata.json"])
+	}
+	code := untar(t, files["code.tar.gz"])
+	// 链码目录中已有的文件优先，公共的vendor补充缺少的包
+	if string(code["src/go.mod"]) != "module cross\n\ngo 1.15\n" || string(code["src/vendor/tlv/tlv.go"]) != "package tlv\n" || code["src/vendor/am/am.go"] == nil || code["src/main.go"] == nil {
+		var names []string
+		for name := range code {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Fatalf("unexpected code package: %v", names)
+	}
+	if code["src/vendor/github.com/hyperledger/fabric/core/chaincode/shim/shim.go"] != nil || code["src/vendor/wrapstub/wrapstub.go"] != nil || code["src/vendor/wrapstub/v2.2/wrapstub.go"] == nil {
+		t.Fatal("legacy vendor packaged")
+	}
+
+	// 合并后的目录与链码包的内容一致
+	staged := filepath.Join(dir, "staged")
+	if err := StageChaincode(dir, staged); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range code {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if raw, err := ioutil.ReadFile(filepath.Join(staged, strings.TrimPrefix(name, "src/"))); err != nil || !bytes.Equal(raw, content) {
+			t.Fatalf("unexpected staged file %s: %v", name, err)
+		}
+	}
+
+	if _, err := PackageChaincode(filepath.Join(dir, "missing"), "cross_1.0"); err == nil {
+		t.Fatal("missing chaincode packaged")
+	}
+}
+
+func TestPackageCCaaS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ccaas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootPath := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(rootPath, []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &CCaaSConfig{Address: "cross:9999", DialTimeout: "10s", TLSRequired: true, ClientAuthRequired: true, ClientKey: "key", ClientCert: "cert", RootCertPath: rootPath}
+	if err := conf.validate(); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := PackageCCaaS(conf, "cross_1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := untar(t, pkg)
+	if string(files["metadata.json"]) != `{"label":"cross_1.0","type":"ccaas"}` {
+		t.Fatalf("unexpected metadata %s", files["metadata.json"])
+	}
+	code := untar(t, files["code.tar.gz"])
+	expected := `{"address":"cross:9999","dial_timeout":"10s","tls_required":true,"client_auth_required":true,"client_key":"key","client_cert":"cert","root_cert":"root"}`
+	if len(code) != 1 || string(code["connection.json"]) != expected {
+		t.Fatalf("unexpected connection %s", code["connection.json"])
+	}
+
+	conf.RootCertPath = filepath.Join(dir, "missing")
+	if _, err := PackageCCaaS(conf, "cross_1.0"); err == nil {
+		t.Fatal("missing root cert packaged")
+	}
+	for _, c := range []*CCaaSConfig{
+		{},
+		{Address: "cross:9999", TLSRequired: true},
+		{Address: "cross:9999", ClientAuthRequired: true},
+		{Address: "cross:9999", TLSRequired: true, RootCert: "root", ClientAuthRequired: true, ClientKey: "key"},
+	} {
+		if err := c.validate(); err == nil {
+			t.Fatalf("%+v should be invalid", c)
+		}
+	}
+}
+
+func untar(t *testing.T, raw []byte) map[string][]byte {
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[h.Name], _ = ioutil.ReadAll(tr)
+	}
+}
@@ -0,0 +1,67 @@
+package fabric
+
+import (
+	"regexp"
+	"strconv"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 跨链链码返回的错误码，与链上crosserr一致
+// 链码的错误信息格式为 [方法名] E<错误码> <错误类型>: <详细信息>，背书失败时SDK返回的错误中带有该信息。
+// 中继按回执的errorCode和retryable判断是否重新提交：可重试的错误稍后重新提交同一报文可能成功，
+// 其余错误重新提交的结果不变。
+var chaincodeErrorSymbols = map[uint32]string{
+	1001: "ERR_INVALID_ARGS",
+	1002: "ERR_UNAUTHORIZED",
+	1003: "ERR_NOT_FOUND",
+	1004: "ERR_CONFLICT",
+	1005: "ERR_LEDGER",
+	1006: "ERR_PROOF_INVALID",
+	1007: "ERR_SEQ_MISMATCH",
+	1008: "ERR_PAUSED",
+	1009: "ERR_INSUFFICIENT_BALANCE",
+	1010: "ERR_RATE_LIMITED",
+	1011: "ERR_CALLBACK_FAILED",
+	1012: "ERR_SEQ_AHEAD",
+	1013: "ERR_EXECUTION_LIMIT",
+	1099: "ERR_INTERNAL",
+}
+
+var retryableChaincodeErrors = map[string]bool{
+	"ERR_LEDGER":               true,
+	"ERR_PAUSED":               true,
+	"ERR_INSUFFICIENT_BALANCE": true,
+	"ERR_RATE_LIMITED":         true,
+	"ERR_SEQ_AHEAD":            true,
+}
+
+// SDK的错误信息中链码的错误信息前还有背书节点等内容，不要求从开头匹配
+var chaincodeErrorPattern = regexp.MustCompile(`\bE(\d{4}) [a-z_]+: `)
+
+// 解析错误信息中的链码错误码，返回符号名和是否可以重试；没有错误码时返回空串
+func parseChaincodeError(msg string) (string, bool) {
+	m := chaincodeErrorPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return "", false
+	}
+	code, _ := strconv.ParseUint(m[1], 10, 32)
+	symbol, ok := chaincodeErrorSymbols[uint32(code)]
+	if !ok {
+		symbol = chaincodeErrorSymbols[1099]
+	}
+	return symbol, retryableChaincodeErrors[symbol]
+}
+
+// 按发送交易的错误或交易校验码填写回执的错误信息
+func setReceiptError(receipt *bbc.CrossChainMessageReceipt, err error, code pb.TxValidationCode) {
+	if err != nil {
+		receipt.ErrorMsg = err.Error()
+		receipt.ErrorCode, receipt.Retryable = parseChaincodeError(receipt.ErrorMsg)
+		return
+	}
+	receipt.ErrorMsg = code.String()
+	receipt.Retryable = RETRYABLE_VALIDATION_CODES[code]
+}
@@ -0,0 +1,24 @@
+package fabric
+
+import (
+	"testing"
+)
+
+func TestParseChaincodeError(t *testing.T) {
+	for _, c := range []struct {
+		msg       string
+		symbol    string
+		retryable bool
+	}{
+		{"Transaction processing for endorser [peer0:7051]: Chaincode status Code: (500) UNKNOWN. Description: [recvMessage] E1012 sequence_ahead: Process AM message failed: recv seq no[2] does not match expected seq no [1]", "ERR_SEQ_AHEAD", true},
+		{"[recvMessage] E1007 sequence: out-of-order message", "ERR_SEQ_MISMATCH", false},
+		{"E1006 verify: endorsement verify failed", "ERR_PROOF_INVALID", false},
+		{"[recvMessage] E1010 rate_limited: retry after 10", "ERR_RATE_LIMITED", true},
+		{"[recvMessage] E1234 unknown: new code", "ERR_INTERNAL", false},
+		{"connection refused", "", false},
+	} {
+		if symbol, retryable := parseChaincodeError(c.msg); symbol != c.symbol || retryable != c.retryable {
+			t.Fatalf("%s: expected %s %v, got %s %v", c.msg, c.symbol, c.retryable, symbol, retryable)
+		}
+	}
+}
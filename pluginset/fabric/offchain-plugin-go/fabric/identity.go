@@ -0,0 +1,165 @@
+package fabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+// 使用signer.Signer的提交身份
+// SDK对提案、交易和deliver请求的签名都通过SigningManager.Sign(消息, ctx.PrivateKey())完成，
+// 身份的私钥为signerKey时由Signer签名，其它身份仍由SDK的cryptosuite签名。Fabric的MSP只支持ECDSA P-256。
+
+func sdkOptions() []fabsdk.Option {
+	return []fabsdk.Option{fabsdk.WithCorePkg(&signerCoreFactory{coreFactory()})}
+}
+
+type signerCoreFactory struct {
+	api.CoreProviderFactory
+}
+
+func (f *signerCoreFactory) CreateSigningManager(cryptoProvider core.CryptoSuite) (core.SigningManager, error) {
+	next, err := f.CoreProviderFactory.CreateSigningManager(cryptoProvider)
+	if err != nil {
+		return nil, err
+	}
+	return &signingManager{next: next}, nil
+}
+
+type signingManager struct {
+	next core.SigningManager
+}
+
+func (m *signingManager) Sign(object []byte, key core.Key) ([]byte, error) {
+	k, ok := key.(*signerKey)
+	if !ok {
+		return m.next.Sign(object, key)
+	}
+	if len(object) == 0 {
+		return nil, fmt.Errorf("signing object is empty")
+	}
+	return signer.Sign(k.signer, object)
+}
+
+// Signer的私钥，不可导出
+type signerKey struct {
+	signer signer.Signer
+	pub    *ecdsa.PublicKey
+}
+
+func (k *signerKey) Bytes() ([]byte, error) {
+	return nil, fmt.Errorf("private key of signer is not exportable")
+}
+
+// 与BCCSP一致，为未压缩公钥的SHA-256
+func (k *signerKey) SKI() []byte {
+	sum := sha256.Sum256(elliptic.Marshal(k.pub.Curve, k.pub.X, k.pub.Y))
+	return sum[:]
+}
+
+func (k *signerKey) Symmetric() bool {
+	return false
+}
+
+func (k *signerKey) Private() bool {
+	return true
+}
+
+func (k *signerKey) PublicKey() (core.Key, error) {
+	return &signerPublicKey{k}, nil
+}
+
+type signerPublicKey struct {
+	*signerKey
+}
+
+func (k *signerPublicKey) Bytes() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(k.pub)
+}
+
+func (k *signerPublicKey) Private() bool {
+	return false
+}
+
+func (k *signerPublicKey) PublicKey() (core.Key, error) {
+	return k, nil
+}
+
+type signerIdentity struct {
+	mspID string
+	// 证书的CN
+	id   string
+	cert []byte
+	key  *signerKey
+}
+
+var _ msp.SigningIdentity = (*signerIdentity)(nil)
+
+// 证书的公钥须与Signer的公钥一致
+func newSignerIdentity(sdk *fabsdk.FabricSDK, org string, cert []byte, s signer.Signer) (*signerIdentity, error) {
+	pub, ok := s.Public().(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("fabric signer must be ECDSA P-256, got %T", s.Public())
+	}
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode user cert")
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user cert: %v", err)
+	}
+	if !signer.SamePublicKey(parsed.PublicKey, pub) {
+		return nil, fmt.Errorf("public key of user cert mismatches the signer")
+	}
+	ctx, err := sdk.Context()()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sdk context: %v", err)
+	}
+	orgConfig, ok := ctx.EndpointConfig().NetworkConfig().Organizations[strings.ToLower(org)]
+	if !ok || orgConfig.MSPID == "" {
+		return nil, fmt.Errorf("msp id of org %s not found in connection profile", org)
+	}
+	return &signerIdentity{mspID: orgConfig.MSPID, id: parsed.Subject.CommonName, cert: cert, key: &signerKey{signer: s, pub: pub}}, nil
+}
+
+func (id *signerIdentity) Identifier() *msp.IdentityIdentifier {
+	return &msp.IdentityIdentifier{MSPID: id.mspID, ID: id.id}
+}
+
+func (id *signerIdentity) Verify(msg []byte, sig []byte) error {
+	return signer.Verify(id.key.pub, msg, sig)
+}
+
+func (id *signerIdentity) Serialize() ([]byte, error) {
+	return proto.Marshal(&mspproto.SerializedIdentity{Mspid: id.mspID, IdBytes: id.cert})
+}
+
+func (id *signerIdentity) EnrollmentCertificate() []byte {
+	return id.cert
+}
+
+func (id *signerIdentity) Sign(msg []byte) ([]byte, error) {
+	return signer.Sign(id.key.signer, msg)
+}
+
+func (id *signerIdentity) PublicVersion() msp.Identity {
+	return id
+}
+
+func (id *signerIdentity) PrivateKey() core.Key {
+	return id.key
+}
@@ -0,0 +1,97 @@
+package fabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+const testSignerProfile = `
+version: 1.0.0
+client:
+  organization: Org1
+organizations:
+  Org1:
+    mspid: Org1MSP
+    cryptoPath: /tmp/msp
+`
+
+func testCert(t *testing.T, s signer.Signer) []byte {
+	// 证书由另一把密钥签发，只需要公钥与Signer一致
+	ca, _ := ecdsa.GenerateKey(s.Public().(*ecdsa.PublicKey).Curve, rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "relayer@org1.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, s.Public(), ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestSignerIdentity(t *testing.T) {
+	sdk, err := fabsdk.New(config.FromRaw([]byte(testSignerProfile), "yaml"), sdkOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdk.Close()
+
+	key, _ := signer.Generate(signer.ECDSA_P256_SHA256)
+	cert := testCert(t, key)
+	id, err := newSignerIdentity(sdk, "Org1", cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Identifier().MSPID != "Org1MSP" || id.Identifier().ID != "relayer@org1.example.com" {
+		t.Fatalf("unexpected identifier: %+v", id.Identifier())
+	}
+	raw, err := id.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serialized mspproto.SerializedIdentity
+	if err := proto.Unmarshal(raw, &serialized); err != nil || serialized.Mspid != "Org1MSP" || string(serialized.IdBytes) != string(cert) {
+		t.Fatalf("unexpected serialized identity: %+v %v", serialized, err)
+	}
+
+	// SDK的SigningManager由Signer签名
+	ctx, err := sdk.Context(fabsdk.WithIdentity(id))()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ctx.SigningManager().Sign([]byte("proposal"), ctx.PrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := id.Verify([]byte("proposal"), sig); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := signer.Generate(signer.ECDSA_P256_SHA256)
+	if _, err := newSignerIdentity(sdk, "Org1", cert, other); err == nil || !strings.Contains(err.Error(), "mismatches") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := newSignerIdentity(sdk, "Org2", cert, key); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sm2, _ := signer.Generate(signer.SM2_SM3)
+	if _, err := newSignerIdentity(sdk, "Org1", cert, sm2); err == nil || !strings.Contains(err.Error(), "P-256") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
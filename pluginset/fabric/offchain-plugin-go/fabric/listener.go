@@ -0,0 +1,290 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 区块监听
+// 通过peer的deliver服务从checkpoint之后的区块开始订阅，逐块提取AM消息和跨链链码的事件并保存，
+// 每处理完一个区块推进checkpoint。插件重启后从checkpoint之后重放，停机期间的区块不会遗漏。
+// 订阅中断时按退避间隔重新订阅；收到的区块不连续时通过账本查询补齐缺失的区块。
+// 订阅前落后通道高度较多时先用追块流水线并发处理，见pipeline.go。
+
+// 默认关注的事件: v1的发送消息事件和v2的发送、接收、回执事件
+var DEFAULT_EVENT_NAMES = []string{"crosschain_outbound", "SENT_MESSAGE", "RECEIVED_MESSAGE", "ACKED_MESSAGE"}
+
+const (
+	MIN_RESUBSCRIBE_INTERVAL = time.Second
+	MAX_RESUBSCRIBE_INTERVAL = 30 * time.Second
+)
+
+type ListenerConfig struct {
+	// 保存checkpoint和区块记录的目录
+	Dir string `json:"dir"`
+	// 没有checkpoint时从该高度开始
+	StartHeight uint64 `json:"startHeight"`
+	// 关注的事件名，为空时使用DEFAULT_EVENT_NAMES
+	EventNames []string `json:"eventNames"`
+	// 追块流水线，未配置时使用默认值
+	Pipeline *PipelineConfig `json:"pipeline,omitempty"`
+}
+
+type Listener struct {
+	client    chainClient
+	chaincode string
+	names     map[string]bool
+	store     *checkpointStore
+	logger    hclog.Logger
+	pipeline  PipelineConfig
+	// 追块时已处理但还没有写入checkpoint的区块数，只在处理区块的goroutine中访问
+	unsaved int
+
+	mu      sync.Mutex
+	onEvent func(*ChaincodeEvent)
+	height  uint64
+	has     bool
+	// 第一个处理的区块
+	start uint64
+	// 已知的通道最新区块，用于计算落后的区块数
+	head uint64
+}
+
+func newListener(client chainClient, chaincode string, conf *ListenerConfig, logger hclog.Logger) (*Listener, error) {
+	if conf.Dir == "" {
+		return nil, fmt.Errorf("listener dir is required")
+	}
+	store, err := openCheckpointStore(conf.Dir)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := store.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	names := conf.EventNames
+	if len(names) == 0 {
+		names = DEFAULT_EVENT_NAMES
+	}
+	l := &Listener{
+		client:    client,
+		chaincode: chaincode,
+		names:     make(map[string]bool),
+		store:     store,
+		logger:    logger,
+		start:     conf.StartHeight,
+	}
+	if conf.Pipeline != nil {
+		l.pipeline = *conf.Pipeline
+	}
+	l.pipeline = l.pipeline.withDefaults()
+	if cp != nil {
+		l.height, l.has, l.start = cp.Height, true, cp.Start
+	}
+	for _, name := range names {
+		l.names[name] = true
+	}
+	return l, nil
+}
+
+// 设置事件回调，新处理的区块中有关注的事件时调用，重放时已处理过的区块不会重复回调
+func (l *Listener) OnEvent(fn func(*ChaincodeEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEvent = fn
+}
+
+func (l *Listener) eventHandler() func(*ChaincodeEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.onEvent
+}
+
+// 已处理的最高区块
+func (l *Listener) Checkpoint() (uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.height, l.has
+}
+
+// 下一个要处理的区块
+func (l *Listener) next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.has {
+		return l.start
+	}
+	return l.height + 1
+}
+
+// 已处理区块的记录，height超过checkpoint或早于第一个处理的区块时返回false
+func (l *Listener) Record(height uint64) (*BlockRecord, bool, error) {
+	l.mu.Lock()
+	processed := l.has && height <= l.height && height >= l.start
+	l.mu.Unlock()
+	if !processed {
+		return nil, false, nil
+	}
+	record, err := l.store.load(height)
+	if err != nil {
+		return nil, false, err
+	}
+	return record, true, nil
+}
+
+// 已处理区块中的AM消息
+func (l *Listener) Messages(height uint64) ([]*bbc.CrossChainMessage, bool, error) {
+	record, ok, err := l.Record(height)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return record.Messages, true, nil
+}
+
+func (l *Listener) process(block *common.Block) error {
+	record, err := readBlock(block, l.chaincode, l.names)
+	if err != nil {
+		return err
+	}
+	return l.commit(record, false)
+}
+
+// 保存区块记录并推进checkpoint，lazy时没有内容的区块攒够CATCH_UP_CHECKPOINT_INTERVAL个再写checkpoint
+func (l *Listener) commit(record *BlockRecord, lazy bool) error {
+	if err := l.store.saveRecord(record); err != nil {
+		return err
+	}
+	if lazy && len(record.Messages) == 0 && len(record.Events) == 0 && l.unsaved+1 < CATCH_UP_CHECKPOINT_INTERVAL {
+		l.unsaved++
+	} else {
+		if err := l.store.saveCheckpoint(record.Height, l.start); err != nil {
+			return err
+		}
+		l.unsaved = 0
+	}
+	l.mu.Lock()
+	l.height, l.has = record.Height, true
+	onEvent := l.onEvent
+	l.mu.Unlock()
+	l.observeHead(record.Height)
+	listenerLastProcessed.SetToCurrentTime()
+	for _, e := range record.Events {
+		listenerEvents.WithLabelValues(e.EventName).Inc()
+	}
+	if len(record.Messages) > 0 || len(record.Events) > 0 {
+		l.logger.Debug("block processed", "height", record.Height, "messages", len(record.Messages), "events", len(record.Events),
+			"traceIds", messageTraceIDs(record.Messages))
+	}
+	if onEvent != nil {
+		for _, e := range record.Events {
+			onEvent(e)
+		}
+	}
+	return nil
+}
+
+// 记录通道最新区块，更新checkpoint和落后区块数的指标
+func (l *Listener) observeHead(head uint64) {
+	l.mu.Lock()
+	if head > l.head {
+		l.head = head
+	}
+	head, height := l.head, l.height
+	l.mu.Unlock()
+	chainHeight.Set(float64(head))
+	listenerCheckpoint.Set(float64(height))
+	listenerLag.Set(float64(head - height))
+}
+
+// 写入尚未保存的checkpoint
+func (l *Listener) flushCheckpoint() error {
+	if l.unsaved == 0 {
+		return nil
+	}
+	height, _ := l.Checkpoint()
+	if err := l.store.saveCheckpoint(height, l.start); err != nil {
+		return err
+	}
+	l.unsaved = 0
+	return nil
+}
+
+// 订阅一次，直到ctx结束或订阅中断
+func (l *Listener) subscribe(ctx context.Context) error {
+	if err := l.catchUp(ctx); err != nil || ctx.Err() != nil {
+		return err
+	}
+	next := l.next()
+	blocks, cancel, err := l.client.BlockEvents(next)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe from block %d: %v", next, err)
+	}
+	defer cancel()
+	l.logger.Info("subscribed to block events", "from", next)
+	for {
+		var block *common.Block
+		select {
+		case <-ctx.Done():
+			return nil
+		case b, ok := <-blocks:
+			if !ok {
+				return fmt.Errorf("block events closed")
+			}
+			block = b
+		}
+		if block.Header == nil {
+			return fmt.Errorf("block without header")
+		}
+		number := block.Header.Number
+		l.observeHead(number)
+		if number < next {
+			continue
+		}
+		// 补齐缺失的区块
+		for ; next < number; next++ {
+			missing, err := l.client.BlockByNumber(next)
+			if err != nil {
+				return fmt.Errorf("failed to query missing block %d: %v", next, err)
+			}
+			if err := l.process(missing); err != nil {
+				return err
+			}
+		}
+		if err := l.process(block); err != nil {
+			return err
+		}
+		next = number + 1
+	}
+}
+
+// 持续监听直到ctx结束
+func (l *Listener) Run(ctx context.Context) {
+	interval := MIN_RESUBSCRIBE_INTERVAL
+	for {
+		start := time.Now()
+		err := l.subscribe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// 订阅持续了一段时间后中断的，从最短间隔重新开始退避
+		if time.Since(start) > MAX_RESUBSCRIBE_INTERVAL {
+			interval = MIN_RESUBSCRIBE_INTERVAL
+		}
+		l.logger.Warn("block subscription interrupted, resubscribe later", "error", err, "after", interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > MAX_RESUBSCRIBE_INTERVAL {
+			interval = MAX_RESUBSCRIBE_INTERVAL
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package fabric
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 等待监听处理到指定高度
+func waitCheckpoint(t *testing.T, l *Listener, height uint64) {
+	for i := 0; i < 200; i++ {
+		if h, ok := l.Checkpoint(); ok && h >= height {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for block %d", height)
+}
+
+// 带一个链码事件的区块
+func newEventBlock(number uint64, chaincode, name string, writes ...*kvrwset.KVWrite) *common.Block {
+	txID := hex.EncodeToString([]byte(name))
+	event := &pb.ChaincodeEvent{ChaincodeId: chaincode, TxId: txID, EventName: name, Payload: []byte(name)}
+	return newBlock(number, []byte{0}, newEndorserTx(txID, int64(number), map[string][]*kvrwset.KVWrite{chaincode: writes}, event))
+}
+
+// 运行监听直到返回的函数被调用
+func runListener(l *Listener) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+type eventCollector struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (c *eventCollector) add(e *ChaincodeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e.EventName)
+}
+
+func (c *eventCollector) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events := c.events
+	c.events = nil
+	return events
+}
+
+func TestListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := newFakeChain()
+	chain.blocks = []*common.Block{
+		newEventBlock(0, "cross", "SENT_MESSAGE"),
+		newEventBlock(1, "cross", "SENT_MESSAGE", &kvrwset.KVWrite{Key: AM_MESSAGE_KEY_PREFIX + "1", Value: []byte("am")}),
+		// 不关注的事件和其他链码的事件
+		newEventBlock(2, "cross", "PAUSED"),
+		newEventBlock(3, "bizcc", "RECEIVED_MESSAGE"),
+		newEventBlock(4, "cross", "RECEIVED_MESSAGE"),
+	}
+	// deliver服务漏推的区块从账本补齐
+	chain.skip = map[uint64]bool{1: true}
+
+	if _, err := newListener(chain, "cross", &ListenerConfig{}, hclog.NewNullLogger()); err == nil {
+		t.Fatal("dir is required")
+	}
+	l, err := newListener(chain, "cross", &ListenerConfig{Dir: dir, StartHeight: 1}, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collector eventCollector
+	l.OnEvent(collector.add)
+	stop := runListener(l)
+	waitCheckpoint(t, l, 4)
+	stop()
+
+	if events := collector.take(); len(events) != 2 || events[0] != "SENT_MESSAGE" || events[1] != "RECEIVED_MESSAGE" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if msgs, ok, err := l.Messages(1); err != nil || !ok || len(msgs) != 1 || string(msgs[0].Message) != "am" {
+		t.Fatalf("unexpected messages: %v %v %v", msgs, ok, err)
+	}
+	if record, ok, err := l.Record(2); err != nil || !ok || len(record.Events) != 0 {
+		t.Fatalf("unexpected record: %+v %v %v", record, ok, err)
+	}
+	// 起始高度之前和checkpoint之后的区块没有记录
+	for _, height := range []uint64{0, 5} {
+		if _, ok, err := l.Messages(height); err != nil || ok {
+			t.Fatalf("block %d should not be processed: %v", height, err)
+		}
+	}
+
+	// 重启后从checkpoint之后继续，配置的起始高度不再生效
+	chain.blocks = append(chain.blocks, newEventBlock(5, "cross", "ACKED_MESSAGE"))
+	l, err = newListener(chain, "cross", &ListenerConfig{Dir: dir}, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height, ok := l.Checkpoint(); !ok || height != 4 {
+		t.Fatalf("unexpected checkpoint: %d %v", height, ok)
+	}
+	l.OnEvent(collector.add)
+	stop = runListener(l)
+	waitCheckpoint(t, l, 5)
+	stop()
+
+	if events := collector.take(); len(events) != 1 || events[0] != "ACKED_MESSAGE" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if _, ok, err := l.Messages(0); err != nil || ok {
+		t.Fatalf("block 0 should not be processed: %v", err)
+	}
+	if record, ok, err := l.Record(1); err != nil || !ok || len(record.Messages) != 1 || len(record.Events) != 1 {
+		t.Fatalf("unexpected record: %+v %v %v", record, ok, err)
+	}
+}
+
+func TestFabricBBCServiceListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := newFakeChain()
+	chain.blocks = []*common.Block{
+		newBlock(0, nil),
+		newEventBlock(1, "cross", "SENT_MESSAGE", &kvrwset.KVWrite{Key: AM_MESSAGE_KEY_PREFIX + "1", Value: []byte("am")}),
+	}
+	service := NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	conf := &Config{
+		ConnectionProfile: "version: 1.0.0",
+		Channel:           "mychannel",
+		Chaincode:         "cross",
+		Org:               "Org1",
+		User:              UserConfig{Cert: "cert", Key: "key"},
+		Listener:          &ListenerConfig{},
+	}
+	raw, _ := json.Marshal(conf)
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err == nil {
+		t.Fatal("listener dir is required")
+	}
+	conf.Listener.Dir = dir
+	raw, _ = json.Marshal(conf)
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	waitCheckpoint(t, service.Listener(), 1)
+
+	// 已处理的区块从监听的记录中读取，不再查询账本
+	chain.blocks[1] = newBlock(1, nil)
+	msgs, err := service.ReadCrossChainMessagesByHeight(1)
+	if err != nil || len(msgs) != 1 || string(msgs[0].Message) != "am" {
+		t.Fatalf("unexpected messages: %v %v", msgs, err)
+	}
+	// 未处理的区块直接查询
+	if _, err := service.ReadCrossChainMessagesByHeight(2); err == nil {
+		t.Fatal("missing block should be rejected")
+	}
+
+	if err := service.Shutdown(); err != nil || !chain.closed || service.Listener() != nil {
+		t.Fatalf("unexpected shutdown: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package fabric
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// Prometheus监控指标
+// 指标注册在插件自己的registry中，由MetricsHandler或ServeMetrics以/metrics暴露。
+// 中继停滞时relayed_messages_total不再增长、listener_lag_blocks持续增大，可以据此告警。
+
+const METRICS_NAMESPACE = "fabric_bbc"
+
+// 转发结果的标签值
+const (
+	RELAY_RESULT_SUCCESS = "success"
+	// 交易上链但校验失败
+	RELAY_RESULT_FAILED = "failed"
+	// 交易未能上链或结果未知
+	RELAY_RESULT_UNCONFIRMED = "unconfirmed"
+	RELAY_RESULT_ERROR       = "error"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	relayedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "relayed_messages_total",
+		Help:      "Number of auth messages relayed to the cross chaincode, by result.",
+	}, []string{"result"})
+	relayDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "relay_duration_seconds",
+		Help:      "Time from receiving an auth message to returning its receipt, by result.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"result"})
+	endorsementFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "endorsement_failures_total",
+		Help:      "Number of relay transactions rejected during endorsement, by chaincode error symbol.",
+	}, []string{"code"})
+	chainHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "chain_height",
+		Help:      "Latest block number of the channel seen by the plugin.",
+	})
+	listenerCheckpoint = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "listener_checkpoint",
+		Help:      "Highest block processed by the listener.",
+	})
+	listenerLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "listener_lag_blocks",
+		Help:      "Number of blocks between the chain head and the listener checkpoint.",
+	})
+	listenerLastProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "listener_last_processed_timestamp_seconds",
+		Help:      "Unix time when the listener last processed a block.",
+	})
+	listenerEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "listener_events_total",
+		Help:      "Number of cross chaincode events processed by the listener, by event name.",
+	}, []string{"event"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		relayedMessages, relayDuration, endorsementFailures,
+		chainHeight, listenerCheckpoint, listenerLag, listenerLastProcessed, listenerEvents,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// 插件指标的http处理
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// 在addr上提供/metrics，直到ctx结束
+func ServeMetrics(ctx context.Context, addr string, logger hclog.Logger) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	logger.Info("serving metrics", "address", lis.Addr().String())
+	if err := server.Serve(lis); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// 记录一次转发的结果和耗时
+func observeRelay(receipt *bbc.CrossChainMessageReceipt, err error, begin time.Time) {
+	result := RELAY_RESULT_ERROR
+	switch {
+	case err != nil || receipt == nil:
+	case receipt.Successful:
+		result = RELAY_RESULT_SUCCESS
+	case receipt.Confirmed:
+		result = RELAY_RESULT_FAILED
+	default:
+		result = RELAY_RESULT_UNCONFIRMED
+	}
+	relayedMessages.WithLabelValues(result).Inc()
+	relayDuration.WithLabelValues(result).Observe(time.Since(begin).Seconds())
+}
+
+// 发送交易的错误来自背书阶段时计数，code为跨链链码的错误符号
+func observeEndorsementFailure(err error, code string) {
+	if code == "" && !isEndorsementError(err) {
+		return
+	}
+	if code == "" {
+		code = "unknown"
+	}
+	endorsementFailures.WithLabelValues(code).Inc()
+}
+
+// 背书节点拒绝提案、链码执行出错或背书结果不一致
+func isEndorsementError(err error) bool {
+	if errs, ok := err.(multi.Errors); ok {
+		for _, e := range errs {
+			if isEndorsementError(e) {
+				return true
+			}
+		}
+		return false
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Group {
+	case status.EndorserClientStatus, status.EndorserServerStatus, status.ChaincodeStatus:
+		return true
+	case status.ClientStatus:
+		// 多个背书节点的错误
+		for _, detail := range s.Details {
+			if e, ok := detail.(error); ok && isEndorsementError(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
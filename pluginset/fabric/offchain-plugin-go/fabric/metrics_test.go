@@ -0,0 +1,93 @@
+package fabric
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func TestRelayMetrics(t *testing.T) {
+	count := func(result string) float64 {
+		return testutil.ToFloat64(relayedMessages.WithLabelValues(result))
+	}
+	for _, c := range []struct {
+		receipt *bbc.CrossChainMessageReceipt
+		err     error
+		result  string
+	}{
+		{&bbc.CrossChainMessageReceipt{Confirmed: true, Successful: true}, nil, RELAY_RESULT_SUCCESS},
+		{&bbc.CrossChainMessageReceipt{Confirmed: true}, nil, RELAY_RESULT_FAILED},
+		{&bbc.CrossChainMessageReceipt{ErrorMsg: "timeout"}, nil, RELAY_RESULT_UNCONFIRMED},
+		{nil, errors.New("invalid relay message"), RELAY_RESULT_ERROR},
+	} {
+		before := count(c.result)
+		observeRelay(c.receipt, c.err, time.Now())
+		if count(c.result) != before+1 {
+			t.Fatalf("%s relay should be counted", c.result)
+		}
+	}
+
+	endorser := status.New(status.EndorserServerStatus, 500, "chaincode error", nil)
+	for _, c := range []struct {
+		err         error
+		endorsement bool
+	}{
+		{endorser, true},
+		{multi.New(errors.New("timeout"), endorser), true},
+		{status.New(status.ClientStatus, status.MultipleErrors.ToInt32(), "multiple errors", []interface{}{endorser}), true},
+		{status.New(status.EventServerStatus, 11, "MVCC_READ_CONFLICT", nil), false},
+		{errors.New("timeout"), false},
+	} {
+		if isEndorsementError(c.err) != c.endorsement {
+			t.Fatalf("unexpected classification of %v", c.err)
+		}
+	}
+	before := testutil.ToFloat64(endorsementFailures.WithLabelValues("ERR_PAUSED"))
+	observeEndorsementFailure(errors.New("E1008 paused: bridge paused"), "ERR_PAUSED")
+	observeEndorsementFailure(errors.New("timeout"), "")
+	if testutil.ToFloat64(endorsementFailures.WithLabelValues("ERR_PAUSED")) != before+1 {
+		t.Fatal("endorsement failure should be counted by code")
+	}
+}
+
+func TestListenerMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := newListener(newFakeChain(), "cross", &ListenerConfig{Dir: dir}, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.observeHead(100)
+	if err := l.commit(&BlockRecord{Height: 40, Events: []*ChaincodeEvent{{EventName: "SENT_MESSAGE"}}}, false); err != nil {
+		t.Fatal(err)
+	}
+	if lag := testutil.ToFloat64(listenerLag); lag != 60 {
+		t.Fatalf("unexpected lag: %v", lag)
+	}
+	if height := testutil.ToFloat64(chainHeight); height != 100 {
+		t.Fatalf("unexpected chain height: %v", height)
+	}
+
+	w := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, name := range []string{"fabric_bbc_listener_lag_blocks 60", `fabric_bbc_listener_events_total{event="SENT_MESSAGE"}`, "go_goroutines"} {
+		if !strings.Contains(w.Body.String(), name) {
+			t.Fatalf("metric %s not exposed", name)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+)
+
+// 追块流水线
+// 监听落后通道高度较多时（首次从较低的高度开始或长时间停机），逐块订阅处理太慢。追块时分四段处理一段高度区间：
+// fetcher并发从账本查询区块，filter筛出调用跨链链码的交易，decoder提取AM消息和事件，sink按高度顺序保存记录、
+// 推进checkpoint并回调事件。各段之间用有界channel连接，同时在途的区块不超过window个，下游处理慢时反压上游，内存占用有界。
+// 追到与通道高度相差不超过catchUpThreshold后改为订阅。
+
+const (
+	DEFAULT_PIPELINE_FETCHERS  = 8
+	DEFAULT_PIPELINE_WINDOW    = 256
+	DEFAULT_CATCH_UP_THRESHOLD = 64
+	// 追块时没有消息和事件的区块每隔多少个写一次checkpoint，有内容的区块总是立即写入，重启后不会重复回调事件
+	CATCH_UP_CHECKPOINT_INTERVAL = 1000
+)
+
+type PipelineConfig struct {
+	// 并发查询区块的数量，默认DEFAULT_PIPELINE_FETCHERS
+	Fetchers int `json:"fetchers"`
+	// 筛选、解码各自的并发数，默认CPU数
+	Workers int `json:"workers"`
+	// 同时在途的区块数上限，默认DEFAULT_PIPELINE_WINDOW
+	Window int `json:"window"`
+	// 落后通道高度超过该值时追块，默认DEFAULT_CATCH_UP_THRESHOLD，负数不追块
+	CatchUpThreshold int64 `json:"catchUpThreshold"`
+}
+
+// 填充默认值
+func (c PipelineConfig) withDefaults() PipelineConfig {
+	if c.Fetchers <= 0 {
+		c.Fetchers = DEFAULT_PIPELINE_FETCHERS
+	}
+	if c.Workers <= 0 {
+		c.Workers = runtime.NumCPU()
+	}
+	if c.Window <= 0 {
+		c.Window = DEFAULT_PIPELINE_WINDOW
+	}
+	if c.CatchUpThreshold == 0 {
+		c.CatchUpThreshold = DEFAULT_CATCH_UP_THRESHOLD
+	}
+	return c
+}
+
+// 流水线中的一个区块，出错后后续阶段直接传递
+type pipelineItem struct {
+	height uint64
+	block  *common.Block
+	header *common.BlockHeader
+	txs    []*endorserTx
+	record *BlockRecord
+	err    error
+}
+
+type pipeline struct {
+	conf   PipelineConfig
+	fetch  func(height uint64) (*common.Block, error)
+	filter func(block *common.Block) ([]*endorserTx, error)
+	decode func(header *common.BlockHeader, txs []*endorserTx) (*BlockRecord, error)
+	// 按高度顺序调用
+	sink func(record *BlockRecord) error
+}
+
+// 处理[from, to)的区块，返回下一个待处理的高度。出错时之前的区块都已交给sink
+func (p *pipeline) run(ctx context.Context, from, to uint64) (uint64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	window := make(chan struct{}, p.conf.Window)
+	heights := make(chan *pipelineItem)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(heights)
+		for h := from; h < to; h++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case heights <- &pipelineItem{height: h}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// 启动一段由workers个goroutine组成的处理，in关闭且处理完后关闭out
+	stage := func(workers int, in <-chan *pipelineItem, fn func(*pipelineItem)) <-chan *pipelineItem {
+		out := make(chan *pipelineItem, p.conf.Window)
+		var stageWg sync.WaitGroup
+		stageWg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer stageWg.Done()
+				for item := range in {
+					if item.err == nil {
+						fn(item)
+					}
+					select {
+					case out <- item:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stageWg.Wait()
+			close(out)
+		}()
+		return out
+	}
+	fetched := stage(p.conf.Fetchers, heights, func(item *pipelineItem) {
+		block, err := p.fetch(item.height)
+		switch {
+		case err != nil:
+			item.err = fmt.Errorf("failed to query block %d: %v", item.height, err)
+		case block.Header == nil || block.Header.Number != item.height:
+			item.err = fmt.Errorf("unexpected block for height %d", item.height)
+		default:
+			item.block = block
+		}
+	})
+	filtered := stage(p.conf.Workers, fetched, func(item *pipelineItem) {
+		item.header = item.block.Header
+		item.txs, item.err = p.filter(item.block)
+		// 区块数据较大，筛选后不再保留
+		item.block = nil
+	})
+	decoded := stage(p.conf.Workers, filtered, func(item *pipelineItem) {
+		item.record, item.err = p.decode(item.header, item.txs)
+		item.txs = nil
+	})
+
+	// 乱序到达的区块暂存，按高度顺序交给sink
+	pending := make(map[uint64]*pipelineItem)
+	next := from
+	for next < to {
+		select {
+		case <-ctx.Done():
+			return next, ctx.Err()
+		case item, ok := <-decoded:
+			if !ok {
+				return next, ctx.Err()
+			}
+			pending[item.height] = item
+		}
+		for item := pending[next]; item != nil; item = pending[next] {
+			delete(pending, next)
+			if item.err != nil {
+				return next, item.err
+			}
+			if ctx.Err() != nil {
+				return next, ctx.Err()
+			}
+			if err := p.sink(item.record); err != nil {
+				return next, err
+			}
+			<-window
+			next++
+		}
+	}
+	return next, nil
+}
+
+// 落后通道高度较多时用流水线追块，追到相差不超过阈值后返回
+func (l *Listener) catchUp(ctx context.Context) error {
+	if l.pipeline.CatchUpThreshold < 0 {
+		return nil
+	}
+	p := &pipeline{
+		conf:  l.pipeline,
+		fetch: l.client.BlockByNumber,
+		filter: func(block *common.Block) ([]*endorserTx, error) {
+			return filterBlock(block, l.chaincode)
+		},
+		decode: func(header *common.BlockHeader, txs []*endorserTx) (*BlockRecord, error) {
+			return decodeBlock(header, txs, l.chaincode, l.names)
+		},
+		sink: func(record *BlockRecord) error {
+			return l.commit(record, true)
+		},
+	}
+	for {
+		height, err := l.client.Height()
+		if err != nil {
+			return fmt.Errorf("failed to query height: %v", err)
+		}
+		from := l.next()
+		if height > 0 {
+			l.observeHead(height - 1)
+		}
+		if height <= from || height-from <= uint64(l.pipeline.CatchUpThreshold) {
+			return nil
+		}
+		l.logger.Info("catching up blocks", "from", from, "to", height)
+		begin := time.Now()
+		next, err := p.run(ctx, from, height)
+		if flushErr := l.flushCheckpoint(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			return fmt.Errorf("catch up interrupted at block %d: %v", next, err)
+		}
+		l.logger.Info("blocks caught up", "from", from, "to", height, "elapsed", time.Since(begin))
+	}
+}
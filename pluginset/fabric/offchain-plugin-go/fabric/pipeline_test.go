@@ -0,0 +1,124 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+)
+
+func TestPipeline(t *testing.T) {
+	blocks := make([]*common.Block, 200)
+	for i := range blocks {
+		blocks[i] = newEventBlock(uint64(i), "cross", "SENT_MESSAGE")
+	}
+	var sunk []uint64
+	p := &pipeline{
+		conf: PipelineConfig{Fetchers: 8, Workers: 4, Window: 16},
+		// 查询耗时随机，区块乱序到达
+		fetch: func(height uint64) (*common.Block, error) {
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			if height == 150 {
+				return nil, fmt.Errorf("not found")
+			}
+			return blocks[height], nil
+		},
+		filter: func(block *common.Block) ([]*endorserTx, error) {
+			return filterBlock(block, "cross")
+		},
+		decode: func(header *common.BlockHeader, txs []*endorserTx) (*BlockRecord, error) {
+			return decodeBlock(header, txs, "cross", map[string]bool{"SENT_MESSAGE": true})
+		},
+		sink: func(record *BlockRecord) error {
+			if len(record.Events) != 1 {
+				return fmt.Errorf("unexpected record %+v", record)
+			}
+			sunk = append(sunk, record.Height)
+			return nil
+		},
+	}
+	check := func(from, to uint64) {
+		for i, height := range sunk {
+			if height != from+uint64(i) {
+				t.Fatalf("blocks out of order: %v", sunk)
+			}
+		}
+		if uint64(len(sunk)) != to-from {
+			t.Fatalf("unexpected blocks: %v", sunk)
+		}
+		sunk = nil
+	}
+
+	if next, err := p.run(context.Background(), 10, 150); err != nil || next != 150 {
+		t.Fatalf("unexpected result: %d %v", next, err)
+	}
+	check(10, 150)
+	// 出错时之前的区块都已处理
+	if next, err := p.run(context.Background(), 100, 200); err == nil || next != 150 {
+		t.Fatalf("unexpected result: %d %v", next, err)
+	}
+	check(100, 150)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.sink = func(record *BlockRecord) error {
+		if record.Height == 20 {
+			cancel()
+		}
+		return nil
+	}
+	if next, err := p.run(ctx, 0, 100); err == nil || next > 21 {
+		t.Fatalf("unexpected result: %d %v", next, err)
+	}
+}
+
+func TestListenerCatchUp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := newFakeChain()
+	chain.skip = make(map[uint64]bool)
+	for i := uint64(0); i < 300; i++ {
+		block := newBlock(i, nil)
+		if i%50 == 0 {
+			block = newEventBlock(i, "cross", "SENT_MESSAGE", &kvrwset.KVWrite{Key: AM_MESSAGE_KEY_PREFIX + fmt.Sprint(i), Value: []byte("am")})
+		}
+		chain.blocks = append(chain.blocks, block)
+		// deliver服务不推送，只能通过追块处理
+		chain.skip[i] = true
+	}
+	conf := &ListenerConfig{Dir: dir, Pipeline: &PipelineConfig{Fetchers: 4, Window: 16, CatchUpThreshold: 10}}
+	l, err := newListener(chain, "cross", conf, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collector eventCollector
+	l.OnEvent(collector.add)
+	stop := runListener(l)
+	waitCheckpoint(t, l, 299)
+	stop()
+
+	if events := collector.take(); len(events) != 6 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if msgs, ok, err := l.Messages(250); err != nil || !ok || len(msgs) != 1 || msgs[0].ProvableData.Height != 250 {
+		t.Fatalf("unexpected messages: %v %v %v", msgs, ok, err)
+	}
+	// 追块结束时写入checkpoint
+	l, err = newListener(chain, "cross", conf, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height, ok := l.Checkpoint(); !ok || height != 299 {
+		t.Fatalf("unexpected checkpoint: %d %v", height, ok)
+	}
+}
@@ -0,0 +1,113 @@
+package fabric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// connection profile和身份的加载
+// 钱包使用fabric-sdk-go gateway的文件钱包格式，目录下每个身份一个<label>.id文件，
+// fabric-sdk-go应用的profile和钱包可以直接给插件使用。
+
+const DEFAULT_RELOAD_INTERVAL = 10 * time.Second
+
+// 钱包中身份文件的扩展名，与gateway.NewFileSystemWallet一致
+const WALLET_ID_EXTENSION = ".id"
+
+type identity struct {
+	cert []byte
+	// 为空时由SDK的cryptosuite按证书查找私钥
+	key []byte
+}
+
+func loadProfile(conf *Config) ([]byte, error) {
+	if conf.ConnectionProfilePath == "" {
+		return []byte(conf.ConnectionProfile), nil
+	}
+	profile, err := ioutil.ReadFile(conf.ConnectionProfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection profile: %v", err)
+	}
+	return profile, nil
+}
+
+func loadIdentity(user *UserConfig) (*identity, error) {
+	if user.Wallet != "" {
+		wallet, err := gateway.NewFileSystemWallet(user.Wallet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open wallet %s: %v", user.Wallet, err)
+		}
+		id, err := wallet.Get(user.Label)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity %s from wallet: %v", user.Label, err)
+		}
+		x509, ok := id.(*gateway.X509Identity)
+		if !ok {
+			return nil, fmt.Errorf("identity %s is not X.509", user.Label)
+		}
+		return &identity{cert: []byte(x509.Certificate()), key: []byte(x509.Key())}, nil
+	}
+
+	id := &identity{cert: []byte(user.Cert)}
+	if user.CertPath != "" {
+		cert, err := ioutil.ReadFile(user.CertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user cert: %v", err)
+		}
+		id.cert = cert
+	}
+	if user.Key != "" {
+		id.key = []byte(user.Key)
+	} else if user.KeyPath != "" {
+		key, err := ioutil.ReadFile(user.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user key: %v", err)
+		}
+		id.key = key
+	}
+	return id, nil
+}
+
+// 配置引用的文件
+func (c *Config) files() []string {
+	var files []string
+	for _, path := range []string{c.ConnectionProfilePath, c.User.CertPath, c.User.KeyPath} {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	if c.User.Wallet != "" {
+		files = append(files, filepath.Join(c.User.Wallet, c.User.Label+WALLET_ID_EXTENSION))
+	}
+	if s := c.User.Signer; s != nil {
+		if s.KeyPath != "" {
+			files = append(files, s.KeyPath)
+		}
+		if s.KMS != nil && s.KMS.TokenPath != "" {
+			files = append(files, s.KMS.TokenPath)
+		}
+	}
+	return files
+}
+
+// 配置引用的文件内容的摘要，文件不存在时记为空
+func (c *Config) fingerprint() (string, error) {
+	h := sha256.New()
+	for _, path := range c.files() {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		sum := sha256.Sum256(raw)
+		h.Write([]byte(path))
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,156 @@
+package fabric
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/signer"
+)
+
+func TestUserConfig(t *testing.T) {
+	for _, c := range []struct {
+		user UserConfig
+		err  string
+	}{
+		{UserConfig{Cert: "cert", Key: "key"}, ""},
+		// 私钥由keystore或HSM提供
+		{UserConfig{CertPath: "cert.pem"}, ""},
+		{UserConfig{Wallet: "wallet", Label: "relayer"}, ""},
+		{UserConfig{}, "is required"},
+		{UserConfig{Cert: "cert", CertPath: "cert.pem"}, "exclusive"},
+		{UserConfig{Cert: "cert", Key: "key", KeyPath: "key.pem"}, "exclusive"},
+		{UserConfig{Wallet: "wallet"}, "label is required"},
+		{UserConfig{Wallet: "wallet", Label: "relayer", KeyPath: "key.pem"}, "read from wallet"},
+		{UserConfig{CertPath: "cert.pem", Signer: &signer.Config{Type: "kms"}}, ""},
+		{UserConfig{CertPath: "cert.pem", KeyPath: "key.pem", Signer: &signer.Config{KeyPath: "key.pem"}}, "signer is exclusive"},
+	} {
+		err := c.user.validate()
+		if (c.err == "" && err != nil) || (c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err))) {
+			t.Fatalf("%+v: unexpected error: %v", c.user, err)
+		}
+	}
+}
+
+func TestLoadIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wallet, err := gateway.NewFileSystemWallet(filepath.Join(dir, "wallet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wallet.Put("relayer", gateway.NewX509Identity("Org1MSP", "wallet cert", "wallet key")); err != nil {
+		t.Fatal(err)
+	}
+	id, err := loadIdentity(&UserConfig{Wallet: filepath.Join(dir, "wallet"), Label: "relayer"})
+	if err != nil || string(id.cert) != "wallet cert" || string(id.key) != "wallet key" {
+		t.Fatalf("unexpected identity: %+v %v", id, err)
+	}
+	if _, err := loadIdentity(&UserConfig{Wallet: filepath.Join(dir, "wallet"), Label: "unknown"}); err == nil {
+		t.Fatal("unknown label should be rejected")
+	}
+
+	certPath := filepath.Join(dir, "cert.pem")
+	ioutil.WriteFile(certPath, []byte("file cert"), 0644)
+	id, err = loadIdentity(&UserConfig{CertPath: certPath, Key: "key"})
+	if err != nil || string(id.cert) != "file cert" || string(id.key) != "key" {
+		t.Fatalf("unexpected identity: %+v %v", id, err)
+	}
+	id, err = loadIdentity(&UserConfig{CertPath: certPath})
+	if err != nil || id.key != nil {
+		t.Fatalf("unexpected identity: %+v %v", id, err)
+	}
+	if _, err := loadIdentity(&UserConfig{CertPath: certPath, KeyPath: filepath.Join(dir, "missing")}); err == nil {
+		t.Fatal("missing key should be rejected")
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	profilePath := filepath.Join(dir, "connection.yaml")
+	ioutil.WriteFile(profilePath, []byte("version: 1.0.0"), 0644)
+
+	var mu sync.Mutex
+	var chains []*fakeChain
+	var profiles []string
+	service := NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		profile, err := loadProfile(conf)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		chain := newFakeChain()
+		chains = append(chains, chain)
+		profiles = append(profiles, string(profile))
+		return chain, nil
+	}
+	raw, _ := json.Marshal(&Config{
+		ConnectionProfilePath: profilePath,
+		Channel:               "mychannel",
+		Chaincode:             "cross",
+		Org:                   "Org1",
+		User:                  UserConfig{Cert: "cert", Key: "key"},
+		Listener:              &ListenerConfig{Dir: filepath.Join(dir, "listener")},
+		ReloadInterval:        10,
+	})
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	first := chains[0]
+	mu.Unlock()
+	listener := service.Listener()
+	listener.OnEvent(func(*ChaincodeEvent) {})
+
+	// profile变化后重新连接，区块监听使用新的连接继续
+	ioutil.WriteFile(profilePath, []byte("version: 1.0.1"), 0644)
+	for i := 0; ; i++ {
+		client, _, err := service.started()
+		if err == nil && client != chainClient(first) {
+			break
+		}
+		if i == 200 {
+			t.Fatal("timeout waiting for reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	if len(chains) != 2 || !chains[0].closed || profiles[1] != "version: 1.0.1" {
+		t.Fatalf("unexpected reload: %d %v", len(chains), profiles)
+	}
+	mu.Unlock()
+	if l := service.Listener(); l == listener || l.eventHandler() == nil {
+		t.Fatal("listener should be restarted with the event handler")
+	}
+
+	if err := service.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	// 停止后不再重新连接
+	ioutil.WriteFile(profilePath, []byte("version: 1.0.2"), 0644)
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(chains) != 2 || !chains[1].closed {
+		t.Fatalf("unexpected chains after shutdown: %d", len(chains))
+	}
+}
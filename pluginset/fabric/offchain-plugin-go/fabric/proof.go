@@ -0,0 +1,124 @@
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 区块证明
+// Fabric的区块头只包含区块号、前一区块头的hash和dataHash，dataHash为区块中全部交易envelope拼接后的sha256，
+// 没有交易的merkle树，证明交易在区块中需要区块中的全部交易。区块头由orderer签名，签名在metadata的SIGNATURES中；
+// 交易的校验结果由peer在提交时写入metadata的TRANSACTIONS_FILTER，不在orderer的签名范围内。
+
+type BlockHeader struct {
+	Number       uint64 `json:"number"`
+	PreviousHash []byte `json:"previousHash"`
+	DataHash     []byte `json:"dataHash"`
+}
+
+// orderer对区块的签名
+type BlockSignature struct {
+	// common.SignatureHeader，creator为签名者的SerializedIdentity
+	SignatureHeader []byte `json:"signatureHeader"`
+	Signature       []byte `json:"signature"`
+}
+
+// 与跨链链码相关的交易
+type CrossChainTx struct {
+	// 交易在区块中的位置
+	Index          int                 `json:"index"`
+	TxID           string              `json:"txId"`
+	ValidationCode pb.TxValidationCode `json:"validationCode"`
+	// 毫秒
+	Timestamp int64 `json:"timestamp"`
+	// 交易写入的AM消息
+	Messages [][]byte `json:"messages,omitempty"`
+	// 跨链链码发出的事件
+	Events []*ChaincodeEvent `json:"events,omitempty"`
+}
+
+type BlockProof struct {
+	Header *BlockHeader `json:"header"`
+	// SIGNATURES元数据的value，orderer签名的内容为value、签名头和区块头的拼接
+	SignatureValue []byte            `json:"signatureValue"`
+	Signatures     []*BlockSignature `json:"signatures"`
+	// 每个交易的校验结果
+	TxFilter []byte `json:"txFilter"`
+	// 区块中的全部交易envelope
+	Data [][]byte        `json:"data"`
+	Txs  []*CrossChainTx `json:"txs"`
+}
+
+func (p *BlockProof) Encode() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func DecodeBlockProof(raw []byte) (*BlockProof, error) {
+	var p BlockProof
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("invalid block proof: %v", err)
+	}
+	if p.Header == nil {
+		return nil, fmt.Errorf("invalid block proof: no header")
+	}
+	return &p, nil
+}
+
+// 从区块中提取区块头、签名和跨链交易，包括校验失败的交易
+func ExtractBlockProof(block *common.Block, chaincode string) (*BlockProof, error) {
+	if block.Header == nil || block.Data == nil || block.Metadata == nil {
+		return nil, fmt.Errorf("incomplete block")
+	}
+	number := block.Header.Number
+	proof := &BlockProof{
+		Header: &BlockHeader{Number: number, PreviousHash: block.Header.PreviousHash, DataHash: block.Header.DataHash},
+		Data:   block.Data.Data,
+	}
+	md := block.Metadata.Metadata
+	if len(md) > int(common.BlockMetadataIndex_SIGNATURES) && len(md[common.BlockMetadataIndex_SIGNATURES]) > 0 {
+		sigs := &common.Metadata{}
+		if err := proto.Unmarshal(md[common.BlockMetadataIndex_SIGNATURES], sigs); err != nil {
+			return nil, fmt.Errorf("invalid signatures of block %d: %v", number, err)
+		}
+		proof.SignatureValue = sigs.Value
+		for _, sig := range sigs.Signatures {
+			proof.Signatures = append(proof.Signatures, &BlockSignature{SignatureHeader: sig.SignatureHeader, Signature: sig.Signature})
+		}
+	}
+	if len(md) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		proof.TxFilter = md[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+
+	for i, data := range block.Data.Data {
+		tx, err := parseEndorserTx(data, chaincode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tx %d of block %d: %v", i, number, err)
+		}
+		if tx == nil {
+			continue
+		}
+		ctx := &CrossChainTx{Index: i, TxID: tx.txID, Timestamp: tx.timestamp}
+		if i < len(proof.TxFilter) {
+			ctx.ValidationCode = pb.TxValidationCode(proof.TxFilter[i])
+		}
+		for _, w := range tx.writes {
+			if !w.IsDelete && strings.HasPrefix(w.Key, AM_MESSAGE_KEY_PREFIX) {
+				ctx.Messages = append(ctx.Messages, w.Value)
+			}
+		}
+		for _, e := range tx.events {
+			if e.ChaincodeId == chaincode {
+				ctx.Events = append(ctx.Events, &ChaincodeEvent{Height: number, TxID: tx.txID, EventName: e.EventName, Payload: e.Payload})
+			}
+		}
+		if len(ctx.Messages) > 0 || len(ctx.Events) > 0 {
+			proof.Txs = append(proof.Txs, ctx)
+		}
+	}
+	return proof, nil
+}
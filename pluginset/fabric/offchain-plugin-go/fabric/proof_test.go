@@ -0,0 +1,96 @@
+package fabric
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func TestExtractBlockProof(t *testing.T) {
+	txID1, txID2, txID3 := hex.EncodeToString([]byte("tx1")), hex.EncodeToString([]byte("tx2")), hex.EncodeToString([]byte("tx3"))
+	block := newBlock(7, []byte{byte(pb.TxValidationCode_VALID), byte(pb.TxValidationCode_MVCC_READ_CONFLICT), byte(pb.TxValidationCode_VALID)},
+		newEndorserTx(txID1, 1, map[string][]*kvrwset.KVWrite{"cross": {{Key: AM_MESSAGE_KEY_PREFIX + "1", Value: []byte("am1")}, {Key: "other", Value: []byte("x")}}},
+			&pb.ChaincodeEvent{ChaincodeId: "cross", TxId: txID1, EventName: "SENT_MESSAGE", Payload: []byte("sent")}),
+		// 校验失败的跨链交易也在证明中
+		newEndorserTx(txID2, 2, nil, &pb.ChaincodeEvent{ChaincodeId: "cross", TxId: txID2, EventName: "PAUSED"}),
+		// 与跨链链码无关的交易
+		newEndorserTx(txID3, 3, map[string][]*kvrwset.KVWrite{"bizcc": {{Key: AM_MESSAGE_KEY_PREFIX + "3", Value: []byte("fake")}}}),
+	)
+	block.Header.PreviousHash = []byte("prev")
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES] = mustMarshal(&common.Metadata{
+		Value:      []byte("value"),
+		Signatures: []*common.MetadataSignature{{SignatureHeader: []byte("shdr1"), Signature: []byte("sig1")}, {SignatureHeader: []byte("shdr2"), Signature: []byte("sig2")}},
+	})
+
+	proof, err := ExtractBlockProof(block, "cross")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := proof.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof, err = DecodeBlockProof(raw); err != nil {
+		t.Fatal(err)
+	}
+	if h := proof.Header; h.Number != 7 || string(h.PreviousHash) != "prev" || string(h.DataHash) != "datahash" {
+		t.Fatalf("unexpected header: %+v", h)
+	}
+	if string(proof.SignatureValue) != "value" || len(proof.Signatures) != 2 || string(proof.Signatures[1].Signature) != "sig2" {
+		t.Fatalf("unexpected signatures: %+v", proof.Signatures)
+	}
+	if len(proof.Data) != 3 || len(proof.TxFilter) != 3 || len(proof.Txs) != 2 {
+		t.Fatalf("unexpected proof: %d %d %d", len(proof.Data), len(proof.TxFilter), len(proof.Txs))
+	}
+	tx := proof.Txs[0]
+	if tx.Index != 0 || tx.TxID != txID1 || tx.ValidationCode != pb.TxValidationCode_VALID || tx.Timestamp != 1500 ||
+		len(tx.Messages) != 1 || string(tx.Messages[0]) != "am1" || len(tx.Events) != 1 || string(tx.Events[0].Payload) != "sent" {
+		t.Fatalf("unexpected tx: %+v", tx)
+	}
+	if tx := proof.Txs[1]; tx.Index != 1 || tx.ValidationCode != pb.TxValidationCode_MVCC_READ_CONFLICT || len(tx.Messages) != 0 || tx.Events[0].EventName != "PAUSED" {
+		t.Fatalf("unexpected tx: %+v", tx)
+	}
+
+	if _, err := ExtractBlockProof(&common.Block{Header: block.Header}, "cross"); err == nil {
+		t.Fatal("incomplete block should be rejected")
+	}
+	if _, err := DecodeBlockProof([]byte("{}")); err == nil {
+		t.Fatal("proof without header should be rejected")
+	}
+
+	chain := newFakeChain()
+	chain.blocks = append(chain.blocks, block)
+	service := NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	if _, err := service.ReadBlockProof(0); err == nil {
+		t.Fatal("service should be started first")
+	}
+	raw, _ = json.Marshal(&Config{ConnectionProfile: "version: 1.0.0", Channel: "mychannel", Chaincode: "cross", Org: "Org1", User: UserConfig{Cert: "cert", Key: "key"}, AttachProof: true})
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	defer service.Shutdown()
+	if proof, err := service.ReadBlockProof(0); err != nil || len(proof.Txs) != 2 {
+		t.Fatalf("unexpected proof: %+v %v", proof, err)
+	}
+	if _, err := service.ReadBlockProof(1); err == nil {
+		t.Fatal("missing block should be rejected")
+	}
+	// 跨链消息附带区块证明
+	msgs, err := service.ReadCrossChainMessagesByHeight(0)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("unexpected messages: %+v %v", msgs, err)
+	}
+	if proof, err := DecodeBlockProof(msgs[0].ProvableData.Proof); err != nil || proof.Header.Number != 7 || len(proof.Txs) != 2 {
+		t.Fatalf("unexpected proof: %+v %v", proof, err)
+	}
+}
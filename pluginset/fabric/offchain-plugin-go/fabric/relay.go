@@ -0,0 +1,321 @@
+package fabric
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 解析中继提交的跨链报文，得到消息的接收方身份
+// 报文格式与Java插件及链上oraclelogic一致:
+//   4字节0 | 4字节大端长度 | 证明(TLV)
+//   证明中tag 5为回复(TLV)，回复中tag 0为AM消息；证明中tag 9为来源域名
+//   AM消息、SDP消息都从尾部开始解析
+//   SDP消息可以带扩展: 内层SDP消息 | 扩展(TLV) | 扩展长度(4) | 0xffffffff，扩展中tag 0为W3C traceparent
+
+const (
+	TLV_PROOF_RESPONSE = 5
+	TLV_PROOF_DOMAIN   = 9
+	TLV_RESP_RAW       = 0
+
+	SDP_V2_MAGIC = 0xff
+	// 带扩展的SDP消息的版本号
+	SDP_VERSION_EXTENDED = 0xffffff
+
+	TLV_SDP_EXT_TRACEPARENT = 0
+)
+
+type authMessage struct {
+	Version       uint32
+	Identity      [32]byte
+	UpperProtocol uint32
+	Payload       []byte
+}
+
+type sdpMessage struct {
+	Version        uint32
+	TargetDomain   string
+	TargetIdentity [32]byte
+	Sequence       uint32
+	Payload        []byte
+	// 扩展中的traceparent，没有扩展时为空
+	TraceParent string
+}
+
+// 解析TLV: 版本(int16 LE) | 长度(int32 LE) | 若干项，每项为 类型(int16 LE) | 长度(int32 LE) | 值
+func decodeTLV(raw []byte) (map[uint16][]byte, error) {
+	if len(raw) < 6 {
+		return nil, fmt.Errorf("tlv packet too short: %d", len(raw))
+	}
+	total := binary.LittleEndian.Uint32(raw[2:6])
+	if uint64(total)+6 > uint64(len(raw)) {
+		return nil, fmt.Errorf("tlv packet length %d exceeds %d", total, len(raw)-6)
+	}
+	items := make(map[uint16][]byte)
+	body := raw[6 : 6+total]
+	for len(body) > 0 {
+		if len(body) < 6 {
+			return nil, fmt.Errorf("truncated tlv item")
+		}
+		tag, size := binary.LittleEndian.Uint16(body[0:2]), binary.LittleEndian.Uint32(body[2:6])
+		if uint64(size)+6 > uint64(len(body)) {
+			return nil, fmt.Errorf("tlv item %d length %d exceeds packet", tag, size)
+		}
+		items[tag] = body[6 : 6+size]
+		body = body[6+size:]
+	}
+	return items, nil
+}
+
+// 解析中继报文，返回来源域名和AM消息
+func decodeRelayPackage(pkg []byte) (string, []byte, error) {
+	if len(pkg) < 8 {
+		return "", nil, fmt.Errorf("relay package too short: %d", len(pkg))
+	}
+	size := binary.BigEndian.Uint32(pkg[4:8])
+	if uint64(size)+8 > uint64(len(pkg)) {
+		return "", nil, fmt.Errorf("relay package length %d exceeds %d", size, len(pkg)-8)
+	}
+	proof, err := decodeTLV(pkg[8 : 8+size])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid proof: %v", err)
+	}
+	resp, err := decodeTLV(proof[TLV_PROOF_RESPONSE])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid proof response: %v", err)
+	}
+	am, ok := resp[TLV_RESP_RAW]
+	if !ok {
+		return "", nil, fmt.Errorf("no auth message in proof")
+	}
+	return string(proof[TLV_PROOF_DOMAIN]), am, nil
+}
+
+// 构造中继报文，hints为空，链码不验证证明中的回复，用于本地联调和测试
+func EncodeRelayPackage(domain string, am []byte) []byte {
+	resp := encodeTLVItems(tlvItem{TLV_RESP_RAW, am})
+	proof := encodeTLVItems(tlvItem{TLV_PROOF_RESPONSE, resp}, tlvItem{TLV_PROOF_DOMAIN, []byte(domain)})
+	pkg := make([]byte, 8, 8+len(proof))
+	binary.BigEndian.PutUint32(pkg[4:8], uint32(len(proof)))
+	return append(pkg, proof...)
+}
+
+type tlvItem struct {
+	tag   uint16
+	value []byte
+}
+
+// 编码TLV，版本为0
+func encodeTLVItems(items ...tlvItem) []byte {
+	var body []byte
+	for _, item := range items {
+		var h [6]byte
+		binary.LittleEndian.PutUint16(h[0:2], item.tag)
+		binary.LittleEndian.PutUint32(h[2:6], uint32(len(item.value)))
+		body = append(append(body, h[:]...), item.value...)
+	}
+	var h [6]byte
+	binary.LittleEndian.PutUint32(h[2:6], uint32(len(body)))
+	return append(h[:], body...)
+}
+
+// 从offset往前读取EVM编码的变长字节，返回数据和字段起始位置
+// 长度在offset前32字节的末4字节，数据按32字节分段，第一段紧挨长度，后续各段依次往前
+func readVarBytesBackward(raw []byte, offset int) ([]byte, int, error) {
+	if offset < 32 {
+		return nil, 0, fmt.Errorf("no room for var bytes length")
+	}
+	size := int(binary.BigEndian.Uint32(raw[offset-4 : offset]))
+	offset -= 32
+	if (size+31)/32*32 > offset {
+		return nil, 0, fmt.Errorf("var bytes length %d exceeds %d", size, offset)
+	}
+	data := make([]byte, size)
+	for i := 0; i < size; i += 32 {
+		offset -= 32
+		copy(data[i:], raw[offset:offset+32])
+	}
+	return data, offset, nil
+}
+
+func decodeAuthMessage(raw []byte) (*authMessage, error) {
+	if len(raw) < 4+32+4 {
+		return nil, fmt.Errorf("auth message too short: %d", len(raw))
+	}
+	am := &authMessage{}
+	offset := len(raw)
+	am.Version = binary.BigEndian.Uint32(raw[offset-4 : offset])
+	offset -= 4
+	copy(am.Identity[:], raw[offset-32:offset])
+	offset -= 32
+	am.UpperProtocol = binary.BigEndian.Uint32(raw[offset-4 : offset])
+	offset -= 4
+	switch am.Version {
+	case 1:
+		payload, _, err := readVarBytesBackward(raw, offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth message payload: %v", err)
+		}
+		am.Payload = payload
+	case 2:
+		// 信任等级1字节，载荷长度4字节
+		if offset < 1+4 {
+			return nil, fmt.Errorf("auth message v2 too short")
+		}
+		offset -= 1
+		size := int(binary.BigEndian.Uint32(raw[offset-4 : offset]))
+		offset -= 4
+		if size > offset {
+			return nil, fmt.Errorf("auth message payload length %d exceeds %d", size, offset)
+		}
+		am.Payload = raw[offset-size : offset]
+	default:
+		return nil, fmt.Errorf("unsupported auth message version %d", am.Version)
+	}
+	return am, nil
+}
+
+func decodeSDPMessage(raw []byte) (*sdpMessage, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("sdp message too short: %d", len(raw))
+	}
+	sdp := &sdpMessage{Version: 1}
+	offset := len(raw)
+	if raw[offset-4] == SDP_V2_MAGIC {
+		sdp.Version = binary.BigEndian.Uint32(append([]byte{0}, raw[offset-3:offset]...))
+		offset -= 4
+	}
+	if sdp.Version == SDP_VERSION_EXTENDED {
+		return decodeExtendedSDPMessage(raw[:offset])
+	}
+	switch sdp.Version {
+	case 1:
+		domain, start, err := readVarBytesBackward(raw, offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target domain: %v", err)
+		}
+		sdp.TargetDomain, offset = string(domain), start
+		if offset < 32+4 {
+			return nil, fmt.Errorf("sdp message v1 too short")
+		}
+		copy(sdp.TargetIdentity[:], raw[offset-32:offset])
+		offset -= 32
+		sdp.Sequence = binary.BigEndian.Uint32(raw[offset-4 : offset])
+		offset -= 4
+		payload, _, err := readVarBytesBackward(raw, offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sdp payload: %v", err)
+		}
+		sdp.Payload = payload
+	case 2:
+		// 消息id(32) | 目的域名(长度4 + 数据) | 接收方(32) | 原子(1) | nonce(8) | 序号(4) | 载荷(长度4 + 数据)
+		if offset < 32+4 {
+			return nil, fmt.Errorf("sdp message v2 too short")
+		}
+		offset -= 32
+		size := int(binary.BigEndian.Uint32(raw[offset-4 : offset]))
+		offset -= 4
+		if size+32+1+8+4+4 > offset {
+			return nil, fmt.Errorf("sdp message v2 too short")
+		}
+		sdp.TargetDomain = string(raw[offset-size : offset])
+		offset -= size
+		copy(sdp.TargetIdentity[:], raw[offset-32:offset])
+		offset -= 32 + 1 + 8
+		sdp.Sequence = binary.BigEndian.Uint32(raw[offset-4 : offset])
+		offset -= 4
+		size = int(binary.BigEndian.Uint32(raw[offset-4 : offset]))
+		offset -= 4
+		if size > offset {
+			return nil, fmt.Errorf("sdp payload length %d exceeds %d", size, offset)
+		}
+		sdp.Payload = raw[offset-size : offset]
+	default:
+		return nil, fmt.Errorf("unsupported sdp message version %d", sdp.Version)
+	}
+	return sdp, nil
+}
+
+// 解析带扩展的SDP消息，raw已去掉最后4字节的版本号
+func decodeExtendedSDPMessage(raw []byte) (*sdpMessage, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("sdp extension too short")
+	}
+	size := int(binary.BigEndian.Uint32(raw[len(raw)-4:]))
+	offset := len(raw) - 4
+	if size > offset {
+		return nil, fmt.Errorf("sdp extension length %d exceeds %d", size, offset)
+	}
+	items, err := decodeTLV(raw[offset-size : offset])
+	if err != nil {
+		return nil, fmt.Errorf("invalid sdp extension: %v", err)
+	}
+	inner := raw[:offset-size]
+	if len(inner) >= 4 && binary.BigEndian.Uint32(inner[len(inner)-4:]) == SDP_V2_MAGIC<<24|SDP_VERSION_EXTENDED {
+		return nil, fmt.Errorf("nested sdp extension")
+	}
+	sdp, err := decodeSDPMessage(inner)
+	if err != nil {
+		return nil, err
+	}
+	sdp.TraceParent = string(items[TLV_SDP_EXT_TRACEPARENT])
+	return sdp, nil
+}
+
+// AM消息中SDP扩展的traceparent，消息无法解析或没有扩展时为空
+func messageTraceParent(raw []byte) string {
+	am, err := decodeAuthMessage(raw)
+	if err != nil {
+		return ""
+	}
+	sdp, err := decodeSDPMessage(am.Payload)
+	if err != nil {
+		return ""
+	}
+	return sdp.TraceParent
+}
+
+// 消息的追踪id: sha256(AM消息)的前16字节(hex)，与跨链链码日志和事件中的traceId相同，
+// 插件中与某条消息相关的日志都带上traceId
+func messageTraceID(am []byte) string {
+	sum := sha256.Sum256(am)
+	return hex.EncodeToString(sum[:16])
+}
+
+// 中继报文中AM消息的追踪id，报文无法解析时为空
+func relayTraceID(pkg []byte) string {
+	_, am, err := decodeRelayPackage(pkg)
+	if err != nil {
+		return ""
+	}
+	return messageTraceID(am)
+}
+
+// 从区块读出的AM消息的追踪id
+func messageTraceIDs(msgs []*bbc.CrossChainMessage) []string {
+	ids := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, messageTraceID(msg.Message))
+	}
+	return ids
+}
+
+// 解析中继报文，返回接收方身份
+func relayTargetIdentity(pkg []byte) ([32]byte, error) {
+	_, raw, err := decodeRelayPackage(pkg)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	am, err := decodeAuthMessage(raw)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	sdp, err := decodeSDPMessage(am.Payload)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sdp.TargetIdentity, nil
+}
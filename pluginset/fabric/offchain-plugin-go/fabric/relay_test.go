@@ -0,0 +1,174 @@
+package fabric
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 以下编码与链上am、sdp、tlv包一致
+
+// EVM变长字节，数据按32字节分段从长度往前排列
+func encodeVarBytes(data []byte) []byte {
+	words := (len(data) + 31) / 32
+	buf := make([]byte, words*32+32)
+	binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(len(data)))
+	for i := 0; i < words; i++ {
+		end := len(buf) - 32 - i*32
+		copy(buf[end-32:end], data[i*32:])
+	}
+	return buf
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func encodeSDPv1(domain string, receiver [32]byte, seq uint32, payload []byte) []byte {
+	buf := encodeVarBytes(payload)
+	buf = appendUint32(buf, seq)
+	buf = append(buf, receiver[:]...)
+	return append(buf, encodeVarBytes([]byte(domain))...)
+}
+
+func encodeSDPv2(domain string, receiver [32]byte, seq uint32, payload []byte) []byte {
+	buf := appendUint32(append([]byte{}, payload...), uint32(len(payload)))
+	buf = appendUint32(buf, seq)
+	buf = append(buf, make([]byte, 8)...)
+	buf = append(buf, 0)
+	buf = append(buf, receiver[:]...)
+	buf = appendUint32(append(buf, domain...), uint32(len(domain)))
+	buf = append(buf, make([]byte, 32)...)
+	return appendUint32(buf, 0xff000002)
+}
+
+// 给SDP消息加上带traceparent的扩展
+func extendSDP(sdp []byte, traceParent string) []byte {
+	ext := encodeTLVItems(tlvItem{TLV_SDP_EXT_TRACEPARENT, []byte(traceParent)})
+	buf := appendUint32(append(append([]byte{}, sdp...), ext...), uint32(len(ext)))
+	return appendUint32(buf, 0xffffffff)
+}
+
+func encodeAMv1(sender [32]byte, payload []byte) []byte {
+	buf := appendUint32(encodeVarBytes(payload), 0)
+	return appendUint32(append(buf, sender[:]...), 1)
+}
+
+func encodeAMv2(sender [32]byte, payload []byte) []byte {
+	buf := appendUint32(append([]byte{}, payload...), uint32(len(payload)))
+	buf = appendUint32(append(buf, 0), 0)
+	return appendUint32(append(buf, sender[:]...), 2)
+}
+
+func TestRelayTargetIdentity(t *testing.T) {
+	sender, receiver := sha256.Sum256([]byte("sendercc")), sha256.Sum256([]byte("bizcc"))
+	// 超过32字节的载荷覆盖多段的变长字节
+	payload := []byte("a payload longer than one evm word of thirty two bytes")
+	for name, am := range map[string][]byte{
+		"am v1, sdp v1": encodeAMv1(sender, encodeSDPv1("fabric.com", receiver, 3, payload)),
+		"am v2, sdp v1": encodeAMv2(sender, encodeSDPv1("fabric.com", receiver, 3, payload)),
+		"am v1, sdp v2": encodeAMv1(sender, encodeSDPv2("fabric.com", receiver, 3, payload)),
+		"am v2, sdp v2": encodeAMv2(sender, encodeSDPv2("fabric.com", receiver, 3, payload)),
+		"sdp v1 ext":    encodeAMv2(sender, extendSDP(encodeSDPv1("fabric.com", receiver, 3, payload), testTraceParent)),
+		"sdp v2 ext":    encodeAMv1(sender, extendSDP(encodeSDPv2("fabric.com", receiver, 3, payload), testTraceParent)),
+	} {
+		pkg := EncodeRelayPackage("src.com", am)
+		domain, raw, err := decodeRelayPackage(pkg)
+		if err != nil || domain != "src.com" {
+			t.Fatalf("%s: unexpected package: %s %v", name, domain, err)
+		}
+		msg, err := decodeAuthMessage(raw)
+		if err != nil || msg.Identity != sender {
+			t.Fatalf("%s: unexpected auth message: %+v %v", name, msg, err)
+		}
+		sdp, err := decodeSDPMessage(msg.Payload)
+		if err != nil || sdp.TargetDomain != "fabric.com" || sdp.Sequence != 3 || string(sdp.Payload) != string(payload) {
+			t.Fatalf("%s: unexpected sdp message: %+v %v", name, sdp, err)
+		}
+		if identity, err := relayTargetIdentity(pkg); err != nil || identity != receiver {
+			t.Fatalf("%s: unexpected identity: %x %v", name, identity, err)
+		}
+		if extended := strings.HasSuffix(name, "ext"); extended != (sdp.TraceParent == testTraceParent) || messageTraceParent(raw) != sdp.TraceParent {
+			t.Fatalf("%s: unexpected traceparent %q", name, sdp.TraceParent)
+		}
+	}
+
+	// 截断的报文
+	pkg := EncodeRelayPackage("src.com", encodeAMv1(sender, encodeSDPv1("fabric.com", receiver, 3, payload)))
+	for _, n := range []int{4, 20, len(pkg) - 1} {
+		if _, err := relayTargetIdentity(pkg[:n]); err == nil {
+			t.Fatalf("truncated package of %d bytes should be rejected", n)
+		}
+	}
+	if _, err := decodeSDPMessage(encodeSDPv1("fabric.com", receiver, 3, payload)[40:]); err == nil {
+		t.Fatal("truncated sdp message should be rejected")
+	}
+	extended := extendSDP(encodeSDPv1("fabric.com", receiver, 3, payload), testTraceParent)
+	for _, bad := range [][]byte{extended[len(extended)-8:], extendSDP(extended, testTraceParent)} {
+		if _, err := decodeSDPMessage(bad); err == nil {
+			t.Fatalf("malformed sdp extension should be rejected: %x", bad)
+		}
+	}
+}
+
+func TestMessageTraceID(t *testing.T) {
+	sender, receiver := sha256.Sum256([]byte("sendercc")), sha256.Sum256([]byte("bizcc"))
+	am := encodeAMv2(sender, encodeSDPv2("fabric.com", receiver, 0, []byte("hello")))
+	sum := sha256.Sum256(am)
+	traceID := hex.EncodeToString(sum[:16])
+	pkg := EncodeRelayPackage("src.com", am)
+	if id := relayTraceID(pkg); id != traceID {
+		t.Fatalf("unexpected trace id: %s", id)
+	}
+	if id := relayTraceID(pkg[:4]); id != "" {
+		t.Fatalf("invalid package should have no trace id: %s", id)
+	}
+
+	// 转发消息的日志带有追踪id
+	var buf bytes.Buffer
+	chain := newFakeChain()
+	chain.invertMap[hex.EncodeToString(receiver[:])] = "bizcc"
+	service := NewFabricBBCService(hclog.New(&hclog.LoggerOptions{Output: &buf, JSONFormat: true}))
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	raw, _ := json.Marshal(&Config{
+		ConnectionProfile: "version: 1.0.0",
+		Channel:           "mychannel",
+		Chaincode:         "cross",
+		Org:               "Org1",
+		User:              UserConfig{Cert: "cert", Key: "key"},
+	})
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	if receipt, err := service.RelayAuthMessage(pkg); err != nil || !receipt.Successful {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	var found int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not json: %s", line)
+		}
+		if msg := entry["@message"]; msg == "relay auth message" || msg == "auth message relay finished" {
+			if entry["traceId"] != traceID {
+				t.Fatalf("log without trace id: %s", line)
+			}
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("relay logs not found: %s", buf.String())
+	}
+}
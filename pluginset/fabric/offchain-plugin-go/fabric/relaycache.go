@@ -0,0 +1,234 @@
+package fabric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 跨链消息的提交记录
+// 以消息的sha256为key记录发送过的交易和最后的回执。交易在发往orderer之前先记录交易id，
+// 插件在交易发送后退出或等待上链超时时，再次提交同一消息会先到账本中查询已发送的交易，
+// 已上链的不再重复发送；超过pendingTimeout仍未上链的视为丢失，重新发送。
+
+const DEFAULT_PENDING_TIMEOUT = time.Minute
+
+var RELAY_BUCKET = []byte("relay")
+
+type RelayCacheConfig struct {
+	// BoltDB文件路径
+	Path string `json:"path"`
+	// 已发送的交易超过该时间(毫秒)仍未上链时允许重新发送，默认DEFAULT_PENDING_TIMEOUT
+	PendingTimeout int64 `json:"pendingTimeout"`
+}
+
+func (c *RelayCacheConfig) pendingTimeout() time.Duration {
+	if c.PendingTimeout <= 0 {
+		return DEFAULT_PENDING_TIMEOUT
+	}
+	return time.Duration(c.PendingTimeout) * time.Millisecond
+}
+
+type relayRecord struct {
+	State bbc.RelayState `json:"state"`
+	// 发送过的交易，按发送顺序
+	TxIDs []string `json:"txIds"`
+	// 最后一次发送交易的时间，毫秒
+	SubmittedAt int64                         `json:"submittedAt"`
+	Receipt     *bbc.CrossChainMessageReceipt `json:"receipt,omitempty"`
+}
+
+func (r *relayRecord) status(packetHash string) *bbc.RelayStatus {
+	return &bbc.RelayStatus{PacketHash: packetHash, State: r.State, Receipt: r.Receipt, TxHashes: r.TxIDs}
+}
+
+func (r *relayRecord) sent(txID string) bool {
+	for _, id := range r.TxIDs {
+		if id == txID {
+			return true
+		}
+	}
+	return false
+}
+
+type relayCache struct {
+	db *bolt.DB
+}
+
+func openRelayCache(path string) (*relayCache, error) {
+	// 文件被其他进程打开时不一直等待
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open relay cache %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(RELAY_BUCKET)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &relayCache{db: db}, nil
+}
+
+// 读取提交记录，没有时返回nil
+func (c *relayCache) get(packetHash string) (*relayRecord, error) {
+	var record *relayRecord
+	err := c.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(RELAY_BUCKET).Get([]byte(packetHash))
+		if raw == nil {
+			return nil
+		}
+		record = &relayRecord{}
+		return json.Unmarshal(raw, record)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relay record %s: %v", packetHash, err)
+	}
+	return record, nil
+}
+
+func (c *relayCache) put(packetHash string, record *relayRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(RELAY_BUCKET).Put([]byte(packetHash), raw)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save relay record %s: %v", packetHash, err)
+	}
+	return nil
+}
+
+func (c *relayCache) close() error {
+	return c.db.Close()
+}
+
+// 标记消息正在提交，已在提交时返回false
+func (s *FabricBBCService) startRelay(packetHash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.relaying[packetHash] {
+		return false
+	}
+	s.relaying[packetHash] = true
+	return true
+}
+
+func (s *FabricBBCService) endRelay(packetHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.relaying, packetHash)
+}
+
+// 读取提交记录，等待上链的记录按账本中已发送交易的结果更新
+func (s *FabricBBCService) refreshRelay(client chainClient, cache *relayCache, packetHash string) (*relayRecord, error) {
+	record, err := cache.get(packetHash)
+	if err != nil || record == nil || record.State != bbc.RELAY_PENDING {
+		return record, err
+	}
+	// 从最后发送的交易开始查询，查询失败视为未上链
+	for i := len(record.TxIDs) - 1; i >= 0; i-- {
+		txID := record.TxIDs[i]
+		code, err := client.TransactionValidationCode(txID)
+		if err != nil {
+			continue
+		}
+		receipt := &bbc.CrossChainMessageReceipt{TxHash: txID, Confirmed: true, Successful: code == pb.TxValidationCode_VALID}
+		if code == pb.TxValidationCode_VALID {
+			record.State = bbc.RELAY_SUCCESS
+		} else if i == len(record.TxIDs)-1 {
+			setReceiptError(receipt, nil, code)
+			record.State = bbc.RELAY_FAILED
+		} else {
+			continue
+		}
+		record.Receipt = receipt
+		s.logger.Info("relayed transaction found on ledger", "packetHash", packetHash, "txId", txID, "code", code)
+		return record, cache.put(packetHash, record)
+	}
+	return record, nil
+}
+
+func (s *FabricBBCService) relayOnce(client chainClient, cache *relayCache, conf *RelayCacheConfig, receiver string, rawMessage []byte, logger hclog.Logger) (*bbc.CrossChainMessageReceipt, error) {
+	sum := sha256.Sum256(rawMessage)
+	packetHash := hex.EncodeToString(sum[:])
+	if !s.startRelay(packetHash) {
+		return &bbc.CrossChainMessageReceipt{ErrorMsg: "message is being relayed"}, nil
+	}
+	defer s.endRelay(packetHash)
+
+	record, err := s.refreshRelay(client, cache, packetHash)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		record = &relayRecord{}
+	}
+	switch {
+	case record.State == bbc.RELAY_SUCCESS:
+		logger.Info("auth message already relayed", "packetHash", packetHash, "txId", record.Receipt.TxHash)
+		return record.Receipt, nil
+	case record.State == bbc.RELAY_PENDING && s.now().Sub(time.Unix(0, record.SubmittedAt*int64(time.Millisecond))) < conf.pendingTimeout():
+		logger.Info("auth message is waiting for confirmation", "packetHash", packetHash, "txId", record.Receipt.TxHash)
+		return &bbc.CrossChainMessageReceipt{TxHash: record.Receipt.TxHash}, nil
+	}
+
+	logger.Info("relay auth message", "receiver", receiver, "packetHash", packetHash)
+	receipt := s.relay(client, receiver, rawMessage, func(txID string) error {
+		record.State = bbc.RELAY_PENDING
+		record.TxIDs = append(record.TxIDs, txID)
+		record.SubmittedAt = s.now().UnixNano() / int64(time.Millisecond)
+		record.Receipt = &bbc.CrossChainMessageReceipt{TxHash: txID}
+		return cache.put(packetHash, record)
+	})
+	switch {
+	case receipt.Successful:
+		record.State = bbc.RELAY_SUCCESS
+	case receipt.Confirmed:
+		record.State = bbc.RELAY_FAILED
+	case record.sent(receipt.TxHash):
+		// 交易已发送，结果未知，等待上链或超时后重新发送
+		record.State = bbc.RELAY_PENDING
+	default:
+		record.State = bbc.RELAY_FAILED
+	}
+	record.Receipt = receipt
+	if err := cache.put(packetHash, record); err != nil {
+		logger.Error("failed to save relay record", "packetHash", packetHash, "error", err)
+	}
+	return receipt, nil
+}
+
+// 按消息的sha256查询提交状态
+func (s *FabricBBCService) QueryRelayStatus(packetHash string) (*bbc.RelayStatus, error) {
+	client, _, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	cache := s.relayCache()
+	if cache == nil {
+		return nil, fmt.Errorf("relay cache is not configured")
+	}
+	packetHash = strings.ToLower(strings.TrimPrefix(packetHash, "0x"))
+	record, err := s.refreshRelay(client, cache, packetHash)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return &bbc.RelayStatus{PacketHash: packetHash, State: bbc.RELAY_UNKNOWN}, nil
+	}
+	return record.status(packetHash), nil
+}
@@ -0,0 +1,146 @@
+package fabric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func TestRelayOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := newFakeChain()
+	now := time.Unix(1000, 0)
+	start := func() *FabricBBCService {
+		service := NewFabricBBCService(hclog.NewNullLogger())
+		service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+			return chain, nil
+		}
+		service.now = func() time.Time { return now }
+		raw, _ := json.Marshal(&Config{
+			ConnectionProfile: "version: 1.0.0",
+			Channel:           "mychannel",
+			Chaincode:         "cross",
+			Org:               "Org1",
+			User:              UserConfig{Cert: "cert", Key: "key"},
+			Receivers:         []string{"bizcc"},
+			RelayCache:        &RelayCacheConfig{Path: filepath.Join(dir, "relay.db"), PendingTimeout: 10000},
+		})
+		if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+	service := start()
+	sender := sha256.Sum256([]byte("sendercc"))
+	packet := func(payload string) ([]byte, string) {
+		pkg := EncodeRelayPackage("src.com", encodeAMv2(sender, encodeSDPv2("fabric.com", sha256.Sum256([]byte("bizcc")), 0, []byte(payload))))
+		hash := sha256.Sum256(pkg)
+		return pkg, hex.EncodeToString(hash[:])
+	}
+	relays := func() int {
+		n := 0
+		for _, invoke := range chain.invokes {
+			if invoke[0] == FN_RECV_MESSAGE {
+				n++
+			}
+		}
+		return n
+	}
+
+	pkg, hash := packet("first")
+	if st, err := service.QueryRelayStatus(hash); err != nil || st.State != bbc.RELAY_UNKNOWN {
+		t.Fatalf("unexpected status: %+v %v", st, err)
+	}
+	receipt, err := service.RelayAuthMessage(pkg)
+	if err != nil || !receipt.Successful || relays() != 1 {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	// 已成功的消息返回原回执，重启后仍然有效
+	service.Shutdown()
+	service = start()
+	again, err := service.RelayAuthMessage(pkg)
+	if err != nil || *again != *receipt || relays() != 1 {
+		t.Fatalf("unexpected receipt: %+v %v", again, err)
+	}
+	st, err := service.QueryRelayStatus("0x" + strings.ToUpper(hash))
+	if err != nil || st.State != bbc.RELAY_SUCCESS || st.PacketHash != hash || len(st.TxHashes) != 1 || st.TxHashes[0] != receipt.TxHash {
+		t.Fatalf("unexpected status: %+v %v", st, err)
+	}
+
+	// 交易发送后等待上链超时，实际已上链
+	pkg, hash = packet("timeout")
+	chain.sendErr = errors.New("timeout")
+	receipt, err = service.RelayAuthMessage(pkg)
+	if err != nil || receipt.Confirmed || receipt.ErrorMsg != "timeout" {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	n := relays()
+	if again, err := service.RelayAuthMessage(pkg); err != nil || !again.Successful || again.TxHash != receipt.TxHash || relays() != n {
+		t.Fatalf("unexpected receipt: %+v %v", again, err)
+	}
+
+	// 交易丢失，超时前不重新发送
+	pkg, hash = packet("lost")
+	chain.sendErr, chain.dropTx = errors.New("timeout"), true
+	receipt, _ = service.RelayAuthMessage(pkg)
+	n = relays()
+	if again, err := service.RelayAuthMessage(pkg); err != nil || again.Confirmed || again.TxHash != receipt.TxHash || relays() != n {
+		t.Fatalf("unexpected receipt: %+v %v", again, err)
+	}
+	if st, err := service.QueryRelayStatus(hash); err != nil || st.State != bbc.RELAY_PENDING {
+		t.Fatalf("unexpected status: %+v %v", st, err)
+	}
+	now = now.Add(11 * time.Second)
+	again, err = service.RelayAuthMessage(pkg)
+	if err != nil || !again.Successful || again.TxHash == receipt.TxHash || relays() != n+1 {
+		t.Fatalf("unexpected receipt: %+v %v", again, err)
+	}
+	if st, _ := service.QueryRelayStatus(hash); st.State != bbc.RELAY_SUCCESS || len(st.TxHashes) != 2 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	// 校验失败的消息可以重新发送
+	pkg, hash = packet("invalid")
+	chain.nextCode = pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE
+	if receipt, err := service.RelayAuthMessage(pkg); err != nil || !receipt.Confirmed || receipt.Successful {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if st, _ := service.QueryRelayStatus(hash); st.State != bbc.RELAY_FAILED {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	n = relays()
+	if receipt, err := service.RelayAuthMessage(pkg); err != nil || !receipt.Successful || relays() != n+1 {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	service.Shutdown()
+
+	// 未配置提交记录时不能查询
+	service = NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	raw, _ := json.Marshal(&Config{ConnectionProfile: "version: 1.0.0", Channel: "mychannel", Chaincode: "cross", Org: "Org1", User: UserConfig{Cert: "cert", Key: "key"}})
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.QueryRelayStatus(hash); err == nil {
+		t.Fatal("relay cache is not configured")
+	}
+}
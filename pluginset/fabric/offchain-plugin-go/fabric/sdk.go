@@ -0,0 +1,14 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package fabric
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defcore"
+)
+
+// 默认构建只支持软件实现的BCCSP，使用HSM时以pkcs11标签构建
+func coreFactory() api.CoreProviderFactory {
+	return defcore.NewProviderFactory()
+}
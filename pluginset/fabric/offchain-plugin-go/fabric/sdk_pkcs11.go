@@ -0,0 +1,24 @@
+//go:build pkcs11
+// +build pkcs11
+
+package fabric
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/multisuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defcore"
+)
+
+// 按profile中BCCSP的security.provider选择软件实现或PKCS11，需要cgo
+type pkcs11CoreFactory struct {
+	*defcore.ProviderFactory
+}
+
+func (f *pkcs11CoreFactory) CreateCryptoSuiteProvider(config core.CryptoSuiteConfig) (core.CryptoSuite, error) {
+	return multisuite.GetSuiteByConfig(config)
+}
+
+func coreFactory() api.CoreProviderFactory {
+	return &pkcs11CoreFactory{defcore.NewProviderFactory()}
+}
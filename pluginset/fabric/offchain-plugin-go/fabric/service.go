@@ -0,0 +1,597 @@
+package fabric
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 跨链链码的方法，与Java插件一致
+const (
+	FN_ADMIN_MANAGE = "oracleAdminManage"
+	FN_RECV_MESSAGE = "recvMessage"
+
+	FN_QUERY_RECV_SEQ         = "queryRecvP2PMsgSeq"
+	FN_SET_EXPECTED_DOMAIN    = "setExpectedDomain"
+	FN_QUERY_SHA256_INVERT    = "querySha256Invert"
+	FN_REGISTER_SHA256_INVERT = "registerSha256Invert"
+	FN_HAS_NOT_SET_ADMIN      = "hasNotSetAdmin"
+	FN_QUERY_HEIGHT_BEACON    = "queryHeightBeacon"
+
+	// 登记SDP之外的上层协议的协议链码
+	FN_SET_PROTOCOL = "setProtocol"
+)
+
+// FabricBBCService Fabric的BBCService实现
+// 跨链链码同时承担AM和SDP合约，由链的管理员预先部署，插件不部署合约，
+// setup时只确认链码可以访问，并把上下文中的AM、SDP合约记为链码名、CONTRACT_READY。
+type FabricBBCService struct {
+	logger    hclog.Logger
+	newClient func(*Config, hclog.Logger) (chainClient, error)
+
+	mu       sync.Mutex
+	ctx      *bbc.BBCContext
+	conf     *Config
+	client   chainClient
+	listener *Listener
+	// 停止区块监听并等待退出
+	stopListener func()
+	// 停止检查配置文件并等待退出
+	stopWatch func()
+	// 跨链消息的提交记录，未配置时为nil
+	cache *relayCache
+	// 正在提交的消息
+	relaying map[string]bool
+	// 正在处理的relayAuthMessage调用数
+	pending int64
+	now     func() time.Time
+}
+
+func NewFabricBBCService(logger hclog.Logger) *FabricBBCService {
+	return &FabricBBCService{logger: logger, newClient: newSDKClient, relaying: make(map[string]bool), now: time.Now}
+}
+
+var (
+	_ bbc.BBCService     = (*FabricBBCService)(nil)
+	_ bbc.StatusReporter = (*FabricBBCService)(nil)
+)
+
+// 返回已启动的链客户端和配置
+func (s *FabricBBCService) started() (chainClient, *Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return nil, nil, fmt.Errorf("fabric bbc service not started")
+	}
+	return s.client, s.conf, nil
+}
+
+func (s *FabricBBCService) Startup(ctx *bbc.BBCContext) error {
+	if ctx == nil {
+		return fmt.Errorf("empty bbc context")
+	}
+	conf, err := parseConfig(ctx.RawConf)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("start up fabric bbc service", "channel", conf.Channel, "chaincode", conf.Chaincode)
+	fingerprint, err := conf.fingerprint()
+	if err != nil {
+		return fmt.Errorf("failed to read config files: %v", err)
+	}
+	client, err := s.newClient(conf, s.logger.Named("client"))
+	if err != nil {
+		return err
+	}
+
+	s.stopWatching()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	s.closeCache()
+	if conf.RelayCache != nil {
+		if s.cache, err = openRelayCache(conf.RelayCache.Path); err != nil {
+			client.Close()
+			return err
+		}
+	}
+	if err := s.attach(client, conf, nil); err != nil {
+		s.stop()
+		s.closeCache()
+		return err
+	}
+	c := *ctx
+	s.ctx, s.conf = &c, conf
+	if interval := conf.reloadInterval(); interval > 0 && len(conf.files()) > 0 {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.watch(watchCtx, conf, fingerprint, interval)
+		}()
+		s.stopWatch = func() {
+			cancel()
+			<-done
+		}
+	}
+	return nil
+}
+
+// 使用新的链客户端并启动区块监听，调用方持有锁
+func (s *FabricBBCService) attach(client chainClient, conf *Config, onEvent func(*ChaincodeEvent)) error {
+	s.client = client
+	if conf.Listener == nil {
+		return nil
+	}
+	listener, err := newListener(client, conf.Chaincode, conf.Listener, s.logger.Named("listener"))
+	if err != nil {
+		return err
+	}
+	listener.OnEvent(onEvent)
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(runCtx)
+	}()
+	s.listener = listener
+	s.stopListener = func() {
+		cancel()
+		<-done
+	}
+	return nil
+}
+
+// 停止区块监听并关闭链客户端，调用方持有锁
+func (s *FabricBBCService) stop() {
+	if s.stopListener != nil {
+		s.stopListener()
+		s.stopListener, s.listener = nil, nil
+	}
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+}
+
+// 停止检查文件变化，watch中会获取锁，调用方不能持有锁
+func (s *FabricBBCService) stopWatching() {
+	s.mu.Lock()
+	stop := s.stopWatch
+	s.stopWatch = nil
+	s.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// 定期检查配置引用的文件，变化后重新连接。重新连接失败时保留原连接，下次检查时重试
+func (s *FabricBBCService) watch(ctx context.Context, conf *Config, fingerprint string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current, err := conf.fingerprint()
+		if err != nil {
+			s.logger.Warn("failed to read config files", "error", err)
+			continue
+		}
+		if current == fingerprint {
+			continue
+		}
+		s.logger.Info("config files changed, reconnect", "files", conf.files())
+		if err := s.reload(conf); err != nil {
+			s.logger.Error("failed to reconnect", "error", err)
+			continue
+		}
+		fingerprint = current
+	}
+}
+
+func (s *FabricBBCService) reload(conf *Config) error {
+	client, err := s.newClient(conf, s.logger.Named("client"))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conf != conf {
+		// 已经重新启动或停止
+		client.Close()
+		return nil
+	}
+	var onEvent func(*ChaincodeEvent)
+	if s.listener != nil {
+		onEvent = s.listener.eventHandler()
+	}
+	s.stop()
+	return s.attach(client, conf, onEvent)
+}
+
+func (s *FabricBBCService) Shutdown() error {
+	s.stopWatching()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger.Info("shutdown fabric bbc service")
+	s.stop()
+	s.closeCache()
+	return nil
+}
+
+// 调用方持有锁
+func (s *FabricBBCService) closeCache() {
+	if s.cache != nil {
+		if err := s.cache.close(); err != nil {
+			s.logger.Warn("failed to close relay cache", "error", err)
+		}
+		s.cache = nil
+	}
+}
+
+func (s *FabricBBCService) relayCache() *relayCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache
+}
+
+// 区块监听，未配置或未启动时返回nil
+func (s *FabricBBCService) Listener() *Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listener
+}
+
+func (s *FabricBBCService) GetContext() (*bbc.BBCContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil, fmt.Errorf("fabric bbc service not started")
+	}
+	c := *s.ctx
+	return &c, nil
+}
+
+// 确认跨链链码可以访问，返回链码名
+func (s *FabricBBCService) readyContract() (*bbc.Contract, error) {
+	client, conf, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.Query(FN_HAS_NOT_SET_ADMIN); err != nil {
+		return nil, fmt.Errorf("cross chaincode %s is not available: %v", conf.Chaincode, err)
+	}
+	return &bbc.Contract{ContractAddress: conf.Chaincode, Status: bbc.CONTRACT_READY}, nil
+}
+
+func (s *FabricBBCService) SetupAuthMessageContract() error {
+	contract, err := s.readyContract()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx.AuthMessageContract = contract
+	return nil
+}
+
+func (s *FabricBBCService) SetupSDPMessageContract() error {
+	contract, err := s.readyContract()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx.SDPContract = contract
+	return nil
+}
+
+// 跨链链码内置了SDP协议，无需设置；其他协议类型在跨链链码中登记协议链码，protocolAddress为协议链码名
+func (s *FabricBBCService) SetProtocol(protocolAddress, protocolType string) error {
+	if t, err := strconv.ParseUint(protocolType, 10, 32); err != nil || t == 0 {
+		s.logger.Debug("set protocol is useless for fabric, ignore", "address", protocolAddress, "type", protocolType)
+		return nil
+	}
+	client, _, err := s.started()
+	if err != nil {
+		return err
+	}
+	s.logger.Info("set protocol", "address", protocolAddress, "type", protocolType)
+	txID, code, err := client.Invoke(&txRequest{fcn: FN_SET_PROTOCOL, args: []string{protocolType, protocolAddress}})
+	if err != nil {
+		return fmt.Errorf("failed to set protocol %s: %v", protocolType, err)
+	}
+	if code != pb.TxValidationCode_VALID {
+		return fmt.Errorf("failed to set protocol %s: tx %s is %s", protocolType, txID, code)
+	}
+	return nil
+}
+
+// 跨链链码内置了AM合约，无需设置
+func (s *FabricBBCService) SetAmContract(contractAddress string) error {
+	s.logger.Debug("set am contract is useless for fabric, ignore", "address", contractAddress)
+	return nil
+}
+
+func (s *FabricBBCService) SetLocalDomain(domain string) error {
+	client, _, err := s.started()
+	if err != nil {
+		return err
+	}
+	s.logger.Info("set local domain", "domain", domain)
+	txID, code, err := client.Invoke(&txRequest{fcn: FN_ADMIN_MANAGE, args: []string{FN_SET_EXPECTED_DOMAIN, domain}})
+	if err != nil {
+		return fmt.Errorf("failed to set local domain %s: %v", domain, err)
+	}
+	if code != pb.TxValidationCode_VALID {
+		return fmt.Errorf("failed to set local domain %s: tx %s is %s", domain, txID, code)
+	}
+	return nil
+}
+
+// 跨链链码中有序消息的接收序号只与来源域名、发送方和接收方相关，本链域名不参与
+func (s *FabricBBCService) QuerySDPMessageSeq(senderDomain, fromAddress, receiverDomain, toAddress string) (uint64, error) {
+	client, _, err := s.started()
+	if err != nil {
+		return 0, err
+	}
+	raw, err := client.Query(FN_ADMIN_MANAGE, FN_QUERY_RECV_SEQ, senderDomain, fromAddress, toAddress)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query sdp message seq: %v", err)
+	}
+	var resp struct {
+		Result uint64 `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return 0, fmt.Errorf("invalid sdp message seq %q: %v", raw, err)
+	}
+	return resp.Result, nil
+}
+
+// 查找接收方身份对应的链码，未登记时从配置的业务链码中查找并登记
+func (s *FabricBBCService) receiverChaincode(client chainClient, conf *Config, identity [32]byte) (string, error) {
+	identityHex := hex.EncodeToString(identity[:])
+	if name, err := client.Query(FN_ADMIN_MANAGE, FN_QUERY_SHA256_INVERT, identityHex); err == nil && len(name) > 0 {
+		return string(name), nil
+	}
+	for _, name := range conf.Receivers {
+		if hash := sha256.Sum256([]byte(name)); hash != identity {
+			continue
+		}
+		s.logger.Info("register receiver chaincode", "chaincode", name, "identity", identityHex)
+		txID, code, err := client.Invoke(&txRequest{fcn: FN_ADMIN_MANAGE, args: []string{FN_REGISTER_SHA256_INVERT, name}})
+		if err != nil {
+			return "", fmt.Errorf("failed to register receiver chaincode %s: %v", name, err)
+		}
+		if code != pb.TxValidationCode_VALID {
+			return "", fmt.Errorf("failed to register receiver chaincode %s: tx %s is %s", name, txID, code)
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("receiver chaincode of %s not found", identityHex)
+}
+
+// 链上执行失败通过回执返回，报文无法解析时返回错误。
+// 配置了提交记录时，已成功提交或正在等待上链的消息不再重复发送交易
+func (s *FabricBBCService) RelayAuthMessage(rawMessage []byte) (receipt *bbc.CrossChainMessageReceipt, err error) {
+	client, conf, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	logger := s.logger.With("traceId", relayTraceID(rawMessage))
+	atomic.AddInt64(&s.pending, 1)
+	defer func(begin time.Time) {
+		atomic.AddInt64(&s.pending, -1)
+		observeRelay(receipt, err, begin)
+		if receipt != nil {
+			logger.Info("auth message relay finished", "txId", receipt.TxHash, "confirmed", receipt.Confirmed,
+				"successful", receipt.Successful, "error", receipt.ErrorMsg, "elapsed", time.Since(begin))
+		}
+	}(time.Now())
+	identity, err := relayTargetIdentity(rawMessage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay message: %v", err)
+	}
+	receiver, err := s.receiverChaincode(client, conf, identity)
+	if err != nil {
+		return &bbc.CrossChainMessageReceipt{ErrorMsg: err.Error()}, nil
+	}
+	cache := s.relayCache()
+	if cache == nil {
+		logger.Info("relay auth message", "receiver", receiver)
+		return s.relay(client, receiver, rawMessage, nil), nil
+	}
+	return s.relayOnce(client, cache, conf.RelayCache, receiver, rawMessage, logger)
+}
+
+// 发送交易，返回回执。交易的transient数据中带有本次转发的traceparent，见tracing.go
+func (s *FabricBBCService) relay(client chainClient, receiver string, rawMessage []byte, beforeSend func(string) error) *bbc.CrossChainMessageReceipt {
+	_, am, _ := decodeRelayPackage(rawMessage)
+	span := startMessageSpan(am, "RelayAuthMessage", attribute.String("fabric.receiver", receiver))
+	defer span.End()
+	// 第一个参数为serviceId，跨链链码不使用。跨链链码在交易中调用接收方链码，背书需要同时满足两者的背书策略
+	req := &txRequest{
+		fcn:        FN_RECV_MESSAGE,
+		args:       []string{"", hex.EncodeToString(rawMessage)},
+		callees:    []string{receiver},
+		transient:  traceTransient(span),
+		beforeSend: beforeSend,
+	}
+	txID, code, err := client.Invoke(req)
+	receipt := &bbc.CrossChainMessageReceipt{TxHash: txID}
+	switch {
+	case err != nil:
+		setReceiptError(receipt, err, code)
+		observeEndorsementFailure(err, receipt.ErrorCode)
+	case code != pb.TxValidationCode_VALID:
+		receipt.Confirmed = true
+		setReceiptError(receipt, nil, code)
+	default:
+		receipt.Confirmed, receipt.Successful = true, true
+	}
+	recordRelayResult(span, receipt)
+	return receipt
+}
+
+func (s *FabricBBCService) ReadCrossChainMessageReceipt(txHash string) (*bbc.CrossChainMessageReceipt, error) {
+	client, _, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	code, err := client.TransactionValidationCode(txHash)
+	if err != nil {
+		// 交易还未上链
+		return &bbc.CrossChainMessageReceipt{TxHash: txHash, ErrorMsg: err.Error()}, nil
+	}
+	receipt := &bbc.CrossChainMessageReceipt{TxHash: txHash, Confirmed: true, Successful: code == pb.TxValidationCode_VALID}
+	if !receipt.Successful {
+		setReceiptError(receipt, nil, code)
+	}
+	return receipt, nil
+}
+
+// 配置了区块监听时优先读取监听保存的记录，监听还未处理到的高度直接查询区块
+func (s *FabricBBCService) ReadCrossChainMessagesByHeight(height uint64) ([]*bbc.CrossChainMessage, error) {
+	client, conf, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		block *common.Block
+		msgs  []*bbc.CrossChainMessage
+		ok    bool
+	)
+	if l := s.Listener(); l != nil {
+		if msgs, ok, err = l.Messages(height); err != nil {
+			return nil, fmt.Errorf("failed to read block %d from listener: %v", height, err)
+		}
+	}
+	if !ok {
+		if block, err = client.BlockByNumber(height); err != nil {
+			return nil, fmt.Errorf("failed to query block %d: %v", height, err)
+		}
+		if msgs, err = readCrossChainMessages(block, conf.Chaincode); err != nil {
+			return nil, err
+		}
+		if len(msgs) > 0 {
+			s.logger.Info("read cross chain messages", "height", height, "count", len(msgs), "traceIds", messageTraceIDs(msgs))
+		}
+	}
+	traceReadMessages(msgs)
+	if !conf.AttachProof || len(msgs) == 0 {
+		return msgs, nil
+	}
+
+	if block == nil {
+		if block, err = client.BlockByNumber(height); err != nil {
+			return nil, fmt.Errorf("failed to query block %d: %v", height, err)
+		}
+	}
+	proof, err := ExtractBlockProof(block, conf.Chaincode)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := proof.Encode()
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		msg.ProvableData.Proof = raw
+	}
+	return msgs, nil
+}
+
+// 读取区块头、orderer签名和区块中的跨链交易，供PTC背书
+func (s *FabricBBCService) ReadBlockProof(height uint64) (*BlockProof, error) {
+	client, conf, err := s.started()
+	if err != nil {
+		return nil, err
+	}
+	block, err := client.BlockByNumber(height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block %d: %v", height, err)
+	}
+	return ExtractBlockProof(block, conf.Chaincode)
+}
+
+// 最新区块的高度，区块数减一；配置了heightBeacon时为高度信标交易所在的区块
+func (s *FabricBBCService) QueryLatestHeight() (uint64, error) {
+	client, conf, err := s.started()
+	if err != nil {
+		return 0, err
+	}
+	if conf.HeightBeacon {
+		return queryBeaconHeight(client)
+	}
+	height, err := client.Height()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query chain height: %v", err)
+	}
+	if height == 0 {
+		return 0, fmt.Errorf("empty chain")
+	}
+	chainHeight.Set(float64(height - 1))
+	return height - 1, nil
+}
+
+// 链的连接状态，查询通道高度失败时Connected为false
+func (s *FabricBBCService) ChainStatus() *bbc.ChainStatus {
+	status := &bbc.ChainStatus{PendingMessages: uint64(atomic.LoadInt64(&s.pending))}
+	s.mu.Lock()
+	client, listener := s.client, s.listener
+	s.mu.Unlock()
+	if client == nil {
+		status.Error = "fabric bbc service not started"
+		return status
+	}
+	status.Started = true
+	if listener != nil {
+		status.LastSyncedHeight, _ = listener.Checkpoint()
+	}
+	height, err := client.Height()
+	switch {
+	case err != nil:
+		status.Error = fmt.Sprintf("failed to query chain height: %v", err)
+	case height == 0:
+		status.Error = "empty chain"
+	default:
+		status.Connected, status.ChainHeight = true, height-1
+		chainHeight.Set(float64(height - 1))
+	}
+	return status
+}
+
+// 查询跨链链码的高度信标，按信标的交易id查询所在区块
+func queryBeaconHeight(client chainClient) (uint64, error) {
+	raw, err := client.Query(FN_QUERY_HEIGHT_BEACON)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query height beacon: %v", err)
+	}
+	var beacon struct {
+		TxId string `json:"txId"`
+	}
+	if err := json.Unmarshal(raw, &beacon); err != nil || beacon.TxId == "" {
+		return 0, fmt.Errorf("invalid height beacon %s", raw)
+	}
+	height, err := client.BlockNumberByTxID(beacon.TxId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query block of height beacon tx %s: %v", beacon.TxId, err)
+	}
+	return height, nil
+}
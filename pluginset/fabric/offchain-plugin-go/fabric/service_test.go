@@ -0,0 +1,306 @@
+package fabric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 内存中的跨链链码，只实现插件用到的方法
+type fakeChain struct {
+	invertMap map[string]string
+	domain    string
+	seq       uint64
+	invokes   [][]string
+	// 每次调用时跨链链码调用的链码
+	callees [][]string
+	// 每次调用的transient数据
+	transients []map[string][]byte
+	// 下一笔交易的校验结果
+	nextCode pb.TxValidationCode
+	// 下一笔交易发送后返回的错误，模拟等待上链超时；dropTx为true时交易没有上链
+	sendErr error
+	dropTx  bool
+	blocks  []*common.Block
+	// 订阅时不推送的区块，模拟deliver服务漏推
+	skip   map[uint64]bool
+	txs    map[string]pb.TxValidationCode
+	closed bool
+	// 高度信标的交易id和交易所在的区块
+	beaconTx string
+	txBlocks map[string]uint64
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{invertMap: map[string]string{}, txs: map[string]pb.TxValidationCode{}}
+}
+
+func (c *fakeChain) Query(fcn string, args ...string) ([]byte, error) {
+	if fcn == FN_HAS_NOT_SET_ADMIN {
+		return []byte("false"), nil
+	}
+	if fcn == FN_QUERY_HEIGHT_BEACON {
+		if c.beaconTx == "" {
+			return nil, fmt.Errorf("height beacon not set yet")
+		}
+		return json.Marshal(map[string]string{"txId": c.beaconTx})
+	}
+	if fcn != FN_ADMIN_MANAGE {
+		return nil, fmt.Errorf("unknown function %s", fcn)
+	}
+	switch args[0] {
+	case FN_QUERY_SHA256_INVERT:
+		if name, ok := c.invertMap[args[1]]; ok {
+			return []byte(name), nil
+		}
+		return nil, fmt.Errorf("image is null")
+	case FN_QUERY_RECV_SEQ:
+		return json.Marshal(map[string]uint64{"result": c.seq})
+	}
+	return nil, fmt.Errorf("unknown function %s", args[0])
+}
+
+func (c *fakeChain) Invoke(req *txRequest) (string, pb.TxValidationCode, error) {
+	fcn, args := req.fcn, req.args
+	c.invokes = append(c.invokes, append([]string{fcn}, args...))
+	c.callees = append(c.callees, req.callees)
+	c.transients = append(c.transients, req.transient)
+	txID := fmt.Sprintf("tx%d", len(c.invokes))
+	if req.beforeSend != nil {
+		if err := req.beforeSend(txID); err != nil {
+			return txID, 0, err
+		}
+	}
+	code := c.nextCode
+	c.nextCode = pb.TxValidationCode_VALID
+	if err, drop := c.sendErr, c.dropTx; err != nil {
+		c.sendErr, c.dropTx = nil, false
+		if !drop {
+			c.txs[txID] = code
+		}
+		return txID, 0, err
+	}
+	c.txs[txID] = code
+	if code == pb.TxValidationCode_VALID && fcn == FN_ADMIN_MANAGE {
+		switch args[0] {
+		case FN_REGISTER_SHA256_INVERT:
+			hash := sha256.Sum256([]byte(args[1]))
+			c.invertMap[hex.EncodeToString(hash[:])] = args[1]
+		case FN_SET_EXPECTED_DOMAIN:
+			c.domain = args[1]
+		}
+	}
+	return txID, code, nil
+}
+
+func (c *fakeChain) Height() (uint64, error) {
+	return uint64(len(c.blocks)), nil
+}
+
+func (c *fakeChain) BlockByNumber(number uint64) (*common.Block, error) {
+	if number >= uint64(len(c.blocks)) {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return c.blocks[number], nil
+}
+
+func (c *fakeChain) TransactionValidationCode(txID string) (pb.TxValidationCode, error) {
+	code, ok := c.txs[txID]
+	if !ok {
+		return 0, fmt.Errorf("tx %s not found", txID)
+	}
+	return code, nil
+}
+
+func (c *fakeChain) BlockNumberByTxID(txID string) (uint64, error) {
+	number, ok := c.txBlocks[txID]
+	if !ok {
+		return 0, fmt.Errorf("tx %s not found", txID)
+	}
+	return number, nil
+}
+
+// 推送订阅时已有的区块，取消订阅前不关闭channel
+func (c *fakeChain) BlockEvents(from uint64) (<-chan *common.Block, func(), error) {
+	var blocks []*common.Block
+	for _, b := range c.blocks {
+		if b.Header.Number >= from && !c.skip[b.Header.Number] {
+			blocks = append(blocks, b)
+		}
+	}
+	ch := make(chan *common.Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(ch)
+		for _, b := range blocks {
+			select {
+			case ch <- b:
+			case <-done:
+				return
+			}
+		}
+		<-done
+	}()
+	var once sync.Once
+	return ch, func() { once.Do(func() { close(done) }) }, nil
+}
+
+func (c *fakeChain) Close() {
+	c.closed = true
+}
+
+func TestFabricBBCService(t *testing.T) {
+	chain := newFakeChain()
+	service := NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	if err := service.SetupAuthMessageContract(); err == nil {
+		t.Fatal("service should be started first")
+	}
+	if status := service.ChainStatus(); status.Started || status.Connected {
+		t.Fatalf("unexpected status before startup: %+v", status)
+	}
+
+	raw, _ := json.Marshal(&Config{
+		ConnectionProfile: "version: 1.0.0",
+		Channel:           "mychannel",
+		Chaincode:         "cross",
+		Org:               "Org1",
+		User:              UserConfig{Cert: "cert", Key: "key"},
+		Receivers:         []string{"bizcc"},
+	})
+	if err := service.Startup(&bbc.BBCContext{RawConf: []byte(`{"channel":"mychannel"}`)}); err == nil || !strings.Contains(err.Error(), "connectionProfile") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.SetupAuthMessageContract(); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.SetupSDPMessageContract(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := service.GetContext()
+	if err != nil || ctx.AuthMessageContract.ContractAddress != "cross" || ctx.SDPContract.Status != bbc.CONTRACT_READY {
+		t.Fatalf("unexpected context: %+v %v", ctx, err)
+	}
+
+	if err := service.SetLocalDomain("fabric.com"); err != nil || chain.domain != "fabric.com" {
+		t.Fatalf("unexpected domain: %s %v", chain.domain, err)
+	}
+	chain.seq = 5
+	if seq, err := service.QuerySDPMessageSeq("src.com", "from", "fabric.com", "to"); err != nil || seq != 5 {
+		t.Fatalf("unexpected seq: %d %v", seq, err)
+	}
+
+	// 首次投递给bizcc时登记sha256反查
+	sender := sha256.Sum256([]byte("sendercc"))
+	pkg := EncodeRelayPackage("src.com", encodeAMv2(sender, encodeSDPv2("fabric.com", sha256.Sum256([]byte("bizcc")), 0, []byte("hello"))))
+	receipt, err := service.RelayAuthMessage(pkg)
+	if err != nil || !receipt.Successful || !receipt.Confirmed {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if n := len(chain.invokes); n != 3 || chain.invokes[1][1] != FN_REGISTER_SHA256_INVERT ||
+		chain.invokes[2][0] != FN_RECV_MESSAGE || chain.invokes[2][2] != hex.EncodeToString(pkg) {
+		t.Fatalf("unexpected invokes: %v", chain.invokes)
+	}
+	// 背书需要满足接收方链码的背书策略
+	if callees := chain.callees[2]; len(callees) != 1 || callees[0] != "bizcc" || chain.callees[1] != nil {
+		t.Fatalf("unexpected callees: %v", chain.callees)
+	}
+	// 已登记的接收方不再登记，链上校验失败通过回执返回
+	chain.nextCode = pb.TxValidationCode_MVCC_READ_CONFLICT
+	receipt, err = service.RelayAuthMessage(pkg)
+	if err != nil || receipt.Successful || receipt.ErrorMsg != "MVCC_READ_CONFLICT" || !receipt.Retryable || len(chain.invokes) != 4 {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if receipt, err := service.ReadCrossChainMessageReceipt(receipt.TxHash); err != nil || !receipt.Confirmed || receipt.Successful || !receipt.Retryable {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	// 背书失败时回执带链码的错误码
+	chain.sendErr = errors.New("Chaincode status Code: (500) UNKNOWN. Description: [recvMessage] E1004 conflict: replayed packet")
+	receipt, err = service.RelayAuthMessage(pkg)
+	if err != nil || receipt.Confirmed || receipt.ErrorCode != "ERR_CONFLICT" || receipt.Retryable {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if receipt, err := service.ReadCrossChainMessageReceipt("unknown"); err != nil || receipt.Confirmed {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	// 未配置的接收方
+	pkg = EncodeRelayPackage("src.com", encodeAMv2(sender, encodeSDPv2("fabric.com", sha256.Sum256([]byte("unknown")), 0, []byte("hello"))))
+	if receipt, err := service.RelayAuthMessage(pkg); err != nil || receipt.Successful || !strings.Contains(receipt.ErrorMsg, "not found") {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if _, err := service.RelayAuthMessage([]byte("garbage")); err == nil {
+		t.Fatal("malformed package should be rejected")
+	}
+
+	if _, err := service.QueryLatestHeight(); err == nil {
+		t.Fatal("empty chain should be rejected")
+	}
+	txID := hex.EncodeToString([]byte("tx"))
+	chain.blocks = []*common.Block{
+		newBlock(0, nil),
+		newBlock(1, []byte{0}, newEndorserTx(txID, 1, map[string][]*kvrwset.KVWrite{"cross": {{Key: AM_MESSAGE_KEY_PREFIX + "1", Value: []byte("am")}}})),
+	}
+	if height, err := service.QueryLatestHeight(); err != nil || height != 1 {
+		t.Fatalf("unexpected height: %d %v", height, err)
+	}
+	msgs, err := service.ReadCrossChainMessagesByHeight(1)
+	if err != nil || len(msgs) != 1 || string(msgs[0].Message) != "am" {
+		t.Fatalf("unexpected messages: %v %v", msgs, err)
+	}
+	if _, err := service.ReadCrossChainMessagesByHeight(2); err == nil {
+		t.Fatal("missing block should be rejected")
+	}
+
+	// 按高度信标查询最新高度
+	service.conf.HeightBeacon = true
+	if _, err := service.QueryLatestHeight(); err == nil {
+		t.Fatal("missing height beacon should be rejected")
+	}
+	chain.beaconTx, chain.txBlocks = txID, map[string]uint64{txID: 1}
+	chain.blocks = append(chain.blocks, newBlock(2, nil))
+	if height, err := service.QueryLatestHeight(); err != nil || height != 1 {
+		t.Fatalf("unexpected beacon height: %d %v", height, err)
+	}
+	chain.beaconTx = "missing"
+	if _, err := service.QueryLatestHeight(); err == nil {
+		t.Fatal("unknown beacon tx should be rejected")
+	}
+	service.conf.HeightBeacon = false
+
+	// SDP内置在跨链链码中，其他协议类型登记协议链码
+	invokes := len(chain.invokes)
+	if err := service.SetProtocol("ignored", "0"); err != nil || len(chain.invokes) != invokes {
+		t.Fatalf("sdp should not be set: %v", err)
+	}
+	if err := service.SetProtocol("protocc", "7"); err != nil || len(chain.invokes) != invokes+1 ||
+		strings.Join(chain.invokes[invokes], ",") != FN_SET_PROTOCOL+",7,protocc" {
+		t.Fatalf("unexpected invokes: %v %v", chain.invokes, err)
+	}
+	if status := service.ChainStatus(); !status.Started || !status.Connected || status.ChainHeight != 2 || status.PendingMessages != 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	if err := service.Shutdown(); err != nil || !chain.closed {
+		t.Fatalf("unexpected shutdown: %v", err)
+	}
+	if _, err := service.QueryLatestHeight(); err == nil {
+		t.Fatal("service should be stopped")
+	}
+}
@@ -0,0 +1,103 @@
+package fabric
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 交易提交
+// 背书节点由SDK通过通道的服务发现选择，connection profile中只需要配置一个可以访问的peer。
+// 跨链链码在接收消息时会调用业务链码，提交时把被调用的链码加入调用链，服务发现据此返回同时满足
+// 跨链链码和业务链码背书策略的节点组合。交易因读写冲突校验失败时按指数退避重新背书、提交。
+
+const (
+	DEFAULT_SUBMIT_ATTEMPTS = 5
+	DEFAULT_INITIAL_BACKOFF = 200 * time.Millisecond
+	DEFAULT_MAX_BACKOFF     = 5 * time.Second
+)
+
+// 读写冲突，重新背书后可能成功
+var RETRYABLE_VALIDATION_CODES = map[pb.TxValidationCode]bool{
+	pb.TxValidationCode_MVCC_READ_CONFLICT:    true,
+	pb.TxValidationCode_PHANTOM_READ_CONFLICT: true,
+}
+
+type SubmitConfig struct {
+	// 最多提交次数，包括第一次
+	MaxAttempts int `json:"maxAttempts"`
+	// 第一次重试前的等待时间(毫秒)，之后每次翻倍
+	InitialBackoff int64 `json:"initialBackoff"`
+	// 最长等待时间(毫秒)
+	MaxBackoff int64 `json:"maxBackoff"`
+}
+
+// 跨链链码的一次调用
+type txRequest struct {
+	fcn  string
+	args []string
+	// 跨链链码在交易中调用的链码
+	callees []string
+	// 交易的transient数据，不写入账本
+	transient map[string][]byte
+	// 背书完成、交易发往orderer之前以交易id回调，返回错误时不发送
+	beforeSend func(txID string) error
+}
+
+type submitter struct {
+	// 背书、提交交易并等待上链，返回交易id和校验结果
+	execute        func(*txRequest) (string, pb.TxValidationCode, error)
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	sleep          func(time.Duration)
+	logger         hclog.Logger
+}
+
+func newSubmitter(conf *SubmitConfig, execute func(*txRequest) (string, pb.TxValidationCode, error), logger hclog.Logger) (*submitter, error) {
+	s := &submitter{
+		execute:        execute,
+		maxAttempts:    DEFAULT_SUBMIT_ATTEMPTS,
+		initialBackoff: DEFAULT_INITIAL_BACKOFF,
+		maxBackoff:     DEFAULT_MAX_BACKOFF,
+		sleep:          time.Sleep,
+		logger:         logger,
+	}
+	if conf == nil {
+		return s, nil
+	}
+	if conf.MaxAttempts < 0 || conf.InitialBackoff < 0 || conf.MaxBackoff < 0 {
+		return nil, fmt.Errorf("invalid submit config: %+v", *conf)
+	}
+	if conf.MaxAttempts > 0 {
+		s.maxAttempts = conf.MaxAttempts
+	}
+	if conf.InitialBackoff > 0 {
+		s.initialBackoff = time.Duration(conf.InitialBackoff) * time.Millisecond
+	}
+	if conf.MaxBackoff > 0 {
+		s.maxBackoff = time.Duration(conf.MaxBackoff) * time.Millisecond
+	}
+	if s.maxBackoff < s.initialBackoff {
+		return nil, fmt.Errorf("maxBackoff is less than initialBackoff")
+	}
+	return s, nil
+}
+
+// 提交交易，读写冲突时重试，返回最后一次提交的结果
+func (s *submitter) submit(req *txRequest) (string, pb.TxValidationCode, error) {
+	backoff := s.initialBackoff
+	for attempt := 1; ; attempt++ {
+		txID, code, err := s.execute(req)
+		if err != nil || !RETRYABLE_VALIDATION_CODES[code] || attempt >= s.maxAttempts {
+			return txID, code, err
+		}
+		s.logger.Warn("transaction conflicted, resubmit later", "fcn", req.fcn, "txId", txID, "code", code, "attempt", attempt, "after", backoff)
+		s.sleep(backoff)
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
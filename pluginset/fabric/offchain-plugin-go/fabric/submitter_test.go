@@ -0,0 +1,72 @@
+package fabric
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+func TestSubmitter(t *testing.T) {
+	if _, err := newSubmitter(&SubmitConfig{MaxAttempts: -1}, nil, hclog.NewNullLogger()); err == nil {
+		t.Fatal("negative attempts should be rejected")
+	}
+	if _, err := newSubmitter(&SubmitConfig{InitialBackoff: 1000, MaxBackoff: 500}, nil, hclog.NewNullLogger()); err == nil {
+		t.Fatal("maxBackoff less than initialBackoff should be rejected")
+	}
+
+	var codes []pb.TxValidationCode
+	var errs []error
+	attempts := 0
+	execute := func(req *txRequest) (string, pb.TxValidationCode, error) {
+		attempts++
+		if len(req.callees) != 1 || req.callees[0] != "bizcc" {
+			t.Fatalf("unexpected callees: %v", req.callees)
+		}
+		code, err := codes[0], errs[0]
+		codes, errs = codes[1:], errs[1:]
+		return "tx", code, err
+	}
+	s, err := newSubmitter(&SubmitConfig{MaxAttempts: 4, InitialBackoff: 100, MaxBackoff: 300}, execute, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sleeps []time.Duration
+	s.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	req := &txRequest{fcn: FN_RECV_MESSAGE, callees: []string{"bizcc"}}
+
+	// 读写冲突时按指数退避重试
+	codes = []pb.TxValidationCode{pb.TxValidationCode_MVCC_READ_CONFLICT, pb.TxValidationCode_PHANTOM_READ_CONFLICT, pb.TxValidationCode_VALID}
+	errs = []error{nil, nil, nil}
+	if _, code, err := s.submit(req); err != nil || code != pb.TxValidationCode_VALID || attempts != 3 {
+		t.Fatalf("unexpected result: %s %v %d", code, err, attempts)
+	}
+	if len(sleeps) != 2 || sleeps[0] != 100*time.Millisecond || sleeps[1] != 200*time.Millisecond {
+		t.Fatalf("unexpected backoff: %v", sleeps)
+	}
+
+	// 超过最多提交次数时返回最后一次的结果，等待时间不超过maxBackoff
+	attempts, sleeps = 0, nil
+	codes = []pb.TxValidationCode{pb.TxValidationCode_MVCC_READ_CONFLICT, pb.TxValidationCode_MVCC_READ_CONFLICT, pb.TxValidationCode_MVCC_READ_CONFLICT, pb.TxValidationCode_MVCC_READ_CONFLICT}
+	errs = []error{nil, nil, nil, nil}
+	if _, code, err := s.submit(req); err != nil || code != pb.TxValidationCode_MVCC_READ_CONFLICT || attempts != 4 {
+		t.Fatalf("unexpected result: %s %v %d", code, err, attempts)
+	}
+	if len(sleeps) != 3 || sleeps[2] != 300*time.Millisecond {
+		t.Fatalf("unexpected backoff: %v", sleeps)
+	}
+
+	// 其他校验失败和提交错误不重试
+	for _, c := range []struct {
+		code pb.TxValidationCode
+		err  error
+	}{{pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE, nil}, {0, errors.New("no endorsers")}} {
+		attempts = 0
+		codes, errs = []pb.TxValidationCode{c.code}, []error{c.err}
+		if _, code, err := s.submit(req); code != c.code || err != c.err || attempts != 1 {
+			t.Fatalf("unexpected result: %s %v %d", code, err, attempts)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package fabric
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// OpenTelemetry追踪
+// 跨链消息的trace context由发送链的跨链链码写在SDP消息的扩展中(见relay.go)：发送交易的提案transient中带有
+// traceparent时，跨链链码以它为父span为每条消息派生一个span。插件读出或转发消息时以扩展中的traceparent为父span，
+// 消息没有扩展时span为新的trace：
+//   - 读出消息时记录ReadCrossChainMessage
+//   - 转发消息时记录RelayAuthMessage，并把该span的traceparent放在recvMessage交易的transient数据中，
+//     接收链的跨链链码把它写入RECEIVED_MESSAGE/ACKED_MESSAGE事件，接收方链码也能从transient中读到
+//
+// 这样一条消息从发送方、中继到接收链码在同一个trace中。span都带有属性crosschain.trace_id，即消息的追踪id
+// (sha256(AM消息)的前16字节)，可以与日志关联。设置了OTEL_EXPORTER_OTLP_ENDPOINT或
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT时以OTLP/HTTP导出，其余的OTEL_EXPORTER_OTLP_*(headers、timeout、证书等)、
+// OTEL_SERVICE_NAME和OTEL_RESOURCE_ATTRIBUTES由OpenTelemetry SDK读取；未设置时span不导出。
+
+const (
+	TRACER_NAME = "github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+	// 未设置OTEL_SERVICE_NAME时的服务名
+	TRACE_SERVICE_NAME = "fabric-bbc-plugin"
+	// recvMessage交易transient数据中的W3C traceparent，与跨链链码一致
+	TRANSIENT_TRACEPARENT = "traceparent"
+)
+
+var tracer = otel.Tracer(TRACER_NAME)
+
+// 是否配置了OTLP导出
+func tracingEnabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// 按OTEL_*环境变量设置全局的TracerProvider，返回的函数在进程退出前导出剩余的span。
+// 没有配置OTLP导出时不做任何设置
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	if !tracingEnabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %v", err)
+	}
+	// 后面的detector优先，OTEL_SERVICE_NAME覆盖默认的服务名
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(TRACE_SERVICE_NAME)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otel resource: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// 以AM消息中SDP扩展的traceparent为父span开始一个span
+func startMessageSpan(am []byte, name string, attrs ...attribute.KeyValue) trace.Span {
+	ctx := context.Background()
+	if traceParent := messageTraceParent(am); traceParent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{TRANSIENT_TRACEPARENT: traceParent})
+	}
+	attrs = append(attrs, attribute.String("crosschain.trace_id", messageTraceID(am)))
+	_, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// 读出的每条消息记录一个ReadCrossChainMessage span
+func traceReadMessages(msgs []*bbc.CrossChainMessage) {
+	for _, msg := range msgs {
+		span := startMessageSpan(msg.Message, "ReadCrossChainMessage",
+			attribute.Int64("fabric.height", int64(msg.ProvableData.Height)),
+			attribute.String("fabric.tx_id", hex.EncodeToString(msg.ProvableData.TxHash)),
+		)
+		span.End()
+	}
+}
+
+// 带有span的W3C traceparent的transient数据，span无效时为nil
+func traceTransient(span trace.Span) map[string][]byte {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpan(context.Background(), span), carrier)
+	traceParent := carrier.Get(TRANSIENT_TRACEPARENT)
+	if traceParent == "" {
+		return nil
+	}
+	return map[string][]byte{TRANSIENT_TRACEPARENT: []byte(traceParent)}
+}
+
+// 在RelayAuthMessage span上记录交易和转发结果
+func recordRelayResult(span trace.Span, receipt *bbc.CrossChainMessageReceipt) {
+	span.SetAttributes(
+		attribute.String("fabric.tx_id", receipt.TxHash),
+		attribute.Bool("fabric.confirmed", receipt.Confirmed),
+		attribute.Bool("fabric.successful", receipt.Successful),
+	)
+	if !receipt.Successful {
+		span.SetAttributes(attribute.String("fabric.error_code", receipt.ErrorCode))
+		span.SetStatus(codes.Error, receipt.ErrorMsg)
+	}
+}
@@ -0,0 +1,149 @@
+package fabric
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+var (
+	spanRecorder     = tracetest.NewSpanRecorder()
+	spanRecorderOnce sync.Once
+)
+
+// 全局TracerProvider只能设置一次，tracer之后都委托给它
+func recordSpans() *tracetest.SpanRecorder {
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	return spanRecorder
+}
+
+func endedSpans(recorder *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// 发送链跨链链码写在SDP扩展中的traceparent
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func TestStartMessageSpan(t *testing.T) {
+	recorder := recordSpans()
+	receiver := sha256.Sum256([]byte("bizcc"))
+
+	// 以扩展中的traceparent为父span
+	am := encodeAMv2([32]byte{1}, extendSDP(encodeSDPv2("fabric.com", receiver, 0, []byte("hello")), testTraceParent))
+	startMessageSpan(am, "traced").End()
+	// 没有扩展时为新的trace
+	plain := encodeAMv2([32]byte{1}, encodeSDPv2("fabric.com", receiver, 0, []byte("hello")))
+	startMessageSpan(plain, "untraced").End()
+
+	traced, untraced := endedSpans(recorder, "traced"), endedSpans(recorder, "untraced")
+	if len(traced) != 1 || len(untraced) != 1 {
+		t.Fatalf("unexpected spans: %d %d", len(traced), len(untraced))
+	}
+	if p := traced[0].Parent(); p.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || p.SpanID().String() != "00f067aa0ba902b7" ||
+		!p.IsRemote() || traced[0].SpanContext().TraceID() != p.TraceID() {
+		t.Fatalf("unexpected parent: %s %s", p.TraceID(), p.SpanID())
+	}
+	if untraced[0].Parent().IsValid() {
+		t.Fatalf("message without extension should start a new trace: %s", untraced[0].Parent().TraceID())
+	}
+	if id := spanAttributes(traced[0])["crosschain.trace_id"]; id != messageTraceID(am) {
+		t.Fatalf("unexpected trace id attribute: %s", id)
+	}
+
+	// 没有配置OTLP导出时不设置TracerProvider
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := SetupTracing(context.Background())
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("unexpected setup: %v", err)
+	}
+}
+
+func TestRelayTracing(t *testing.T) {
+	recorder := recordSpans()
+	chain := newFakeChain()
+	service := NewFabricBBCService(hclog.NewNullLogger())
+	service.newClient = func(conf *Config, logger hclog.Logger) (chainClient, error) {
+		return chain, nil
+	}
+	raw, _ := json.Marshal(&Config{
+		ConnectionProfile: "version: 1.0.0",
+		Channel:           "mychannel",
+		Chaincode:         "cross",
+		Org:               "Org1",
+		User:              UserConfig{Cert: "cert", Key: "key"},
+		Receivers:         []string{"bizcc"},
+	})
+	if err := service.Startup(&bbc.BBCContext{RawConf: raw}); err != nil {
+		t.Fatal(err)
+	}
+
+	// recvMessage交易的transient数据带有RelayAuthMessage span的traceparent，父span为SDP扩展中的traceparent
+	am := encodeAMv2(sha256.Sum256([]byte("sendercc")), extendSDP(encodeSDPv2("fabric.com", sha256.Sum256([]byte("bizcc")), 0, []byte("hello")), testTraceParent))
+	receipt, err := service.RelayAuthMessage(EncodeRelayPackage("src.com", am))
+	if err != nil || !receipt.Successful {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	spans := endedSpans(recorder, "RelayAuthMessage")
+	if len(spans) != 1 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+	span := spans[0]
+	if span.Parent().SpanID().String() != "00f067aa0ba902b7" || span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected parent: %s %s", span.Parent().TraceID(), span.Parent().SpanID())
+	}
+	transient := chain.transients[len(chain.transients)-1]
+	if expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanContext().SpanID().String() + "-01"; string(transient[TRANSIENT_TRACEPARENT]) != expected {
+		t.Fatalf("unexpected traceparent: %s", transient[TRANSIENT_TRACEPARENT])
+	}
+	attrs := spanAttributes(span)
+	if attrs["fabric.tx_id"] != receipt.TxHash || attrs["fabric.successful"] != "true" || attrs["crosschain.trace_id"] != messageTraceID(am) {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+
+	// 接收方拒绝的消息span状态为错误
+	chain.nextCode = pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE
+	other := encodeAMv2(sha256.Sum256([]byte("sendercc")), encodeSDPv2("fabric.com", sha256.Sum256([]byte("bizcc")), 1, []byte("other")))
+	receipt, err = service.RelayAuthMessage(EncodeRelayPackage("src.com", other))
+	if err != nil || receipt.Successful {
+		t.Fatalf("unexpected receipt: %+v %v", receipt, err)
+	}
+	spans = endedSpans(recorder, "RelayAuthMessage")
+	if failed := spans[len(spans)-1]; failed.Status().Code != codes.Error || failed.Status().Description != receipt.ErrorMsg {
+		t.Fatalf("unexpected status: %+v", failed.Status())
+	}
+
+	// 读出的消息记录ReadCrossChainMessage span
+	traceReadMessages([]*bbc.CrossChainMessage{{Message: am, ProvableData: &bbc.ProvableLedgerData{Height: 3, TxHash: []byte{0xab}}}})
+	read := endedSpans(recorder, "ReadCrossChainMessage")
+	if len(read) != 1 || read[0].Parent().SpanID().String() != "00f067aa0ba902b7" || spanAttributes(read[0])["fabric.tx_id"] != "ab" {
+		t.Fatalf("unexpected read spans: %v", read)
+	}
+}
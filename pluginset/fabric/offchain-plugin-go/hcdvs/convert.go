@@ -0,0 +1,37 @@
+package hcdvs
+
+import (
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// hcdvs类型与协议消息的转换
+
+// 共识状态的转换，委员会协议也使用
+func StateToPB(s *consensus.ConsensusState) *pb.ConsensusState {
+	if s == nil {
+		return nil
+	}
+	return &pb.ConsensusState{Product: s.Product, Height: s.Height, Hash: s.Hash, ConsensusNodes: s.ConsensusNodes, StateData: s.StateData}
+}
+
+func StateFromPB(s *pb.ConsensusState) *consensus.ConsensusState {
+	if s == nil {
+		return nil
+	}
+	return &consensus.ConsensusState{Product: s.Product, Height: s.Height, Hash: s.Hash, ConsensusNodes: s.ConsensusNodes, StateData: s.StateData}
+}
+
+func resultToPB(r *VerifyResult) *pb.VerifyResult {
+	if r == nil {
+		return nil
+	}
+	return &pb.VerifyResult{Verified: r.Verified, ErrorMsg: r.ErrorMsg}
+}
+
+func resultFromPB(r *pb.VerifyResult) *VerifyResult {
+	if r == nil {
+		return &VerifyResult{ErrorMsg: "no verify result"}
+	}
+	return &VerifyResult{Verified: r.Verified, ErrorMsg: r.ErrorMsg}
+}
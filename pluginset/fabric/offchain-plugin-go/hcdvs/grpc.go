@@ -0,0 +1,148 @@
+package hcdvs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// gRPC协议
+// HCDVSService以proto/hcdvs.proto中的服务提供，由PTC通过go-plugin启动。与BBC插件相同，
+// 调用失败以UNKNOWN状态码传递，客户端还原为原始的错误信息。
+
+// go-plugin插件集合中HCDVSService的名字
+const PLUGIN_NAME = "hcdvs"
+
+// PTC与插件握手的配置，与BBC插件区分
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "ANTCHAIN_BRIDGE_HCDVS_PLUGIN",
+	MagicCookieValue: "hcdvs",
+}
+
+// PTC侧Dispense(PLUGIN_NAME)得到HCDVSService
+var PluginMap = map[string]plugin.Plugin{
+	PLUGIN_NAME: &HCDVSPlugin{},
+}
+
+// HCDVSPlugin 实现plugin.GRPCPlugin，插件侧设置Impl
+type HCDVSPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl HCDVSService
+}
+
+func (p *HCDVSPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	RegisterGRPCServer(s, p.Impl)
+	return nil
+}
+
+func (p *HCDVSPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return NewGRPCClient(conn), nil
+}
+
+// 在gRPC服务中注册HCDVSService
+func RegisterGRPCServer(s *grpc.Server, impl HCDVSService) {
+	pb.RegisterHCDVSServiceServer(s, &grpcServer{impl: impl})
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+type grpcServer struct {
+	impl HCDVSService
+}
+
+func (s *grpcServer) VerifyAnchorConsensusState(ctx context.Context, req *pb.VerifyAnchorConsensusStateRequest) (*pb.VerifyResponse, error) {
+	result, err := s.impl.VerifyAnchorConsensusState(StateFromPB(req.Anchor))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VerifyResponse{Result: resultToPB(result)}, nil
+}
+
+func (s *grpcServer) VerifyConsensusState(ctx context.Context, req *pb.VerifyConsensusStateRequest) (*pb.VerifyResponse, error) {
+	result, err := s.impl.VerifyConsensusState(StateFromPB(req.State), StateFromPB(req.Anchor))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VerifyResponse{Result: resultToPB(result)}, nil
+}
+
+func (s *grpcServer) VerifyCrossChainMessage(ctx context.Context, req *pb.VerifyCrossChainMessageRequest) (*pb.VerifyResponse, error) {
+	result, err := s.impl.VerifyCrossChainMessage(bbc.MessageFromPB(req.Message), StateFromPB(req.Anchor))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VerifyResponse{Result: resultToPB(result)}, nil
+}
+
+func (s *grpcServer) ParseMessageFromLedgerData(ctx context.Context, req *pb.ParseMessageRequest) (*pb.ParseMessageResponse, error) {
+	msg, err := s.impl.ParseMessageFromLedgerData(req.LedgerData)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ParseMessageResponse{Message: msg}, nil
+}
+
+// GRPCClient PTC侧的HCDVSService实现
+type GRPCClient struct {
+	client pb.HCDVSServiceClient
+}
+
+func NewGRPCClient(conn *grpc.ClientConn) *GRPCClient {
+	return &GRPCClient{client: pb.NewHCDVSServiceClient(conn)}
+}
+
+var _ HCDVSService = (*GRPCClient)(nil)
+
+// 还原插件返回的错误信息
+func fromStatus(err error) error {
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+		return errors.New(s.Message())
+	}
+	return err
+}
+
+func (c *GRPCClient) VerifyAnchorConsensusState(anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	resp, err := c.client.VerifyAnchorConsensusState(context.Background(), &pb.VerifyAnchorConsensusStateRequest{Anchor: StateToPB(anchor)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resultFromPB(resp.Result), nil
+}
+
+func (c *GRPCClient) VerifyConsensusState(state, anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	resp, err := c.client.VerifyConsensusState(context.Background(), &pb.VerifyConsensusStateRequest{State: StateToPB(state), Anchor: StateToPB(anchor)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resultFromPB(resp.Result), nil
+}
+
+func (c *GRPCClient) VerifyCrossChainMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	resp, err := c.client.VerifyCrossChainMessage(context.Background(), &pb.VerifyCrossChainMessageRequest{Message: bbc.MessageToPB(msg), Anchor: StateToPB(anchor)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resultFromPB(resp.Result), nil
+}
+
+func (c *GRPCClient) ParseMessageFromLedgerData(ledgerData []byte) ([]byte, error) {
+	resp, err := c.client.ParseMessageFromLedgerData(context.Background(), &pb.ParseMessageRequest{LedgerData: ledgerData})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp.Message, nil
+}
@@ -0,0 +1,71 @@
+package hcdvs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+)
+
+type fakeVerifier struct {
+	anchor *consensus.ConsensusState
+	msg    *bbc.CrossChainMessage
+}
+
+func (v *fakeVerifier) VerifyAnchorConsensusState(anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	v.anchor = anchor
+	return ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) VerifyConsensusState(state, anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	if state.Height <= anchor.Height {
+		return ResultOf(errors.New("state is not after anchor")), nil
+	}
+	return ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) VerifyCrossChainMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*VerifyResult, error) {
+	v.msg = msg
+	return ResultOf(nil), nil
+}
+
+func (v *fakeVerifier) ParseMessageFromLedgerData(ledgerData []byte) ([]byte, error) {
+	return nil, errors.New("unsupported ledger data")
+}
+
+func TestGRPCPlugin(t *testing.T) {
+	impl := &fakeVerifier{}
+	client, _ := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{PLUGIN_NAME: &HCDVSPlugin{Impl: impl}})
+	defer client.Close()
+	raw, err := client.Dispense(PLUGIN_NAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := raw.(HCDVSService)
+
+	anchor := &consensus.ConsensusState{Product: "fabric", Height: 5, Hash: []byte("hash"), ConsensusNodes: []string{"OrdererMSP"}, StateData: []byte("data")}
+	if result, err := service.VerifyAnchorConsensusState(anchor); err != nil || !result.Verified {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if a := impl.anchor; a.Product != "fabric" || a.Height != 5 || string(a.Hash) != "hash" || a.ConsensusNodes[0] != "OrdererMSP" || string(a.StateData) != "data" {
+		t.Fatalf("unexpected anchor: %+v", a)
+	}
+	// 验证不通过不是调用错误
+	result, err := service.VerifyConsensusState(&consensus.ConsensusState{Height: 3}, anchor)
+	if err != nil || result.Verified || result.ErrorMsg != "state is not after anchor" {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	msg := &bbc.CrossChainMessage{Type: bbc.AUTH_MSG, Message: []byte("am"), ProvableData: &bbc.ProvableLedgerData{Height: 10, Proof: []byte("proof"), TxHash: []byte{0xab}}}
+	if result, err := service.VerifyCrossChainMessage(msg, anchor); err != nil || !result.Verified {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if m := impl.msg; string(m.Message) != "am" || string(m.ProvableData.Proof) != "proof" || m.ProvableData.Height != 10 {
+		t.Fatalf("unexpected message: %+v", m)
+	}
+	if _, err := service.ParseMessageFromLedgerData([]byte("data")); err == nil || err.Error() != "unsupported ledger data" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
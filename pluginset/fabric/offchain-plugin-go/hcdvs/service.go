@@ -0,0 +1,32 @@
+package hcdvs
+
+import (
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+)
+
+// HCDVSService 对应antchain-bridge-spi中的IHeteroChainDateVerifierService，PTC用其验证异构链的共识状态和跨链消息。
+// 验证不通过时返回Verified为false的结果；返回error表示调用失败，PTC可以重试。
+type HCDVSService interface {
+	// 检查锚定状态，锚定状态本身由PTC链外确认
+	VerifyAnchorConsensusState(anchor *consensus.ConsensusState) (*VerifyResult, error)
+	// 用已验证的anchor验证下一个共识状态
+	VerifyConsensusState(state, anchor *consensus.ConsensusState) (*VerifyResult, error)
+	// 用消息所在区块适用的共识状态验证跨链消息
+	VerifyCrossChainMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*VerifyResult, error)
+	// 从账本数据中读取跨链消息
+	ParseMessageFromLedgerData(ledgerData []byte) ([]byte, error)
+}
+
+type VerifyResult struct {
+	Verified bool   `json:"verified"`
+	ErrorMsg string `json:"errorMsg,omitempty"`
+}
+
+// 验证的错误转换为结果，err为空时验证通过
+func ResultOf(err error) *VerifyResult {
+	if err != nil {
+		return &VerifyResult{ErrorMsg: err.Error()}
+	}
+	return &VerifyResult{Verified: true}
+}
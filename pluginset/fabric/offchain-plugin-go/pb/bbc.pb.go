@@ -0,0 +1,1805 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: bbc.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ContractStatus int32
+
+const (
+	ContractStatus_INIT              ContractStatus = 0
+	ContractStatus_CONTRACT_DEPLOYED ContractStatus = 1
+	ContractStatus_CONTRACT_READY    ContractStatus = 2
+	ContractStatus_CONTRACT_FREEZE   ContractStatus = 3
+)
+
+var ContractStatus_name = map[int32]string{
+	0: "INIT",
+	1: "CONTRACT_DEPLOYED",
+	2: "CONTRACT_READY",
+	3: "CONTRACT_FREEZE",
+}
+
+var ContractStatus_value = map[string]int32{
+	"INIT":              0,
+	"CONTRACT_DEPLOYED": 1,
+	"CONTRACT_READY":    2,
+	"CONTRACT_FREEZE":   3,
+}
+
+func (x ContractStatus) String() string {
+	return proto.EnumName(ContractStatus_name, int32(x))
+}
+
+func (ContractStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{0}
+}
+
+type CrossChainMessageType int32
+
+const (
+	CrossChainMessageType_AUTH_MSG         CrossChainMessageType = 0
+	CrossChainMessageType_DEVELOPER_DESIGN CrossChainMessageType = 1
+)
+
+var CrossChainMessageType_name = map[int32]string{
+	0: "AUTH_MSG",
+	1: "DEVELOPER_DESIGN",
+}
+
+var CrossChainMessageType_value = map[string]int32{
+	"AUTH_MSG":         0,
+	"DEVELOPER_DESIGN": 1,
+}
+
+func (x CrossChainMessageType) String() string {
+	return proto.EnumName(CrossChainMessageType_name, int32(x))
+}
+
+func (CrossChainMessageType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{1}
+}
+
+// 插件记录的跨链消息提交状态
+type RelayState int32
+
+const (
+	RelayState_RELAY_UNKNOWN RelayState = 0
+	RelayState_RELAY_PENDING RelayState = 1
+	RelayState_RELAY_SUCCESS RelayState = 2
+	RelayState_RELAY_FAILED  RelayState = 3
+)
+
+var RelayState_name = map[int32]string{
+	0: "RELAY_UNKNOWN",
+	1: "RELAY_PENDING",
+	2: "RELAY_SUCCESS",
+	3: "RELAY_FAILED",
+}
+
+var RelayState_value = map[string]int32{
+	"RELAY_UNKNOWN": 0,
+	"RELAY_PENDING": 1,
+	"RELAY_SUCCESS": 2,
+	"RELAY_FAILED":  3,
+}
+
+func (x RelayState) String() string {
+	return proto.EnumName(RelayState_name, int32(x))
+}
+
+func (RelayState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{2}
+}
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{0}
+}
+
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+// AM、SDP、PTC合约
+type Contract struct {
+	ContractAddress      string         `protobuf:"bytes,1,opt,name=contract_address,json=contractAddress,proto3" json:"contract_address,omitempty"`
+	Status               ContractStatus `protobuf:"varint,2,opt,name=status,proto3,enum=antchain.bridge.plugin.ContractStatus" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Contract) Reset()         { *m = Contract{} }
+func (m *Contract) String() string { return proto.CompactTextString(m) }
+func (*Contract) ProtoMessage()    {}
+func (*Contract) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{1}
+}
+
+func (m *Contract) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Contract.Unmarshal(m, b)
+}
+func (m *Contract) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Contract.Marshal(b, m, deterministic)
+}
+func (m *Contract) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Contract.Merge(m, src)
+}
+func (m *Contract) XXX_Size() int {
+	return xxx_messageInfo_Contract.Size(m)
+}
+func (m *Contract) XXX_DiscardUnknown() {
+	xxx_messageInfo_Contract.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Contract proto.InternalMessageInfo
+
+func (m *Contract) GetContractAddress() string {
+	if m != nil {
+		return m.ContractAddress
+	}
+	return ""
+}
+
+func (m *Contract) GetStatus() ContractStatus {
+	if m != nil {
+		return m.Status
+	}
+	return ContractStatus_INIT
+}
+
+type BBCContext struct {
+	AmContract  *Contract `protobuf:"bytes,1,opt,name=am_contract,json=amContract,proto3" json:"am_contract,omitempty"`
+	PtcContract *Contract `protobuf:"bytes,2,opt,name=ptc_contract,json=ptcContract,proto3" json:"ptc_contract,omitempty"`
+	SdpContract *Contract `protobuf:"bytes,3,opt,name=sdp_contract,json=sdpContract,proto3" json:"sdp_contract,omitempty"`
+	// 链客户端的配置，由插件自行解析
+	RawConf              []byte   `protobuf:"bytes,4,opt,name=raw_conf,json=rawConf,proto3" json:"raw_conf,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BBCContext) Reset()         { *m = BBCContext{} }
+func (m *BBCContext) String() string { return proto.CompactTextString(m) }
+func (*BBCContext) ProtoMessage()    {}
+func (*BBCContext) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{2}
+}
+
+func (m *BBCContext) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BBCContext.Unmarshal(m, b)
+}
+func (m *BBCContext) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BBCContext.Marshal(b, m, deterministic)
+}
+func (m *BBCContext) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BBCContext.Merge(m, src)
+}
+func (m *BBCContext) XXX_Size() int {
+	return xxx_messageInfo_BBCContext.Size(m)
+}
+func (m *BBCContext) XXX_DiscardUnknown() {
+	xxx_messageInfo_BBCContext.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BBCContext proto.InternalMessageInfo
+
+func (m *BBCContext) GetAmContract() *Contract {
+	if m != nil {
+		return m.AmContract
+	}
+	return nil
+}
+
+func (m *BBCContext) GetPtcContract() *Contract {
+	if m != nil {
+		return m.PtcContract
+	}
+	return nil
+}
+
+func (m *BBCContext) GetSdpContract() *Contract {
+	if m != nil {
+		return m.SdpContract
+	}
+	return nil
+}
+
+func (m *BBCContext) GetRawConf() []byte {
+	if m != nil {
+		return m.RawConf
+	}
+	return nil
+}
+
+type ProvableLedgerData struct {
+	Height    uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	BlockHash []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	// 毫秒
+	Timestamp            int64    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	LedgerData           []byte   `protobuf:"bytes,4,opt,name=ledger_data,json=ledgerData,proto3" json:"ledger_data,omitempty"`
+	Proof                []byte   `protobuf:"bytes,5,opt,name=proof,proto3" json:"proof,omitempty"`
+	TxHash               []byte   `protobuf:"bytes,6,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProvableLedgerData) Reset()         { *m = ProvableLedgerData{} }
+func (m *ProvableLedgerData) String() string { return proto.CompactTextString(m) }
+func (*ProvableLedgerData) ProtoMessage()    {}
+func (*ProvableLedgerData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{3}
+}
+
+func (m *ProvableLedgerData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProvableLedgerData.Unmarshal(m, b)
+}
+func (m *ProvableLedgerData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProvableLedgerData.Marshal(b, m, deterministic)
+}
+func (m *ProvableLedgerData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProvableLedgerData.Merge(m, src)
+}
+func (m *ProvableLedgerData) XXX_Size() int {
+	return xxx_messageInfo_ProvableLedgerData.Size(m)
+}
+func (m *ProvableLedgerData) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProvableLedgerData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProvableLedgerData proto.InternalMessageInfo
+
+func (m *ProvableLedgerData) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ProvableLedgerData) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *ProvableLedgerData) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *ProvableLedgerData) GetLedgerData() []byte {
+	if m != nil {
+		return m.LedgerData
+	}
+	return nil
+}
+
+func (m *ProvableLedgerData) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *ProvableLedgerData) GetTxHash() []byte {
+	if m != nil {
+		return m.TxHash
+	}
+	return nil
+}
+
+type CrossChainMessage struct {
+	Type                 CrossChainMessageType `protobuf:"varint,1,opt,name=type,proto3,enum=antchain.bridge.plugin.CrossChainMessageType" json:"type,omitempty"`
+	Message              []byte                `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ProvableData         *ProvableLedgerData   `protobuf:"bytes,3,opt,name=provable_data,json=provableData,proto3" json:"provable_data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *CrossChainMessage) Reset()         { *m = CrossChainMessage{} }
+func (m *CrossChainMessage) String() string { return proto.CompactTextString(m) }
+func (*CrossChainMessage) ProtoMessage()    {}
+func (*CrossChainMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{4}
+}
+
+func (m *CrossChainMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CrossChainMessage.Unmarshal(m, b)
+}
+func (m *CrossChainMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CrossChainMessage.Marshal(b, m, deterministic)
+}
+func (m *CrossChainMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CrossChainMessage.Merge(m, src)
+}
+func (m *CrossChainMessage) XXX_Size() int {
+	return xxx_messageInfo_CrossChainMessage.Size(m)
+}
+func (m *CrossChainMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_CrossChainMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CrossChainMessage proto.InternalMessageInfo
+
+func (m *CrossChainMessage) GetType() CrossChainMessageType {
+	if m != nil {
+		return m.Type
+	}
+	return CrossChainMessageType_AUTH_MSG
+}
+
+func (m *CrossChainMessage) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *CrossChainMessage) GetProvableData() *ProvableLedgerData {
+	if m != nil {
+		return m.ProvableData
+	}
+	return nil
+}
+
+type CrossChainMessageReceipt struct {
+	TxHash               string   `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	Confirmed            bool     `protobuf:"varint,2,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	Successful           bool     `protobuf:"varint,3,opt,name=successful,proto3" json:"successful,omitempty"`
+	ErrorMsg             string   `protobuf:"bytes,4,opt,name=error_msg,json=errorMsg,proto3" json:"error_msg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CrossChainMessageReceipt) Reset()         { *m = CrossChainMessageReceipt{} }
+func (m *CrossChainMessageReceipt) String() string { return proto.CompactTextString(m) }
+func (*CrossChainMessageReceipt) ProtoMessage()    {}
+func (*CrossChainMessageReceipt) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{5}
+}
+
+func (m *CrossChainMessageReceipt) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CrossChainMessageReceipt.Unmarshal(m, b)
+}
+func (m *CrossChainMessageReceipt) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CrossChainMessageReceipt.Marshal(b, m, deterministic)
+}
+func (m *CrossChainMessageReceipt) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CrossChainMessageReceipt.Merge(m, src)
+}
+func (m *CrossChainMessageReceipt) XXX_Size() int {
+	return xxx_messageInfo_CrossChainMessageReceipt.Size(m)
+}
+func (m *CrossChainMessageReceipt) XXX_DiscardUnknown() {
+	xxx_messageInfo_CrossChainMessageReceipt.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CrossChainMessageReceipt proto.InternalMessageInfo
+
+func (m *CrossChainMessageReceipt) GetTxHash() string {
+	if m != nil {
+		return m.TxHash
+	}
+	return ""
+}
+
+func (m *CrossChainMessageReceipt) GetConfirmed() bool {
+	if m != nil {
+		return m.Confirmed
+	}
+	return false
+}
+
+func (m *CrossChainMessageReceipt) GetSuccessful() bool {
+	if m != nil {
+		return m.Successful
+	}
+	return false
+}
+
+func (m *CrossChainMessageReceipt) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+type RelayStatus struct {
+	// 消息的sha256，hex
+	PacketHash           string                    `protobuf:"bytes,1,opt,name=packet_hash,json=packetHash,proto3" json:"packet_hash,omitempty"`
+	State                RelayState                `protobuf:"varint,2,opt,name=state,proto3,enum=antchain.bridge.plugin.RelayState" json:"state,omitempty"`
+	Receipt              *CrossChainMessageReceipt `protobuf:"bytes,3,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	TxHashes             []string                  `protobuf:"bytes,4,rep,name=tx_hashes,json=txHashes,proto3" json:"tx_hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *RelayStatus) Reset()         { *m = RelayStatus{} }
+func (m *RelayStatus) String() string { return proto.CompactTextString(m) }
+func (*RelayStatus) ProtoMessage()    {}
+func (*RelayStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{6}
+}
+
+func (m *RelayStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RelayStatus.Unmarshal(m, b)
+}
+func (m *RelayStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RelayStatus.Marshal(b, m, deterministic)
+}
+func (m *RelayStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RelayStatus.Merge(m, src)
+}
+func (m *RelayStatus) XXX_Size() int {
+	return xxx_messageInfo_RelayStatus.Size(m)
+}
+func (m *RelayStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_RelayStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RelayStatus proto.InternalMessageInfo
+
+func (m *RelayStatus) GetPacketHash() string {
+	if m != nil {
+		return m.PacketHash
+	}
+	return ""
+}
+
+func (m *RelayStatus) GetState() RelayState {
+	if m != nil {
+		return m.State
+	}
+	return RelayState_RELAY_UNKNOWN
+}
+
+func (m *RelayStatus) GetReceipt() *CrossChainMessageReceipt {
+	if m != nil {
+		return m.Receipt
+	}
+	return nil
+}
+
+func (m *RelayStatus) GetTxHashes() []string {
+	if m != nil {
+		return m.TxHashes
+	}
+	return nil
+}
+
+type StartupRequest struct {
+	Context              *BBCContext `protobuf:"bytes,1,opt,name=context,proto3" json:"context,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *StartupRequest) Reset()         { *m = StartupRequest{} }
+func (m *StartupRequest) String() string { return proto.CompactTextString(m) }
+func (*StartupRequest) ProtoMessage()    {}
+func (*StartupRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{7}
+}
+
+func (m *StartupRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StartupRequest.Unmarshal(m, b)
+}
+func (m *StartupRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StartupRequest.Marshal(b, m, deterministic)
+}
+func (m *StartupRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StartupRequest.Merge(m, src)
+}
+func (m *StartupRequest) XXX_Size() int {
+	return xxx_messageInfo_StartupRequest.Size(m)
+}
+func (m *StartupRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StartupRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StartupRequest proto.InternalMessageInfo
+
+func (m *StartupRequest) GetContext() *BBCContext {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+type ContextResponse struct {
+	Context              *BBCContext `protobuf:"bytes,1,opt,name=context,proto3" json:"context,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ContextResponse) Reset()         { *m = ContextResponse{} }
+func (m *ContextResponse) String() string { return proto.CompactTextString(m) }
+func (*ContextResponse) ProtoMessage()    {}
+func (*ContextResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{8}
+}
+
+func (m *ContextResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContextResponse.Unmarshal(m, b)
+}
+func (m *ContextResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContextResponse.Marshal(b, m, deterministic)
+}
+func (m *ContextResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContextResponse.Merge(m, src)
+}
+func (m *ContextResponse) XXX_Size() int {
+	return xxx_messageInfo_ContextResponse.Size(m)
+}
+func (m *ContextResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContextResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContextResponse proto.InternalMessageInfo
+
+func (m *ContextResponse) GetContext() *BBCContext {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+type SetProtocolRequest struct {
+	ProtocolAddress      string   `protobuf:"bytes,1,opt,name=protocol_address,json=protocolAddress,proto3" json:"protocol_address,omitempty"`
+	ProtocolType         string   `protobuf:"bytes,2,opt,name=protocol_type,json=protocolType,proto3" json:"protocol_type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetProtocolRequest) Reset()         { *m = SetProtocolRequest{} }
+func (m *SetProtocolRequest) String() string { return proto.CompactTextString(m) }
+func (*SetProtocolRequest) ProtoMessage()    {}
+func (*SetProtocolRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{9}
+}
+
+func (m *SetProtocolRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetProtocolRequest.Unmarshal(m, b)
+}
+func (m *SetProtocolRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetProtocolRequest.Marshal(b, m, deterministic)
+}
+func (m *SetProtocolRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetProtocolRequest.Merge(m, src)
+}
+func (m *SetProtocolRequest) XXX_Size() int {
+	return xxx_messageInfo_SetProtocolRequest.Size(m)
+}
+func (m *SetProtocolRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetProtocolRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetProtocolRequest proto.InternalMessageInfo
+
+func (m *SetProtocolRequest) GetProtocolAddress() string {
+	if m != nil {
+		return m.ProtocolAddress
+	}
+	return ""
+}
+
+func (m *SetProtocolRequest) GetProtocolType() string {
+	if m != nil {
+		return m.ProtocolType
+	}
+	return ""
+}
+
+type SetAmContractRequest struct {
+	ContractAddress      string   `protobuf:"bytes,1,opt,name=contract_address,json=contractAddress,proto3" json:"contract_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetAmContractRequest) Reset()         { *m = SetAmContractRequest{} }
+func (m *SetAmContractRequest) String() string { return proto.CompactTextString(m) }
+func (*SetAmContractRequest) ProtoMessage()    {}
+func (*SetAmContractRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{10}
+}
+
+func (m *SetAmContractRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetAmContractRequest.Unmarshal(m, b)
+}
+func (m *SetAmContractRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetAmContractRequest.Marshal(b, m, deterministic)
+}
+func (m *SetAmContractRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetAmContractRequest.Merge(m, src)
+}
+func (m *SetAmContractRequest) XXX_Size() int {
+	return xxx_messageInfo_SetAmContractRequest.Size(m)
+}
+func (m *SetAmContractRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetAmContractRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetAmContractRequest proto.InternalMessageInfo
+
+func (m *SetAmContractRequest) GetContractAddress() string {
+	if m != nil {
+		return m.ContractAddress
+	}
+	return ""
+}
+
+type SetLocalDomainRequest struct {
+	Domain               string   `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLocalDomainRequest) Reset()         { *m = SetLocalDomainRequest{} }
+func (m *SetLocalDomainRequest) String() string { return proto.CompactTextString(m) }
+func (*SetLocalDomainRequest) ProtoMessage()    {}
+func (*SetLocalDomainRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{11}
+}
+
+func (m *SetLocalDomainRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLocalDomainRequest.Unmarshal(m, b)
+}
+func (m *SetLocalDomainRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLocalDomainRequest.Marshal(b, m, deterministic)
+}
+func (m *SetLocalDomainRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLocalDomainRequest.Merge(m, src)
+}
+func (m *SetLocalDomainRequest) XXX_Size() int {
+	return xxx_messageInfo_SetLocalDomainRequest.Size(m)
+}
+func (m *SetLocalDomainRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLocalDomainRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLocalDomainRequest proto.InternalMessageInfo
+
+func (m *SetLocalDomainRequest) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+type QuerySDPMessageSeqRequest struct {
+	SenderDomain         string   `protobuf:"bytes,1,opt,name=sender_domain,json=senderDomain,proto3" json:"sender_domain,omitempty"`
+	FromAddress          string   `protobuf:"bytes,2,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
+	ReceiverDomain       string   `protobuf:"bytes,3,opt,name=receiver_domain,json=receiverDomain,proto3" json:"receiver_domain,omitempty"`
+	ToAddress            string   `protobuf:"bytes,4,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QuerySDPMessageSeqRequest) Reset()         { *m = QuerySDPMessageSeqRequest{} }
+func (m *QuerySDPMessageSeqRequest) String() string { return proto.CompactTextString(m) }
+func (*QuerySDPMessageSeqRequest) ProtoMessage()    {}
+func (*QuerySDPMessageSeqRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{12}
+}
+
+func (m *QuerySDPMessageSeqRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuerySDPMessageSeqRequest.Unmarshal(m, b)
+}
+func (m *QuerySDPMessageSeqRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuerySDPMessageSeqRequest.Marshal(b, m, deterministic)
+}
+func (m *QuerySDPMessageSeqRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuerySDPMessageSeqRequest.Merge(m, src)
+}
+func (m *QuerySDPMessageSeqRequest) XXX_Size() int {
+	return xxx_messageInfo_QuerySDPMessageSeqRequest.Size(m)
+}
+func (m *QuerySDPMessageSeqRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuerySDPMessageSeqRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuerySDPMessageSeqRequest proto.InternalMessageInfo
+
+func (m *QuerySDPMessageSeqRequest) GetSenderDomain() string {
+	if m != nil {
+		return m.SenderDomain
+	}
+	return ""
+}
+
+func (m *QuerySDPMessageSeqRequest) GetFromAddress() string {
+	if m != nil {
+		return m.FromAddress
+	}
+	return ""
+}
+
+func (m *QuerySDPMessageSeqRequest) GetReceiverDomain() string {
+	if m != nil {
+		return m.ReceiverDomain
+	}
+	return ""
+}
+
+func (m *QuerySDPMessageSeqRequest) GetToAddress() string {
+	if m != nil {
+		return m.ToAddress
+	}
+	return ""
+}
+
+type SeqResponse struct {
+	Seq                  uint64   `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SeqResponse) Reset()         { *m = SeqResponse{} }
+func (m *SeqResponse) String() string { return proto.CompactTextString(m) }
+func (*SeqResponse) ProtoMessage()    {}
+func (*SeqResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{13}
+}
+
+func (m *SeqResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SeqResponse.Unmarshal(m, b)
+}
+func (m *SeqResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SeqResponse.Marshal(b, m, deterministic)
+}
+func (m *SeqResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeqResponse.Merge(m, src)
+}
+func (m *SeqResponse) XXX_Size() int {
+	return xxx_messageInfo_SeqResponse.Size(m)
+}
+func (m *SeqResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeqResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeqResponse proto.InternalMessageInfo
+
+func (m *SeqResponse) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+type RelayAuthMessageRequest struct {
+	RawMessage           []byte   `protobuf:"bytes,1,opt,name=raw_message,json=rawMessage,proto3" json:"raw_message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RelayAuthMessageRequest) Reset()         { *m = RelayAuthMessageRequest{} }
+func (m *RelayAuthMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*RelayAuthMessageRequest) ProtoMessage()    {}
+func (*RelayAuthMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{14}
+}
+
+func (m *RelayAuthMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RelayAuthMessageRequest.Unmarshal(m, b)
+}
+func (m *RelayAuthMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RelayAuthMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *RelayAuthMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RelayAuthMessageRequest.Merge(m, src)
+}
+func (m *RelayAuthMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_RelayAuthMessageRequest.Size(m)
+}
+func (m *RelayAuthMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RelayAuthMessageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RelayAuthMessageRequest proto.InternalMessageInfo
+
+func (m *RelayAuthMessageRequest) GetRawMessage() []byte {
+	if m != nil {
+		return m.RawMessage
+	}
+	return nil
+}
+
+type ReadReceiptRequest struct {
+	TxHash               string   `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReadReceiptRequest) Reset()         { *m = ReadReceiptRequest{} }
+func (m *ReadReceiptRequest) String() string { return proto.CompactTextString(m) }
+func (*ReadReceiptRequest) ProtoMessage()    {}
+func (*ReadReceiptRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{15}
+}
+
+func (m *ReadReceiptRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadReceiptRequest.Unmarshal(m, b)
+}
+func (m *ReadReceiptRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadReceiptRequest.Marshal(b, m, deterministic)
+}
+func (m *ReadReceiptRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadReceiptRequest.Merge(m, src)
+}
+func (m *ReadReceiptRequest) XXX_Size() int {
+	return xxx_messageInfo_ReadReceiptRequest.Size(m)
+}
+func (m *ReadReceiptRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadReceiptRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReadReceiptRequest proto.InternalMessageInfo
+
+func (m *ReadReceiptRequest) GetTxHash() string {
+	if m != nil {
+		return m.TxHash
+	}
+	return ""
+}
+
+type ReceiptResponse struct {
+	Receipt              *CrossChainMessageReceipt `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *ReceiptResponse) Reset()         { *m = ReceiptResponse{} }
+func (m *ReceiptResponse) String() string { return proto.CompactTextString(m) }
+func (*ReceiptResponse) ProtoMessage()    {}
+func (*ReceiptResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{16}
+}
+
+func (m *ReceiptResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReceiptResponse.Unmarshal(m, b)
+}
+func (m *ReceiptResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReceiptResponse.Marshal(b, m, deterministic)
+}
+func (m *ReceiptResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReceiptResponse.Merge(m, src)
+}
+func (m *ReceiptResponse) XXX_Size() int {
+	return xxx_messageInfo_ReceiptResponse.Size(m)
+}
+func (m *ReceiptResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReceiptResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReceiptResponse proto.InternalMessageInfo
+
+func (m *ReceiptResponse) GetReceipt() *CrossChainMessageReceipt {
+	if m != nil {
+		return m.Receipt
+	}
+	return nil
+}
+
+type QueryRelayStatusRequest struct {
+	PacketHash           string   `protobuf:"bytes,1,opt,name=packet_hash,json=packetHash,proto3" json:"packet_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryRelayStatusRequest) Reset()         { *m = QueryRelayStatusRequest{} }
+func (m *QueryRelayStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryRelayStatusRequest) ProtoMessage()    {}
+func (*QueryRelayStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{17}
+}
+
+func (m *QueryRelayStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryRelayStatusRequest.Unmarshal(m, b)
+}
+func (m *QueryRelayStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryRelayStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *QueryRelayStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryRelayStatusRequest.Merge(m, src)
+}
+func (m *QueryRelayStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_QueryRelayStatusRequest.Size(m)
+}
+func (m *QueryRelayStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryRelayStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryRelayStatusRequest proto.InternalMessageInfo
+
+func (m *QueryRelayStatusRequest) GetPacketHash() string {
+	if m != nil {
+		return m.PacketHash
+	}
+	return ""
+}
+
+type RelayStatusResponse struct {
+	Status               *RelayStatus `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RelayStatusResponse) Reset()         { *m = RelayStatusResponse{} }
+func (m *RelayStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*RelayStatusResponse) ProtoMessage()    {}
+func (*RelayStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{18}
+}
+
+func (m *RelayStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RelayStatusResponse.Unmarshal(m, b)
+}
+func (m *RelayStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RelayStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *RelayStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RelayStatusResponse.Merge(m, src)
+}
+func (m *RelayStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_RelayStatusResponse.Size(m)
+}
+func (m *RelayStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RelayStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RelayStatusResponse proto.InternalMessageInfo
+
+func (m *RelayStatusResponse) GetStatus() *RelayStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type HeightRequest struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeightRequest) Reset()         { *m = HeightRequest{} }
+func (m *HeightRequest) String() string { return proto.CompactTextString(m) }
+func (*HeightRequest) ProtoMessage()    {}
+func (*HeightRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{19}
+}
+
+func (m *HeightRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeightRequest.Unmarshal(m, b)
+}
+func (m *HeightRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeightRequest.Marshal(b, m, deterministic)
+}
+func (m *HeightRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeightRequest.Merge(m, src)
+}
+func (m *HeightRequest) XXX_Size() int {
+	return xxx_messageInfo_HeightRequest.Size(m)
+}
+func (m *HeightRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeightRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeightRequest proto.InternalMessageInfo
+
+func (m *HeightRequest) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type MessagesResponse struct {
+	Messages             []*CrossChainMessage `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *MessagesResponse) Reset()         { *m = MessagesResponse{} }
+func (m *MessagesResponse) String() string { return proto.CompactTextString(m) }
+func (*MessagesResponse) ProtoMessage()    {}
+func (*MessagesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{20}
+}
+
+func (m *MessagesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessagesResponse.Unmarshal(m, b)
+}
+func (m *MessagesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessagesResponse.Marshal(b, m, deterministic)
+}
+func (m *MessagesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessagesResponse.Merge(m, src)
+}
+func (m *MessagesResponse) XXX_Size() int {
+	return xxx_messageInfo_MessagesResponse.Size(m)
+}
+func (m *MessagesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessagesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessagesResponse proto.InternalMessageInfo
+
+func (m *MessagesResponse) GetMessages() []*CrossChainMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type HeightResponse struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeightResponse) Reset()         { *m = HeightResponse{} }
+func (m *HeightResponse) String() string { return proto.CompactTextString(m) }
+func (*HeightResponse) ProtoMessage()    {}
+func (*HeightResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b00dc19eb500fd0b, []int{21}
+}
+
+func (m *HeightResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeightResponse.Unmarshal(m, b)
+}
+func (m *HeightResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeightResponse.Marshal(b, m, deterministic)
+}
+func (m *HeightResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeightResponse.Merge(m, src)
+}
+func (m *HeightResponse) XXX_Size() int {
+	return xxx_messageInfo_HeightResponse.Size(m)
+}
+func (m *HeightResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeightResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeightResponse proto.InternalMessageInfo
+
+func (m *HeightResponse) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("antchain.bridge.plugin.ContractStatus", ContractStatus_name, ContractStatus_value)
+	proto.RegisterEnum("antchain.bridge.plugin.CrossChainMessageType", CrossChainMessageType_name, CrossChainMessageType_value)
+	proto.RegisterEnum("antchain.bridge.plugin.RelayState", RelayState_name, RelayState_value)
+	proto.RegisterType((*Empty)(nil), "antchain.bridge.plugin.Empty")
+	proto.RegisterType((*Contract)(nil), "antchain.bridge.plugin.Contract")
+	proto.RegisterType((*BBCContext)(nil), "antchain.bridge.plugin.BBCContext")
+	proto.RegisterType((*ProvableLedgerData)(nil), "antchain.bridge.plugin.ProvableLedgerData")
+	proto.RegisterType((*CrossChainMessage)(nil), "antchain.bridge.plugin.CrossChainMessage")
+	proto.RegisterType((*CrossChainMessageReceipt)(nil), "antchain.bridge.plugin.CrossChainMessageReceipt")
+	proto.RegisterType((*RelayStatus)(nil), "antchain.bridge.plugin.RelayStatus")
+	proto.RegisterType((*StartupRequest)(nil), "antchain.bridge.plugin.StartupRequest")
+	proto.RegisterType((*ContextResponse)(nil), "antchain.bridge.plugin.ContextResponse")
+	proto.RegisterType((*SetProtocolRequest)(nil), "antchain.bridge.plugin.SetProtocolRequest")
+	proto.RegisterType((*SetAmContractRequest)(nil), "antchain.bridge.plugin.SetAmContractRequest")
+	proto.RegisterType((*SetLocalDomainRequest)(nil), "antchain.bridge.plugin.SetLocalDomainRequest")
+	proto.RegisterType((*QuerySDPMessageSeqRequest)(nil), "antchain.bridge.plugin.QuerySDPMessageSeqRequest")
+	proto.RegisterType((*SeqResponse)(nil), "antchain.bridge.plugin.SeqResponse")
+	proto.RegisterType((*RelayAuthMessageRequest)(nil), "antchain.bridge.plugin.RelayAuthMessageRequest")
+	proto.RegisterType((*ReadReceiptRequest)(nil), "antchain.bridge.plugin.ReadReceiptRequest")
+	proto.RegisterType((*ReceiptResponse)(nil), "antchain.bridge.plugin.ReceiptResponse")
+	proto.RegisterType((*QueryRelayStatusRequest)(nil), "antchain.bridge.plugin.QueryRelayStatusRequest")
+	proto.RegisterType((*RelayStatusResponse)(nil), "antchain.bridge.plugin.RelayStatusResponse")
+	proto.RegisterType((*HeightRequest)(nil), "antchain.bridge.plugin.HeightRequest")
+	proto.RegisterType((*MessagesResponse)(nil), "antchain.bridge.plugin.MessagesResponse")
+	proto.RegisterType((*HeightResponse)(nil), "antchain.bridge.plugin.HeightResponse")
+}
+
+func init() {
+	proto.RegisterFile("bbc.proto", fileDescriptor_b00dc19eb500fd0b)
+}
+
+var fileDescriptor_b00dc19eb500fd0b = []byte{
+	// 1362 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x5b, 0x72, 0x1a, 0x47,
+	0x17, 0xf6, 0x08, 0x5d, 0xe0, 0x80, 0xd0, 0xa8, 0x7d, 0xc3, 0xfa, 0x7d, 0xd1, 0x3f, 0xaa, 0x58,
+	0xb2, 0x12, 0x41, 0xa2, 0xbc, 0xa4, 0xe2, 0x54, 0xaa, 0x10, 0x8c, 0x25, 0xc5, 0x18, 0xc8, 0x8c,
+	0x1c, 0x5b, 0x2a, 0x27, 0x54, 0x33, 0xd3, 0x5c, 0xca, 0xc0, 0x8c, 0xa6, 0x1b, 0xcb, 0x2c, 0x21,
+	0x2f, 0xd9, 0x48, 0x56, 0x90, 0xc7, 0x2c, 0x21, 0xcf, 0xd9, 0x46, 0x16, 0x90, 0x9a, 0xee, 0x9e,
+	0x8b, 0x84, 0x46, 0x50, 0xe5, 0xbc, 0x31, 0x1f, 0x7d, 0xbe, 0x73, 0xe9, 0x73, 0xce, 0xd7, 0x90,
+	0x69, 0xb7, 0xad, 0xa2, 0xeb, 0x39, 0xcc, 0x41, 0xf7, 0xf0, 0x88, 0x59, 0x3d, 0xdc, 0x1f, 0x15,
+	0xdb, 0x5e, 0xdf, 0xee, 0x92, 0xa2, 0x3b, 0x18, 0x77, 0xfb, 0x23, 0x6d, 0x05, 0x96, 0xf4, 0xa1,
+	0xcb, 0x26, 0xda, 0x18, 0xd2, 0x15, 0x67, 0xc4, 0x3c, 0x6c, 0x31, 0xf4, 0x0c, 0x54, 0x4b, 0xfe,
+	0x6e, 0x61, 0xdb, 0xf6, 0x08, 0xa5, 0x05, 0x65, 0x53, 0xd9, 0xc9, 0x18, 0x6b, 0x01, 0x5e, 0x16,
+	0x30, 0xfa, 0x1e, 0x96, 0x29, 0xc3, 0x6c, 0x4c, 0x0b, 0x0b, 0x9b, 0xca, 0x4e, 0x7e, 0xff, 0x69,
+	0xf1, 0x7a, 0x47, 0xc5, 0x80, 0xdc, 0xe4, 0xa7, 0x0d, 0x69, 0xa5, 0xfd, 0xa3, 0x00, 0x1c, 0x1c,
+	0x54, 0xfc, 0x7f, 0xc9, 0x47, 0x86, 0xca, 0x90, 0xc5, 0xc3, 0x56, 0xe0, 0x84, 0x3b, 0xcd, 0xee,
+	0x6f, 0xce, 0xe2, 0x34, 0x00, 0x0f, 0xc3, 0xe0, 0x2b, 0x90, 0x73, 0x99, 0x15, 0x71, 0x2c, 0xcc,
+	0xc9, 0x91, 0x75, 0x99, 0x15, 0x27, 0xa1, 0xb6, 0x1b, 0x91, 0xa4, 0xe6, 0x25, 0xa1, 0xb6, 0x1b,
+	0x92, 0x3c, 0x80, 0xb4, 0x87, 0x2f, 0x7c, 0x92, 0x4e, 0x61, 0x71, 0x53, 0xd9, 0xc9, 0x19, 0x2b,
+	0x1e, 0xbe, 0xa8, 0x38, 0xa3, 0x8e, 0xf6, 0x87, 0x02, 0xa8, 0xe9, 0x39, 0x1f, 0x70, 0x7b, 0x40,
+	0x6a, 0xc4, 0xee, 0x12, 0xaf, 0x8a, 0x19, 0x46, 0xf7, 0x60, 0xb9, 0x47, 0xfa, 0xdd, 0x9e, 0xc8,
+	0x7c, 0xd1, 0x90, 0x5f, 0xe8, 0x11, 0x40, 0x7b, 0xe0, 0x58, 0xef, 0x5b, 0x3d, 0x4c, 0x7b, 0x3c,
+	0xa3, 0x9c, 0x91, 0xe1, 0xc8, 0x11, 0xa6, 0x3d, 0xf4, 0x10, 0x32, 0xac, 0x3f, 0x24, 0x94, 0xe1,
+	0xa1, 0xcb, 0x43, 0x4d, 0x19, 0x11, 0x80, 0x9e, 0x40, 0x76, 0xc0, 0x5d, 0xb4, 0x6c, 0xcc, 0xb0,
+	0x8c, 0x04, 0x06, 0x91, 0xd7, 0x3b, 0xb0, 0xe4, 0x7a, 0x8e, 0xd3, 0x29, 0x2c, 0xf1, 0xbf, 0xc4,
+	0x07, 0xba, 0x0f, 0x2b, 0xec, 0xa3, 0x70, 0xb8, 0xcc, 0xf1, 0x65, 0xf6, 0xd1, 0xf7, 0xa6, 0xfd,
+	0xa9, 0xc0, 0x7a, 0xc5, 0x73, 0x28, 0xad, 0xf8, 0x95, 0x78, 0x45, 0x28, 0xc5, 0x5d, 0x82, 0xca,
+	0xb0, 0xc8, 0x26, 0x2e, 0xe1, 0x81, 0xe7, 0xf7, 0xf7, 0x12, 0x2b, 0x75, 0xd5, 0xf0, 0x64, 0xe2,
+	0x12, 0x83, 0x9b, 0xa2, 0x02, 0xac, 0x0c, 0x05, 0x28, 0x53, 0x0c, 0x3e, 0x51, 0x03, 0x56, 0x5d,
+	0x59, 0x2d, 0x91, 0x84, 0xb8, 0x8f, 0xdd, 0x24, 0x2f, 0xd3, 0xa5, 0x35, 0x72, 0x01, 0x81, 0xff,
+	0xa5, 0xfd, 0xa6, 0x40, 0x61, 0x2a, 0x14, 0x83, 0x58, 0xa4, 0xef, 0xb2, 0x78, 0xe6, 0xa2, 0xeb,
+	0x65, 0xe6, 0x7e, 0x9d, 0xfd, 0xcb, 0xec, 0x7b, 0x43, 0x62, 0xf3, 0x10, 0xd3, 0x46, 0x04, 0xa0,
+	0xc7, 0x00, 0x74, 0x6c, 0x59, 0x84, 0xd2, 0xce, 0x78, 0xc0, 0x23, 0x4c, 0x1b, 0x31, 0x04, 0xfd,
+	0x0f, 0x32, 0xc4, 0xf3, 0x1c, 0xaf, 0x35, 0xa4, 0x5d, 0x7e, 0x0b, 0x19, 0x23, 0xcd, 0x81, 0x57,
+	0xb4, 0xab, 0xfd, 0xa5, 0x40, 0xd6, 0x20, 0x03, 0x3c, 0x11, 0xf3, 0xe1, 0x5f, 0x9a, 0x8b, 0xad,
+	0xf7, 0x84, 0xc5, 0xe3, 0x00, 0x01, 0xf1, 0x58, 0xbe, 0x81, 0x25, 0x7f, 0x84, 0x88, 0x9c, 0x3b,
+	0x2d, 0xa9, 0x14, 0x21, 0x29, 0x31, 0x84, 0x01, 0xfa, 0x01, 0x56, 0x3c, 0x91, 0xa9, 0x2c, 0xe3,
+	0x97, 0x73, 0x5f, 0x96, 0xac, 0x90, 0x11, 0x10, 0xf8, 0x39, 0xc9, 0x52, 0x11, 0x5a, 0x58, 0xdc,
+	0x4c, 0xf9, 0x39, 0x89, 0x62, 0x11, 0xaa, 0xd5, 0x21, 0x6f, 0x32, 0xec, 0xb1, 0xb1, 0x6b, 0x90,
+	0xf3, 0x31, 0xa1, 0x0c, 0x7d, 0x07, 0x2b, 0x96, 0x98, 0x74, 0x39, 0xda, 0x89, 0x61, 0x47, 0x3b,
+	0xc1, 0x08, 0x4c, 0xb4, 0x06, 0xac, 0x05, 0x18, 0xa1, 0xae, 0x33, 0xa2, 0xe4, 0x13, 0x09, 0x6d,
+	0x40, 0x26, 0x61, 0x4d, 0x7f, 0x41, 0x5a, 0xce, 0x20, 0x08, 0xf2, 0x19, 0xa8, 0xae, 0x84, 0xae,
+	0x6e, 0xbf, 0x00, 0x0f, 0xb6, 0xdf, 0x16, 0xac, 0x06, 0x50, 0x8b, 0x77, 0xff, 0x02, 0x3f, 0x97,
+	0x0b, 0x40, 0xbf, 0xb9, 0xb5, 0x32, 0xdc, 0x31, 0x09, 0x2b, 0x87, 0x1b, 0x2a, 0xe6, 0x67, 0xce,
+	0x2d, 0xab, 0x95, 0xe0, 0xae, 0x49, 0x58, 0xcd, 0xb1, 0xf0, 0xa0, 0xea, 0x0c, 0x71, 0x7f, 0x14,
+	0x70, 0xdc, 0x83, 0x65, 0x9b, 0x03, 0x41, 0xa7, 0x8a, 0x2f, 0xed, 0x77, 0x05, 0x1e, 0xfc, 0x38,
+	0x26, 0xde, 0xc4, 0xac, 0x36, 0xe5, 0xdd, 0x99, 0xe4, 0x3c, 0xb0, 0xda, 0x82, 0x55, 0x4a, 0x46,
+	0xb6, 0xbf, 0x11, 0xe2, 0xc6, 0x39, 0x01, 0x0a, 0x0f, 0xe8, 0xff, 0x90, 0xeb, 0x78, 0xce, 0x30,
+	0x0c, 0x4d, 0xa4, 0x96, 0xf5, 0xb1, 0x20, 0xfd, 0x6d, 0x58, 0xe3, 0x8d, 0xf0, 0x21, 0x62, 0x4a,
+	0xf1, 0x53, 0xf9, 0x00, 0x96, 0x5c, 0x8f, 0x00, 0x98, 0x13, 0x32, 0x89, 0xde, 0xcf, 0x30, 0x27,
+	0x48, 0xef, 0x09, 0x64, 0x79, 0x74, 0xf2, 0x52, 0x55, 0x48, 0x51, 0x72, 0x2e, 0x57, 0xa0, 0xff,
+	0x53, 0xfb, 0x16, 0xee, 0xf3, 0x3e, 0x2e, 0x8f, 0x59, 0x2f, 0x6c, 0x45, 0x91, 0xcb, 0x13, 0xc8,
+	0xfa, 0x4b, 0x36, 0x58, 0x1c, 0x8a, 0xd8, 0x6e, 0x1e, 0xbe, 0x90, 0xe7, 0xb4, 0x3d, 0x40, 0x06,
+	0xc1, 0x76, 0xd0, 0xba, 0xd2, 0x2c, 0x69, 0xc6, 0xb5, 0x9f, 0x61, 0x2d, 0x3c, 0x2a, 0xe3, 0x89,
+	0x0d, 0x8c, 0xf2, 0x89, 0x03, 0xe3, 0x67, 0xc2, 0xef, 0x25, 0x36, 0xeb, 0xb1, 0x4c, 0x6e, 0x1c,
+	0x79, 0xcd, 0x80, 0xdb, 0x97, 0xcc, 0x64, 0x78, 0xcf, 0x43, 0x09, 0x16, 0xd1, 0x6d, 0xcd, 0x5c,
+	0x05, 0x31, 0xfd, 0xdd, 0x86, 0xd5, 0x23, 0xae, 0x31, 0xb1, 0x8e, 0xba, 0x4e, 0x82, 0xb4, 0x53,
+	0x50, 0x65, 0x4e, 0x91, 0x67, 0x1d, 0xd2, 0xb2, 0xee, 0xbe, 0xef, 0xd4, 0x4e, 0x76, 0xff, 0xd9,
+	0xfc, 0x95, 0x09, 0x4d, 0xb5, 0x1d, 0xc8, 0x07, 0x31, 0x48, 0xe2, 0x84, 0x20, 0x76, 0xcf, 0x20,
+	0x7f, 0xf9, 0x1d, 0x81, 0xd2, 0xb0, 0x78, 0x5c, 0x3f, 0x3e, 0x51, 0x6f, 0xa1, 0xbb, 0xb0, 0x5e,
+	0x69, 0xd4, 0x4f, 0x8c, 0x72, 0xe5, 0xa4, 0x55, 0xd5, 0x9b, 0xb5, 0xc6, 0xa9, 0x5e, 0x55, 0x15,
+	0x84, 0x20, 0x1f, 0xc2, 0x86, 0x5e, 0xae, 0x9e, 0xaa, 0x0b, 0xe8, 0x36, 0xac, 0x85, 0xd8, 0x0b,
+	0x43, 0xd7, 0xcf, 0x74, 0x35, 0xb5, 0xfb, 0x1c, 0xee, 0x5e, 0x2b, 0x4e, 0x28, 0x07, 0xe9, 0xf2,
+	0xeb, 0x93, 0xa3, 0xd6, 0x2b, 0xf3, 0x50, 0xbd, 0x85, 0xee, 0x80, 0x5a, 0xd5, 0x7f, 0xd2, 0x6b,
+	0x8d, 0xa6, 0x6e, 0xb4, 0xaa, 0xba, 0x79, 0x7c, 0x58, 0x57, 0x95, 0xdd, 0x37, 0x00, 0xd1, 0xa2,
+	0x45, 0xeb, 0xb0, 0x6a, 0xe8, 0xb5, 0xf2, 0x69, 0xeb, 0x75, 0xfd, 0x65, 0xbd, 0xf1, 0xa6, 0xae,
+	0xde, 0x8a, 0xa0, 0xa6, 0x5e, 0xaf, 0x1e, 0xd7, 0x0f, 0x55, 0x25, 0x82, 0xcc, 0xd7, 0x95, 0x8a,
+	0x6e, 0x9a, 0xea, 0x02, 0x52, 0x21, 0x27, 0xa0, 0x17, 0xe5, 0xe3, 0x9a, 0x5e, 0x55, 0x53, 0xfb,
+	0x7f, 0x03, 0x7f, 0x1f, 0x99, 0xc4, 0xfb, 0xd0, 0xb7, 0x08, 0x6a, 0xc2, 0x8a, 0x5c, 0xa9, 0x28,
+	0xf1, 0xa5, 0x75, 0x79, 0xe7, 0x6e, 0x3c, 0x4a, 0x3a, 0xc7, 0xdf, 0x7d, 0xe8, 0x08, 0xd2, 0x66,
+	0x6f, 0xcc, 0x6c, 0xe7, 0x62, 0x84, 0x6e, 0x3e, 0x3a, 0x8b, 0xe9, 0x04, 0xe0, 0x90, 0xb0, 0xe0,
+	0x25, 0x37, 0x83, 0x6b, 0xfb, 0xa6, 0xa7, 0x54, 0x7c, 0xc3, 0xbf, 0x85, 0x82, 0x49, 0xd8, 0xd8,
+	0x8d, 0x8d, 0x7e, 0xf8, 0xc0, 0xfa, 0xb4, 0x78, 0xdf, 0xc0, 0x7d, 0xce, 0x1c, 0xad, 0xc8, 0xff,
+	0x88, 0xf8, 0x2d, 0x64, 0x63, 0xb2, 0x82, 0x12, 0x5f, 0x29, 0xd3, 0xda, 0x33, 0x8b, 0xf9, 0x1d,
+	0xac, 0x5e, 0x92, 0x12, 0xf4, 0xc5, 0x0d, 0xdc, 0x53, 0x8a, 0x33, 0x8b, 0xfd, 0x17, 0xc8, 0x5f,
+	0x56, 0x19, 0xb4, 0x77, 0x03, 0xfd, 0xb4, 0x1a, 0xcd, 0xe2, 0x1f, 0x00, 0x9a, 0xd6, 0x24, 0xf4,
+	0x55, 0x92, 0x51, 0xa2, 0x7e, 0x6d, 0x6c, 0x25, 0x87, 0x15, 0xa9, 0xc8, 0x00, 0xd4, 0xab, 0x9a,
+	0x81, 0x4a, 0x37, 0xae, 0xc6, 0x69, 0x75, 0x49, 0x6e, 0xd3, 0xab, 0x1a, 0xe1, 0x82, 0x7a, 0x75,
+	0xaf, 0x27, 0x7b, 0x4b, 0x50, 0x80, 0x8d, 0xcf, 0xe7, 0xd9, 0xdc, 0x81, 0x47, 0x0a, 0x0f, 0x7d,
+	0x5d, 0x4b, 0x7c, 0xc5, 0xee, 0x26, 0x93, 0x5d, 0x55, 0xc3, 0xf9, 0xd3, 0x3c, 0x87, 0xc7, 0xd7,
+	0x3a, 0xa5, 0x07, 0x13, 0xb1, 0xc2, 0xd1, 0x67, 0x49, 0x54, 0x97, 0x64, 0x66, 0x63, 0x27, 0xe9,
+	0xd8, 0x94, 0xc8, 0x9c, 0xc1, 0x3a, 0xaf, 0x57, 0x0d, 0x33, 0x42, 0x99, 0xf4, 0x32, 0x63, 0x40,
+	0x9f, 0xce, 0x0a, 0x42, 0x70, 0x1f, 0xfc, 0xaa, 0xc0, 0xb6, 0xe5, 0x0c, 0x8b, 0x78, 0xd0, 0x77,
+	0xf1, 0x24, 0xc1, 0x88, 0x16, 0xbb, 0x9e, 0x6b, 0x35, 0x95, 0xb3, 0x77, 0xdd, 0x3e, 0xeb, 0x8d,
+	0xdb, 0x45, 0xcb, 0x19, 0x96, 0xca, 0x23, 0xc6, 0xf3, 0x6f, 0xb8, 0x64, 0x54, 0xc3, 0xed, 0xf0,
+	0xfb, 0x80, 0x5b, 0x36, 0xb9, 0xa1, 0x59, 0x7d, 0x59, 0x92, 0x14, 0x84, 0x95, 0x3a, 0xb8, 0xed,
+	0xf5, 0xad, 0x92, 0xd3, 0xe9, 0x70, 0x1f, 0x7b, 0xe2, 0x9f, 0xbd, 0xae, 0x53, 0x72, 0xdb, 0xcf,
+	0xdd, 0x76, 0x7b, 0x99, 0x3f, 0x1a, 0xbf, 0xfe, 0x77, 0x00, 0xb2, 0xac, 0x51, 0xac, 0xb5, 0x0f,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// BBCServiceClient is the client API for BBCService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BBCServiceClient interface {
+	Startup(ctx context.Context, in *StartupRequest, opts ...grpc.CallOption) (*Empty, error)
+	Shutdown(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	GetContext(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ContextResponse, error)
+	SetupAuthMessageContract(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	SetupSDPMessageContract(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	SetProtocol(ctx context.Context, in *SetProtocolRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetAmContract(ctx context.Context, in *SetAmContractRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetLocalDomain(ctx context.Context, in *SetLocalDomainRequest, opts ...grpc.CallOption) (*Empty, error)
+	QuerySDPMessageSeq(ctx context.Context, in *QuerySDPMessageSeqRequest, opts ...grpc.CallOption) (*SeqResponse, error)
+	RelayAuthMessage(ctx context.Context, in *RelayAuthMessageRequest, opts ...grpc.CallOption) (*ReceiptResponse, error)
+	QueryRelayStatus(ctx context.Context, in *QueryRelayStatusRequest, opts ...grpc.CallOption) (*RelayStatusResponse, error)
+	ReadCrossChainMessageReceipt(ctx context.Context, in *ReadReceiptRequest, opts ...grpc.CallOption) (*ReceiptResponse, error)
+	ReadCrossChainMessagesByHeight(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*MessagesResponse, error)
+	QueryLatestHeight(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HeightResponse, error)
+}
+
+type bBCServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBBCServiceClient(cc grpc.ClientConnInterface) BBCServiceClient {
+	return &bBCServiceClient{cc}
+}
+
+func (c *bBCServiceClient) Startup(ctx context.Context, in *StartupRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/Startup", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) Shutdown(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/Shutdown", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) GetContext(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ContextResponse, error) {
+	out := new(ContextResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/GetContext", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) SetupAuthMessageContract(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/SetupAuthMessageContract", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) SetupSDPMessageContract(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/SetupSDPMessageContract", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) SetProtocol(ctx context.Context, in *SetProtocolRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/SetProtocol", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) SetAmContract(ctx context.Context, in *SetAmContractRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/SetAmContract", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) SetLocalDomain(ctx context.Context, in *SetLocalDomainRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/SetLocalDomain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) QuerySDPMessageSeq(ctx context.Context, in *QuerySDPMessageSeqRequest, opts ...grpc.CallOption) (*SeqResponse, error) {
+	out := new(SeqResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/QuerySDPMessageSeq", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) RelayAuthMessage(ctx context.Context, in *RelayAuthMessageRequest, opts ...grpc.CallOption) (*ReceiptResponse, error) {
+	out := new(ReceiptResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/RelayAuthMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) QueryRelayStatus(ctx context.Context, in *QueryRelayStatusRequest, opts ...grpc.CallOption) (*RelayStatusResponse, error) {
+	out := new(RelayStatusResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/QueryRelayStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) ReadCrossChainMessageReceipt(ctx context.Context, in *ReadReceiptRequest, opts ...grpc.CallOption) (*ReceiptResponse, error) {
+	out := new(ReceiptResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/ReadCrossChainMessageReceipt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) ReadCrossChainMessagesByHeight(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*MessagesResponse, error) {
+	out := new(MessagesResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/ReadCrossChainMessagesByHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bBCServiceClient) QueryLatestHeight(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HeightResponse, error) {
+	out := new(HeightResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.BBCService/QueryLatestHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BBCServiceServer is the server API for BBCService service.
+type BBCServiceServer interface {
+	Startup(context.Context, *StartupRequest) (*Empty, error)
+	Shutdown(context.Context, *Empty) (*Empty, error)
+	GetContext(context.Context, *Empty) (*ContextResponse, error)
+	SetupAuthMessageContract(context.Context, *Empty) (*Empty, error)
+	SetupSDPMessageContract(context.Context, *Empty) (*Empty, error)
+	SetProtocol(context.Context, *SetProtocolRequest) (*Empty, error)
+	SetAmContract(context.Context, *SetAmContractRequest) (*Empty, error)
+	SetLocalDomain(context.Context, *SetLocalDomainRequest) (*Empty, error)
+	QuerySDPMessageSeq(context.Context, *QuerySDPMessageSeqRequest) (*SeqResponse, error)
+	RelayAuthMessage(context.Context, *RelayAuthMessageRequest) (*ReceiptResponse, error)
+	QueryRelayStatus(context.Context, *QueryRelayStatusRequest) (*RelayStatusResponse, error)
+	ReadCrossChainMessageReceipt(context.Context, *ReadReceiptRequest) (*ReceiptResponse, error)
+	ReadCrossChainMessagesByHeight(context.Context, *HeightRequest) (*MessagesResponse, error)
+	QueryLatestHeight(context.Context, *Empty) (*HeightResponse, error)
+}
+
+// UnimplementedBBCServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedBBCServiceServer struct {
+}
+
+func (*UnimplementedBBCServiceServer) Startup(ctx context.Context, req *StartupRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Startup not implemented")
+}
+func (*UnimplementedBBCServiceServer) Shutdown(ctx context.Context, req *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
+}
+func (*UnimplementedBBCServiceServer) GetContext(ctx context.Context, req *Empty) (*ContextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContext not implemented")
+}
+func (*UnimplementedBBCServiceServer) SetupAuthMessageContract(ctx context.Context, req *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetupAuthMessageContract not implemented")
+}
+func (*UnimplementedBBCServiceServer) SetupSDPMessageContract(ctx context.Context, req *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetupSDPMessageContract not implemented")
+}
+func (*UnimplementedBBCServiceServer) SetProtocol(ctx context.Context, req *SetProtocolRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProtocol not implemented")
+}
+func (*UnimplementedBBCServiceServer) SetAmContract(ctx context.Context, req *SetAmContractRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAmContract not implemented")
+}
+func (*UnimplementedBBCServiceServer) SetLocalDomain(ctx context.Context, req *SetLocalDomainRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLocalDomain not implemented")
+}
+func (*UnimplementedBBCServiceServer) QuerySDPMessageSeq(ctx context.Context, req *QuerySDPMessageSeqRequest) (*SeqResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuerySDPMessageSeq not implemented")
+}
+func (*UnimplementedBBCServiceServer) RelayAuthMessage(ctx context.Context, req *RelayAuthMessageRequest) (*ReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RelayAuthMessage not implemented")
+}
+func (*UnimplementedBBCServiceServer) QueryRelayStatus(ctx context.Context, req *QueryRelayStatusRequest) (*RelayStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryRelayStatus not implemented")
+}
+func (*UnimplementedBBCServiceServer) ReadCrossChainMessageReceipt(ctx context.Context, req *ReadReceiptRequest) (*ReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadCrossChainMessageReceipt not implemented")
+}
+func (*UnimplementedBBCServiceServer) ReadCrossChainMessagesByHeight(ctx context.Context, req *HeightRequest) (*MessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadCrossChainMessagesByHeight not implemented")
+}
+func (*UnimplementedBBCServiceServer) QueryLatestHeight(ctx context.Context, req *Empty) (*HeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryLatestHeight not implemented")
+}
+
+func RegisterBBCServiceServer(s *grpc.Server, srv BBCServiceServer) {
+	s.RegisterService(&_BBCService_serviceDesc, srv)
+}
+
+func _BBCService_Startup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).Startup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/Startup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).Startup(ctx, req.(*StartupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/Shutdown",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).Shutdown(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_GetContext_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).GetContext(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/GetContext",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).GetContext(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_SetupAuthMessageContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).SetupAuthMessageContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/SetupAuthMessageContract",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).SetupAuthMessageContract(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_SetupSDPMessageContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).SetupSDPMessageContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/SetupSDPMessageContract",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).SetupSDPMessageContract(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_SetProtocol_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetProtocolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).SetProtocol(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/SetProtocol",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).SetProtocol(ctx, req.(*SetProtocolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_SetAmContract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAmContractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).SetAmContract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/SetAmContract",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).SetAmContract(ctx, req.(*SetAmContractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_SetLocalDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLocalDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).SetLocalDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/SetLocalDomain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).SetLocalDomain(ctx, req.(*SetLocalDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_QuerySDPMessageSeq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuerySDPMessageSeqRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).QuerySDPMessageSeq(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/QuerySDPMessageSeq",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).QuerySDPMessageSeq(ctx, req.(*QuerySDPMessageSeqRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_RelayAuthMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelayAuthMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).RelayAuthMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/RelayAuthMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).RelayAuthMessage(ctx, req.(*RelayAuthMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_QueryRelayStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRelayStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).QueryRelayStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/QueryRelayStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).QueryRelayStatus(ctx, req.(*QueryRelayStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_ReadCrossChainMessageReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).ReadCrossChainMessageReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/ReadCrossChainMessageReceipt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).ReadCrossChainMessageReceipt(ctx, req.(*ReadReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_ReadCrossChainMessagesByHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).ReadCrossChainMessagesByHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/ReadCrossChainMessagesByHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).ReadCrossChainMessagesByHeight(ctx, req.(*HeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BBCService_QueryLatestHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BBCServiceServer).QueryLatestHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.BBCService/QueryLatestHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BBCServiceServer).QueryLatestHeight(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BBCService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "antchain.bridge.plugin.BBCService",
+	HandlerType: (*BBCServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Startup",
+			Handler:    _BBCService_Startup_Handler,
+		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _BBCService_Shutdown_Handler,
+		},
+		{
+			MethodName: "GetContext",
+			Handler:    _BBCService_GetContext_Handler,
+		},
+		{
+			MethodName: "SetupAuthMessageContract",
+			Handler:    _BBCService_SetupAuthMessageContract_Handler,
+		},
+		{
+			MethodName: "SetupSDPMessageContract",
+			Handler:    _BBCService_SetupSDPMessageContract_Handler,
+		},
+		{
+			MethodName: "SetProtocol",
+			Handler:    _BBCService_SetProtocol_Handler,
+		},
+		{
+			MethodName: "SetAmContract",
+			Handler:    _BBCService_SetAmContract_Handler,
+		},
+		{
+			MethodName: "SetLocalDomain",
+			Handler:    _BBCService_SetLocalDomain_Handler,
+		},
+		{
+			MethodName: "QuerySDPMessageSeq",
+			Handler:    _BBCService_QuerySDPMessageSeq_Handler,
+		},
+		{
+			MethodName: "RelayAuthMessage",
+			Handler:    _BBCService_RelayAuthMessage_Handler,
+		},
+		{
+			MethodName: "QueryRelayStatus",
+			Handler:    _BBCService_QueryRelayStatus_Handler,
+		},
+		{
+			MethodName: "ReadCrossChainMessageReceipt",
+			Handler:    _BBCService_ReadCrossChainMessageReceipt_Handler,
+		},
+		{
+			MethodName: "ReadCrossChainMessagesByHeight",
+			Handler:    _BBCService_ReadCrossChainMessagesByHeight_Handler,
+		},
+		{
+			MethodName: "QueryLatestHeight",
+			Handler:    _BBCService_QueryLatestHeight_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bbc.proto",
+}
@@ -0,0 +1,417 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: committee.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type NodeSignature struct {
+	NodeId    string `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// 签名算法，与链码sigverify包一致：0为SHA-256加ECDSA P-256，2为SM3加SM2
+	SignAlgo             uint32   `protobuf:"varint,3,opt,name=sign_algo,json=signAlgo,proto3" json:"sign_algo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeSignature) Reset()         { *m = NodeSignature{} }
+func (m *NodeSignature) String() string { return proto.CompactTextString(m) }
+func (*NodeSignature) ProtoMessage()    {}
+func (*NodeSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a6bf5e12114a1d6, []int{0}
+}
+
+func (m *NodeSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeSignature.Unmarshal(m, b)
+}
+func (m *NodeSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeSignature.Marshal(b, m, deterministic)
+}
+func (m *NodeSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeSignature.Merge(m, src)
+}
+func (m *NodeSignature) XXX_Size() int {
+	return xxx_messageInfo_NodeSignature.Size(m)
+}
+func (m *NodeSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeSignature proto.InternalMessageInfo
+
+func (m *NodeSignature) GetNodeId() string {
+	if m != nil {
+		return m.NodeId
+	}
+	return ""
+}
+
+func (m *NodeSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *NodeSignature) GetSignAlgo() uint32 {
+	if m != nil {
+		return m.SignAlgo
+	}
+	return 0
+}
+
+type EndorseRequest struct {
+	Message *CrossChainMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// 消息所在区块适用的共识状态
+	Anchor               *ConsensusState `protobuf:"bytes,2,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *EndorseRequest) Reset()         { *m = EndorseRequest{} }
+func (m *EndorseRequest) String() string { return proto.CompactTextString(m) }
+func (*EndorseRequest) ProtoMessage()    {}
+func (*EndorseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a6bf5e12114a1d6, []int{1}
+}
+
+func (m *EndorseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorseRequest.Unmarshal(m, b)
+}
+func (m *EndorseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorseRequest.Marshal(b, m, deterministic)
+}
+func (m *EndorseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorseRequest.Merge(m, src)
+}
+func (m *EndorseRequest) XXX_Size() int {
+	return xxx_messageInfo_EndorseRequest.Size(m)
+}
+func (m *EndorseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorseRequest proto.InternalMessageInfo
+
+func (m *EndorseRequest) GetMessage() *CrossChainMessage {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *EndorseRequest) GetAnchor() *ConsensusState {
+	if m != nil {
+		return m.Anchor
+	}
+	return nil
+}
+
+type EndorseResponse struct {
+	Signature            *NodeSignature `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *EndorseResponse) Reset()         { *m = EndorseResponse{} }
+func (m *EndorseResponse) String() string { return proto.CompactTextString(m) }
+func (*EndorseResponse) ProtoMessage()    {}
+func (*EndorseResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a6bf5e12114a1d6, []int{2}
+}
+
+func (m *EndorseResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorseResponse.Unmarshal(m, b)
+}
+func (m *EndorseResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorseResponse.Marshal(b, m, deterministic)
+}
+func (m *EndorseResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorseResponse.Merge(m, src)
+}
+func (m *EndorseResponse) XXX_Size() int {
+	return xxx_messageInfo_EndorseResponse.Size(m)
+}
+func (m *EndorseResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorseResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorseResponse proto.InternalMessageInfo
+
+func (m *EndorseResponse) GetSignature() *NodeSignature {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type AggregateRequest struct {
+	// AM报文，即AUTH_MSG消息的message
+	Package              []byte           `protobuf:"bytes,1,opt,name=package,proto3" json:"package,omitempty"`
+	Signatures           []*NodeSignature `protobuf:"bytes,2,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *AggregateRequest) Reset()         { *m = AggregateRequest{} }
+func (m *AggregateRequest) String() string { return proto.CompactTextString(m) }
+func (*AggregateRequest) ProtoMessage()    {}
+func (*AggregateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a6bf5e12114a1d6, []int{3}
+}
+
+func (m *AggregateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AggregateRequest.Unmarshal(m, b)
+}
+func (m *AggregateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AggregateRequest.Marshal(b, m, deterministic)
+}
+func (m *AggregateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AggregateRequest.Merge(m, src)
+}
+func (m *AggregateRequest) XXX_Size() int {
+	return xxx_messageInfo_AggregateRequest.Size(m)
+}
+func (m *AggregateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AggregateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AggregateRequest proto.InternalMessageInfo
+
+func (m *AggregateRequest) GetPackage() []byte {
+	if m != nil {
+		return m.Package
+	}
+	return nil
+}
+
+func (m *AggregateRequest) GetSignatures() []*NodeSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+type AggregateResponse struct {
+	// TLV编码的背书
+	Endorsement          []byte   `protobuf:"bytes,1,opt,name=endorsement,proto3" json:"endorsement,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AggregateResponse) Reset()         { *m = AggregateResponse{} }
+func (m *AggregateResponse) String() string { return proto.CompactTextString(m) }
+func (*AggregateResponse) ProtoMessage()    {}
+func (*AggregateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a6bf5e12114a1d6, []int{4}
+}
+
+func (m *AggregateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AggregateResponse.Unmarshal(m, b)
+}
+func (m *AggregateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AggregateResponse.Marshal(b, m, deterministic)
+}
+func (m *AggregateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AggregateResponse.Merge(m, src)
+}
+func (m *AggregateResponse) XXX_Size() int {
+	return xxx_messageInfo_AggregateResponse.Size(m)
+}
+func (m *AggregateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AggregateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AggregateResponse proto.InternalMessageInfo
+
+func (m *AggregateResponse) GetEndorsement() []byte {
+	if m != nil {
+		return m.Endorsement
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*NodeSignature)(nil), "antchain.bridge.plugin.NodeSignature")
+	proto.RegisterType((*EndorseRequest)(nil), "antchain.bridge.plugin.EndorseRequest")
+	proto.RegisterType((*EndorseResponse)(nil), "antchain.bridge.plugin.EndorseResponse")
+	proto.RegisterType((*AggregateRequest)(nil), "antchain.bridge.plugin.AggregateRequest")
+	proto.RegisterType((*AggregateResponse)(nil), "antchain.bridge.plugin.AggregateResponse")
+}
+
+func init() {
+	proto.RegisterFile("committee.proto", fileDescriptor_9a6bf5e12114a1d6)
+}
+
+var fileDescriptor_9a6bf5e12114a1d6 = []byte{
+	// 450 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xdb, 0x6a, 0xd4, 0x40,
+	0x18, 0x26, 0x2d, 0xec, 0xba, 0xff, 0xb6, 0xb6, 0xce, 0x85, 0x86, 0xd5, 0x8b, 0x10, 0xd0, 0x6e,
+	0x2f, 0x9a, 0xc0, 0x8a, 0x57, 0x82, 0xb0, 0x8d, 0xbd, 0x10, 0x4f, 0x25, 0x0b, 0x5e, 0x14, 0xa1,
+	0x4e, 0x26, 0xff, 0xce, 0x0e, 0x6e, 0x66, 0xc6, 0x99, 0x49, 0xc1, 0x47, 0xf0, 0x01, 0x7c, 0x2f,
+	0x1f, 0x49, 0x36, 0x27, 0x53, 0x31, 0xd8, 0xcb, 0xff, 0xf0, 0xfd, 0xdf, 0x21, 0x13, 0x38, 0x62,
+	0xaa, 0x28, 0x84, 0x73, 0x88, 0x91, 0x36, 0xca, 0x29, 0xf2, 0x90, 0x4a, 0xc7, 0x36, 0x54, 0xc8,
+	0x28, 0x33, 0x22, 0xe7, 0x18, 0xe9, 0x6d, 0xc9, 0x85, 0x9c, 0x4d, 0xb2, 0x8c, 0xd5, 0x2b, 0xb3,
+	0xe9, 0x86, 0xe5, 0x37, 0xb6, 0x2e, 0x42, 0x06, 0x87, 0x1f, 0x54, 0x8e, 0x2b, 0xc1, 0x25, 0x75,
+	0xa5, 0x41, 0xf2, 0x08, 0xc6, 0x52, 0xe5, 0x78, 0x2d, 0x72, 0xdf, 0x0b, 0xbc, 0xf9, 0x24, 0x1d,
+	0xed, 0xca, 0x37, 0x39, 0x79, 0x02, 0x13, 0xdb, 0x6e, 0xf9, 0x7b, 0x81, 0x37, 0x3f, 0x48, 0xff,
+	0x34, 0xc8, 0xe3, 0x7a, 0x7a, 0x4d, 0xb7, 0x5c, 0xf9, 0xfb, 0x81, 0x37, 0x3f, 0x4c, 0xef, 0xed,
+	0x1a, 0xcb, 0x2d, 0x57, 0xe1, 0x4f, 0x0f, 0xee, 0x5f, 0xc8, 0x5c, 0x19, 0x8b, 0x29, 0x7e, 0x2b,
+	0xd1, 0x3a, 0x92, 0xc0, 0xb8, 0x40, 0x6b, 0x29, 0xc7, 0x8a, 0x66, 0xba, 0x38, 0x8d, 0xfe, 0xad,
+	0x3c, 0x4a, 0x8c, 0xb2, 0x36, 0xd9, 0x0d, 0xde, 0xd7, 0x80, 0xb4, 0x45, 0x92, 0x57, 0x30, 0xa2,
+	0x92, 0x6d, 0x94, 0xa9, 0xf4, 0x4c, 0x17, 0xcf, 0x06, 0x6f, 0x28, 0x69, 0x51, 0xda, 0xd2, 0xae,
+	0x1c, 0x75, 0x98, 0x36, 0xa8, 0xf0, 0x13, 0x1c, 0x75, 0xb2, 0xac, 0xde, 0xed, 0x90, 0xa4, 0xef,
+	0xb2, 0x56, 0xf6, 0x74, 0xe8, 0xea, 0xad, 0xe0, 0x7a, 0x61, 0x84, 0x16, 0x8e, 0x97, 0x9c, 0x1b,
+	0xe4, 0xd4, 0x75, 0x86, 0x7d, 0x18, 0x6b, 0xca, 0xbe, 0xb6, 0x86, 0x0f, 0xd2, 0xb6, 0x24, 0x17,
+	0x00, 0x1d, 0xd4, 0xfa, 0x7b, 0xc1, 0xfe, 0xdd, 0x39, 0x7b, 0xc0, 0xf0, 0x05, 0x3c, 0xe8, 0x91,
+	0x36, 0x76, 0x02, 0x98, 0x62, 0xed, 0xb0, 0x40, 0xe9, 0x1a, 0xe6, 0x7e, 0x6b, 0xf1, 0xcb, 0x83,
+	0xe3, 0xa4, 0x7d, 0x44, 0x2b, 0x34, 0x37, 0x82, 0x21, 0xb9, 0x82, 0x71, 0x13, 0x0c, 0x19, 0xcc,
+	0xf4, 0xf6, 0x07, 0x9d, 0x9d, 0xfc, 0x77, 0xaf, 0x91, 0xf4, 0x05, 0x26, 0x9d, 0x4e, 0x32, 0x1f,
+	0x42, 0xfd, 0x9d, 0xdf, 0xec, 0xf4, 0x0e, 0x9b, 0x35, 0xc3, 0xf9, 0x0f, 0x0f, 0x4e, 0x98, 0x2a,
+	0x22, 0xba, 0x15, 0x9a, 0x7e, 0x1f, 0xc0, 0xd9, 0x88, 0x1b, 0xcd, 0x2e, 0xbd, 0xab, 0xcf, 0x5c,
+	0xb8, 0x4d, 0x99, 0x45, 0x4c, 0x15, 0xf1, 0x52, 0xba, 0xea, 0xa5, 0x7d, 0xd4, 0x28, 0xdf, 0xd1,
+	0xac, 0xab, 0xcf, 0x2b, 0xe4, 0x65, 0x05, 0x5c, 0xbd, 0x7e, 0x1b, 0x37, 0x27, 0xd0, 0xc5, 0x6b,
+	0x9a, 0x19, 0xc1, 0x62, 0xb5, 0x5e, 0x57, 0x1c, 0x67, 0xf5, 0xe4, 0x8c, 0xab, 0x58, 0x67, 0x2f,
+	0x75, 0x96, 0x8d, 0xaa, 0xdf, 0xec, 0xf9, 0xef, 0x01, 0x00, 0x6e, 0x9d, 0x7e, 0x52, 0xa9, 0x03,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// CommitteeServiceClient is the client API for CommitteeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type CommitteeServiceClient interface {
+	Endorse(ctx context.Context, in *EndorseRequest, opts ...grpc.CallOption) (*EndorseResponse, error)
+	Aggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*AggregateResponse, error)
+}
+
+type committeeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommitteeServiceClient(cc grpc.ClientConnInterface) CommitteeServiceClient {
+	return &committeeServiceClient{cc}
+}
+
+func (c *committeeServiceClient) Endorse(ctx context.Context, in *EndorseRequest, opts ...grpc.CallOption) (*EndorseResponse, error) {
+	out := new(EndorseResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.CommitteeService/Endorse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *committeeServiceClient) Aggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*AggregateResponse, error) {
+	out := new(AggregateResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.CommitteeService/Aggregate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CommitteeServiceServer is the server API for CommitteeService service.
+type CommitteeServiceServer interface {
+	Endorse(context.Context, *EndorseRequest) (*EndorseResponse, error)
+	Aggregate(context.Context, *AggregateRequest) (*AggregateResponse, error)
+}
+
+// UnimplementedCommitteeServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedCommitteeServiceServer struct {
+}
+
+func (*UnimplementedCommitteeServiceServer) Endorse(ctx context.Context, req *EndorseRequest) (*EndorseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Endorse not implemented")
+}
+func (*UnimplementedCommitteeServiceServer) Aggregate(ctx context.Context, req *AggregateRequest) (*AggregateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Aggregate not implemented")
+}
+
+func RegisterCommitteeServiceServer(s *grpc.Server, srv CommitteeServiceServer) {
+	s.RegisterService(&_CommitteeService_serviceDesc, srv)
+}
+
+func _CommitteeService_Endorse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndorseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommitteeServiceServer).Endorse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.CommitteeService/Endorse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommitteeServiceServer).Endorse(ctx, req.(*EndorseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommitteeService_Aggregate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AggregateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommitteeServiceServer).Aggregate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.CommitteeService/Aggregate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommitteeServiceServer).Aggregate(ctx, req.(*AggregateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _CommitteeService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "antchain.bridge.plugin.CommitteeService",
+	HandlerType: (*CommitteeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Endorse",
+			Handler:    _CommitteeService_Endorse_Handler,
+		},
+		{
+			MethodName: "Aggregate",
+			Handler:    _CommitteeService_Aggregate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "committee.proto",
+}
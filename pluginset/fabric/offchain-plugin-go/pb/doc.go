@@ -0,0 +1,5 @@
+// Package pb 为proto目录下BBC插件协议生成的代码
+// 使用protoc-gen-go v1.3.4生成，与fabric-sdk-go依赖的protobuf、grpc版本一致
+package pb
+
+//go:generate protoc -I ../proto --go_out=plugins=grpc,paths=source_relative:. bbc.proto pluginserver.proto hcdvs.proto committee.proto
@@ -0,0 +1,640 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: hcdvs.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ConsensusState struct {
+	// 链类型，如fabric
+	Product        string   `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	Height         uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Hash           []byte   `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	ConsensusNodes []string `protobuf:"bytes,4,rep,name=consensus_nodes,json=consensusNodes,proto3" json:"consensus_nodes,omitempty"`
+	// 链相关的状态数据
+	StateData            []byte   `protobuf:"bytes,5,opt,name=state_data,json=stateData,proto3" json:"state_data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConsensusState) Reset()         { *m = ConsensusState{} }
+func (m *ConsensusState) String() string { return proto.CompactTextString(m) }
+func (*ConsensusState) ProtoMessage()    {}
+func (*ConsensusState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{0}
+}
+
+func (m *ConsensusState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConsensusState.Unmarshal(m, b)
+}
+func (m *ConsensusState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConsensusState.Marshal(b, m, deterministic)
+}
+func (m *ConsensusState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConsensusState.Merge(m, src)
+}
+func (m *ConsensusState) XXX_Size() int {
+	return xxx_messageInfo_ConsensusState.Size(m)
+}
+func (m *ConsensusState) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConsensusState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConsensusState proto.InternalMessageInfo
+
+func (m *ConsensusState) GetProduct() string {
+	if m != nil {
+		return m.Product
+	}
+	return ""
+}
+
+func (m *ConsensusState) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ConsensusState) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *ConsensusState) GetConsensusNodes() []string {
+	if m != nil {
+		return m.ConsensusNodes
+	}
+	return nil
+}
+
+func (m *ConsensusState) GetStateData() []byte {
+	if m != nil {
+		return m.StateData
+	}
+	return nil
+}
+
+type VerifyResult struct {
+	Verified bool `protobuf:"varint,1,opt,name=verified,proto3" json:"verified,omitempty"`
+	// 验证不通过的原因
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=error_msg,json=errorMsg,proto3" json:"error_msg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyResult) Reset()         { *m = VerifyResult{} }
+func (m *VerifyResult) String() string { return proto.CompactTextString(m) }
+func (*VerifyResult) ProtoMessage()    {}
+func (*VerifyResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{1}
+}
+
+func (m *VerifyResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyResult.Unmarshal(m, b)
+}
+func (m *VerifyResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyResult.Marshal(b, m, deterministic)
+}
+func (m *VerifyResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyResult.Merge(m, src)
+}
+func (m *VerifyResult) XXX_Size() int {
+	return xxx_messageInfo_VerifyResult.Size(m)
+}
+func (m *VerifyResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyResult proto.InternalMessageInfo
+
+func (m *VerifyResult) GetVerified() bool {
+	if m != nil {
+		return m.Verified
+	}
+	return false
+}
+
+func (m *VerifyResult) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+type VerifyAnchorConsensusStateRequest struct {
+	Anchor               *ConsensusState `protobuf:"bytes,1,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *VerifyAnchorConsensusStateRequest) Reset()         { *m = VerifyAnchorConsensusStateRequest{} }
+func (m *VerifyAnchorConsensusStateRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyAnchorConsensusStateRequest) ProtoMessage()    {}
+func (*VerifyAnchorConsensusStateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{2}
+}
+
+func (m *VerifyAnchorConsensusStateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyAnchorConsensusStateRequest.Unmarshal(m, b)
+}
+func (m *VerifyAnchorConsensusStateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyAnchorConsensusStateRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyAnchorConsensusStateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyAnchorConsensusStateRequest.Merge(m, src)
+}
+func (m *VerifyAnchorConsensusStateRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyAnchorConsensusStateRequest.Size(m)
+}
+func (m *VerifyAnchorConsensusStateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyAnchorConsensusStateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyAnchorConsensusStateRequest proto.InternalMessageInfo
+
+func (m *VerifyAnchorConsensusStateRequest) GetAnchor() *ConsensusState {
+	if m != nil {
+		return m.Anchor
+	}
+	return nil
+}
+
+type VerifyConsensusStateRequest struct {
+	State                *ConsensusState `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Anchor               *ConsensusState `protobuf:"bytes,2,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *VerifyConsensusStateRequest) Reset()         { *m = VerifyConsensusStateRequest{} }
+func (m *VerifyConsensusStateRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyConsensusStateRequest) ProtoMessage()    {}
+func (*VerifyConsensusStateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{3}
+}
+
+func (m *VerifyConsensusStateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyConsensusStateRequest.Unmarshal(m, b)
+}
+func (m *VerifyConsensusStateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyConsensusStateRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyConsensusStateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyConsensusStateRequest.Merge(m, src)
+}
+func (m *VerifyConsensusStateRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyConsensusStateRequest.Size(m)
+}
+func (m *VerifyConsensusStateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyConsensusStateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyConsensusStateRequest proto.InternalMessageInfo
+
+func (m *VerifyConsensusStateRequest) GetState() *ConsensusState {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+func (m *VerifyConsensusStateRequest) GetAnchor() *ConsensusState {
+	if m != nil {
+		return m.Anchor
+	}
+	return nil
+}
+
+type VerifyCrossChainMessageRequest struct {
+	Message *CrossChainMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// 消息所在区块适用的共识状态
+	Anchor               *ConsensusState `protobuf:"bytes,2,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *VerifyCrossChainMessageRequest) Reset()         { *m = VerifyCrossChainMessageRequest{} }
+func (m *VerifyCrossChainMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyCrossChainMessageRequest) ProtoMessage()    {}
+func (*VerifyCrossChainMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{4}
+}
+
+func (m *VerifyCrossChainMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyCrossChainMessageRequest.Unmarshal(m, b)
+}
+func (m *VerifyCrossChainMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyCrossChainMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyCrossChainMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyCrossChainMessageRequest.Merge(m, src)
+}
+func (m *VerifyCrossChainMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyCrossChainMessageRequest.Size(m)
+}
+func (m *VerifyCrossChainMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyCrossChainMessageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyCrossChainMessageRequest proto.InternalMessageInfo
+
+func (m *VerifyCrossChainMessageRequest) GetMessage() *CrossChainMessage {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *VerifyCrossChainMessageRequest) GetAnchor() *ConsensusState {
+	if m != nil {
+		return m.Anchor
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	Result               *VerifyResult `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *VerifyResponse) Reset()         { *m = VerifyResponse{} }
+func (m *VerifyResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyResponse) ProtoMessage()    {}
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{5}
+}
+
+func (m *VerifyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyResponse.Unmarshal(m, b)
+}
+func (m *VerifyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyResponse.Marshal(b, m, deterministic)
+}
+func (m *VerifyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyResponse.Merge(m, src)
+}
+func (m *VerifyResponse) XXX_Size() int {
+	return xxx_messageInfo_VerifyResponse.Size(m)
+}
+func (m *VerifyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyResponse proto.InternalMessageInfo
+
+func (m *VerifyResponse) GetResult() *VerifyResult {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+type ParseMessageRequest struct {
+	LedgerData           []byte   `protobuf:"bytes,1,opt,name=ledger_data,json=ledgerData,proto3" json:"ledger_data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ParseMessageRequest) Reset()         { *m = ParseMessageRequest{} }
+func (m *ParseMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*ParseMessageRequest) ProtoMessage()    {}
+func (*ParseMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{6}
+}
+
+func (m *ParseMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ParseMessageRequest.Unmarshal(m, b)
+}
+func (m *ParseMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ParseMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *ParseMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ParseMessageRequest.Merge(m, src)
+}
+func (m *ParseMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_ParseMessageRequest.Size(m)
+}
+func (m *ParseMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ParseMessageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ParseMessageRequest proto.InternalMessageInfo
+
+func (m *ParseMessageRequest) GetLedgerData() []byte {
+	if m != nil {
+		return m.LedgerData
+	}
+	return nil
+}
+
+type ParseMessageResponse struct {
+	Message              []byte   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ParseMessageResponse) Reset()         { *m = ParseMessageResponse{} }
+func (m *ParseMessageResponse) String() string { return proto.CompactTextString(m) }
+func (*ParseMessageResponse) ProtoMessage()    {}
+func (*ParseMessageResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8b660d03e02263, []int{7}
+}
+
+func (m *ParseMessageResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ParseMessageResponse.Unmarshal(m, b)
+}
+func (m *ParseMessageResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ParseMessageResponse.Marshal(b, m, deterministic)
+}
+func (m *ParseMessageResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ParseMessageResponse.Merge(m, src)
+}
+func (m *ParseMessageResponse) XXX_Size() int {
+	return xxx_messageInfo_ParseMessageResponse.Size(m)
+}
+func (m *ParseMessageResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ParseMessageResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ParseMessageResponse proto.InternalMessageInfo
+
+func (m *ParseMessageResponse) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ConsensusState)(nil), "antchain.bridge.plugin.ConsensusState")
+	proto.RegisterType((*VerifyResult)(nil), "antchain.bridge.plugin.VerifyResult")
+	proto.RegisterType((*VerifyAnchorConsensusStateRequest)(nil), "antchain.bridge.plugin.VerifyAnchorConsensusStateRequest")
+	proto.RegisterType((*VerifyConsensusStateRequest)(nil), "antchain.bridge.plugin.VerifyConsensusStateRequest")
+	proto.RegisterType((*VerifyCrossChainMessageRequest)(nil), "antchain.bridge.plugin.VerifyCrossChainMessageRequest")
+	proto.RegisterType((*VerifyResponse)(nil), "antchain.bridge.plugin.VerifyResponse")
+	proto.RegisterType((*ParseMessageRequest)(nil), "antchain.bridge.plugin.ParseMessageRequest")
+	proto.RegisterType((*ParseMessageResponse)(nil), "antchain.bridge.plugin.ParseMessageResponse")
+}
+
+func init() {
+	proto.RegisterFile("hcdvs.proto", fileDescriptor_ae8b660d03e02263)
+}
+
+var fileDescriptor_ae8b660d03e02263 = []byte{
+	// 570 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x6f, 0x6b, 0xd3, 0x40,
+	0x18, 0x27, 0x6b, 0xd7, 0x35, 0x4f, 0x4b, 0x85, 0x73, 0xcc, 0x90, 0xa1, 0xd6, 0x22, 0x5b, 0x45,
+	0x97, 0xca, 0x06, 0x03, 0x71, 0x08, 0x5b, 0x8b, 0x0a, 0x6e, 0xb3, 0xa4, 0xb0, 0x17, 0x22, 0x94,
+	0xcb, 0xe5, 0x9a, 0x04, 0xda, 0x5c, 0xbc, 0xbb, 0x54, 0xfa, 0xca, 0xd7, 0x7e, 0x06, 0xc1, 0x77,
+	0x7e, 0x47, 0x5f, 0x4a, 0x2e, 0x69, 0x68, 0x67, 0xd3, 0x4d, 0xf7, 0x2e, 0xcf, 0x73, 0xf7, 0xfb,
+	0xf3, 0xdc, 0xf3, 0x23, 0x50, 0xf3, 0x89, 0x3b, 0x15, 0x56, 0xc4, 0x99, 0x64, 0x68, 0x07, 0x87,
+	0x92, 0xf8, 0x38, 0x08, 0x2d, 0x87, 0x07, 0xae, 0x47, 0xad, 0x68, 0x1c, 0x7b, 0x41, 0x68, 0xea,
+	0x8e, 0x43, 0xd2, 0x2b, 0xad, 0x9f, 0x1a, 0x34, 0xba, 0x2c, 0x14, 0x34, 0x14, 0xb1, 0x18, 0x48,
+	0x2c, 0x29, 0x32, 0x60, 0x2b, 0xe2, 0xcc, 0x8d, 0x89, 0x34, 0xb4, 0xa6, 0xd6, 0xd6, 0xed, 0x79,
+	0x89, 0x76, 0xa0, 0xe2, 0xd3, 0xc0, 0xf3, 0xa5, 0xb1, 0xd1, 0xd4, 0xda, 0x65, 0x3b, 0xab, 0x10,
+	0x82, 0xb2, 0x8f, 0x85, 0x6f, 0x94, 0x9a, 0x5a, 0xbb, 0x6e, 0xab, 0x6f, 0xb4, 0x0f, 0xf7, 0xc8,
+	0x9c, 0x77, 0x18, 0x32, 0x97, 0x0a, 0xa3, 0xdc, 0x2c, 0xb5, 0x75, 0xbb, 0x91, 0xb7, 0x2f, 0x93,
+	0x2e, 0x7a, 0x08, 0x20, 0x12, 0xdd, 0xa1, 0x8b, 0x25, 0x36, 0x36, 0x15, 0x85, 0xae, 0x3a, 0x3d,
+	0x2c, 0x71, 0xeb, 0x1d, 0xd4, 0xaf, 0x28, 0x0f, 0x46, 0x33, 0x9b, 0x8a, 0x78, 0x2c, 0x91, 0x09,
+	0xd5, 0x69, 0x52, 0x07, 0xd4, 0x55, 0xf6, 0xaa, 0x76, 0x5e, 0xa3, 0x5d, 0xd0, 0x29, 0xe7, 0x8c,
+	0x0f, 0x27, 0xc2, 0x53, 0x16, 0x75, 0xbb, 0xaa, 0x1a, 0x17, 0xc2, 0x6b, 0x11, 0x78, 0x92, 0x12,
+	0x9d, 0x86, 0xc4, 0x67, 0x7c, 0x79, 0x68, 0x9b, 0x7e, 0x89, 0xa9, 0x90, 0xe8, 0x0d, 0x54, 0xb0,
+	0x3a, 0x56, 0xdc, 0xb5, 0xc3, 0x3d, 0x6b, 0xf5, 0x13, 0x5a, 0xd7, 0xe0, 0x19, 0xaa, 0xf5, 0x43,
+	0x83, 0xdd, 0x54, 0x65, 0x35, 0xff, 0x09, 0x6c, 0xaa, 0xd1, 0xfe, 0x91, 0x3e, 0x05, 0x2d, 0xb8,
+	0xdb, 0xf8, 0x2f, 0x77, 0xbf, 0x34, 0x78, 0x94, 0xb9, 0xe3, 0x4c, 0x88, 0x6e, 0x82, 0xbc, 0xa0,
+	0x42, 0x60, 0x2f, 0x37, 0xd8, 0x85, 0xad, 0x49, 0xda, 0xc9, 0x2c, 0x3e, 0x2b, 0xd4, 0xf8, 0x8b,
+	0x62, 0x8e, 0xbc, 0xb3, 0xcf, 0x4b, 0x68, 0xe4, 0x3b, 0x8f, 0x92, 0x2b, 0xe8, 0x04, 0x2a, 0x5c,
+	0xed, 0x3f, 0x73, 0xf5, 0xb4, 0x88, 0x71, 0x31, 0x2b, 0x76, 0x86, 0x69, 0x1d, 0xc3, 0xfd, 0x3e,
+	0xe6, 0x82, 0x5e, 0x9b, 0xf5, 0x31, 0xd4, 0xc6, 0xd4, 0xf5, 0x28, 0x4f, 0xa3, 0xa7, 0xa9, 0xe8,
+	0x41, 0xda, 0x52, 0xd9, 0x7b, 0x09, 0xdb, 0xcb, 0xb8, 0xcc, 0x8d, 0xb1, 0xfc, 0x48, 0xf5, 0x7c,
+	0xf2, 0xc3, 0xdf, 0x25, 0xa8, 0xbf, 0xef, 0xf6, 0xae, 0x06, 0x03, 0xca, 0xa7, 0x01, 0xa1, 0xe8,
+	0x1b, 0x98, 0xc5, 0xa9, 0x43, 0xaf, 0xd6, 0x8f, 0xb1, 0x26, 0xa9, 0xe6, 0xde, 0x8d, 0x2f, 0x90,
+	0x7a, 0x15, 0xb0, 0xbd, 0x2a, 0x90, 0xe8, 0x68, 0x3d, 0xfe, 0x6e, 0xa2, 0x33, 0x78, 0x50, 0x90,
+	0x33, 0x74, 0x7c, 0x83, 0x6e, 0x41, 0x30, 0x6f, 0x2d, 0xfd, 0x15, 0xcc, 0xc5, 0x9d, 0xbd, 0xe5,
+	0x6c, 0x72, 0x9e, 0x6f, 0x14, 0x3d, 0x2f, 0x62, 0x59, 0x91, 0x0f, 0xf3, 0xc5, 0xed, 0x2e, 0xa7,
+	0xc2, 0x67, 0xdf, 0x35, 0xd8, 0x27, 0x6c, 0x62, 0xe1, 0x71, 0x10, 0xe1, 0x59, 0x01, 0x54, 0x58,
+	0x1e, 0x8f, 0x48, 0x5f, 0xfb, 0xf4, 0xd9, 0x0b, 0xa4, 0x1f, 0x3b, 0x16, 0x61, 0x93, 0xce, 0x69,
+	0x28, 0xd5, 0xc4, 0x1f, 0x23, 0x1a, 0x9e, 0x63, 0x27, 0xaf, 0xcf, 0x14, 0xb2, 0xaf, 0x80, 0x83,
+	0xde, 0x87, 0x4e, 0x46, 0x41, 0x65, 0x67, 0x84, 0x1d, 0x1e, 0x90, 0x0e, 0x1b, 0x8d, 0x94, 0xc6,
+	0x41, 0x7a, 0x72, 0xe0, 0xb1, 0x4e, 0xe4, 0xbc, 0x8e, 0x1c, 0xa7, 0xa2, 0x7e, 0xee, 0x47, 0x7f,
+	0x06, 0x00, 0x07, 0x88, 0x6b, 0xfa, 0x0e, 0x06, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// HCDVSServiceClient is the client API for HCDVSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HCDVSServiceClient interface {
+	VerifyAnchorConsensusState(ctx context.Context, in *VerifyAnchorConsensusStateRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	VerifyConsensusState(ctx context.Context, in *VerifyConsensusStateRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	VerifyCrossChainMessage(ctx context.Context, in *VerifyCrossChainMessageRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	ParseMessageFromLedgerData(ctx context.Context, in *ParseMessageRequest, opts ...grpc.CallOption) (*ParseMessageResponse, error)
+}
+
+type hCDVSServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHCDVSServiceClient(cc grpc.ClientConnInterface) HCDVSServiceClient {
+	return &hCDVSServiceClient{cc}
+}
+
+func (c *hCDVSServiceClient) VerifyAnchorConsensusState(ctx context.Context, in *VerifyAnchorConsensusStateRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.HCDVSService/VerifyAnchorConsensusState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hCDVSServiceClient) VerifyConsensusState(ctx context.Context, in *VerifyConsensusStateRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.HCDVSService/VerifyConsensusState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hCDVSServiceClient) VerifyCrossChainMessage(ctx context.Context, in *VerifyCrossChainMessageRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.HCDVSService/VerifyCrossChainMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hCDVSServiceClient) ParseMessageFromLedgerData(ctx context.Context, in *ParseMessageRequest, opts ...grpc.CallOption) (*ParseMessageResponse, error) {
+	out := new(ParseMessageResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.HCDVSService/ParseMessageFromLedgerData", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HCDVSServiceServer is the server API for HCDVSService service.
+type HCDVSServiceServer interface {
+	VerifyAnchorConsensusState(context.Context, *VerifyAnchorConsensusStateRequest) (*VerifyResponse, error)
+	VerifyConsensusState(context.Context, *VerifyConsensusStateRequest) (*VerifyResponse, error)
+	VerifyCrossChainMessage(context.Context, *VerifyCrossChainMessageRequest) (*VerifyResponse, error)
+	ParseMessageFromLedgerData(context.Context, *ParseMessageRequest) (*ParseMessageResponse, error)
+}
+
+// UnimplementedHCDVSServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedHCDVSServiceServer struct {
+}
+
+func (*UnimplementedHCDVSServiceServer) VerifyAnchorConsensusState(ctx context.Context, req *VerifyAnchorConsensusStateRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyAnchorConsensusState not implemented")
+}
+func (*UnimplementedHCDVSServiceServer) VerifyConsensusState(ctx context.Context, req *VerifyConsensusStateRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyConsensusState not implemented")
+}
+func (*UnimplementedHCDVSServiceServer) VerifyCrossChainMessage(ctx context.Context, req *VerifyCrossChainMessageRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyCrossChainMessage not implemented")
+}
+func (*UnimplementedHCDVSServiceServer) ParseMessageFromLedgerData(ctx context.Context, req *ParseMessageRequest) (*ParseMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParseMessageFromLedgerData not implemented")
+}
+
+func RegisterHCDVSServiceServer(s *grpc.Server, srv HCDVSServiceServer) {
+	s.RegisterService(&_HCDVSService_serviceDesc, srv)
+}
+
+func _HCDVSService_VerifyAnchorConsensusState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyAnchorConsensusStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HCDVSServiceServer).VerifyAnchorConsensusState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.HCDVSService/VerifyAnchorConsensusState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HCDVSServiceServer).VerifyAnchorConsensusState(ctx, req.(*VerifyAnchorConsensusStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HCDVSService_VerifyConsensusState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyConsensusStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HCDVSServiceServer).VerifyConsensusState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.HCDVSService/VerifyConsensusState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HCDVSServiceServer).VerifyConsensusState(ctx, req.(*VerifyConsensusStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HCDVSService_VerifyCrossChainMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyCrossChainMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HCDVSServiceServer).VerifyCrossChainMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.HCDVSService/VerifyCrossChainMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HCDVSServiceServer).VerifyCrossChainMessage(ctx, req.(*VerifyCrossChainMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HCDVSService_ParseMessageFromLedgerData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HCDVSServiceServer).ParseMessageFromLedgerData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.HCDVSService/ParseMessageFromLedgerData",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HCDVSServiceServer).ParseMessageFromLedgerData(ctx, req.(*ParseMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _HCDVSService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "antchain.bridge.plugin.HCDVSService",
+	HandlerType: (*HCDVSServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "VerifyAnchorConsensusState",
+			Handler:    _HCDVSService_VerifyAnchorConsensusState_Handler,
+		},
+		{
+			MethodName: "VerifyConsensusState",
+			Handler:    _HCDVSService_VerifyConsensusState_Handler,
+		},
+		{
+			MethodName: "VerifyCrossChainMessage",
+			Handler:    _HCDVSService_VerifyCrossChainMessage_Handler,
+		},
+		{
+			MethodName: "ParseMessageFromLedgerData",
+			Handler:    _HCDVSService_ParseMessageFromLedgerData_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "hcdvs.proto",
+}
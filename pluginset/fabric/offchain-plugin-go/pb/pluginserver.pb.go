@@ -0,0 +1,833 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pluginserver.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type PluginInfo struct {
+	// 链类型，如fabric
+	Product string `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	// 只服务指定域名时填写，为空时服务该链类型的所有域名
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// 插件BBCService的地址，host:port
+	Address              string   `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Version              string   `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PluginInfo) Reset()         { *m = PluginInfo{} }
+func (m *PluginInfo) String() string { return proto.CompactTextString(m) }
+func (*PluginInfo) ProtoMessage()    {}
+func (*PluginInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{0}
+}
+
+func (m *PluginInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginInfo.Unmarshal(m, b)
+}
+func (m *PluginInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginInfo.Marshal(b, m, deterministic)
+}
+func (m *PluginInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginInfo.Merge(m, src)
+}
+func (m *PluginInfo) XXX_Size() int {
+	return xxx_messageInfo_PluginInfo.Size(m)
+}
+func (m *PluginInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginInfo proto.InternalMessageInfo
+
+func (m *PluginInfo) GetProduct() string {
+	if m != nil {
+		return m.Product
+	}
+	return ""
+}
+
+func (m *PluginInfo) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *PluginInfo) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PluginInfo) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type RegisterRequest struct {
+	Plugin               *PluginInfo `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *RegisterRequest) Reset()         { *m = RegisterRequest{} }
+func (m *RegisterRequest) String() string { return proto.CompactTextString(m) }
+func (*RegisterRequest) ProtoMessage()    {}
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{1}
+}
+
+func (m *RegisterRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisterRequest.Unmarshal(m, b)
+}
+func (m *RegisterRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisterRequest.Marshal(b, m, deterministic)
+}
+func (m *RegisterRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisterRequest.Merge(m, src)
+}
+func (m *RegisterRequest) XXX_Size() int {
+	return xxx_messageInfo_RegisterRequest.Size(m)
+}
+func (m *RegisterRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisterRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisterRequest proto.InternalMessageInfo
+
+func (m *RegisterRequest) GetPlugin() *PluginInfo {
+	if m != nil {
+		return m.Plugin
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	// 注册id，心跳和注销时使用
+	PluginId string `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
+	// 心跳有效期(秒)，插件应在有效期内再次心跳
+	Ttl                  int64    `protobuf:"varint,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegisterResponse) Reset()         { *m = RegisterResponse{} }
+func (m *RegisterResponse) String() string { return proto.CompactTextString(m) }
+func (*RegisterResponse) ProtoMessage()    {}
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{2}
+}
+
+func (m *RegisterResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisterResponse.Unmarshal(m, b)
+}
+func (m *RegisterResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisterResponse.Marshal(b, m, deterministic)
+}
+func (m *RegisterResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisterResponse.Merge(m, src)
+}
+func (m *RegisterResponse) XXX_Size() int {
+	return xxx_messageInfo_RegisterResponse.Size(m)
+}
+func (m *RegisterResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisterResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisterResponse proto.InternalMessageInfo
+
+func (m *RegisterResponse) GetPluginId() string {
+	if m != nil {
+		return m.PluginId
+	}
+	return ""
+}
+
+func (m *RegisterResponse) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type HeartbeatRequest struct {
+	PluginId             string   `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeartbeatRequest) Reset()         { *m = HeartbeatRequest{} }
+func (m *HeartbeatRequest) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatRequest) ProtoMessage()    {}
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{3}
+}
+
+func (m *HeartbeatRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatRequest.Unmarshal(m, b)
+}
+func (m *HeartbeatRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatRequest.Marshal(b, m, deterministic)
+}
+func (m *HeartbeatRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatRequest.Merge(m, src)
+}
+func (m *HeartbeatRequest) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatRequest.Size(m)
+}
+func (m *HeartbeatRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatRequest proto.InternalMessageInfo
+
+func (m *HeartbeatRequest) GetPluginId() string {
+	if m != nil {
+		return m.PluginId
+	}
+	return ""
+}
+
+type UnregisterRequest struct {
+	PluginId             string   `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnregisterRequest) Reset()         { *m = UnregisterRequest{} }
+func (m *UnregisterRequest) String() string { return proto.CompactTextString(m) }
+func (*UnregisterRequest) ProtoMessage()    {}
+func (*UnregisterRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{4}
+}
+
+func (m *UnregisterRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnregisterRequest.Unmarshal(m, b)
+}
+func (m *UnregisterRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnregisterRequest.Marshal(b, m, deterministic)
+}
+func (m *UnregisterRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnregisterRequest.Merge(m, src)
+}
+func (m *UnregisterRequest) XXX_Size() int {
+	return xxx_messageInfo_UnregisterRequest.Size(m)
+}
+func (m *UnregisterRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnregisterRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnregisterRequest proto.InternalMessageInfo
+
+func (m *UnregisterRequest) GetPluginId() string {
+	if m != nil {
+		return m.PluginId
+	}
+	return ""
+}
+
+type RegisteredPlugin struct {
+	PluginId string      `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
+	Plugin   *PluginInfo `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// 注册和最近心跳的时间，unix秒
+	RegisteredAt         int64    `protobuf:"varint,3,opt,name=registered_at,json=registeredAt,proto3" json:"registered_at,omitempty"`
+	LastHeartbeat        int64    `protobuf:"varint,4,opt,name=last_heartbeat,json=lastHeartbeat,proto3" json:"last_heartbeat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegisteredPlugin) Reset()         { *m = RegisteredPlugin{} }
+func (m *RegisteredPlugin) String() string { return proto.CompactTextString(m) }
+func (*RegisteredPlugin) ProtoMessage()    {}
+func (*RegisteredPlugin) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{5}
+}
+
+func (m *RegisteredPlugin) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisteredPlugin.Unmarshal(m, b)
+}
+func (m *RegisteredPlugin) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisteredPlugin.Marshal(b, m, deterministic)
+}
+func (m *RegisteredPlugin) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisteredPlugin.Merge(m, src)
+}
+func (m *RegisteredPlugin) XXX_Size() int {
+	return xxx_messageInfo_RegisteredPlugin.Size(m)
+}
+func (m *RegisteredPlugin) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisteredPlugin.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisteredPlugin proto.InternalMessageInfo
+
+func (m *RegisteredPlugin) GetPluginId() string {
+	if m != nil {
+		return m.PluginId
+	}
+	return ""
+}
+
+func (m *RegisteredPlugin) GetPlugin() *PluginInfo {
+	if m != nil {
+		return m.Plugin
+	}
+	return nil
+}
+
+func (m *RegisteredPlugin) GetRegisteredAt() int64 {
+	if m != nil {
+		return m.RegisteredAt
+	}
+	return 0
+}
+
+func (m *RegisteredPlugin) GetLastHeartbeat() int64 {
+	if m != nil {
+		return m.LastHeartbeat
+	}
+	return 0
+}
+
+type ListPluginsResponse struct {
+	Plugins              []*RegisteredPlugin `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ListPluginsResponse) Reset()         { *m = ListPluginsResponse{} }
+func (m *ListPluginsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPluginsResponse) ProtoMessage()    {}
+func (*ListPluginsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{6}
+}
+
+func (m *ListPluginsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPluginsResponse.Unmarshal(m, b)
+}
+func (m *ListPluginsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPluginsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPluginsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPluginsResponse.Merge(m, src)
+}
+func (m *ListPluginsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPluginsResponse.Size(m)
+}
+func (m *ListPluginsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPluginsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPluginsResponse proto.InternalMessageInfo
+
+func (m *ListPluginsResponse) GetPlugins() []*RegisteredPlugin {
+	if m != nil {
+		return m.Plugins
+	}
+	return nil
+}
+
+// 插件连接的链的状态
+type ChainStatus struct {
+	Product string `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	Domain  string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// 链客户端已启动，且最近一次查询链成功
+	Connected bool `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`
+	// 未连接的原因
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// 通道最新区块
+	ChainHeight uint64 `protobuf:"varint,5,opt,name=chain_height,json=chainHeight,proto3" json:"chain_height,omitempty"`
+	// 区块监听已处理的最高区块，未配置监听时为0
+	LastSyncedHeight uint64 `protobuf:"varint,6,opt,name=last_synced_height,json=lastSyncedHeight,proto3" json:"last_synced_height,omitempty"`
+	// 已收到还没有提交完成的跨链消息数
+	PendingMessages      uint64   `protobuf:"varint,7,opt,name=pending_messages,json=pendingMessages,proto3" json:"pending_messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChainStatus) Reset()         { *m = ChainStatus{} }
+func (m *ChainStatus) String() string { return proto.CompactTextString(m) }
+func (*ChainStatus) ProtoMessage()    {}
+func (*ChainStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{7}
+}
+
+func (m *ChainStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChainStatus.Unmarshal(m, b)
+}
+func (m *ChainStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChainStatus.Marshal(b, m, deterministic)
+}
+func (m *ChainStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChainStatus.Merge(m, src)
+}
+func (m *ChainStatus) XXX_Size() int {
+	return xxx_messageInfo_ChainStatus.Size(m)
+}
+func (m *ChainStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChainStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChainStatus proto.InternalMessageInfo
+
+func (m *ChainStatus) GetProduct() string {
+	if m != nil {
+		return m.Product
+	}
+	return ""
+}
+
+func (m *ChainStatus) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *ChainStatus) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
+func (m *ChainStatus) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *ChainStatus) GetChainHeight() uint64 {
+	if m != nil {
+		return m.ChainHeight
+	}
+	return 0
+}
+
+func (m *ChainStatus) GetLastSyncedHeight() uint64 {
+	if m != nil {
+		return m.LastSyncedHeight
+	}
+	return 0
+}
+
+func (m *ChainStatus) GetPendingMessages() uint64 {
+	if m != nil {
+		return m.PendingMessages
+	}
+	return 0
+}
+
+type PluginStatusResponse struct {
+	Plugin *PluginInfo `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// 已启动且所有链都已连接，与健康检查中BBCService的状态一致
+	Ready                bool           `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
+	Chains               []*ChainStatus `protobuf:"bytes,3,rep,name=chains,proto3" json:"chains,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *PluginStatusResponse) Reset()         { *m = PluginStatusResponse{} }
+func (m *PluginStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*PluginStatusResponse) ProtoMessage()    {}
+func (*PluginStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05bb9d8a0f0c7ae1, []int{8}
+}
+
+func (m *PluginStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginStatusResponse.Unmarshal(m, b)
+}
+func (m *PluginStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *PluginStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginStatusResponse.Merge(m, src)
+}
+func (m *PluginStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_PluginStatusResponse.Size(m)
+}
+func (m *PluginStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginStatusResponse proto.InternalMessageInfo
+
+func (m *PluginStatusResponse) GetPlugin() *PluginInfo {
+	if m != nil {
+		return m.Plugin
+	}
+	return nil
+}
+
+func (m *PluginStatusResponse) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *PluginStatusResponse) GetChains() []*ChainStatus {
+	if m != nil {
+		return m.Chains
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*PluginInfo)(nil), "antchain.bridge.plugin.PluginInfo")
+	proto.RegisterType((*RegisterRequest)(nil), "antchain.bridge.plugin.RegisterRequest")
+	proto.RegisterType((*RegisterResponse)(nil), "antchain.bridge.plugin.RegisterResponse")
+	proto.RegisterType((*HeartbeatRequest)(nil), "antchain.bridge.plugin.HeartbeatRequest")
+	proto.RegisterType((*UnregisterRequest)(nil), "antchain.bridge.plugin.UnregisterRequest")
+	proto.RegisterType((*RegisteredPlugin)(nil), "antchain.bridge.plugin.RegisteredPlugin")
+	proto.RegisterType((*ListPluginsResponse)(nil), "antchain.bridge.plugin.ListPluginsResponse")
+	proto.RegisterType((*ChainStatus)(nil), "antchain.bridge.plugin.ChainStatus")
+	proto.RegisterType((*PluginStatusResponse)(nil), "antchain.bridge.plugin.PluginStatusResponse")
+}
+
+func init() {
+	proto.RegisterFile("pluginserver.proto", fileDescriptor_05bb9d8a0f0c7ae1)
+}
+
+var fileDescriptor_05bb9d8a0f0c7ae1 = []byte{
+	// 658 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xdd, 0x4e, 0x13, 0x41,
+	0x14, 0xce, 0x52, 0x28, 0xed, 0x29, 0x48, 0x1d, 0x09, 0xd9, 0x54, 0x4d, 0x70, 0x89, 0xa1, 0x44,
+	0xd8, 0x9a, 0x7a, 0x27, 0x57, 0xa0, 0x26, 0x10, 0x21, 0x92, 0x45, 0x4c, 0x30, 0x9a, 0x66, 0x76,
+	0x77, 0xba, 0x9d, 0xa4, 0x9d, 0x59, 0x67, 0xa6, 0x24, 0x7d, 0x04, 0x1f, 0xc4, 0x67, 0xf0, 0xb1,
+	0xbc, 0xf3, 0xda, 0xec, 0xcc, 0x74, 0x17, 0x2b, 0xdb, 0x46, 0xbd, 0xdb, 0x73, 0xce, 0xf7, 0xcd,
+	0x39, 0xdf, 0xf9, 0x69, 0x01, 0xa5, 0xc3, 0x71, 0x42, 0x99, 0x24, 0xe2, 0x86, 0x08, 0x3f, 0x15,
+	0x5c, 0x71, 0xb4, 0x85, 0x99, 0x8a, 0x06, 0x98, 0x32, 0x3f, 0x14, 0x34, 0x4e, 0x88, 0x6f, 0x30,
+	0xad, 0x7a, 0x18, 0x46, 0x06, 0xe2, 0x09, 0x80, 0x0b, 0xed, 0x3c, 0x65, 0x7d, 0x8e, 0x5c, 0x58,
+	0x4d, 0x05, 0x8f, 0xc7, 0x91, 0x72, 0x9d, 0x6d, 0xa7, 0x5d, 0x0f, 0xa6, 0x26, 0xda, 0x82, 0x6a,
+	0xcc, 0x47, 0x98, 0x32, 0x77, 0x49, 0x07, 0xac, 0x95, 0x31, 0x70, 0x1c, 0x0b, 0x22, 0xa5, 0x5b,
+	0x31, 0x0c, 0x6b, 0x66, 0x91, 0x1b, 0x22, 0x24, 0xe5, 0xcc, 0x5d, 0x36, 0x11, 0x6b, 0x7a, 0xe7,
+	0xb0, 0x11, 0x90, 0x84, 0x4a, 0x45, 0x44, 0x40, 0xbe, 0x8c, 0x89, 0x54, 0xe8, 0x25, 0x54, 0x4d,
+	0x6d, 0x3a, 0x6f, 0xa3, 0xeb, 0xf9, 0x77, 0x97, 0xee, 0x17, 0xc5, 0x06, 0x96, 0xe1, 0x1d, 0x41,
+	0xb3, 0x78, 0x4e, 0xa6, 0x9c, 0x49, 0x82, 0x1e, 0x42, 0xdd, 0x44, 0x7b, 0x34, 0xb6, 0x52, 0x6a,
+	0xc6, 0x71, 0x1a, 0xa3, 0x26, 0x54, 0x94, 0x1a, 0x6a, 0x21, 0x95, 0x20, 0xfb, 0xf4, 0x3a, 0xd0,
+	0x3c, 0x21, 0x58, 0xa8, 0x90, 0x60, 0x35, 0x2d, 0x69, 0xde, 0x13, 0xde, 0x73, 0xb8, 0x7f, 0xc5,
+	0xc4, 0x8c, 0x88, 0xb9, 0x8c, 0xef, 0x4e, 0x51, 0x26, 0x89, 0x8d, 0x8c, 0xf9, 0x65, 0x16, 0x3d,
+	0x59, 0xfa, 0xdb, 0x9e, 0xa0, 0x1d, 0x58, 0x17, 0x79, 0xb2, 0x1e, 0x56, 0x7a, 0x38, 0x95, 0x60,
+	0xad, 0x70, 0x1e, 0x29, 0xf4, 0x14, 0xee, 0x0d, 0xb1, 0x54, 0xbd, 0xc1, 0x54, 0xba, 0x1e, 0x54,
+	0x25, 0x58, 0xcf, 0xbc, 0x79, 0x3f, 0xbc, 0x6b, 0x78, 0x70, 0x46, 0xa5, 0x32, 0x59, 0x64, 0xde,
+	0xe2, 0x63, 0x58, 0xb5, 0x2b, 0xe7, 0x3a, 0xdb, 0x95, 0x76, 0xa3, 0xdb, 0x2e, 0xab, 0x6f, 0x56,
+	0x76, 0x30, 0x25, 0x7a, 0x3f, 0x1c, 0x68, 0xbc, 0xca, 0x18, 0x97, 0x0a, 0xab, 0xb1, 0xfc, 0x87,
+	0xfd, 0x7b, 0x04, 0xf5, 0x88, 0x33, 0x46, 0x22, 0x45, 0x62, 0x2d, 0xb2, 0x16, 0x14, 0x0e, 0xb4,
+	0x09, 0x2b, 0x44, 0x08, 0x2e, 0xec, 0x06, 0x1a, 0x03, 0x3d, 0x81, 0x35, 0x5d, 0x66, 0x6f, 0x40,
+	0x68, 0x32, 0x50, 0xee, 0xca, 0xb6, 0xd3, 0x5e, 0x0e, 0x1a, 0xda, 0x77, 0xa2, 0x5d, 0x68, 0x1f,
+	0x90, 0x6e, 0x8d, 0x9c, 0xb0, 0x88, 0xc4, 0x53, 0x60, 0x55, 0x03, 0x9b, 0x59, 0xe4, 0x52, 0x07,
+	0x2c, 0x7a, 0x0f, 0x9a, 0x29, 0x61, 0x31, 0x65, 0x49, 0x6f, 0x44, 0xa4, 0xc4, 0x09, 0x91, 0xee,
+	0xaa, 0xc6, 0x6e, 0x58, 0xff, 0xb9, 0x75, 0x7b, 0xdf, 0x1c, 0xd8, 0x34, 0x5d, 0x30, 0x92, 0xf3,
+	0x76, 0xfe, 0xc7, 0x05, 0x64, 0x32, 0x05, 0xc1, 0xf1, 0x44, 0xf7, 0xa6, 0x16, 0x18, 0x03, 0x1d,
+	0x42, 0x55, 0xf3, 0xb3, 0xcb, 0xcc, 0xe6, 0xb3, 0x53, 0xf6, 0xe2, 0xad, 0x09, 0x04, 0x96, 0xd2,
+	0xfd, 0xb9, 0x04, 0x6b, 0xb6, 0x4e, 0xfd, 0x8b, 0x82, 0x3e, 0x43, 0x6d, 0x3a, 0x47, 0xb4, 0xbb,
+	0x68, 0xd2, 0xf6, 0x22, 0x5a, 0xed, 0xc5, 0x40, 0x2b, 0xff, 0x3d, 0xd4, 0xf3, 0x8d, 0x43, 0xa5,
+	0xb4, 0xd9, 0x23, 0x6d, 0x3d, 0x2e, 0x43, 0xbe, 0x19, 0xa5, 0x6a, 0x82, 0x3e, 0x00, 0x14, 0x67,
+	0x8a, 0xf6, 0xca, 0xc0, 0x7f, 0x9c, 0xf2, 0xa2, 0x77, 0xaf, 0xa1, 0x71, 0xeb, 0x24, 0xd0, 0x7c,
+	0x74, 0xeb, 0x59, 0x59, 0xf8, 0x8e, 0xb3, 0xea, 0x92, 0xbc, 0xef, 0xe6, 0x24, 0xae, 0xa0, 0x6a,
+	0xbf, 0x16, 0x64, 0xd9, 0x9f, 0xbf, 0x30, 0xbf, 0xaf, 0xdb, 0xf1, 0x57, 0x07, 0x76, 0x23, 0x3e,
+	0xf2, 0xf1, 0x90, 0xa6, 0x78, 0x52, 0x42, 0x95, 0x7e, 0x22, 0xd2, 0xe8, 0xc2, 0xf9, 0xf8, 0x29,
+	0xa1, 0x6a, 0x30, 0x0e, 0xfd, 0x88, 0x8f, 0x3a, 0x47, 0x4c, 0xe9, 0x85, 0x79, 0x97, 0x12, 0x76,
+	0x86, 0xc3, 0xdc, 0x3e, 0xd6, 0x4c, 0x9b, 0xec, 0xf5, 0xdb, 0x8e, 0x7d, 0x82, 0xa8, 0x4e, 0x1f,
+	0x87, 0x82, 0x46, 0x1d, 0xde, 0xef, 0xeb, 0x1c, 0x07, 0x26, 0x72, 0x90, 0xf0, 0x4e, 0x1a, 0x1e,
+	0xa6, 0x61, 0x58, 0xd5, 0x7f, 0x45, 0x2f, 0x7e, 0x0d, 0x00, 0x36, 0xa6, 0x1b, 0xcc, 0xc3, 0x06,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PluginServerClient is the client API for PluginServer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PluginServerClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	// 注册已过期或不存在时返回NOT_FOUND，插件应重新注册
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*Empty, error)
+	Unregister(ctx context.Context, in *UnregisterRequest, opts ...grpc.CallOption) (*Empty, error)
+	ListPlugins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListPluginsResponse, error)
+}
+
+type pluginServerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginServerClient(cc grpc.ClientConnInterface) PluginServerClient {
+	return &pluginServerClient{cc}
+}
+
+func (c *pluginServerClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.PluginServer/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServerClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.PluginServer/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServerClient) Unregister(ctx context.Context, in *UnregisterRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.PluginServer/Unregister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServerClient) ListPlugins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListPluginsResponse, error) {
+	out := new(ListPluginsResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.PluginServer/ListPlugins", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginServerServer is the server API for PluginServer service.
+type PluginServerServer interface {
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	// 注册已过期或不存在时返回NOT_FOUND，插件应重新注册
+	Heartbeat(context.Context, *HeartbeatRequest) (*Empty, error)
+	Unregister(context.Context, *UnregisterRequest) (*Empty, error)
+	ListPlugins(context.Context, *Empty) (*ListPluginsResponse, error)
+}
+
+// UnimplementedPluginServerServer can be embedded to have forward compatible implementations.
+type UnimplementedPluginServerServer struct {
+}
+
+func (*UnimplementedPluginServerServer) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (*UnimplementedPluginServerServer) Heartbeat(ctx context.Context, req *HeartbeatRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (*UnimplementedPluginServerServer) Unregister(ctx context.Context, req *UnregisterRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unregister not implemented")
+}
+func (*UnimplementedPluginServerServer) ListPlugins(ctx context.Context, req *Empty) (*ListPluginsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPlugins not implemented")
+}
+
+func RegisterPluginServerServer(s *grpc.Server, srv PluginServerServer) {
+	s.RegisterService(&_PluginServer_serviceDesc, srv)
+}
+
+func _PluginServer_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServerServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.PluginServer/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServerServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginServer_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServerServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.PluginServer/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServerServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginServer_Unregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServerServer).Unregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.PluginServer/Unregister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServerServer).Unregister(ctx, req.(*UnregisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginServer_ListPlugins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServerServer).ListPlugins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.PluginServer/ListPlugins",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServerServer).ListPlugins(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PluginServer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "antchain.bridge.plugin.PluginServer",
+	HandlerType: (*PluginServerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _PluginServer_Register_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _PluginServer_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Unregister",
+			Handler:    _PluginServer_Unregister_Handler,
+		},
+		{
+			MethodName: "ListPlugins",
+			Handler:    _PluginServer_ListPlugins_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pluginserver.proto",
+}
+
+// PluginStatusClient is the client API for PluginStatus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PluginStatusClient interface {
+	Status(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginStatusResponse, error)
+}
+
+type pluginStatusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginStatusClient(cc grpc.ClientConnInterface) PluginStatusClient {
+	return &pluginStatusClient{cc}
+}
+
+func (c *pluginStatusClient) Status(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginStatusResponse, error) {
+	out := new(PluginStatusResponse)
+	err := c.cc.Invoke(ctx, "/antchain.bridge.plugin.PluginStatus/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginStatusServer is the server API for PluginStatus service.
+type PluginStatusServer interface {
+	Status(context.Context, *Empty) (*PluginStatusResponse, error)
+}
+
+// UnimplementedPluginStatusServer can be embedded to have forward compatible implementations.
+type UnimplementedPluginStatusServer struct {
+}
+
+func (*UnimplementedPluginStatusServer) Status(ctx context.Context, req *Empty) (*PluginStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+
+func RegisterPluginStatusServer(s *grpc.Server, srv PluginStatusServer) {
+	s.RegisterService(&_PluginStatus_serviceDesc, srv)
+}
+
+func _PluginStatus_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginStatusServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/antchain.bridge.plugin.PluginStatus/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginStatusServer).Status(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PluginStatus_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "antchain.bridge.plugin.PluginStatus",
+	HandlerType: (*PluginStatusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler:    _PluginStatus_Status_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pluginserver.proto",
+}
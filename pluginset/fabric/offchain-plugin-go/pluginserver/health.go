@@ -0,0 +1,118 @@
+package pluginserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// 健康检查
+// 独立进程的插件在BBCService的gRPC服务中同时提供grpc.health.v1.Health和PluginStatus，编排平台可以直接使用gRPC探针:
+//   - 服务名为空: 存活检查。插件启动后连续failureThreshold次查询链失败时为NOT_SERVING，重启插件会重建链客户端
+//   - antchain.bridge.plugin.BBCService: 就绪检查。插件已启动且链已连接时为SERVING
+// BBCService没有实现bbc.StatusReporter时无法获取链的状态，进程运行期间总是视为健康和就绪。
+
+const (
+	// 就绪检查的服务名
+	BBC_SERVICE_NAME = "antchain.bridge.plugin.BBCService"
+
+	DEFAULT_HEALTH_INTERVAL   = 10 * time.Second
+	DEFAULT_FAILURE_THRESHOLD = 3
+)
+
+type Health struct {
+	info             *pb.PluginInfo
+	impl             bbc.BBCService
+	server           *health.Server
+	failureThreshold int
+
+	mu sync.Mutex
+	// 启动后连续查询链失败的次数
+	failures int
+}
+
+func NewHealth(info *pb.PluginInfo, impl bbc.BBCService, failureThreshold int) *Health {
+	if failureThreshold <= 0 {
+		failureThreshold = DEFAULT_FAILURE_THRESHOLD
+	}
+	h := &Health{info: info, impl: impl, server: health.NewServer(), failureThreshold: failureThreshold}
+	h.server.SetServingStatus(BBC_SERVICE_NAME, healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+var _ pb.PluginStatusServer = (*Health)(nil)
+
+// 在gRPC服务中注册Health和PluginStatus
+func (h *Health) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.server)
+	pb.RegisterPluginStatusServer(s, h)
+}
+
+func servingStatus(serving bool) healthpb.HealthCheckResponse_ServingStatus {
+	if serving {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// 查询一次链的状态并更新健康检查的结果
+func (h *Health) Check() *pb.PluginStatusResponse {
+	resp := &pb.PluginStatusResponse{Plugin: h.info, Ready: true}
+	reporter, ok := h.impl.(bbc.StatusReporter)
+	if !ok {
+		h.server.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		h.server.SetServingStatus(BBC_SERVICE_NAME, healthpb.HealthCheckResponse_SERVING)
+		return resp
+	}
+	status := reporter.ChainStatus()
+	resp.Ready = status.Connected
+	resp.Chains = []*pb.ChainStatus{{
+		Product:          h.info.Product,
+		Domain:           h.info.Domain,
+		Connected:        status.Connected,
+		Error:            status.Error,
+		ChainHeight:      status.ChainHeight,
+		LastSyncedHeight: status.LastSyncedHeight,
+		PendingMessages:  status.PendingMessages,
+	}}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if status.Started && !status.Connected {
+		h.failures++
+	} else {
+		h.failures = 0
+	}
+	h.server.SetServingStatus("", servingStatus(h.failures < h.failureThreshold))
+	h.server.SetServingStatus(BBC_SERVICE_NAME, servingStatus(resp.Ready))
+	return resp
+}
+
+// 每隔interval检查一次，ctx结束后所有服务为NOT_SERVING
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DEFAULT_HEALTH_INTERVAL
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.Check()
+		select {
+		case <-ctx.Done():
+			h.server.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Health) Status(ctx context.Context, req *pb.Empty) (*pb.PluginStatusResponse, error) {
+	return h.Check(), nil
+}
@@ -0,0 +1,75 @@
+package pluginserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// 返回设置的链状态
+type statusService struct {
+	heightService
+	status bbc.ChainStatus
+}
+
+func (s *statusService) ChainStatus() *bbc.ChainStatus {
+	status := s.status
+	return &status
+}
+
+func TestHealth(t *testing.T) {
+	impl := &statusService{}
+	h := NewHealth(&pb.PluginInfo{Product: "fabric", Domain: "fabric.com"}, impl, 2)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	h.Register(s)
+	go s.Serve(lis)
+	defer s.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	healthClient := healthpb.NewHealthClient(conn)
+	statusClient := pb.NewPluginStatusClient(conn)
+
+	ctx := context.Background()
+	expect := func(live, ready bool) {
+		t.Helper()
+		resp, err := statusClient.Status(ctx, &pb.Empty{})
+		if err != nil || resp.Ready != ready || len(resp.Chains) != 1 || resp.Chains[0].Domain != "fabric.com" {
+			t.Fatalf("unexpected status: %v %v", resp, err)
+		}
+		for service, serving := range map[string]bool{"": live, BBC_SERVICE_NAME: ready} {
+			check, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+			if err != nil || (check.Status == healthpb.HealthCheckResponse_SERVING) != serving {
+				t.Fatalf("unexpected health of %q: %v %v", service, check, err)
+			}
+		}
+	}
+	// 未启动时存活但未就绪
+	expect(true, false)
+	impl.status = bbc.ChainStatus{Started: true, Connected: true, ChainHeight: 10, LastSyncedHeight: 8, PendingMessages: 1}
+	expect(true, true)
+	// 连续失败达到阈值后存活检查失败
+	impl.status = bbc.ChainStatus{Started: true, Error: "connection refused"}
+	expect(true, false)
+	expect(false, false)
+	impl.status.Connected = true
+	expect(true, true)
+
+	// 不报告状态的BBCService总是就绪
+	plain := NewHealth(&pb.PluginInfo{Product: "fabric"}, &heightService{}, 0)
+	if resp := plain.Check(); !resp.Ready || len(resp.Chains) != 0 {
+		t.Fatalf("unexpected status: %v", resp)
+	}
+}
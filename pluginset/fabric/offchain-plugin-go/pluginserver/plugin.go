@@ -0,0 +1,127 @@
+package pluginserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// 插件侧: 启动BBCService的gRPC服务并注册到插件服务，同一服务中提供健康检查，见health.go
+
+type PluginConfig struct {
+	// 插件服务的地址
+	ServerAddress string
+	Product       string
+	Domain        string
+	Version       string
+	// BBCService监听的地址，如127.0.0.1:0
+	ListenAddress string
+	// 注册给插件服务的地址，为空时使用实际监听的地址
+	AdvertiseAddress string
+	Impl             bbc.BBCService
+	Logger           hclog.Logger
+	// 连接插件服务的选项，默认不使用TLS
+	DialOptions []grpc.DialOption
+	// 检查链状态的间隔，默认DEFAULT_HEALTH_INTERVAL
+	HealthInterval time.Duration
+	// 连续查询链失败多少次后存活检查为NOT_SERVING，默认DEFAULT_FAILURE_THRESHOLD
+	FailureThreshold int
+}
+
+// 注册并保持心跳，ctx结束时注销并停止服务
+// 插件服务重启导致注册丢失时自动重新注册
+func ServePlugin(ctx context.Context, conf *PluginConfig) error {
+	logger := conf.Logger
+	if logger == nil {
+		logger = hclog.NewNullLogger()
+	}
+	lis, err := net.Listen("tcp", conf.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", conf.ListenAddress, err)
+	}
+	info := &pb.PluginInfo{Product: conf.Product, Domain: conf.Domain, Address: conf.AdvertiseAddress, Version: conf.Version}
+	if info.Address == "" {
+		info.Address = lis.Addr().String()
+	}
+	s := grpc.NewServer()
+	bbc.RegisterGRPCServer(s, conf.Impl)
+	health := NewHealth(info, conf.Impl, conf.FailureThreshold)
+	health.Register(s)
+	go s.Serve(lis)
+	defer s.GracefulStop()
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	healthDone := make(chan struct{})
+	go func() {
+		defer close(healthDone)
+		health.Run(healthCtx, conf.HealthInterval)
+	}()
+	defer func() {
+		stopHealth()
+		<-healthDone
+	}()
+	opts := conf.DialOptions
+	if opts == nil {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	conn, err := grpc.DialContext(ctx, conf.ServerAddress, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to dial plugin server %s: %v", conf.ServerAddress, err)
+	}
+	defer conn.Close()
+	client := pb.NewPluginServerClient(conn)
+
+	register := func() (*pb.RegisterResponse, error) {
+		resp, err := client.Register(ctx, &pb.RegisterRequest{Plugin: info})
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("registered to plugin server", "id", resp.PluginId, "address", info.Address, "ttl", resp.Ttl)
+		return resp, nil
+	}
+	reg, err := register()
+	if err != nil {
+		return fmt.Errorf("failed to register to plugin server: %v", err)
+	}
+
+	interval := func(ttl int64) time.Duration {
+		if d := time.Duration(ttl) * time.Second / 3; d > 0 {
+			return d
+		}
+		return time.Second
+	}
+	ticker := time.NewTicker(interval(reg.Ttl))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			unregisterCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if _, err := client.Unregister(unregisterCtx, &pb.UnregisterRequest{PluginId: reg.PluginId}); err != nil {
+				logger.Warn("failed to unregister from plugin server", "error", err)
+			}
+			return nil
+		case <-ticker.C:
+		}
+		_, err := client.Heartbeat(ctx, &pb.HeartbeatRequest{PluginId: reg.PluginId})
+		if status.Code(err) == codes.NotFound {
+			logger.Warn("registration lost, register again", "id", reg.PluginId)
+			if r, err := register(); err == nil {
+				reg = r
+				ticker.Reset(interval(reg.Ttl))
+			} else {
+				logger.Warn("failed to register to plugin server", "error", err)
+			}
+		} else if err != nil && ctx.Err() == nil {
+			logger.Warn("failed to heartbeat", "error", err)
+		}
+	}
+}
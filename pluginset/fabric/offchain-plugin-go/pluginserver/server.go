@@ -0,0 +1,199 @@
+package pluginserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// 插件服务
+// 进程外的插件按proto/pluginserver.proto注册，插件服务按链类型和域名查找插件并返回BBCService客户端。
+// 同一链类型可以注册多个插件，指定域名的插件优先，其次是不限域名的插件，同类中取最近心跳的一个。
+
+const DEFAULT_TTL = 30 * time.Second
+
+type registration struct {
+	id            string
+	info          *pb.PluginInfo
+	registeredAt  time.Time
+	lastHeartbeat time.Time
+	conn          *grpc.ClientConn
+	service       *bbc.GRPCClient
+}
+
+type Server struct {
+	logger hclog.Logger
+	ttl    time.Duration
+	// 连接插件的选项，默认不使用TLS，插件应部署在可信网络中
+	DialOptions []grpc.DialOption
+	now         func() time.Time
+
+	mu      sync.Mutex
+	plugins map[string]*registration
+}
+
+func NewServer(logger hclog.Logger, ttl time.Duration) *Server {
+	if ttl <= 0 {
+		ttl = DEFAULT_TTL
+	}
+	return &Server{
+		logger:      logger,
+		ttl:         ttl,
+		DialOptions: []grpc.DialOption{grpc.WithInsecure()},
+		now:         time.Now,
+		plugins:     make(map[string]*registration),
+	}
+}
+
+var _ pb.PluginServerServer = (*Server)(nil)
+
+func newPluginID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func (s *Server) expired(r *registration, now time.Time) bool {
+	return now.Sub(r.lastHeartbeat) > s.ttl
+}
+
+// 删除过期的插件，调用方持有锁
+func (s *Server) purgeLocked(now time.Time) {
+	for id, r := range s.plugins {
+		if s.expired(r, now) {
+			s.logger.Warn("plugin heartbeat expired", "id", id, "product", r.info.Product, "address", r.info.Address)
+			r.conn.Close()
+			delete(s.plugins, id)
+		}
+	}
+}
+
+func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	info := req.Plugin
+	if info == nil || info.Product == "" || info.Address == "" {
+		return nil, status.Error(codes.InvalidArgument, "product and address are required")
+	}
+	id, err := newPluginID()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	conn, err := grpc.Dial(info.Address, s.DialOptions...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to dial %s: %v", info.Address, err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	// 同一地址重新注册时替换旧的注册
+	for oldID, r := range s.plugins {
+		if r.info.Product == info.Product && r.info.Domain == info.Domain && r.info.Address == info.Address {
+			r.conn.Close()
+			delete(s.plugins, oldID)
+		}
+	}
+	s.plugins[id] = &registration{
+		id:            id,
+		info:          info,
+		registeredAt:  now,
+		lastHeartbeat: now,
+		conn:          conn,
+		service:       bbc.NewGRPCClient(conn),
+	}
+	s.logger.Info("plugin registered", "id", id, "product", info.Product, "domain", info.Domain, "address", info.Address, "version", info.Version)
+	return &pb.RegisterResponse{PluginId: id, Ttl: int64(s.ttl / time.Second)}, nil
+}
+
+func (s *Server) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.purgeLocked(now)
+	r, ok := s.plugins[req.PluginId]
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("plugin %s not registered", req.PluginId))
+	}
+	r.lastHeartbeat = now
+	return &pb.Empty{}, nil
+}
+
+func (s *Server) Unregister(ctx context.Context, req *pb.UnregisterRequest) (*pb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.plugins[req.PluginId]; ok {
+		s.logger.Info("plugin unregistered", "id", req.PluginId, "product", r.info.Product, "address", r.info.Address)
+		r.conn.Close()
+		delete(s.plugins, req.PluginId)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (s *Server) ListPlugins(ctx context.Context, req *pb.Empty) (*pb.ListPluginsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(s.now())
+	resp := &pb.ListPluginsResponse{}
+	for _, r := range s.plugins {
+		resp.Plugins = append(resp.Plugins, &pb.RegisteredPlugin{
+			PluginId:      r.id,
+			Plugin:        r.info,
+			RegisteredAt:  r.registeredAt.Unix(),
+			LastHeartbeat: r.lastHeartbeat.Unix(),
+		})
+	}
+	sort.Slice(resp.Plugins, func(i, j int) bool {
+		return resp.Plugins[i].PluginId < resp.Plugins[j].PluginId
+	})
+	return resp, nil
+}
+
+// 查找服务链类型和域名的插件
+func (s *Server) Service(product, domain string) (bbc.BBCService, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(s.now())
+	var found *registration
+	for _, r := range s.plugins {
+		if r.info.Product != product || (r.info.Domain != domain && r.info.Domain != "") {
+			continue
+		}
+		switch {
+		case found == nil:
+			found = r
+		case (r.info.Domain == domain) != (found.info.Domain == domain):
+			if r.info.Domain == domain {
+				found = r
+			}
+		case r.lastHeartbeat.After(found.lastHeartbeat):
+			found = r
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no plugin for product %s and domain %s", product, domain)
+	}
+	return found.service, nil
+}
+
+// 关闭到所有插件的连接
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, r := range s.plugins {
+		r.conn.Close()
+		delete(s.plugins, id)
+	}
+}
@@ -0,0 +1,153 @@
+package pluginserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/pb"
+)
+
+// 只实现QueryLatestHeight，其他方法不会被调用
+type heightService struct {
+	bbc.BBCService
+	height uint64
+}
+
+func (s *heightService) QueryLatestHeight() (uint64, error) {
+	if s.height == 0 {
+		return 0, errors.New("empty chain")
+	}
+	return s.height, nil
+}
+
+func startServer(t *testing.T, server *Server) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterPluginServerServer(s, server)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// 等待插件注册或注销
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout")
+}
+
+func TestServePlugin(t *testing.T) {
+	server := NewServer(hclog.NewNullLogger(), time.Minute)
+	defer server.Close()
+	addr := startServer(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	serve := func(domain string, height uint64) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ServePlugin(ctx, &PluginConfig{
+				ServerAddress: addr,
+				Product:       "fabric",
+				Domain:        domain,
+				ListenAddress: "127.0.0.1:0",
+				Impl:          &heightService{height: height},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	serve("", 10)
+	serve("fabric.com", 20)
+	waitFor(t, func() bool {
+		resp, _ := server.ListPlugins(context.Background(), &pb.Empty{})
+		return len(resp.Plugins) == 2
+	})
+
+	// 指定域名的插件优先
+	for domain, expect := range map[string]uint64{"fabric.com": 20, "other.com": 10} {
+		service, err := server.Service("fabric", domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height, err := service.QueryLatestHeight(); err != nil || height != expect {
+			t.Fatalf("%s: unexpected height %d %v", domain, height, err)
+		}
+	}
+	if _, err := server.Service("mychain", ""); err == nil {
+		t.Fatal("unknown product should be rejected")
+	}
+
+	// 插件退出时注销
+	cancel()
+	wg.Wait()
+	resp, _ := server.ListPlugins(context.Background(), &pb.Empty{})
+	if len(resp.Plugins) != 0 {
+		t.Fatalf("unexpected plugins: %v", resp.Plugins)
+	}
+}
+
+func TestPluginExpiry(t *testing.T) {
+	server := NewServer(hclog.NewNullLogger(), 10*time.Second)
+	defer server.Close()
+	now := time.Unix(1000, 0)
+	server.now = func() time.Time { return now }
+
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	s := grpc.NewServer()
+	bbc.RegisterGRPCServer(s, &heightService{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	ctx := context.Background()
+	if _, err := server.Register(ctx, &pb.RegisterRequest{Plugin: &pb.PluginInfo{Product: "fabric"}}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reg, err := server.Register(ctx, &pb.RegisterRequest{Plugin: &pb.PluginInfo{Product: "fabric", Address: lis.Addr().String()}})
+	if err != nil || reg.Ttl != 10 {
+		t.Fatalf("unexpected registration: %v %v", reg, err)
+	}
+	// 插件的错误原样返回
+	service, err := server.Service("fabric", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.QueryLatestHeight(); err == nil || err.Error() != "empty chain" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = now.Add(8 * time.Second)
+	if _, err := server.Heartbeat(ctx, &pb.HeartbeatRequest{PluginId: reg.PluginId}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(8 * time.Second)
+	if _, err := server.Service("fabric", ""); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(3 * time.Second)
+	if _, err := server.Service("fabric", ""); err == nil {
+		t.Fatal("expired plugin should be removed")
+	}
+	if _, err := server.Heartbeat(ctx, &pb.HeartbeatRequest{PluginId: reg.PluginId}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
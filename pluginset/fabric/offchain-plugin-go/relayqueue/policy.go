@@ -0,0 +1,97 @@
+package relayqueue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+// 重试策略
+// 投递失败时按回执判断能否重试：调用插件出错或没有错误码的失败（如等待上链超时）总是重试；有错误码时，
+// 配置了retryableCodes的只重试其中的错误码，否则按回执的retryable。可以重试的消息按指数退避等待后重新投递，
+// 不能重试或达到最多投递次数的消息移入死信，由运维排查后用relay-queue requeue重新入队。
+
+const (
+	DEFAULT_MAX_ATTEMPTS    = 10
+	DEFAULT_INITIAL_BACKOFF = time.Second
+	DEFAULT_MAX_BACKOFF     = 5 * time.Minute
+)
+
+type RetryPolicy struct {
+	// 最多投递次数，包括第一次，默认DEFAULT_MAX_ATTEMPTS
+	MaxAttempts int `json:"maxAttempts"`
+	// 第一次重试前的等待时间(毫秒)，之后每次翻倍
+	InitialBackoff int64 `json:"initialBackoff"`
+	// 最长等待时间(毫秒)
+	MaxBackoff int64 `json:"maxBackoff"`
+	// 可以重试的错误码，如ERR_SEQ_AHEAD、MVCC_READ_CONFLICT，为空时按回执的retryable判断
+	RetryableCodes []string `json:"retryableCodes"`
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return DEFAULT_MAX_ATTEMPTS
+	}
+	return p.MaxAttempts
+}
+
+// 第attempts次失败后的等待时间
+func (p *RetryPolicy) backoff(attempts int) time.Duration {
+	initial, max := DEFAULT_INITIAL_BACKOFF, DEFAULT_MAX_BACKOFF
+	if p != nil && p.InitialBackoff > 0 {
+		initial = time.Duration(p.InitialBackoff) * time.Millisecond
+	}
+	if p != nil && p.MaxBackoff > 0 {
+		max = time.Duration(p.MaxBackoff) * time.Millisecond
+	}
+	d := initial
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// 失败的错误码：链码错误的符号名或交易的校验码
+func receiptCode(receipt *bbc.CrossChainMessageReceipt) string {
+	if receipt.ErrorCode != "" || !receipt.Confirmed {
+		return receipt.ErrorCode
+	}
+	return receipt.ErrorMsg
+}
+
+// 投递失败的原因和能否重试
+func (p *RetryPolicy) classify(receipt *bbc.CrossChainMessageReceipt, err error) (string, bool) {
+	if err != nil {
+		return err.Error(), true
+	}
+	code := receiptCode(receipt)
+	reason := receipt.ErrorMsg
+	if code != "" && code != reason {
+		reason = fmt.Sprintf("%s: %s", code, reason)
+	}
+	if code == "" {
+		return reason, true
+	}
+	if p == nil || len(p.RetryableCodes) == 0 {
+		return reason, receipt.Retryable
+	}
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return reason, true
+		}
+	}
+	return reason, false
+}
+
+// 第attempts次投递失败后的处理，返回失败原因和重试前的等待时间，不再重试时retry为false
+func (p *RetryPolicy) Next(attempts int, receipt *bbc.CrossChainMessageReceipt, err error) (reason string, backoff time.Duration, retry bool) {
+	reason, retryable := p.classify(receipt, err)
+	if !retryable || attempts >= p.maxAttempts() {
+		return reason, 0, false
+	}
+	return reason, p.backoff(attempts), true
+}
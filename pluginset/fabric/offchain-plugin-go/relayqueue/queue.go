@@ -0,0 +1,273 @@
+package relayqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 持久化的待投递消息队列
+// 中继读取来源链的跨链消息后先写入队列，投递到目的链、交易上链且校验成功后才确认删除(ack-on-commit)。
+// 同一区块的消息和读取进度在一个事务中写入，进程在任何时刻退出，重新打开后既不会漏读区块，
+// 也不会丢失已读出还没有投递的消息，未确认的消息按入队顺序重新投递，至少投递一次。
+// 重复投递的消息由插件的提交记录和跨链链码的序号检查拒绝，不会重复执行。
+// 按重试策略不再重试的消息移入死信(见policy.go)，死信不会自动投递，重新入队后排在队尾。
+
+var (
+	MESSAGE_BUCKET = []byte("messages")
+	DEAD_BUCKET    = []byte("dead")
+	META_BUCKET    = []byte("meta")
+
+	// 下一个要读取的来源链高度
+	CURSOR_KEY = []byte("cursor")
+)
+
+type Message struct {
+	// 队列中的序号，入队时分配，按入队顺序递增
+	Seq uint64 `json:"seq"`
+	// 来源链的域名和消息所在的区块
+	Domain string `json:"domain"`
+	Height uint64 `json:"height"`
+	// AM报文
+	Payload []byte `json:"payload"`
+	// 入队时间，毫秒
+	EnqueuedAt int64 `json:"enqueuedAt"`
+	// 投递失败的次数和最后一次失败的原因
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+	// 下一次投递的最早时间，毫秒
+	NextAttemptAt int64 `json:"nextAttemptAt,omitempty"`
+	// 移入死信的时间，毫秒
+	DeadAt int64 `json:"deadAt,omitempty"`
+}
+
+type Queue struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+func Open(path string) (*Queue, error) {
+	// 文件被其他进程打开时不一直等待
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open relay queue %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{MESSAGE_BUCKET, DEAD_BUCKET, META_BUCKET} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Queue{db: db, now: time.Now}, nil
+}
+
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+func (q *Queue) millis() int64 {
+	return q.now().UnixNano() / int64(time.Millisecond)
+}
+
+func seqKey(seq uint64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	return key[:]
+}
+
+// 下一个要读取的高度，没有读取过时返回false
+func (q *Queue) Cursor() (uint64, bool, error) {
+	var cursor uint64
+	var ok bool
+	err := q.db.View(func(tx *bolt.Tx) error {
+		if raw := tx.Bucket(META_BUCKET).Get(CURSOR_KEY); len(raw) == 8 {
+			cursor, ok = binary.BigEndian.Uint64(raw), true
+		}
+		return nil
+	})
+	return cursor, ok, err
+}
+
+// 保存消息并把读取进度推进到cursor，两者在同一事务中写入
+func (q *Queue) Enqueue(cursor uint64, msgs ...*Message) error {
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(MESSAGE_BUCKET)
+		for _, msg := range msgs {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			m := *msg
+			m.Seq, m.EnqueuedAt = seq, q.millis()
+			if err := putMessage(b, &m); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(META_BUCKET).Put(CURSOR_KEY, seqKey(cursor))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue messages: %v", err)
+	}
+	return nil
+}
+
+func putMessage(b *bolt.Bucket, msg *Message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.Put(seqKey(msg.Seq), raw)
+}
+
+func getMessage(b *bolt.Bucket, seq uint64) (*Message, error) {
+	raw := b.Get(seqKey(seq))
+	if raw == nil {
+		return nil, fmt.Errorf("message not found")
+	}
+	msg := &Message{}
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (q *Queue) list(bucket []byte, limit int) ([]*Message, error) {
+	var msgs []*Message
+	err := q.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.First(); k != nil && (limit <= 0 || len(msgs) < limit); k, v = c.Next() {
+			msg := &Message{}
+			if err := json.Unmarshal(v, msg); err != nil {
+				return fmt.Errorf("invalid message %d: %v", binary.BigEndian.Uint64(k), err)
+			}
+			msgs = append(msgs, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", bucket, err)
+	}
+	return msgs, nil
+}
+
+// 按入队顺序返回最多limit条未确认的消息，limit不大于0时返回全部
+func (q *Queue) Pending(limit int) ([]*Message, error) {
+	return q.list(MESSAGE_BUCKET, limit)
+}
+
+// 按序号返回最多limit条死信，limit不大于0时返回全部
+func (q *Queue) Dead(limit int) ([]*Message, error) {
+	return q.list(DEAD_BUCKET, limit)
+}
+
+// 未确认的消息数
+func (q *Queue) Len() (int, error) {
+	var n int
+	err := q.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(MESSAGE_BUCKET).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// 投递的交易已上链，删除消息
+func (q *Queue) Ack(seq uint64) error {
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(MESSAGE_BUCKET).Delete(seqKey(seq))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ack message %d: %v", seq, err)
+	}
+	return nil
+}
+
+// 记录一次投递失败，消息留在队列中，retryAt之后重新投递
+func (q *Queue) Fail(seq uint64, reason string, retryAt time.Time) error {
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(MESSAGE_BUCKET)
+		msg, err := getMessage(b, seq)
+		if err != nil {
+			return err
+		}
+		msg.Attempts++
+		msg.LastError = reason
+		msg.NextAttemptAt = retryAt.UnixNano() / int64(time.Millisecond)
+		return putMessage(b, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record failure of message %d: %v", seq, err)
+	}
+	return nil
+}
+
+// 记录最后一次失败并把消息移入死信
+func (q *Queue) Bury(seq uint64, reason string) error {
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(MESSAGE_BUCKET)
+		msg, err := getMessage(b, seq)
+		if err != nil {
+			return err
+		}
+		msg.Attempts++
+		msg.LastError = reason
+		msg.NextAttemptAt, msg.DeadAt = 0, q.millis()
+		if err := b.Delete(seqKey(seq)); err != nil {
+			return err
+		}
+		return putMessage(tx.Bucket(DEAD_BUCKET), msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move message %d to dead letters: %v", seq, err)
+	}
+	return nil
+}
+
+// 把死信重新放到队尾，投递次数清零，返回新的序号
+func (q *Queue) Requeue(seq uint64) (uint64, error) {
+	var next uint64
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(DEAD_BUCKET)
+		msg, err := getMessage(dead, seq)
+		if err != nil {
+			return err
+		}
+		if err := dead.Delete(seqKey(seq)); err != nil {
+			return err
+		}
+		b := tx.Bucket(MESSAGE_BUCKET)
+		if next, err = b.NextSequence(); err != nil {
+			return err
+		}
+		msg.Seq, msg.EnqueuedAt = next, q.millis()
+		msg.Attempts, msg.NextAttemptAt, msg.DeadAt = 0, 0, 0
+		return putMessage(b, msg)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead letter %d: %v", seq, err)
+	}
+	return next, nil
+}
+
+// 删除死信
+func (q *Queue) Drop(seq uint64) error {
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(DEAD_BUCKET)
+		if _, err := getMessage(dead, seq); err != nil {
+			return err
+		}
+		return dead.Delete(seqKey(seq))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop dead letter %d: %v", seq, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package relayqueue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := q.Cursor(); ok || err != nil {
+		t.Fatalf("new queue should have no cursor: %v", err)
+	}
+	if err := q.Enqueue(5, &Message{Domain: "a.com", Height: 4, Payload: []byte("m1")}, &Message{Domain: "a.com", Height: 4, Payload: []byte("m2")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(7, &Message{Domain: "a.com", Height: 6, Payload: []byte("m3")}); err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := q.Pending(2)
+	if err != nil || len(msgs) != 2 || string(msgs[0].Payload) != "m1" || string(msgs[1].Payload) != "m2" {
+		t.Fatalf("unexpected pending messages: %v %v", msgs, err)
+	}
+	if err := q.Ack(msgs[0].Seq); err != nil {
+		t.Fatal(err)
+	}
+	retryAt := time.Unix(1000, 0)
+	if err := q.Fail(msgs[1].Seq, "timeout", retryAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Fail(msgs[0].Seq, "timeout", retryAt); err == nil {
+		t.Fatal("acked message should not be found")
+	}
+
+	// 重新打开后未确认的消息和读取进度都在
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if q, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if cursor, ok, err := q.Cursor(); !ok || cursor != 7 || err != nil {
+		t.Fatalf("unexpected cursor: %d %v", cursor, err)
+	}
+	msgs, err = q.Pending(0)
+	if err != nil || len(msgs) != 2 || string(msgs[0].Payload) != "m2" || msgs[0].Attempts != 1 || msgs[0].LastError != "timeout" || msgs[0].NextAttemptAt != 1000000 || msgs[1].Height != 6 {
+		t.Fatalf("unexpected pending messages after reopen: %+v %v", msgs, err)
+	}
+	if n, err := q.Len(); n != 2 || err != nil {
+		t.Fatalf("unexpected length: %d %v", n, err)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if err := q.Enqueue(2, &Message{Height: 1, Payload: []byte("m1")}, &Message{Height: 1, Payload: []byte("m2")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Bury(1, "ERR_PROOF_INVALID: invalid proof"); err != nil {
+		t.Fatal(err)
+	}
+	dead, err := q.Dead(0)
+	if err != nil || len(dead) != 1 || dead[0].Attempts != 1 || dead[0].DeadAt == 0 || dead[0].LastError != "ERR_PROOF_INVALID: invalid proof" {
+		t.Fatalf("unexpected dead letters: %+v %v", dead, err)
+	}
+	if n, _ := q.Len(); n != 1 {
+		t.Fatalf("dead letter should leave the queue, %d pending", n)
+	}
+
+	// 重新入队排在队尾
+	seq, err := q.Requeue(1)
+	if err != nil || seq != 3 {
+		t.Fatalf("unexpected requeue: %d %v", seq, err)
+	}
+	msgs, _ := q.Pending(0)
+	if len(msgs) != 2 || string(msgs[1].Payload) != "m1" || msgs[1].Attempts != 0 || msgs[1].DeadAt != 0 {
+		t.Fatalf("unexpected pending messages: %+v", msgs)
+	}
+	if _, err := q.Requeue(1); err == nil {
+		t.Fatal("requeued message should not be dead")
+	}
+	if err := q.Bury(2, "rejected"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Drop(2); err != nil {
+		t.Fatal(err)
+	}
+	if dead, _ := q.Dead(0); len(dead) != 0 {
+		t.Fatalf("unexpected dead letters: %+v", dead)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var defaults *RetryPolicy
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: 100, MaxBackoff: 300, RetryableCodes: []string{"ERR_SEQ_AHEAD", "MVCC_READ_CONFLICT"}}
+	for _, c := range []struct {
+		policy   *RetryPolicy
+		attempts int
+		receipt  *bbc.CrossChainMessageReceipt
+		err      error
+		reason   string
+		backoff  time.Duration
+		retry    bool
+	}{
+		{defaults, 1, nil, errors.New("connection refused"), "connection refused", time.Second, true},
+		{defaults, 3, &bbc.CrossChainMessageReceipt{ErrorMsg: "timeout"}, nil, "timeout", 4 * time.Second, true},
+		{defaults, DEFAULT_MAX_ATTEMPTS, &bbc.CrossChainMessageReceipt{ErrorMsg: "timeout"}, nil, "timeout", 0, false},
+		{defaults, 1, &bbc.CrossChainMessageReceipt{ErrorCode: "ERR_PAUSED", ErrorMsg: "paused", Retryable: true}, nil, "ERR_PAUSED: paused", time.Second, true},
+		{defaults, 1, &bbc.CrossChainMessageReceipt{ErrorCode: "ERR_PROOF_INVALID", ErrorMsg: "invalid"}, nil, "ERR_PROOF_INVALID: invalid", 0, false},
+		// 配置了错误码时只重试其中的错误码
+		{policy, 1, &bbc.CrossChainMessageReceipt{ErrorCode: "ERR_PAUSED", ErrorMsg: "paused", Retryable: true}, nil, "ERR_PAUSED: paused", 0, false},
+		{policy, 1, &bbc.CrossChainMessageReceipt{ErrorCode: "ERR_SEQ_AHEAD", ErrorMsg: "ahead"}, nil, "ERR_SEQ_AHEAD: ahead", 100 * time.Millisecond, true},
+		{policy, 2, &bbc.CrossChainMessageReceipt{Confirmed: true, ErrorMsg: "MVCC_READ_CONFLICT"}, nil, "MVCC_READ_CONFLICT", 200 * time.Millisecond, true},
+		{policy, 3, &bbc.CrossChainMessageReceipt{Confirmed: true, ErrorMsg: "MVCC_READ_CONFLICT"}, nil, "MVCC_READ_CONFLICT", 0, false},
+		{&RetryPolicy{MaxAttempts: 10, InitialBackoff: 100, MaxBackoff: 300}, 5, nil, errors.New("timeout"), "timeout", 300 * time.Millisecond, true},
+	} {
+		reason, backoff, retry := c.policy.Next(c.attempts, c.receipt, c.err)
+		if reason != c.reason || backoff != c.backoff || retry != c.retry {
+			t.Fatalf("unexpected decision for %+v %v: %s %v %v", c.receipt, c.err, reason, backoff, retry)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+// PEM格式的私钥
+// 私钥为PKCS#8(PRIVATE KEY)或SEC1(EC PRIVATE KEY)，公钥为SubjectPublicKeyInfo。
+// 标准库不支持SM2曲线，SM2密钥的算法为id-ecPublicKey，参数为曲线OID，与OpenSSL生成的一致。
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidCurveSM2       = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type pkcs8 struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+type ecdsaSigner struct {
+	priv *ecdsa.PrivateKey
+}
+
+func NewECDSASigner(priv *ecdsa.PrivateKey) (Signer, error) {
+	if priv.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported ecdsa curve %s", priv.Curve.Params().Name)
+	}
+	return &ecdsaSigner{priv: priv}, nil
+}
+
+func (s *ecdsaSigner) Public() crypto.PublicKey {
+	return &s.priv.PublicKey
+}
+
+func (s *ecdsaSigner) SignDigest(digest []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, s.priv, digest)
+}
+
+type sm2Signer struct {
+	priv *SM2PrivateKey
+}
+
+func NewSM2Signer(priv *SM2PrivateKey) Signer {
+	return &sm2Signer{priv: priv}
+}
+
+func (s *sm2Signer) Public() crypto.PublicKey {
+	return &s.priv.SM2PublicKey
+}
+
+func (s *sm2Signer) SignDigest(digest []byte) ([]byte, error) {
+	r, ss, err := s.priv.SignDigest(rand.Reader, digest)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecdsaSignature{R: r, S: ss})
+}
+
+// 按算法生成密钥
+func Generate(algo Algorithm) (Signer, error) {
+	switch algo {
+	case ECDSA_P256_SHA256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewECDSASigner(priv)
+	case SM2_SM3:
+		priv, err := GenerateSM2Key(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewSM2Signer(priv), nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %s", algo)
+}
+
+// 编码为PEM格式的私钥，P-256为PKCS#8，SM2为SEC1，只支持Generate和文件中的密钥
+func MarshalPrivateKeyPEM(s Signer) (string, error) {
+	switch k := s.(type) {
+	case *ecdsaSigner:
+		der, err := x509.MarshalPKCS8PrivateKey(k.priv)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	case *sm2Signer:
+		point := k.priv.marshal()
+		der, err := asn1.Marshal(ecPrivateKey{
+			Version:       1,
+			PrivateKey:    k.priv.D.FillBytes(make([]byte, 32)),
+			NamedCurveOID: oidCurveSM2,
+			PublicKey:     asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+	}
+	return "", fmt.Errorf("private key of %T is not exportable", s)
+}
+
+// 解析PEM格式的私钥
+func ParsePrivateKeyPEM(raw []byte) (Signer, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if priv, ok := key.(*ecdsa.PrivateKey); ok {
+			return NewECDSASigner(priv)
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if priv, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return NewECDSASigner(priv)
+	}
+
+	var ec ecPrivateKey
+	der := block.Bytes
+	var p8 pkcs8
+	if rest, err := asn1.Unmarshal(der, &p8); err == nil && len(rest) == 0 && p8.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(p8.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidCurveSM2) {
+			return nil, fmt.Errorf("unsupported private key curve %v", curve)
+		}
+		der = p8.PrivateKey
+	}
+	if rest, err := asn1.Unmarshal(der, &ec); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("failed to parse private key")
+	}
+	if len(ec.NamedCurveOID) > 0 && !ec.NamedCurveOID.Equal(oidCurveSM2) {
+		return nil, fmt.Errorf("unsupported private key curve %v", ec.NamedCurveOID)
+	}
+	priv, err := NewSM2PrivateKey(new(big.Int).SetBytes(ec.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return NewSM2Signer(priv), nil
+}
+
+func LoadKeyFile(path string) (Signer, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+	return ParsePrivateKeyPEM(raw)
+}
+
+func MarshalPublicKeyPEM(pub crypto.PublicKey) (string, error) {
+	var der []byte
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		var err error
+		if der, err = x509.MarshalPKIXPublicKey(k); err != nil {
+			return "", err
+		}
+	case *SM2PublicKey:
+		params, _ := asn1.Marshal(oidCurveSM2)
+		point := k.marshal()
+		der, _ = asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}},
+			PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+		})
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// 解析PEM格式的公钥，返回*ecdsa.PublicKey(P-256)或*SM2PublicKey
+func ParsePublicKeyPEM(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+	return ParsePublicKeyDER(block.Bytes)
+}
+
+func ParsePublicKeyDER(der []byte) (crypto.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if _, err := AlgorithmOf(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("failed to parse public key")
+	}
+	var curve asn1.ObjectIdentifier
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, fmt.Errorf("unsupported public key algorithm %v", spki.Algorithm.Algorithm)
+	}
+	if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidCurveSM2) {
+		return nil, fmt.Errorf("unsupported curve %v", curve)
+	}
+	key, ok := unmarshalSM2PublicKey(spki.PublicKey.RightAlign())
+	if !ok {
+		return nil, fmt.Errorf("invalid sm2 public key")
+	}
+	return key, nil
+}
+
+func SamePublicKey(a, b crypto.PublicKey) bool {
+	switch ka := a.(type) {
+	case *ecdsa.PublicKey:
+		kb, ok := b.(*ecdsa.PublicKey)
+		return ok && ka.Curve == kb.Curve && ka.X.Cmp(kb.X) == 0 && ka.Y.Cmp(kb.Y) == 0
+	case *SM2PublicKey:
+		kb, ok := b.(*SM2PublicKey)
+		return ok && ka.X.Cmp(kb.X) == 0 && ka.Y.Cmp(kb.Y) == 0
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KMS中的密钥
+// 私钥不离开KMS，启动时读取一次公钥，签名时把摘要发给KMS。其它云厂商的KMS实现KMSClient即可接入。
+type KMSClient interface {
+	// DER编码的SubjectPublicKeyInfo
+	GetPublicKey() ([]byte, error)
+	// 对摘要签名，返回ASN.1编码的签名
+	Sign(digest []byte) ([]byte, error)
+}
+
+type KMSConfig struct {
+	// 目前内置vault(HashiCorp Vault的transit引擎)
+	Provider string `json:"provider"`
+	Address  string `json:"address"`
+	// 访问令牌或令牌文件的路径
+	Token     string `json:"token,omitempty"`
+	TokenPath string `json:"tokenPath,omitempty"`
+	// transit引擎的挂载路径，默认为transit
+	Mount string `json:"mount,omitempty"`
+	Key   string `json:"key"`
+	// 请求超时，默认10秒
+	TimeoutMs int64 `json:"timeoutMs,omitempty"`
+}
+
+type kmsSigner struct {
+	client KMSClient
+	pub    crypto.PublicKey
+}
+
+func NewKMSSigner(conf *KMSConfig) (Signer, error) {
+	switch conf.Provider {
+	case "", "vault":
+		client, err := NewVaultClient(conf)
+		if err != nil {
+			return nil, err
+		}
+		return NewKMSClientSigner(client)
+	}
+	return nil, fmt.Errorf("unknown kms provider %s", conf.Provider)
+}
+
+func NewKMSClientSigner(client KMSClient) (Signer, error) {
+	der, err := client.GetPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kms public key: %v", err)
+	}
+	pub, err := ParsePublicKeyDER(der)
+	if err != nil {
+		return nil, err
+	}
+	return &kmsSigner{client: client, pub: pub}, nil
+}
+
+func (s *kmsSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *kmsSigner) SignDigest(digest []byte) ([]byte, error) {
+	sig, err := s.client.Sign(digest)
+	if err != nil {
+		return nil, fmt.Errorf("kms sign failed: %v", err)
+	}
+	return sig, nil
+}
+
+// Vault transit引擎，密钥类型为ecdsa-p256
+type VaultClient struct {
+	address string
+	mount   string
+	key     string
+	token   string
+	client  *http.Client
+	// GetPublicKey读取的版本，签名使用同一版本，密钥轮换后需要重启
+	version int
+}
+
+func NewVaultClient(conf *KMSConfig) (*VaultClient, error) {
+	if conf.Address == "" || conf.Key == "" {
+		return nil, fmt.Errorf("kms address and key are required")
+	}
+	token := conf.Token
+	if conf.TokenPath != "" {
+		if token != "" {
+			return nil, fmt.Errorf("kms token and tokenPath are exclusive")
+		}
+		raw, err := ioutil.ReadFile(conf.TokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kms token: %v", err)
+		}
+		token = strings.TrimSpace(string(raw))
+	}
+	mount := conf.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+	timeout := 10 * time.Second
+	if conf.TimeoutMs > 0 {
+		timeout = time.Duration(conf.TimeoutMs) * time.Millisecond
+	}
+	return &VaultClient{
+		address: strings.TrimRight(conf.Address, "/"),
+		mount:   strings.Trim(mount, "/"),
+		key:     conf.Key,
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (c *VaultClient) do(method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, c.address+"/v1/"+c.mount+"/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(raw, &e) == nil && len(e.Errors) > 0 {
+			return fmt.Errorf("vault returns %d: %s", resp.StatusCode, strings.Join(e.Errors, "; "))
+		}
+		return fmt.Errorf("vault returns %d", resp.StatusCode)
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// 读取最新版本的公钥
+func (c *VaultClient) GetPublicKey() ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := c.do(http.MethodGet, "keys/"+url.PathEscape(c.key), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Type != "ecdsa-p256" {
+		return nil, fmt.Errorf("unsupported vault key type %s", resp.Data.Type)
+	}
+	key, ok := resp.Data.Keys[strconv.Itoa(resp.Data.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("vault key version %d not found", resp.Data.LatestVersion)
+	}
+	c.version = resp.Data.LatestVersion
+	block, _ := pem.Decode([]byte(key.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode vault public key")
+	}
+	return block.Bytes, nil
+}
+
+func (c *VaultClient) Sign(digest []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	req := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"marshaling_algorithm": "asn1",
+	}
+	if c.version > 0 {
+		req["key_version"] = c.version
+	}
+	if err := c.do(http.MethodPost, "sign/"+url.PathEscape(c.key)+"/sha2-256", req, &resp); err != nil {
+		return nil, err
+	}
+	// vault:v<版本>:<base64签名>
+	parts := strings.Split(resp.Data.Signature, ":")
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("invalid vault signature %s", resp.Data.Signature)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
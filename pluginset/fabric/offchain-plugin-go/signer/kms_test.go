@@ -0,0 +1,86 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 模拟Vault transit引擎的keys和sign接口
+func fakeVault(t *testing.T, priv *ecdsa.PrivateKey) *httptest.Server {
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pub := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/relayer":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"type":           "ecdsa-p256",
+				"latest_version": 2,
+				"keys":           map[string]interface{}{"2": map[string]string{"public_key": pub}},
+			}})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/sign/relayer/sha2-256":
+			var req struct {
+				Input      string `json:"input"`
+				Prehashed  bool   `json:"prehashed"`
+				Marshaling string `json:"marshaling_algorithm"`
+				KeyVersion int    `json:"key_version"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			digest, err := base64.StdEncoding.DecodeString(req.Input)
+			if err != nil || !req.Prehashed || req.Marshaling != "asn1" || req.KeyVersion != 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors":["bad request"]}`)
+				return
+			}
+			sig, _ := ecdsa.SignASN1(rand.Reader, priv, digest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{
+				"signature": "vault:v2:" + base64.StdEncoding.EncodeToString(sig),
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+}
+
+func TestVaultSigner(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := fakeVault(t, priv)
+	defer server.Close()
+
+	s, err := New(&Config{Type: "kms", KMS: &KMSConfig{Provider: "vault", Address: server.URL + "/", Token: "s.token", Key: "relayer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePublicKey(s.Public(), &priv.PublicKey) {
+		t.Fatal("unexpected public key")
+	}
+	sig, err := Sign(s, []byte("proposal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(&priv.PublicKey, []byte("proposal"), sig); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(&Config{Type: "kms", KMS: &KMSConfig{Address: server.URL, Token: "other", Key: "relayer"}}); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := New(&Config{Type: "kms", KMS: &KMSConfig{Address: server.URL, Token: "s.token", Key: "missing"}}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+//go:build pkcs11
+// +build pkcs11
+
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// HSM中的ECDSA P-256密钥，按token标签找到slot，按密钥标签或CKA_ID找到私钥和对应的公钥，
+// 使用CKM_ECDSA对摘要签名。一个Signer持有一个登录的会话，签名串行执行。
+type pkcs11Signer struct {
+	library string
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	pub     *ecdsa.PublicKey
+
+	mu sync.Mutex
+}
+
+var oidCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+// C_Initialize和C_Finalize对整个进程生效，重新连接时新旧Signer同时存在，
+// 同一个库共享一个Ctx，最后一个Signer关闭时才Finalize
+type module struct {
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = make(map[string]*module)
+)
+
+func openModule(library string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if m, ok := modules[library]; ok {
+		m.refs++
+		return m.ctx, nil
+	}
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load pkcs11 library %s", library)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize pkcs11: %v", err)
+	}
+	modules[library] = &module{ctx: ctx, refs: 1}
+	return ctx, nil
+}
+
+func closeModule(library string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	m, ok := modules[library]
+	if !ok {
+		return
+	}
+	if m.refs--; m.refs > 0 {
+		return
+	}
+	delete(modules, library)
+	m.ctx.Finalize()
+	m.ctx.Destroy()
+}
+
+func NewPKCS11Signer(conf *PKCS11Config) (Signer, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := openModule(conf.Library)
+	if err != nil {
+		return nil, err
+	}
+	s := &pkcs11Signer{library: conf.Library, ctx: ctx}
+	if err := s.open(conf); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *pkcs11Signer) open(conf *PKCS11Config) error {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return fmt.Errorf("failed to list pkcs11 slots: %v", err)
+	}
+	slot, found := uint(0), false
+	for _, id := range slots {
+		info, err := s.ctx.GetTokenInfo(id)
+		if err == nil && info.Label == conf.TokenLabel {
+			slot, found = id, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("pkcs11 token %s not found", conf.TokenLabel)
+	}
+	if s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		return fmt.Errorf("failed to open pkcs11 session: %v", err)
+	}
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, conf.Pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return fmt.Errorf("failed to login pkcs11 token: %v", err)
+	}
+
+	template := func(class uint) []*pkcs11.Attribute {
+		attrs := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class), pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC)}
+		if conf.KeyLabel != "" {
+			attrs = append(attrs, pkcs11.NewAttribute(pkcs11.CKA_LABEL, conf.KeyLabel))
+		}
+		if conf.KeyID != "" {
+			id, _ := hex.DecodeString(conf.KeyID)
+			attrs = append(attrs, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+		}
+		return attrs
+	}
+	if s.key, err = s.findObject(template(pkcs11.CKO_PRIVATE_KEY)); err != nil {
+		return fmt.Errorf("private key: %v", err)
+	}
+	pubKey, err := s.findObject(template(pkcs11.CKO_PUBLIC_KEY))
+	if err != nil {
+		return fmt.Errorf("public key: %v", err)
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.session, pubKey, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %v", err)
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(attrs[0].Value, &curve); err != nil || !curve.Equal(oidCurveP256) {
+		return fmt.Errorf("unsupported pkcs11 key curve %v", curve)
+	}
+	s.pub, err = parseECPoint(attrs[1].Value)
+	return err
+}
+
+// 查找唯一的对象
+func (s *pkcs11Signer) findObject(template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(objects) != 1 {
+		return 0, fmt.Errorf("found %d keys", len(objects))
+	}
+	return objects[0], nil
+}
+
+// CKA_EC_POINT一般为DER编码的OCTET STRING，部分HSM直接返回未压缩的点
+func parseECPoint(raw []byte) (*ecdsa.PublicKey, error) {
+	point := raw
+	var octets []byte
+	if rest, err := asn1.Unmarshal(raw, &octets); err == nil && len(rest) == 0 {
+		point = octets
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), point)
+	if x == nil {
+		return nil, fmt.Errorf("invalid pkcs11 ec point")
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *pkcs11Signer) SignDigest(digest []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, fmt.Errorf("pkcs11 sign init failed: %v", err)
+	}
+	raw, err := s.ctx.Sign(s.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 sign failed: %v", err)
+	}
+	// r || s
+	if len(raw) != 64 {
+		return nil, fmt.Errorf("unexpected pkcs11 signature length %d", len(raw))
+	}
+	return asn1.Marshal(ecdsaSignature{R: new(big.Int).SetBytes(raw[:32]), S: new(big.Int).SetBytes(raw[32:])})
+}
+
+func (s *pkcs11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil
+	}
+	// 登录状态由同一token的所有会话共享，关闭会话即可，最后一个会话关闭时token自动登出
+	if s.session != 0 {
+		s.ctx.CloseSession(s.session)
+		s.session = 0
+	}
+	closeModule(s.library)
+	s.ctx = nil
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package signer
+
+import "fmt"
+
+// 默认构建不依赖cgo，HSM密钥需要以pkcs11标签构建
+func NewPKCS11Signer(conf *PKCS11Config) (Signer, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("pkcs11 signer is not supported, build with -tags pkcs11")
+}
@@ -0,0 +1,187 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// 签名密钥的管理
+// 中继提交交易的Fabric身份和委员会节点都通过Signer签名，私钥可以是PEM文件、HSM(PKCS#11)或KMS中的密钥，
+// 插件只持有公钥和签名接口。算法由公钥决定：P-256为SHA-256加ECDSA，SM2曲线为SM3加SM2。
+// 签名均为ASN.1编码的(r, s)，ECDSA签名规整为low-S，与Fabric的要求一致。
+
+type Algorithm string
+
+const (
+	ECDSA_P256_SHA256 Algorithm = "ECDSA_P256_SHA256"
+	SM2_SM3           Algorithm = "SM2_SM3"
+)
+
+type Signer interface {
+	// *ecdsa.PublicKey(P-256)或*SM2PublicKey
+	Public() crypto.PublicKey
+	// 对摘要签名，ECDSA的摘要为SHA-256，SM2的摘要为SM3(Z_A || M)，摘要由Digest计算
+	SignDigest(digest []byte) ([]byte, error)
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func AlgorithmOf(pub crypto.PublicKey) (Algorithm, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return ECDSA_P256_SHA256, nil
+		}
+	case *SM2PublicKey:
+		return SM2_SM3, nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// 按公钥的算法计算消息的摘要，SM2使用默认用户标识
+func Digest(pub crypto.PublicKey, msg []byte) ([]byte, error) {
+	algo, err := AlgorithmOf(pub)
+	if err != nil {
+		return nil, err
+	}
+	if algo == SM2_SM3 {
+		return pub.(*SM2PublicKey).Digest(SM2_DEFAULT_UID, msg), nil
+	}
+	digest := sha256.Sum256(msg)
+	return digest[:], nil
+}
+
+// 对消息签名
+func Sign(s Signer, msg []byte) ([]byte, error) {
+	digest, err := Digest(s.Public(), msg)
+	if err != nil {
+		return nil, err
+	}
+	return SignDigest(s, digest)
+}
+
+// 对摘要签名，ECDSA签名规整为low-S
+func SignDigest(s Signer, digest []byte) ([]byte, error) {
+	sig, err := s.SignDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := s.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return sig, nil
+	}
+	var rs ecdsaSignature
+	if rest, err := asn1.Unmarshal(sig, &rs); err != nil || len(rest) != 0 || rs.R == nil || rs.S == nil {
+		return nil, fmt.Errorf("invalid ecdsa signature from signer")
+	}
+	n := pub.Curve.Params().N
+	if rs.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		rs.S.Sub(n, rs.S)
+		return asn1.Marshal(rs)
+	}
+	return sig, nil
+}
+
+// 校验对消息的签名
+func Verify(pub crypto.PublicKey, msg, sig []byte) error {
+	digest, err := Digest(pub, msg)
+	if err != nil {
+		return err
+	}
+	var rs ecdsaSignature
+	if rest, err := asn1.Unmarshal(sig, &rs); err != nil || len(rest) != 0 || rs.R == nil || rs.S == nil {
+		return fmt.Errorf("invalid signature")
+	}
+	ok := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.Verify(k, digest, rs.R, rs.S)
+	case *SM2PublicKey:
+		ok = k.VerifyDigest(digest, rs.R, rs.S)
+	}
+	if !ok {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// 签名密钥的配置
+type Config struct {
+	// file(默认)、pkcs11或kms
+	Type string `json:"type"`
+	// file: PEM格式的私钥或私钥文件的路径
+	Key     string `json:"key,omitempty"`
+	KeyPath string `json:"keyPath,omitempty"`
+	// pkcs11: HSM中的密钥，需要以pkcs11标签构建
+	PKCS11 *PKCS11Config `json:"pkcs11,omitempty"`
+	// kms: KMS中的密钥
+	KMS *KMSConfig `json:"kms,omitempty"`
+}
+
+// HSM中的ECDSA P-256密钥
+type PKCS11Config struct {
+	// PKCS#11库的路径，如/usr/lib/softhsm/libsofthsm2.so
+	Library    string `json:"library"`
+	TokenLabel string `json:"tokenLabel"`
+	Pin        string `json:"pin"`
+	// 按标签或十六进制的CKA_ID查找密钥，至少指定一个
+	KeyLabel string `json:"keyLabel,omitempty"`
+	KeyID    string `json:"keyId,omitempty"`
+}
+
+func (c *PKCS11Config) validate() error {
+	if c.Library == "" || c.TokenLabel == "" {
+		return fmt.Errorf("pkcs11 library and tokenLabel are required")
+	}
+	if c.KeyLabel == "" && c.KeyID == "" {
+		return fmt.Errorf("pkcs11 keyLabel or keyId is required")
+	}
+	if _, err := hex.DecodeString(c.KeyID); err != nil {
+		return fmt.Errorf("invalid pkcs11 keyId: %v", err)
+	}
+	return nil
+}
+
+func New(conf *Config) (Signer, error) {
+	switch conf.Type {
+	case "", "file":
+		if conf.Key != "" && conf.KeyPath != "" {
+			return nil, fmt.Errorf("key and keyPath are exclusive")
+		}
+		if conf.KeyPath != "" {
+			return LoadKeyFile(conf.KeyPath)
+		}
+		if conf.Key == "" {
+			return nil, fmt.Errorf("key or keyPath is required")
+		}
+		return ParsePrivateKeyPEM([]byte(conf.Key))
+	case "pkcs11":
+		if conf.PKCS11 == nil {
+			return nil, fmt.Errorf("pkcs11 config is required")
+		}
+		return NewPKCS11Signer(conf.PKCS11)
+	case "kms":
+		if conf.KMS == nil {
+			return nil, fmt.Errorf("kms config is required")
+		}
+		return NewKMSSigner(conf.KMS)
+	}
+	return nil, fmt.Errorf("unknown signer type %s", conf.Type)
+}
+
+// 释放HSM会话等资源
+func Close(s Signer) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
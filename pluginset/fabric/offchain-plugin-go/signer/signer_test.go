@@ -0,0 +1,197 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSM3(t *testing.T) {
+	for _, c := range []struct{ in, out string }{
+		// GB/T 32905-2016 附录A
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	} {
+		if sum := sm3Sum([]byte(c.in)); hex.EncodeToString(sum[:]) != c.out {
+			t.Fatalf("sm3(%s) = %x", c.in, sum)
+		}
+	}
+}
+
+// GB/T 32918.2-2016 附录A 数字签名示例
+func TestSM2Vector(t *testing.T) {
+	priv, err := NewSM2PrivateKey(hexInt("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.X.Cmp(hexInt("09F9DF311E5421A150DD7D161E4BC5C672179FAD1833FC076BB08FF356F35020")) != 0 ||
+		priv.Y.Cmp(hexInt("CCEA490CE26775A52DC6EA718CC1AA600AED05FBF35E084A6632F6072DA9AD13")) != 0 {
+		t.Fatal("unexpected public key")
+	}
+	r := hexInt("F5A03B0648D2C4630EEAC513E1BB81A15944DA3827D5B74143AC7EACEEE720B3")
+	s := hexInt("B1B6AA29DF212FD8763182BC0D421CA1BB9038FD1F7F42D4840B69C485BBC1AA")
+	digest := priv.Digest(SM2_DEFAULT_UID, []byte("message digest"))
+	if !priv.VerifyDigest(digest, r, s) {
+		t.Fatal("valid sm2 signature rejected")
+	}
+	if priv.VerifyDigest(priv.Digest(SM2_DEFAULT_UID, []byte("message digesT")), r, s) {
+		t.Fatal("sm2 signature of other message accepted")
+	}
+	r, s, err = priv.SignDigest(rand.Reader, digest)
+	if err != nil || !priv.VerifyDigest(digest, r, s) {
+		t.Fatalf("sm2 signature rejected: %v", err)
+	}
+}
+
+func TestKeyPEM(t *testing.T) {
+	msg := []byte("endorse body")
+	for _, algo := range []Algorithm{ECDSA_P256_SHA256, SM2_SM3} {
+		key, err := Generate(algo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := MarshalPrivateKeyPEM(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParsePrivateKeyPEM([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a, _ := AlgorithmOf(parsed.Public()); a != algo || !SamePublicKey(parsed.Public(), key.Public()) {
+			t.Fatalf("%s: unexpected key", algo)
+		}
+		pubPEM, err := MarshalPublicKeyPEM(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := ParsePublicKeyPEM(pubPEM)
+		if err != nil || !SamePublicKey(pub, key.Public()) {
+			t.Fatalf("%s: unexpected public key: %v", algo, err)
+		}
+		sig, err := Sign(parsed, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(pub, msg, sig); err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		if err := Verify(pub, []byte("other"), sig); err == nil {
+			t.Fatalf("%s: signature of other message accepted", algo)
+		}
+	}
+
+	// PKCS#8编码的SM2私钥
+	priv, _ := GenerateSM2Key(rand.Reader)
+	params, _ := asn1.Marshal(oidCurveSM2)
+	inner, _ := asn1.Marshal(ecPrivateKey{Version: 1, PrivateKey: priv.D.FillBytes(make([]byte, 32))})
+	p8 := pkcs8{PrivateKey: inner}
+	p8.Algorithm.Algorithm = oidPublicKeyECDSA
+	p8.Algorithm.Parameters.FullBytes = params
+	der, _ := asn1.Marshal(p8)
+	key, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil || !SamePublicKey(key.Public(), &priv.SM2PublicKey) {
+		t.Fatalf("unexpected sm2 key: %v", err)
+	}
+
+	// 只支持P-256
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ = x509.MarshalPKCS8PrivateKey(p384)
+	if _, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err == nil {
+		t.Fatal("p384 key accepted")
+	}
+	der, _ = x509.MarshalPKIXPublicKey(&p384.PublicKey)
+	if _, err := ParsePublicKeyPEM(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))); err == nil {
+		t.Fatal("p384 public key accepted")
+	}
+	if _, err := ParsePrivateKeyPEM([]byte("not a key")); err == nil {
+		t.Fatal("invalid pem accepted")
+	}
+}
+
+// 返回high-S签名的Signer
+type highSSigner struct {
+	Signer
+}
+
+func (s *highSSigner) SignDigest(digest []byte) ([]byte, error) {
+	sig, err := s.Signer.SignDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	var rs ecdsaSignature
+	asn1.Unmarshal(sig, &rs)
+	n := elliptic.P256().Params().N
+	if rs.S.Cmp(new(big.Int).Rsh(n, 1)) <= 0 {
+		rs.S.Sub(n, rs.S)
+	}
+	return asn1.Marshal(rs)
+}
+
+func TestLowS(t *testing.T) {
+	key, _ := Generate(ECDSA_P256_SHA256)
+	half := new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+	for i := 0; i < 8; i++ {
+		sig, err := Sign(&highSSigner{key}, []byte("proposal"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rs ecdsaSignature
+		if _, err := asn1.Unmarshal(sig, &rs); err != nil || rs.S.Cmp(half) > 0 {
+			t.Fatalf("high s signature: %x", sig)
+		}
+		if err := Verify(key.Public(), []byte("proposal"), sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	key, _ := Generate(SM2_SM3)
+	raw, _ := MarshalPrivateKeyPEM(key)
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(path, []byte(raw), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, conf := range []*Config{{Key: raw}, {Type: "file", KeyPath: path}} {
+		s, err := New(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !SamePublicKey(s.Public(), key.Public()) {
+			t.Fatalf("unexpected key of %+v", conf)
+		}
+	}
+	for _, c := range []struct {
+		conf *Config
+		err  string
+	}{
+		{&Config{Key: raw, KeyPath: path}, "exclusive"},
+		{&Config{}, "required"},
+		{&Config{Type: "pkcs11"}, "pkcs11 config is required"},
+		{&Config{Type: "pkcs11", PKCS11: &PKCS11Config{Library: "lib.so", TokenLabel: "token"}}, "keyLabel or keyId"},
+		{&Config{Type: "pkcs11", PKCS11: &PKCS11Config{Library: "lib.so", TokenLabel: "token", KeyID: "xyz"}}, "invalid pkcs11 keyId"},
+		{&Config{Type: "kms", KMS: &KMSConfig{Provider: "other"}}, "unknown kms provider"},
+		{&Config{Type: "hsm"}, "unknown signer type"},
+	} {
+		if _, err := New(c.conf); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%+v: unexpected error: %v", c.conf, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package signer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// SM2数字签名(GB/T 32918.2-2016)
+// 曲线运算与链码sigverify包相同，使用big.Int的仿射坐标，不是常数时间实现，文件中的SM2密钥不应与其他服务共用进程。
+// 签名对 e = SM3(Z_A || M) 计算，委员会签名使用默认用户标识，与链码验签一致。
+
+var SM2_DEFAULT_UID = []byte("1234567812345678")
+
+type sm2Curve struct {
+	P, N, A, B *big.Int
+	Gx, Gy     *big.Int
+}
+
+func hexInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid curve parameter " + s)
+	}
+	return v
+}
+
+// SM2推荐曲线(GB/T 32918.5-2017)
+var sm2P256V1 = &sm2Curve{
+	P:  hexInt("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF"),
+	N:  hexInt("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123"),
+	A:  hexInt("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFC"),
+	B:  hexInt("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93"),
+	Gx: hexInt("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7"),
+	Gy: hexInt("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0"),
+}
+
+func (c *sm2Curve) isOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 || y.Sign() < 0 || y.Cmp(c.P) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, c.P)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.A)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.B)
+	rhs.Mod(rhs, c.P)
+	return lhs.Cmp(rhs) == 0
+}
+
+// 无穷远点用nil表示
+func (c *sm2Curve) add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+	var num, den *big.Int
+	if x1.Cmp(x2) == 0 {
+		if sum := new(big.Int).Add(y1, y2); sum.Mod(sum, c.P).Sign() == 0 {
+			return nil, nil
+		}
+		// (3x^2 + a) / 2y
+		num = new(big.Int).Mul(x1, x1)
+		num.Mul(num, big.NewInt(3))
+		num.Add(num, c.A)
+		den = new(big.Int).Lsh(y1, 1)
+	} else {
+		// (y2 - y1) / (x2 - x1)
+		num = new(big.Int).Sub(y2, y1)
+		den = new(big.Int).Sub(x2, x1)
+	}
+	den.Mod(den, c.P)
+	lambda := num.Mul(num, new(big.Int).ModInverse(den, c.P))
+	lambda.Mod(lambda, c.P)
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, c.P)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, c.P)
+	return x3, y3
+}
+
+func (c *sm2Curve) scalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	var rx, ry *big.Int
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			rx, ry = c.add(rx, ry, rx, ry)
+			if b>>uint(i)&1 == 1 {
+				rx, ry = c.add(rx, ry, x, y)
+			}
+		}
+	}
+	return rx, ry
+}
+
+func (c *sm2Curve) scalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.scalarMult(c.Gx, c.Gy, k)
+}
+
+type SM2PublicKey struct {
+	X, Y *big.Int
+}
+
+type SM2PrivateKey struct {
+	SM2PublicKey
+	D *big.Int
+}
+
+// 私钥d的范围为[1, n-2]
+func NewSM2PrivateKey(d *big.Int) (*SM2PrivateKey, error) {
+	c := sm2P256V1
+	if d.Sign() <= 0 || d.Cmp(new(big.Int).Sub(c.N, big.NewInt(1))) >= 0 {
+		return nil, fmt.Errorf("invalid sm2 private key")
+	}
+	x, y := c.scalarBaseMult(d.Bytes())
+	return &SM2PrivateKey{SM2PublicKey: SM2PublicKey{X: x, Y: y}, D: new(big.Int).Set(d)}, nil
+}
+
+func GenerateSM2Key(random io.Reader) (*SM2PrivateKey, error) {
+	d, err := randScalar(random, new(big.Int).Sub(sm2P256V1.N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return NewSM2PrivateKey(d)
+}
+
+// [1, max)中的随机数
+func randScalar(random io.Reader, max *big.Int) (*big.Int, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	k, err := rand.Int(random, new(big.Int).Sub(max, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// 解析未压缩的公钥 04||X||Y
+func unmarshalSM2PublicKey(data []byte) (*SM2PublicKey, bool) {
+	if len(data) != 65 || data[0] != 4 {
+		return nil, false
+	}
+	x := new(big.Int).SetBytes(data[1:33])
+	y := new(big.Int).SetBytes(data[33:])
+	if !sm2P256V1.isOnCurve(x, y) {
+		return nil, false
+	}
+	return &SM2PublicKey{X: x, Y: y}, true
+}
+
+func (k *SM2PublicKey) marshal() []byte {
+	out := make([]byte, 65)
+	out[0] = 4
+	k.X.FillBytes(out[1:33])
+	k.Y.FillBytes(out[33:])
+	return out
+}
+
+// Z_A = SM3(ENTL_A || ID_A || a || b || x_G || y_G || x_A || y_A)
+func (k *SM2PublicKey) za(uid []byte) [SM3_SIZE]byte {
+	c := sm2P256V1
+	d := newSM3()
+	bitLen := len(uid) * 8
+	d.Write([]byte{byte(bitLen >> 8), byte(bitLen)})
+	d.Write(uid)
+	buf := make([]byte, 32)
+	for _, v := range []*big.Int{c.A, c.B, c.Gx, c.Gy, k.X, k.Y} {
+		v.FillBytes(buf)
+		d.Write(buf)
+	}
+	var za [SM3_SIZE]byte
+	copy(za[:], d.Sum(nil))
+	return za
+}
+
+// 消息的摘要 e = SM3(Z_A || M)
+func (k *SM2PublicKey) Digest(uid, msg []byte) []byte {
+	za := k.za(uid)
+	e := sm3Sum(append(za[:], msg...))
+	return e[:]
+}
+
+// 对摘要e签名，返回(r, s)
+func (k *SM2PrivateKey) SignDigest(random io.Reader, digest []byte) (*big.Int, *big.Int, error) {
+	c := sm2P256V1
+	e := new(big.Int).SetBytes(digest)
+	inv := new(big.Int).ModInverse(new(big.Int).Add(k.D, big.NewInt(1)), c.N)
+	for {
+		rk, err := randScalar(random, c.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		x1, _ := c.scalarBaseMult(rk.Bytes())
+		// r = e + x1, s = (1+d)^-1 * (k - r*d)
+		r := new(big.Int).Add(e, x1)
+		r.Mod(r, c.N)
+		if r.Sign() == 0 || new(big.Int).Add(r, rk).Cmp(c.N) == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, k.D)
+		s.Sub(rk, s).Mul(s, inv).Mod(s, c.N)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s, nil
+	}
+}
+
+func inScalarRange(v, n *big.Int) bool {
+	return v.Sign() > 0 && v.Cmp(n) < 0
+}
+
+// 对摘要e的签名(r, s)验签
+func (k *SM2PublicKey) VerifyDigest(digest []byte, r, s *big.Int) bool {
+	c := sm2P256V1
+	if !inScalarRange(r, c.N) || !inScalarRange(s, c.N) {
+		return false
+	}
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, c.N)
+	if t.Sign() == 0 {
+		return false
+	}
+	x1, y1 := c.scalarBaseMult(s.Bytes())
+	x2, y2 := c.scalarMult(k.X, k.Y, t.Bytes())
+	x, _ := c.add(x1, y1, x2, y2)
+	if x == nil {
+		return false
+	}
+	rr := new(big.Int).SetBytes(digest)
+	rr.Add(rr, x)
+	rr.Mod(rr, c.N)
+	return rr.Cmp(r) == 0
+}
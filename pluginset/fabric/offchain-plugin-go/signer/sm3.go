@@ -0,0 +1,121 @@
+package signer
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// SM3杂凑算法(GB/T 32905-2016)
+const (
+	SM3_SIZE       = 32
+	SM3_BLOCK_SIZE = 64
+)
+
+var sm3IV = [8]uint32{
+	0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+	0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+}
+
+type sm3Digest struct {
+	v   [8]uint32
+	buf [SM3_BLOCK_SIZE]byte
+	n   int
+	len uint64
+}
+
+func newSM3() hash.Hash {
+	d := &sm3Digest{}
+	d.Reset()
+	return d
+}
+
+func sm3Sum(data []byte) [SM3_SIZE]byte {
+	var out [SM3_SIZE]byte
+	d := newSM3()
+	d.Write(data)
+	copy(out[:], d.Sum(nil))
+	return out
+}
+
+func (d *sm3Digest) Size() int      { return SM3_SIZE }
+func (d *sm3Digest) BlockSize() int { return SM3_BLOCK_SIZE }
+
+func (d *sm3Digest) Reset() {
+	d.v, d.n, d.len = sm3IV, 0, 0
+}
+
+func (d *sm3Digest) Write(p []byte) (int, error) {
+	written := len(p)
+	d.len += uint64(written)
+	if d.n > 0 {
+		c := copy(d.buf[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n < SM3_BLOCK_SIZE {
+			return written, nil
+		}
+		d.compress(d.buf[:])
+		d.n = 0
+	}
+	for len(p) >= SM3_BLOCK_SIZE {
+		d.compress(p[:SM3_BLOCK_SIZE])
+		p = p[SM3_BLOCK_SIZE:]
+	}
+	d.n = copy(d.buf[:], p)
+	return written, nil
+}
+
+func (d *sm3Digest) Sum(in []byte) []byte {
+	// 在副本上填充，不影响后续写入
+	c := *d
+	var pad [SM3_BLOCK_SIZE + 8]byte
+	pad[0] = 0x80
+	padLen := SM3_BLOCK_SIZE - (c.n+8)%SM3_BLOCK_SIZE
+	binary.BigEndian.PutUint64(pad[padLen:], c.len*8)
+	c.Write(pad[:padLen+8])
+
+	var out [SM3_SIZE]byte
+	for i, v := range c.v {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return append(in, out[:]...)
+}
+
+func sm3P0(x uint32) uint32 { return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17) }
+func sm3P1(x uint32) uint32 { return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23) }
+
+func (d *sm3Digest) compress(block []byte) {
+	var w [68]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[i*4:])
+	}
+	for j := 16; j < 68; j++ {
+		w[j] = sm3P1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, dd, e, f, g, h := d.v[0], d.v[1], d.v[2], d.v[3], d.v[4], d.v[5], d.v[6], d.v[7]
+	for j := 0; j < 64; j++ {
+		var t, ff, gg uint32
+		if j < 16 {
+			t, ff, gg = 0x79cc4519, a^b^c, e^f^g
+		} else {
+			t, ff, gg = 0x7a879d8a, (a&b)|(a&c)|(b&c), (e&f)|(^e&g)
+		}
+		a12 := bits.RotateLeft32(a, 12)
+		ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ a12
+		tt1 := ff + dd + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + h + ss1 + w[j]
+		dd, c, b, a = c, bits.RotateLeft32(b, 9), a, tt1
+		h, g, f, e = g, bits.RotateLeft32(f, 19), e, sm3P0(tt2)
+	}
+	d.v[0] ^= a
+	d.v[1] ^= b
+	d.v[2] ^= c
+	d.v[3] ^= dd
+	d.v[4] ^= e
+	d.v[5] ^= f
+	d.v[6] ^= g
+	d.v[7] ^= h
+}
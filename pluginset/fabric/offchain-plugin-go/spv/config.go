@@ -0,0 +1,218 @@
+package spv
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 通道配置
+// 验证区块只需要通道配置中orderer组织的MSP和BlockValidation策略。策略只支持configtxgen默认的
+// ImplicitMeta策略，按规则换算为需要签名的orderer组织数，组织内任一成员的签名都计入。
+
+const (
+	ORDERER_GROUP           = "Orderer"
+	MSP_KEY                 = "MSP"
+	BLOCK_VALIDATION_POLICY = "BlockValidation"
+)
+
+type ordererOrg struct {
+	mspID         string
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+}
+
+type ChannelConfig struct {
+	ChannelID string
+	// 配置所在区块的高度，区块的LastConfig指向该高度时使用该配置验证
+	Height   uint64
+	Sequence uint64
+	// 区块需要来自多少个orderer组织的签名
+	Threshold int
+	orgs      map[string]*ordererOrg
+	// 配置区块的证明，从配置区块解析的为空
+	proof *fabric.BlockProof
+}
+
+// 配置所在配置区块的证明
+func (c *ChannelConfig) Proof() *fabric.BlockProof {
+	return c.proof
+}
+
+// orderer组织的MSP ID
+func (c *ChannelConfig) OrdererOrgs() []string {
+	var orgs []string
+	for id := range c.orgs {
+		orgs = append(orgs, id)
+	}
+	sort.Strings(orgs)
+	return orgs
+}
+
+func DecodeConfigBlock(raw []byte) (*ChannelConfig, error) {
+	block := &common.Block{}
+	if err := proto.Unmarshal(raw, block); err != nil {
+		return nil, fmt.Errorf("invalid config block: %v", err)
+	}
+	return ParseConfigBlock(block)
+}
+
+// 从配置区块中读取通道配置
+func ParseConfigBlock(block *common.Block) (*ChannelConfig, error) {
+	if block.Header == nil || block.Data == nil || len(block.Data.Data) != 1 {
+		return nil, fmt.Errorf("invalid config block")
+	}
+	chdr, payload, err := channelHeader(block.Data.Data[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid config block %d: %v", block.Header.Number, err)
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_CONFIG {
+		return nil, fmt.Errorf("block %d is not a config block", block.Header.Number)
+	}
+	env := &common.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.Data, env); err != nil {
+		return nil, fmt.Errorf("invalid config envelope in block %d: %v", block.Header.Number, err)
+	}
+	if env.Config == nil {
+		return nil, fmt.Errorf("no config in block %d", block.Header.Number)
+	}
+	conf, err := ParseConfig(chdr.ChannelId, env.Config)
+	if err != nil {
+		return nil, err
+	}
+	conf.Height = block.Header.Number
+	return conf, nil
+}
+
+func configFromProof(proof *fabric.BlockProof) (*ChannelConfig, error) {
+	conf, err := ParseConfigBlock(&common.Block{Header: &common.BlockHeader{Number: proof.Header.Number}, Data: &common.BlockData{Data: proof.Data}})
+	if err != nil {
+		return nil, err
+	}
+	conf.proof = proof
+	return conf, nil
+}
+
+func ParseConfig(channelID string, config *common.Config) (*ChannelConfig, error) {
+	if config.ChannelGroup == nil || config.ChannelGroup.Groups[ORDERER_GROUP] == nil {
+		return nil, fmt.Errorf("no orderer group in config of channel %s", channelID)
+	}
+	orderer := config.ChannelGroup.Groups[ORDERER_GROUP]
+	conf := &ChannelConfig{ChannelID: channelID, Sequence: config.Sequence, orgs: make(map[string]*ordererOrg)}
+	for name, group := range orderer.Groups {
+		org, err := parseOrdererOrg(group)
+		if err != nil {
+			return nil, fmt.Errorf("invalid orderer org %s: %v", name, err)
+		}
+		conf.orgs[org.mspID] = org
+	}
+	if len(conf.orgs) == 0 {
+		return nil, fmt.Errorf("no orderer org in config of channel %s", channelID)
+	}
+	threshold, err := blockValidationThreshold(orderer)
+	if err != nil {
+		return nil, err
+	}
+	conf.Threshold = threshold
+	return conf, nil
+}
+
+func parseOrdererOrg(group *common.ConfigGroup) (*ordererOrg, error) {
+	value := group.Values[MSP_KEY]
+	if value == nil {
+		return nil, fmt.Errorf("no msp")
+	}
+	mspConf := &msp.MSPConfig{}
+	if err := proto.Unmarshal(value.Value, mspConf); err != nil {
+		return nil, err
+	}
+	// 0为X.509的FABRIC类型
+	if mspConf.Type != 0 {
+		return nil, fmt.Errorf("unsupported msp type %d", mspConf.Type)
+	}
+	fabricConf := &msp.FabricMSPConfig{}
+	if err := proto.Unmarshal(mspConf.Config, fabricConf); err != nil {
+		return nil, err
+	}
+	if len(fabricConf.RootCerts) == 0 {
+		return nil, fmt.Errorf("no root cert")
+	}
+	org := &ordererOrg{mspID: fabricConf.Name, roots: x509.NewCertPool(), intermediates: x509.NewCertPool()}
+	for _, raw := range fabricConf.RootCerts {
+		cert, err := parseCert(raw)
+		if err != nil {
+			return nil, err
+		}
+		org.roots.AddCert(cert)
+	}
+	for _, raw := range fabricConf.IntermediateCerts {
+		cert, err := parseCert(raw)
+		if err != nil {
+			return nil, err
+		}
+		org.intermediates.AddCert(cert)
+	}
+	return org, nil
+}
+
+func blockValidationThreshold(orderer *common.ConfigGroup) (int, error) {
+	policy := orderer.Policies[BLOCK_VALIDATION_POLICY]
+	if policy == nil || policy.Policy == nil {
+		return 0, fmt.Errorf("no %s policy", BLOCK_VALIDATION_POLICY)
+	}
+	if common.Policy_PolicyType(policy.Policy.Type) != common.Policy_IMPLICIT_META {
+		return 0, fmt.Errorf("unsupported %s policy type %d", BLOCK_VALIDATION_POLICY, policy.Policy.Type)
+	}
+	meta := &common.ImplicitMetaPolicy{}
+	if err := proto.Unmarshal(policy.Policy.Value, meta); err != nil {
+		return 0, fmt.Errorf("invalid %s policy: %v", BLOCK_VALIDATION_POLICY, err)
+	}
+	n := len(orderer.Groups)
+	switch meta.Rule {
+	case common.ImplicitMetaPolicy_ANY:
+		return 1, nil
+	case common.ImplicitMetaPolicy_ALL:
+		return n, nil
+	case common.ImplicitMetaPolicy_MAJORITY:
+		return n/2 + 1, nil
+	}
+	return 0, fmt.Errorf("unsupported %s policy rule %v", BLOCK_VALIDATION_POLICY, meta.Rule)
+}
+
+// MSP中的证书一般为PEM格式
+func parseCert(raw []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %v", err)
+	}
+	return cert, nil
+}
+
+func channelHeader(data []byte) (*common.ChannelHeader, *common.Payload, error) {
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(data, env); err != nil {
+		return nil, nil, err
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil, nil, err
+	}
+	if payload.Header == nil {
+		return nil, nil, fmt.Errorf("no payload header")
+	}
+	chdr := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, chdr); err != nil {
+		return nil, nil, err
+	}
+	return chdr, payload, nil
+}
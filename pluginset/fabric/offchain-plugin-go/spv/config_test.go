@@ -0,0 +1,151 @@
+package spv
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+func mustMarshal(m proto.Message) []byte {
+	raw, err := proto.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// 测试用的orderer组织，root为CA证书，signer为组织成员
+type testOrg struct {
+	mspID string
+	root  []byte
+	key   *ecdsa.PrivateKey
+	cert  []byte
+}
+
+func newCert(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) []byte {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		panic(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestOrg(mspID string) *testOrg {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca." + mspID},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	org := &testOrg{mspID: mspID, root: newCert(ca, ca, &caKey.PublicKey, caKey)}
+	org.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	// 已过期的证书签名的历史区块仍然有效
+	org.cert = newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "orderer." + mspID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(-time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, ca, &org.key.PublicKey, caKey)
+	return org
+}
+
+func (o *testOrg) signatureHeader() []byte {
+	return mustMarshal(&common.SignatureHeader{Creator: mustMarshal(&msp.SerializedIdentity{Mspid: o.mspID, IdBytes: o.cert}), Nonce: []byte("nonce")})
+}
+
+// low-S的ECDSA签名
+func (o *testOrg) sign(msg []byte) []byte {
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, o.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	n := o.key.Curve.Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+	raw, _ := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	return raw
+}
+
+func newConfig(sequence uint64, rule common.ImplicitMetaPolicy_Rule, orgs ...*testOrg) *common.Config {
+	orderer := &common.ConfigGroup{
+		Groups: make(map[string]*common.ConfigGroup),
+		Policies: map[string]*common.ConfigPolicy{BLOCK_VALIDATION_POLICY: {Policy: &common.Policy{
+			Type:  int32(common.Policy_IMPLICIT_META),
+			Value: mustMarshal(&common.ImplicitMetaPolicy{SubPolicy: "Writers", Rule: rule}),
+		}}},
+	}
+	for _, org := range orgs {
+		fabricConf := &msp.FabricMSPConfig{Name: org.mspID, RootCerts: [][]byte{org.root}}
+		orderer.Groups[org.mspID+"Org"] = &common.ConfigGroup{Values: map[string]*common.ConfigValue{
+			MSP_KEY: {Value: mustMarshal(&msp.MSPConfig{Config: mustMarshal(fabricConf)})},
+		}}
+	}
+	return &common.Config{Sequence: sequence, ChannelGroup: &common.ConfigGroup{Groups: map[string]*common.ConfigGroup{ORDERER_GROUP: orderer}}}
+}
+
+func newConfigEnvelope(channel string, config *common.Config) []byte {
+	chdr := &common.ChannelHeader{Type: int32(common.HeaderType_CONFIG), ChannelId: channel}
+	payload := &common.Payload{Header: &common.Header{ChannelHeader: mustMarshal(chdr)}, Data: mustMarshal(&common.ConfigEnvelope{Config: config})}
+	return mustMarshal(&common.Envelope{Payload: mustMarshal(payload)})
+}
+
+func newConfigBlock(number uint64, channel string, config *common.Config) *common.Block {
+	return &common.Block{
+		Header: &common.BlockHeader{Number: number},
+		Data:   &common.BlockData{Data: [][]byte{newConfigEnvelope(channel, config)}},
+	}
+}
+
+func TestParseConfigBlock(t *testing.T) {
+	org1, org2, org3 := newTestOrg("Orderer1MSP"), newTestOrg("Orderer2MSP"), newTestOrg("Orderer3MSP")
+	for _, c := range []struct {
+		rule      common.ImplicitMetaPolicy_Rule
+		threshold int
+	}{{common.ImplicitMetaPolicy_ANY, 1}, {common.ImplicitMetaPolicy_MAJORITY, 2}, {common.ImplicitMetaPolicy_ALL, 3}} {
+		raw := mustMarshal(newConfigBlock(5, "mychannel", newConfig(3, c.rule, org1, org2, org3)))
+		conf, err := DecodeConfigBlock(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conf.ChannelID != "mychannel" || conf.Height != 5 || conf.Sequence != 3 || conf.Threshold != c.threshold {
+			t.Fatalf("unexpected config: %+v", conf)
+		}
+		if orgs := conf.OrdererOrgs(); strings.Join(orgs, ",") != "Orderer1MSP,Orderer2MSP,Orderer3MSP" {
+			t.Fatalf("unexpected orgs: %v", orgs)
+		}
+	}
+
+	config := newConfig(1, common.ImplicitMetaPolicy_ANY, org1)
+	config.ChannelGroup.Groups[ORDERER_GROUP].Policies[BLOCK_VALIDATION_POLICY].Policy.Type = int32(common.Policy_SIGNATURE)
+	if _, err := ParseConfigBlock(newConfigBlock(0, "mychannel", config)); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ParseConfigBlock(newConfigBlock(0, "mychannel", newConfig(1, common.ImplicitMetaPolicy_ANY))); err == nil {
+		t.Fatal("config without orderer org should be rejected")
+	}
+	block := newConfigBlock(0, "mychannel", config)
+	block.Data.Data = append(block.Data.Data, block.Data.Data[0])
+	if _, err := ParseConfigBlock(block); err == nil {
+		t.Fatal("block with multiple transactions is not a config block")
+	}
+}
@@ -0,0 +1,98 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/hcdvs"
+)
+
+// Fabric的HCDVS
+// 跨链消息的ProvableData.Proof为消息所在区块的BlockProof(BBC插件配置attachProof时填写)，TxHash为交易id的字节，
+// BlockHash为区块的dataHash。用消息所在区块的LastConfig对应的共识状态验证区块签名，再确认消息由跨链链码
+// 写入且交易校验通过。账本数据为交易的envelope。
+
+type FabricHCDVS struct {
+	// 跨链链码，只接受该链码写入的消息
+	chaincode string
+}
+
+var _ hcdvs.HCDVSService = (*FabricHCDVS)(nil)
+
+func NewFabricHCDVS(chaincode string) *FabricHCDVS {
+	return &FabricHCDVS{chaincode: chaincode}
+}
+
+func (v *FabricHCDVS) VerifyAnchorConsensusState(anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	_, _, err := StateConfig(anchor)
+	return hcdvs.ResultOf(err), nil
+}
+
+func (v *FabricHCDVS) VerifyConsensusState(state, anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	return hcdvs.ResultOf(verifyNextState(state, anchor)), nil
+}
+
+func (v *FabricHCDVS) VerifyCrossChainMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) (*hcdvs.VerifyResult, error) {
+	return hcdvs.ResultOf(v.verifyMessage(msg, anchor)), nil
+}
+
+func (v *FabricHCDVS) verifyMessage(msg *bbc.CrossChainMessage, anchor *consensus.ConsensusState) error {
+	if msg == nil || msg.ProvableData == nil {
+		return fmt.Errorf("no provable data")
+	}
+	if msg.Type != bbc.AUTH_MSG {
+		return fmt.Errorf("unsupported message type %s", msg.Type)
+	}
+	conf, _, err := StateConfig(anchor)
+	if err != nil {
+		return err
+	}
+	data := msg.ProvableData
+	if len(data.Proof) == 0 {
+		return fmt.Errorf("no block proof, attachProof should be enabled in the plugin")
+	}
+	proof, err := fabric.DecodeBlockProof(data.Proof)
+	if err != nil {
+		return err
+	}
+	if proof.Header.Number != data.Height || !bytes.Equal(proof.Header.DataHash, data.BlockHash) {
+		return fmt.Errorf("block proof mismatches message at height %d", data.Height)
+	}
+	txID := hex.EncodeToString(data.TxHash)
+	tx, err := conf.VerifyTransaction(proof, v.chaincode, txID)
+	if err != nil {
+		return err
+	}
+	if tx.Timestamp != data.Timestamp {
+		return fmt.Errorf("timestamp of tx %s mismatch", txID)
+	}
+	for _, m := range tx.Messages {
+		if bytes.Equal(m, msg.Message) {
+			return nil
+		}
+	}
+	return fmt.Errorf("message is not written by tx %s", txID)
+}
+
+// 账本数据为交易的envelope，交易需要只写入一个跨链消息
+func (v *FabricHCDVS) ParseMessageFromLedgerData(ledgerData []byte) ([]byte, error) {
+	metadata := make([][]byte, len(common.BlockMetadataIndex_name))
+	proof, err := fabric.ExtractBlockProof(&common.Block{
+		Header:   &common.BlockHeader{},
+		Data:     &common.BlockData{Data: [][]byte{ledgerData}},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}, v.chaincode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ledger data: %v", err)
+	}
+	if len(proof.Txs) != 1 || len(proof.Txs[0].Messages) != 1 {
+		return nil, fmt.Errorf("ledger data should contain exactly one cross-chain message")
+	}
+	return proof.Txs[0].Messages[0], nil
+}
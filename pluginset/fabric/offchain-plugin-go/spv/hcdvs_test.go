@@ -0,0 +1,76 @@
+package spv
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+)
+
+func TestFabricHCDVS(t *testing.T) {
+	trusted, source, orgs := newRotatedChain(t)
+	anchor, _ := ConfigState(trusted, source[0])
+	verifier := NewFabricHCDVS("cross")
+	if result, _ := verifier.VerifyAnchorConsensusState(anchor); !result.Verified {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	syncer := NewFabricSyncer(source)
+	states, _ := syncer.SyncConsensusState(anchor)
+	if result, _ := verifier.VerifyConsensusState(states[0], anchor); !result.Verified {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result, _ := verifier.VerifyConsensusState(states[1], anchor); result.Verified {
+		t.Fatal("skipped config should be rejected")
+	}
+
+	txID := hex.EncodeToString([]byte("tx1"))
+	ledgerData := newCrossChainTx("mychannel", txID, "cross", []byte("am1"))
+	proof := newSignedProof(t, 3, 0, []byte{byte(pb.TxValidationCode_VALID)}, [][]byte{ledgerData}, orgs[0])
+	raw, _ := proof.Encode()
+	newMessage := func() *bbc.CrossChainMessage {
+		return &bbc.CrossChainMessage{Type: bbc.AUTH_MSG, Message: []byte("am1"), ProvableData: &bbc.ProvableLedgerData{
+			Height: 3, BlockHash: proof.Header.DataHash, Timestamp: 1000, Proof: raw, TxHash: []byte("tx1"),
+		}}
+	}
+	if result, err := verifier.VerifyCrossChainMessage(newMessage(), anchor); err != nil || !result.Verified {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+
+	for name, c := range map[string]struct {
+		modify func(msg *bbc.CrossChainMessage)
+		anchor bool
+		expect string
+	}{
+		"forged message":  {func(msg *bbc.CrossChainMessage) { msg.Message = []byte("forged") }, false, "not written"},
+		"other tx":        {func(msg *bbc.CrossChainMessage) { msg.ProvableData.TxHash = []byte("tx2") }, false, "not a cross-chain transaction"},
+		"other height":    {func(msg *bbc.CrossChainMessage) { msg.ProvableData.Height = 4 }, false, "mismatches"},
+		"other timestamp": {func(msg *bbc.CrossChainMessage) { msg.ProvableData.Timestamp = 1 }, false, "timestamp"},
+		"no proof":        {func(msg *bbc.CrossChainMessage) { msg.ProvableData.Proof = nil }, false, "attachProof"},
+		"other type":      {func(msg *bbc.CrossChainMessage) { msg.Type = bbc.DEVELOPER_DESIGN }, false, "unsupported"},
+		// 区块由配置0签名，不能用之后的配置验证
+		"other anchor": {func(msg *bbc.CrossChainMessage) {}, true, "signed under config block 0"},
+	} {
+		msg := newMessage()
+		c.modify(msg)
+		state := anchor
+		if c.anchor {
+			state = states[0]
+		}
+		result, err := verifier.VerifyCrossChainMessage(msg, state)
+		if err != nil || result.Verified || !strings.Contains(result.ErrorMsg, c.expect) {
+			t.Fatalf("%s: unexpected result: %+v %v", name, result, err)
+		}
+	}
+
+	if msg, err := verifier.ParseMessageFromLedgerData(ledgerData); err != nil || string(msg) != "am1" {
+		t.Fatalf("unexpected message: %s %v", msg, err)
+	}
+	config := newConfigEnvelope("mychannel", newConfig(1, common.ImplicitMetaPolicy_ANY, orgs[0]))
+	if _, err := verifier.ParseMessageFromLedgerData(config); err == nil {
+		t.Fatal("ledger data without message should be rejected")
+	}
+}
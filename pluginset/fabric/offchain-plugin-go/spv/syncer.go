@@ -0,0 +1,186 @@
+package spv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/bbc"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// Fabric的共识状态同步
+// 共识状态为通道的配置区块，StateData为配置区块的BlockProof。锚定状态一般为通道的创世区块或PTC确认过的配置区块，
+// 之后的每个配置区块由上一个配置的orderer签名，按顺序验证。
+
+const PRODUCT = "fabric"
+
+func init() {
+	consensus.Register(PRODUCT, openFabricSyncer)
+}
+
+// 读取最新高度和区块证明，fabric.FabricBBCService满足该接口
+type ChainSource interface {
+	BlockSource
+	QueryLatestHeight() (uint64, error)
+}
+
+var _ ChainSource = (*fabric.FabricBBCService)(nil)
+
+type FabricSyncer struct {
+	source ChainSource
+	close  func() error
+}
+
+var _ consensus.ConsensusStateSyncer = (*FabricSyncer)(nil)
+
+func NewFabricSyncer(source ChainSource) *FabricSyncer {
+	return &FabricSyncer{source: source, close: func() error { return nil }}
+}
+
+// 按插件的配置连接通道
+func openFabricSyncer(rawConf []byte, logger hclog.Logger) (consensus.ConsensusStateSyncer, error) {
+	service := fabric.NewFabricBBCService(logger)
+	if err := service.Startup(&bbc.BBCContext{RawConf: rawConf}); err != nil {
+		return nil, err
+	}
+	return &FabricSyncer{source: service, close: service.Shutdown}, nil
+}
+
+// 配置区块的共识状态
+func ConfigState(conf *ChannelConfig, proof *fabric.BlockProof) (*consensus.ConsensusState, error) {
+	raw, err := proof.Encode()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(blockHeaderBytes(proof.Header))
+	return &consensus.ConsensusState{
+		Product:        PRODUCT,
+		Height:         proof.Header.Number,
+		Hash:           hash[:],
+		ConsensusNodes: conf.OrdererOrgs(),
+		StateData:      raw,
+	}, nil
+}
+
+// 解析共识状态中的配置，检查状态与配置区块一致，不验证签名
+func StateConfig(state *consensus.ConsensusState) (*ChannelConfig, *fabric.BlockProof, error) {
+	if state == nil {
+		return nil, nil, fmt.Errorf("no consensus state")
+	}
+	if state.Product != PRODUCT {
+		return nil, nil, fmt.Errorf("not a %s consensus state: %s", PRODUCT, state.Product)
+	}
+	proof, err := fabric.DecodeBlockProof(state.StateData)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf, err := ParseConfigProof(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	expect, err := ConfigState(conf, proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.Height != expect.Height || !bytes.Equal(state.Hash, expect.Hash) || !sameNodes(state.ConsensusNodes, expect.ConsensusNodes) {
+		return nil, nil, fmt.Errorf("consensus state at %d mismatches its config block", state.Height)
+	}
+	return conf, proof, nil
+}
+
+// 从配置区块的证明中读取通道配置，检查dataHash，不验证签名
+func ParseConfigProof(proof *fabric.BlockProof) (*ChannelConfig, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no block header")
+	}
+	sum := sha256.Sum256(bytes.Join(proof.Data, nil))
+	if !bytes.Equal(sum[:], proof.Header.DataHash) {
+		return nil, fmt.Errorf("data hash of block %d mismatch", proof.Header.Number)
+	}
+	return configFromProof(proof)
+}
+
+func sameNodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *FabricSyncer) VerifyAnchorConsensusState(anchor *consensus.ConsensusState) error {
+	_, _, err := StateConfig(anchor)
+	return err
+}
+
+func (s *FabricSyncer) VerifyConsensusState(state, anchor *consensus.ConsensusState) error {
+	return verifyNextState(state, anchor)
+}
+
+// 用已验证的anchor验证下一个配置区块的状态
+func verifyNextState(state, anchor *consensus.ConsensusState) error {
+	conf, _, err := StateConfig(anchor)
+	if err != nil {
+		return err
+	}
+	if _, proof, err := StateConfig(state); err != nil {
+		return err
+	} else if _, err := conf.VerifyConfigBlock(proof); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 按最新区块的LastConfig跟进锚定状态之后的配置区块
+func (s *FabricSyncer) SyncConsensusState(anchor *consensus.ConsensusState) ([]*consensus.ConsensusState, error) {
+	conf, _, err := StateConfig(anchor)
+	if err != nil {
+		return nil, err
+	}
+	height, err := s.source.QueryLatestHeight()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest height: %v", err)
+	}
+	if height <= conf.Height {
+		return nil, nil
+	}
+	latest, err := s.source.ReadBlockProof(height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block %d: %v", height, err)
+	}
+	if latest.Header == nil {
+		return nil, fmt.Errorf("no header of block %d", height)
+	}
+	index, ok, err := lastConfigIndex(latest)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("block %d has no last config", height)
+	}
+	tracker := NewConfigTracker(conf, s.source)
+	if _, err := tracker.Follow(index); err != nil {
+		return nil, err
+	}
+	var states []*consensus.ConsensusState
+	for _, next := range tracker.Configs()[1:] {
+		state, err := ConfigState(next, next.Proof())
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (s *FabricSyncer) Close() error {
+	return s.close()
+}
@@ -0,0 +1,75 @@
+package spv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/consensus"
+)
+
+func TestFabricSyncer(t *testing.T) {
+	trusted, source, _ := newRotatedChain(t)
+	anchor, err := ConfigState(trusted, source[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := NewFabricSyncer(source)
+	defer syncer.Close()
+	if err := syncer.VerifyAnchorConsensusState(anchor); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := syncer.SyncConsensusState(anchor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states[0].Height != 5 || states[1].Height != 9 || states[1].ConsensusNodes[0] != "Orderer3MSP" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+	// PTC逐个验证、保存
+	prev := anchor
+	for _, state := range states {
+		raw, _ := state.Encode()
+		decoded, err := consensus.DecodeConsensusState(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := syncer.VerifyConsensusState(decoded, prev); err != nil {
+			t.Fatal(err)
+		}
+		prev = decoded
+	}
+	if states, err := syncer.SyncConsensusState(prev); err != nil || len(states) != 0 {
+		t.Fatalf("unexpected states: %+v %v", states, err)
+	}
+
+	// 不能跳过中间的配置
+	if err := syncer.VerifyConsensusState(states[1], anchor); err == nil {
+		t.Fatal("skipped config should be rejected")
+	}
+	forged := *states[0]
+	forged.ConsensusNodes = []string{"Orderer1MSP"}
+	if err := syncer.VerifyConsensusState(&forged, anchor); err == nil || !strings.Contains(err.Error(), "mismatches") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	forged = *anchor
+	forged.Product = "other"
+	if err := syncer.VerifyAnchorConsensusState(&forged); err == nil {
+		t.Fatal("state of other product should be rejected")
+	}
+}
+
+func TestFabricSyncerRegistered(t *testing.T) {
+	found := false
+	for _, product := range consensus.Products() {
+		found = found || product == PRODUCT
+	}
+	if !found {
+		t.Fatal("fabric syncer should be registered")
+	}
+	if _, err := consensus.NewSyncer(PRODUCT, []byte("{}"), hclog.NewNullLogger()); err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+}
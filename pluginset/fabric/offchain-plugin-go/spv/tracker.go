@@ -0,0 +1,168 @@
+package spv
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 通道配置的跟进
+// 区块的LastConfig指向签名时的最新配置区块，指向的配置比已知的新时，从区块来源读取之间的配置区块，
+// 用上一个配置依次验证后更新，orderer证书轮换后不需要手动更换可信配置。配置区块的前一个区块的
+// LastConfig为上一个配置区块，据此从新的配置区块往前找到已知的配置，中间区块只用于查找，不需要验证。
+// 已知的配置全部保留，用于验证配置更新之前的区块。
+
+// 按高度读取区块证明，fabric.FabricBBCService满足该接口
+type BlockSource interface {
+	ReadBlockProof(height uint64) (*fabric.BlockProof, error)
+}
+
+var _ BlockSource = (*fabric.FabricBBCService)(nil)
+
+type ConfigTracker struct {
+	// 为空时只能通过Update按顺序提供配置区块
+	source BlockSource
+	mu     sync.Mutex
+	// 按高度递增，第一个为可信的初始配置
+	configs []*ChannelConfig
+}
+
+func NewConfigTracker(trusted *ChannelConfig, source BlockSource) *ConfigTracker {
+	return &ConfigTracker{source: source, configs: []*ChannelConfig{trusted}}
+}
+
+// 已知的最新配置
+func (t *ConfigTracker) Current() *ChannelConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.configs[len(t.configs)-1]
+}
+
+// 已知的全部配置，按高度递增
+func (t *ConfigTracker) Configs() []*ChannelConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*ChannelConfig{}, t.configs...)
+}
+
+// 跟进到指定高度的配置区块，一般为最新区块的LastConfig
+func (t *ConfigTracker) Follow(height uint64) (*ChannelConfig, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.follow(height, nil)
+}
+
+// 验证区块证明，区块使用未知的配置时先跟进配置区块
+func (t *ConfigTracker) VerifyBlockProof(proof *fabric.BlockProof, chaincode string) ([]*fabric.CrossChainTx, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no block header")
+	}
+	index, ok, err := lastConfigIndex(proof)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return t.Current().VerifyBlockProof(proof, chaincode)
+	}
+	// 配置区块中没有跨链交易，验证后跟进
+	if index == proof.Header.Number {
+		_, err := t.Update(proof)
+		return nil, err
+	}
+	conf, err := t.Follow(index)
+	if err != nil {
+		return nil, err
+	}
+	return conf.VerifyBlockProof(proof, chaincode)
+}
+
+// 验证交易在区块中且校验通过
+func (t *ConfigTracker) VerifyTransaction(proof *fabric.BlockProof, chaincode, txID string) (*fabric.CrossChainTx, error) {
+	txs, err := t.VerifyBlockProof(proof, chaincode)
+	if err != nil {
+		return nil, err
+	}
+	return findTx(txs, txID, proof.Header.Number)
+}
+
+// 验证配置区块并跟进，返回配置区块中的配置
+func (t *ConfigTracker) Update(proof *fabric.BlockProof) (*ChannelConfig, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no block header")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.follow(proof.Header.Number, proof)
+}
+
+func (t *ConfigTracker) find(height uint64) int {
+	for i := len(t.configs) - 1; i >= 0; i-- {
+		if t.configs[i].Height == height {
+			return i
+		}
+	}
+	return -1
+}
+
+// 跟进到target高度的配置区块，last不为空时为该配置区块的证明。调用时持有锁
+func (t *ConfigTracker) follow(target uint64, last *fabric.BlockProof) (*ChannelConfig, error) {
+	if i := t.find(target); i == 0 || (i > 0 && last == nil) {
+		return t.configs[i], nil
+	} else if i > 0 {
+		// 已经跟进的配置区块，仍然验证提供的证明
+		if _, err := t.configs[i-1].VerifyConfigBlock(last); err != nil {
+			return nil, err
+		}
+		return t.configs[i], nil
+	}
+	current := t.configs[len(t.configs)-1]
+	if target < current.Height {
+		return nil, fmt.Errorf("config block %d is unknown, earliest trusted config block is %d", target, t.configs[0].Height)
+	}
+
+	heights := []uint64{target}
+	for h := target; t.source != nil; {
+		prev, err := t.source.ReadBlockProof(h - 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read block %d: %v", h-1, err)
+		}
+		if prev.Header == nil {
+			return nil, fmt.Errorf("no header of block %d", h-1)
+		}
+		index, ok, err := lastConfigIndex(prev)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || index >= h || index < current.Height {
+			return nil, fmt.Errorf("unexpected last config of block %d", h-1)
+		}
+		if index == current.Height {
+			break
+		}
+		heights = append(heights, index)
+		h = index
+	}
+
+	for i := len(heights) - 1; i >= 0; i-- {
+		proof := last
+		if i > 0 || last == nil {
+			if t.source == nil {
+				return nil, fmt.Errorf("no block source to read config block %d", heights[i])
+			}
+			var err error
+			if proof, err = t.source.ReadBlockProof(heights[i]); err != nil {
+				return nil, fmt.Errorf("failed to read config block %d: %v", heights[i], err)
+			}
+		}
+		if proof.Header == nil || proof.Header.Number != heights[i] {
+			return nil, fmt.Errorf("unexpected proof of config block %d", heights[i])
+		}
+		next, err := t.configs[len(t.configs)-1].VerifyConfigBlock(proof)
+		if err != nil {
+			return nil, err
+		}
+		t.configs = append(t.configs, next)
+	}
+	return t.configs[len(t.configs)-1], nil
+}
@@ -0,0 +1,123 @@
+package spv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+type fakeSource map[uint64]*fabric.BlockProof
+
+func (s fakeSource) ReadBlockProof(height uint64) (*fabric.BlockProof, error) {
+	proof, ok := s[height]
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	// 返回副本，验证不会修改来源中的证明
+	raw, _ := proof.Encode()
+	return fabric.DecodeBlockProof(raw)
+}
+
+func (s fakeSource) QueryLatestHeight() (uint64, error) {
+	var height uint64
+	for h := range s {
+		if h > height {
+			height = h
+		}
+	}
+	return height, nil
+}
+
+// 0、5、9为配置区块，orderer组织依次轮换为org1、org2、org3
+func newRotatedChain(t *testing.T) (*ChannelConfig, fakeSource, []*testOrg) {
+	orgs := []*testOrg{newTestOrg("Orderer1MSP"), newTestOrg("Orderer2MSP"), newTestOrg("Orderer3MSP")}
+	valid := []byte{byte(pb.TxValidationCode_VALID)}
+	source := fakeSource{0: newSignedProof(t, 0, 0, valid, [][]byte{newConfigEnvelope("mychannel", newConfig(0, common.ImplicitMetaPolicy_ANY, orgs[0]))})}
+	trusted, err := ParseConfigProof(source[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, lastConfig, sequence := orgs[0], uint64(0), uint64(0)
+	for number := uint64(1); number <= 10; number++ {
+		switch number {
+		case 5, 9:
+			next := orgs[1]
+			if number == 9 {
+				next = orgs[2]
+			}
+			sequence++
+			config := newConfigEnvelope("mychannel", newConfig(sequence, common.ImplicitMetaPolicy_ANY, next))
+			source[number] = newSignedProof(t, number, number, valid, [][]byte{config}, signer)
+			signer, lastConfig = next, number
+		default:
+			tx := newCrossChainTx("mychannel", fmt.Sprintf("tx%d", number), "cross", []byte("am"))
+			source[number] = newSignedProof(t, number, lastConfig, valid, [][]byte{tx}, signer)
+		}
+	}
+	return trusted, source, orgs
+}
+
+func TestConfigTracker(t *testing.T) {
+	trusted, source, orgs := newRotatedChain(t)
+	tracker := NewConfigTracker(trusted, source)
+
+	// 区块10由org3签名，依次跟进配置区块5、9
+	proof, _ := source.ReadBlockProof(10)
+	if tx, err := tracker.VerifyTransaction(proof, "cross", "tx10"); err != nil || tx.Index != 0 {
+		t.Fatalf("unexpected tx: %+v %v", tx, err)
+	}
+	if current := tracker.Current(); current.Height != 9 || current.Sequence != 2 || current.OrdererOrgs()[0] != "Orderer3MSP" {
+		t.Fatalf("unexpected config: %+v", current)
+	}
+	// 配置更新之前的区块使用当时的配置
+	for _, number := range []uint64{3, 7} {
+		proof, _ := source.ReadBlockProof(number)
+		if _, err := tracker.VerifyTransaction(proof, "cross", fmt.Sprintf("tx%d", number)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 配置区块本身没有跨链交易
+	proof, _ = source.ReadBlockProof(9)
+	if txs, err := tracker.VerifyBlockProof(proof, "cross"); err != nil || len(txs) != 0 {
+		t.Fatalf("unexpected txs: %v %v", txs, err)
+	}
+	proof.Signatures = nil
+	if _, err := tracker.VerifyBlockProof(proof, "cross"); err == nil {
+		t.Fatal("unsigned config block should be rejected")
+	}
+	// 轮换出去的组织不能再签名新的区块
+	forged := newSignedProof(t, 11, 9, []byte{byte(pb.TxValidationCode_VALID)}, [][]byte{newCrossChainTx("mychannel", "tx11", "cross", []byte("am"))}, orgs[0])
+	if _, err := tracker.VerifyBlockProof(forged, "cross"); err == nil || !strings.Contains(err.Error(), "not an orderer org") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 未知的配置区块
+	forged = newSignedProof(t, 12, 11, []byte{byte(pb.TxValidationCode_VALID)}, [][]byte{newCrossChainTx("mychannel", "tx12", "cross", []byte("am"))}, orgs[2])
+	if _, err := tracker.VerifyBlockProof(forged, "cross"); err == nil || !strings.Contains(err.Error(), "config block 11") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 没有区块来源时按顺序提供配置区块
+	tracker = NewConfigTracker(trusted, nil)
+	proof, _ = source.ReadBlockProof(10)
+	if _, err := tracker.VerifyBlockProof(proof, "cross"); err == nil || !strings.Contains(err.Error(), "no block source") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config9, _ := source.ReadBlockProof(9)
+	if _, err := tracker.Update(config9); err == nil || !strings.Contains(err.Error(), "not an orderer org") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config5, _ := source.ReadBlockProof(5)
+	for _, config := range []*fabric.BlockProof{config5, config9} {
+		if _, err := tracker.Update(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tracker.VerifyTransaction(proof, "cross", "tx10"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,237 @@
+package spv
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 区块证明的验证
+// 验证方持有可信的通道配置，按配置验证区块头的orderer签名，按区块的全部交易验证dataHash，
+// 再从交易中重新提取跨链交易，不信任中继提供的交易内容。交易的校验结果不在orderer的签名范围内，
+// 由中继提供。
+
+// 与fabric protoutil.BlockHeaderBytes一致
+type asn1Header struct {
+	Number       *big.Int
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func blockHeaderBytes(header *fabric.BlockHeader) []byte {
+	raw, err := asn1.Marshal(asn1Header{Number: new(big.Int).SetUint64(header.Number), PreviousHash: header.PreviousHash, DataHash: header.DataHash})
+	if err != nil {
+		// 只包含整数和字节数组，不会失败
+		panic(err)
+	}
+	return raw
+}
+
+// 验证区块的签名和交易，返回从交易中提取的跨链交易
+func (c *ChannelConfig) VerifyBlockProof(proof *fabric.BlockProof, chaincode string) ([]*fabric.CrossChainTx, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no block header")
+	}
+	number := proof.Header.Number
+	if err := c.verifySignatures(proof, c.Height); err != nil {
+		return nil, err
+	}
+	if err := c.verifyData(proof); err != nil {
+		return nil, err
+	}
+
+	metadata := make([][]byte, len(common.BlockMetadataIndex_name))
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = proof.TxFilter
+	extracted, err := fabric.ExtractBlockProof(&common.Block{
+		Header:   &common.BlockHeader{Number: number, PreviousHash: proof.Header.PreviousHash, DataHash: proof.Header.DataHash},
+		Data:     &common.BlockData{Data: proof.Data},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}, chaincode)
+	if err != nil {
+		return nil, err
+	}
+	// 编码后比较，避免空字节数组和nil的差别
+	claimed, _ := json.Marshal(proof.Txs)
+	actual, _ := json.Marshal(extracted.Txs)
+	if !bytes.Equal(claimed, actual) {
+		return nil, fmt.Errorf("cross-chain transactions of block %d mismatch", number)
+	}
+	return extracted.Txs, nil
+}
+
+// 验证当前配置之后的下一个配置区块，返回新的通道配置。配置区块由更新前的配置签名
+func (c *ChannelConfig) VerifyConfigBlock(proof *fabric.BlockProof) (*ChannelConfig, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no block header")
+	}
+	number := proof.Header.Number
+	if number <= c.Height {
+		return nil, fmt.Errorf("config block %d is not after config block %d", number, c.Height)
+	}
+	// 配置区块的LastConfig指向自身
+	if err := c.verifySignatures(proof, number); err != nil {
+		return nil, err
+	}
+	if err := c.verifyData(proof); err != nil {
+		return nil, err
+	}
+	next, err := configFromProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	// 配置更新需要依次跟进，每个配置区块由上一个配置签名
+	if next.Sequence != c.Sequence+1 {
+		return nil, fmt.Errorf("config block %d has sequence %d, expected %d", number, next.Sequence, c.Sequence+1)
+	}
+	return next, nil
+}
+
+// 验证交易在区块中且校验通过
+func (c *ChannelConfig) VerifyTransaction(proof *fabric.BlockProof, chaincode, txID string) (*fabric.CrossChainTx, error) {
+	txs, err := c.VerifyBlockProof(proof, chaincode)
+	if err != nil {
+		return nil, err
+	}
+	return findTx(txs, txID, proof.Header.Number)
+}
+
+func findTx(txs []*fabric.CrossChainTx, txID string, number uint64) (*fabric.CrossChainTx, error) {
+	for _, tx := range txs {
+		if tx.TxID != txID {
+			continue
+		}
+		if tx.ValidationCode != pb.TxValidationCode_VALID {
+			return nil, fmt.Errorf("tx %s is invalid: %v", txID, tx.ValidationCode)
+		}
+		return tx, nil
+	}
+	return nil, fmt.Errorf("tx %s is not a cross-chain transaction of block %d", txID, number)
+}
+
+// 区块签名时的最新配置区块，fabric 1.4的区块不在签名中记录，返回false
+func lastConfigIndex(proof *fabric.BlockProof) (uint64, bool, error) {
+	meta := &common.OrdererBlockMetadata{}
+	if err := proto.Unmarshal(proof.SignatureValue, meta); err != nil {
+		return 0, false, fmt.Errorf("invalid signature value of block %d: %v", proof.Header.Number, err)
+	}
+	if meta.LastConfig == nil {
+		return 0, false, nil
+	}
+	return meta.LastConfig.Index, true, nil
+}
+
+// 区块头中的dataHash、交易的校验结果和交易所属的通道
+func (c *ChannelConfig) verifyData(proof *fabric.BlockProof) error {
+	number := proof.Header.Number
+	sum := sha256.Sum256(bytes.Join(proof.Data, nil))
+	if !bytes.Equal(sum[:], proof.Header.DataHash) {
+		return fmt.Errorf("data hash of block %d mismatch", number)
+	}
+	if len(proof.TxFilter) != len(proof.Data) {
+		return fmt.Errorf("block %d has %d transactions but %d validation codes", number, len(proof.Data), len(proof.TxFilter))
+	}
+	// orderer可能服务多个通道，区块需要属于配置的通道
+	for i, data := range proof.Data {
+		chdr, _, err := channelHeader(data)
+		if err != nil {
+			return fmt.Errorf("invalid tx %d of block %d: %v", i, number, err)
+		}
+		if chdr.ChannelId != c.ChannelID {
+			return fmt.Errorf("tx %d of block %d belongs to channel %s", i, number, chdr.ChannelId)
+		}
+	}
+	return nil
+}
+
+// 验证区块的orderer签名，lastConfig为区块应记录的最新配置区块
+func (c *ChannelConfig) verifySignatures(proof *fabric.BlockProof, lastConfig uint64) error {
+	number := proof.Header.Number
+	index, ok, err := lastConfigIndex(proof)
+	if err != nil {
+		return err
+	}
+	if ok && index != lastConfig {
+		return fmt.Errorf("block %d is signed under config block %d, not %d", number, index, lastConfig)
+	}
+
+	header := blockHeaderBytes(proof.Header)
+	signed := make(map[string]bool)
+	var lastErr error
+	for _, sig := range proof.Signatures {
+		// 无效的签名不计入，与fabric的策略验证一致
+		mspID, err := c.verifySignature(sig, proof.SignatureValue, header)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		signed[mspID] = true
+	}
+	if len(signed) < c.Threshold {
+		return fmt.Errorf("block %d is signed by %d orderer orgs, %d required, last error: %v", number, len(signed), c.Threshold, lastErr)
+	}
+	return nil
+}
+
+// 验证一个orderer签名，返回签名者的MSP ID
+func (c *ChannelConfig) verifySignature(sig *fabric.BlockSignature, value, header []byte) (string, error) {
+	shdr := &common.SignatureHeader{}
+	if err := proto.Unmarshal(sig.SignatureHeader, shdr); err != nil {
+		return "", fmt.Errorf("invalid signature header: %v", err)
+	}
+	id := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(shdr.Creator, id); err != nil {
+		return "", fmt.Errorf("invalid signer: %v", err)
+	}
+	org := c.orgs[id.Mspid]
+	if org == nil {
+		return "", fmt.Errorf("%s is not an orderer org", id.Mspid)
+	}
+	cert, err := parseCert(id.IdBytes)
+	if err != nil {
+		return "", err
+	}
+	// 与fabric的MSP一致，不检查证书是否过期
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         org.roots,
+		Intermediates: org.intermediates,
+		CurrentTime:   cert.NotBefore.Add(time.Second),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return "", fmt.Errorf("signer is not a member of %s: %v", id.Mspid, err)
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported public key of signer")
+	}
+	var es ecdsaSignature
+	if rest, err := asn1.Unmarshal(sig.Signature, &es); err != nil || len(rest) > 0 || es.R == nil || es.S == nil {
+		return "", fmt.Errorf("invalid signature")
+	}
+	// fabric只接受low-S的签名
+	if es.S.Cmp(new(big.Int).Rsh(pub.Curve.Params().N, 1)) > 0 {
+		return "", fmt.Errorf("signature is not low-S")
+	}
+	digest := sha256.Sum256(bytes.Join([][]byte{value, sig.SignatureHeader, header}, nil))
+	if !ecdsa.Verify(pub, digest[:], es.R, es.S) {
+		return "", fmt.Errorf("signature of %s mismatch", id.Mspid)
+	}
+	return id.Mspid, nil
+}
@@ -0,0 +1,128 @@
+package spv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/AntChainOpenLab/AntChainBridgePluginSDK/pluginset/fabric/offchain-plugin-go/fabric"
+)
+
+// 跨链链码写入一个AM消息的交易
+func newCrossChainTx(channel, txID, chaincode string, message []byte) []byte {
+	kv := &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: fabric.AM_MESSAGE_KEY_PREFIX + txID, Value: message}}}
+	txrw := &rwset.TxReadWriteSet{DataModel: rwset.TxReadWriteSet_KV, NsRwset: []*rwset.NsReadWriteSet{{Namespace: chaincode, Rwset: mustMarshal(kv)}}}
+	prp := &pb.ProposalResponsePayload{Extension: mustMarshal(&pb.ChaincodeAction{Results: mustMarshal(txrw)})}
+	ccap := &pb.ChaincodeActionPayload{Action: &pb.ChaincodeEndorsedAction{ProposalResponsePayload: mustMarshal(prp)}}
+	tx := &pb.Transaction{Actions: []*pb.TransactionAction{{Payload: mustMarshal(ccap)}}}
+	chdr := &common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), ChannelId: channel, TxId: txID, Timestamp: &timestamp.Timestamp{Seconds: 1}}
+	payload := &common.Payload{Header: &common.Header{ChannelHeader: mustMarshal(chdr)}, Data: mustMarshal(tx)}
+	return mustMarshal(&common.Envelope{Payload: mustMarshal(payload)})
+}
+
+// 构造由signers签名的区块并提取证明
+func newSignedProof(t *testing.T, number, lastConfig uint64, filter []byte, data [][]byte, signers ...*testOrg) *fabric.BlockProof {
+	sum := sha256.Sum256(bytes.Join(data, nil))
+	header := &common.BlockHeader{Number: number, PreviousHash: []byte("prev"), DataHash: sum[:]}
+	value := mustMarshal(&common.OrdererBlockMetadata{LastConfig: &common.LastConfig{Index: lastConfig}})
+	signed := &common.Metadata{Value: value}
+	for _, org := range signers {
+		shdr := org.signatureHeader()
+		msg := bytes.Join([][]byte{value, shdr, blockHeaderBytes(&fabric.BlockHeader{Number: number, PreviousHash: header.PreviousHash, DataHash: header.DataHash})}, nil)
+		signed.Signatures = append(signed.Signatures, &common.MetadataSignature{SignatureHeader: shdr, Signature: org.sign(msg)})
+	}
+	metadata := make([][]byte, len(common.BlockMetadataIndex_name))
+	metadata[common.BlockMetadataIndex_SIGNATURES] = mustMarshal(signed)
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = filter
+	proof, err := fabric.ExtractBlockProof(&common.Block{Header: header, Data: &common.BlockData{Data: data}, Metadata: &common.BlockMetadata{Metadata: metadata}}, "cross")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestVerifyBlockProof(t *testing.T) {
+	org1, org2, org3 := newTestOrg("Orderer1MSP"), newTestOrg("Orderer2MSP"), newTestOrg("Orderer3MSP")
+	conf, err := ParseConfigBlock(newConfigBlock(4, "mychannel", newConfig(2, common.ImplicitMetaPolicy_MAJORITY, org1, org2, org3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := [][]byte{
+		newCrossChainTx("mychannel", "tx1", "cross", []byte("am1")),
+		newCrossChainTx("mychannel", "tx2", "cross", []byte("am2")),
+		newCrossChainTx("mychannel", "tx3", "bizcc", []byte("fake")),
+	}
+	filter := []byte{byte(pb.TxValidationCode_VALID), byte(pb.TxValidationCode_MVCC_READ_CONFLICT), byte(pb.TxValidationCode_VALID)}
+
+	proof := newSignedProof(t, 10, 4, filter, data, org1, org3)
+	txs, err := conf.VerifyBlockProof(proof, "cross")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 || txs[0].TxID != "tx1" || string(txs[0].Messages[0]) != "am1" {
+		t.Fatalf("unexpected txs: %+v", txs)
+	}
+	if tx, err := conf.VerifyTransaction(proof, "cross", "tx1"); err != nil || tx.Index != 0 {
+		t.Fatalf("unexpected tx: %+v %v", tx, err)
+	}
+	if _, err := conf.VerifyTransaction(proof, "cross", "tx2"); err == nil || !strings.Contains(err.Error(), "MVCC_READ_CONFLICT") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := conf.VerifyTransaction(proof, "cross", "tx3"); err == nil {
+		t.Fatal("tx of other chaincode should be rejected")
+	}
+
+	// 编码传输后仍然可以验证
+	raw, _ := proof.Encode()
+	decoded, _ := fabric.DecodeBlockProof(raw)
+	if _, err := conf.VerifyBlockProof(decoded, "cross"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]struct {
+		proof  func() *fabric.BlockProof
+		expect string
+	}{
+		"not enough signatures": {func() *fabric.BlockProof { return newSignedProof(t, 10, 4, filter, data, org1) }, "1 orderer orgs, 2 required"},
+		"duplicated org":        {func() *fabric.BlockProof { return newSignedProof(t, 10, 4, filter, data, org2, org2) }, "1 orderer orgs"},
+		"unknown org": {func() *fabric.BlockProof {
+			return newSignedProof(t, 10, 4, filter, data, org1, newTestOrg("Orderer4MSP"))
+		}, "not an orderer org"},
+		"forged member": {func() *fabric.BlockProof {
+			forged := newTestOrg("Orderer2MSP")
+			return newSignedProof(t, 10, 4, filter, data, org1, forged)
+		}, "not a member"},
+		"other config": {func() *fabric.BlockProof { return newSignedProof(t, 10, 8, filter, data, org1, org2) }, "config block 8"},
+		"tampered header": {func() *fabric.BlockProof {
+			p := newSignedProof(t, 10, 4, filter, data, org1, org2)
+			p.Header.Number = 11
+			return p
+		}, "mismatch"},
+		"tampered data": {func() *fabric.BlockProof {
+			p := newSignedProof(t, 10, 4, filter, data, org1, org2)
+			p.Data = append([][]byte{}, p.Data...)
+			p.Data[2] = newCrossChainTx("mychannel", "tx3", "cross", []byte("fake"))
+			return p
+		}, "data hash"},
+		"tampered txs": {func() *fabric.BlockProof {
+			p := newSignedProof(t, 10, 4, filter, data, org1, org2)
+			p.Txs[0].Messages[0] = []byte("forged")
+			return p
+		}, "transactions of block 10 mismatch"},
+		"missing filter": {func() *fabric.BlockProof { return newSignedProof(t, 10, 4, filter[:2], data, org1, org2) }, "validation codes"},
+		"other channel": {func() *fabric.BlockProof {
+			return newSignedProof(t, 10, 4, filter[:1], [][]byte{newCrossChainTx("other", "tx1", "cross", []byte("am1"))}, org1, org2)
+		}, "channel other"},
+	} {
+		if _, err := conf.VerifyBlockProof(c.proof(), "cross"); err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -1,7 +0,0 @@
-module bizcc
-
-go 1.16
-
-require (
-
-)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
@@ -140,7 +142,7 @@ func (bs *CrossChainTest) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 
 		var re pb.Response
 		for i := 0; i < 20; i++ {
-			args_cross[4] = []byte(args[4] + "-" + string(i))
+			args_cross[4] = []byte(args[4] + "-" + strconv.Itoa(i))
 			re = stub.InvokeChaincode(cc, args_cross, stub.GetChannelID())
 		}
 
@@ -0,0 +1,29 @@
+#!/bin/sh
+# 在GOPATH模式下运行v2.2跨链链码及vendor中本仓库维护的包的vet和单元测试，合并vendor的方式与打包一致：
+#   ./test.sh                  全部测试
+#   ./test.sh -run TestSDP -v  其余参数传给go test
+# go test ./...不进入vendor目录，vendor中的包需要逐个列出，第三方包(目录名带域名)不测试
+set -e
+cd "$(dirname "$0")"
+GOPATH=$(mktemp -d)
+trap 'rm -rf "$GOPATH"' EXIT
+dst=$GOPATH/src/cross
+mkdir -p "$dst"
+cp -r v2.2/. "$dst"/
+cp -rn vendor/. "$dst"/vendor/
+(cd "$dst"/vendor && rm -rf github.com/hyperledger/fabric github.com/hyperledger/fabric-amcl \
+  oraclelogic/oraclelogic.go wrapstub/wrapstub.go wrapstub/mockwrapstub.go)
+
+cd "$dst"
+pkgs=.
+for d in vendor/*/; do
+  case "$d" in
+  *.*/) continue ;;
+  esac
+  for p in $(find "$d" -name '*_test.go' -exec dirname {} \; | sort -u); do
+    pkgs="$pkgs ./$p"
+  done
+done
+export GO111MODULE=off GOPATH
+go vet $pkgs
+go test "$@" $pkgs
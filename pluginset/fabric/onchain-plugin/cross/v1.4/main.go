@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crosserr"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -79,7 +80,7 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	// parser应该是product的枚举值，比如fabric_14，不同parser对应于不同的函数。
 	case "setDomainParser":
 		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
-			return shim.Error("[setDomainParser] " + ret.Message)
+			return errorResponse("setDomainParser", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
 		}
 		return bs.setDomainParser(stub, args)
 
@@ -91,7 +92,7 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	case "sendMessage":
 		re := bs.sendMessage(stub, args, oraclelogic.K_MSG_TYPE_ORDERED)
 		if re.Status != shim.OK {
-			return shim.Error("[sendMessage] " + re.Message)
+			return errorResponse("sendMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
 		}
 		return re
 
@@ -103,7 +104,7 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	case "sendUnorderedMessage":
 		re := bs.sendMessage(stub, args, oraclelogic.K_MSG_TYPE_UNORDERED)
 		if re.Status != shim.OK {
-			return shim.Error("[sendUnorderedMessage] " + re.Message)
+			return errorResponse("sendUnorderedMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
 		}
 		return re
 
@@ -114,14 +115,14 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	case "batchSendUnorderedMessage":
 		if len(args) < 3 {
 			fmt.Println("Unexpected args len")
-			return shim.Error("Unexpected args len")
+			return errorResponse("batchSendUnorderedMessage", crosserr.New(crosserr.CodeInvalidArgs, "Unexpected args len: %d", len(args)))
 		}
 		for i := 2; i < len(args); i++ {
 			nounce := "nounce" + strconv.Itoa(i)
 			newargs := []string{args[0], args[1], args[i], nounce}
 			re := bs.sendMessage(stub, newargs, oraclelogic.K_MSG_TYPE_UNORDERED)
 			if re.Status != shim.OK {
-				return shim.Error("[batchSendUnorderedMessage] " + re.Message)
+				return errorResponse("batchSendUnorderedMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
 			}
 		}
 		return shim.Success([]byte("success"))
@@ -129,7 +130,7 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	// 跨链服务上传跨链消息的接口
 	case "recvMessage":
 		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
-			return shim.Error("[recvMessage] " + ret.Message)
+			return errorResponse("recvMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
 		}
 		return bs.recvMessage(stub, args)
 
@@ -150,24 +151,24 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 		return bs.callbackBizChaincode(stub, []byte(args[0]))
 
 	default:
-		return shim.Error("Method not found")
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "Method not found: %s", fn))
 	}
 }
 
 func (bs *CrossChain) setDomainParser(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) != 2 {
-		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
 	}
 
 	senderDomain := args[0]
 	parser := args[1]
 
 	if senderDomain == "" || parser == "" {
-		return shim.Error(fmt.Sprintf("Wrong args: %s, %s", senderDomain, parser))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", senderDomain, parser))
 	}
 
 	if err := bs.Os.PutState(stub, false, fmt.Sprintf("%s_%s", oraclelogic.KMychainParserInfo, senderDomain), []byte(parser)); err != nil {
-		return shim.Error(fmt.Sprintf("failed to put parser: %v", err))
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put parser"))
 	}
 
 	return shim.Success(nil)
@@ -181,7 +182,7 @@ func (bs *CrossChain) sendMessage(stub shim.ChaincodeStubInterface, args []strin
 	// 构造对外发送的消息，准备目的域名、接收账号、消息内容, nounce
 	if len(args) != 4 && len(args) != 3 {
 		fmt.Println("Unexpected args len")
-		return shim.Error(fmt.Sprintf("Unexpected args len: %d", len(args)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Unexpected args len: %d", len(args)))
 	}
 	var (
 		destDomain    = args[0]
@@ -190,11 +191,11 @@ func (bs *CrossChain) sendMessage(stub shim.ChaincodeStubInterface, args []strin
 	)
 
 	if err != nil {
-		return shim.Error(fmt.Sprintf("receiver(%s) format error: %v", args[1], err))
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "receiver(%s) format error", args[1]))
 	}
 
 	if len(msg) > oraclelogic.K_SEND_MESSAGE_LENGTH_LIMIT {
-		return shim.Error(fmt.Sprintf(" message exceed length limit (%d)", len(msg)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "message exceed length limit (%d)", len(msg)))
 	}
 
 	msgnounce := ""
@@ -296,3 +297,9 @@ func (bs *CrossChain) callbackBizChaincode(stub shim.ChaincodeStubInterface, mes
 	}
 	return shim.Success([]byte("callback biz chaincode success"))
 }
+
+// 构造带错误码的错误响应，见crosserr
+func errorResponse(fn string, err error) pb.Response {
+	status, msg := crosserr.Response(fn, err)
+	return pb.Response{Status: status, Message: msg}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"chaincodepb"
+	"crosserr"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -1029,3 +1030,27 @@ func Test_CallbackBizChaincode(t *testing.T) {
 //	}
 //
 //}
+
+func TestErrorCodes(t *testing.T) {
+	stub := shimtest.NewMockStub("crosschain", new(CrossChain))
+	var sp pb.SignedProposal
+	MockSignedProposal("crosscc", &sp)
+	doInit(t, stub, [][]byte{[]byte("Init")}, &sp)
+
+	cases := []struct {
+		args [][]byte
+		fn   string
+		code crosserr.Code
+	}{
+		{[][]byte{[]byte("noSuchMethod")}, "", crosserr.CodeNotFound},
+		{[][]byte{[]byte("batchSendUnorderedMessage"), []byte("a"), []byte("b")}, "batchSendUnorderedMessage", crosserr.CodeInvalidArgs},
+		{[][]byte{[]byte("sendMessage"), []byte(MYCHAIN_DOMAIN)}, "sendMessage", crosserr.CodeInvalidArgs},
+	}
+	for _, c := range cases {
+		res := InvokeChaincode(t, stub, c.args, &sp)
+		code, _, ok := crosserr.Parse(res.Message)
+		if res.Status != crosserr.STATUS_ERROR || !ok || code != c.code || (c.fn != "" && !strings.HasPrefix(res.Message, "["+c.fn+"] ")) {
+			t.Fatalf("%s: expected %s, got %d %s", c.args[0], c.code, res.Status, res.Message)
+		}
+	}
+}
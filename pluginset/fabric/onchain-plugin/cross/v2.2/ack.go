@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// SDP回执
+// 来源链发来的SDP v2原子请求(原子标志为ATOMIC_REQUEST)投递后，以接收链码的身份向发送方回复回执：
+// 业务链码执行成功时回复ACK_SUCCESS，返回错误时回复ACK_ERROR并带上错误信息。回执的消息内容为请求的消息内容，
+// 业务链码执行成功且返回了payload时为该payload，作为请求方的响应(见request_response.go)。与以太坊SDP合约一致，
+// 原子请求的业务链码返回错误时交易不回滚，消息序号照常推进，由发送方根据回执决定重试或回退。
+// 其余消息业务链码返回错误时整笔交易失败，行为不变。
+//
+// 回执报文与普通发送消息一样写入发送积压和可靠中继队列，但不发v1的发送事件(收消息的交易可能已经有事件)，
+// 中继通过queryOutboundBacklog或queryRelayQueue取回执；事件版本为v2时接收交易发出ACKED_MESSAGE事件，
+// 其中带有回执报文的key(见event_v2.go)。
+//
+// 注意业务链码返回错误前写入的状态不会被撤销，原子请求的接收方应当先校验再写状态。
+const (
+	// crosschain_ack_status_${messageId} -> AckStatus
+	K_ACK_STATUS_PREFIX = CROSSCHAIN_PREFIX + "ack_status_"
+)
+
+type AckStatus struct {
+	// SDP v2消息id(hex)
+	MessageId    string `json:"messageId"`
+	SenderDomain string `json:"senderDomain"`
+	Sender       string `json:"sender"`
+	Receiver     string `json:"receiver"`
+	Sequence     uint32 `json:"sequence"`
+	Nonce        uint64 `json:"nonce"`
+	// SDP_ATOMIC_ACK_SUCCESS或SDP_ATOMIC_ACK_ERROR
+	AtomicFlag uint8  `json:"atomicFlag"`
+	ErrorMsg   string `json:"errorMsg,omitempty"`
+	// 回执报文在state中的key
+	AckKey  string `json:"ackKey"`
+	TxId    string `json:"txId"`
+	AckedAt int64  `json:"ackedAt"`
+}
+
+func needAck(msg oraclelogic.RecvAuthMessage) bool {
+	return msg.SDPVersion >= oraclelogic.SDP_VERSION_2 && msg.AtomicFlag == oraclelogic.SDP_ATOMIC_REQUEST
+}
+
+// 向原子请求的发送方回复回执，callErr为业务链码返回的错误，nil表示成功，response为业务链码返回的payload
+func (bs *CrossChain) ackMessage(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, callErr error, response []byte) (*AckStatus, error) {
+	messageId, err := hex.DecodeString(msg.MessageId)
+	if err != nil || len(messageId) != 32 {
+		return nil, fmt.Errorf("message id(%s) format error", msg.MessageId)
+	}
+	has, err := getJSONState(stub, K_ACK_STATUS_PREFIX+msg.MessageId, &AckStatus{})
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return nil, fmt.Errorf("message %s is already acked", msg.MessageId)
+	}
+
+	ack := &oraclelogic.SDPMessage{
+		Version:        oraclelogic.SDP_VERSION_2,
+		TargetDomain:   msg.From,
+		TargetIdentity: msg.Identity,
+		Sequence:       msg.Seq,
+		Payload:        msg.Content,
+		MessageId:      oraclelogic.CopySliceToByte32(messageId),
+		AtomicFlag:     oraclelogic.SDP_ATOMIC_ACK_SUCCESS,
+		Nonce:          msg.Nonce,
+	}
+	if callErr != nil {
+		ack.AtomicFlag, ack.ErrorMsg = oraclelogic.SDP_ATOMIC_ACK_ERROR, callErr.Error()
+	} else if len(response) > 0 {
+		ack.Payload = response
+	}
+	res := bs.Os.SendSDPMessage(stub, msg.Receiver, ack, "ack_"+msg.MessageId)
+	if res.Status != shim.OK {
+		return nil, fmt.Errorf("failed to send ack: %s", res.Message)
+	}
+	if err := bs.indexOutboundMessage(stub, res.Payload); err != nil {
+		return nil, err
+	}
+	if err := bs.enqueueRelay(stub, res.Payload); err != nil {
+		return nil, err
+	}
+	var outbound oraclelogic.OutboundMessage
+	_ = json.Unmarshal(res.Payload, &outbound)
+
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return nil, err
+	}
+	status := &AckStatus{
+		MessageId:    msg.MessageId,
+		SenderDomain: msg.From,
+		Sender:       hex.EncodeToString(msg.Identity[:]),
+		Receiver:     hex.EncodeToString(msg.Receiver[:]),
+		Sequence:     msg.Seq,
+		Nonce:        msg.Nonce,
+		AtomicFlag:   ack.AtomicFlag,
+		ErrorMsg:     ack.ErrorMsg,
+		AckKey:       outbound.Key,
+		TxId:         stub.GetTxID(),
+		AckedAt:      now,
+	}
+	if err := putJSONState(stub, K_ACK_STATUS_PREFIX+msg.MessageId, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// 查询原子请求的回执状态
+// args[0] SDP v2消息id(hex)
+func (bs *CrossChain) queryAckStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var status AckStatus
+	if has, err := getJSONState(stub, K_ACK_STATUS_PREFIX+args[0], &status); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "ack of message %s not found", args[0]))
+	}
+	raw, _ := json.Marshal(&status)
+	return shim.Success(raw)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+// 总是返回错误的业务链码
+type rejectingChaincode struct{}
+
+func (rejectingChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (rejectingChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Error("rejected by receiver")
+}
+
+func TestAckAtomicRequest(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	stub.MockPeerChaincode("failcc", shimtest.NewMockStub("failcc", rejectingChaincode{}), "")
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "registerSha256Invert", "failcc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	sender := sha256.Sum256([]byte("sender"))
+	recv := func(receiver string, payload string, seq uint32, atomicFlag uint8) (pb.Response, string) {
+		msg := testSDPMessageV2(payload, seq)
+		msg.TargetIdentity, msg.AtomicFlag = sha256.Sum256([]byte(receiver)), atomicFlag
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sender, sdp)
+		res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am)))
+		return res, hex.EncodeToString(msg.MessageId[:])
+	}
+	queryAck := func(id string) AckStatus {
+		var status AckStatus
+		res := InvokeWithStrings(t, stub, sp, "queryAckStatus", id)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if err := json.Unmarshal(res.Payload, &status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	res, id := recv("bizcc", "atomic ok", 0, oraclelogic.SDP_ATOMIC_REQUEST)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if status := queryAck(id); status.AtomicFlag != oraclelogic.SDP_ATOMIC_ACK_SUCCESS || status.ErrorMsg != "" {
+		t.Fatalf("unexpected ack status: %+v", status)
+	}
+
+	// 业务链码返回错误时交易成功，回复ACK_ERROR
+	res, id = recv("failcc", "atomic fail", 0, oraclelogic.SDP_ATOMIC_REQUEST)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	status := queryAck(id)
+	if status.AtomicFlag != oraclelogic.SDP_ATOMIC_ACK_ERROR || status.ErrorMsg != "rejected by receiver" || status.SenderDomain != "src.com" {
+		t.Fatalf("unexpected ack status: %+v", status)
+	}
+	author, p2p, _ := oraclelogic.TestRecvAuthMessage(stub.State[status.AckKey])
+	ack, err := oraclelogic.DecodeSDPMessage(p2p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failcc := sha256.Sum256([]byte("failcc"))
+	if string(author) != string(failcc[:]) || ack.TargetDomain != "src.com" || ack.TargetIdentity != sender ||
+		ack.AtomicFlag != oraclelogic.SDP_ATOMIC_ACK_ERROR || ack.ErrorMsg != "rejected by receiver" ||
+		ack.MessageIdHex() != id || ack.Sequence != 0 || ack.Nonce != 42 || string(ack.Payload) != "atomic fail" {
+		t.Fatalf("unexpected ack message: %+v", ack)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryAckStatus", hex.EncodeToString(make([]byte, 32))); res.Status == shim.OK {
+		t.Fatal("ack of unknown message should not be found")
+	}
+
+	// 非原子请求仍然整笔失败
+	if res, _ := recv("failcc", "plain", 1, oraclelogic.SDP_ATOMIC_NONE); res.Status == shim.OK {
+		t.Fatal("non-atomic message should fail with receiver")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 发送消息积压查询
+// 发送消息时按目的域名和消息序号登记索引，中继从零重建时按目的域名分页查询某个序号之后的所有消息，
+// 连同与发送事件相同的消息记录，不需要从头回放区块。
+//
+// 无序消息没有序号，登记在K_UNORDERED_MSG_SEQ下，总是排在有序消息之后返回，
+// 重复提交由目的链的防重放检查拒绝。
+const (
+	// 复合key: crosschain_outbound_index ${destDomain} ${seq} ${msgKey}
+	K_OUTBOUND_OBJECT_TYPE = CROSSCHAIN_PREFIX + "outbound_index"
+)
+
+type BacklogMessage struct {
+	Seq   uint32                      `json:"seq"`
+	Event oraclelogic.OutboundMessage `json:"event"`
+}
+
+type BacklogPage struct {
+	Messages []BacklogMessage `json:"messages"`
+	Bookmark string           `json:"bookmark"`
+}
+
+// 登记发送消息的索引和消息记录，payload为sendMessage返回的消息记录
+func (bs *CrossChain) indexOutboundMessage(stub shim.ChaincodeStubInterface, payload []byte) error {
+	var msg oraclelogic.OutboundMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("failed to parse outbound message: %v", err)
+	}
+	destDomain, seq, err := oraclelogic.ParseOutboundPackage(msg.Package)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(K_OUTBOUND_OBJECT_TYPE, []string{destDomain, fmt.Sprintf("%010d", seq), msg.Key})
+	if err != nil {
+		return err
+	}
+	if err := stub.PutState(key, []byte{0x01}); err != nil {
+		return err
+	}
+	return bs.recordOutboundMessage(stub, msg)
+}
+
+// 按页查询发往目的域名、序号不小于指定值的消息
+// args[0] 目的域名
+// args[1] 起始序号
+// args[2] 每页条数(可选)
+// args[3] 书签(可选)
+func (bs *CrossChain) queryOutboundBacklog(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	fromSeq, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "seq(%s) format error", args[1]))
+	}
+	pageSize, bookmark, err := parsePageArgs(args[2:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+
+	page := BacklogPage{Messages: []BacklogMessage{}}
+	page.Bookmark, err = scanCompositeKeyPage(stub, K_OUTBOUND_OBJECT_TYPE, []string{args[0]}, pageSize, bookmark, func(key string, _ []byte) error {
+		_, attrs, err := stub.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		seq, err := strconv.ParseUint(attrs[1], 10, 32)
+		if err != nil {
+			return err
+		}
+		if seq < fromSeq {
+			return nil
+		}
+		value, err := stub.GetState(attrs[2])
+		if err != nil {
+			return err
+		}
+		page.Messages = append(page.Messages, BacklogMessage{
+			Seq:   uint32(seq),
+			Event: oraclelogic.OutboundMessage{Key: attrs[2], Package: value, Commitment: oraclelogic.MessageCommitment(attrs[2], value)},
+		})
+		return nil
+	})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
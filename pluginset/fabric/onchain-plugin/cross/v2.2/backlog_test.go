@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestOutboundBacklog(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	receiver := hex.EncodeToString(make([]byte, 32))
+	for _, nounce := range []string{"n0", "n1", "n2"} {
+		if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", receiver, "ordered", nounce); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "sendUnorderedMessage", "dest.com", receiver, "unordered", "u"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "sendMessage", "other.com", receiver, "other", "o"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var events [][]oraclelogic.OutboundMessage
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		var msgs []oraclelogic.OutboundMessage
+		_ = json.Unmarshal((<-stub.ChaincodeEventsChannel).Payload, &msgs)
+		events = append(events, msgs)
+	}
+
+	query := func(args ...string) pb.Response {
+		return CallPagedWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().queryOutboundBacklog(stub, args)
+		})
+	}
+	var page BacklogPage
+	if err := json.Unmarshal(query("dest.com", "1").Payload, &page); err != nil {
+		t.Fatal(err)
+	}
+	backlog := page.Messages
+	if len(backlog) != 3 {
+		t.Fatalf("unexpected backlog: %+v", backlog)
+	}
+	expected := []struct {
+		seq   uint32
+		event oraclelogic.OutboundMessage
+	}{
+		{1, events[1][0]},
+		{2, events[2][0]},
+		{oraclelogic.K_UNORDERED_MSG_SEQ, events[3][0]},
+	}
+	for i, e := range expected {
+		if backlog[i].Seq != e.seq {
+			t.Fatalf("unexpected seq %d at %d", backlog[i].Seq, i)
+		}
+		bz, _ := json.Marshal(backlog[i].Event)
+		origin, _ := json.Marshal(e.event)
+		if string(bz) != string(origin) {
+			t.Fatalf("backlog should carry the original event payload: %s != %s", bz, origin)
+		}
+	}
+
+	if res := query("dest.com", "-1"); res.Status == shim.OK {
+		t.Fatal("invalid seq should be rejected")
+	}
+}
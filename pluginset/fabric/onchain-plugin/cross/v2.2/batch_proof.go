@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 批量证明模块
+// PTC对N条AM报文构造RFC6962默克尔树，只对树根出具一次zk证明。中继先提交树根和证明，
+// 链码按来源域名的zk路由校验一次后保存树根；之后每条报文只需提交默克尔包含路径，
+// 校验路径能推导到已保存的树根即可，按普通消息的流程检查序号并回调业务链码。
+//
+// 批量证明的publicInputs依次为: 默克尔根, 来源域名, 批量大小(8字节大端)
+const (
+	// crosschain_zk_batch_${domain}_${root} -> ZKBatch
+	K_ZK_BATCH_PREFIX = CROSSCHAIN_PREFIX + "zk_batch_"
+	// crosschain_zk_batch_consumed_${domain}_${root}_${index} -> 1
+	K_ZK_BATCH_CONSUMED_PREFIX = CROSSCHAIN_PREFIX + "zk_batch_consumed_"
+
+	// 单个批量的报文数上限，包含路径长度不超过log2(上限)
+	MAX_BATCH_SIZE = 1 << 16
+)
+
+type ZKBatch struct {
+	Size     uint64 `json:"size"`
+	Received uint64 `json:"received"`
+}
+
+// 批量中的一条报文及其包含路径
+type BatchMessage struct {
+	Index  uint64   `json:"index"`
+	AMPkg  string   `json:"amPkg"` // hex
+	Path   []string `json:"path"`  // hex，从叶子到根
+	pkgRaw []byte
+}
+
+func zkBatchKey(domain, root string) string {
+	return K_ZK_BATCH_PREFIX + domain + "_" + root
+}
+
+func batchLeafHash(item []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(item)
+	return h.Sum(nil)
+}
+
+func batchInnerHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// 按RFC9162 2.1.3.2校验包含路径
+func verifyBatchInclusion(root []byte, size, index uint64, item []byte, path [][]byte) error {
+	if index >= size {
+		return errors.New("index out of range")
+	}
+	fn, sn := index, size-1
+	r := batchLeafHash(item)
+	for _, p := range path {
+		if sn == 0 {
+			return errors.New("inclusion path too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = batchInnerHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = batchInnerHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("inclusion path too short")
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("merkle root mismatch")
+	}
+	return nil
+}
+
+// 提交批量报文的默克尔根及zk证明
+// args[0] 来源域名
+// args[1] 默克尔根(hex)
+// args[2] 批量大小
+// args[3] zk证明(hex)
+func (bs *CrossChain) submitZKBatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	root, err := hex.DecodeString(args[1])
+	if err != nil || len(root) != sha256.Size {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid batch root"))
+	}
+	size, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil || size == 0 || size > MAX_BATCH_SIZE {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid batch size: %s", args[2]))
+	}
+	proof, err := hex.DecodeString(args[3])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "proof format error"))
+	}
+
+	rootHex := hex.EncodeToString(root)
+	if has, err := getJSONState(stub, zkBatchKey(srcDomain, rootHex), &ZKBatch{}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if has {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "batch already submitted"))
+	}
+
+	route, err := bs.getZKRoute(stub, srcDomain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get ZK route"))
+	}
+	if route == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no zk route for domain %s", srcDomain))
+	}
+	verifier, ok := zkProofVerifiers[route.Scheme]
+	if !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "zk proof scheme %s is not supported", route.Scheme))
+	}
+	vk, _ := hex.DecodeString(route.VerifyingKey)
+	sizeBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(sizeBytes, size)
+	if err := verifier.Verify(vk, proof, [][]byte{root, []byte(srcDomain), sizeBytes}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "zk proof verify failed"))
+	}
+
+	if err := putJSONState(stub, zkBatchKey(srcDomain, rootHex), &ZKBatch{Size: size}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put zk batch"))
+	}
+	return shim.Success(nil)
+}
+
+// 接收已校验批量中的报文，一次可以提交多条，按提交顺序回调业务链码
+// args[0] 来源域名
+// args[1] 默克尔根(hex)
+// args[2] 报文及包含路径(json数组)
+func (bs *CrossChain) recvZKBatchMessages(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	root, err := hex.DecodeString(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid batch root"))
+	}
+	rootHex := hex.EncodeToString(root)
+	var batch ZKBatch
+	if has, err := getJSONState(stub, zkBatchKey(srcDomain, rootHex), &batch); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "batch %s of domain %s not verified", rootHex, srcDomain))
+	}
+	var items []BatchMessage
+	if err := json.Unmarshal([]byte(args[2]), &items); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid batch messages"))
+	}
+	if len(items) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty batch messages"))
+	}
+
+	// 先校验全部包含路径，再处理报文
+	for i := range items {
+		item := &items[i]
+		if item.pkgRaw, err = hex.DecodeString(item.AMPkg); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "message %d: am package format error", i))
+		}
+		path := make([][]byte, len(item.Path))
+		for j, p := range item.Path {
+			if path[j], err = hex.DecodeString(p); err != nil || len(path[j]) != sha256.Size {
+				return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "message %d: invalid inclusion path", i))
+			}
+		}
+		if err := verifyBatchInclusion(root, batch.Size, item.Index, item.pkgRaw, path); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "message %d: inclusion proof verify failed", i))
+		}
+	}
+
+	var msgs []oraclelogic.RecvAuthMessage
+	for i, item := range items {
+		consumedKey := K_ZK_BATCH_CONSUMED_PREFIX + srcDomain + "_" + rootHex + "_" + strconv.FormatUint(item.Index, 10)
+		if consumed, err := stub.GetState(consumedKey); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+		} else if len(consumed) != 0 {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %d already received", i))
+		}
+		ret := bs.Os.RecvAMPackage(stub, srcDomain, hex.EncodeToString(item.pkgRaw))
+		if ret.Status != shim.OK {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, crosserr.FromMessage(crosserr.CodeVerify, ret.Message), "message %d", i))
+		}
+		if err := stub.PutState(consumedKey, []byte{1}); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+		}
+		var msg oraclelogic.RecvAuthMessage
+		if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+		}
+		msgs = append(msgs, msg)
+	}
+
+	batch.Received += uint64(len(items))
+	if err := putJSONState(stub, zkBatchKey(srcDomain, rootHex), &batch); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put zk batch"))
+	}
+	payload, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: msgs})
+	return bs.callbackBizChaincode(stub, payload)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"tmlightclient"
+)
+
+// RFC6962中的PATH(m, D[n])
+func testBatchPath(items [][]byte, index int) [][]byte {
+	if len(items) <= 1 {
+		return nil
+	}
+	k := 1
+	for k*2 < len(items) {
+		k *= 2
+	}
+	if index < k {
+		return append(testBatchPath(items[:k], index), tmlightclient.MerkleRoot(items[k:]))
+	}
+	return append(testBatchPath(items[k:], index-k), tmlightclient.MerkleRoot(items[:k]))
+}
+
+func testBatchMessage(items [][]byte, index int) BatchMessage {
+	msg := BatchMessage{Index: uint64(index), AMPkg: hex.EncodeToString(items[index])}
+	for _, p := range testBatchPath(items, index) {
+		msg.Path = append(msg.Path, hex.EncodeToString(p))
+	}
+	return msg
+}
+
+func TestVerifyBatchInclusion(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		var items [][]byte
+		for i := 0; i < size; i++ {
+			items = append(items, []byte{byte(i)})
+		}
+		root := tmlightclient.MerkleRoot(items)
+		for i := range items {
+			path := testBatchPath(items, i)
+			if err := verifyBatchInclusion(root, uint64(size), uint64(i), items[i], path); err != nil {
+				t.Fatalf("size %d index %d: %v", size, i, err)
+			}
+			if err := verifyBatchInclusion(root, uint64(size), uint64(i), []byte{0xff}, path); err == nil {
+				t.Fatalf("size %d index %d: wrong item should fail", size, i)
+			}
+			if len(path) > 0 {
+				if err := verifyBatchInclusion(root, uint64(size), uint64(i), items[i], path[1:]); err == nil {
+					t.Fatalf("size %d index %d: short path should fail", size, i)
+				}
+			}
+			if err := verifyBatchInclusion(root, uint64(size), uint64(i), items[i], append(path, root)); err == nil {
+				t.Fatalf("size %d index %d: long path should fail", size, i)
+			}
+		}
+		if err := verifyBatchInclusion(root, uint64(size), uint64(size), items[0], nil); err == nil {
+			t.Fatalf("size %d: out of range index should fail", size)
+		}
+	}
+}
+
+func TestRecvZKBatchMessages(t *testing.T) {
+	registerZKProofVerifier("test-hash", &hashZKVerifier{})
+	defer delete(zkProofVerifiers, "test-hash")
+
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setZKRoute", "src.com", "test-hash", "01"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "batch 0", "batch 1", "batch 2")
+	var items [][]byte
+	for _, pkg := range pkgs {
+		raw, _ := hex.DecodeString(pkg)
+		items = append(items, raw)
+	}
+	items = append(items, []byte("padding"))
+	root := tmlightclient.MerkleRoot(items)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(items)))
+	proof := hashZKProof([]byte{0x01}, [][]byte{root, []byte("src.com"), size})
+
+	if res := InvokeWithStrings(t, stub, sp, "recvZKBatchMessages", "src.com", hex.EncodeToString(root), mustJSON([]BatchMessage{testBatchMessage(items, 0)})); res.Status == shim.OK {
+		t.Fatal("message of unverified batch should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitZKBatch", "src.com", hex.EncodeToString(root), "5", hex.EncodeToString(proof)); res.Status == shim.OK {
+		t.Fatal("batch with wrong size should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitZKBatch", "src.com", hex.EncodeToString(root), "4", hex.EncodeToString(proof)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitZKBatch", "src.com", hex.EncodeToString(root), "4", hex.EncodeToString(proof)); res.Status == shim.OK {
+		t.Fatal("batch should not be submitted twice")
+	}
+
+	tampered := testBatchMessage(items, 1)
+	tampered.AMPkg = pkgs[2]
+	if res := InvokeWithStrings(t, stub, sp, "recvZKBatchMessages", "src.com", hex.EncodeToString(root), mustJSON([]BatchMessage{testBatchMessage(items, 0), tampered})); res.Status == shim.OK {
+		t.Fatal("message with wrong inclusion path should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvZKBatchMessages", "src.com", hex.EncodeToString(root), mustJSON([]BatchMessage{testBatchMessage(items, 0), testBatchMessage(items, 1)})); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "batch 1") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvZKBatchMessages", "src.com", hex.EncodeToString(root), mustJSON([]BatchMessage{testBatchMessage(items, 1)})); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvZKBatchMessages", "src.com", hex.EncodeToString(root), mustJSON([]BatchMessage{testBatchMessage(items, 2)})); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "batch 2") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bcdns"
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// BCDNS信任根
+// 管理员设置BCDNS信任根证书后，每个来源域名都要登记为其出具证明的PTC证书，
+// 收消息时按交易时间校验PTC证书链到信任根且都在有效期内，否则拒绝该域名的消息。
+// 登记对端域名时域名证书也必须链到信任根。未设置信任根时不做证书链校验。
+const (
+	// crosschain_bcdns_trust_root -> BCDNSCertRecord
+	K_BCDNS_TRUST_ROOT = CROSSCHAIN_PREFIX + "bcdns_trust_root"
+
+	// crosschain_domain_ptc_cert_${domain} -> BCDNSCertRecord
+	K_DOMAIN_PTC_CERT_PREFIX = CROSSCHAIN_PREFIX + "domain_ptc_cert_"
+)
+
+type BCDNSCertRecord struct {
+	Cert      string `json:"cert"`     // PEM
+	CertId    string `json:"certId"`   // 证书id
+	CertHash  string `json:"certHash"` // sha256(证书内容)，hex
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+func newBCDNSCertRecord(certPEM string, cert *bcdns.CrossChainCertificate, now int64) *BCDNSCertRecord {
+	return &BCDNSCertRecord{Cert: certPEM, CertId: cert.Id, CertHash: sha256Hex(cert.Raw), UpdatedAt: now}
+}
+
+// 未设置信任根时返回nil
+func (bs *CrossChain) getBCDNSTrustRoot(stub shim.ChaincodeStubInterface) (*bcdns.CrossChainCertificate, error) {
+	var record BCDNSCertRecord
+	has, err := getJSONState(stub, K_BCDNS_TRUST_ROOT, &record)
+	if err != nil || !has {
+		return nil, err
+	}
+	return bcdns.DecodePEM(record.Cert)
+}
+
+// 校验证书链到信任根，未设置信任根时不校验
+func (bs *CrossChain) verifyBCDNSChain(stub shim.ChaincodeStubInterface, chain ...*bcdns.CrossChainCertificate) error {
+	root, err := bs.getBCDNSTrustRoot(stub)
+	if err != nil || root == nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return bcdns.VerifyChain(root, now, chain...)
+}
+
+// 设置信任根后，来源域名的PTC证书必须链到信任根
+func (bs *CrossChain) checkPTCCertChain(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage) error {
+	root, err := bs.getBCDNSTrustRoot(stub)
+	if err != nil || root == nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	checked := make(map[string]bool)
+	for _, msg := range msgs {
+		if checked[msg.From] {
+			continue
+		}
+		var record BCDNSCertRecord
+		if has, err := getJSONState(stub, K_DOMAIN_PTC_CERT_PREFIX+msg.From, &record); err != nil {
+			return err
+		} else if !has {
+			return crosserr.New(crosserr.CodeVerify, "no PTC certificate for domain %s", msg.From)
+		}
+		cert, err := bcdns.DecodePEM(record.Cert)
+		if err != nil {
+			return err
+		}
+		if err := bcdns.VerifyChain(root, now, cert); err != nil {
+			return crosserr.New(crosserr.CodeVerify, "PTC certificate of domain %s: %v", msg.From, err)
+		}
+		checked[msg.From] = true
+	}
+	return nil
+}
+
+// 设置BCDNS信任根证书
+// args[0] 信任根证书(PEM)
+func (bs *CrossChain) setBCDNSTrustRoot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	root, err := bcdns.DecodePEM(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "trust root format error"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if err := bcdns.VerifyTrustRoot(root, now); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "trust root verify failed"))
+	}
+	record := newBCDNSCertRecord(args[0], root, now)
+	if err := putJSONState(stub, K_BCDNS_TRUST_ROOT, record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put trust root"))
+	}
+	bz, _ := json.Marshal(record)
+	return shim.Success(bz)
+}
+
+// 查询BCDNS信任根证书
+func (bs *CrossChain) queryBCDNSTrustRoot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	bz, err := stub.GetState(K_BCDNS_TRUST_ROOT)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if bz == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "BCDNS trust root not set"))
+	}
+	return shim.Success(bz)
+}
+
+// 登记为来源域名出具证明的PTC证书，证书必须由信任根签发
+// args[0] 来源域名
+// args[1] PTC证书(PEM)
+func (bs *CrossChain) setDomainPTCCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	cert, err := bcdns.DecodePEM(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "certificate format error"))
+	}
+	if _, err := cert.PTCSubject(); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid PTC certificate"))
+	}
+	root, err := bs.getBCDNSTrustRoot(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BCDNS trust root"))
+	}
+	if root == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "BCDNS trust root not set"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if err := bcdns.VerifyChain(root, now, cert); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "certificate chain verify failed"))
+	}
+	record := newBCDNSCertRecord(args[1], cert, now)
+	if err := putJSONState(stub, K_DOMAIN_PTC_CERT_PREFIX+args[0], record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put PTC certificate"))
+	}
+	bz, _ := json.Marshal(record)
+	return shim.Success(bz)
+}
+
+// 查询来源域名的PTC证书
+// args[0] 来源域名
+func (bs *CrossChain) queryDomainPTCCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bz, err := stub.GetState(K_DOMAIN_PTC_CERT_PREFIX + args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if bz == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no PTC certificate for domain %s", args[0]))
+	}
+	return shim.Success(bz)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bcdns"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"tlv"
+)
+
+// 测试用的BCDNS证书签发者
+type testBCDNSKey struct {
+	priv ed25519.PrivateKey
+	oid  *bcdns.ObjectIdentity
+}
+
+func newTestBCDNSKey() *testBCDNSKey {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	return &testBCDNSKey{priv: priv, oid: &bcdns.ObjectIdentity{Type: bcdns.OID_TYPE_X509_PUBLIC_KEY_INFO, RawId: der}}
+}
+
+// 签发证书，返回PEM
+func (k *testBCDNSKey) issue(t *testing.T, id string, certType uint8, subject interface{}, notAfter uint64) string {
+	raw, err := tlv.Marshal(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &bcdns.CrossChainCertificate{Version: "1", Id: id, Type: certType, Issuer: k.oid, ExpirationDate: notAfter, CredentialSubject: raw}
+	body, _ := cert.EncodedToSign()
+	hash := sha256.Sum256(body)
+	cert.Proof = &bcdns.IssueProof{HashAlgo: "SHA256", CertHash: hash[:], SigAlgo: "Ed25519", RawProof: ed25519.Sign(k.priv, body)}
+	certPEM, err := cert.EncodePEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM
+}
+
+func (k *testBCDNSKey) issueRoot(t *testing.T) string {
+	return k.issue(t, "root", bcdns.CERT_TYPE_BCDNS_TRUST_ROOT, &bcdns.BCDNSTrustRootCredentialSubject{Name: "bcdns", RootOwner: k.oid}, math.MaxInt64)
+}
+
+func (k *testBCDNSKey) issueDomain(t *testing.T, id string, domain string, space string, applicant *testBCDNSKey) string {
+	return k.issue(t, id, bcdns.CERT_TYPE_DOMAIN_NAME, &bcdns.DomainNameCredentialSubject{
+		Version: "1.0", DomainNameType: bcdns.DOMAIN_NAME_TYPE_DOMAIN_NAME, ParentDomainSpace: space, DomainName: domain, Applicant: applicant.oid,
+	}, math.MaxInt64)
+}
+
+func (k *testBCDNSKey) issuePTC(t *testing.T, id string, notAfter uint64) string {
+	return k.issue(t, id, bcdns.CERT_TYPE_PTC, &bcdns.PTCCredentialSubject{Version: "1.0", Name: id, Applicant: newTestBCDNSKey().oid}, notAfter)
+}
+
+func TestBCDNSTrustRoot(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	recv := func(seq uint32) pb.Response {
+		msg := testSDPMessageV2("ptc cert", seq)
+		msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+		return InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am)))
+	}
+	rootKey, otherKey := newTestBCDNSKey(), newTestBCDNSKey()
+	ptcCert := rootKey.issuePTC(t, "ptc", math.MaxInt64)
+
+	// 未设置信任根时不校验
+	if res := recv(0); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setDomainPTCCert", "src.com", ptcCert); res.Status == shim.OK {
+		t.Fatal("ptc certificate should be rejected without trust root")
+	}
+	for _, cert := range []string{"not a cert", ptcCert, otherKey.issue(t, "root", bcdns.CERT_TYPE_BCDNS_TRUST_ROOT,
+		&bcdns.BCDNSTrustRootCredentialSubject{Name: "bcdns", RootOwner: rootKey.oid}, math.MaxInt64)} {
+		if res := InvokeWithStrings(t, stub, sp, "setBCDNSTrustRoot", cert); res.Status == shim.OK {
+			t.Fatal("invalid trust root should be rejected")
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setBCDNSTrustRoot", rootKey.issueRoot(t)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryBCDNSTrustRoot"); res.Status != shim.OK || !strings.Contains(string(res.Payload), `"certId":"root"`) {
+		t.Fatalf("unexpected trust root: %s %s", res.Payload, res.Message)
+	}
+
+	// PTC证书必须由信任根签发
+	for _, cert := range []string{
+		otherKey.issuePTC(t, "other", math.MaxInt64),
+		rootKey.issuePTC(t, "expired", 1),
+		rootKey.issueDomain(t, "domain", "src.com", "", otherKey),
+	} {
+		if res := InvokeWithStrings(t, stub, sp, "setDomainPTCCert", "src.com", cert); res.Status == shim.OK {
+			t.Fatal("invalid ptc certificate should be rejected")
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryDomainPTCCert", "src.com"); res.Status == shim.OK {
+		t.Fatal("ptc certificate should not be set")
+	}
+	// MockStub不回滚失败的交易，被拒绝的消息仍占用序号1
+	if res := recv(1); res.Status == shim.OK || !strings.Contains(res.Message, "no PTC certificate for domain src.com") {
+		t.Fatalf("message without ptc certificate should be rejected: %s", res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setDomainPTCCert", "src.com", ptcCert); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryDomainPTCCert", "src.com"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := recv(2); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 证书过期后拒绝
+	expiring := rootKey.issuePTC(t, "expiring", 2000)
+	bs := NewCrossChain()
+	if res := CallWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.setDomainPTCCert(stub, []string{"src.com", expiring})
+	}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	for now, ok := range map[int64]bool{2000: true, 2001: false} {
+		res := CallWithTimestamp(stub, now, func(stub shim.ChaincodeStubInterface) pb.Response {
+			if err := bs.checkPTCCertChain(stub, []oraclelogic.RecvAuthMessage{{From: "src.com"}}); err != nil {
+				return shim.Error(err.Error())
+			}
+			return shim.Success(nil)
+		})
+		if (res.Status == shim.OK) != ok {
+			t.Fatalf("unexpected result at %d: %s", now, res.Message)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 多租户：一个跨链链码实例承载多个相互隔离的逻辑跨链桥
+// 管理员用createBridge创建桥并指定桥管理员，调用方在方法名前加上桥id访问该桥，如"bizA/sendMessage"，
+// 不带桥id的调用访问默认桥，即升级前的状态。同一通道上的多个业务线不需要各自部署跨链链码。
+//
+// 每个桥的状态写在独立的命名空间中，本链域名、收发序号、中继者白名单、手续费配置等全部隔离：
+//   - 普通key: bridge~${bridgeId}~${key}
+//   - 复合key: 对象类型加上同样的前缀
+//   - 事件名: ${bridgeId}/${eventName}
+//
+// 富查询的结果只保留本桥的状态，但默认桥的富查询会返回所有桥的状态。业务链码向某个桥发送消息时同样在方法名前加上桥id，
+// 中继监听该桥时按上面的规则匹配消息key和事件名。
+const (
+	// crosschain_bridge_${bridgeId} -> BridgeRecord，保存在默认桥中
+	K_BRIDGE_PREFIX = CROSSCHAIN_PREFIX + "bridge_"
+
+	BRIDGE_NAMESPACE_PREFIX = "bridge~"
+	BRIDGE_FN_SEPARATOR     = "/"
+)
+
+var bridgeIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+type BridgeRecord struct {
+	BridgeId  string `json:"bridgeId"`
+	CreatedAt int64  `json:"createdAt"`
+	// 创建交易的发起者证书sha256(hex)
+	Creator string `json:"creator"`
+}
+
+// 拆分方法名中的桥id，不带桥id时返回空
+func splitBridgeFn(fn string) (string, string) {
+	if i := strings.Index(fn, BRIDGE_FN_SEPARATOR); i >= 0 {
+		return fn[:i], fn[i+1:]
+	}
+	return "", fn
+}
+
+// 进入桥的命名空间，桥不存在时返回错误
+func openBridge(stub shim.ChaincodeStubInterface, bridgeId string) (shim.ChaincodeStubInterface, error) {
+	var record BridgeRecord
+	if has, err := getJSONState(stub, K_BRIDGE_PREFIX+bridgeId, &record); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "bridge %s not found", bridgeId)
+	}
+	return newBridgeStub(stub, bridgeId), nil
+}
+
+// 创建桥
+// args[0] 桥id，字母、数字、_和-，不超过32个字符
+// args[1] 桥管理员证书，x509公钥证书
+func (bs *CrossChain) createBridge(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bridgeId := args[0]
+	if !bridgeIdPattern.MatchString(bridgeId) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "bridge id(%s) format error", bridgeId))
+	}
+	raw, err := stub.GetState(K_BRIDGE_PREFIX + bridgeId)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(raw) != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "bridge %s already exists", bridgeId))
+	}
+	record := BridgeRecord{BridgeId: bridgeId}
+	if record.CreatedAt, err = getTxTimestamp(stub); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if _, record.Creator, err = getCreatorIdentity(stub); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	if err := putJSONState(stub, K_BRIDGE_PREFIX+bridgeId, &record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put bridge"))
+	}
+	// 新桥还没有管理员，SetAdmin直接写入，不会被其他人抢先设置
+	if ret := bs.Os.SetAdmin(newBridgeStub(stub, bridgeId), []byte(args[1])); ret.Status != shim.OK {
+		return ret
+	}
+	raw, _ = json.Marshal(&record)
+	return shim.Success(raw)
+}
+
+// 查询桥
+// args[0] 桥id，不填时返回全部桥
+func (bs *CrossChain) queryBridges(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) > 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	prefix := K_BRIDGE_PREFIX
+	if len(args) == 1 {
+		var record BridgeRecord
+		if has, err := getJSONState(stub, prefix+args[0], &record); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+		} else if !has {
+			return errorResponse("", crosserr.New(crosserr.CodeNotFound, "bridge %s not found", args[0]))
+		}
+		raw, _ := json.Marshal(&record)
+		return shim.Success(raw)
+	}
+	iter, err := stub.GetStateByRange(prefix, prefix+string(utf8.MaxRune))
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	defer iter.Close()
+	records := []BridgeRecord{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to iterate state"))
+		}
+		var record BridgeRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse bridge %s", kv.Key))
+		}
+		records = append(records, record)
+	}
+	raw, _ := json.Marshal(records)
+	return shim.Success(raw)
+}
+
+// 把状态读写映射到桥的命名空间，其余方法直接调用原stub
+type bridgeStub struct {
+	shim.ChaincodeStubInterface
+	bridgeId  string
+	namespace string
+}
+
+func newBridgeStub(stub shim.ChaincodeStubInterface, bridgeId string) *bridgeStub {
+	return &bridgeStub{ChaincodeStubInterface: stub, bridgeId: bridgeId, namespace: BRIDGE_NAMESPACE_PREFIX + bridgeId + "~"}
+}
+
+// 桥内的key映射为底层的key
+func (s *bridgeStub) mapKey(key string) (string, error) {
+	if !strings.HasPrefix(key, "\x00") {
+		return s.namespace + key, nil
+	}
+	objectType, attrs, err := s.ChaincodeStubInterface.SplitCompositeKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.ChaincodeStubInterface.CreateCompositeKey(s.namespace+objectType, attrs)
+}
+
+// 底层的key映射回桥内的key，不属于本桥时返回false
+func (s *bridgeStub) unmapKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, "\x00") {
+		if !strings.HasPrefix(key, s.namespace) {
+			return "", false
+		}
+		return strings.TrimPrefix(key, s.namespace), true
+	}
+	objectType, attrs, err := s.ChaincodeStubInterface.SplitCompositeKey(key)
+	if err != nil || !strings.HasPrefix(objectType, s.namespace) {
+		return "", false
+	}
+	key, err = s.ChaincodeStubInterface.CreateCompositeKey(strings.TrimPrefix(objectType, s.namespace), attrs)
+	return key, err == nil
+}
+
+// 范围查询的结束key，为空表示不限
+func (s *bridgeStub) mapRangeEnd(key string) (string, error) {
+	if key == "" {
+		return s.namespace + string(utf8.MaxRune), nil
+	}
+	return s.mapKey(key)
+}
+
+func (s *bridgeStub) mapBookmark(bookmark string) (string, error) {
+	if bookmark == "" {
+		return "", nil
+	}
+	return s.mapKey(bookmark)
+}
+
+func (s *bridgeStub) unmapMetadata(meta *pb.QueryResponseMetadata) *pb.QueryResponseMetadata {
+	if meta == nil || meta.Bookmark == "" {
+		return meta
+	}
+	if bookmark, ok := s.unmapKey(meta.Bookmark); ok {
+		return &pb.QueryResponseMetadata{FetchedRecordsCount: meta.FetchedRecordsCount, Bookmark: bookmark}
+	}
+	// 富查询的书签不是key，原样返回
+	return meta
+}
+
+func (s *bridgeStub) GetState(key string) ([]byte, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetState(k)
+}
+
+func (s *bridgeStub) PutState(key string, value []byte) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.PutState(k, value)
+}
+
+func (s *bridgeStub) DelState(key string) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.DelState(k)
+}
+
+func (s *bridgeStub) SetStateValidationParameter(key string, ep []byte) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.SetStateValidationParameter(k, ep)
+}
+
+func (s *bridgeStub) GetStateValidationParameter(key string) ([]byte, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetStateValidationParameter(k)
+}
+
+func (s *bridgeStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	start, err := s.mapKey(startKey)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.mapRangeEnd(endKey)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := s.ChaincodeStubInterface.GetStateByRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	start, err := s.mapKey(startKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	end, err := s.mapRangeEnd(endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bookmark, err = s.mapBookmark(bookmark); err != nil {
+		return nil, nil, err
+	}
+	iter, meta, err := s.ChaincodeStubInterface.GetStateByRangeWithPagination(start, end, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, s.unmapMetadata(meta), nil
+}
+
+func (s *bridgeStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	iter, err := s.ChaincodeStubInterface.GetStateByPartialCompositeKey(s.namespace+objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	bookmark, err := s.mapBookmark(bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	iter, meta, err := s.ChaincodeStubInterface.GetStateByPartialCompositeKeyWithPagination(s.namespace+objectType, keys, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, s.unmapMetadata(meta), nil
+}
+
+func (s *bridgeStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	iter, err := s.ChaincodeStubInterface.GetQueryResult(query)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	iter, meta, err := s.ChaincodeStubInterface.GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, meta, nil
+}
+
+func (s *bridgeStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetHistoryForKey(k)
+}
+
+func (s *bridgeStub) GetPrivateData(collection, key string) ([]byte, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetPrivateData(collection, k)
+}
+
+func (s *bridgeStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetPrivateDataHash(collection, k)
+}
+
+func (s *bridgeStub) PutPrivateData(collection string, key string, value []byte) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.PutPrivateData(collection, k, value)
+}
+
+func (s *bridgeStub) DelPrivateData(collection, key string) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.DelPrivateData(collection, k)
+}
+
+func (s *bridgeStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return err
+	}
+	return s.ChaincodeStubInterface.SetPrivateDataValidationParameter(collection, k, ep)
+}
+
+func (s *bridgeStub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	k, err := s.mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChaincodeStubInterface.GetPrivateDataValidationParameter(collection, k)
+}
+
+func (s *bridgeStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	start, err := s.mapKey(startKey)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.mapRangeEnd(endKey)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := s.ChaincodeStubInterface.GetPrivateDataByRange(collection, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	iter, err := s.ChaincodeStubInterface.GetPrivateDataByPartialCompositeKey(collection, s.namespace+objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	iter, err := s.ChaincodeStubInterface.GetPrivateDataQueryResult(collection, query)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeIterator{StateQueryIteratorInterface: iter, stub: s}, nil
+}
+
+func (s *bridgeStub) SetEvent(name string, payload []byte) error {
+	return s.ChaincodeStubInterface.SetEvent(s.bridgeId+BRIDGE_FN_SEPARATOR+name, payload)
+}
+
+// 把结果的key映射回桥内的key，跳过不属于本桥的结果(仅富查询会出现)
+type bridgeIterator struct {
+	shim.StateQueryIteratorInterface
+	stub *bridgeStub
+	next *queryresult.KV
+	err  error
+}
+
+func (it *bridgeIterator) fetch() {
+	for it.next == nil && it.err == nil && it.StateQueryIteratorInterface.HasNext() {
+		kv, err := it.StateQueryIteratorInterface.Next()
+		if err != nil {
+			it.err = err
+			return
+		}
+		if key, ok := it.stub.unmapKey(kv.Key); ok {
+			it.next = &queryresult.KV{Namespace: kv.Namespace, Key: key, Value: kv.Value}
+		}
+	}
+}
+
+func (it *bridgeIterator) HasNext() bool {
+	it.fetch()
+	return it.next != nil || it.err != nil
+}
+
+func (it *bridgeIterator) Next() (*queryresult.KV, error) {
+	it.fetch()
+	if it.err != nil {
+		err := it.err
+		it.err = nil
+		return nil, err
+	}
+	if it.next == nil {
+		return nil, fmt.Errorf("no more results")
+	}
+	kv := it.next
+	it.next = nil
+	return kv, nil
+}
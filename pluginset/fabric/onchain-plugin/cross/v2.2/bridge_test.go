@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestBridges(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	admin := stub.Creator
+	bridgeAdmin := MockCreator(TEST_RELAYER_CERT)
+	invokeAs := func(creator []byte, args ...string) (bool, string, []byte) {
+		stub.Creator = creator
+		defer func() { stub.Creator = admin }()
+		res := InvokeWithStrings(t, stub, sp, args...)
+		return res.Status == shim.OK, res.Message, res.Payload
+	}
+	mustInvoke := func(creator []byte, args ...string) []byte {
+		ok, msg, payload := invokeAs(creator, args...)
+		if !ok {
+			t.Fatalf("%v: %s", args, msg)
+		}
+		return payload
+	}
+
+	var record BridgeRecord
+	if err := json.Unmarshal(mustInvoke(admin, "createBridge", "bizA", TEST_RELAYER_CERT), &record); err != nil || record.BridgeId != "bizA" ||
+		record.Creator != testCertHash(TEST_ADMIN_CERT) {
+		t.Fatalf("unexpected bridge: %+v", record)
+	}
+	mustInvoke(admin, "oracleAdminManage", "setExpectedDomain", "fabric.test")
+	mustInvoke(bridgeAdmin, "bizA/oracleAdminManage", "setExpectedDomain", "biza.fabric.test")
+	if !strings.HasPrefix(string(stub.State[BRIDGE_NAMESPACE_PREFIX+"bizA~"+oraclelogic.K_EXPECTED_DOMAIN]), "biza.fabric.test") ||
+		!strings.HasPrefix(string(stub.State[oraclelogic.K_EXPECTED_DOMAIN]), "fabric.test") {
+		t.Fatal("expected domain should be isolated")
+	}
+	// 两个桥的管理员互不相通
+	if ok, _, _ := invokeAs(admin, "bizA/setRelayConfig", "true", "60"); ok {
+		t.Fatal("default admin should not manage bridge")
+	}
+	if ok, _, _ := invokeAs(bridgeAdmin, "setRelayConfig", "true", "60"); ok {
+		t.Fatal("bridge admin should not manage default bridge")
+	}
+
+	// 序号各自独立
+	receiver := sha256.Sum256([]byte("dest"))
+	send := func(fn string) MessageRecord {
+		var msg oraclelogic.OutboundMessage
+		_ = json.Unmarshal(mustInvoke(admin, fn, "dest.com", hex.EncodeToString(receiver[:]), "hello", "n"), &msg)
+		prefix := ""
+		if bridgeId, _ := splitBridgeFn(fn); bridgeId != "" {
+			prefix = BRIDGE_NAMESPACE_PREFIX + bridgeId + "~"
+		}
+		var record MessageRecord
+		if err := json.Unmarshal(stub.State[prefix+outboundRecordKey(msg.Key)], &record); err != nil {
+			t.Fatalf("no record of %s: %v", msg.Key, err)
+		}
+		return record
+	}
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+	if r := send("sendMessage"); r.Seq != 0 || r.SrcDomain != "fabric.test" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	if r := send("sendMessage"); r.Seq != 1 {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	if r := send("bizA/sendMessage"); r.Seq != 0 || r.SrcDomain != "biza.fabric.test" {
+		t.Fatalf("unexpected bridge record: %+v", r)
+	}
+	var events []string
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		events = append(events, (<-stub.ChaincodeEventsChannel).EventName)
+	}
+	if len(events) != 3 || events[0] != K_OUTBOUND_EVENT || events[2] != "bizA/"+K_OUTBOUND_EVENT {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	// 复合key同样隔离
+	mustInvoke(bridgeAdmin, "bizA/setRelayerACL", RELAYER_ACL_KIND_MSP, "Org1MSP", "true")
+	var entry RelayerACLEntry
+	_ = json.Unmarshal(mustInvoke(admin, "queryRelayerACL", RELAYER_ACL_KIND_MSP, "Org1MSP"), &entry)
+	if entry.Allowed {
+		t.Fatal("relayer acl should be isolated")
+	}
+	_ = json.Unmarshal(mustInvoke(admin, "bizA/queryRelayerACL", RELAYER_ACL_KIND_MSP, "Org1MSP"), &entry)
+	if !entry.Allowed {
+		t.Fatal("relayer acl should be set in bridge")
+	}
+
+	var records []BridgeRecord
+	if err := json.Unmarshal(mustInvoke(bridgeAdmin, "queryBridges"), &records); err != nil || len(records) != 1 || records[0].BridgeId != "bizA" {
+		t.Fatalf("unexpected bridges: %v", records)
+	}
+	for _, c := range []struct {
+		creator []byte
+		args    []string
+	}{
+		{admin, []string{"createBridge", "bizA", TEST_RELAYER_CERT}},
+		{admin, []string{"createBridge", "biz/B", TEST_RELAYER_CERT}},
+		{admin, []string{"createBridge", "", TEST_RELAYER_CERT}},
+		{bridgeAdmin, []string{"createBridge", "bizB", TEST_RELAYER_CERT}},
+		{bridgeAdmin, []string{"bizA/createBridge", "bizB", TEST_RELAYER_CERT}},
+		{admin, []string{"bizB/sendMessage", "dest.com", hex.EncodeToString(receiver[:]), "hello", "n"}},
+		{admin, []string{"queryBridges", "bizB"}},
+	} {
+		if ok, _, _ := invokeAs(c.creator, c.args...); ok {
+			t.Fatalf("%v should be rejected", c.args)
+		}
+	}
+}
+
+func TestBridgeStubIteration(t *testing.T) {
+	mock := shimtest.NewMockStub("crosschain", new(CrossChain))
+	mock.MockTransactionStart(txid)
+	defer mock.MockTransactionEnd(txid)
+	a, b := newBridgeStub(mock, "a"), newBridgeStub(mock, "b")
+	for _, s := range []shim.ChaincodeStubInterface{mock, a, b} {
+		for _, key := range []string{"k1", "k2"} {
+			if err := s.PutState(key, []byte(key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ck, _ := s.CreateCompositeKey("obj", []string{"x", "y"})
+		_ = s.PutState(ck, []byte("composite"))
+	}
+
+	iter, err := a.GetStateByRange("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		keys = append(keys, kv.Key)
+	}
+	iter.Close()
+	if strings.Join(keys, ",") != "k1,k2" {
+		t.Fatalf("unexpected range keys: %v", keys)
+	}
+
+	iter, err = b.GetStateByPartialCompositeKey("obj", []string{"x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = nil
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		objectType, attrs, err := b.SplitCompositeKey(kv.Key)
+		if err != nil || objectType != "obj" || len(attrs) != 2 {
+			t.Fatalf("unexpected composite key %q", kv.Key)
+		}
+		if v, _ := b.GetState(kv.Key); string(v) != "composite" {
+			t.Fatal("composite key should be readable by returned key")
+		}
+		keys = append(keys, kv.Key)
+	}
+	iter.Close()
+	if len(keys) != 1 {
+		t.Fatalf("unexpected composite keys: %v", keys)
+	}
+
+	// 分页书签同样映射
+	bookmark, _ := a.mapBookmark("k2")
+	if meta := a.unmapMetadata(&pb.QueryResponseMetadata{Bookmark: bookmark}); bookmark != BRIDGE_NAMESPACE_PREFIX+"a~k2" || meta.Bookmark != "k2" {
+		t.Fatalf("unexpected bookmark: %s %s", bookmark, meta.Bookmark)
+	}
+}
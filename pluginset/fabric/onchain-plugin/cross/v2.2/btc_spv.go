@@ -0,0 +1,504 @@
+package main
+
+import (
+	"btcspv"
+	"bytes"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math/big"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 比特币SPV模块
+// 中继从可信检查点开始提交比特币区块头，链码校验工作量证明、难度调整和时间戳后保存区块头，
+// 按累计工作量选择主链；接收消息时中继提交锚定交易及其默克尔证明，
+// 链码校验交易包含在主链区块中且确认数足够后，按普通消息的流程检查序号并回调业务链码。
+//
+// 锚定交易的第一个输入由锚定公钥以P2WPKH方式花费，
+// 并包含一个OP_RETURN输出，数据为 "ACB1" || sha256(AM报文)。
+const (
+	// crosschain_btc_spv_${domain} -> BTCSPVState
+	K_BTC_SPV_PREFIX = CROSSCHAIN_PREFIX + "btc_spv_"
+	// crosschain_btc_header_${domain}_${blockHash} -> BTCHeaderRecord
+	K_BTC_HEADER_PREFIX = CROSSCHAIN_PREFIX + "btc_header_"
+	// crosschain_btc_height_${domain}_${height} -> 主链区块哈希
+	K_BTC_HEIGHT_PREFIX = CROSSCHAIN_PREFIX + "btc_height_"
+	// crosschain_btc_consumed_${domain}_${txid} -> 1
+	K_BTC_CONSUMED_PREFIX = CROSSCHAIN_PREFIX + "btc_consumed_"
+
+	BTC_AM_COMMITMENT_TAG = "ACB1"
+	// 默认确认数
+	BTC_DEFAULT_CONFIRMATIONS = 6
+)
+
+type BTCSPVState struct {
+	Network          string `json:"network"`
+	CheckpointHeight int64  `json:"checkpointHeight"`
+	TipHash          string `json:"tipHash"`
+	TipHeight        int64  `json:"tipHeight"`
+	Confirmations    int64  `json:"confirmations"`
+	// 锚定公钥(压缩格式hex)
+	AnchorPubKey string `json:"anchorPubKey"`
+}
+
+type BTCHeaderRecord struct {
+	Header    string `json:"header"` // hex
+	Height    int64  `json:"height"`
+	ChainWork string `json:"chainWork"` // hex
+}
+
+func (r *BTCHeaderRecord) parse() (*btcspv.BlockHeader, *big.Int, error) {
+	raw, err := hex.DecodeString(r.Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := btcspv.ParseHeader(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	work, ok := new(big.Int).SetString(r.ChainWork, 16)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid chain work %s", r.ChainWork)
+	}
+	return header, work, nil
+}
+
+func btcHeaderKey(domain, hash string) string {
+	return K_BTC_HEADER_PREFIX + domain + "_" + hash
+}
+
+func btcHeightKey(domain string, height int64) string {
+	return K_BTC_HEIGHT_PREFIX + domain + "_" + strconv.FormatInt(height, 10)
+}
+
+func (bs *CrossChain) getBTCSPVState(stub shim.ChaincodeStubInterface, domain string) (*BTCSPVState, error) {
+	var state BTCSPVState
+	has, err := getJSONState(stub, K_BTC_SPV_PREFIX+domain, &state)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "btc spv for domain %s not initialized", domain)
+	}
+	return &state, nil
+}
+
+func (bs *CrossChain) getBTCHeader(stub shim.ChaincodeStubInterface, domain, hash string) (*BTCHeaderRecord, error) {
+	var record BTCHeaderRecord
+	has, err := getJSONState(stub, btcHeaderKey(domain, hash), &record)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (bs *CrossChain) getBTCMainChainHash(stub shim.ChaincodeStubInterface, domain string, height int64) (string, error) {
+	hash, err := stub.GetState(btcHeightKey(domain, height))
+	return string(hash), err
+}
+
+// 取record所在链上指定高度的祖先区块
+// 沿父区块回溯直到遇到主链区块，之后按高度索引查询
+func (bs *CrossChain) getBTCAncestor(stub shim.ChaincodeStubInterface, domain string, record *BTCHeaderRecord, height int64) (*BTCHeaderRecord, error) {
+	for record.Height > height {
+		header, _, err := record.parse()
+		if err != nil {
+			return nil, err
+		}
+		hash := header.BlockHash().String()
+		if main, err := bs.getBTCMainChainHash(stub, domain, record.Height); err != nil {
+			return nil, err
+		} else if main == hash {
+			main, err = bs.getBTCMainChainHash(stub, domain, height)
+			if err != nil {
+				return nil, err
+			}
+			return bs.getBTCHeader(stub, domain, main)
+		}
+		if record, err = bs.getBTCHeader(stub, domain, header.PrevBlock.String()); err != nil {
+			return nil, err
+		} else if record == nil {
+			return nil, fmt.Errorf("missing ancestor of block %s", hash)
+		}
+	}
+	return record, nil
+}
+
+// 初始化来源域名的比特币SPV
+// args[0] 来源域名
+// args[1] 网络(mainnet/regtest)
+// args[2] 检查点高度，除regtest外必须是难度调整周期的第一个区块
+// args[3] 检查点区块头(hex)
+// args[4] 锚定公钥(hex)
+func (bs *CrossChain) initBTCSPV(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	if domain == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	params, ok := btcspv.NetworkParams[args[1]]
+	if !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unsupported network %s", args[1]))
+	}
+	height, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || height < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid checkpoint height: %s", args[2]))
+	}
+	if !params.NoRetargeting && height%params.BlocksPerRetarget() != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "checkpoint must be the first block of a retarget period"))
+	}
+	raw, err := hex.DecodeString(args[3])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid header"))
+	}
+	header, err := btcspv.ParseHeader(raw)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "header format error"))
+	}
+	if err := btcspv.CheckProofOfWork(header, params); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "checkpoint verify failed"))
+	}
+	if pubkey, err := hex.DecodeString(args[4]); err != nil || len(pubkey) != 33 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid anchor public key"))
+	}
+
+	hash := header.BlockHash().String()
+	record := &BTCHeaderRecord{Header: args[3], Height: height, ChainWork: btcspv.CalcWork(header.Bits).Text(16)}
+	if err := putJSONState(stub, btcHeaderKey(domain, hash), record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put header"))
+	}
+	if err := stub.PutState(btcHeightKey(domain, height), []byte(hash)); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	state := &BTCSPVState{
+		Network:          params.Name,
+		CheckpointHeight: height,
+		TipHash:          hash,
+		TipHeight:        height,
+		Confirmations:    BTC_DEFAULT_CONFIRMATIONS,
+		AnchorPubKey:     args[4],
+	}
+	if err := putJSONState(stub, K_BTC_SPV_PREFIX+domain, state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 设置消息所在区块需要的确认数
+// args[0] 来源域名
+// args[1] 确认数
+func (bs *CrossChain) setBTCConfirmations(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	state, err := bs.getBTCSPVState(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTCSPV state"))
+	}
+	confirmations, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || confirmations < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid confirmations: %s", args[1]))
+	}
+	state.Confirmations = confirmations
+	if err := putJSONState(stub, K_BTC_SPV_PREFIX+args[0], state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 提交区块头，可以一次提交多个连续的区块头
+// args[0] 来源域名
+// args[1] 区块头(hex)，多个区块头直接拼接
+func (bs *CrossChain) submitBTCHeaders(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	state, err := bs.getBTCSPVState(stub, domain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTCSPV state"))
+	}
+	raw, err := hex.DecodeString(args[1])
+	if err != nil || len(raw) == 0 || len(raw)%btcspv.HEADER_SIZE != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid headers"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	for i := 0; i < len(raw); i += btcspv.HEADER_SIZE {
+		if err := bs.connectBTCHeader(stub, domain, state, raw[i:i+btcspv.HEADER_SIZE], now); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "header %d", i/btcspv.HEADER_SIZE))
+		}
+	}
+	if err := putJSONState(stub, K_BTC_SPV_PREFIX+domain, state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 校验并保存一个区块头，累计工作量超过当前主链时切换主链
+func (bs *CrossChain) connectBTCHeader(stub shim.ChaincodeStubInterface, domain string, state *BTCSPVState, raw []byte, now int64) error {
+	params := btcspv.NetworkParams[state.Network]
+	header, err := btcspv.ParseHeader(raw)
+	if err != nil {
+		return err
+	}
+	hash := header.BlockHash().String()
+	if existing, err := bs.getBTCHeader(stub, domain, hash); err != nil {
+		return err
+	} else if existing != nil {
+		return nil
+	}
+	prev, err := bs.getBTCHeader(stub, domain, header.PrevBlock.String())
+	if err != nil {
+		return err
+	}
+	if prev == nil {
+		return fmt.Errorf("unknown previous block %s", header.PrevBlock.String())
+	}
+	prevHeader, prevWork, err := prev.parse()
+	if err != nil {
+		return err
+	}
+	height := prev.Height + 1
+
+	// 难度
+	expectedBits := prevHeader.Bits
+	if !params.NoRetargeting && height%params.BlocksPerRetarget() == 0 {
+		first, err := bs.getBTCAncestor(stub, domain, prev, height-params.BlocksPerRetarget())
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			return fmt.Errorf("missing first block of retarget period")
+		}
+		firstHeader, _, err := first.parse()
+		if err != nil {
+			return err
+		}
+		expectedBits = btcspv.CalcNextBits(params, int64(firstHeader.Timestamp), prevHeader)
+	}
+	if header.Bits != expectedBits {
+		return fmt.Errorf("unexpected bits %08x, expected %08x", header.Bits, expectedBits)
+	}
+	if err := btcspv.CheckProofOfWork(header, params); err != nil {
+		return err
+	}
+
+	// 时间戳需大于前11个区块时间的中位数，且不能超过当前时间2小时
+	timestamps := []uint32{prevHeader.Timestamp}
+	for cur := prev; len(timestamps) < btcspv.MEDIAN_TIME_BLOCKS && cur.Height > state.CheckpointHeight; {
+		curHeader, _, err := cur.parse()
+		if err != nil {
+			return err
+		}
+		if cur, err = bs.getBTCHeader(stub, domain, curHeader.PrevBlock.String()); err != nil {
+			return err
+		} else if cur == nil {
+			break
+		}
+		ancestor, _, err := cur.parse()
+		if err != nil {
+			return err
+		}
+		timestamps = append(timestamps, ancestor.Timestamp)
+	}
+	if header.Timestamp <= btcspv.MedianTime(timestamps) {
+		return fmt.Errorf("block timestamp %d is not after median time past", header.Timestamp)
+	}
+	if int64(header.Timestamp) > now+2*60*60 {
+		return fmt.Errorf("block timestamp %d is too far in the future", header.Timestamp)
+	}
+
+	work := new(big.Int).Add(prevWork, btcspv.CalcWork(header.Bits))
+	record := &BTCHeaderRecord{Header: hex.EncodeToString(raw), Height: height, ChainWork: work.Text(16)}
+	if err := putJSONState(stub, btcHeaderKey(domain, hash), record); err != nil {
+		return err
+	}
+
+	tip, err := bs.getBTCHeader(stub, domain, state.TipHash)
+	if err != nil {
+		return err
+	}
+	_, tipWork, err := tip.parse()
+	if err != nil {
+		return err
+	}
+	if work.Cmp(tipWork) <= 0 {
+		return nil
+	}
+	return bs.reorgBTCMainChain(stub, domain, state, record, hash)
+}
+
+// 以新区块为主链末端，重写高度索引直到与原主链的分叉点
+func (bs *CrossChain) reorgBTCMainChain(stub shim.ChaincodeStubInterface, domain string, state *BTCSPVState, tip *BTCHeaderRecord, tipHash string) error {
+	for height := tip.Height + 1; height <= state.TipHeight; height++ {
+		if err := stub.DelState(btcHeightKey(domain, height)); err != nil {
+			return err
+		}
+	}
+	state.TipHash, state.TipHeight = tipHash, tip.Height
+
+	record, hash := tip, tipHash
+	for {
+		main, err := bs.getBTCMainChainHash(stub, domain, record.Height)
+		if err != nil {
+			return err
+		}
+		if main == hash {
+			return nil
+		}
+		if record.Height <= state.CheckpointHeight {
+			return fmt.Errorf("reorg below checkpoint")
+		}
+		if err := stub.PutState(btcHeightKey(domain, record.Height), []byte(hash)); err != nil {
+			return err
+		}
+		header, _, err := record.parse()
+		if err != nil {
+			return err
+		}
+		hash = header.PrevBlock.String()
+		if record, err = bs.getBTCHeader(stub, domain, hash); err != nil {
+			return err
+		} else if record == nil {
+			return fmt.Errorf("missing block %s", hash)
+		}
+	}
+}
+
+// 查询比特币SPV状态
+// args[0] 来源域名
+func (bs *CrossChain) queryBTCSPV(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	state, err := bs.getBTCSPVState(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTCSPV state"))
+	}
+	status, _ := json.Marshal(state)
+	return shim.Success(status)
+}
+
+// 接收比特币锚定的跨链消息
+// args[0] 来源域名
+// args[1] 区块哈希
+// args[2] 锚定交易(hex)
+// args[3] 交易在区块中的序号
+// args[4] 默克尔证明(json)，从叶子到根的兄弟节点哈希
+// args[5] AM报文(hex)
+func (bs *CrossChain) recvBTCMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 6 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	state, err := bs.getBTCSPVState(stub, srcDomain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTCSPV state"))
+	}
+	record, err := bs.getBTCHeader(stub, srcDomain, args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTC header"))
+	}
+	if record == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "unknown block %s", args[1]))
+	}
+	if main, err := bs.getBTCMainChainHash(stub, srcDomain, record.Height); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get BTC main chain hash"))
+	} else if main != args[1] {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "block is not in main chain"))
+	}
+	if confirmations := state.TipHeight - record.Height + 1; confirmations < state.Confirmations {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "block has %d confirmations, %d required", confirmations, state.Confirmations))
+	}
+	header, _, err := record.parse()
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse header record"))
+	}
+
+	rawTx, err := hex.DecodeString(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid tx"))
+	}
+	tx, err := btcspv.ParseTx(rawTx)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "tx format error"))
+	}
+	index, err := strconv.ParseUint(args[3], 10, 32)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid tx index"))
+	}
+	// 锚定交易不可能是coinbase
+	if index == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "coinbase tx is not accepted"))
+	}
+	var branchStr []string
+	if err := json.Unmarshal([]byte(args[4]), &branchStr); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid merkle branch"))
+	}
+	branch := make([]btcspv.Hash, len(branchStr))
+	for i, s := range branchStr {
+		if branch[i], err = btcspv.HashFromString(s); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "merkle branch format error"))
+		}
+	}
+	if err := btcspv.VerifyMerkleBranch(tx.TxID, uint32(index), branch, header.MerkleRoot); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "merkle proof verify failed"))
+	}
+
+	// 锚定交易由锚定公钥签名
+	anchorPubKey, _ := hex.DecodeString(state.AnchorPubKey)
+	if witness := tx.Inputs[0].Witness; len(tx.Inputs[0].SigScript) != 0 || len(witness) != 2 || !bytes.Equal(witness[1], anchorPubKey) {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "tx is not signed by anchor key"))
+	}
+	pkg, err := hex.DecodeString(args[5])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "am package format error"))
+	}
+	commitment := sha256.Sum256(pkg)
+	expected := append([]byte(BTC_AM_COMMITMENT_TAG), commitment[:]...)
+	found := false
+	for _, out := range tx.Outputs {
+		if data, ok := btcspv.NullData(out.PkScript); ok && bytes.Equal(data, expected) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "am package commitment not found in tx"))
+	}
+
+	consumedKey := K_BTC_CONSUMED_PREFIX + srcDomain + "_" + tx.TxID.String()
+	if consumed, err := stub.GetState(consumedKey); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if len(consumed) != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message already received"))
+	}
+
+	ret := bs.Os.RecvAMPackage(stub, srcDomain, args[5])
+	if ret.Status != shim.OK {
+		return ret
+	}
+	if err := stub.PutState(consumedKey, []byte{1}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	var msg oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+	}
+	msgs, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{msg}})
+	return bs.callbackBizChaincode(stub, msgs)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"btcspv"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 挖出满足regtest难度的区块头
+func testBTCMine(prev btcspv.Hash, merkleRoot btcspv.Hash, timestamp uint32) *btcspv.BlockHeader {
+	header := &btcspv.BlockHeader{Version: 0x20000000, PrevBlock: prev, MerkleRoot: merkleRoot, Timestamp: timestamp, Bits: 0x207fffff}
+	for btcspv.CheckProofOfWork(header, btcspv.RegTestParams) != nil {
+		header.Nonce++
+	}
+	return header
+}
+
+func testBTCChain(prev *btcspv.BlockHeader, n int, roots ...btcspv.Hash) []*btcspv.BlockHeader {
+	var headers []*btcspv.BlockHeader
+	for i := 0; i < n; i++ {
+		var root btcspv.Hash
+		if i < len(roots) {
+			root = roots[i]
+		} else {
+			root = btcspv.DoubleSha256([]byte{byte(i), byte(n)})
+		}
+		prev = testBTCMine(prev.BlockHash(), root, prev.Timestamp+600)
+		headers = append(headers, prev)
+	}
+	return headers
+}
+
+func testBTCHeadersHex(headers ...*btcspv.BlockHeader) string {
+	var raw []byte
+	for _, header := range headers {
+		raw = append(raw, header.Serialize()...)
+	}
+	return hex.EncodeToString(raw)
+}
+
+// 构造由pubkey以P2WPKH方式花费、OP_RETURN承诺AM报文的锚定交易
+func testBTCAnchorTx(pubkey []byte, pkg string) []byte {
+	rawPkg, _ := hex.DecodeString(pkg)
+	commitment := sha256.Sum256(rawPkg)
+	data := append([]byte(BTC_AM_COMMITMENT_TAG), commitment[:]...)
+
+	tx := []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01}
+	tx = append(tx, make([]byte, 36)...)
+	tx = append(tx, 0x00, 0xff, 0xff, 0xff, 0xff)
+	tx = append(tx, 0x01)
+	tx = append(tx, make([]byte, 8)...)
+	tx = append(tx, byte(len(data)+2), btcspv.OP_RETURN, byte(len(data)))
+	tx = append(tx, data...)
+	tx = append(tx, 0x02, 0x48)
+	tx = append(tx, make([]byte, 0x48)...)
+	tx = append(tx, byte(len(pubkey)))
+	tx = append(tx, pubkey...)
+	return append(tx, 0x00, 0x00, 0x00, 0x00)
+}
+
+func testBTCBranch(txids []btcspv.Hash, index uint32) string {
+	var branch []string
+	for _, h := range btcspv.MerkleBranch(txids, index) {
+		branch = append(branch, h.String())
+	}
+	return mustJSON(branch)
+}
+
+func TestBTCSPV(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "btc hello", "btc world")
+
+	anchorKey := append([]byte{0x02}, make([]byte, 32)...)
+	otherKey := append([]byte{0x03}, make([]byte, 32)...)
+	anchorTx := testBTCAnchorTx(anchorKey, pkgs[0])
+	staleTx := testBTCAnchorTx(anchorKey, pkgs[1])
+	forgedTx := testBTCAnchorTx(otherKey, pkgs[0])
+	var txids []btcspv.Hash
+	for i, raw := range [][]byte{{0x00}, anchorTx, forgedTx, staleTx} {
+		if i == 0 {
+			txids = append(txids, btcspv.DoubleSha256(raw))
+			continue
+		}
+		tx, err := btcspv.ParseTx(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txids = append(txids, tx.TxID)
+	}
+
+	checkpoint := testBTCMine(btcspv.Hash{}, btcspv.Hash{}, uint32(time.Now().Unix()-100000))
+	if res := InvokeWithStrings(t, stub, sp, "initBTCSPV", "btc.test", "regtest", "100", testBTCHeadersHex(checkpoint), hex.EncodeToString(anchorKey)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	chain := testBTCChain(checkpoint, 6, btcspv.MerkleRoot(txids))
+	block := chain[0].BlockHash().String()
+
+	// 未知父区块、错误的难度和时间戳
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(chain[1])); res.Status == shim.OK {
+		t.Fatal("header with unknown parent should be rejected")
+	}
+	wrongBits := *chain[0]
+	wrongBits.Bits = 0x1d00ffff
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(&wrongBits)); res.Status == shim.OK {
+		t.Fatal("header with wrong bits should be rejected")
+	}
+	stale := testBTCMine(checkpoint.BlockHash(), btcspv.Hash{}, checkpoint.Timestamp)
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(stale)); res.Status == shim.OK {
+		t.Fatal("header not after median time past should be rejected")
+	}
+	unmined := *chain[0]
+	for btcspv.CheckProofOfWork(&unmined, btcspv.RegTestParams) == nil {
+		unmined.Nonce++
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(&unmined)); res.Status == shim.OK {
+		t.Fatal("header without pow should be rejected")
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(chain[:3]...)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(anchorTx), "1", testBTCBranch(txids, 1), pkgs[0]); res.Status == shim.OK {
+		t.Fatal("message without enough confirmations should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(chain[3:]...)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryBTCSPV", "btc.test")
+	if !strings.Contains(string(res.Payload), `"tipHeight":106`) {
+		t.Fatalf("unexpected status: %s", res.Payload)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(anchorTx), "2", testBTCBranch(txids, 1), pkgs[0]); res.Status == shim.OK {
+		t.Fatal("proof with wrong index should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(anchorTx), "1", testBTCBranch(txids, 1), pkgs[1]); res.Status == shim.OK {
+		t.Fatal("package not committed by tx should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(forgedTx), "2", testBTCBranch(txids, 2), pkgs[0]); res.Status == shim.OK {
+		t.Fatal("tx not signed by anchor key should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(anchorTx), "1", testBTCBranch(txids, 1), pkgs[0]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "btc hello") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(anchorTx), "1", testBTCBranch(txids, 1), pkgs[0]); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+
+	// 累计工作量更大的分叉成为主链，原链上的消息不再被接受
+	fork := testBTCChain(checkpoint, 8)
+	if res := InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "btc.test", testBTCHeadersHex(fork...)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res = InvokeWithStrings(t, stub, sp, "queryBTCSPV", "btc.test")
+	if !strings.Contains(string(res.Payload), fork[7].BlockHash().String()) {
+		t.Fatalf("unexpected status after reorg: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvBTCMessage", "btc.test", block, hex.EncodeToString(staleTx), "3", testBTCBranch(txids, 3), pkgs[1]); res.Status == shim.OK {
+		t.Fatal("message in orphaned block should be rejected")
+	}
+}
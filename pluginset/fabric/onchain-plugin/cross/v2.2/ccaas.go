@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"io/ioutil"
+	"strconv"
+)
+
+// 链码即服务(CCaaS)
+// 设置CHAINCODE_SERVER_ADDRESS时跨链链码作为gRPC服务监听该地址，由peer的ccaas外部builder按链码包中的connection.json连接，
+// 可以部署为Kubernetes的Deployment，不需要peer管理docker容器；不设置时与以前一样由peer启动后连接peer。
+// 部署用的Dockerfile、链码包和Kubernetes配置见../ccaas。
+const (
+	// 监听地址，如0.0.0.0:9999
+	ENV_CCAAS_ADDRESS = "CHAINCODE_SERVER_ADDRESS"
+	// peer lifecycle chaincode install返回的package id，如cross_1.6.0:<hash>
+	ENV_CCAAS_ID = "CHAINCODE_ID"
+	// true时不使用TLS，仅用于测试环境
+	ENV_CCAAS_TLS_DISABLED = "CHAINCODE_TLS_DISABLED"
+	// 服务端私钥和证书(PEM)的文件路径，开启TLS时必须设置
+	ENV_CCAAS_TLS_KEY  = "CHAINCODE_TLS_KEY"
+	ENV_CCAAS_TLS_CERT = "CHAINCODE_TLS_CERT"
+	// 签发peer客户端证书的CA(PEM)的文件路径，设置后校验peer的客户端证书，与connection.json的client_auth_required对应
+	ENV_CCAAS_CLIENT_CA_CERT = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// 按环境变量构造链码服务，未设置CHAINCODE_SERVER_ADDRESS时返回nil
+func newChaincodeServer(cc shim.Chaincode, getenv func(string) string) (*shim.ChaincodeServer, error) {
+	address := getenv(ENV_CCAAS_ADDRESS)
+	if address == "" {
+		return nil, nil
+	}
+	ccid := getenv(ENV_CCAAS_ID)
+	if ccid == "" {
+		return nil, fmt.Errorf("%s is required in chaincode server mode", ENV_CCAAS_ID)
+	}
+	tlsProps, err := loadServerTLS(getenv)
+	if err != nil {
+		return nil, err
+	}
+	return &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       cc,
+		TLSProps: *tlsProps,
+	}, nil
+}
+
+func loadServerTLS(getenv func(string) string) (*shim.TLSProperties, error) {
+	if raw := getenv(ENV_CCAAS_TLS_DISABLED); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", ENV_CCAAS_TLS_DISABLED, err)
+		}
+		if disabled {
+			return &shim.TLSProperties{Disabled: true}, nil
+		}
+	}
+	keyPath, certPath := getenv(ENV_CCAAS_TLS_KEY), getenv(ENV_CCAAS_TLS_CERT)
+	if keyPath == "" || certPath == "" {
+		return nil, errors.New(ENV_CCAAS_TLS_KEY + " and " + ENV_CCAAS_TLS_CERT + " are required when TLS is enabled")
+	}
+	var props shim.TLSProperties
+	var err error
+	if props.Key, err = ioutil.ReadFile(keyPath); err != nil {
+		return nil, fmt.Errorf("failed to read TLS key: %v", err)
+	}
+	if props.Cert, err = ioutil.ReadFile(certPath); err != nil {
+		return nil, fmt.Errorf("failed to read TLS cert: %v", err)
+	}
+	if caPath := getenv(ENV_CCAAS_CLIENT_CA_CERT); caPath != "" {
+		if props.ClientCACerts, err = ioutil.ReadFile(caPath); err != nil {
+			return nil, fmt.Errorf("failed to read client CA cert: %v", err)
+		}
+	}
+	return &props, nil
+}
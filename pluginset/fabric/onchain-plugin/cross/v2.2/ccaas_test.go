@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewChaincodeServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ccaas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{"tls.key": "key", "tls.crt": TEST_ADMIN_CERT, "ca.crt": TEST_RELAYER_CERT}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := func(kv map[string]string) func(string) string {
+		return func(key string) string { return kv[key] }
+	}
+	cc := NewCrossChain()
+
+	// 未设置监听地址时由peer启动
+	if server, err := newChaincodeServer(cc, env(nil)); err != nil || server != nil {
+		t.Fatalf("unexpected server mode: %v %v", server, err)
+	}
+
+	server, err := newChaincodeServer(cc, env(map[string]string{
+		ENV_CCAAS_ADDRESS:        "0.0.0.0:9999",
+		ENV_CCAAS_ID:             "cross_1.0:abc",
+		ENV_CCAAS_TLS_KEY:        filepath.Join(dir, "tls.key"),
+		ENV_CCAAS_TLS_CERT:       filepath.Join(dir, "tls.crt"),
+		ENV_CCAAS_CLIENT_CA_CERT: filepath.Join(dir, "ca.crt"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.CCID != "cross_1.0:abc" || server.Address != "0.0.0.0:9999" || server.CC != cc || server.TLSProps.Disabled ||
+		string(server.TLSProps.Key) != "key" || string(server.TLSProps.Cert) != TEST_ADMIN_CERT || string(server.TLSProps.ClientCACerts) != TEST_RELAYER_CERT {
+		t.Fatalf("unexpected server: %+v", server)
+	}
+
+	server, err = newChaincodeServer(cc, env(map[string]string{ENV_CCAAS_ADDRESS: ":9999", ENV_CCAAS_ID: "cross_1.0:abc", ENV_CCAAS_TLS_DISABLED: "true"}))
+	if err != nil || !server.TLSProps.Disabled {
+		t.Fatalf("TLS should be disabled: %+v %v", server, err)
+	}
+
+	for _, c := range []struct {
+		env map[string]string
+		err string
+	}{
+		{map[string]string{ENV_CCAAS_ADDRESS: ":9999"}, ENV_CCAAS_ID},
+		{map[string]string{ENV_CCAAS_ADDRESS: ":9999", ENV_CCAAS_ID: "id", ENV_CCAAS_TLS_DISABLED: "no"}, "invalid " + ENV_CCAAS_TLS_DISABLED},
+		{map[string]string{ENV_CCAAS_ADDRESS: ":9999", ENV_CCAAS_ID: "id", ENV_CCAAS_TLS_DISABLED: "false"}, "required when TLS is enabled"},
+		{map[string]string{ENV_CCAAS_ADDRESS: ":9999", ENV_CCAAS_ID: "id", ENV_CCAAS_TLS_KEY: filepath.Join(dir, "missing"), ENV_CCAAS_TLS_CERT: filepath.Join(dir, "tls.crt")}, "TLS key"},
+		{map[string]string{ENV_CCAAS_ADDRESS: ":9999", ENV_CCAAS_ID: "id", ENV_CCAAS_TLS_KEY: filepath.Join(dir, "tls.key"), ENV_CCAAS_TLS_CERT: filepath.Join(dir, "tls.crt"), ENV_CCAAS_CLIENT_CA_CERT: filepath.Join(dir, "missing")}, "client CA"},
+	} {
+		if _, err := newChaincodeServer(cc, env(c.env)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%v: unexpected error %v", c.env, err)
+		}
+	}
+}
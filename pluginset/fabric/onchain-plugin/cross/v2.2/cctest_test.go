@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cctest"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+// 用cctest的MockStub运行跨链链码和bizcc，确认测试工具构造的报文、背书与链码一致
+func newCCTestStubs(t *testing.T) (*cctest.MockStub, *cctest.MockStub) {
+	cross := cctest.NewMockStub("crosscc", new(CrossChain))
+	biz := cctest.NewMockStub("bizcc", new(CrossChainTest))
+	cross.AddPeer(biz)
+	biz.AddPeer(cross)
+	cross.SetCreator("Org1MSP", []byte(TEST_ADMIN_CERT))
+
+	if res := cross.Init([]byte("Init")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	for _, args := range [][]string{
+		{"setAdmin", TEST_ADMIN_CERT},
+		{"oracleAdminManage", "registerSha256Invert", "bizcc"},
+		{"oracleAdminManage", "setExpectedDomain", "fabric.test"},
+	} {
+		if res := cross.InvokeWithStrings(args...); res.Status != shim.OK {
+			t.Fatalf("%v: %s", args, res.Message)
+		}
+	}
+	return cross, biz
+}
+
+func TestCCTestRecvMessage(t *testing.T) {
+	cross, biz := newCCTestStubs(t)
+	sender := sha256.Sum256([]byte("sender"))
+	for seq, content := range []string{"hello", "world"} {
+		am, err := cctest.BuildAMPackage(sender, cctest.NewSDPMessage("fabric.test", sha256.Sum256([]byte("bizcc")), uint32(seq), []byte(content), false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("src.com", am)...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if got := string(biz.State[LASTMSG]); got != "src.com::"+hex.EncodeToString(sender[:])+":"+content {
+			t.Fatalf("unexpected message: %s", got)
+		}
+	}
+
+	// 序号不连续的消息被拒绝，bizcc的状态不变
+	am, _ := cctest.BuildAMPackage(sender, cctest.NewSDPMessage("fabric.test", sha256.Sum256([]byte("bizcc")), 5, []byte("gap"), false))
+	if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("src.com", am)...); res.Status == shim.OK {
+		t.Fatal("out of order message should be rejected")
+	}
+	if got := string(biz.State[LASTMSG]); got != "src.com::"+hex.EncodeToString(sender[:])+":world" {
+		t.Fatalf("failed transaction changed state: %s", got)
+	}
+}
+
+func TestCCTestRecvPTCMessage(t *testing.T) {
+	cross, biz := newCCTestStubs(t)
+	committee, err := cctest.NewCommittee("src.com", "committee", 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := cross.InvokeWithStrings(committee.SetCommitteeArgs()...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	sender := sha256.Sum256([]byte("sender"))
+	am, _ := cctest.BuildAMPackage(sender, cctest.NewSDPMessage("fabric.test", sha256.Sum256([]byte("bizcc")), 0, []byte("endorsed"), true))
+	// 登记委员会后不接受未背书的消息
+	if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("src.com", am)...); res.Status == shim.OK {
+		t.Fatal("message without endorsement should be rejected")
+	}
+	endorsement, _ := committee.Endorse(am, 1)
+	if res := cross.InvokeWithStrings("recvPTCMessage", "src.com", hex.EncodeToString(am), hex.EncodeToString(endorsement)); res.Status == shim.OK {
+		t.Fatal("endorsement below threshold should be rejected")
+	}
+	args, err := committee.RecvPTCMessageArgs(am)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := cross.InvokeWithStrings(args...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if got := string(biz.State[LAST_UNORDERED_MSG]); got != "src.com::"+hex.EncodeToString(sender[:])+":endorsed" {
+		t.Fatalf("unexpected message: %s", got)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 分片消息
+// 单条消息长度限制为K_SEND_MESSAGE_LENGTH_LIMIT，更大的业务消息由sendChunkedMessage拆成编号的分片，
+// 每个分片作为一条有序消息发送，中继可以分多笔交易提交。接收方跨链链码把分片暂存在账本中，
+// 收齐后按编号拼接，校验总长度和sha256，再以完整的消息内容回调业务链码，业务链码感知不到分片。
+//
+// 分片的消息内容为CHUNK_MAGIC加ChunkHeader的json。暂存的分片按(来源域名, 发送方, 分片组id)隔离，
+// 其他发送方不能往别人的分片组中插入分片。分片消息不能设置有效期，取消其中一片会使整组无法拼接。
+const (
+	// 复合key: crosschain_chunk ${from} ${sender} ${chunkId} -> ChunkAssembly
+	K_CHUNK_OBJECT_TYPE = CROSSCHAIN_PREFIX + "chunk"
+	// 复合key: crosschain_chunk_fragment ${from} ${sender} ${chunkId} ${index} -> 分片数据
+	K_CHUNK_FRAGMENT_OBJECT_TYPE = CROSSCHAIN_PREFIX + "chunk_fragment"
+
+	CHUNK_MAGIC = "\x00ACB_CHUNK\x00"
+
+	// 分片数据默认长度，base64编码后加上分片头不超过单条消息长度限制
+	DEFAULT_CHUNK_SIZE = 6000
+	MAX_CHUNK_COUNT    = 256
+)
+
+type ChunkHeader struct {
+	// sha256(txid_nonce_发送方链码名)(hex)
+	ChunkId string `json:"chunkId"`
+	Index   uint32 `json:"index"`
+	Total   uint32 `json:"total"`
+	// 完整消息的长度和sha256(hex)
+	Size uint64 `json:"size"`
+	Hash string `json:"hash"`
+	Data []byte `json:"data"`
+}
+
+// 接收方暂存的分片组
+type ChunkAssembly struct {
+	ChunkId   string `json:"chunkId"`
+	From      string `json:"from"`
+	Sender    string `json:"sender"`
+	Receiver  string `json:"receiver"`
+	Total     uint32 `json:"total"`
+	Size      uint64 `json:"size"`
+	Hash      string `json:"hash"`
+	Received  uint32 `json:"received"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type ChunkedMessage struct {
+	ChunkId string `json:"chunkId"`
+	Total   uint32 `json:"total"`
+	Size    uint64 `json:"size"`
+	Hash    string `json:"hash"`
+	// 各分片在发送积压中的消息key
+	Keys []string `json:"keys"`
+}
+
+func encodeChunk(header *ChunkHeader) string {
+	raw, _ := json.Marshal(header)
+	return CHUNK_MAGIC + string(raw)
+}
+
+// 解析分片消息，不是分片消息时返回nil
+func decodeChunk(content []byte) (*ChunkHeader, error) {
+	if !bytes.HasPrefix(content, []byte(CHUNK_MAGIC)) {
+		return nil, nil
+	}
+	var header ChunkHeader
+	if err := json.Unmarshal(content[len(CHUNK_MAGIC):], &header); err != nil {
+		return nil, fmt.Errorf("invalid chunk: %v", err)
+	}
+	if header.Total == 0 || header.Total > MAX_CHUNK_COUNT || header.Index >= header.Total {
+		return nil, fmt.Errorf("invalid chunk %s: index %d, total %d", header.ChunkId, header.Index, header.Total)
+	}
+	return &header, nil
+}
+
+// 拆分消息并逐片发送
+// args[0] 目的地的域名
+// args[1] 目的地账号，byte32 hexstring
+// args[2] 消息内容
+// args[3] 消息nounce(可选)，区分同一笔交易内发送多个分片消息
+// args[4] 分片长度(可选)，默认DEFAULT_CHUNK_SIZE
+// 返回值为各分片的发送积压记录和分片组
+func (bs *CrossChain) sendChunkedMessage(stub shim.ChaincodeStubInterface, args []string) ([][]byte, *ChunkedMessage, error) {
+	if len(args) < 3 || len(args) > 5 {
+		return nil, nil, fmt.Errorf("Wrong length of args: %v", len(args))
+	}
+	payload := []byte(args[2])
+	var nounce string
+	if len(args) > 3 {
+		nounce = args[3]
+	}
+	chunkSize := DEFAULT_CHUNK_SIZE
+	if len(args) > 4 {
+		var err error
+		if chunkSize, err = strconv.Atoi(args[4]); err != nil || chunkSize <= 0 {
+			return nil, nil, fmt.Errorf("invalid chunk size: %s", args[4])
+		}
+	}
+	if len(payload) == 0 {
+		return nil, nil, fmt.Errorf("empty message")
+	}
+	total := (len(payload) + chunkSize - 1) / chunkSize
+	if total > MAX_CHUNK_COUNT {
+		return nil, nil, fmt.Errorf("message of %d bytes exceeds %d chunks", len(payload), MAX_CHUNK_COUNT)
+	}
+
+	sender, err := getProposalChaincode(stub)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(payload)
+	chunked := &ChunkedMessage{
+		ChunkId: sha256Hex([]byte(stub.GetTxID() + "_" + nounce + "_" + sender)),
+		Total:   uint32(total),
+		Size:    uint64(len(payload)),
+		Hash:    hex.EncodeToString(hash[:]),
+	}
+	var outbound [][]byte
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		content := encodeChunk(&ChunkHeader{
+			ChunkId: chunked.ChunkId,
+			Index:   uint32(i),
+			Total:   chunked.Total,
+			Size:    chunked.Size,
+			Hash:    chunked.Hash,
+			Data:    payload[i*chunkSize : end],
+		})
+		// 有效期为0，分片不过期
+		re := bs.sendMessage(stub, []string{args[0], args[1], content, fmt.Sprintf("%s_chunk_%d", nounce, i), "0"}, oraclelogic.K_MSG_TYPE_ORDERED)
+		if re.Status != shim.OK {
+			return nil, nil, fmt.Errorf("failed to send chunk %d: %s", i, re.Message)
+		}
+		var msg oraclelogic.OutboundMessage
+		_ = json.Unmarshal(re.Payload, &msg)
+		chunked.Keys = append(chunked.Keys, msg.Key)
+		outbound = append(outbound, re.Payload)
+	}
+	return outbound, chunked, nil
+}
+
+func chunkAssemblyKey(stub shim.ChaincodeStubInterface, from, sender, chunkId string) (string, error) {
+	return stub.CreateCompositeKey(K_CHUNK_OBJECT_TYPE, []string{from, sender, chunkId})
+}
+
+func chunkFragmentKey(stub shim.ChaincodeStubInterface, from, sender, chunkId string, index uint32) (string, error) {
+	return stub.CreateCompositeKey(K_CHUNK_FRAGMENT_OBJECT_TYPE, []string{from, sender, chunkId, fmt.Sprintf("%06d", index)})
+}
+
+// 暂存收到的分片，收齐时返回拼接好的完整消息，未收齐时返回nil
+func (bs *CrossChain) assembleChunk(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, header *ChunkHeader) ([]byte, error) {
+	sender, receiver := hex.EncodeToString(msg.Identity[:]), hex.EncodeToString(msg.Receiver[:])
+	key, err := chunkAssemblyKey(stub, msg.From, sender, header.ChunkId)
+	if err != nil {
+		return nil, err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return nil, err
+	}
+	var assembly ChunkAssembly
+	has, err := getJSONState(stub, key, &assembly)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		assembly = ChunkAssembly{
+			ChunkId:   header.ChunkId,
+			From:      msg.From,
+			Sender:    sender,
+			Receiver:  receiver,
+			Total:     header.Total,
+			Size:      header.Size,
+			Hash:      header.Hash,
+			CreatedAt: now,
+		}
+	} else if assembly.Receiver != receiver || assembly.Total != header.Total || assembly.Size != header.Size || assembly.Hash != header.Hash {
+		return nil, fmt.Errorf("chunk %d does not match chunk group %s", header.Index, header.ChunkId)
+	}
+
+	fragmentKey, err := chunkFragmentKey(stub, msg.From, sender, header.ChunkId, header.Index)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := stub.GetState(fragmentKey); err != nil {
+		return nil, err
+	} else if raw != nil {
+		return nil, fmt.Errorf("duplicate chunk %d of chunk group %s", header.Index, header.ChunkId)
+	}
+	assembly.Received++
+	assembly.UpdatedAt = now
+	if assembly.Received < assembly.Total {
+		if err := stub.PutState(fragmentKey, header.Data); err != nil {
+			return nil, err
+		}
+		return nil, putJSONState(stub, key, &assembly)
+	}
+
+	// 收齐，按编号拼接并删除暂存的分片
+	var payload []byte
+	for i := uint32(0); i < assembly.Total; i++ {
+		if i == header.Index {
+			payload = append(payload, header.Data...)
+			continue
+		}
+		k, err := chunkFragmentKey(stub, msg.From, sender, header.ChunkId, i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := stub.GetState(k)
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, data...)
+		if err := stub.DelState(k); err != nil {
+			return nil, err
+		}
+	}
+	if uint64(len(payload)) != assembly.Size || sha256Hex(payload) != assembly.Hash {
+		return nil, fmt.Errorf("chunk group %s hash mismatch", header.ChunkId)
+	}
+	if err := stub.DelState(key); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// 查询暂存中的分片组
+// args[0] 来源域名
+// args[1] 发送方身份, byte32 hexstring
+// args[2] 分片组id
+func (bs *CrossChain) queryChunkAssembly(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	key, err := chunkAssemblyKey(stub, args[0], args[1], args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	var assembly ChunkAssembly
+	if has, err := getJSONState(stub, key, &assembly); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "chunk group %s not found", args[2]))
+	}
+	raw, _ := json.Marshal(&assembly)
+	return shim.Success(raw)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestChunkedMessage(t *testing.T) {
+	stub, sp, _, bizsp := NewBizCrossChainStubs(t)
+	responder := &responderChaincode{}
+	stub.MockPeerChaincode("chunkcc", shimtest.NewMockStub("chunkcc", responder), "")
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "registerSha256Invert", "chunkcc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	receiver := sha256.Sum256([]byte("chunkcc"))
+	payload := strings.Repeat("0123456789", 250)
+
+	// 发送方拆成3片有序消息
+	if res := InvokeWithStrings(t, stub, bizsp, "sendChunkedMessage", "dest.com", hex.EncodeToString(receiver[:]), payload, "n", "0"); res.Status == shim.OK {
+		t.Fatal("chunk size should be positive")
+	}
+	res := InvokeWithStrings(t, stub, bizsp, "sendChunkedMessage", "dest.com", hex.EncodeToString(receiver[:]), payload, "n", "1000")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	<-stub.ChaincodeEventsChannel
+	var chunked ChunkedMessage
+	if err := json.Unmarshal(res.Payload, &chunked); err != nil {
+		t.Fatal(err)
+	}
+	if chunked.Total != 3 || len(chunked.Keys) != 3 || chunked.Size != 2500 || chunked.Hash != sha256Hex([]byte(payload)) {
+		t.Fatalf("unexpected chunked message: %+v", chunked)
+	}
+	var chunks []string
+	for i, key := range chunked.Keys {
+		_, p2p, _ := oraclelogic.TestRecvAuthMessage(stub.State[key])
+		sdp, err := oraclelogic.DecodeSDPMessage(p2p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header, err := decodeChunk(sdp.Payload); err != nil || header == nil || header.Index != uint32(i) || sdp.Sequence != uint32(i) {
+			t.Fatalf("unexpected chunk %d: %+v %v", i, header, err)
+		}
+		chunks = append(chunks, string(sdp.Payload))
+	}
+
+	// 接收方逐片暂存，收齐后回调一次
+	// MockStub不回滚失败交易的写入，每次提交使用新的序号
+	sender := sha256.Sum256([]byte("sendercc"))
+	seq := uint32(0)
+	recv := func(identity [32]byte, content string) pb.Response {
+		defer func() { seq++ }()
+		msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+			{From: "src.com", Identity: identity, Content: []byte(content), Receiver: receiver,
+				MsgType: oraclelogic.K_MSG_TYPE_ORDERED, Seq: seq},
+		}}
+		raw, _ := json.Marshal(msgs)
+		return CallWithTimestamp(stub, 1000, func(s shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().callbackBizChaincode(s, raw)
+		})
+	}
+	if res := recv(sender, chunks[0]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := recv(sender, chunks[0]); res.Status == shim.OK {
+		t.Fatal("duplicate chunk should be rejected")
+	}
+	// 其他发送方的同名分片组互不影响
+	other := sha256.Sum256([]byte("other"))
+	if res := recv(other, chunks[2]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var forged ChunkHeader
+	_ = json.Unmarshal([]byte(chunks[1][len(CHUNK_MAGIC):]), &forged)
+	forged.Hash = sha256Hex([]byte("forged"))
+	if res := recv(sender, encodeChunk(&forged)); res.Status == shim.OK {
+		t.Fatal("chunk of another hash should be rejected")
+	}
+	if res := recv(sender, chunks[1]); res.Status != shim.OK || len(responder.calls) != 0 {
+		t.Fatalf("unexpected result: %s %v", res.Message, responder.calls)
+	}
+
+	var assembly ChunkAssembly
+	res = InvokeWithStrings(t, stub, sp, "queryChunkAssembly", "src.com", hex.EncodeToString(sender[:]), chunked.ChunkId)
+	if err := json.Unmarshal(res.Payload, &assembly); err != nil || assembly.Received != 2 || assembly.Total != 3 {
+		t.Fatalf("unexpected assembly: %+v %s", assembly, res.Message)
+	}
+	if res := recv(sender, chunks[2]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if len(responder.calls) != 1 || responder.calls[0][0] != "recvMessage" || responder.calls[0][3] != payload {
+		t.Fatalf("unexpected calls: %v", responder.calls)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryChunkAssembly", "src.com", hex.EncodeToString(sender[:]), chunked.ChunkId); res.Status == shim.OK {
+		t.Fatal("assembled chunk group should be removed")
+	}
+	for k := range stub.State {
+		if strings.HasPrefix(k, "\x00"+K_CHUNK_FRAGMENT_OBJECT_TYPE+"\x00src.com\x00"+hex.EncodeToString(sender[:])) {
+			t.Fatalf("fragment %q should be removed", k)
+		}
+	}
+}
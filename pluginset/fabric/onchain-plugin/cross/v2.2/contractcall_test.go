@@ -0,0 +1,147 @@
+package main
+
+import (
+	"abicodec"
+	"am"
+	"cctest"
+	"contractcall"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math/big"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"upperprotocol"
+)
+
+// 测试用的目标链码：只接受来自evm.test上minter的mint调用
+type callTarget struct {
+	minter string
+}
+
+func (c *callTarget) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (c *callTarget) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	if fn != contractcall.FN_RECV_CONTRACT_CALL || len(args) < 3 {
+		return shim.Error("unknown function " + fn)
+	}
+	srcDomain, caller, signature := args[0], args[1], args[2]
+	switch signature {
+	case "mint(address,uint256)":
+		if srcDomain != "evm.test" || caller != c.minter {
+			return shim.Error("caller is not minter")
+		}
+		if err := stub.PutState(args[3], []byte(args[4])); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(`["` + args[4] + `",true]`))
+	case "name()":
+		return shim.Success([]byte("token"))
+	}
+	return shim.Error("unknown method " + signature)
+}
+
+// 跨链链码保存的发出的调用结果
+func outboundCallResult(t *testing.T, cross *cctest.MockStub, callId [32]byte) *contractcall.Result {
+	for key, value := range cross.State {
+		if !strings.HasPrefix(key, oraclelogic.K_CROSSCHAIN_MSG_PREFIX) {
+			continue
+		}
+		msg, err := am.Decode(value)
+		if err != nil || msg.GetProtocolType() != contractcall.PROTOCOL_TYPE {
+			continue
+		}
+		if result, err := contractcall.DecodeResult(msg.GetPayload()); err == nil && result.CallId == callId {
+			return result
+		}
+	}
+	t.Fatalf("result of call %x not found", callId)
+	return nil
+}
+
+// 消息编解码、执行和结果发送的规则见vendor/contractcall，这里检查经跨链链码投递调用和发回结果的完整流程
+func TestContractCallProtocol(t *testing.T) {
+	cross, _ := newCCTestStubs(t)
+	callcc := cctest.NewMockStub("callcc", contractcall.NewChaincode("callcc", "crosscc"))
+	minter := [32]byte{31: 0x01}
+	target := cctest.NewMockStub("tokencc", &callTarget{minter: hex.EncodeToString(minter[:])})
+	cross.AddPeer(callcc)
+	callcc.AddPeer(cross)
+	callcc.AddPeer(target)
+	if res := cross.InvokeWithStrings(upperprotocol.RegisterArgs(contractcall.PROTOCOL_TYPE, "callcc")...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	deliver := func(sender [32]byte, call *contractcall.Call) *contractcall.InboundCall {
+		payload, err := call.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("evm.test", cctest.BuildProtocolAMPackage(sender, contractcall.PROTOCOL_TYPE, payload))...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		callId := hex.EncodeToString(call.CallId[:])
+		res := callcc.InvokeWithStrings(contractcall.FN_QUERY_CALL, "evm.test", callId)
+		var inbound contractcall.InboundCall
+		if err := json.Unmarshal(res.Payload, &inbound); err != nil {
+			t.Fatalf("%v %s", err, res.Message)
+		}
+		return &inbound
+	}
+
+	// 类型化的参数转为文本交给目标链码，返回值按returns编码
+	account := abicodec.Address{19: 0xaa}
+	mint, err := contractcall.NewCall([32]byte{1}, "tokencc", "mint(address, uint)", []interface{}{account, 100}, "(uint256,bool)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inbound := deliver(minter, mint); !inbound.Success || inbound.Signature != "mint(address,uint256)" || inbound.Status != contractcall.STATUS_PENDING {
+		t.Fatalf("unexpected call: %+v", inbound)
+	}
+	if got := string(target.State[account.String()]); got != "100" {
+		t.Fatalf("unexpected balance: %s", got)
+	}
+
+	// 调用失败时结果为失败，交易不回滚
+	other := [32]byte{31: 0x02}
+	denied, _ := contractcall.NewCall([32]byte{2}, "tokencc", "mint(address,uint256)", []interface{}{account, 1}, "(uint256,bool)")
+	if inbound := deliver(other, denied); inbound.Success || !strings.Contains(inbound.Error, "caller is not minter") {
+		t.Fatalf("unexpected call: %+v", inbound)
+	}
+	raw, _ := contractcall.NewCall([32]byte{3}, "tokencc", "name()", nil, "")
+	if inbound := deliver(minter, raw); !inbound.Success || string(inbound.Result) != "token" {
+		t.Fatalf("unexpected call: %+v", inbound)
+	}
+
+	res := callcc.InvokeWithStrings(contractcall.FN_QUERY_PENDING_CALLS, "evm.test")
+	var pending []*contractcall.InboundCall
+	if err := json.Unmarshal(res.Payload, &pending); err != nil || len(pending) != 3 {
+		t.Fatalf("unexpected pending calls: %v %s", err, res.Payload)
+	}
+
+	// 结果经跨链链码发回调用方的链
+	for _, call := range pending {
+		if res := callcc.InvokeWithStrings(contractcall.FN_SEND_CALL_RESULT, "evm.test", call.CallId); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	if res := callcc.InvokeWithStrings(contractcall.FN_QUERY_PENDING_CALLS); string(res.Payload) != "[]" {
+		t.Fatalf("sent results should not be pending: %s", res.Payload)
+	}
+
+	values, err := outboundCallResult(t, cross, mint.CallId).Values(mint.Returns)
+	if err != nil || values[0].(*big.Int).Int64() != 100 || values[1] != true {
+		t.Fatalf("unexpected return values: %v %v", values, err)
+	}
+	if result := outboundCallResult(t, cross, denied.CallId); result.Success || !strings.Contains(result.Error, "caller is not minter") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result := outboundCallResult(t, cross, raw.CallId); string(result.Result) != "token" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
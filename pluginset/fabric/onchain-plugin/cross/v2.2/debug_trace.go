@@ -0,0 +1,244 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 调试追踪
+// 管理员临时打开后，每条收到的消息在解析、校验、接收方解析(ACL)、投递各步骤的结果写入状态中的
+// 环形缓冲区，用于事后排查。缓冲区容量固定，写满后覆盖最早的记录。
+//
+// 链码读不到区块高度，打开时按出块间隔把有效区块数折算成截止时间，之后第一笔带追踪的交易
+// 自动关闭追踪。
+// 失败的交易不会提交写集，失败步骤的原因只在交易的错误信息中返回，追踪记录只包含提交成功的交易。
+// 追踪期间每笔收消息交易都会更新同一个配置key，并发提交的收消息交易会发生读写冲突，不要长期开启。
+const (
+	K_DEBUG_TRACE_CONFIG = CROSSCHAIN_PREFIX + "debug_trace"
+	// crosschain_debug_trace_entry_${slot} -> TraceRecord
+	K_DEBUG_TRACE_ENTRY_PREFIX = CROSSCHAIN_PREFIX + "debug_trace_entry_"
+
+	DEBUG_TRACE_MAX_CAPACITY = 1000
+
+	TRACE_STEP_PARSE    = "parse"
+	TRACE_STEP_VERIFY   = "verify"
+	TRACE_STEP_ACL      = "acl"
+	TRACE_STEP_DELIVERY = "delivery"
+)
+
+type DebugTraceConfig struct {
+	Enabled  bool  `json:"enabled"`
+	Capacity int64 `json:"capacity"`
+	// 打开时设置的有效区块数和出块间隔(秒)
+	Blocks        int64 `json:"blocks"`
+	BlockInterval int64 `json:"blockInterval"`
+	ExpireAt      int64 `json:"expireAt"`
+	// 已写入的记录总数，下一条记录写在 Next % Capacity
+	Next int64 `json:"next"`
+}
+
+type TraceStep struct {
+	Step   string `json:"step"`
+	Ok     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type TraceRecord struct {
+	Seq       int64       `json:"seq"`
+	TxId      string      `json:"txId"`
+	Timestamp int64       `json:"timestamp"`
+	Function  string      `json:"function"`
+	From      string      `json:"from"`
+	Steps     []TraceStep `json:"steps"`
+}
+
+type DebugTraceDump struct {
+	Config  DebugTraceConfig `json:"config"`
+	Records []TraceRecord    `json:"records"`
+}
+
+// 一笔交易内的追踪记录，未开启追踪时为nil，所有方法都可以在nil上调用
+type debugTracer struct {
+	config    DebugTraceConfig
+	txId      string
+	timestamp int64
+	function  string
+	records   []TraceRecord
+}
+
+func debugTraceEntryKey(slot int64) string {
+	return K_DEBUG_TRACE_ENTRY_PREFIX + fmt.Sprintf("%04d", slot)
+}
+
+func (bs *CrossChain) getDebugTraceConfig(stub shim.ChaincodeStubInterface) (*DebugTraceConfig, error) {
+	var config DebugTraceConfig
+	if _, err := getJSONState(stub, K_DEBUG_TRACE_CONFIG, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// 开始一笔交易的追踪，未开启或已过期时返回nil，过期时顺带关闭追踪
+func (bs *CrossChain) newDebugTracer(stub shim.ChaincodeStubInterface) (*debugTracer, error) {
+	config, err := bs.getDebugTraceConfig(stub)
+	if err != nil || !config.Enabled {
+		return nil, err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return nil, err
+	}
+	if now >= config.ExpireAt {
+		config.Enabled = false
+		return nil, putJSONState(stub, K_DEBUG_TRACE_CONFIG, config)
+	}
+	fn, _ := stub.GetFunctionAndParameters()
+	return &debugTracer{config: *config, txId: stub.GetTxID(), timestamp: now, function: fn}, nil
+}
+
+// 开始一条消息的追踪记录
+func (t *debugTracer) begin(msg oraclelogic.RecvAuthMessage) {
+	if t == nil {
+		return
+	}
+	t.records = append(t.records, TraceRecord{
+		TxId:      t.txId,
+		Timestamp: t.timestamp,
+		Function:  t.function,
+		From:      msg.From,
+		Steps:     []TraceStep{},
+	})
+}
+
+// 记录当前消息的一个步骤
+func (t *debugTracer) step(name string, ok bool, format string, args ...interface{}) {
+	if t == nil || len(t.records) == 0 {
+		return
+	}
+	record := &t.records[len(t.records)-1]
+	record.Steps = append(record.Steps, TraceStep{Step: name, Ok: ok, Detail: fmt.Sprintf(format, args...)})
+}
+
+// 解析得到的消息，记录解析和校验步骤
+func (t *debugTracer) parsed(msg oraclelogic.RecvAuthMessage) {
+	if t == nil {
+		return
+	}
+	t.begin(msg)
+	t.step(TRACE_STEP_PARSE, true, "type=%s sender=%s receiver=%s length=%d",
+		msg.MsgType, hex.EncodeToString(msg.Identity[:]), hex.EncodeToString(msg.Receiver[:]), len(msg.Content))
+	t.step(TRACE_STEP_VERIFY, true, "verified by %s", t.function)
+}
+
+// 把本交易的记录写入环形缓冲区
+func (bs *CrossChain) flushDebugTrace(stub shim.ChaincodeStubInterface, t *debugTracer) error {
+	if t == nil || len(t.records) == 0 {
+		return nil
+	}
+	for i := range t.records {
+		t.records[i].Seq = t.config.Next
+		if err := putJSONState(stub, debugTraceEntryKey(t.config.Next%t.config.Capacity), &t.records[i]); err != nil {
+			return err
+		}
+		t.config.Next++
+	}
+	return putJSONState(stub, K_DEBUG_TRACE_CONFIG, &t.config)
+}
+
+// 打开或关闭调试追踪，重新打开时清空之前的记录
+// args[0] on/off
+// args[1] 缓冲区容量，不超过DEBUG_TRACE_MAX_CAPACITY(仅on)
+// args[2] 有效区块数(仅on)
+// args[3] 出块间隔(秒)(仅on)
+func (bs *CrossChain) setDebugTrace(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	config, err := bs.getDebugTraceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get debug trace config"))
+	}
+
+	switch args[0] {
+	case "off":
+		if len(args) != 1 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+		}
+		config.Enabled = false
+	case "on":
+		if len(args) != 4 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+		}
+		var params [3]int64
+		for i, arg := range args[1:] {
+			if params[i], err = strconv.ParseInt(arg, 10, 64); err != nil || params[i] <= 0 {
+				return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid argument: %s", arg))
+			}
+		}
+		if params[0] > DEBUG_TRACE_MAX_CAPACITY {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "capacity exceeds %d", DEBUG_TRACE_MAX_CAPACITY))
+		}
+		now, err := getTxTimestamp(stub)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+		}
+		// 清空上一轮的记录
+		for slot := int64(0); slot < config.Capacity && slot < config.Next; slot++ {
+			if err := stub.DelState(debugTraceEntryKey(slot)); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+			}
+		}
+		config = &DebugTraceConfig{
+			Enabled:       true,
+			Capacity:      params[0],
+			Blocks:        params[1],
+			BlockInterval: params[2],
+			ExpireAt:      now + params[1]*params[2],
+		}
+	default:
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown switch: %s", args[0]))
+	}
+	if err := putJSONState(stub, K_DEBUG_TRACE_CONFIG, config); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put config"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询追踪配置和缓冲区中的记录，记录按写入顺序返回
+func (bs *CrossChain) queryDebugTrace(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	config, err := bs.getDebugTraceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get debug trace config"))
+	}
+	dump := DebugTraceDump{Config: *config, Records: []TraceRecord{}}
+	if config.Enabled {
+		now, err := getTxTimestamp(stub)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+		}
+		// 已过期但还没有交易触发关闭
+		dump.Config.Enabled = now < config.ExpireAt
+	}
+	first := config.Next - config.Capacity
+	if first < 0 {
+		first = 0
+	}
+	for seq := first; seq < config.Next; seq++ {
+		var record TraceRecord
+		has, err := getJSONState(stub, debugTraceEntryKey(seq%config.Capacity), &record)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+		}
+		if has {
+			dump.Records = append(dump.Records, record)
+		}
+	}
+	bz, _ := json.Marshal(dump)
+	return shim.Success(bz)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func TestDebugTrace(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	bs := NewCrossChain()
+	call := func(now int64, fn func(stub shim.ChaincodeStubInterface, args []string) pb.Response, args ...string) pb.Response {
+		return CallWithTimestamp(stub, now, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return fn(stub, args)
+		})
+	}
+	query := func(now int64) DebugTraceDump {
+		var dump DebugTraceDump
+		_ = json.Unmarshal(call(now, bs.queryDebugTrace).Payload, &dump)
+		return dump
+	}
+
+	if res := call(1000, bs.setDebugTrace, "on", "2000", "10", "5"); res.Status == shim.OK {
+		t.Fatal("capacity over limit should be rejected")
+	}
+	// 容量2，10个区块 * 5秒，1050过期
+	if res := call(1000, bs.setDebugTrace, "on", "2", "10", "5"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	testCallbackInTx(t, stub, "tx1", 1010, "first")
+	testCallbackInTx(t, stub, "tx2", 1020, "second")
+	testCallbackInTx(t, stub, "tx3", 1030, "third")
+
+	dump := query(1040)
+	if !dump.Config.Enabled || dump.Config.Next != 3 || len(dump.Records) != 2 {
+		t.Fatalf("unexpected dump: %+v", dump)
+	}
+	if dump.Records[0].TxId != "tx2" || dump.Records[1].TxId != "tx3" || dump.Records[1].Seq != 2 {
+		t.Fatalf("ring buffer should keep the latest records: %+v", dump.Records)
+	}
+	steps := dump.Records[1].Steps
+	if len(steps) != 4 || steps[0].Step != TRACE_STEP_PARSE || steps[1].Step != TRACE_STEP_VERIFY ||
+		steps[2].Step != TRACE_STEP_ACL || steps[3].Step != TRACE_STEP_DELIVERY || steps[2].Detail != "receiver resolved to bizcc" {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+
+	// 过期后查询显示关闭，下一笔交易关闭追踪且不再写记录
+	if query(1050).Config.Enabled {
+		t.Fatal("trace should be reported as disabled after expiry")
+	}
+	testCallbackInTx(t, stub, "tx4", 1060, "fourth")
+	if dump := query(1060); dump.Config.Next != 3 || len(dump.Records) != 2 {
+		t.Fatalf("expired trace should not record: %+v", dump)
+	}
+	config, _ := bs.getDebugTraceConfig(stub)
+	if config.Enabled {
+		t.Fatal("expired trace should be switched off")
+	}
+
+	// 重新打开时清空之前的记录
+	if res := InvokeWithStrings(t, stub, sp, "setDebugTrace", "on", "5", "10", "5"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if dump := query(1070); len(dump.Records) != 0 || dump.Config.Next != 0 {
+		t.Fatalf("records should be cleared: %+v", dump)
+	}
+	if _, has := stub.State[debugTraceEntryKey(0)]; has {
+		t.Fatal("old entries should be deleted")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"regexp"
+	"strings"
+)
+
+// 节点装饰(decorations)策略
+// 节点可以通过decorator插件在提案中注入元数据，链码用GetDecorations读取。收消息时读取提交方的
+// 客户端等级和网络分区：
+//   - crosschain.client_tier: 按等级限流，每个等级在固定时间窗口内最多收多少条消息
+//   - crosschain.network_zone: 按分区限定允许提交的来源域名
+//
+// 装饰由背书节点注入，不在交易中签名，只应在各背书组织的decorator配置一致时开启。
+// 开启后 crosschain. 前缀下只允许上面两个key，取值必须是小写字母、数字和 _ . -，
+// 其他前缀的装饰属于别的插件，忽略。
+const (
+	K_DECORATION_POLICY = CROSSCHAIN_PREFIX + "decoration_policy"
+	// crosschain_decoration_usage_${tier} -> TierUsage
+	K_DECORATION_USAGE_PREFIX = CROSSCHAIN_PREFIX + "decoration_usage_"
+
+	DECORATION_PREFIX       = "crosschain."
+	DECORATION_CLIENT_TIER  = DECORATION_PREFIX + "client_tier"
+	DECORATION_NETWORK_ZONE = DECORATION_PREFIX + "network_zone"
+)
+
+var decorationValuePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+type TierRateLimit struct {
+	Window      int64 `json:"window"` // 秒
+	MaxMessages int64 `json:"maxMessages"`
+}
+
+type DecorationPolicy struct {
+	Enabled bool `json:"enabled"`
+	// 客户端等级 -> 限流，为空表示不限流
+	Tiers map[string]TierRateLimit `json:"tiers"`
+	// 没有等级装饰时使用的等级，为空表示必须带等级装饰
+	DefaultTier string `json:"defaultTier,omitempty"`
+	// 网络分区 -> 允许的来源域名(为空表示不限)，为空表示不按分区限制；未列出的分区不允许提交
+	Zones map[string][]string `json:"zones"`
+}
+
+type TierUsage struct {
+	WindowStart int64 `json:"windowStart"`
+	Count       int64 `json:"count"`
+}
+
+func (p *DecorationPolicy) validate() error {
+	for tier, limit := range p.Tiers {
+		if !decorationValuePattern.MatchString(tier) {
+			return fmt.Errorf("invalid tier name: %q", tier)
+		}
+		if limit.Window <= 0 || limit.MaxMessages < 0 {
+			return fmt.Errorf("invalid rate limit of tier %s", tier)
+		}
+	}
+	if _, ok := p.Tiers[p.DefaultTier]; p.DefaultTier != "" && !ok {
+		return fmt.Errorf("default tier %s is not configured", p.DefaultTier)
+	}
+	for zone := range p.Zones {
+		if !decorationValuePattern.MatchString(zone) {
+			return fmt.Errorf("invalid zone name: %q", zone)
+		}
+	}
+	return nil
+}
+
+// 读取并校验本链码关心的装饰
+func readDecorations(stub shim.ChaincodeStubInterface) (map[string]string, error) {
+	values := map[string]string{}
+	for key, value := range stub.GetDecorations() {
+		if !strings.HasPrefix(key, DECORATION_PREFIX) {
+			continue
+		}
+		if key != DECORATION_CLIENT_TIER && key != DECORATION_NETWORK_ZONE {
+			return nil, fmt.Errorf("unknown decoration: %q", key)
+		}
+		if !decorationValuePattern.Match(value) {
+			return nil, fmt.Errorf("invalid value of decoration %s", key)
+		}
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+// 收消息前按装饰检查分区路由并计入等级限流，未开启时不做任何检查
+func (bs *CrossChain) applyDecorationPolicy(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage) error {
+	var policy DecorationPolicy
+	if has, err := getJSONState(stub, K_DECORATION_POLICY, &policy); err != nil || !has || !policy.Enabled {
+		return err
+	}
+	decorations, err := readDecorations(stub)
+	if err != nil {
+		return err
+	}
+
+	if len(policy.Zones) != 0 {
+		zone, ok := decorations[DECORATION_NETWORK_ZONE]
+		if !ok {
+			return fmt.Errorf("missing decoration %s", DECORATION_NETWORK_ZONE)
+		}
+		domains, ok := policy.Zones[zone]
+		if !ok {
+			return fmt.Errorf("zone %s is not allowed", zone)
+		}
+		for _, msg := range msgs {
+			if len(domains) != 0 && !containsString(domains, msg.From) {
+				return fmt.Errorf("domain %s is not routed through zone %s", msg.From, zone)
+			}
+		}
+	}
+
+	if len(policy.Tiers) != 0 {
+		tier, ok := decorations[DECORATION_CLIENT_TIER]
+		if !ok {
+			if tier = policy.DefaultTier; tier == "" {
+				return fmt.Errorf("missing decoration %s", DECORATION_CLIENT_TIER)
+			}
+		}
+		limit, ok := policy.Tiers[tier]
+		if !ok {
+			return fmt.Errorf("tier %s is not allowed", tier)
+		}
+		now, err := getTxTimestamp(stub)
+		if err != nil {
+			return err
+		}
+		var usage TierUsage
+		if _, err := getJSONState(stub, K_DECORATION_USAGE_PREFIX+tier, &usage); err != nil {
+			return err
+		}
+		if start := now - now%limit.Window; usage.WindowStart != start {
+			usage = TierUsage{WindowStart: start}
+		}
+		usage.Count += int64(len(msgs))
+		if usage.Count > limit.MaxMessages {
+			return fmt.Errorf("rate limit of tier %s exceeded: %d messages in %d seconds", tier, limit.MaxMessages, limit.Window)
+		}
+		if err := putJSONState(stub, K_DECORATION_USAGE_PREFIX+tier, &usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 设置装饰策略
+// args[0] DecorationPolicy, json
+func (bs *CrossChain) setDecorationPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var policy DecorationPolicy
+	if err := json.Unmarshal([]byte(args[0]), &policy); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "policy format error"))
+	}
+	if err := policy.validate(); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid decoration policy"))
+	}
+	if err := putJSONState(stub, K_DECORATION_POLICY, &policy); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put policy"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询装饰策略
+func (bs *CrossChain) queryDecorationPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	bz, err := stub.GetState(K_DECORATION_POLICY)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(bz) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "decoration policy not set"))
+	}
+	return shim.Success(bz)
+}
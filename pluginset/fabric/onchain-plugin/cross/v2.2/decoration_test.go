@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+	"wrapstub/v2.2"
+)
+
+func TestDecorationPolicy(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	recv := func(tx string, seconds int64, decorations map[string]string) pb.Response {
+		stub.Decorations = map[string][]byte{}
+		for k, v := range decorations {
+			stub.Decorations[k] = []byte(v)
+		}
+		msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+			{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte(tx),
+				Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+		}}
+		raw, _ := json.Marshal(msgs)
+		stub.MockTransactionStart(tx)
+		defer stub.MockTransactionEnd(tx)
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+		return NewCrossChain().callbackBizChaincode(wrapstub.NewMockWrapStub(stub), raw)
+	}
+	edge := map[string]string{DECORATION_NETWORK_ZONE: "edge"}
+
+	// 未开启时忽略装饰
+	if res := recv("tx0", 1000, map[string]string{DECORATION_PREFIX + "unknown": "x"}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setDecorationPolicy", `{"enabled":true,"tiers":{"Gold":{"window":60,"maxMessages":1}}}`); res.Status == shim.OK {
+		t.Fatal("invalid tier name should be rejected")
+	}
+	policy := `{"enabled":true,"tiers":{"basic":{"window":60,"maxMessages":2}},"defaultTier":"basic","zones":{"edge":["from.com"],"core":[]}}`
+	if res := InvokeWithStrings(t, stub, sp, "setDecorationPolicy", policy); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	if res := recv("tx1", 1000, map[string]string{DECORATION_NETWORK_ZONE: "dmz"}); res.Status == shim.OK {
+		t.Fatal("unlisted zone should be rejected")
+	}
+	if res := recv("tx2", 1000, map[string]string{DECORATION_NETWORK_ZONE: "edge", DECORATION_PREFIX + "unknown": "x"}); res.Status == shim.OK {
+		t.Fatal("unknown decoration key should be rejected")
+	}
+	if res := recv("tx3", 1000, map[string]string{DECORATION_NETWORK_ZONE: "Edge!"}); res.Status == shim.OK {
+		t.Fatal("invalid decoration value should be rejected")
+	}
+	if res := recv("tx4", 1000, nil); res.Status == shim.OK {
+		t.Fatal("missing zone should be rejected")
+	}
+
+	// 默认等级每60秒最多2条
+	for _, tx := range []string{"tx5", "tx6"} {
+		if res := recv(tx, 1000, edge); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	if res := recv("tx7", 1010, map[string]string{DECORATION_NETWORK_ZONE: "core"}); res.Status == shim.OK {
+		t.Fatal("rate limit should be enforced")
+	}
+	if res := recv("tx8", 1010, map[string]string{DECORATION_NETWORK_ZONE: "edge", DECORATION_CLIENT_TIER: "premium"}); res.Status == shim.OK {
+		t.Fatal("unconfigured tier should be rejected")
+	}
+	// 下一个窗口重新计数
+	if res := recv("tx9", 1020, edge); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
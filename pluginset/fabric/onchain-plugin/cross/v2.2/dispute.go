@@ -0,0 +1,335 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 争议窗口模块
+// 对配置了争议窗口的来源域名，收到的消息不会立即回调业务链码，而是进入CHALLENGEABLE状态。
+// 窗口期内授权的挑战者可以冻结消息，由管理员(治理)裁决；窗口期结束后任何人都可以触发投递。
+// 链码内无法获取区块高度，窗口长度以交易时间戳(秒)计算。
+const (
+	// crosschain_dispute_window_${domain} -> 窗口长度(秒)
+	K_DISPUTE_WINDOW_PREFIX = CROSSCHAIN_PREFIX + "dispute_window_"
+
+	// composite key: crosschain_challenger~${certHash}
+	K_CHALLENGER_OBJECT_TYPE = CROSSCHAIN_PREFIX + "challenger"
+
+	// composite key: crosschain_dispute~${msgId}
+	K_DISPUTE_OBJECT_TYPE = CROSSCHAIN_PREFIX + "dispute"
+
+	DISPUTE_STATUS_CHALLENGEABLE = "CHALLENGEABLE"
+	DISPUTE_STATUS_FROZEN        = "FROZEN"
+	DISPUTE_STATUS_FINALIZED     = "FINALIZED"
+	DISPUTE_STATUS_REJECTED      = "REJECTED"
+)
+
+type DisputedMessage struct {
+	MsgId      string                      `json:"msgId"`
+	Message    oraclelogic.RecvAuthMessage `json:"message"`
+	Status     string                      `json:"status"`
+	TxId       string                      `json:"txId"` // 提交消息的交易
+	ReceivedAt int64                       `json:"receivedAt"`
+	Deadline   int64                       `json:"deadline"`
+	Challenger string                      `json:"challenger"`
+	Reason     string                      `json:"reason"`
+	Erased     bool                        `json:"erased,omitempty"` // 消息内容已擦除，摘要见擦除回执
+}
+
+type DisputePage struct {
+	Messages []DisputedMessage `json:"messages"`
+	Bookmark string            `json:"bookmark"`
+}
+
+func (bs *CrossChain) getDisputeWindow(stub shim.ChaincodeStubInterface, domain string) (int64, error) {
+	raw, err := stub.GetState(K_DISPUTE_WINDOW_PREFIX + domain)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(raw), 10, 64)
+}
+
+func (bs *CrossChain) getDisputedMessage(stub shim.ChaincodeStubInterface, msgId string) (*DisputedMessage, string, error) {
+	key, err := stub.CreateCompositeKey(K_DISPUTE_OBJECT_TYPE, []string{msgId})
+	if err != nil {
+		return nil, "", err
+	}
+	var dm DisputedMessage
+	has, err := getJSONState(stub, key, &dm)
+	if err != nil || !has {
+		return nil, key, err
+	}
+	return &dm, key, nil
+}
+
+// 设置来源域名的争议窗口
+// args[0] 来源域名
+// args[1] 窗口长度(秒)，0表示关闭
+func (bs *CrossChain) setDisputeWindow(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	window, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || window < 0 || args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", args[0], args[1]))
+	}
+
+	if window > 0 {
+		if route, err := bs.getPrivateRoute(stub, args[0]); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get private route"))
+		} else if route != nil {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "domain %s has private route, dispute window is not allowed", args[0]))
+		}
+	}
+	if window == 0 {
+		err = stub.DelState(K_DISPUTE_WINDOW_PREFIX + args[0])
+	} else {
+		err = stub.PutState(K_DISPUTE_WINDOW_PREFIX+args[0], []byte(args[1]))
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put dispute window"))
+	}
+	return shim.Success(nil)
+}
+
+// 授权或取消挑战者
+// args[0] 挑战者证书sha256(hex)
+// args[1] true授权/false取消
+func (bs *CrossChain) setChallenger(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	enabled, err := strconv.ParseBool(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "enabled(%s) format error", args[1]))
+	}
+	key, err := stub.CreateCompositeKey(K_CHALLENGER_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+
+	if enabled {
+		err = putRegistryState(stub, key, []byte{0x01})
+	} else {
+		err = delRegistryState(stub, key)
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put challenger"))
+	}
+	return shim.Success(nil)
+}
+
+func (bs *CrossChain) isChallenger(stub shim.ChaincodeStubInterface, certHash string) (bool, error) {
+	key, err := stub.CreateCompositeKey(K_CHALLENGER_OBJECT_TYPE, []string{certHash})
+	if err != nil {
+		return false, err
+	}
+	raw, err := getRegistryState(stub, key)
+	if err != nil {
+		return false, err
+	}
+	return len(raw) != 0, nil
+}
+
+// 将消息暂存进争议窗口，返回消息id
+// index 为消息在本交易内的序号，与交易id共同确定消息id
+func (bs *CrossChain) holdMessage(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, index int, window int64) (string, error) {
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s_%d", stub.GetTxID(), index)))
+	msgId := hex.EncodeToString(h[:])
+
+	_, key, err := bs.getDisputedMessage(stub, msgId)
+	if err != nil {
+		return "", err
+	}
+	dm := &DisputedMessage{
+		MsgId:      msgId,
+		Message:    msg,
+		Status:     DISPUTE_STATUS_CHALLENGEABLE,
+		TxId:       stub.GetTxID(),
+		ReceivedAt: now,
+		Deadline:   now + window,
+	}
+	if err := putJSONState(stub, key, dm); err != nil {
+		return "", err
+	}
+	if err := bs.trackRetention(stub, msg.From, &RetentionEntry{Kind: ERASE_KIND_DISPUTE, Key: key}); err != nil {
+		return "", err
+	}
+	return msgId, nil
+}
+
+// 挑战者在窗口期内冻结消息
+// args[0] 消息id
+// args[1] 挑战原因
+func (bs *CrossChain) challengeMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	_, challenger, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	if ok, err := bs.isChallenger(stub, challenger); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to check challenger"))
+	} else if !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "%s is not an authorized challenger", challenger))
+	}
+
+	dm, key, err := bs.getDisputedMessage(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get disputed message"))
+	}
+	if dm == nil || dm.Status != DISPUTE_STATUS_CHALLENGEABLE {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %s is not challengeable", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if now >= dm.Deadline {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "dispute window of message %s has closed", args[0]))
+	}
+
+	dm.Status = DISPUTE_STATUS_FROZEN
+	dm.Challenger = challenger
+	dm.Reason = args[1]
+	if err := putJSONState(stub, key, dm); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put disputed message"))
+	}
+	return shim.Success(nil)
+}
+
+// 管理员裁决被冻结的消息
+// args[0] 消息id
+// args[1] true放行并投递/false拒绝
+func (bs *CrossChain) resolveDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	accept, err := strconv.ParseBool(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "accept(%s) format error", args[1]))
+	}
+	dm, key, err := bs.getDisputedMessage(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get disputed message"))
+	}
+	if dm == nil || dm.Status != DISPUTE_STATUS_FROZEN {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %s is not frozen", args[0]))
+	}
+
+	if !accept {
+		dm.Status = DISPUTE_STATUS_REJECTED
+		if err := putJSONState(stub, key, dm); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put disputed message"))
+		}
+		return shim.Success(nil)
+	}
+	return bs.finalizeDisputedMessage(stub, dm, key)
+}
+
+// 窗口期结束后投递消息，任何人都可以调用
+// args[0] 消息id
+func (bs *CrossChain) finalizeMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	dm, key, err := bs.getDisputedMessage(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get disputed message"))
+	}
+	if dm == nil || dm.Status != DISPUTE_STATUS_CHALLENGEABLE {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %s is not challengeable", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if now < dm.Deadline {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "dispute window of message %s is still open until %d", args[0], dm.Deadline))
+	}
+	return bs.finalizeDisputedMessage(stub, dm, key)
+}
+
+func (bs *CrossChain) finalizeDisputedMessage(stub shim.ChaincodeStubInterface, dm *DisputedMessage, key string) pb.Response {
+	tracer, err := bs.newDebugTracer(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to start debug trace"))
+	}
+	tracer.begin(dm.Message)
+	tracer.step(TRACE_STEP_VERIFY, true, "dispute status %s, window closed at %d", dm.Status, dm.Deadline)
+	if re := bs.deliverMessage(stub, dm.Message, tracer); re.Status != shim.OK {
+		return re
+	}
+	dm.Status = DISPUTE_STATUS_FINALIZED
+	if err := putJSONState(stub, key, dm); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put disputed message"))
+	}
+	if dm.Message.PacketHash != "" {
+		if err := bs.updateMessageRecord(stub, inboundRecordKey(dm.Message.From, dm.Message.PacketHash), MESSAGE_STATUS_DELIVERED); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to update message record"))
+		}
+	}
+	if err := bs.flushDebugTrace(stub, tracer); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to write debug trace"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询争议窗口中的消息
+// args[0] 消息id
+func (bs *CrossChain) queryDisputedMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	dm, _, err := bs.getDisputedMessage(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get disputed message"))
+	}
+	if dm == nil {
+		return shim.Success(nil)
+	}
+	raw, _ := json.Marshal(dm)
+	return shim.Success(raw)
+}
+
+// 按页查询尚未投递的消息(CHALLENGEABLE和FROZEN)
+// args[0] 每页条数(可选)
+// args[1] 书签(可选)
+func (bs *CrossChain) queryPendingDisputes(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	pageSize, bookmark, err := parsePageArgs(args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+	page := DisputePage{Messages: []DisputedMessage{}}
+	page.Bookmark, err = scanCompositeKeyPage(stub, K_DISPUTE_OBJECT_TYPE, []string{}, pageSize, bookmark, func(_ string, value []byte) error {
+		var dm DisputedMessage
+		if err := json.Unmarshal(value, &dm); err != nil {
+			return err
+		}
+		if dm.Status == DISPUTE_STATUS_CHALLENGEABLE || dm.Status == DISPUTE_STATUS_FROZEN {
+			page.Messages = append(page.Messages, dm)
+		}
+		return nil
+	})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to query disputes"))
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
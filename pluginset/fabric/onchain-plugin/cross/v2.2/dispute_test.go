@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestDisputeWindow(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	challenger := testCertHash(TEST_RELAYER_CERT)
+
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "from.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setChallenger", challenger, "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("first"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_ORDERED},
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("second"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_ORDERED},
+	}}
+	raw, _ := json.Marshal(msgs)
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 消息暂存，尚未回调业务链码
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); len(res.Payload) != 0 {
+		t.Fatalf("message should be held, got %s", res.Payload)
+	}
+	bs := NewCrossChain()
+	res := CallPagedWithTimestamp(stub, 0, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.queryPendingDisputes(stub, nil)
+	})
+	var page DisputePage
+	if err := json.Unmarshal(res.Payload, &page); err != nil || len(page.Messages) != 2 {
+		t.Fatalf("unexpected pending disputes: %s", res.Payload)
+	}
+	pending := page.Messages
+	var first, second DisputedMessage
+	for _, dm := range pending {
+		if string(dm.Message.Content) == "first" {
+			first = dm
+		} else {
+			second = dm
+		}
+	}
+
+	now := first.ReceivedAt
+
+	// 窗口期内不能投递
+	res = CallWithTimestamp(stub, now+1, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeMessage(stub, []string{first.MsgId})
+	})
+	if res.Status == shim.OK {
+		t.Fatal("finalize inside dispute window should fail")
+	}
+
+	// 非挑战者不能冻结
+	if res := InvokeWithStrings(t, stub, sp, "challengeMessage", second.MsgId, "bad proof"); res.Status == shim.OK {
+		t.Fatal("unauthorized challenge should fail")
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "challengeMessage", second.MsgId, "bad proof"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+
+	// 窗口期结束后投递未被挑战的消息
+	res = CallWithTimestamp(stub, first.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeMessage(stub, []string{first.MsgId})
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "first") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+
+	// 被冻结的消息只能由治理裁决
+	res = CallWithTimestamp(stub, second.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeMessage(stub, []string{second.MsgId})
+	})
+	if res.Status == shim.OK {
+		t.Fatal("frozen message should not be finalized")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "resolveDispute", second.MsgId, "false"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res = InvokeWithStrings(t, stub, sp, "queryDisputedMessage", second.MsgId)
+	var dm DisputedMessage
+	_ = json.Unmarshal(res.Payload, &dm)
+	if dm.Status != DISPUTE_STATUS_REJECTED || dm.Challenger != testCertHash(TEST_RELAYER_CERT) {
+		t.Fatalf("unexpected disputed message: %+v", dm)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "first") {
+		t.Fatalf("rejected message should not be delivered: %s", res.Payload)
+	}
+}
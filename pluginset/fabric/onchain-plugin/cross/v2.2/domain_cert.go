@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 本链域名证书轮换
+// BCDNS重新签发域名证书时，管理员设置新证书并指定双证书有效窗口，窗口内新旧证书都被接受，
+// 中继和对端链可以通过queryDomainCert/checkDomainCert或轮换事件提前切换，路由不中断。
+// 上一次轮换的窗口结束前不能再次轮换，同一时刻最多两张有效证书。
+const (
+	// crosschain_domain_cert -> DomainCertState
+	K_DOMAIN_CERT = CROSSCHAIN_PREFIX + "domain_cert"
+
+	// 设置或轮换证书时发出的事件，payload为DomainCertState
+	K_DOMAIN_CERT_EVENT = CROSSCHAIN_PREFIX + "domain_cert_rotated"
+)
+
+type DomainCert struct {
+	Cert     string `json:"cert"` // PEM
+	Hash     string `json:"hash"` // sha256(DER)，hex
+	NotAfter int64  `json:"notAfter"`
+}
+
+type DomainCertState struct {
+	Current  *DomainCert `json:"current"`
+	Previous *DomainCert `json:"previous,omitempty"`
+	// 旧证书在此时间之前(含)仍被接受
+	PreviousValidUntil int64 `json:"previousValidUntil,omitempty"`
+}
+
+func parseDomainCert(certPEM string) (*DomainCert, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, errors.New("invalid domain certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse domain certificate: %v", err)
+	}
+	h := sha256.Sum256(cert.Raw)
+	return &DomainCert{Cert: certPEM, Hash: hex.EncodeToString(h[:]), NotAfter: cert.NotAfter.Unix()}, nil
+}
+
+// 读取证书状态，去掉窗口已结束的旧证书；未设置证书时返回nil
+func (bs *CrossChain) getDomainCertState(stub shim.ChaincodeStubInterface) (*DomainCertState, error) {
+	var state DomainCertState
+	has, err := getJSONState(stub, K_DOMAIN_CERT, &state)
+	if err != nil || !has {
+		return nil, err
+	}
+	if state.Previous != nil {
+		now, err := getTxTimestamp(stub)
+		if err != nil {
+			return nil, err
+		}
+		if now > state.PreviousValidUntil {
+			state.Previous, state.PreviousValidUntil = nil, 0
+		}
+	}
+	return &state, nil
+}
+
+// 设置或轮换本链域名证书
+// args[0] 新证书(PEM)
+// args[1] 旧证书继续有效的时长(秒)，首次设置时不需要
+func (bs *CrossChain) rotateDomainCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 && len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	cert, err := parseDomainCert(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "domain certificate format error"))
+	}
+	state, err := bs.getDomainCertState(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain cert state"))
+	}
+	if state == nil {
+		state = &DomainCertState{Current: cert}
+	} else {
+		if len(args) != 2 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "rotation window is required when replacing domain certificate"))
+		}
+		window, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || window < 0 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid rotation window: %s", args[1]))
+		}
+		if state.Current.Hash == cert.Hash {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "new domain certificate is the same as current"))
+		}
+		if state.Previous != nil {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "previous domain certificate is still valid until %d", state.PreviousValidUntil))
+		}
+		now, err := getTxTimestamp(stub)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+		}
+		state = &DomainCertState{Current: cert, Previous: state.Current, PreviousValidUntil: now + window}
+	}
+	if err := putJSONState(stub, K_DOMAIN_CERT, state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	bz, _ := json.Marshal(state)
+	if err := stub.SetEvent(K_DOMAIN_CERT_EVENT, bz); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+	}
+	return shim.Success(bz)
+}
+
+// 查询当前被接受的域名证书
+func (bs *CrossChain) queryDomainCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	state, err := bs.getDomainCertState(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain cert state"))
+	}
+	if state == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "domain certificate not set"))
+	}
+	bz, _ := json.Marshal(state)
+	return shim.Success(bz)
+}
+
+// 检查证书当前是否被接受，返回current或previous
+// args[0] 证书sha256(hex)
+func (bs *CrossChain) checkDomainCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	state, err := bs.getDomainCertState(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain cert state"))
+	}
+	switch {
+	case state == nil:
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "domain certificate not set"))
+	case state.Current.Hash == args[0]:
+		return shim.Success([]byte("current"))
+	case state.Previous != nil && state.Previous.Hash == args[0]:
+		return shim.Success([]byte("previous"))
+	}
+	return errorResponse("", crosserr.New(crosserr.CodeVerify, "domain certificate %s is not accepted", args[0]))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func TestRotateDomainCert(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	if res := InvokeWithStrings(t, stub, sp, "queryDomainCert"); res.Status == shim.OK {
+		t.Fatal("domain certificate should not be set")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "rotateDomainCert", "not a cert"); res.Status == shim.OK {
+		t.Fatal("invalid certificate should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "rotateDomainCert", TEST_ADMIN_CERT); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	<-stub.ChaincodeEventsChannel
+
+	call := func(now int64, fn func(stub shim.ChaincodeStubInterface, args []string) pb.Response, args ...string) pb.Response {
+		return CallWithTimestamp(stub, now, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return fn(stub, args)
+		})
+	}
+	bs := NewCrossChain()
+	if res := call(1000, bs.rotateDomainCert, TEST_RELAYER_CERT); res.Status == shim.OK {
+		t.Fatal("rotation window is required")
+	}
+	if res := call(1000, bs.rotateDomainCert, TEST_ADMIN_CERT, "100"); res.Status == shim.OK {
+		t.Fatal("same certificate should be rejected")
+	}
+	if res := call(1000, bs.rotateDomainCert, TEST_RELAYER_CERT, "100"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := <-stub.ChaincodeEventsChannel
+	var published DomainCertState
+	if err := json.Unmarshal(event.Payload, &published); err != nil || event.EventName != K_DOMAIN_CERT_EVENT {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	if published.Current.Hash != testCertHash(TEST_RELAYER_CERT) || published.Previous.Hash != testCertHash(TEST_ADMIN_CERT) || published.PreviousValidUntil != 1100 {
+		t.Fatalf("unexpected published state: %+v", published)
+	}
+
+	// 窗口内新旧证书都被接受，不能再次轮换
+	if res := call(1100, bs.checkDomainCert, testCertHash(TEST_ADMIN_CERT)); string(res.Payload) != "previous" {
+		t.Fatalf("old certificate should be accepted in window: %s", res.Message)
+	}
+	if res := call(1100, bs.checkDomainCert, testCertHash(TEST_RELAYER_CERT)); string(res.Payload) != "current" {
+		t.Fatalf("new certificate should be accepted: %s", res.Message)
+	}
+	if res := call(1050, bs.rotateDomainCert, TEST_ADMIN_CERT, "100"); res.Status == shim.OK {
+		t.Fatal("rotation within window should be rejected")
+	}
+
+	// 窗口结束后只接受新证书
+	if res := call(1101, bs.checkDomainCert, testCertHash(TEST_ADMIN_CERT)); res.Status == shim.OK {
+		t.Fatal("old certificate should expire after window")
+	}
+	var state DomainCertState
+	_ = json.Unmarshal(call(1101, bs.queryDomainCert).Payload, &state)
+	if state.Previous != nil || state.Current.Hash != testCertHash(TEST_RELAYER_CERT) {
+		t.Fatalf("unexpected state after window: %+v", state)
+	}
+}
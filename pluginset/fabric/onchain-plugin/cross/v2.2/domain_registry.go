@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bcdns"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 对端域名登记表
+// 管理员登记BCDNS域名对应的域名证书和所有者身份(所有者证书sha256)，所有者或管理员可以更新证书，
+// 接收消息时可以按来源域名取出登记的证书做校验，而不是只比较域名字符串。
+// 证书必须是该域名的域名证书，设置了BCDNS信任根时证书链必须链到信任根，见bcdns_trust.go。
+// 本链自己的域名证书见domain_cert.go。
+const (
+	// crosschain_domain_registry_${domain} -> DomainRecord
+	K_DOMAIN_REGISTRY_PREFIX = CROSSCHAIN_PREFIX + "domain_registry_"
+)
+
+type DomainRecord struct {
+	Domain   string `json:"domain"`
+	Cert     string `json:"cert"`     // PEM
+	CertHash string `json:"certHash"` // sha256(证书内容)，hex
+	// 签发域名证书的域名空间证书(PEM)，由下往上
+	Chain []string `json:"chain,omitempty"`
+	// 所有者证书sha256(hex)
+	Owner        string `json:"owner"`
+	RegisteredAt int64  `json:"registeredAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+}
+
+// 解析并校验domain的域名证书，chain为签发它的域名空间证书
+func (bs *CrossChain) verifyDomainCert(stub shim.ChaincodeStubInterface, domain string, certPEM string, chain []string) (*bcdns.CrossChainCertificate, error) {
+	cert, err := bcdns.DecodePEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := cert.DomainNameSubject()
+	if err != nil {
+		return nil, err
+	}
+	if subject.DomainNameType != bcdns.DOMAIN_NAME_TYPE_DOMAIN_NAME || subject.DomainName != domain {
+		return nil, fmt.Errorf("certificate %s is not a domain name certificate of %s", cert.Id, domain)
+	}
+	certs := []*bcdns.CrossChainCertificate{cert}
+	for _, p := range chain {
+		c, err := bcdns.DecodePEM(p)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if err := bs.verifyBCDNSChain(stub, certs...); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// 未登记时返回nil
+func (bs *CrossChain) getDomainRecord(stub shim.ChaincodeStubInterface, domain string) (*DomainRecord, error) {
+	var record DomainRecord
+	has, err := getJSONState(stub, K_DOMAIN_REGISTRY_PREFIX+domain, &record)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// 登记对端域名
+// args[0] 域名
+// args[1] 域名证书(PEM)
+// args[2] 所有者证书sha256(hex)
+// args[3..] 签发域名证书的域名空间证书(PEM，可选)
+func (bs *CrossChain) registerDomain(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain, owner := args[0], args[2]
+	if domain == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	if id, err := hex.DecodeString(owner); err != nil || len(id) != sha256.Size {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid owner: %s", owner))
+	}
+	cert, err := bs.verifyDomainCert(stub, domain, args[1], args[3:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "domain certificate verify failed"))
+	}
+	if old, err := bs.getDomainRecord(stub, domain); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain record"))
+	} else if old != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "domain %s is already registered", domain))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	record := &DomainRecord{Domain: domain, Cert: args[1], CertHash: sha256Hex(cert.Raw), Chain: args[3:], Owner: owner, RegisteredAt: now, UpdatedAt: now}
+	if err := putJSONState(stub, K_DOMAIN_REGISTRY_PREFIX+domain, record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put domain record"))
+	}
+	bz, _ := json.Marshal(record)
+	return shim.Success(bz)
+}
+
+// 更新对端域名证书，调用者必须是管理员或登记的所有者
+// args[0] 域名
+// args[1] 新证书(PEM)
+// args[2..] 签发新证书的域名空间证书(PEM，可选)
+func (bs *CrossChain) updateDomainCert(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	record, err := bs.getDomainRecord(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain record"))
+	}
+	if record == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "domain %s is not registered", args[0]))
+	}
+	if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+		_, caller, err := getCreatorIdentity(stub)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+		}
+		if caller != record.Owner {
+			return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "%s is neither admin nor owner of domain %s", caller, args[0]))
+		}
+	}
+	cert, err := bs.verifyDomainCert(stub, args[0], args[1], args[2:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "domain certificate verify failed"))
+	}
+	hash := sha256Hex(cert.Raw)
+	if hash == record.CertHash {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "new domain certificate is the same as current"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	record.Cert, record.CertHash, record.Chain, record.UpdatedAt = args[1], hash, args[2:], now
+	if err := putJSONState(stub, K_DOMAIN_REGISTRY_PREFIX+args[0], record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put domain record"))
+	}
+	bz, _ := json.Marshal(record)
+	return shim.Success(bz)
+}
+
+// 查询登记的对端域名
+// args[0] 域名
+func (bs *CrossChain) queryDomainRecord(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	record, err := bs.getDomainRecord(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get domain record"))
+	}
+	if record == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "domain %s is not registered", args[0]))
+	}
+	bz, _ := json.Marshal(record)
+	return shim.Success(bz)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bcdns"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+func TestDomainRegistry(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	owner := testCertHash(TEST_RELAYER_CERT)
+	issuer, applicant := newTestBCDNSKey(), newTestBCDNSKey()
+	cert := issuer.issueDomain(t, "v1", "src.com", "", applicant)
+
+	for _, args := range [][]string{
+		{"src.com", "not a cert", owner},
+		{"src.com", TEST_ADMIN_CERT, owner},
+		{"src.com", issuer.issueDomain(t, "v1", "other.com", "", applicant), owner},
+		{"src.com", issuer.issuePTC(t, "ptc", 1<<40), owner},
+		{"src.com", cert, "owner"},
+		{"", cert, owner},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, append([]string{"registerDomain"}, args...)...); res.Status == shim.OK {
+			t.Fatalf("invalid registration should be rejected: %v", args)
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "src.com", cert, owner); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "src.com", cert, owner); res.Status == shim.OK {
+		t.Fatal("duplicated registration should be rejected")
+	}
+	query := func() DomainRecord {
+		var record DomainRecord
+		res := InvokeWithStrings(t, stub, sp, "queryDomainCert", "src.com")
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if err := json.Unmarshal(res.Payload, &record); err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+	if record := query(); record.Owner != owner || record.Cert != cert || record.CertHash != testCertHash(cert) {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryDomainCert", "other.com"); res.Status == shim.OK {
+		t.Fatal("unregistered domain should not be found")
+	}
+	// 不带参数仍查询本链域名证书
+	if res := InvokeWithStrings(t, stub, sp, "queryDomainCert"); res.Status == shim.OK {
+		t.Fatal("local domain certificate should not be set")
+	}
+
+	// 管理员和所有者都可以更新
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "src.com", cert); res.Status == shim.OK {
+		t.Fatal("same certificate should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "src.com", issuer.issueDomain(t, "v2", "src.com", "", applicant)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	v3 := issuer.issueDomain(t, "v3", "src.com", "", applicant)
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "src.com", v3); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "other.com", cert, owner); res.Status == shim.OK {
+		t.Fatal("non-admin registration should be rejected")
+	}
+	if record := query(); record.CertHash != testCertHash(v3) {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	otherCert := issuer.issueDomain(t, "other", "other.com", "", applicant)
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "other.com", otherCert, testCertHash(TEST_ADMIN_CERT)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "other.com", issuer.issueDomain(t, "other2", "other.com", "", applicant)); res.Status == shim.OK {
+		t.Fatal("non-owner update should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "unknown.com", cert); res.Status == shim.OK {
+		t.Fatal("update of unregistered domain should be rejected")
+	}
+}
+
+func TestDomainRegistryTrustRoot(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	owner := testCertHash(TEST_RELAYER_CERT)
+	rootKey, spaceKey, applicant := newTestBCDNSKey(), newTestBCDNSKey(), newTestBCDNSKey()
+	if res := InvokeWithStrings(t, stub, sp, "setBCDNSTrustRoot", rootKey.issueRoot(t)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	space := rootKey.issue(t, "space", bcdns.CERT_TYPE_DOMAIN_NAME, &bcdns.DomainNameCredentialSubject{
+		Version: "1.0", DomainNameType: bcdns.DOMAIN_NAME_TYPE_DOMAIN_NAME_SPACE, DomainName: ".com", Applicant: spaceKey.oid,
+	}, 1<<40)
+
+	// 设置信任根后证书必须链到信任根
+	for _, args := range [][]string{
+		{"a.com", newTestBCDNSKey().issueDomain(t, "a", "a.com", "", applicant), owner},
+		{"a.com", spaceKey.issueDomain(t, "a", "a.com", ".com", applicant), owner},
+		{"a.org", spaceKey.issueDomain(t, "a", "a.org", ".org", applicant), owner, space},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, append([]string{"registerDomain"}, args...)...); res.Status == shim.OK {
+			t.Fatalf("certificate not chained to trust root should be rejected: %s", args[0])
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "a.com", spaceKey.issueDomain(t, "a", "a.com", ".com", applicant), owner, space); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerDomain", "b.com", rootKey.issueDomain(t, "b", "b.com", "", applicant), owner); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "a.com", spaceKey.issueDomain(t, "a2", "a.com", ".com", applicant)); res.Status == shim.OK {
+		t.Fatal("update without domain space certificate should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "updateDomainCert", "a.com", spaceKey.issueDomain(t, "a2", "a.com", ".com", applicant), space); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 背书要求查询
+// 链码无法读取自身定义中的背书策略，由管理员把链码级背书策略(需要哪些组织、至少几个)登记在链上；
+// key级背书策略(state-based endorsement)直接从账本读取。链下插件提交跨链交易前查询，
+// 预先选择满足要求的背书节点，避免背书失败后重试。
+const (
+	K_ENDORSEMENT_POLICY = CROSSCHAIN_PREFIX + "endorsement_policy"
+)
+
+type EndorsementPolicy struct {
+	// 至少需要多少个组织背书
+	Threshold int      `json:"threshold"`
+	Orgs      []string `json:"orgs"` // MSP ID
+}
+
+type KeyEndorsementPolicy struct {
+	Key       string   `json:"key"`
+	Threshold int      `json:"threshold"`
+	Orgs      []string `json:"orgs"`
+}
+
+type EndorsementRequirement struct {
+	Chaincode *EndorsementPolicy     `json:"chaincode"`
+	Keys      []KeyEndorsementPolicy `json:"keys"`
+}
+
+// args[0]为背书组织数下限，其后为组织MSP ID
+func parseEndorsementPolicyArgs(args []string) (*EndorsementPolicy, error) {
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil || threshold <= 0 || threshold > len(args)-1 {
+		return nil, fmt.Errorf("invalid endorsement threshold: %s", args[0])
+	}
+	policy := &EndorsementPolicy{Threshold: threshold}
+	seen := make(map[string]bool)
+	for _, org := range args[1:] {
+		if org == "" || seen[org] {
+			return nil, fmt.Errorf("invalid or duplicated org: %s", org)
+		}
+		seen[org] = true
+		policy.Orgs = append(policy.Orgs, org)
+	}
+	return policy, nil
+}
+
+// 登记链码级背书策略
+// args[0] 至少需要多少个组织背书
+// args[1..] 组织MSP ID
+func (bs *CrossChain) setEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	policy, err := parseEndorsementPolicyArgs(args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid endorsement policy"))
+	}
+	if err := putJSONState(stub, K_ENDORSEMENT_POLICY, policy); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put policy"))
+	}
+	return shim.Success(nil)
+}
+
+// 构造key级背书策略，与statebased包生成的策略格式一致：N-out-of-M个组织成员签名
+func buildEndorsementPolicy(threshold int, orgs []string) ([]byte, error) {
+	nOutOf := &comm.SignaturePolicy_NOutOf{N: int32(threshold)}
+	envelope := &comm.SignaturePolicyEnvelope{
+		Rule: &comm.SignaturePolicy{Type: &comm.SignaturePolicy_NOutOf_{NOutOf: nOutOf}},
+	}
+	for i, org := range orgs {
+		role, err := proto.Marshal(&msp.MSPRole{MspIdentifier: org, Role: msp.MSPRole_MEMBER})
+		if err != nil {
+			return nil, err
+		}
+		envelope.Identities = append(envelope.Identities, &msp.MSPPrincipal{PrincipalClassification: msp.MSPPrincipal_ROLE, Principal: role})
+		nOutOf.Rules = append(nOutOf.Rules, &comm.SignaturePolicy{Type: &comm.SignaturePolicy_SignedBy{SignedBy: int32(i)}})
+	}
+	return proto.Marshal(envelope)
+}
+
+// 解析key级背书策略
+func parseKeyEndorsementPolicy(key string, ep []byte) (*KeyEndorsementPolicy, error) {
+	var envelope comm.SignaturePolicyEnvelope
+	if err := proto.Unmarshal(ep, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid endorsement policy of key %s: %v", key, err)
+	}
+	policy := &KeyEndorsementPolicy{Key: key, Threshold: int(envelope.GetRule().GetNOutOf().GetN())}
+	for _, principal := range envelope.Identities {
+		if principal.PrincipalClassification != msp.MSPPrincipal_ROLE {
+			return nil, fmt.Errorf("unsupported principal classification %v in policy of key %s", principal.PrincipalClassification, key)
+		}
+		var role msp.MSPRole
+		if err := proto.Unmarshal(principal.Principal, &role); err != nil {
+			return nil, fmt.Errorf("invalid principal in policy of key %s: %v", key, err)
+		}
+		policy.Orgs = append(policy.Orgs, role.MspIdentifier)
+	}
+	return policy, nil
+}
+
+// 查询跨链写操作的背书要求
+// args[0..] 可选，要写入的state key，返回其中设置了key级背书策略的key
+func (bs *CrossChain) queryEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var req EndorsementRequirement
+	var policy EndorsementPolicy
+	if has, err := getJSONState(stub, K_ENDORSEMENT_POLICY, &policy); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if has {
+		req.Chaincode = &policy
+	}
+	for _, key := range args {
+		ep, err := stub.GetStateValidationParameter(key)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get endorsement policy of key %s", key))
+		}
+		if len(ep) == 0 {
+			continue
+		}
+		kp, err := parseKeyEndorsementPolicy(key, ep)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse endorsement policy"))
+		}
+		req.Keys = append(req.Keys, *kp)
+	}
+	bz, _ := json.Marshal(&req)
+	return shim.Success(bz)
+}
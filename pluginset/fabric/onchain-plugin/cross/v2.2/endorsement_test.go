@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+func TestEndorsementPolicy(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+
+	var req EndorsementRequirement
+	res := InvokeWithStrings(t, stub, sp, "queryEndorsementPolicy")
+	if err := json.Unmarshal(res.Payload, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Chaincode != nil || len(req.Keys) != 0 {
+		t.Fatalf("unexpected endorsement requirement: %s", res.Payload)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setEndorsementPolicy", "3", "Org1MSP", "Org2MSP"); res.Status == shim.OK {
+		t.Fatal("threshold larger than orgs should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEndorsementPolicy", "2", "Org1MSP", "Org1MSP"); res.Status == shim.OK {
+		t.Fatal("duplicated org should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEndorsementPolicy", "2", "Org1MSP", "Org2MSP", "Org3MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	ep, err := buildEndorsementPolicy(1, []string{"Org2MSP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stub.SetStateValidationParameter("seq_key", ep); err != nil {
+		t.Fatal(err)
+	}
+	res = InvokeWithStrings(t, stub, sp, "queryEndorsementPolicy", "seq_key", "other_key")
+	if err := json.Unmarshal(res.Payload, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Chaincode == nil || req.Chaincode.Threshold != 2 || len(req.Chaincode.Orgs) != 3 {
+		t.Fatalf("unexpected chaincode endorsement policy: %s", res.Payload)
+	}
+	if len(req.Keys) != 1 || req.Keys[0].Key != "seq_key" || req.Keys[0].Threshold != 1 || len(req.Keys[0].Orgs) != 1 || req.Keys[0].Orgs[0] != "Org2MSP" {
+		t.Fatalf("unexpected key endorsement policies: %s", res.Payload)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 消息内容擦除
+// 已投递(或已拒绝、已撤销)的消息在链码中仍保存有完整内容：争议窗口暂存的消息、乐观模式的声明、
+// 隐私路由集合中的消息。管理员或接收消息的业务链码可以擦除这些内容，满足数据最小化要求；
+// 擦除时保存回执，记录内容摘要，审计方可以用持有的原文核对。
+//
+// 私有数据集合中的旧版本由集合的blockToLive配置清理。
+const (
+	// crosschain_erase_${kind}_${id} -> EraseReceipt
+	K_ERASE_RECEIPT_PREFIX = CROSSCHAIN_PREFIX + "erase_"
+
+	ERASE_KIND_DISPUTE    = "dispute"
+	ERASE_KIND_OPTIMISTIC = "optimistic"
+	ERASE_KIND_PRIVATE    = "private"
+)
+
+type EraseReceipt struct {
+	Kind        string `json:"kind"`
+	Id          string `json:"id"`
+	Receiver    string `json:"receiver"`              // 接收方身份(hex)
+	PayloadHash string `json:"payloadHash"`           // sha256(消息内容)
+	PackageHash string `json:"packageHash,omitempty"` // sha256(AM报文)，仅乐观模式
+	ErasedBy    string `json:"erasedBy"`              // 管理员证书sha256或业务链码名
+	TxId        string `json:"txId"`
+	ErasedAt    int64  `json:"erasedAt"`
+}
+
+func eraseReceiptKey(kind, id string) string {
+	return K_ERASE_RECEIPT_PREFIX + kind + "_" + id
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// 管理员或消息的接收方可以擦除，返回擦除者
+func (bs *CrossChain) checkEraser(stub shim.ChaincodeStubInterface, receiver [32]byte) (string, error) {
+	if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status == shim.OK {
+		_, admin, err := getCreatorIdentity(stub)
+		return admin, err
+	}
+	caller, err := getProposalChaincode(stub)
+	if err != nil {
+		return "", err
+	}
+	if sha256.Sum256([]byte(caller)) != receiver {
+		return "", fmt.Errorf("%s is neither admin nor receiver of the message", caller)
+	}
+	return caller, nil
+}
+
+// 擦除消息内容
+// args[0] 类型: dispute/optimistic/private
+// args[1] 消息id/声明id/隐私消息的"${txid}_${index}"
+// args[2] 来源域名，仅private需要
+func (bs *CrossChain) erasePayload(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	kind, id := args[0], args[1]
+	receiptKey := eraseReceiptKey(kind, id)
+	if has, err := getJSONState(stub, receiptKey, &EraseReceipt{}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if has {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "%s message %s already erased", kind, id))
+	}
+
+	receipt := &EraseReceipt{Kind: kind, Id: id, TxId: stub.GetTxID()}
+	var msg *oraclelogic.RecvAuthMessage
+	var commit func() error
+
+	switch kind {
+	case ERASE_KIND_DISPUTE:
+		dm, key, err := bs.getDisputedMessage(stub, id)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get disputed message"))
+		}
+		if dm == nil || (dm.Status != DISPUTE_STATUS_FINALIZED && dm.Status != DISPUTE_STATUS_REJECTED) {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %s is not finalized or rejected", id))
+		}
+		msg = &dm.Message
+		commit = func() error {
+			dm.Erased = true
+			return putJSONState(stub, key, dm)
+		}
+
+	case ERASE_KIND_OPTIMISTIC:
+		claim, key, err := bs.getOptimisticClaim(stub, id)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic claim"))
+		}
+		if claim == nil || claim.Status == CLAIM_STATUS_PENDING {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "claim %s is not finalized or reverted", id))
+		}
+		pkg, _ := hex.DecodeString(claim.AMPackage)
+		receipt.PackageHash = sha256Hex(pkg)
+		msg = &claim.Message
+		commit = func() error {
+			claim.AMPackage, claim.Proof, claim.Hint = "", "", ""
+			claim.Erased = true
+			return putJSONState(stub, key, claim)
+		}
+
+	case ERASE_KIND_PRIVATE:
+		if len(args) != 3 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+		}
+		route, err := bs.getPrivateRoute(stub, args[2])
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get private route"))
+		}
+		if route == nil {
+			return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no private route for domain %s", args[2]))
+		}
+		key := K_PRIVATE_MSG_PREFIX + id
+		raw, err := stub.GetPrivateData(route.Collection, key)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+		}
+		if len(raw) == 0 {
+			return errorResponse("", crosserr.New(crosserr.CodeNotFound, "private message %s not found", id))
+		}
+		var pm oraclelogic.RecvAuthMessage
+		if err := json.Unmarshal(raw, &pm); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read private message"))
+		}
+		msg = &pm
+		commit = func() error {
+			bz, _ := json.Marshal(pm)
+			return stub.PutPrivateData(route.Collection, key, bz)
+		}
+
+	default:
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown erase kind: %s", kind))
+	}
+
+	eraser, err := bs.checkEraser(stub, msg.Receiver)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "check eraser failed"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	receipt.Receiver = hex.EncodeToString(msg.Receiver[:])
+	receipt.PayloadHash = sha256Hex(msg.Content)
+	receipt.ErasedBy = eraser
+	receipt.ErasedAt = now
+
+	msg.Content = nil
+	if err := commit(); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to erase message"))
+	}
+	if err := putJSONState(stub, receiptKey, receipt); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put receipt"))
+	}
+	bz, _ := json.Marshal(receipt)
+	return shim.Success(bz)
+}
+
+// 查询擦除回执
+// args[0] 类型
+// args[1] id
+func (bs *CrossChain) queryEraseReceipt(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	raw, err := stub.GetState(eraseReceiptKey(args[0], args[1]))
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(raw) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no erase receipt for %s message %s", args[0], args[1]))
+	}
+	return shim.Success(raw)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestErasePayload(t *testing.T) {
+	stub, sp, _, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "from.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setPrivateRoute", "private.com", "bridgeCollection", "1", "Org1MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("held"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+		{From: "private.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("secret"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+	}}
+	raw, _ := json.Marshal(msgs)
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := CallPagedWithTimestamp(stub, 0, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return NewCrossChain().queryPendingDisputes(stub, nil)
+	})
+	var page DisputePage
+	if err := json.Unmarshal(res.Payload, &page); err != nil || len(page.Messages) != 1 {
+		t.Fatal("message should be held in dispute window")
+	}
+	dm := page.Messages[0]
+
+	// 投递前不能擦除
+	if res := InvokeWithStrings(t, stub, sp, "erasePayload", ERASE_KIND_DISPUTE, dm.MsgId); res.Status == shim.OK {
+		t.Fatal("pending message should not be erased")
+	}
+	res = CallWithTimestamp(stub, dm.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return NewCrossChain().finalizeMessage(stub, []string{dm.MsgId})
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 既不是管理员也不是接收方
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "erasePayload", ERASE_KIND_DISPUTE, dm.MsgId); res.Status == shim.OK {
+		t.Fatal("erase by others should be rejected")
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "erasePayload", ERASE_KIND_DISPUTE, dm.MsgId); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "erasePayload", ERASE_KIND_DISPUTE, dm.MsgId); res.Status == shim.OK {
+		t.Fatal("message should not be erased twice")
+	}
+	var erased DisputedMessage
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryDisputedMessage", dm.MsgId).Payload, &erased)
+	if !erased.Erased || len(erased.Message.Content) != 0 || erased.Status != DISPUTE_STATUS_FINALIZED {
+		t.Fatalf("unexpected erased message: %+v", erased)
+	}
+	var receipt EraseReceipt
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryEraseReceipt", ERASE_KIND_DISPUTE, dm.MsgId).Payload, &receipt)
+	if receipt.PayloadHash != sha256Hex([]byte("held")) || receipt.ErasedBy != testCertHash(TEST_ADMIN_CERT) {
+		t.Fatalf("unexpected erase receipt: %+v", receipt)
+	}
+
+	// 接收方业务链码擦除隐私路由集合中的消息
+	privateId := txid + "_1"
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, bizsp, "erasePayload", ERASE_KIND_PRIVATE, privateId, "private.com"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	var pm oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(stub.PvtState["bridgeCollection"][K_PRIVATE_MSG_PREFIX+privateId], &pm); err != nil {
+		t.Fatal(err)
+	}
+	receiver := sha256.Sum256([]byte("bizcc"))
+	if len(pm.Content) != 0 || pm.Receiver != receiver {
+		t.Fatalf("unexpected erased private message: %+v", pm)
+	}
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryEraseReceipt", ERASE_KIND_PRIVATE, privateId).Payload, &receipt)
+	if receipt.PayloadHash != sha256Hex([]byte("secret")) || receipt.ErasedBy != "bizcc" || receipt.Receiver != hex.EncodeToString(receiver[:]) {
+		t.Fatalf("unexpected erase receipt: %+v", receipt)
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"ethlightclient"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"mpt"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 以太坊轻客户端模块
+// 中继提交以太坊信标链的同步委员会更新，链码按PoS轻客户端规则校验后保存最终确认的执行层区块头；
+// 接收消息时中继提交交易回执的MPT证明，链码校验回执包含在已确认区块的receiptsRoot中，
+// 并从AM合约的事件中取出AM报文，ETH到Fabric方向不再需要信任中继。
+//
+// 链码不内置BLS12-381实现，部署时在本包内新增文件并在init()中注册：
+//
+//	func init() {
+//		registerEthBLSVerifier(&blstVerifier{})
+//	}
+const (
+	// crosschain_eth_lc_${domain} -> ethlightclient.Store
+	K_ETH_LC_PREFIX = CROSSCHAIN_PREFIX + "eth_lc_"
+	// crosschain_eth_header_${domain}_${blockNumber} -> EthExecutionHeader
+	K_ETH_HEADER_PREFIX = CROSSCHAIN_PREFIX + "eth_header_"
+	// crosschain_eth_am_${domain} -> EthAMContract
+	K_ETH_AM_CONTRACT_PREFIX = CROSSCHAIN_PREFIX + "eth_am_"
+	// crosschain_eth_consumed_${domain}_${blockNumber}_${txIndex}_${logIndex} -> 1
+	K_ETH_CONSUMED_PREFIX = CROSSCHAIN_PREFIX + "eth_consumed_"
+
+	// AM合约发送消息的事件签名
+	ETH_SEND_AUTH_MESSAGE_EVENT = "SendAuthMessage(bytes)"
+)
+
+// 已最终确认的执行层区块头
+type EthExecutionHeader struct {
+	BlockNumber  uint64 `json:"blockNumber"`
+	BlockHash    string `json:"blockHash"`
+	ReceiptsRoot string `json:"receiptsRoot"`
+	Timestamp    uint64 `json:"timestamp"`
+	BeaconSlot   uint64 `json:"beaconSlot"`
+}
+
+type EthAMContract struct {
+	Address string `json:"address"` // hex
+	Topic   string `json:"topic"`   // 事件topic0(hex)
+}
+
+type EthLightClientStatus struct {
+	FinalizedSlot        uint64 `json:"finalizedSlot"`
+	FinalizedBlockNumber uint64 `json:"finalizedBlockNumber"`
+	FinalizedBlockHash   string `json:"finalizedBlockHash"`
+	Period               uint64 `json:"period"`
+	NextCommitteeKnown   bool   `json:"nextCommitteeKnown"`
+	ForkVersion          string `json:"forkVersion"`
+}
+
+var ethBLSVerifier ethlightclient.BLSVerifier
+
+func registerEthBLSVerifier(verifier ethlightclient.BLSVerifier) {
+	ethBLSVerifier = verifier
+}
+
+func ethHeaderKey(domain string, blockNumber uint64) string {
+	return K_ETH_HEADER_PREFIX + domain + "_" + strconv.FormatUint(blockNumber, 10)
+}
+
+func (bs *CrossChain) getEthLightClient(stub shim.ChaincodeStubInterface, domain string) (*ethlightclient.Store, error) {
+	var store ethlightclient.Store
+	has, err := getJSONState(stub, K_ETH_LC_PREFIX+domain, &store)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "eth light client for domain %s not initialized", domain)
+	}
+	return &store, nil
+}
+
+func (bs *CrossChain) putEthFinalizedHeader(stub shim.ChaincodeStubInterface, domain string, header *ethlightclient.LightClientHeader) error {
+	return putJSONState(stub, ethHeaderKey(domain, header.Execution.BlockNumber), &EthExecutionHeader{
+		BlockNumber:  header.Execution.BlockNumber,
+		BlockHash:    hex.EncodeToString(header.Execution.BlockHash[:]),
+		ReceiptsRoot: hex.EncodeToString(header.Execution.ReceiptsRoot[:]),
+		Timestamp:    header.Execution.Timestamp,
+		BeaconSlot:   header.Beacon.Slot,
+	})
+}
+
+// 当前同步委员会哈希，用于区块头同步模块记录委员会轮换
+func ethSyncCommitteeHash(store *ethlightclient.Store) (string, error) {
+	root, err := store.CurrentSyncCommittee.HashTreeRoot()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(root[:]), nil
+}
+
+func parseRoot(s string) (ethlightclient.Root, error) {
+	var root ethlightclient.Root
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != 32 {
+		return root, fmt.Errorf("invalid root %s", s)
+	}
+	copy(root[:], raw)
+	return root, nil
+}
+
+// 初始化来源域名的以太坊轻客户端
+// args[0] 来源域名
+// args[1] genesis_validators_root(hex)
+// args[2] 信标链创世时间(秒)
+// args[3] 当前fork version(hex)
+// args[4] 可信的信标区块根(hex)
+// args[5] LightClientBootstrap(json)
+func (bs *CrossChain) initEthLightClient(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 6 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	if domain == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	genesisValidatorsRoot, err := parseRoot(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid genesis validators root"))
+	}
+	genesisTime, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid genesis time"))
+	}
+	forkVersion, err := hex.DecodeString(args[3])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid fork version"))
+	}
+	trustedRoot, err := parseRoot(args[4])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid trusted root"))
+	}
+	var bootstrap ethlightclient.LightClientBootstrap
+	if err := json.Unmarshal([]byte(args[5]), &bootstrap); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid bootstrap"))
+	}
+
+	store, err := ethlightclient.NewStore(genesisValidatorsRoot, genesisTime, forkVersion, trustedRoot, &bootstrap)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid bootstrap"))
+	}
+	if err := putJSONState(stub, K_ETH_LC_PREFIX+domain, store); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put store"))
+	}
+	if err := bs.putEthFinalizedHeader(stub, domain, &store.FinalizedHeader); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put finalized header"))
+	}
+	committeeHash, err := ethSyncCommitteeHash(store)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to hash sync committee"))
+	}
+	if err := bs.initHeaderSync(stub, domain, HEADER_SYNC_CLIENT_ETH, int64(store.FinalizedHeader.Execution.BlockNumber), committeeHash); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to init header sync"))
+	}
+	return shim.Success(nil)
+}
+
+// 以太坊硬分叉后更新fork version
+// args[0] 来源域名
+// args[1] fork version(hex)
+func (bs *CrossChain) setEthForkVersion(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	store, err := bs.getEthLightClient(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get eth light client"))
+	}
+	forkVersion, err := hex.DecodeString(args[1])
+	if err != nil || len(forkVersion) != 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid fork version"))
+	}
+	store.ForkVersion = forkVersion
+	if err := putJSONState(stub, K_ETH_LC_PREFIX+args[0], store); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put store"))
+	}
+	return shim.Success(nil)
+}
+
+// 设置来源域名的AM合约地址和事件topic
+// args[0] 来源域名
+// args[1] AM合约地址(hex)
+// args[2] 事件topic0(hex)，为空时使用SendAuthMessage(bytes)
+func (bs *CrossChain) setEthAMContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	address, err := hex.DecodeString(args[1])
+	if err != nil || len(address) != 20 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid contract address"))
+	}
+	topic := args[2]
+	if topic == "" {
+		eventTopic := ethlightclient.Keccak256([]byte(ETH_SEND_AUTH_MESSAGE_EVENT))
+		topic = hex.EncodeToString(eventTopic[:])
+	} else if _, err := parseRoot(topic); err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid event topic"))
+	}
+	if err := putJSONState(stub, K_ETH_AM_CONTRACT_PREFIX+args[0], &EthAMContract{Address: hex.EncodeToString(address), Topic: topic}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 提交同步委员会更新
+// args[0] 来源域名
+// args[1] LightClientUpdate(json)
+func (bs *CrossChain) submitEthUpdate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	store, err := bs.getEthLightClient(stub, domain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get eth light client"))
+	}
+	var update ethlightclient.LightClientUpdate
+	if err := json.Unmarshal([]byte(args[1]), &update); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid update"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil || now < 0 {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid tx timestamp"))
+	}
+
+	advanced, err := store.ProcessUpdate(&update, store.CurrentSlot(uint64(now)), ethBLSVerifier)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "update verify failed"))
+	}
+	if err := putJSONState(stub, K_ETH_LC_PREFIX+domain, store); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put store"))
+	}
+	if advanced {
+		if err := bs.putEthFinalizedHeader(stub, domain, &store.FinalizedHeader); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put finalized header"))
+		}
+		committeeHash, err := ethSyncCommitteeHash(store)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to hash sync committee"))
+		}
+		if err := bs.recordSyncedHeader(stub, domain, int64(store.FinalizedHeader.Execution.BlockNumber), committeeHash); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to record synced header"))
+		}
+	}
+	return shim.Success(nil)
+}
+
+// 查询轻客户端状态
+// args[0] 来源域名
+func (bs *CrossChain) queryEthLightClient(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	store, err := bs.getEthLightClient(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get eth light client"))
+	}
+	finalized := store.FinalizedHeader
+	status, _ := json.Marshal(&EthLightClientStatus{
+		FinalizedSlot:        finalized.Beacon.Slot,
+		FinalizedBlockNumber: finalized.Execution.BlockNumber,
+		FinalizedBlockHash:   hex.EncodeToString(finalized.Execution.BlockHash[:]),
+		Period:               ethlightclient.SyncCommitteePeriod(finalized.Beacon.Slot),
+		NextCommitteeKnown:   store.NextSyncCommittee != nil,
+		ForkVersion:          hex.EncodeToString(store.ForkVersion),
+	})
+	return shim.Success(status)
+}
+
+// 接收以太坊跨链消息，证明为交易回执在已确认区块中的MPT证明
+// args[0] 来源域名
+// args[1] 区块高度
+// args[2] 交易在区块中的序号
+// args[3] 回执证明，hex编码的节点数组(json)
+// args[4] AM合约事件在回执中的序号
+func (bs *CrossChain) recvEthMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	blockNumber, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid block number"))
+	}
+	txIndex, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid tx index"))
+	}
+	var proofHex []string
+	if err := json.Unmarshal([]byte(args[3]), &proofHex); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "invalid proof"))
+	}
+	proof := make([][]byte, len(proofHex))
+	for i, node := range proofHex {
+		if proof[i], err = hex.DecodeString(node); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "invalid proof node %d", i))
+		}
+	}
+	logIndex, err := strconv.Atoi(args[4])
+	if err != nil || logIndex < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid log index"))
+	}
+
+	consumedKey := fmt.Sprintf("%s%s_%d_%d_%d", K_ETH_CONSUMED_PREFIX, srcDomain, blockNumber, txIndex, logIndex)
+	if consumed, err := stub.GetState(consumedKey); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if len(consumed) != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message already received"))
+	}
+
+	var header EthExecutionHeader
+	has, err := getJSONState(stub, ethHeaderKey(srcDomain, blockNumber), &header)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "block %d of domain %s is not finalized", blockNumber, srcDomain))
+	}
+	var contract EthAMContract
+	if has, err = getJSONState(stub, K_ETH_AM_CONTRACT_PREFIX+srcDomain, &contract); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no am contract for domain %s", srcDomain))
+	}
+
+	receiptsRoot, _ := parseRoot(header.ReceiptsRoot)
+	receipt, err := mpt.VerifyIndexProof(receiptsRoot, txIndex, proof)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "receipt proof verify failed"))
+	}
+	logs, err := ethlightclient.DecodeReceiptLogs(receipt)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "receipt format error"))
+	}
+	if logIndex >= len(logs) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "log index %d out of range", logIndex))
+	}
+	log := logs[logIndex]
+	if hex.EncodeToString(log.Address[:]) != contract.Address {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "log is not emitted by am contract"))
+	}
+	if len(log.Topics) == 0 || hex.EncodeToString(log.Topics[0][:]) != contract.Topic {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "log is not an am message event"))
+	}
+	pkg, err := ethlightclient.DecodeABIBytes(log.Data)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "am package format error"))
+	}
+
+	ret := bs.Os.RecvAMPackage(stub, srcDomain, hex.EncodeToString(pkg))
+	if ret.Status != shim.OK {
+		return ret
+	}
+	if err := stub.PutState(consumedKey, []byte{1}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	var msg oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+	}
+	msgs, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{msg}})
+	return bs.callbackBizChaincode(stub, msgs)
+}
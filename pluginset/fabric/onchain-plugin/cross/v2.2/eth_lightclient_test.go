@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"ethlightclient"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"mpt"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+// 测试用的BLS校验器：签名为sha256(message || pubkeys...)
+type hashBLSVerifier struct{}
+
+func (v *hashBLSVerifier) FastAggregateVerify(pubkeys []ethlightclient.BLSPubkey, message [32]byte, signature ethlightclient.BLSSignature) error {
+	expected := hashBLSSign(pubkeys, message)
+	if !bytes.Equal(expected[:], signature[:]) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func hashBLSSign(pubkeys []ethlightclient.BLSPubkey, message [32]byte) ethlightclient.BLSSignature {
+	h := sha256.New()
+	h.Write(message[:])
+	for _, p := range pubkeys {
+		h.Write(p[:])
+	}
+	var sig ethlightclient.BLSSignature
+	copy(sig[:], h.Sum(nil))
+	return sig
+}
+
+// 稀疏merkle树，未设置的节点为全零
+type sparseMerkleTree map[uint64][32]byte
+
+func (tree sparseMerkleTree) node(g uint64) [32]byte {
+	if leaf, ok := tree[g]; ok {
+		return leaf
+	}
+	for l := range tree {
+		for ; l > g; l >>= 1 {
+		}
+		if l == g {
+			left, right := tree.node(2*g), tree.node(2*g+1)
+			return sha256.Sum256(append(left[:], right[:]...))
+		}
+	}
+	return [32]byte{}
+}
+
+func (tree sparseMerkleTree) branch(g uint64) []ethlightclient.Root {
+	var branch []ethlightclient.Root
+	for ; g > 1; g >>= 1 {
+		branch = append(branch, tree.node(g^1))
+	}
+	return branch
+}
+
+func testCommittee(seed byte) *ethlightclient.SyncCommittee {
+	committee := &ethlightclient.SyncCommittee{Pubkeys: make([]ethlightclient.BLSPubkey, ethlightclient.SYNC_COMMITTEE_SIZE)}
+	for i := range committee.Pubkeys {
+		committee.Pubkeys[i][0] = seed
+		binary.BigEndian.PutUint16(committee.Pubkeys[i][1:], uint16(i))
+	}
+	committee.AggregatePubkey[0] = seed
+	return committee
+}
+
+func testLightClientHeader(t *testing.T, slot, blockNumber uint64, receiptsRoot [32]byte, stateLeaves sparseMerkleTree) ethlightclient.LightClientHeader {
+	execution := ethlightclient.ExecutionPayloadHeader{
+		FeeRecipient:  make([]byte, 20),
+		ReceiptsRoot:  receiptsRoot,
+		LogsBloom:     make([]byte, 256),
+		BlockNumber:   blockNumber,
+		Timestamp:     slot * 12,
+		BaseFeePerGas: "7",
+	}
+	execution.BlockHash[0] = byte(blockNumber)
+	executionRoot, err := execution.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := sparseMerkleTree{ethlightclient.EXECUTION_PAYLOAD_GINDEX: executionRoot}
+
+	header := ethlightclient.LightClientHeader{Execution: execution, ExecutionBranch: body.branch(ethlightclient.EXECUTION_PAYLOAD_GINDEX)}
+	header.Beacon.Slot = slot
+	header.Beacon.BodyRoot = body.node(1)
+	header.Beacon.StateRoot = stateLeaves.node(1)
+	return header
+}
+
+// 构造包含两笔交易回执的receipts trie，第0笔交易的回执包含AM合约事件
+func testReceiptTrie(contract []byte, topic [32]byte, pkg []byte) ([32]byte, [][]byte) {
+	abiData := make([]byte, 64+(len(pkg)+31)/32*32)
+	abiData[31] = 32
+	binary.BigEndian.PutUint64(abiData[56:64], uint64(len(pkg)))
+	copy(abiData[64:], pkg)
+
+	amLog := ethlightclient.EncodeRLPList(
+		ethlightclient.EncodeRLPBytes(contract),
+		ethlightclient.EncodeRLPList(ethlightclient.EncodeRLPBytes(topic[:])),
+		ethlightclient.EncodeRLPBytes(abiData),
+	)
+	receipt := func(logs ...[]byte) []byte {
+		return append([]byte{0x02}, ethlightclient.EncodeRLPList(
+			ethlightclient.EncodeRLPUint(1),
+			ethlightclient.EncodeRLPUint(21000),
+			ethlightclient.EncodeRLPBytes(make([]byte, 256)),
+			ethlightclient.EncodeRLPList(logs...),
+		)...)
+	}
+
+	trie := mpt.NewTrie()
+	trie.Put(mpt.AppendIndexKey(nil, 0), receipt(amLog))
+	trie.Put(mpt.AppendIndexKey(nil, 1), receipt())
+	return trie.Root(), trie.Prove(mpt.AppendIndexKey(nil, 0))
+}
+
+func TestEthLightClient(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkg := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "eth hello")[0]
+	rawPkg, _ := hex.DecodeString(pkg)
+
+	contract := bytes.Repeat([]byte{0xaa}, 20)
+	topic := ethlightclient.Keccak256([]byte(ETH_SEND_AUTH_MESSAGE_EVENT))
+	receiptsRoot, proof := testReceiptTrie(contract, topic, rawPkg)
+
+	// 初始化
+	const period = 10
+	current, next := testCommittee(1), testCommittee(2)
+	currentRoot, _ := current.HashTreeRoot()
+	nextRoot, _ := next.HashTreeRoot()
+
+	bootstrapState := sparseMerkleTree{ethlightclient.CURRENT_SYNC_COMMITTEE_GINDEX: currentRoot}
+	bootstrapHeader := testLightClientHeader(t, period*ethlightclient.SLOTS_PER_PERIOD+10, 100, [32]byte{}, bootstrapState)
+	bootstrap, _ := json.Marshal(&ethlightclient.LightClientBootstrap{
+		Header:                     bootstrapHeader,
+		CurrentSyncCommittee:       *current,
+		CurrentSyncCommitteeBranch: bootstrapState.branch(ethlightclient.CURRENT_SYNC_COMMITTEE_GINDEX),
+	})
+	trustedRoot := bootstrapHeader.Beacon.HashTreeRoot()
+	genesisValidatorsRoot := hex.EncodeToString(bytes.Repeat([]byte{0x11}, 32))
+
+	if res := InvokeWithStrings(t, stub, sp, "initEthLightClient", "eth.test", genesisValidatorsRoot, "0", "04000000", hex.EncodeToString(bytes.Repeat([]byte{0x22}, 32)), string(bootstrap)); res.Status == shim.OK {
+		t.Fatal("bootstrap not matching trusted root should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "initEthLightClient", "eth.test", genesisValidatorsRoot, "0", "04000000", hex.EncodeToString(trustedRoot[:]), string(bootstrap)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEthAMContract", "eth.test", hex.EncodeToString(contract), ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 构造最终确认区块150的更新
+	finalizedHeader := testLightClientHeader(t, period*ethlightclient.SLOTS_PER_PERIOD+50, 150, receiptsRoot, sparseMerkleTree{})
+	finalizedRoot := finalizedHeader.Beacon.HashTreeRoot()
+	attestedState := sparseMerkleTree{
+		ethlightclient.FINALIZED_ROOT_GINDEX:      finalizedRoot,
+		ethlightclient.NEXT_SYNC_COMMITTEE_GINDEX: nextRoot,
+	}
+	attestedHeader := testLightClientHeader(t, period*ethlightclient.SLOTS_PER_PERIOD+100, 200, [32]byte{}, attestedState)
+
+	var forkVersion [4]byte
+	forkVersion[0] = 0x04
+	gvr, _ := parseRoot(genesisValidatorsRoot)
+	signingRoot := ethlightclient.ComputeSigningRoot(attestedHeader.Beacon.HashTreeRoot(), ethlightclient.ComputeSyncCommitteeDomain(forkVersion, gvr))
+	update := &ethlightclient.LightClientUpdate{
+		AttestedHeader:          attestedHeader,
+		NextSyncCommittee:       next,
+		NextSyncCommitteeBranch: attestedState.branch(ethlightclient.NEXT_SYNC_COMMITTEE_GINDEX),
+		FinalizedHeader:         &finalizedHeader,
+		FinalityBranch:          attestedState.branch(ethlightclient.FINALIZED_ROOT_GINDEX),
+		SyncAggregate: ethlightclient.SyncAggregate{
+			SyncCommitteeBits:      bytes.Repeat([]byte{0xff}, ethlightclient.SYNC_COMMITTEE_SIZE/8),
+			SyncCommitteeSignature: hashBLSSign(current.Pubkeys, signingRoot),
+		},
+		SignatureSlot: attestedHeader.Beacon.Slot + 1,
+	}
+	updateJSON, _ := json.Marshal(update)
+
+	// 未注册BLS校验器时拒绝更新
+	if res := InvokeWithStrings(t, stub, sp, "submitEthUpdate", "eth.test", string(updateJSON)); res.Status == shim.OK {
+		t.Fatal("update should be rejected without bls verifier")
+	}
+	registerEthBLSVerifier(&hashBLSVerifier{})
+	defer registerEthBLSVerifier(nil)
+
+	// 更新的各项校验见vendor/ethlightclient
+	forged := *update
+	forged.SyncAggregate.SyncCommitteeBits = append(bytes.Repeat([]byte{0xff}, 32), make([]byte, 32)...)
+	forgedJSON, _ := json.Marshal(&forged)
+	if res := InvokeWithStrings(t, stub, sp, "submitEthUpdate", "eth.test", string(forgedJSON)); res.Status == shim.OK {
+		t.Fatal("invalid update should be rejected")
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "recvEthMessage", "eth.test", "150", "0", mustJSONHex(proof), "0"); res.Status == shim.OK {
+		t.Fatal("message in unfinalized block should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "submitEthUpdate", "eth.test", string(updateJSON)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryEthLightClient", "eth.test")
+	var status EthLightClientStatus
+	if err := json.Unmarshal(res.Payload, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.FinalizedBlockNumber != 150 || !status.NextCommitteeKnown {
+		t.Fatalf("unexpected status: %s", res.Payload)
+	}
+
+	// 接收消息
+	if res := InvokeWithStrings(t, stub, sp, "recvEthMessage", "eth.test", "150", "1", mustJSONHex(proof), "0"); res.Status == shim.OK {
+		t.Fatal("proof for another key should be rejected")
+	}
+	tampered := [][]byte{proof[0], append([]byte{}, proof[1]...)}
+	tampered[1][len(tampered[1])-1] ^= 0xff
+	if res := InvokeWithStrings(t, stub, sp, "recvEthMessage", "eth.test", "150", "0", mustJSONHex(tampered), "0"); res.Status == shim.OK {
+		t.Fatal("tampered proof should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvEthMessage", "eth.test", "150", "0", mustJSONHex(proof), "0"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "eth hello") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvEthMessage", "eth.test", "150", "0", mustJSONHex(proof), "0"); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+}
+
+func mustJSONHex(nodes [][]byte) string {
+	encoded := make([]string, len(nodes))
+	for i, node := range nodes {
+		encoded[i] = hex.EncodeToString(node)
+	}
+	raw, _ := json.Marshal(encoded)
+	return string(raw)
+}
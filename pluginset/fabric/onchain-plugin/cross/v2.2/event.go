@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crosserr"
+	"crossevent"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+)
+
+// 发送消息事件
+// 每笔发送交易发出一个事件，包含本交易写入的所有消息记录及其承诺
+// sha256(uint32(len(key)) || key || value)。中继从某个节点订阅到事件后，可以通过
+// queryOutboundMessage从另一个节点读取同一个key重新计算承诺并比对，发现篡改事件或落后的节点。
+//
+// Fabric只保留最外层链码设置的事件，业务链码通过InvokeChaincode发送消息时，
+// 跨链链码的返回值同样是带承诺的消息记录，业务链码可以自行转发到自己的事件中。
+const (
+	K_OUTBOUND_EVENT = CROSSCHAIN_PREFIX + "outbound"
+)
+
+// v1发送事件中的消息记录，附带消息的追踪id(见log.go)
+type outboundEventRecord struct {
+	oraclelogic.OutboundMessage
+	TraceId string `json:"traceId"`
+}
+
+// 把sendMessage返回的消息记录作为本交易的事件发出，事件版本为v2时发出crossevent格式的事件(见event_v2.go)
+func (bs *CrossChain) emitOutboundEvent(stub shim.ChaincodeStubInterface, payloads ...[]byte) error {
+	msgs := []oraclelogic.OutboundMessage{}
+	for _, payload := range payloads {
+		var msg oraclelogic.OutboundMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return fmt.Errorf("failed to parse outbound message: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	version, err := getEventVersion(stub)
+	if err != nil {
+		return err
+	}
+	if version == EVENT_VERSION_2 {
+		events := make([]*crossevent.CrossChainEventV2, 0, len(msgs))
+		for _, msg := range msgs {
+			event, err := bs.sendEvent(stub, msg)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return setEventV2(stub, crossevent.EVENT_SENT_MESSAGE, events, nil)
+	}
+	records := make([]outboundEventRecord, 0, len(msgs))
+	for _, msg := range msgs {
+		records = append(records, outboundEventRecord{OutboundMessage: msg, TraceId: packageTraceId(msg.Package)})
+	}
+	bz, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(K_OUTBOUND_EVENT, bz)
+}
+
+// 从本节点的账本读取发送消息记录，并计算承诺
+// args[0] 消息记录的key，见事件中的key
+func (bs *CrossChain) queryOutboundMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if !strings.HasPrefix(args[0], oraclelogic.K_CROSSCHAIN_MSG_PREFIX) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid outbound message key: %s", args[0]))
+	}
+	value, err := stub.GetState(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(value) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "outbound message %s not found", args[0]))
+	}
+	bz, _ := json.Marshal(oraclelogic.OutboundMessage{Key: args[0], Package: value, Commitment: oraclelogic.MessageCommitment(args[0], value)})
+	return shim.Success(bz)
+}
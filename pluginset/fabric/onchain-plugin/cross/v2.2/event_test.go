@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestOutboundEventCommitment(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	if res := InvokeWithStrings(t, stub, sp, "batchSendUnorderedMessage", "dest.com", hex.EncodeToString(make([]byte, 32)), "m1", "m2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := <-stub.ChaincodeEventsChannel
+	if event.EventName != K_OUTBOUND_EVENT {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	var msgs []oraclelogic.OutboundMessage
+	if err := json.Unmarshal(event.Payload, &msgs); err != nil || len(msgs) != 2 {
+		t.Fatalf("unexpected event payload: %s", event.Payload)
+	}
+
+	// 从账本读取同一个key，承诺一致
+	for _, msg := range msgs {
+		var onLedger oraclelogic.OutboundMessage
+		if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryOutboundMessage", msg.Key).Payload, &onLedger); err != nil {
+			t.Fatal(err)
+		}
+		if onLedger.Commitment != msg.Commitment || string(onLedger.Package) != string(stub.State[msg.Key]) {
+			t.Fatalf("commitment mismatch for %s", msg.Key)
+		}
+	}
+
+	// 事件中的报文被篡改后承诺不再匹配
+	tampered := append([]byte{}, msgs[0].Package...)
+	tampered[len(tampered)-1] ^= 0xff
+	if oraclelogic.MessageCommitment(msgs[0].Key, tampered) == msgs[0].Commitment {
+		t.Fatal("tampered package should not match commitment")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryOutboundMessage", K_OUTBOUND_EVENT); res.Status == shim.OK {
+		t.Fatal("only outbound message keys can be queried")
+	}
+
+	// 单条发送的返回值就是带承诺的消息记录
+	res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", hex.EncodeToString(make([]byte, 32)), "m3", "n")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var sent oraclelogic.OutboundMessage
+	if err := json.Unmarshal(res.Payload, &sent); err != nil || sent.Commitment != oraclelogic.MessageCommitment(sent.Key, stub.State[sent.Key]) {
+		t.Fatalf("unexpected send result: %s", res.Payload)
+	}
+	if event := <-stub.ChaincodeEventsChannel; event.EventName != K_OUTBOUND_EVENT {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"am"
+	"crosserr"
+	"crossevent"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+	"tracecontext"
+)
+
+// 事件版本
+// 默认发出v1事件(发送消息记录见event.go，批量接收结果见recv_batch.go)。管理员设置为v2后，
+// 发送交易发出SENT_MESSAGE事件，接收交易发出RECEIVED_MESSAGE事件，接收交易回复了原子请求的回执时
+// 发出ACKED_MESSAGE事件，事件中同时带有回执报文的key，中继不必再轮询发送积压。载荷格式见crossevent包。
+// Fabric每笔交易只保留一个事件，v2下批量接收不再发出v1的批量结果事件，结果作为接收事件的batchResult字段发出。
+const (
+	// crosschain_event_version -> 十进制版本号
+	K_EVENT_VERSION = CROSSCHAIN_PREFIX + "event_version"
+
+	EVENT_VERSION_1 = 1
+	EVENT_VERSION_2 = 2
+)
+
+// 未设置时为v1
+func getEventVersion(stub shim.ChaincodeStubInterface) (int, error) {
+	raw, err := stub.GetState(K_EVENT_VERSION)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) == 0 {
+		return EVENT_VERSION_1, nil
+	}
+	return strconv.Atoi(string(raw))
+}
+
+func (bs *CrossChain) localDomain(stub shim.ChaincodeStubInterface) string {
+	raw, _ := bs.Os.GetState(stub, true, oraclelogic.K_EXPECTED_DOMAIN)
+	return string(raw)
+}
+
+// 从发送消息记录中的AM报文构造发送事件，手续费按目的域名当前的收费标准(见fee.go)
+func (bs *CrossChain) sendEvent(stub shim.ChaincodeStubInterface, msg oraclelogic.OutboundMessage) (*crossevent.CrossChainEventV2, error) {
+	amMsg, err := am.Decode(msg.Package)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+	}
+	// 其他上层协议的消息没有接收方和序号，目标域名在消息记录中
+	sdpMsg := &oraclelogic.SDPMessage{TargetDomain: msg.DestDomain, Sequence: oraclelogic.K_UNORDERED_MSG_SEQ}
+	if amMsg.GetProtocolType() == oraclelogic.P2P_MSG_PROTOCOL_TYPE {
+		if sdpMsg, err = oraclelogic.DecodeSDPMessage(amMsg.GetPayload()); err != nil {
+			return nil, fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+		}
+	}
+	fee, err := bs.getFee(stub, sdpMsg.TargetDomain)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256.Sum256(msg.Package)
+	return &crossevent.CrossChainEventV2{
+		Direction:      crossevent.DIRECTION_SEND,
+		SenderDomain:   bs.localDomain(stub),
+		ReceiverDomain: sdpMsg.TargetDomain,
+		Sender:         amMsg.GetAuthor(),
+		Receiver:       sdpMsg.TargetIdentity,
+		Seq:            sdpMsg.Sequence,
+		PayloadHash:    payloadHash[:],
+		Key:            msg.Key,
+		Fee:            fee,
+		TraceId:        crossevent.TraceID(payloadHash[:]),
+		TraceParent:    sdpMsg.TraceParent(),
+	}, nil
+}
+
+// 构造接收事件，不经过AM报文解析的消息以sha256(消息内容)作为载荷摘要
+func (bs *CrossChain) recvEvent(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage) (*crossevent.CrossChainEventV2, error) {
+	payloadHash, err := hex.DecodeString(msg.PacketHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid packet hash %s: %v", msg.PacketHash, err)
+	}
+	if len(payloadHash) == 0 {
+		h := sha256.Sum256(msg.Content)
+		payloadHash = h[:]
+	}
+	return &crossevent.CrossChainEventV2{
+		Direction:      crossevent.DIRECTION_RECV,
+		SenderDomain:   msg.From,
+		ReceiverDomain: bs.localDomain(stub),
+		Sender:         msg.Identity,
+		Receiver:       msg.Receiver,
+		Seq:            msg.Seq,
+		PayloadHash:    payloadHash,
+		TraceId:        crossevent.TraceID(payloadHash),
+		TraceParent:    recvTraceParent(stub, msg),
+	}, nil
+}
+
+// 接收事件的traceparent: 中继在transient中给出的span延续了报文中的链路(或报文没有链路)时使用中继的span，
+// 否则使用报文扩展字段中的traceparent，都没有时为空
+func recvTraceParent(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage) string {
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return msg.TraceParent
+	}
+	relay, err := tracecontext.Parse(string(transient[tracecontext.TRANSIENT_KEY]))
+	if err != nil {
+		return msg.TraceParent
+	}
+	if msg.TraceParent == "" {
+		return relay.String()
+	}
+	if origin, err := tracecontext.Parse(msg.TraceParent); err == nil && origin.TraceId == relay.TraceId {
+		return relay.String()
+	}
+	return msg.TraceParent
+}
+
+// 事件版本为v2时发出接收事件，本交易回复了回执时改为回执事件
+// batchResult为批量接收的结果，非空时随事件发出
+func (bs *CrossChain) emitRecvEventV2(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage, batchResult []byte) error {
+	version, err := getEventVersion(stub)
+	if err != nil || version != EVENT_VERSION_2 {
+		return err
+	}
+	name := crossevent.EVENT_RECEIVED_MESSAGE
+	events := make([]*crossevent.CrossChainEventV2, 0, len(msgs))
+	var acks []*crossevent.CrossChainEventV2
+	for _, msg := range msgs {
+		event, err := bs.recvEvent(stub, msg)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		if !needAck(msg) {
+			continue
+		}
+		// 进入争议窗口的原子请求在放行的交易中回复回执
+		var status AckStatus
+		if has, err := getJSONState(stub, K_ACK_STATUS_PREFIX+msg.MessageId, &status); err != nil {
+			return err
+		} else if !has || status.TxId != stub.GetTxID() {
+			continue
+		}
+		ack, err := bs.ackEvent(stub, status.AckKey)
+		if err != nil {
+			return err
+		}
+		acks = append(acks, ack)
+	}
+	if len(acks) > 0 {
+		name = crossevent.EVENT_ACKED_MESSAGE
+		events = append(events, acks...)
+	}
+	return setEventV2(stub, name, events, batchResult)
+}
+
+// 从回执报文构造回执事件
+func (bs *CrossChain) ackEvent(stub shim.ChaincodeStubInterface, key string) (*crossevent.CrossChainEventV2, error) {
+	pkg, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	event, err := bs.sendEvent(stub, oraclelogic.OutboundMessage{Key: key, Package: pkg})
+	if err != nil {
+		return nil, err
+	}
+	// 回执不收费
+	event.Direction, event.Fee = crossevent.DIRECTION_ACK, 0
+	return event, nil
+}
+
+func setEventV2(stub shim.ChaincodeStubInterface, name string, events []*crossevent.CrossChainEventV2, batchResult []byte) error {
+	bz, err := crossevent.EncodeBatch(events, batchResult)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(name, bz)
+}
+
+// 设置事件版本
+// args[0] 1或2
+func (bs *CrossChain) setEventVersion(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || (version != EVENT_VERSION_1 && version != EVENT_VERSION_2) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid event version: %s", args[0]))
+	}
+	if err := stub.PutState(K_EVENT_VERSION, []byte(strconv.Itoa(version))); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询事件版本
+func (bs *CrossChain) queryEventVersion(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	version, err := getEventVersion(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get event version"))
+	}
+	return shim.Success([]byte(strconv.Itoa(version)))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crossevent"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestEventV2(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryEventVersion"); string(res.Payload) != "1" {
+		t.Fatalf("unexpected default event version: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 发送事件
+	receiver := sha256.Sum256([]byte("dest"))
+	if res := InvokeWithStrings(t, stub, sp, "batchSendUnorderedMessage", "dest.com", hex.EncodeToString(receiver[:]), "m1", "m2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := <-stub.ChaincodeEventsChannel
+	if event.EventName != crossevent.EVENT_SENT_MESSAGE {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	events, err := crossevent.Decode(event.Payload)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("unexpected events: %v %v", events, err)
+	}
+	for _, e := range events {
+		hash := sha256.Sum256(stub.State[e.Key])
+		if e.Direction != crossevent.DIRECTION_SEND || e.SenderDomain != "fabric.test" || e.ReceiverDomain != "dest.com" ||
+			e.Receiver != receiver || !e.Unordered() || hex.EncodeToString(e.PayloadHash) != hex.EncodeToString(hash[:]) {
+			t.Fatalf("unexpected send event: %+v", e)
+		}
+	}
+
+	// 接收事件，批量接收不再发出v1的批量结果事件
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "v2 event")
+	raw, _ := json.Marshal([]string{mockRecvRawData(t, "src.com", pkgs[0])})
+	if res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event = <-stub.ChaincodeEventsChannel
+	if event.EventName != crossevent.EVENT_RECEIVED_MESSAGE || len(stub.ChaincodeEventsChannel) != 0 {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	events, err = crossevent.Decode(event.Payload)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("unexpected events: %v %v", events, err)
+	}
+	pkt, _ := hex.DecodeString(pkgs[0])
+	if e := events[0]; e.Direction != crossevent.DIRECTION_RECV || e.SenderDomain != "src.com" || e.ReceiverDomain != "fabric.test" ||
+		e.Receiver != sha256.Sum256([]byte("bizcc")) || e.Seq != 0 || hex.EncodeToString(e.PayloadHash) != sha256Hex(pkt) || e.Key != "" {
+		t.Fatalf("unexpected recv event: %+v", e)
+	}
+
+	// 回复了原子请求回执的接收交易
+	sender := sha256.Sum256([]byte("sender"))
+	msg := testSDPMessageV2("atomic", 0)
+	msg.AtomicFlag = oraclelogic.SDP_ATOMIC_REQUEST
+	sdp, _ := msg.Encode()
+	am := oraclelogic.TestBuildAuthMessage(sender, sdp)
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event = <-stub.ChaincodeEventsChannel
+	if event.EventName != crossevent.EVENT_ACKED_MESSAGE {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	events, err = crossevent.Decode(event.Payload)
+	if err != nil || len(events) != 2 || events[0].Direction != crossevent.DIRECTION_RECV || events[0].Sender != sender {
+		t.Fatalf("unexpected events: %v %v", events, err)
+	}
+	var status AckStatus
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryAckStatus", hex.EncodeToString(msg.MessageId[:])).Payload, &status)
+	if e := events[1]; e.Direction != crossevent.DIRECTION_ACK || e.Key != status.AckKey || e.ReceiverDomain != "src.com" ||
+		e.Receiver != sender || e.SenderDomain != "fabric.test" {
+		t.Fatalf("unexpected ack event: %+v", e)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "3"); res.Status == shim.OK {
+		t.Fatal("unknown event version should be rejected")
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "1"); res.Status == shim.OK {
+		t.Fatal("non-admin should be rejected")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+	"sync"
+)
+
+// 接收消息的执行限制
+// 管理员限制回调业务链码的消息内容大小，以及一笔交易中跨链链码调用(InvokeChaincode)业务链码的次数，
+// 防止超大消息或一个报文中的大量消息(含分片拼接、争议窗口和乐观投递到期的消息)耗尽背书节点的资源。
+// 调用次数只统计跨链链码发起的回调：业务链码再调用的其他链码对跨链链码不可见，
+// 同一笔交易中再次调用跨链链码会被peer拒绝，嵌套的调用最终都计入发起它的那次回调。
+//
+// 超出限制时不回调业务链码，错误码E1013，中继重新提交的结果不变：
+//   - 原子请求回复ACK_ERROR回执，回执不带超限的消息内容，交易不回滚
+//   - 回执记为请求失败，不回调请求方
+//   - 其他消息整笔交易失败
+const (
+	// crosschain_exec_limit -> ExecLimit
+	K_EXEC_LIMIT = CROSSCHAIN_PREFIX + "exec_limit"
+)
+
+type ExecLimit struct {
+	// 回调业务链码的消息内容最大字节数，0表示不限制
+	MaxPayloadSize int `json:"maxPayloadSize"`
+	// 一笔交易中回调业务链码的最多次数，0表示不限制
+	MaxInvokeHops int   `json:"maxInvokeHops"`
+	UpdatedAt     int64 `json:"updatedAt"`
+}
+
+// 每笔交易已经回调的次数，交易结束时清除
+var invokeHops = struct {
+	sync.Mutex
+	count map[string]int
+}{count: map[string]int{}}
+
+func invokeHopKey(stub shim.ChaincodeStubInterface) string {
+	return stub.GetChannelID() + "/" + stub.GetTxID()
+}
+
+func clearInvokeHops(stub shim.ChaincodeStubInterface) {
+	invokeHops.Lock()
+	defer invokeHops.Unlock()
+	delete(invokeHops.count, invokeHopKey(stub))
+}
+
+func (bs *CrossChain) getExecLimit(stub shim.ChaincodeStubInterface) (*ExecLimit, error) {
+	var limit ExecLimit
+	has, err := getJSONState(stub, K_EXEC_LIMIT, &limit)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// 回调业务链码前检查执行限制，invoke为true时计入一次回调
+func (bs *CrossChain) checkExecLimit(stub shim.ChaincodeStubInterface, payload []byte, invoke bool) error {
+	limit, err := bs.getExecLimit(stub)
+	if err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to get exec limit")
+	}
+	if limit == nil {
+		return nil
+	}
+	if limit.MaxPayloadSize > 0 && len(payload) > limit.MaxPayloadSize {
+		return crosserr.New(crosserr.CodeExecLimit, "payload size %d exceeds %d", len(payload), limit.MaxPayloadSize)
+	}
+	if !invoke || limit.MaxInvokeHops <= 0 {
+		return nil
+	}
+	invokeHops.Lock()
+	defer invokeHops.Unlock()
+	key := invokeHopKey(stub)
+	if invokeHops.count[key] >= limit.MaxInvokeHops {
+		return crosserr.New(crosserr.CodeExecLimit, "invoke chaincode more than %d times in tx %s", limit.MaxInvokeHops, stub.GetTxID())
+	}
+	invokeHops.count[key]++
+	return nil
+}
+
+// 设置执行限制，两项都为0时取消限制
+// args[0] 消息内容最大字节数，0表示不限制
+// args[1] 一笔交易中回调业务链码的最多次数，0表示不限制
+func (bs *CrossChain) setExecLimit(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	size, err := strconv.Atoi(args[0])
+	if err != nil || size < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max payload size: %s", args[0]))
+	}
+	hops, err := strconv.Atoi(args[1])
+	if err != nil || hops < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max invoke hops: %s", args[1]))
+	}
+	if size == 0 && hops == 0 {
+		if err := stub.DelState(K_EXEC_LIMIT); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	limit := &ExecLimit{MaxPayloadSize: size, MaxInvokeHops: hops, UpdatedAt: now}
+	if err := putJSONState(stub, K_EXEC_LIMIT, limit); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put exec limit"))
+	}
+	bz, _ := json.Marshal(limit)
+	return shim.Success(bz)
+}
+
+// 查询执行限制
+func (bs *CrossChain) queryExecLimit(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	limit, err := bs.getExecLimit(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get exec limit"))
+	}
+	if limit == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "exec limit not found"))
+	}
+	bz, _ := json.Marshal(limit)
+	return shim.Success(bz)
+}
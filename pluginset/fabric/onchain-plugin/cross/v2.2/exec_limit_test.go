@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestExecLimit(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "setExecLimit", "10", "-1"); res.Status == shim.OK {
+		t.Fatal("max invoke hops should not be negative")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setExecLimit", "10", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 在一笔交易中接收多条消息
+	recv := func(contents ...string) error {
+		var msgs oraclelogic.RecvAuthMessages
+		for _, content := range contents {
+			msgs.Message = append(msgs.Message, oraclelogic.RecvAuthMessage{
+				From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte(content),
+				Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED,
+			})
+		}
+		raw, _ := json.Marshal(msgs)
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		defer clearInvokeHops(stub)
+		if res := NewCrossChain().callbackBizChaincode(wrapStub(stub), raw); res.Status != shim.OK {
+			return crosserr.FromMessage(crosserr.CodeInternal, res.Message)
+		}
+		return nil
+	}
+
+	if err := recv("hello 1", "hello 2"); err != nil {
+		t.Fatal(err)
+	}
+	// 回调次数在交易结束后重新计数
+	if err := recv("hello 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := recv("hello 4", "hello 5", "hello 6"); crosserr.CodeOf(err) != crosserr.CodeExecLimit {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := recv("hello world!"); crosserr.CodeOf(err) != crosserr.CodeExecLimit {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 超限的原子请求回复ACK_ERROR，交易成功
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	msg := testSDPMessageV2("atomic request too large", 0)
+	msg.TargetIdentity, msg.AtomicFlag = sha256.Sum256([]byte("bizcc")), oraclelogic.SDP_ATOMIC_REQUEST
+	sdp, _ := msg.Encode()
+	am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var status AckStatus
+	if res := InvokeWithStrings(t, stub, sp, "queryAckStatus", hex.EncodeToString(msg.MessageId[:])); json.Unmarshal(res.Payload, &status) != nil {
+		t.Fatal(res.Message)
+	}
+	if status.AtomicFlag != oraclelogic.SDP_ATOMIC_ACK_ERROR || crosserr.CodeOf(crosserr.FromMessage(crosserr.CodeInternal, status.ErrorMsg)) != crosserr.CodeExecLimit {
+		t.Fatalf("unexpected ack status: %+v", status)
+	}
+	_, p2p, _ := oraclelogic.TestRecvAuthMessage(stub.State[status.AckKey])
+	if ack, err := oraclelogic.DecodeSDPMessage(p2p); err != nil || len(ack.Payload) != 0 {
+		t.Fatalf("ack should not carry the payload: %v %+v", err, ack)
+	}
+
+	var limit ExecLimit
+	if res := InvokeWithStrings(t, stub, sp, "queryExecLimit"); json.Unmarshal(res.Payload, &limit) != nil {
+		t.Fatal(res.Message)
+	}
+	if limit.MaxPayloadSize != 10 || limit.MaxInvokeHops != 2 {
+		t.Fatalf("unexpected limit: %+v", limit)
+	}
+
+	// 取消限制
+	if res := InvokeWithStrings(t, stub, sp, "setExecLimit", "0", "0"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var contents []string
+	for i := 0; i < 5; i++ {
+		contents = append(contents, fmt.Sprintf("message %d without limit", i))
+	}
+	if err := recv(contents...); err != nil {
+		t.Fatal(err)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryExecLimit"); res.Status == shim.OK {
+		t.Fatal("exec limit should be removed")
+	}
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"am"
+	"chaincodepb"
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"sort"
+	"strconv"
+)
+
+// 有序消息的有效期
+// 发送有序消息时可以指定有效期(秒)，不指定时按管理员设置的默认值，0表示不过期。链码内无法获取区块高度，
+// 有效期按交易时间戳计算。过期仍未被中继转发的消息可以取消：
+//   - 发送方业务链码调用cancelMessage取消自己的消息，管理员调用cleanupExpired按目的域名批量取消
+//   - 取消时删除消息记录、发送积压索引和可靠中继队列中的记录，退还已扣的手续费(见fee.go)
+//   - 消息是所在发送通道的最后一条时回退通道序号，下一条消息重新使用该序号；否则需要先取消之后的消息，
+//     否则目的链AM层的序号检查会卡住整个通道
+//
+// 可靠中继队列中已被认领且未超时的消息不能取消，中继也不能认领已过期的消息，ackRelay确认后有效期记录删除。
+// 没有开启可靠中继时，中继应当在提交前通过queryPending确认消息没有被取消，并监听取消事件。
+const (
+	// crosschain_message_expiry -> ExpiryConfig
+	K_MESSAGE_EXPIRY = CROSSCHAIN_PREFIX + "message_expiry"
+
+	// 复合key: crosschain_expiry ${destDomain} ${seq} ${msgKey} -> ExpiringMessage
+	K_EXPIRY_OBJECT_TYPE = CROSSCHAIN_PREFIX + "expiry"
+
+	// 取消消息时发出的事件，payload为取消的ExpiringMessage列表
+	K_CANCELLED_EVENT = "MESSAGE_CANCELLED"
+)
+
+type ExpiryConfig struct {
+	// 默认有效期(秒)，0表示不过期
+	TTL       int64 `json:"ttl"`
+	UpdatedAt int64 `json:"updatedAt"`
+}
+
+type ExpiringMessage struct {
+	Key        string `json:"key"`
+	DestDomain string `json:"destDomain"`
+	Seq        uint32 `json:"seq"`
+	// 发送消息的业务链码及其身份sha256(链码名)(hex)
+	Sender   string `json:"sender"`
+	SenderID string `json:"senderID"`
+	Receiver string `json:"receiver"`
+	// 扣费的账户和手续费，不收费时为空和0
+	Client    string `json:"client,omitempty"`
+	Fee       uint64 `json:"fee,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type PendingMessage struct {
+	ExpiringMessage
+	Expired bool `json:"expired"`
+}
+
+type PendingMessagePage struct {
+	Messages []PendingMessage `json:"messages"`
+	Bookmark string           `json:"bookmark"`
+}
+
+type CleanupResult struct {
+	Cancelled []*ExpiringMessage `json:"cancelled"`
+	// 过期但不能取消的消息key及原因
+	Skipped map[string]string `json:"skipped"`
+}
+
+// 消息的有效期，arg为发送时指定的有效期，为空时按默认值
+func (bs *CrossChain) messageTTL(stub shim.ChaincodeStubInterface, arg string) (int64, error) {
+	if arg != "" {
+		ttl, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || ttl < 0 {
+			return 0, fmt.Errorf("invalid ttl: %s", arg)
+		}
+		return ttl, nil
+	}
+	var config ExpiryConfig
+	if _, err := getJSONState(stub, K_MESSAGE_EXPIRY, &config); err != nil {
+		return 0, err
+	}
+	return config.TTL, nil
+}
+
+func expiryKey(stub shim.ChaincodeStubInterface, destDomain string, seq uint32, msgKey string) (string, error) {
+	return stub.CreateCompositeKey(K_EXPIRY_OBJECT_TYPE, []string{destDomain, fmt.Sprintf("%010d", seq), msgKey})
+}
+
+// 登记有序消息的有效期，payload为sendMessage返回的消息记录
+func (bs *CrossChain) trackExpiry(stub shim.ChaincodeStubInterface, payload []byte, ttl int64, client string, fee uint64) error {
+	var msg oraclelogic.OutboundMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("failed to parse outbound message: %v", err)
+	}
+	amMsg, err := am.Decode(msg.Package)
+	if err != nil {
+		return fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+	}
+	sdpMsg, err := oraclelogic.DecodeSDPMessage(amMsg.GetPayload())
+	if err != nil {
+		return fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+	}
+	sender, err := getProposalChaincode(stub)
+	if err != nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	author := amMsg.GetAuthor()
+	record := &ExpiringMessage{
+		Key:        msg.Key,
+		DestDomain: sdpMsg.TargetDomain,
+		Seq:        sdpMsg.Sequence,
+		Sender:     sender,
+		SenderID:   hex.EncodeToString(author[:]),
+		Receiver:   hex.EncodeToString(sdpMsg.TargetIdentity[:]),
+		Client:     client,
+		Fee:        fee,
+		CreatedAt:  now,
+		ExpiresAt:  now + ttl,
+	}
+	key, err := expiryKey(stub, record.DestDomain, record.Seq, record.Key)
+	if err != nil {
+		return err
+	}
+	return putJSONState(stub, key, record)
+}
+
+// 读取消息的有效期记录，没有登记时返回nil
+func (bs *CrossChain) getExpiry(stub shim.ChaincodeStubInterface, msgKey string) (*ExpiringMessage, string, error) {
+	ammsg, err := stub.GetState(msgKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(ammsg) == 0 {
+		return nil, "", crosserr.New(crosserr.CodeNotFound, "message %s not found", msgKey)
+	}
+	destDomain, seq, err := oraclelogic.ParseOutboundPackage(ammsg)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := expiryKey(stub, destDomain, seq, msgKey)
+	if err != nil {
+		return nil, "", err
+	}
+	var record ExpiringMessage
+	has, err := getJSONState(stub, key, &record)
+	if err != nil || !has {
+		return nil, key, err
+	}
+	return &record, key, nil
+}
+
+// 中继确认消息已上链，删除有效期记录
+func (bs *CrossChain) settleExpiry(stub shim.ChaincodeStubInterface, msgKey string) error {
+	record, key, err := bs.getExpiry(stub, msgKey)
+	if err != nil || record == nil {
+		return err
+	}
+	return stub.DelState(key)
+}
+
+// 消息已过期时返回错误，用于拒绝中继认领
+func (bs *CrossChain) checkNotExpired(stub shim.ChaincodeStubInterface, msgKey string, now int64) error {
+	record, _, err := bs.getExpiry(stub, msgKey)
+	if err != nil || record == nil {
+		return err
+	}
+	if now >= record.ExpiresAt {
+		return fmt.Errorf("message %s expired at %d", msgKey, record.ExpiresAt)
+	}
+	return nil
+}
+
+// 取消过期的消息
+// 返回值: 不能取消的原因(可以稍后重试)，账本错误
+func (bs *CrossChain) cancelExpired(stub shim.ChaincodeStubInterface, record *ExpiringMessage, key string, now int64) (string, error) {
+	if now < record.ExpiresAt {
+		return fmt.Sprintf("message %s expires at %d", record.Key, record.ExpiresAt), nil
+	}
+	entry, relayKey, err := bs.getRelayEntry(stub, record.Key)
+	if err != nil {
+		return "", err
+	}
+	if entry != nil && entry.Attempts > 0 {
+		config, err := bs.getRelayConfig(stub)
+		if err != nil {
+			return "", err
+		}
+		if now < entry.ConfirmedAt+config.Timeout {
+			return fmt.Sprintf("message %s is claimed by %s until %d", record.Key, entry.Relayer, entry.ConfirmedAt+config.Timeout), nil
+		}
+	}
+
+	// 只有通道的最后一条消息可以回退序号
+	var ids [2][32]byte
+	for i, id := range []string{record.SenderID, record.Receiver} {
+		raw, err := hex.DecodeString(id)
+		if err != nil || len(raw) != 32 {
+			return "", fmt.Errorf("identity(%s) format error", id)
+		}
+		copy(ids[i][:], raw)
+	}
+	seqKey := bs.Os.SendSeqKey(record.DestDomain, ids[0], ids[1])
+	raw, err := bs.Os.GetState(stub, false, seqKey)
+	if err != nil {
+		return "", err
+	}
+	var nounce chaincodepb.MsgNounce
+	if err := proto.Unmarshal(raw, &nounce); err != nil {
+		return "", fmt.Errorf("failed to decode seq %s: %v", seqKey, err)
+	}
+	if nounce.Seqno != record.Seq+1 {
+		return fmt.Sprintf("message %s is followed by seq %d, cancel later messages first", record.Key, nounce.Seqno-1), nil
+	}
+	nounce.Seqno = record.Seq
+	raw, err = proto.Marshal(&nounce)
+	if err != nil {
+		return "", err
+	}
+	if err := bs.Os.PutState(stub, false, seqKey, raw); err != nil {
+		return "", err
+	}
+
+	indexKey, err := stub.CreateCompositeKey(K_OUTBOUND_OBJECT_TYPE, []string{record.DestDomain, fmt.Sprintf("%010d", record.Seq), record.Key})
+	if err != nil {
+		return "", err
+	}
+	for _, k := range []string{record.Key, indexKey, relayKey, key} {
+		if k == "" {
+			continue
+		}
+		if err := stub.DelState(k); err != nil {
+			return "", err
+		}
+	}
+	if err := bs.updateMessageRecord(stub, outboundRecordKey(record.Key), MESSAGE_STATUS_CANCELLED); err != nil {
+		return "", err
+	}
+	return "", bs.refundFee(stub, record.Client, record.Fee)
+}
+
+func emitCancelledEvent(stub shim.ChaincodeStubInterface, cancelled []*ExpiringMessage) error {
+	raw, err := json.Marshal(cancelled)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(K_CANCELLED_EVENT, raw)
+}
+
+// 发送方业务链码取消自己的过期消息
+// args[0] 消息key
+func (bs *CrossChain) cancelMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	record, key, err := bs.getExpiry(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get expiry"))
+	}
+	if record == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "message %s has no expiry", args[0]))
+	}
+	sender, err := getProposalChaincode(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get proposal chaincode"))
+	}
+	if sender != record.Sender {
+		return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "message %s is sent by %s, not %s", args[0], record.Sender, sender))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	reason, err := bs.cancelExpired(stub, record, key, now)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to cancel message"))
+	}
+	if reason != "" {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "%s", reason))
+	}
+	if err := emitCancelledEvent(stub, []*ExpiringMessage{record}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+	}
+	raw, _ := json.Marshal(record)
+	return shim.Success(raw)
+}
+
+// 批量取消发往目的域名的过期消息，同一通道从序号大的开始取消
+// args[0] 目的域名
+// args[1] 最多取消的条数(可选)
+func (bs *CrossChain) cleanupExpired(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 && len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	limit := 0
+	if len(args) == 2 {
+		var err error
+		if limit, err = strconv.Atoi(args[1]); err != nil || limit <= 0 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid limit: %s", args[1]))
+		}
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	type expired struct {
+		key    string
+		record *ExpiringMessage
+	}
+	var candidates []expired
+	iter, err := stub.GetStateByPartialCompositeKey(K_EXPIRY_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to iterate state"))
+		}
+		var record ExpiringMessage
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read expiry record"))
+		}
+		if now >= record.ExpiresAt {
+			candidates = append(candidates, expired{key: kv.Key, record: &record})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].record.Seq > candidates[j].record.Seq
+	})
+
+	result := CleanupResult{Cancelled: []*ExpiringMessage{}, Skipped: map[string]string{}}
+	for _, c := range candidates {
+		if limit > 0 && len(result.Cancelled) >= limit {
+			break
+		}
+		reason, err := bs.cancelExpired(stub, c.record, c.key, now)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to cancel message"))
+		}
+		if reason != "" {
+			result.Skipped[c.record.Key] = reason
+			continue
+		}
+		result.Cancelled = append(result.Cancelled, c.record)
+	}
+	if len(result.Cancelled) > 0 {
+		if err := emitCancelledEvent(stub, result.Cancelled); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+		}
+	}
+	raw, _ := json.Marshal(&result)
+	return shim.Success(raw)
+}
+
+// 按页查询发往目的域名、设置了有效期且尚未确认或取消的消息
+// args[0] 目的域名
+// args[1] 每页条数(可选)
+// args[2] 书签(可选)
+func (bs *CrossChain) queryPending(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	pageSize, bookmark, err := parsePageArgs(args[1:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	page := PendingMessagePage{Messages: []PendingMessage{}}
+	page.Bookmark, err = scanCompositeKeyPage(stub, K_EXPIRY_OBJECT_TYPE, []string{args[0]}, pageSize, bookmark, func(key string, value []byte) error {
+		var record ExpiringMessage
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		page.Messages = append(page.Messages, PendingMessage{ExpiringMessage: record, Expired: now >= record.ExpiresAt})
+		return nil
+	})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to query pending messages"))
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
+
+// 设置有序消息的默认有效期
+// args[0] 有效期(秒)，0表示不过期
+func (bs *CrossChain) setMessageExpiry(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	ttl, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || ttl < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid ttl: %s", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if err := putJSONState(stub, K_MESSAGE_EXPIRY, &ExpiryConfig{TTL: ttl, UpdatedAt: now}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+func (bs *CrossChain) queryMessageExpiry(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var config ExpiryConfig
+	if _, err := getJSONState(stub, K_MESSAGE_EXPIRY, &config); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	raw, _ := json.Marshal(&config)
+	return shim.Success(raw)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 带签名提案的stub，用于以指定时间戳模拟业务链码调用
+type proposalStub struct {
+	*shimtest.MockStub
+	sp *pb.SignedProposal
+}
+
+func (s *proposalStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return s.sp, nil
+}
+
+func TestMessageExpiry(t *testing.T) {
+	stub, sp, _, bizsp := NewBizCrossChainStubs(t)
+	client := testCertHash(TEST_RELAYER_CERT)
+	receiver := hex.EncodeToString(make([]byte, 32))
+	for _, args := range [][]string{
+		{"setFeeSchedule", "dest.com", "10"},
+		{"depositFee", client, "100"},
+		{"setMessageExpiry", "60"},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, args...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+
+	send := func(args ...string) string {
+		stub.Creator = MockCreator(TEST_RELAYER_CERT)
+		defer func() { stub.Creator = MockCreator(TEST_ADMIN_CERT) }()
+		res := InvokeWithStrings(t, stub, bizsp, append([]string{"sendMessage", "dest.com", receiver}, args...)...)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		<-stub.ChaincodeEventsChannel
+		var msg oraclelogic.OutboundMessage
+		if err := json.Unmarshal(res.Payload, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg.Key
+	}
+	later := time.Now().Unix() + 3600
+	call := func(proposal *pb.SignedProposal, fn string, args ...string) pb.Response {
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: later}
+		return NewCrossChain().dispatch(wrapStub(&proposalStub{MockStub: stub, sp: proposal}), fn, args)
+	}
+	pending := func() []PendingMessage {
+		var page PendingMessagePage
+		res := CallPagedWithTimestamp(stub, later, func(s shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().queryPending(s, []string{"dest.com"})
+		})
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			t.Fatal(res.Message)
+		}
+		return page.Messages
+	}
+	balance := func() uint64 {
+		var account FeeAccount
+		_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryFeeAccount", client).Payload, &account)
+		return account.Balance
+	}
+
+	// 默认有效期和指定有效期，0表示不过期
+	m0 := send("m0")
+	m1 := send("m1", "n1", "7200")
+	send("m2", "n2", "0")
+	if res := InvokeWithStrings(t, stub, bizsp, "sendUnorderedMessage", "dest.com", receiver, "u", "n", "60"); res.Status == shim.OK {
+		t.Fatal("unordered message should not expire")
+	}
+	messages := pending()
+	if len(messages) != 2 || messages[0].Key != m0 || messages[0].Seq != 0 || !messages[0].Expired ||
+		messages[1].Key != m1 || messages[1].Seq != 1 || messages[1].Expired || messages[0].Fee != 10 || messages[0].Sender != "bizcc" {
+		t.Fatalf("unexpected pending messages: %+v", messages)
+	}
+	if balance() != 70 {
+		t.Fatalf("unexpected balance: %d", balance())
+	}
+
+	// 未过期、非发送方、非通道最后一条消息不能取消
+	if res := call(bizsp, "cancelMessage", m1); res.Status == shim.OK {
+		t.Fatal("unexpired message should not be cancelled")
+	}
+	if res := call(sp, "cancelMessage", m0); res.Status == shim.OK {
+		t.Fatal("only sender can cancel message")
+	}
+	if res := call(bizsp, "cancelMessage", m0); res.Status == shim.OK {
+		t.Fatal("message followed by later messages should not be cancelled")
+	}
+
+	// 中继不能认领过期的消息
+	if res := InvokeWithStrings(t, stub, sp, "setRelayConfig", "true", "60"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	m3 := send("m3", "n3", "1")
+	res := CallWithTimestamp(stub, later, func(s shim.ChaincodeStubInterface) pb.Response {
+		return NewCrossChain().confirmRelay(s, []string{m3})
+	})
+	if res.Status == shim.OK || !strings.Contains(res.Message, "expired") {
+		t.Fatalf("expired message should not be claimed: %s", res.Message)
+	}
+
+	// 管理员按序号从大到小取消，序号回退、手续费退还
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := call(sp, "cleanupExpired", "dest.com"); res.Status == shim.OK {
+		t.Fatal("non-admin should not cleanup")
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	res = call(sp, "cleanupExpired", "dest.com")
+	var result CleanupResult
+	if err := json.Unmarshal(res.Payload, &result); err != nil {
+		t.Fatal(res.Message)
+	}
+	<-stub.ChaincodeEventsChannel
+	if len(result.Cancelled) != 1 || result.Cancelled[0].Key != m3 || len(result.Skipped) != 1 || result.Skipped[m0] == "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, ok := stub.State[m3]; ok || balance() != 70 {
+		t.Fatalf("message should be removed and fee refunded, balance %d", balance())
+	}
+	if m4 := send("m4", "n4", "60"); len(pending()) != 3 || pending()[2].Key != m4 || pending()[2].Seq != 3 {
+		t.Fatalf("seq should be released: %+v", pending())
+	}
+}
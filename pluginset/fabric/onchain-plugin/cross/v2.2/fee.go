@@ -0,0 +1,306 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 跨链手续费
+// 管理员按目的域名设置每条消息的手续费，发送消息时从交易提交者的账户扣除，计入手续费池；
+// 未设置手续费的目的域名不收费。账户按提交者证书sha256(hex)记账，余额由管理员按链下的
+// 结算充值，跨链桥运营方通过管理员从手续费池提取。用于按部门结算内部的跨链流量。
+//
+// 只有业务链码发送的消息收费，原子请求的回执(见ack.go)不收费。扣费只写提交者自己的账户，
+// 手续费池的收入在查询和提取时汇总各账户，不同提交者的发送交易之间没有读写冲突。
+// 设置了有效期的有序消息过期取消时退还手续费(见expiry.go)。
+const (
+	// ${K_FEE_SCHEDULE_OBJECT_TYPE}~${domain} -> FeeSchedule
+	K_FEE_SCHEDULE_OBJECT_TYPE = CROSSCHAIN_PREFIX + "fee_schedule"
+
+	// ${K_FEE_ACCOUNT_OBJECT_TYPE}~${client} -> FeeAccount
+	K_FEE_ACCOUNT_OBJECT_TYPE = CROSSCHAIN_PREFIX + "fee_account"
+
+	// crosschain_fee_pool -> FeePool，只保存提取记录
+	K_FEE_POOL = CROSSCHAIN_PREFIX + "fee_pool"
+)
+
+type FeeSchedule struct {
+	Domain    string `json:"domain"`
+	Fee       uint64 `json:"fee"` // 每条消息
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type FeeAccount struct {
+	Client    string `json:"client"` // 提交者证书sha256(hex)
+	Balance   uint64 `json:"balance"`
+	Deposited uint64 `json:"deposited"`
+	Charged   uint64 `json:"charged"`
+	// 取消的消息退还的手续费，已从Charged中扣除
+	Refunded  uint64 `json:"refunded,omitempty"`
+	Messages  uint64 `json:"messages"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type FeeWithdrawal struct {
+	Amount    uint64 `json:"amount"`
+	Reference string `json:"reference"` // 链下结算凭证
+	TxId      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type FeePool struct {
+	Collected   uint64          `json:"collected"` // 各账户已扣费用之和
+	Withdrawn   uint64          `json:"withdrawn"`
+	Withdrawals []FeeWithdrawal `json:"withdrawals"`
+}
+
+// 提取后又有退费时可能为0
+func (p *FeePool) available() uint64 {
+	if p.Collected < p.Withdrawn {
+		return 0
+	}
+	return p.Collected - p.Withdrawn
+}
+
+// 未设置时返回0
+func (bs *CrossChain) getFee(stub shim.ChaincodeStubInterface, domain string) (uint64, error) {
+	key, err := stub.CreateCompositeKey(K_FEE_SCHEDULE_OBJECT_TYPE, []string{domain})
+	if err != nil {
+		return 0, err
+	}
+	var schedule FeeSchedule
+	if _, err := getJSONState(stub, key, &schedule); err != nil {
+		return 0, err
+	}
+	return schedule.Fee, nil
+}
+
+func (bs *CrossChain) getFeeAccount(stub shim.ChaincodeStubInterface, client string) (*FeeAccount, string, error) {
+	key, err := stub.CreateCompositeKey(K_FEE_ACCOUNT_OBJECT_TYPE, []string{client})
+	if err != nil {
+		return nil, "", err
+	}
+	account := &FeeAccount{Client: client}
+	if _, err := getJSONState(stub, key, account); err != nil {
+		return nil, "", err
+	}
+	return account, key, nil
+}
+
+func (bs *CrossChain) getFeePool(stub shim.ChaincodeStubInterface) (*FeePool, error) {
+	pool := &FeePool{Withdrawals: []FeeWithdrawal{}}
+	if _, err := getJSONState(stub, K_FEE_POOL, pool); err != nil {
+		return nil, err
+	}
+	iter, err := stub.GetStateByPartialCompositeKey(K_FEE_ACCOUNT_OBJECT_TYPE, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	pool.Collected = 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var account FeeAccount
+		if err := json.Unmarshal(kv.Value, &account); err != nil {
+			return nil, err
+		}
+		pool.Collected += account.Charged
+	}
+	return pool, nil
+}
+
+// 按目的域名从交易提交者的账户扣除一条消息的手续费
+// 返回值: 扣费的账户, 手续费，不收费时为空和0
+func (bs *CrossChain) chargeFee(stub shim.ChaincodeStubInterface, destDomain string) (string, uint64, error) {
+	fee, err := bs.getFee(stub, destDomain)
+	if err != nil || fee == 0 {
+		return "", 0, err
+	}
+	_, client, err := getCreatorIdentity(stub)
+	if err != nil {
+		return "", 0, err
+	}
+	account, key, err := bs.getFeeAccount(stub, client)
+	if err != nil {
+		return "", 0, err
+	}
+	if account.Balance < fee {
+		return "", 0, crosserr.New(crosserr.CodeInsufficient, "balance %d of %s is less than fee %d to %s", account.Balance, client, fee, destDomain)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return "", 0, err
+	}
+	account.Balance -= fee
+	account.Charged += fee
+	account.Messages++
+	account.UpdatedAt = now
+	return client, fee, putJSONState(stub, key, account)
+}
+
+// 退还取消的消息已扣的手续费
+func (bs *CrossChain) refundFee(stub shim.ChaincodeStubInterface, client string, fee uint64) error {
+	if fee == 0 {
+		return nil
+	}
+	account, key, err := bs.getFeeAccount(stub, client)
+	if err != nil {
+		return err
+	}
+	if account.Charged < fee {
+		return fmt.Errorf("refund %d exceeds charged fee %d of %s", fee, account.Charged, client)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	account.Balance += fee
+	account.Charged -= fee
+	account.Refunded += fee
+	account.UpdatedAt = now
+	return putJSONState(stub, key, account)
+}
+
+// 设置目的域名的手续费
+// args[0] 目的域名
+// args[1] 每条消息的手续费，0表示不收费
+func (bs *CrossChain) setFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	fee, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "fee(%s) format error", args[1]))
+	}
+	key, err := stub.CreateCompositeKey(K_FEE_SCHEDULE_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	if fee == 0 {
+		if err := stub.DelState(key); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	schedule := &FeeSchedule{Domain: args[0], Fee: fee, UpdatedAt: now}
+	if err := putJSONState(stub, key, schedule); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put schedule"))
+	}
+	bz, _ := json.Marshal(schedule)
+	return shim.Success(bz)
+}
+
+// 管理员为账户充值
+// args[0] 提交者证书sha256(hex)
+// args[1] 充值数额
+func (bs *CrossChain) depositFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	amount, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil || amount == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "amount(%s) format error", args[1]))
+	}
+	account, key, err := bs.getFeeAccount(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get fee account"))
+	}
+	if account.Balance+amount < account.Balance {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "balance of %s overflows", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	account.Balance += amount
+	account.Deposited += amount
+	account.UpdatedAt = now
+	if err := putJSONState(stub, key, account); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put account"))
+	}
+	bz, _ := json.Marshal(account)
+	return shim.Success(bz)
+}
+
+// 跨链桥运营方从手续费池提取
+// args[0] 提取数额
+// args[1] 链下结算凭证
+func (bs *CrossChain) withdrawFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	amount, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil || amount == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "amount(%s) format error", args[0]))
+	}
+	pool, err := bs.getFeePool(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get fee pool"))
+	}
+	if amount > pool.available() {
+		return errorResponse("", crosserr.New(crosserr.CodeInsufficient, "amount %d exceeds available fee %d", amount, pool.available()))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	pool.Withdrawn += amount
+	pool.Withdrawals = append(pool.Withdrawals, FeeWithdrawal{Amount: amount, Reference: args[1], TxId: stub.GetTxID(), Timestamp: now})
+	if err := putJSONState(stub, K_FEE_POOL, pool); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put pool"))
+	}
+	bz, _ := json.Marshal(pool)
+	return shim.Success(bz)
+}
+
+// 查询账户，未充值过的账户余额为0
+// args[0] 提交者证书sha256(hex)
+func (bs *CrossChain) queryFeeAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	account, _, err := bs.getFeeAccount(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get fee account"))
+	}
+	bz, _ := json.Marshal(account)
+	return shim.Success(bz)
+}
+
+// 查询目的域名的手续费
+// args[0] 目的域名
+func (bs *CrossChain) queryFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	fee, err := bs.getFee(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get fee"))
+	}
+	bz, _ := json.Marshal(&FeeSchedule{Domain: args[0], Fee: fee})
+	return shim.Success(bz)
+}
+
+// 查询手续费池
+func (bs *CrossChain) queryFeePool(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	pool, err := bs.getFeePool(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get fee pool"))
+	}
+	bz, _ := json.Marshal(pool)
+	return shim.Success(bz)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crosserr"
+	"crossevent"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+func TestFee(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	client := testCertHash(TEST_RELAYER_CERT)
+	receiver := hex.EncodeToString(make([]byte, 32))
+	queryAccount := func() FeeAccount {
+		var account FeeAccount
+		if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryFeeAccount", client).Payload, &account); err != nil {
+			t.Fatal(err)
+		}
+		return account
+	}
+	queryPool := func() FeePool {
+		var pool FeePool
+		if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryFeePool").Payload, &pool); err != nil {
+			t.Fatal(err)
+		}
+		return pool
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setFeeSchedule", "dest.com", "10"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "depositFee", client, "25"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 按目的域名扣费，未设置手续费的域名不收费
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "batchSendUnorderedMessage", "dest.com", receiver, "m1", "m2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := <-stub.ChaincodeEventsChannel
+	if events, err := crossevent.Decode(event.Payload); err != nil || len(events) != 2 || events[0].Fee != 10 {
+		t.Fatalf("unexpected events: %v %v", events, err)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "sendUnorderedMessage", "free.com", receiver, "m3"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	<-stub.ChaincodeEventsChannel
+	if account := queryAccount(); account.Balance != 5 || account.Charged != 20 || account.Deposited != 25 || account.Messages != 2 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+
+	// 余额不足
+	res := InvokeWithStrings(t, stub, sp, "sendUnorderedMessage", "dest.com", receiver, "m4")
+	if code, _, ok := crosserr.Parse(res.Message); res.Status == shim.OK || !ok || code != crosserr.CodeInsufficient {
+		t.Fatalf("unexpected result: %d %s", res.Status, res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "withdrawFee", "20", "settle-001"); res.Status == shim.OK {
+		t.Fatal("non-admin should not withdraw fee")
+	}
+
+	// 运营方提取
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "withdrawFee", "15", "settle-001"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if pool := queryPool(); pool.Collected != 20 || pool.Withdrawn != 15 || len(pool.Withdrawals) != 1 || pool.Withdrawals[0].Reference != "settle-001" {
+		t.Fatalf("unexpected pool: %+v", pool)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "withdrawFee", "6", "settle-002"); res.Status == shim.OK {
+		t.Fatal("withdrawal should not exceed available fee")
+	}
+
+	// 取消收费
+	if res := InvokeWithStrings(t, stub, sp, "setFeeSchedule", "dest.com", "0"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var schedule FeeSchedule
+	if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryFeeSchedule", "dest.com").Payload, &schedule); err != nil || schedule.Fee != 0 {
+		t.Fatalf("unexpected schedule: %+v %v", schedule, err)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 多管理员治理
+// 开启后，敏感操作(更换管理员、域名解析器和AM合约配置、中继者白名单等)不能直接调用，
+// 需要由治理管理员发起提案，M个治理管理员中至少N个批准后，再通过executeAdminAction执行。
+// 执行时按提案中的函数名和参数分发调用，被调用函数自身的权限检查(通常为管理员)仍然有效。
+//
+// 执行时按当前的治理管理员统计批准数，已经移出的管理员的批准不再计入。
+// 修改治理配置本身也需要审批。
+const (
+	K_GOVERNANCE_CONFIG = CROSSCHAIN_PREFIX + "governance_config"
+	// crosschain_admin_proposal_${txId} -> AdminProposal
+	K_ADMIN_PROPOSAL_PREFIX = CROSSCHAIN_PREFIX + "admin_proposal_"
+)
+
+// 需要审批的操作，oracleAdminManage按子函数区分
+var governedFunctions = map[string]bool{
+	"setAdmin":                            true,
+	"setDomainParser":                     true,
+	"setGovernance":                       true,
+	"setKeyEndorsement":                   true,
+	"setEthAMContract":                    true,
+	"setTMAMStore":                        true,
+	"setPTCCommittee":                     true,
+	"removePTCCommittee":                  true,
+	"registerDomain":                      true,
+	"setBCDNSTrustRoot":                   true,
+	"setDomainPTCCert":                    true,
+	"setRelayerACLConfig":                 true,
+	"setRelayerACL":                       true,
+	"setRoleAttribute":                    true,
+	"createBridge":                        true,
+	"setRelayerTokenConfig":               true,
+	"setRelayerTokenKey":                  true,
+	"setBondConfig":                       true,
+	"slashBond":                           true,
+	"setRegistryCollection":               true,
+	"setRoute":                            true,
+	"deleteRoute":                         true,
+	"setReceiverChannel":                  true,
+	"setReceiverCollection":               true,
+	"setEventVersion":                     true,
+	"setFeeSchedule":                      true,
+	"depositFee":                          true,
+	"withdrawFee":                         true,
+	"setMessageExpiry":                    true,
+	"setRateLimit":                        true,
+	"unpause":                             true,
+	"oracleAdminManage.setExpectedDomain": true,
+	"oracleAdminManage.setMyChainDomainAMClient": true,
+	"oracleAdminManage.setDomainServiceId":       true,
+}
+
+type GovernanceConfig struct {
+	// 治理管理员证书sha256(hex)
+	Admins    []string `json:"admins"`
+	Threshold int      `json:"threshold"`
+	// 提案有效期(秒)
+	ProposalTTL int64 `json:"proposalTTL"`
+}
+
+type AdminProposal struct {
+	Id           string   `json:"id"`
+	Function     string   `json:"function"`
+	Args         []string `json:"args"`
+	Proposer     string   `json:"proposer"`
+	Approvals    []string `json:"approvals"`
+	CreatedAt    int64    `json:"createdAt"`
+	ExpiresAt    int64    `json:"expiresAt"`
+	Executed     bool     `json:"executed"`
+	ExecutedTxId string   `json:"executedTxId,omitempty"`
+}
+
+func governedFunctionName(fn string, args []string) string {
+	if fn == "oracleAdminManage" && len(args) > 0 {
+		return fn + "." + args[0]
+	}
+	return fn
+}
+
+func (bs *CrossChain) getGovernanceConfig(stub shim.ChaincodeStubInterface) (*GovernanceConfig, error) {
+	var config GovernanceConfig
+	if _, err := getJSONState(stub, K_GOVERNANCE_CONFIG, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// 开启治理后拒绝直接调用需要审批的操作
+func (bs *CrossChain) checkGovernedCall(stub shim.ChaincodeStubInterface, fn string, args []string) pb.Response {
+	if !governedFunctions[governedFunctionName(fn, args)] {
+		return shim.Success(nil)
+	}
+	config, err := bs.getGovernanceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get governance config"))
+	}
+	if len(config.Admins) == 0 {
+		return shim.Success(nil)
+	}
+	return errorResponse(fn, crosserr.New(crosserr.CodeUnauthorized, "requires approval of %d admins, submit it with proposeAdminAction", config.Threshold))
+}
+
+// 调用者必须是治理管理员，返回调用者证书sha256
+func (bs *CrossChain) checkGovernanceAdmin(stub shim.ChaincodeStubInterface, config *GovernanceConfig) (string, error) {
+	if len(config.Admins) == 0 {
+		return "", fmt.Errorf("governance is not enabled")
+	}
+	_, admin, err := getCreatorIdentity(stub)
+	if err != nil {
+		return "", err
+	}
+	if !containsString(config.Admins, admin) {
+		return "", fmt.Errorf("%s is not a governance admin", admin)
+	}
+	return admin, nil
+}
+
+func (bs *CrossChain) getAdminProposal(stub shim.ChaincodeStubInterface, id string) (*AdminProposal, error) {
+	var proposal AdminProposal
+	if has, err := getJSONState(stub, K_ADMIN_PROPOSAL_PREFIX+id, &proposal); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "proposal %s not found", id)
+	}
+	return &proposal, nil
+}
+
+// 设置治理管理员，管理员列表为空时关闭治理
+// 未开启治理时由管理员直接设置，开启后需要审批
+// args[0] 治理管理员证书sha256(json数组)
+// args[1] 批准门限
+// args[2] 提案有效期(秒)
+func (bs *CrossChain) setGovernance(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var config GovernanceConfig
+	if err := json.Unmarshal([]byte(args[0]), &config.Admins); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "admins format error"))
+	}
+	for i, admin := range config.Admins {
+		if id, err := hex.DecodeString(admin); err != nil || len(id) != sha256.Size {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "admin(%s) format error", admin))
+		}
+		if containsString(config.Admins[:i], admin) {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "duplicate admin %s", admin))
+		}
+	}
+	threshold, err := strconv.Atoi(args[1])
+	if err != nil || threshold < 0 || threshold > len(config.Admins) || (len(config.Admins) > 0 && threshold == 0) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid threshold: %s", args[1]))
+	}
+	ttl, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || ttl <= 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid proposal ttl: %s", args[2]))
+	}
+	config.Threshold, config.ProposalTTL = threshold, ttl
+	if err := putJSONState(stub, K_GOVERNANCE_CONFIG, &config); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put config"))
+	}
+	return shim.Success(nil)
+}
+
+// 发起需要审批的操作，发起者自动批准，返回提案id
+// args[0] 函数名
+// args[1..] 函数参数
+func (bs *CrossChain) proposeAdminAction(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if !governedFunctions[governedFunctionName(args[0], args[1:])] {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "%s does not require approval", governedFunctionName(args[0], args[1:])))
+	}
+	config, err := bs.getGovernanceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get governance config"))
+	}
+	admin, err := bs.checkGovernanceAdmin(stub, config)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "check governance admin failed"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	proposal := &AdminProposal{
+		Id:        stub.GetTxID(),
+		Function:  args[0],
+		Args:      append([]string{}, args[1:]...),
+		Proposer:  admin,
+		Approvals: []string{admin},
+		CreatedAt: now,
+		ExpiresAt: now + config.ProposalTTL,
+	}
+	if err := putJSONState(stub, K_ADMIN_PROPOSAL_PREFIX+proposal.Id, proposal); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put proposal"))
+	}
+	return shim.Success([]byte(proposal.Id))
+}
+
+// 批准提案
+// args[0] 提案id
+func (bs *CrossChain) approveAdminAction(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	config, err := bs.getGovernanceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get governance config"))
+	}
+	admin, err := bs.checkGovernanceAdmin(stub, config)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "check governance admin failed"))
+	}
+	proposal, err := bs.getAdminProposal(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get admin proposal"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if proposal.Executed || now >= proposal.ExpiresAt {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "proposal %s is executed or expired", proposal.Id))
+	}
+	if containsString(proposal.Approvals, admin) {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "proposal %s is already approved by %s", proposal.Id, admin))
+	}
+	proposal.Approvals = append(proposal.Approvals, admin)
+	if err := putJSONState(stub, K_ADMIN_PROPOSAL_PREFIX+proposal.Id, proposal); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put proposal"))
+	}
+	return shim.Success(nil)
+}
+
+// 执行批准数达到门限的提案
+// args[0] 提案id
+func (bs *CrossChain) executeAdminAction(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	config, err := bs.getGovernanceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get governance config"))
+	}
+	if len(config.Admins) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "governance is not enabled"))
+	}
+	proposal, err := bs.getAdminProposal(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get admin proposal"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if proposal.Executed || now >= proposal.ExpiresAt {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "proposal %s is executed or expired", proposal.Id))
+	}
+	approvals := 0
+	for _, admin := range proposal.Approvals {
+		if containsString(config.Admins, admin) {
+			approvals++
+		}
+	}
+	if approvals < config.Threshold {
+		return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "proposal %s has %d approvals, %d required", proposal.Id, approvals, config.Threshold))
+	}
+
+	proposal.Executed, proposal.ExecutedTxId = true, stub.GetTxID()
+	if err := putJSONState(stub, K_ADMIN_PROPOSAL_PREFIX+proposal.Id, proposal); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put proposal"))
+	}
+	return bs.dispatch(stub, proposal.Function, proposal.Args)
+}
+
+// 查询提案
+// args[0] 提案id
+func (bs *CrossChain) queryAdminProposal(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	proposal, err := bs.getAdminProposal(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get admin proposal"))
+	}
+	raw, _ := json.Marshal(proposal)
+	return shim.Success(raw)
+}
+
+// 查询治理配置
+func (bs *CrossChain) queryGovernance(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	config, err := bs.getGovernanceConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get governance config"))
+	}
+	raw, _ := json.Marshal(config)
+	return shim.Success(raw)
+}
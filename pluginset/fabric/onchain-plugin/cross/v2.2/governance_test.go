@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func TestGovernance(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	admin, relayer := testCertHash(TEST_ADMIN_CERT), testCertHash(TEST_RELAYER_CERT)
+	// 提案id为交易id，每次调用使用不同的交易id
+	invoke := func(tx string, cert string, args ...string) pb.Response {
+		stub.Creator = MockCreator(cert)
+		defer func() { stub.Creator = MockCreator(TEST_ADMIN_CERT) }()
+		var bargs [][]byte
+		for _, arg := range args {
+			bargs = append(bargs, []byte(arg))
+		}
+		return stub.MockInvokeWithSignedProposal(tx, bargs, sp)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setGovernance", `["00"]`, "1", "600"); res.Status == shim.OK {
+		t.Fatal("invalid admin should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setGovernance", `["`+admin+`","`+relayer+`"]`, "3", "600"); res.Status == shim.OK {
+		t.Fatal("threshold over admin count should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setGovernance", `["`+admin+`","`+relayer+`"]`, "2", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 开启后不能直接调用
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACLConfig", "true"); res.Status == shim.OK {
+		t.Fatal("governed function should not be called directly")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status == shim.OK {
+		t.Fatal("governed oracle function should not be called directly")
+	}
+	if res := invoke("tx0", TEST_RELAYER_CERT, "proposeAdminAction", "queryGovernance"); res.Status == shim.OK {
+		t.Fatal("function without approval should not be proposed")
+	}
+
+	res := invoke("tx1", TEST_RELAYER_CERT, "proposeAdminAction", "setRelayerACLConfig", "true")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	id := string(res.Payload)
+	if res := invoke("tx2", TEST_ADMIN_CERT, "executeAdminAction", id); res.Status == shim.OK {
+		t.Fatal("proposal below threshold should not be executed")
+	}
+	if res := invoke("tx3", TEST_RELAYER_CERT, "approveAdminAction", id); res.Status == shim.OK {
+		t.Fatal("duplicate approval should be rejected")
+	}
+	if res := invoke("tx4", TEST_ADMIN_CERT, "approveAdminAction", id); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := invoke("tx5", TEST_ADMIN_CERT, "executeAdminAction", id); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var config RelayerACLConfig
+	if _, err := getJSONState(stub, K_RELAYER_ACL_CONFIG, &config); err != nil || !config.Enabled {
+		t.Fatal("approved action should be executed")
+	}
+	if res := invoke("tx6", TEST_ADMIN_CERT, "executeAdminAction", id); res.Status == shim.OK {
+		t.Fatal("proposal should not be executed twice")
+	}
+	var proposal AdminProposal
+	if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryAdminProposal", id).Payload, &proposal); err != nil {
+		t.Fatal(err)
+	}
+	if !proposal.Executed || proposal.ExecutedTxId != "tx5" || len(proposal.Approvals) != 2 {
+		t.Fatalf("unexpected proposal: %+v", proposal)
+	}
+
+	// 修改治理配置也需要审批，移出的管理员不能再发起提案
+	if res := InvokeWithStrings(t, stub, sp, "setGovernance", `["`+admin+`"]`, "1", "600"); res.Status == shim.OK {
+		t.Fatal("governance config should not be changed directly")
+	}
+	res = invoke("tx7", TEST_RELAYER_CERT, "proposeAdminAction", "setGovernance", `["`+admin+`"]`, "1", "600")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	id = string(res.Payload)
+	if res := invoke("tx8", TEST_ADMIN_CERT, "approveAdminAction", id); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := invoke("tx9", TEST_ADMIN_CERT, "executeAdminAction", id); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := invoke("tx10", TEST_RELAYER_CERT, "proposeAdminAction", "setRelayerACLConfig", "false"); res.Status == shim.OK {
+		t.Fatal("removed admin should not propose")
+	}
+}
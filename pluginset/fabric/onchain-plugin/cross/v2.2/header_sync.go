@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 对方链区块头同步管理
+// 各轻客户端(以太坊、Tendermint)在初始化和接受新区块头后登记到这里，由本模块统一：
+//   - 记录初始可信状态和最新同步高度，要求区块头按高度递增提交
+//   - 记录验证人集合/同步委员会的轮换历史
+//   - 按保留数量裁剪旧区块头，每笔交易最多裁剪HEADER_SYNC_MAX_PRUNE个，避免交易写集过大
+//
+// 已同步的高度按提交顺序保存在队列 [TailSeq, HeadSeq) 中，高度可以不连续。
+// 重新初始化到更低的高度时，队列中较高的旧记录会暂时阻塞裁剪，直到同步高度超过它们。
+const (
+	// crosschain_hs_${domain} -> HeaderSyncState
+	K_HEADER_SYNC_PREFIX = CROSSCHAIN_PREFIX + "hs_"
+	// crosschain_hs_height_${domain}_${seq} -> 已同步的区块高度
+	K_HEADER_SYNC_HEIGHT_PREFIX = CROSSCHAIN_PREFIX + "hs_height_"
+	// crosschain_hs_rotation_${domain}_${index} -> ValidatorRotation
+	K_HEADER_SYNC_ROTATION_PREFIX = CROSSCHAIN_PREFIX + "hs_rotation_"
+
+	HEADER_SYNC_CLIENT_ETH = "eth"
+	HEADER_SYNC_CLIENT_TM  = "tm"
+
+	HEADER_SYNC_MAX_PRUNE = 32
+)
+
+// 各轻客户端保存区块头的key
+var headerSyncClients = map[string]func(domain string, height int64) string{
+	HEADER_SYNC_CLIENT_ETH: func(domain string, height int64) string { return ethHeaderKey(domain, uint64(height)) },
+	HEADER_SYNC_CLIENT_TM:  tmConsensusKey,
+}
+
+type HeaderSyncState struct {
+	Client        string `json:"client"`
+	TrustedHeight int64  `json:"trustedHeight"`
+	LatestHeight  int64  `json:"latestHeight"`
+	// 最早仍保存的区块高度
+	OldestHeight int64 `json:"oldestHeight"`
+	// 保留最近多少个高度内的区块头，0表示不裁剪
+	Retain int64 `json:"retain"`
+	// 当前验证人集合/同步委员会的哈希(hex)
+	ValidatorsHash string `json:"validatorsHash"`
+	Rotations      int64  `json:"rotations"`
+	HeadSeq        int64  `json:"headSeq"`
+	TailSeq        int64  `json:"tailSeq"`
+}
+
+type ValidatorRotation struct {
+	Height         int64  `json:"height"`
+	ValidatorsHash string `json:"validatorsHash"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+func headerSyncHeightKey(domain string, seq int64) string {
+	return K_HEADER_SYNC_HEIGHT_PREFIX + domain + "_" + strconv.FormatInt(seq, 10)
+}
+
+func headerSyncRotationKey(domain string, index int64) string {
+	return K_HEADER_SYNC_ROTATION_PREFIX + domain + "_" + strconv.FormatInt(index, 10)
+}
+
+func (bs *CrossChain) getHeaderSyncState(stub shim.ChaincodeStubInterface, domain string) (*HeaderSyncState, error) {
+	var state HeaderSyncState
+	has, err := getJSONState(stub, K_HEADER_SYNC_PREFIX+domain, &state)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "header sync for domain %s not initialized", domain)
+	}
+	return &state, nil
+}
+
+// 轻客户端初始化时登记可信状态
+// 同一域名重新初始化时保留裁剪配置和未裁剪的高度队列，不允许换用其他类型的轻客户端
+func (bs *CrossChain) initHeaderSync(stub shim.ChaincodeStubInterface, domain, client string, height int64, validatorsHash string) error {
+	state := &HeaderSyncState{Client: client}
+	if has, err := getJSONState(stub, K_HEADER_SYNC_PREFIX+domain, state); err != nil {
+		return err
+	} else if has && state.Client != client {
+		return fmt.Errorf("domain %s is already synced by %s light client", domain, state.Client)
+	} else if !has {
+		state.OldestHeight = height
+	}
+	state.TrustedHeight = height
+	state.LatestHeight = height
+
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return bs.appendSyncedHeader(stub, domain, state, height, validatorsHash, now)
+}
+
+// 轻客户端接受新区块头后登记，区块高度必须大于已同步的最新高度
+func (bs *CrossChain) recordSyncedHeader(stub shim.ChaincodeStubInterface, domain string, height int64, validatorsHash string) error {
+	state, err := bs.getHeaderSyncState(stub, domain)
+	if err != nil {
+		return err
+	}
+	if height <= state.LatestHeight {
+		return fmt.Errorf("header height %d is not after latest synced height %d", height, state.LatestHeight)
+	}
+	state.LatestHeight = height
+
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return bs.appendSyncedHeader(stub, domain, state, height, validatorsHash, now)
+}
+
+func (bs *CrossChain) appendSyncedHeader(stub shim.ChaincodeStubInterface, domain string, state *HeaderSyncState, height int64, validatorsHash string, now int64) error {
+	if err := stub.PutState(headerSyncHeightKey(domain, state.HeadSeq), []byte(strconv.FormatInt(height, 10))); err != nil {
+		return err
+	}
+	state.HeadSeq++
+
+	if validatorsHash != state.ValidatorsHash || state.Rotations == 0 {
+		rotation := &ValidatorRotation{Height: height, ValidatorsHash: validatorsHash, Timestamp: now}
+		if err := putJSONState(stub, headerSyncRotationKey(domain, state.Rotations), rotation); err != nil {
+			return err
+		}
+		state.Rotations++
+		state.ValidatorsHash = validatorsHash
+	}
+
+	if err := bs.pruneSyncedHeaders(stub, domain, state); err != nil {
+		return err
+	}
+	return putJSONState(stub, K_HEADER_SYNC_PREFIX+domain, state)
+}
+
+// 裁剪超出保留范围的区块头，最新同步的区块头始终保留
+func (bs *CrossChain) pruneSyncedHeaders(stub shim.ChaincodeStubInterface, domain string, state *HeaderSyncState) error {
+	if state.Retain <= 0 {
+		return nil
+	}
+	headerKey := headerSyncClients[state.Client]
+	for pruned := 0; state.TailSeq < state.HeadSeq-1; pruned++ {
+		height, err := bs.getSyncedHeight(stub, domain, state.TailSeq)
+		if err != nil {
+			return err
+		}
+		if pruned == HEADER_SYNC_MAX_PRUNE || height > state.LatestHeight-state.Retain {
+			state.OldestHeight = height
+			return nil
+		}
+		if err := stub.DelState(headerKey(domain, height)); err != nil {
+			return err
+		}
+		if err := stub.DelState(headerSyncHeightKey(domain, state.TailSeq)); err != nil {
+			return err
+		}
+		state.TailSeq++
+	}
+	state.OldestHeight = state.LatestHeight
+	return nil
+}
+
+func (bs *CrossChain) getSyncedHeight(stub shim.ChaincodeStubInterface, domain string, seq int64) (int64, error) {
+	raw, err := stub.GetState(headerSyncHeightKey(domain, seq))
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid synced height %q", raw)
+	}
+	return height, nil
+}
+
+// 设置区块头保留数量
+// args[0] 来源域名
+// args[1] 保留最近多少个高度内的区块头，0表示不裁剪
+func (bs *CrossChain) setHeaderRetention(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	state, err := bs.getHeaderSyncState(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get header sync state"))
+	}
+	retain, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || retain < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid retention: %s", args[1]))
+	}
+	state.Retain = retain
+	if err := bs.pruneSyncedHeaders(stub, args[0], state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to prune synced headers"))
+	}
+	if err := putJSONState(stub, K_HEADER_SYNC_PREFIX+args[0], state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询区块头同步状态
+// args[0] 来源域名
+func (bs *CrossChain) queryHeaderSync(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	state, err := bs.getHeaderSyncState(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get header sync state"))
+	}
+	status, _ := json.Marshal(state)
+	return shim.Success(status)
+}
+
+// 查询验证人集合/同步委员会的轮换记录
+// args[0] 来源域名
+// args[1] 轮换序号，从0开始
+func (bs *CrossChain) queryValidatorRotation(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid rotation index"))
+	}
+	rotation, err := stub.GetState(headerSyncRotationKey(args[0], index))
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(rotation) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "rotation %d of domain %s not found", index, args[0]))
+	}
+	return shim.Success(rotation)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+	"time"
+	"tmlightclient"
+)
+
+func TestHeaderSync(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	keys, vals := testTMValidators(4)
+	newKeys, newVals := testTMValidators(5)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	trusted := testTMHeader(10, now.Add(-100*time.Second), vals, nil)
+	if res := InvokeWithStrings(t, stub, sp, "initTMLightClient", "cosmos.test", "cosmos-test", "86400", mustJSON(&trusted), mustJSON(vals)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	submit := func(header tmlightclient.Header, keys []ed25519.PrivateKey, vals, nextVals *tmlightclient.ValidatorSet) {
+		signers := make([]bool, len(keys))
+		for i := range signers {
+			signers[i] = true
+		}
+		sh := testTMSignedHeader(header, keys, vals, signers...)
+		if res := InvokeWithStrings(t, stub, sp, "submitTMHeader", "cosmos.test", mustJSON(sh), mustJSON(vals), mustJSON(nextVals)); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	submit(testTMHeader(11, now.Add(-90*time.Second), vals, nil), keys, vals, vals)
+	// 12之后验证人集合轮换
+	rotating := testTMHeader(12, now.Add(-80*time.Second), vals, nil)
+	rotating.NextValidatorsHash = newVals.Hash()
+	submit(rotating, keys, vals, newVals)
+	submit(testTMHeader(14, now.Add(-70*time.Second), newVals, nil), newKeys, newVals, newVals)
+
+	var state HeaderSyncState
+	res := InvokeWithStrings(t, stub, sp, "queryHeaderSync", "cosmos.test")
+	if err := json.Unmarshal(res.Payload, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Client != HEADER_SYNC_CLIENT_TM || state.TrustedHeight != 10 || state.LatestHeight != 14 || state.OldestHeight != 10 || state.Rotations != 2 {
+		t.Fatalf("unexpected header sync state: %s", res.Payload)
+	}
+	var rotation ValidatorRotation
+	res = InvokeWithStrings(t, stub, sp, "queryValidatorRotation", "cosmos.test", "1")
+	if err := json.Unmarshal(res.Payload, &rotation); err != nil {
+		t.Fatal(err)
+	}
+	if rotation.Height != 12 || rotation.ValidatorsHash != hex.EncodeToString(newVals.Hash()) {
+		t.Fatalf("unexpected rotation: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryValidatorRotation", "cosmos.test", "2"); res.Status == shim.OK {
+		t.Fatal("unknown rotation should not be found")
+	}
+
+	// 只保留最近3个高度内的区块头
+	if res := InvokeWithStrings(t, stub, sp, "setHeaderRetention", "cosmos.test", "3"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	for height, kept := range map[int64]bool{10: false, 11: false, 12: true, 14: true} {
+		if _, has := stub.State[tmConsensusKey("cosmos.test", height)]; has != kept {
+			t.Fatalf("consensus state at height %d: kept=%v, expected %v", height, has, kept)
+		}
+	}
+	submit(testTMHeader(16, now.Add(-60*time.Second), newVals, nil), newKeys, newVals, newVals)
+	if _, has := stub.State[tmConsensusKey("cosmos.test", 12)]; has {
+		t.Fatal("consensus state at height 12 should be pruned")
+	}
+	res = InvokeWithStrings(t, stub, sp, "queryHeaderSync", "cosmos.test")
+	if err := json.Unmarshal(res.Payload, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.OldestHeight != 14 || state.LatestHeight != 16 {
+		t.Fatalf("unexpected header sync state after pruning: %s", res.Payload)
+	}
+
+	// 按高度递增提交
+	if res := InvokeWithStrings(t, stub, sp, "submitTMHeader", "cosmos.test", mustJSON(testTMSignedHeader(testTMHeader(15, now.Add(-50*time.Second), newVals, nil), newKeys, newVals, true, true, true, true, true)), mustJSON(newVals), mustJSON(newVals)); res.Status == shim.OK {
+		t.Fatal("header below latest synced height should be rejected")
+	}
+}
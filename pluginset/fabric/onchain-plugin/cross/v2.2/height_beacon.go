@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 高度信标
+// 链码读不到区块高度，这里记下最近一笔跨链交易的交易id和时间，中继插件查询信标后按交易id查到所在区块，
+// 不需要扫描区块就能得到跨链链码最近活跃的高度。有序发送和各种接收消息成功后顺带更新，没有消息时中继可以调用touch。
+//
+// 信标只写不读，同一区块中的多笔交易同时更新不会产生读写冲突，以最后一笔为准。无序发送不更新信标，保持只写入与单条消息相关的key。
+const (
+	K_HEIGHT_BEACON = CROSSCHAIN_PREFIX + "height_beacon"
+)
+
+type HeightBeacon struct {
+	TxId      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+	// 更新信标的方法
+	Function string `json:"function"`
+}
+
+// 成功后更新信标的方法
+var heightBeaconFunctions = map[string]bool{
+	"sendMessage":             true,
+	"sendMessageWithResponse": true,
+	"sendChunkedMessage":      true,
+	"recvMessage":             true,
+	"recvBatchMessages":       true,
+	"recvOptimisticMessage":   true,
+	"recvZKMessage":           true,
+	"recvZKBatchMessages":     true,
+	"recvEthMessage":          true,
+	"recvTMMessage":           true,
+	"recvBTCMessage":          true,
+	"recvPTCMessage":          true,
+	"touch":                   true,
+}
+
+// 写入信标，不读取旧值
+func touchHeightBeacon(stub shim.ChaincodeStubInterface, fn string) error {
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return putJSONState(stub, K_HEIGHT_BEACON, &HeightBeacon{TxId: stub.GetTxID(), Timestamp: now, Function: fn})
+}
+
+// 查询高度信标
+func (bs *CrossChain) queryHeightBeacon(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var beacon HeightBeacon
+	if has, err := getJSONState(stub, K_HEIGHT_BEACON, &beacon); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "height beacon not set yet"))
+	}
+	raw, _ := json.Marshal(&beacon)
+	return shim.Success(raw)
+}
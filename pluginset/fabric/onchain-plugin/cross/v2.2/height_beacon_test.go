@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+func TestHeightBeacon(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	beacon := func() HeightBeacon {
+		res := InvokeWithStrings(t, stub, sp, "queryHeightBeacon")
+		var b HeightBeacon
+		if err := json.Unmarshal(res.Payload, &b); err != nil {
+			t.Fatalf("no height beacon: %s", res.Message)
+		}
+		return b
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryHeightBeacon"); res.Status == shim.OK {
+		t.Fatal("height beacon should not be set yet")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	receiver := hex.EncodeToString(make([]byte, 32))
+	if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", receiver, "hello", "n"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if b := beacon(); b.TxId != txid || b.Function != "sendMessage" {
+		t.Fatalf("unexpected beacon: %+v", b)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "touch"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 无序发送和失败的调用不更新信标
+	if res := InvokeWithStrings(t, stub, sp, "sendUnorderedMessage", "dest.com", receiver, "hello", "n"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", "zz"); res.Status == shim.OK {
+		t.Fatal("invalid message should be rejected")
+	}
+	if b := beacon(); b.Function != "touch" {
+		t.Fatalf("unexpected beacon: %+v", b)
+	}
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "touch"); res.Status == shim.OK {
+		t.Fatal("touch should require relayer role")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"cctest"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"identityregistry"
+	"strings"
+	"testing"
+)
+
+// 测试用的业务链码：只接受绑定到指定MSP身份的远端账户发来的消息
+type identityGuard struct {
+	mspId, certHash string
+}
+
+func (g *identityGuard) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (g *identityGuard) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	if fn != "recvMessage" || len(args) != 3 {
+		return shim.Error("unknown function " + fn)
+	}
+	if err := identityregistry.CheckRemote(stub, "idcc", args[0], args[1], g.mspId, g.certHash); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState("last", []byte(args[2])); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func certHash(certPEM string) string {
+	block, _ := pem.Decode([]byte(certPEM))
+	hash := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// 登记表的绑定规则和查询见vendor/identityregistry，这里检查管理员由跨链链码认定，以及业务链码在回调中检查发送者
+func TestIdentityRegistry(t *testing.T) {
+	cross, _ := newCCTestStubs(t)
+	idcc := cctest.NewMockStub("idcc", identityregistry.NewChaincode("crosscc"))
+	adminHash := certHash(TEST_ADMIN_CERT)
+	guard := cctest.NewMockStub("guardcc", &identityGuard{mspId: "Org1MSP", certHash: adminHash})
+	idcc.AddPeer(cross)
+	guard.AddPeer(idcc)
+
+	evm := "0x00000000000000000000000000000000000000aa"
+	sender, _ := identityregistry.RemoteIdentity(identityregistry.KIND_EVM, evm)
+	idcc.SetCreator("Org1MSP", []byte(TEST_RELAYER_CERT))
+	if res := idcc.InvokeWithStrings(identityregistry.FN_BIND_IDENTITY, "evm.test", identityregistry.KIND_EVM, evm, "Org1MSP", adminHash); !strings.Contains(res.Message, "not oracle service admin") {
+		t.Fatalf("non-admin should not bind: %s", res.Message)
+	}
+	idcc.SetCreator("Org1MSP", []byte(TEST_ADMIN_CERT))
+	if res := idcc.InvokeWithStrings(identityregistry.FN_BIND_IDENTITY, "evm.test", identityregistry.KIND_EVM, evm, "Org1MSP", adminHash); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	if res := cctest.DeliverMessage(guard, "crosscc", "evm.test", sender, []byte("hello"), false); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if string(guard.State["last"]) != "hello" {
+		t.Fatalf("unexpected message: %s", guard.State["last"])
+	}
+	if res := cctest.DeliverMessage(guard, "crosscc", "eos.test", sender, []byte("hello"), false); !strings.Contains(res.Message, "not bound") {
+		t.Fatalf("sender bound on other domain should be rejected: %s", res.Message)
+	}
+
+	idcc.SetCreator("Org1MSP", []byte(TEST_RELAYER_CERT))
+	if res := idcc.InvokeWithStrings(identityregistry.FN_UNBIND_IDENTITY, "evm.test", identityregistry.KIND_EVM, evm); res.Status == shim.OK {
+		t.Fatal("non-admin should not unbind")
+	}
+	idcc.SetCreator("Org1MSP", []byte(TEST_ADMIN_CERT))
+	if res := idcc.InvokeWithStrings(identityregistry.FN_UNBIND_IDENTITY, "evm.test", identityregistry.KIND_EVM, evm); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := cctest.DeliverMessage(guard, "crosscc", "evm.test", sender, []byte("hello"), false); res.Status == shim.OK {
+		t.Fatal("unbound sender should be rejected")
+	}
+}
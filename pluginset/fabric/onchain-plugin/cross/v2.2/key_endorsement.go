@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"unicode/utf8"
+)
+
+// 关键状态的key级背书策略
+// 管理员可以为跨链链码的关键状态设置key级背书策略(state-based endorsement)，修改这些key的交易除满足链码级背书策略外，
+// 还需要指定组织的背书，管理员私钥泄露或单个组织的节点被攻破时不能单独修改：
+//   - admin：管理员证书和按证书属性认定角色的规则(见role.go)
+//   - registry：协议注册表，即本链域名、接收方登记表、sha256原像表和登记表所在的集合
+//   - committee：各来源域名的PTC委员会
+//
+// 设置时对已有的key生效，之后新建的key在写入时设置。策略本身也受同一策略保护，修改或删除策略需要满足当前策略。
+// sha256原像表的key没有公共前缀，登记表保存在集合中时公共状态只有摘要，这两类表项在设置前已经存在的需要重新登记才受保护。
+const (
+	// crosschain_key_endorsement_${scope} -> EndorsementPolicy
+	K_KEY_ENDORSEMENT_PREFIX = CROSSCHAIN_PREFIX + "key_endorsement_"
+
+	KEY_SCOPE_ADMIN     = "admin"
+	KEY_SCOPE_REGISTRY  = "registry"
+	KEY_SCOPE_COMMITTEE = "committee"
+)
+
+// 各范围的固定key和key前缀
+var keyScopes = map[string]struct {
+	keys        []string
+	prefixes    []string
+	objectTypes []string
+}{
+	KEY_SCOPE_ADMIN:     {keys: []string{oraclelogic.K_ADMIN_CERT}, objectTypes: []string{K_ROLE_OBJECT_TYPE}},
+	KEY_SCOPE_REGISTRY:  {keys: []string{oraclelogic.K_EXPECTED_DOMAIN, K_REGISTRY_COLLECTION}, prefixes: []string{K_RECEIVER_PREFIX}},
+	KEY_SCOPE_COMMITTEE: {prefixes: []string{K_PTC_COMMITTEE_PREFIX}},
+}
+
+// 范围当前的背书策略，未设置时返回nil
+func (bs *CrossChain) getKeyEndorsement(stub shim.ChaincodeStubInterface, scope string) (*EndorsementPolicy, error) {
+	var policy EndorsementPolicy
+	if has, err := getJSONState(stub, K_KEY_ENDORSEMENT_PREFIX+scope, &policy); err != nil || !has {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// 为新写入的key设置所在范围的背书策略，范围未设置策略时不处理
+func (bs *CrossChain) protectKeys(stub shim.ChaincodeStubInterface, scope string, keys ...string) error {
+	policy, err := bs.getKeyEndorsement(stub, scope)
+	if err != nil || policy == nil {
+		return err
+	}
+	ep, err := buildEndorsementPolicy(policy.Threshold, policy.Orgs)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := stub.SetStateValidationParameter(key, ep); err != nil {
+			return fmt.Errorf("failed to set endorsement policy of key %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// 接收方登记表在公共状态中的key，配置了集合时为摘要key
+func registryPublicKey(stub shim.ChaincodeStubInterface, key string) (string, error) {
+	collection, err := getRegistryCollection(stub)
+	if err != nil || collection == "" {
+		return key, err
+	}
+	return registryDigestKey(key), nil
+}
+
+// sha256原像表的key
+func sha256InvertKey(image string) string {
+	h := sha256.Sum256([]byte(image))
+	return hex.EncodeToString(h[:])
+}
+
+// 范围内已经存在的key
+func scopeKeys(stub shim.ChaincodeStubInterface, scope string) ([]string, error) {
+	var keys []string
+	for _, key := range keyScopes[scope].keys {
+		value, err := stub.GetState(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) != 0 {
+			keys = append(keys, key)
+		}
+	}
+	for _, prefix := range keyScopes[scope].prefixes {
+		iter, err := stub.GetStateByRange(prefix, prefix+string(utf8.MaxRune))
+		if err != nil {
+			return nil, err
+		}
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				return nil, err
+			}
+			keys = append(keys, kv.Key)
+		}
+		iter.Close()
+	}
+	for _, objectType := range keyScopes[scope].objectTypes {
+		iter, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+		if err != nil {
+			return nil, err
+		}
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				return nil, err
+			}
+			keys = append(keys, kv.Key)
+		}
+		iter.Close()
+	}
+	return keys, nil
+}
+
+// 设置关键状态的背书策略
+// args[0] 范围，admin/registry/committee
+// args[1] 至少需要多少个组织背书，0表示删除策略
+// args[2..] 组织MSP ID
+func (bs *CrossChain) setKeyEndorsement(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	scope := args[0]
+	if _, ok := keyScopes[scope]; !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown key scope: %s", scope))
+	}
+	keys, err := scopeKeys(stub, scope)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read scope keys"))
+	}
+	configKey := K_KEY_ENDORSEMENT_PREFIX + scope
+
+	var ep []byte
+	if args[1] == "0" && len(args) == 2 {
+		// 删除key时节点同时删除其背书策略
+		if err := stub.DelState(configKey); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+	} else {
+		policy, err := parseEndorsementPolicyArgs(args[1:])
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid endorsement policy"))
+		}
+		if ep, err = buildEndorsementPolicy(policy.Threshold, policy.Orgs); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid endorsement policy"))
+		}
+		if err := putJSONState(stub, configKey, policy); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put policy"))
+		}
+		keys = append(keys, configKey)
+	}
+	for _, key := range keys {
+		if err := stub.SetStateValidationParameter(key, ep); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to set endorsement policy of key %s", key))
+		}
+	}
+	bz, _ := json.Marshal(keys)
+	return shim.Success(bz)
+}
+
+// 查询关键状态的背书策略
+// args[0] 范围，admin/registry/committee
+func (bs *CrossChain) queryKeyEndorsement(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if _, ok := keyScopes[args[0]]; !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown key scope: %s", args[0]))
+	}
+	policy, err := bs.getKeyEndorsement(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get key endorsement"))
+	}
+	if policy == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no endorsement policy for scope %s", args[0]))
+	}
+	bz, _ := json.Marshal(policy)
+	return shim.Success(bz)
+}
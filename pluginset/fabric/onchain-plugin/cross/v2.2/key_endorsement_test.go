@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestKeyEndorsement(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	invoke := func(args ...string) {
+		if res := InvokeWithStrings(t, stub, sp, args...); res.Status != shim.OK {
+			t.Fatalf("%v: %s", args, res.Message)
+		}
+	}
+	keyPolicy := func(key string) *KeyEndorsementPolicy {
+		ep, _ := stub.GetStateValidationParameter(key)
+		if len(ep) == 0 {
+			return nil
+		}
+		policy, err := parseKeyEndorsementPolicy(key, ep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return policy
+	}
+	receiverKey := func(name string) string {
+		h := sha256.Sum256([]byte(name))
+		return K_RECEIVER_PREFIX + hex.EncodeToString(h[:])
+	}
+
+	// 设置前已经存在的key
+	invoke("registerReceiver", "bizcc")
+	stub.MockTransactionStart("committee")
+	_ = stub.PutState(K_PTC_COMMITTEE_PREFIX+"src.com", []byte("{}"))
+	stub.MockTransactionEnd("committee")
+
+	res := InvokeWithStrings(t, stub, sp, "setKeyEndorsement", KEY_SCOPE_ADMIN, "2", "Org1MSP", "Org2MSP")
+	var keys []string
+	if err := json.Unmarshal(res.Payload, &keys); err != nil {
+		t.Fatal(res.Message)
+	}
+	if len(keys) != 2 || keys[0] != oraclelogic.K_ADMIN_CERT || keys[1] != K_KEY_ENDORSEMENT_PREFIX+KEY_SCOPE_ADMIN {
+		t.Fatalf("unexpected protected keys: %v", keys)
+	}
+	for _, key := range keys {
+		if p := keyPolicy(key); p == nil || p.Threshold != 2 || len(p.Orgs) != 2 || p.Orgs[1] != "Org2MSP" {
+			t.Fatalf("unexpected policy of %s: %+v", key, p)
+		}
+	}
+	invoke("setKeyEndorsement", KEY_SCOPE_REGISTRY, "1", "Org1MSP")
+	invoke("setKeyEndorsement", KEY_SCOPE_COMMITTEE, "1", "Org3MSP")
+	if p := keyPolicy(receiverKey("bizcc")); p == nil || p.Orgs[0] != "Org1MSP" {
+		t.Fatalf("existing receiver should be protected: %+v", p)
+	}
+	if p := keyPolicy(K_PTC_COMMITTEE_PREFIX + "src.com"); p == nil || p.Orgs[0] != "Org3MSP" {
+		t.Fatalf("existing committee should be protected: %+v", p)
+	}
+	if keyPolicy(oraclelogic.K_EXPECTED_DOMAIN) != nil {
+		t.Fatal("missing key should not be protected")
+	}
+
+	// 之后新写入的key
+	invoke("oracleAdminManage", "setExpectedDomain", "fabric.test")
+	invoke("oracleAdminManage", "registerSha256Invert", "othercc")
+	invoke("registerReceiver", "othercc")
+	invoke("setAdmin", TEST_ADMIN_CERT)
+	for _, key := range []string{oraclelogic.K_EXPECTED_DOMAIN, sha256InvertKey("othercc"), receiverKey("othercc")} {
+		if p := keyPolicy(key); p == nil || p.Orgs[0] != "Org1MSP" {
+			t.Fatalf("new registry key %s should be protected: %+v", key, p)
+		}
+	}
+	if p := keyPolicy(oraclelogic.K_ADMIN_CERT); p == nil || p.Threshold != 2 {
+		t.Fatalf("admin should stay protected: %+v", p)
+	}
+
+	var policy EndorsementPolicy
+	res = InvokeWithStrings(t, stub, sp, "queryKeyEndorsement", KEY_SCOPE_REGISTRY)
+	if err := json.Unmarshal(res.Payload, &policy); err != nil || policy.Threshold != 1 || policy.Orgs[0] != "Org1MSP" {
+		t.Fatalf("unexpected registry policy: %s %s", res.Payload, res.Message)
+	}
+
+	// 删除策略
+	invoke("setKeyEndorsement", KEY_SCOPE_REGISTRY, "0")
+	if keyPolicy(receiverKey("bizcc")) != nil || keyPolicy(oraclelogic.K_EXPECTED_DOMAIN) != nil {
+		t.Fatal("registry policy should be removed")
+	}
+	invoke("registerReceiver", "thirdcc")
+	if keyPolicy(receiverKey("thirdcc")) != nil {
+		t.Fatal("receiver should not be protected after policy removed")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryKeyEndorsement", KEY_SCOPE_REGISTRY); res.Status == shim.OK {
+		t.Fatal("removed policy should not be found")
+	}
+
+	for _, args := range [][]string{
+		{"setKeyEndorsement", "routes", "1", "Org1MSP"},
+		{"setKeyEndorsement", KEY_SCOPE_ADMIN, "2", "Org1MSP"},
+		{"setKeyEndorsement", KEY_SCOPE_ADMIN},
+		{"queryKeyEndorsement", "routes"},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, args...); res.Status == shim.OK {
+			t.Fatalf("%v should be rejected", args)
+		}
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setKeyEndorsement", KEY_SCOPE_ADMIN, "1", "Org1MSP"); res.Status == shim.OK {
+		t.Fatal("non-admin should not set key endorsement")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crossevent"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"oraclelogic/v2.2"
+	"os"
+)
+
+// 结构化日志
+// 链码日志以json行输出到标准输出，由peer收集到链码容器的日志中。每行带交易id，跨链消息相关的日志带traceId，
+// 取sha256(AM报文)的前16字节(hex，见crossevent.TraceID)，与v1/v2事件和中继插件日志中的traceId相同，
+// 可以按traceId串起同一条消息在发送链码、中继和接收链码中的日志。
+// 日志级别通过环境变量CROSSCHAIN_LOG_LEVEL设置(debug/info/warn/error)，默认info。
+var ccLogger = newCCLogger(zapcore.AddSync(os.Stdout), os.Getenv("CROSSCHAIN_LOG_LEVEL"))
+
+func newCCLogger(w zapcore.WriteSyncer, level string) *zap.Logger {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = zapcore.InfoLevel
+	}
+	conf := zap.NewProductionEncoderConfig()
+	conf.EncodeTime = zapcore.ISO8601TimeEncoder
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(conf), w, lvl))
+}
+
+// 带交易id的日志
+func txLogger(stub shim.ChaincodeStubInterface) *zap.Logger {
+	return ccLogger.With(zap.String("txId", stub.GetTxID()))
+}
+
+// AM报文的追踪id
+func packageTraceId(pkg []byte) string {
+	h := sha256.Sum256(pkg)
+	return crossevent.TraceID(h[:])
+}
+
+// 收到的消息的追踪id，不经过AM报文解析的消息以sha256(消息内容)代替
+func recvTraceId(msg oraclelogic.RecvAuthMessage) string {
+	if h, err := hex.DecodeString(msg.PacketHash); err == nil && len(h) > 0 {
+		return crossevent.TraceID(h)
+	}
+	return packageTraceId(msg.Content)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"crossevent"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestTraceIdLogging(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(logger *zap.Logger) { ccLogger = logger }(ccLogger)
+	ccLogger = newCCLogger(zapcore.AddSync(&buf), "info")
+	// 按消息和追踪id查找日志
+	logged := func(msg, traceId string) bool {
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("log line is not json: %s", line)
+			}
+			if entry["msg"] == msg && entry["traceId"] == traceId && entry["txId"] == txid {
+				return true
+			}
+		}
+		return false
+	}
+
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	receiver := sha256.Sum256([]byte("dest"))
+	if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", hex.EncodeToString(receiver[:]), "hello", "n"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var records []outboundEventRecord
+	if err := json.Unmarshal((<-stub.ChaincodeEventsChannel).Payload, &records); err != nil || len(records) != 1 {
+		t.Fatalf("unexpected outbound event: %v", err)
+	}
+	hash := sha256.Sum256(stub.State[records[0].Key])
+	sendTraceId := hex.EncodeToString(hash[:16])
+	if records[0].TraceId != sendTraceId || !logged("message sent", sendTraceId) {
+		t.Fatalf("send trace id %s not found in event or log: %s", sendTraceId, buf.String())
+	}
+
+	// 接收方的追踪id与发送方的计算方式相同
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "traced")
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", pkgs[0])); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkt, _ := hex.DecodeString(pkgs[0])
+	hash = sha256.Sum256(pkt)
+	recvTraceId := crossevent.TraceID(hash[:])
+	events, err := crossevent.Decode((<-stub.ChaincodeEventsChannel).Payload)
+	if err != nil || len(events) != 1 || events[0].TraceId != recvTraceId || !logged("message received", recvTraceId) {
+		t.Fatalf("recv trace id %s not found in event or log: %v %s", recvTraceId, err, buf.String())
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", "zz"); res.Status == shim.OK {
+		t.Fatal("invalid message should be rejected")
+	}
+	if !strings.Contains(buf.String(), `"crosschain invoke failed"`) {
+		t.Fatal("failed invoke should be logged")
+	}
+}
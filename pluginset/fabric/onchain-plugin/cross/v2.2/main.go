@@ -1,20 +1,37 @@
 package main
 
 import (
+	"crosserr"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"go.uber.org/zap"
 	"oraclelogic/v2.2"
+	"os"
 	"strconv"
 	"wrapstub/v2.2"
 )
 
 // 实例化合约
+// 设置了CHAINCODE_SERVER_ADDRESS时以链码即服务方式运行，见ccaas.go
 func main() {
-	if err := shim.Start(NewCrossChain()); err != nil {
+	cc := NewCrossChain()
+	server, err := newChaincodeServer(cc, os.Getenv)
+	if err != nil {
+		fmt.Printf("Error configuring chaincode server: %s", err)
+		os.Exit(1)
+	}
+	if server != nil {
+		if err := server.Start(); err != nil {
+			fmt.Printf("Error starting chaincode server: %s", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := shim.Start(cc); err != nil {
 		fmt.Printf("Error starting Biz chaincode: %s", err)
 	}
 }
@@ -35,17 +52,16 @@ func NewCrossChain() *CrossChain {
 
 // 初始化Init函数
 // 建议在初始化跨链合约时设置oraclelogic的管理员证书
-func (bs *CrossChain) Init(stub shim.ChaincodeStubInterface) pb.Response {
+// 从旧版本升级时在Init中迁移状态：{"Args":["migrate","<fromVersion>"]}，见migration.go
+func (bs *CrossChain) Init(originStub shim.ChaincodeStubInterface) pb.Response {
+	if fn, args := originStub.GetFunctionAndParameters(); fn == "migrate" {
+		return bs.migrate(wrapStub(originStub), args)
+	}
 	return shim.Success([]byte("Init success"))
 }
 
-/*
- * 合约调用
- */
-func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response {
-	fn, args := originStub.GetFunctionAndParameters()
-	fmt.Println("CrossChain Invoked func ", fn)
-	var stub shim.ChaincodeStubInterface
+func wrapStub(originStub shim.ChaincodeStubInterface) shim.ChaincodeStubInterface {
+	stub := originStub
 	cstub, ok := (originStub).(*shim.ChaincodeStub)
 	if ok {
 		stub = wrapstub.NewWrapStub(cstub)
@@ -54,7 +70,48 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	if ok2 {
 		stub = wrapstub.NewMockWrapStub(mstub)
 	}
+	return stub
+}
+
+/*
+ * 合约调用
+ */
+func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := originStub.GetFunctionAndParameters()
+	logger := txLogger(originStub).With(zap.String("fn", fn))
+	logger.Debug("crosschain invoked")
+	stub := wrapStub(originStub)
+	defer clearInvokeHops(originStub)
+
+	// 带桥id的调用进入该桥的命名空间，见bridge.go
+	if bridgeId, bridgeFn := splitBridgeFn(fn); bridgeId != "" {
+		bridgeStub, err := openBridge(stub, bridgeId)
+		if err != nil {
+			return errorResponse(fn, crosserr.FromMessage(crosserr.CodeNotFound, err.Error()))
+		}
+		stub, fn = bridgeStub, bridgeFn
+	}
 
+	if ret := bs.checkGovernedCall(stub, fn, args); ret.Status != shim.OK {
+		return ret
+	}
+	if err := bs.checkPaused(stub, fn); err != nil {
+		return errorResponse(fn, err)
+	}
+	ret := bs.dispatch(stub, fn, args)
+	if ret.Status != shim.OK {
+		logger.Warn("crosschain invoke failed", zap.String("error", ret.Message))
+	}
+	if ret.Status == shim.OK && heightBeaconFunctions[fn] {
+		if err := touchHeightBeacon(stub, fn); err != nil {
+			return errorResponse(fn, crosserr.Wrap(crosserr.CodeLedger, err, "failed to update height beacon"))
+		}
+	}
+	return ret
+}
+
+// 按函数名分发调用，多管理员审批通过的操作也从这里执行
+func (bs *CrossChain) dispatch(stub shim.ChaincodeStubInterface, fn string, args []string) pb.Response {
 	switch fn {
 
 	// 初始化函数，目前暂无用途
@@ -73,13 +130,16 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 			fmt.Printf("Set Oracle Admin failed %s\n", ret.Message)
 			return ret
 		}
+		if err := bs.protectKeys(stub, KEY_SCOPE_ADMIN, oraclelogic.K_ADMIN_CERT); err != nil {
+			return errorResponse("setAdmin", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect admin key"))
+		}
 		return shim.Success(nil)
 
 	// 设置domain parser。
 	// parser应该是product的枚举值，比如fabric_14，不同parser对应于不同的函数。
 	case "setDomainParser":
 		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
-			return shim.Error("[setDomainParser] " + ret.Message)
+			return errorResponse("setDomainParser", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
 		}
 		return bs.setDomainParser(stub, args)
 
@@ -88,13 +148,45 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	// args[1] 目的地账号(必选)，byte32 hexstring
 	// args[2] 消息内容(必选), string
 	// args[3] 消息nounce(可选)，区分同一笔交易内发送多个消息, string
+	// args[4] 有效期(可选)，秒，0表示不过期，不指定时按setMessageExpiry的默认值，见expiry.go
 	case "sendMessage":
 		re := bs.sendMessage(stub, args, oraclelogic.K_MSG_TYPE_ORDERED)
 		if re.Status != shim.OK {
-			return shim.Error("[sendMessage] " + re.Message)
+			return errorResponse("sendMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		if err := bs.emitOutboundEvent(stub, re.Payload); err != nil {
+			return errorResponse("sendMessage", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
 		}
 		return re
 
+	// 链码发送已登记的上层协议的消息，见protocol.go
+	// args[0] 协议类型
+	// args[1] 目的地的域名
+	// args[2] 协议消息，hexstring
+	// args[3] nounce(可选)
+	case "sendAuthMessage":
+		re := bs.sendAuthMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("sendAuthMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		if err := bs.emitOutboundEvent(stub, re.Payload); err != nil {
+			return errorResponse("sendAuthMessage", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
+		}
+		return re
+
+	// 登记上层协议的协议链码，协议类型0为SDP，不能登记
+	// args[0] 协议类型
+	// args[1] 协议链码名，为空时删除登记
+	case "setProtocol":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setProtocol", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setProtocol(stub, args)
+
+	// 查询已登记的上层协议
+	case "queryProtocols":
+		return bs.queryProtocols(stub, args)
+
 	// 客户链码 invoke 跨链链码发送「无序」消息
 	// args[0] 目的地的域名(必选)
 	// args[1] 目的地账号(必选)，byte32 hexstring
@@ -103,7 +195,10 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	case "sendUnorderedMessage":
 		re := bs.sendMessage(stub, args, oraclelogic.K_MSG_TYPE_UNORDERED)
 		if re.Status != shim.OK {
-			return shim.Error("[sendUnorderedMessage] " + re.Message)
+			return errorResponse("sendUnorderedMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		if err := bs.emitOutboundEvent(stub, re.Payload); err != nil {
+			return errorResponse("sendUnorderedMessage", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
 		}
 		return re
 
@@ -114,34 +209,1236 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 	case "batchSendUnorderedMessage":
 		if len(args) < 3 {
 			fmt.Println("Unexpected args len")
-			return shim.Error("Unexpected args len")
+			return errorResponse("batchSendUnorderedMessage", crosserr.New(crosserr.CodeInvalidArgs, "Unexpected args len: %d", len(args)))
 		}
+		var outbound [][]byte
 		for i := 2; i < len(args); i++ {
 			nounce := "nounce" + strconv.Itoa(i)
 			newargs := []string{args[0], args[1], args[i], nounce}
 			re := bs.sendMessage(stub, newargs, oraclelogic.K_MSG_TYPE_UNORDERED)
 			if re.Status != shim.OK {
-				return shim.Error("[batchSendUnorderedMessage] " + re.Message)
+				return errorResponse("batchSendUnorderedMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
 			}
+			outbound = append(outbound, re.Payload)
+		}
+		if err := bs.emitOutboundEvent(stub, outbound...); err != nil {
+			return errorResponse("batchSendUnorderedMessage", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
 		}
 		return shim.Success([]byte("success"))
 
+	// 客户链码 invoke 跨链链码发送原子请求，接收方的响应随回执返回，见request_response.go
+	// args[0] 目的地的域名(必选)
+	// args[1] 目的地账号(必选)，byte32 hexstring
+	// args[2] 消息内容(必选), string
+	// args[3] 消息nounce(可选)，区分同一笔交易内发送多个请求, string
+	// args[4] 回调函数(可选)，收到回执后回调请求方
+	case "sendMessageWithResponse":
+		outbound, request, err := bs.sendMessageWithResponse(stub, args)
+		if err != nil {
+			return errorResponse("sendMessageWithResponse", crosserr.Wrap(crosserr.CodeInternal, err, "failed to send request"))
+		}
+		if err := bs.emitOutboundEvent(stub, outbound); err != nil {
+			return errorResponse("sendMessageWithResponse", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
+		}
+		raw, _ := json.Marshal(request)
+		return shim.Success(raw)
+
+	// 客户链码 invoke 跨链链码发送超过长度限制的「有序」消息，拆成分片发送，见chunk.go
+	// args[0] 目的地的域名(必选)
+	// args[1] 目的地账号(必选)，byte32 hexstring
+	// args[2] 消息内容(必选), string
+	// args[3] 消息nounce(可选)，区分同一笔交易内发送多个分片消息, string
+	// args[4] 分片长度(可选)
+	case "sendChunkedMessage":
+		outbound, chunked, err := bs.sendChunkedMessage(stub, args)
+		if err != nil {
+			return errorResponse("sendChunkedMessage", crosserr.FromMessage(crosserr.CodeInternal, err.Error()))
+		}
+		if err := bs.emitOutboundEvent(stub, outbound...); err != nil {
+			return errorResponse("sendChunkedMessage", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit outbound event"))
+		}
+		raw, _ := json.Marshal(chunked)
+		return shim.Success(raw)
+
 	// 跨链服务上传跨链消息的接口
 	case "recvMessage":
-		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
-			return shim.Error("[recvMessage] " + ret.Message)
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 跨链服务批量上传跨链消息的接口
+	// args[0] oracle service id
+	// args[1] 报文(json数组)，每个元素为一条报文的rawdata(hex)
+	case "recvBatchMessages":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvBatchMessages", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvBatchMessages", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvBatchMessages(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvBatchMessages", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
 		}
-		return bs.recvMessage(stub, args)
+		return re
 
 	// 跨链服务管理接口
 	case "oracleAdminManage":
 		fmt.Printf("go to oracleAdminManage\n")
 		ret := bs.Os.AdminManage(stub, args[0], args[1:])
+		if ret.Status != shim.OK || len(args) < 2 {
+			return ret
+		}
+		// 本链域名和sha256原像表属于协议注册表，见key_endorsement.go
+		var err error
+		switch args[0] {
+		case "setExpectedDomain":
+			err = bs.protectKeys(stub, KEY_SCOPE_REGISTRY, oraclelogic.K_EXPECTED_DOMAIN)
+		case "registerSha256Invert":
+			err = bs.protectKeys(stub, KEY_SCOPE_REGISTRY, sha256InvertKey(args[1]))
+		}
+		if err != nil {
+			return errorResponse("oracleAdminManage", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect registry key"))
+		}
 		return ret
 
+	// 设置中继者保证金模块
+	// args[0] 是否开启, true/false
+	// args[1] 最低有效保证金
+	case "setBondConfig":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setBondConfig", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setBondConfig(stub, args)
+
+	// 中继者登记保证金
+	// args[0] 保证金数额
+	// args[1] 链下托管凭证
+	case "postBond":
+		re := bs.postBond(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("postBond", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 管理员确认中继者保证金
+	// args[0] 中继者证书sha256(hex)
+	case "confirmBond":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("confirmBond", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.confirmBond(stub, args)
+
+	// 管理员拒绝中继者待确认的保证金
+	// args[0] 中继者证书sha256(hex)
+	case "rejectBond":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("rejectBond", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.rejectBond(stub, args)
+
+	// 管理员罚没中继者保证金
+	// args[0] 中继者证书sha256(hex)
+	// args[1] 罚没数额
+	// args[2] 罚没原因
+	// args[3] 作恶证据(hex)
+	case "slashBond":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("slashBond", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.slashBond(stub, args)
+
+	// 管理员释放中继者保证金
+	// args[0] 中继者证书sha256(hex)
+	case "withdrawBond":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("withdrawBond", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.withdrawBond(stub, args)
+
+	// 查询中继者保证金
+	// args[0] 中继者证书sha256(hex)
+	case "queryBond":
+		return bs.queryBond(stub, args)
+
+	// 设置来源域名的争议窗口
+	// args[0] 来源域名
+	// args[1] 窗口长度(秒)，0表示关闭
+	case "setDisputeWindow":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setDisputeWindow", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setDisputeWindow(stub, args)
+
+	// 授权或取消挑战者
+	// args[0] 挑战者证书sha256(hex)
+	// args[1] true/false
+	case "setChallenger":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setChallenger", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setChallenger(stub, args)
+
+	// 挑战者冻结争议窗口中的消息
+	// args[0] 消息id
+	// args[1] 挑战原因
+	case "challengeMessage":
+		re := bs.challengeMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("challengeMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 管理员裁决被冻结的消息
+	// args[0] 消息id
+	// args[1] true放行/false拒绝
+	case "resolveDispute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("resolveDispute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.resolveDispute(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("resolveDispute", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 争议窗口结束后投递消息
+	// args[0] 消息id
+	case "finalizeMessage":
+		re := bs.finalizeMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("finalizeMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 查询争议窗口中的消息
+	// args[0] 消息id
+	case "queryDisputedMessage":
+		return bs.queryDisputedMessage(stub, args)
+
+	// 按页查询尚未投递的争议消息
+	// args[0] 每页条数(可选)
+	// args[1] 书签(可选)
+	case "queryPendingDisputes":
+		return bs.queryPendingDisputes(stub, args)
+
+	// 设置来源域名的乐观验证模式
+	// args[0] 来源域名
+	// args[1] 挑战窗口长度(秒)，0表示关闭
+	case "setOptimisticMode":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setOptimisticMode", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setOptimisticMode(stub, args)
+
+	// 跨链服务以乐观模式提交跨链消息
+	// args[0] oracle service id
+	// args[1] 来源域名
+	// args[2] AM报文(hex)
+	// args[3] 证明(hex)
+	// args[4] 证明提示信息
+	case "recvOptimisticMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvOptimisticMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvOptimisticMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvOptimisticMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvOptimisticMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 对乐观模式提交的消息提交欺诈证明
+	// args[0] 声明id
+	// args[1] 欺诈证明(hex)
+	case "submitFraudProof":
+		re := bs.submitFraudProof(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("submitFraudProof", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 挑战窗口结束后确认乐观模式提交的消息
+	// args[0] 声明id
+	case "finalizeOptimisticMessage":
+		re := bs.finalizeOptimisticMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("finalizeOptimisticMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 查询乐观模式提交的消息
+	// args[0] 声明id
+	case "queryOptimisticClaim":
+		return bs.queryOptimisticClaim(stub, args)
+
+	// 设置来源域名的zk路由
+	// args[0] 来源域名
+	// args[1] 证明系统，为空表示删除路由
+	// args[2] 验证密钥(hex)
+	case "setZKRoute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setZKRoute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setZKRoute(stub, args)
+
+	// 跨链服务上传附带zk有效性证明的跨链消息
+	// args[0] 来源域名
+	// args[1] AM报文(hex)
+	// args[2] zk证明(hex)
+	case "recvZKMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvZKMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvZKMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvZKMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvZKMessage", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 中继提交批量报文的默克尔根及zk证明
+	// args[0] 来源域名
+	// args[1] 默克尔根(hex)
+	// args[2] 批量大小
+	// args[3] zk证明(hex)
+	case "submitZKBatch":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("submitZKBatch", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("submitZKBatch", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.submitZKBatch(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("submitZKBatch", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 中继提交已校验批量中的报文及包含路径
+	// args[0] 来源域名
+	// args[1] 默克尔根(hex)
+	// args[2] 报文及包含路径(json数组)
+	case "recvZKBatchMessages":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvZKBatchMessages", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvZKBatchMessages", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvZKBatchMessages(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvZKBatchMessages", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 初始化来源域名的以太坊轻客户端
+	// args[0] 来源域名
+	// args[1] genesis_validators_root(hex)
+	// args[2] 信标链创世时间(秒)
+	// args[3] fork version(hex)
+	// args[4] 可信的信标区块根(hex)
+	// args[5] LightClientBootstrap(json)
+	case "initEthLightClient":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("initEthLightClient", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.initEthLightClient(stub, args)
+
+	// 更新以太坊fork version
+	// args[0] 来源域名
+	// args[1] fork version(hex)
+	case "setEthForkVersion":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setEthForkVersion", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setEthForkVersion(stub, args)
+
+	// 设置以太坊AM合约
+	// args[0] 来源域名
+	// args[1] AM合约地址(hex)
+	// args[2] 事件topic0(hex)
+	case "setEthAMContract":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setEthAMContract", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setEthAMContract(stub, args)
+
+	// 中继提交以太坊同步委员会更新
+	// args[0] 来源域名
+	// args[1] LightClientUpdate(json)
+	case "submitEthUpdate":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("submitEthUpdate", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("submitEthUpdate", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.submitEthUpdate(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("submitEthUpdate", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 中继提交以太坊跨链消息及回执证明
+	// args[0] 来源域名
+	// args[1] 区块高度
+	// args[2] 交易序号
+	// args[3] 回执MPT证明(json)
+	// args[4] 事件序号
+	case "recvEthMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvEthMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvEthMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvEthMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvEthMessage", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 查询以太坊轻客户端状态
+	// args[0] 来源域名
+	case "queryEthLightClient":
+		return bs.queryEthLightClient(stub, args)
+
+	// 初始化来源域名的Tendermint轻客户端
+	// args[0] 来源域名
+	// args[1] chain id
+	// args[2] 信任期(秒)
+	// args[3] 可信区块头(json)
+	// args[4] 下一个验证人集合(json)
+	case "initTMLightClient":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("initTMLightClient", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.initTMLightClient(stub, args)
+
+	// 设置Cosmos SDK链AM模块的存储位置
+	// args[0] 来源域名
+	// args[1] 存储名称
+	// args[2] key前缀(hex)
+	case "setTMAMStore":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setTMAMStore", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setTMAMStore(stub, args)
+
+	// 中继提交Tendermint区块头
+	// args[0] 来源域名
+	// args[1] 区块头及commit(json)
+	// args[2] 验证人集合(json)
+	// args[3] 下一个验证人集合(json)
+	case "submitTMHeader":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("submitTMHeader", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("submitTMHeader", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.submitTMHeader(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("submitTMHeader", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 中继提交Cosmos SDK链的跨链消息及ICS-23证明
+	// args[0] 来源域名
+	// args[1] 区块高度
+	// args[2] 存储key(hex)
+	// args[3] AM报文(hex)
+	// args[4] ICS-23证明(json)
+	case "recvTMMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvTMMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvTMMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvTMMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvTMMessage", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 查询Tendermint轻客户端状态
+	// args[0] 来源域名
+	case "queryTMLightClient":
+		return bs.queryTMLightClient(stub, args)
+
+	// 初始化来源域名的比特币SPV
+	// args[0] 来源域名
+	// args[1] 网络(mainnet/regtest)
+	// args[2] 检查点高度
+	// args[3] 检查点区块头(hex)
+	// args[4] 锚定公钥(hex)
+	case "initBTCSPV":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("initBTCSPV", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.initBTCSPV(stub, args)
+
+	// 设置比特币消息需要的确认数
+	// args[0] 来源域名
+	// args[1] 确认数
+	case "setBTCConfirmations":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setBTCConfirmations", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setBTCConfirmations(stub, args)
+
+	// 中继提交比特币区块头
+	// args[0] 来源域名
+	// args[1] 区块头(hex)，多个区块头直接拼接
+	case "submitBTCHeaders":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("submitBTCHeaders", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("submitBTCHeaders", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.submitBTCHeaders(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("submitBTCHeaders", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 中继提交比特币锚定的跨链消息及SPV证明
+	// args[0] 来源域名
+	// args[1] 区块哈希
+	// args[2] 锚定交易(hex)
+	// args[3] 交易序号
+	// args[4] 默克尔证明(json)
+	// args[5] AM报文(hex)
+	case "recvBTCMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvBTCMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvBTCMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvBTCMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvBTCMessage", crosserr.FromMessage(crosserr.CodeVerify, re.Message))
+		}
+		return re
+
+	// 查询比特币SPV状态
+	// args[0] 来源域名
+	case "queryBTCSPV":
+		return bs.queryBTCSPV(stub, args)
+
+	// 登记或轮换来源域名的PTC委员会
+	// args[0] 来源域名
+	// args[1] 委员会id
+	// args[2] 签名门限
+	// args[3] 节点列表(json)
+	case "setPTCCommittee":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setPTCCommittee", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setPTCCommittee(stub, args)
+
+	// 删除来源域名的PTC委员会
+	// args[0] 来源域名
+	case "removePTCCommittee":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("removePTCCommittee", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.removePTCCommittee(stub, args)
+
+	// 查询来源域名的PTC委员会
+	// args[0] 来源域名
+	case "queryPTCCommittee":
+		return bs.queryPTCCommittee(stub, args)
+
+	// 中继提交PTC委员会背书的跨链消息
+	// args[0] 来源域名
+	// args[1] AM报文(hex)
+	// args[2] 委员会背书(hex)
+	case "recvPTCMessage":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("recvPTCMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("recvPTCMessage", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		re := bs.recvPTCMessage(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("recvPTCMessage", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 设置轻客户端区块头保留数量
+	// args[0] 来源域名
+	// args[1] 保留最近多少个高度内的区块头，0表示不裁剪
+	case "setHeaderRetention":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setHeaderRetention", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setHeaderRetention(stub, args)
+
+	// 查询区块头同步状态
+	// args[0] 来源域名
+	case "queryHeaderSync":
+		return bs.queryHeaderSync(stub, args)
+
+	// 查询验证人集合/同步委员会的轮换记录
+	// args[0] 来源域名
+	// args[1] 轮换序号
+	case "queryValidatorRotation":
+		return bs.queryValidatorRotation(stub, args)
+
+	// 登记链码级背书策略
+	// args[0] 至少需要多少个组织背书
+	// args[1..] 组织MSP ID
+	case "setEndorsementPolicy":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setEndorsementPolicy", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setEndorsementPolicy(stub, args)
+
+	// 查询跨链写操作的背书要求
+	// args[0..] 可选，要写入的state key
+	case "queryEndorsementPolicy":
+		return bs.queryEndorsementPolicy(stub, args)
+
+	// 设置关键状态的key级背书策略，见key_endorsement.go
+	// args[0] 范围，admin/registry/committee
+	// args[1] 至少需要多少个组织背书，0表示删除策略
+	// args[2..] 组织MSP ID
+	case "setKeyEndorsement":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setKeyEndorsement", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setKeyEndorsement(stub, args)
+
+	// 查询关键状态的key级背书策略
+	// args[0] 范围，admin/registry/committee
+	case "queryKeyEndorsement":
+		return bs.queryKeyEndorsement(stub, args)
+
+	// 配置来源域名的隐私路由
+	// args[0] 来源域名
+	// args[1] 私有数据集合名，空字符串表示取消隐私路由
+	// args[2] 至少需要多少个组织背书
+	// args[3..] 组织MSP ID
+	case "setPrivateRoute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setPrivateRoute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setPrivateRoute(stub, args)
+
+	// 查询来源域名的隐私路由
+	// args[0] 来源域名
+	case "queryPrivateRoute":
+		return bs.queryPrivateRoute(stub, args)
+
+	// 配置保存授权与路由表的私有数据集合
+	// args[0] 集合名，空字符串表示使用公共状态
+	case "setRegistryCollection":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRegistryCollection", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRegistryCollection(stub, args)
+
+	// 登记接收消息的业务链码，配置了私有数据集合时登记表保存在集合中
+	// args[0] 业务链码名
+	case "registerReceiver":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("registerReceiver", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.registerReceiver(stub, args)
+
+	// 为来源域名添加接收消息的业务链码路由
+	// args[0] 来源域名
+	// args[1] 业务链码名
+	case "setRoute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRoute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRoute(stub, args)
+
+	// 删除路由
+	// args[0] 来源域名
+	// args[1] 业务链码名
+	case "deleteRoute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("deleteRoute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.deleteRoute(stub, args)
+
+	// 按页查询路由
+	// args[0] 来源域名，空字符串表示全部域名
+	// args[1] 每页条数(可选)
+	// args[2] 书签(可选)
+	case "queryRoutes":
+		return bs.queryRoutes(stub, args)
+
+	// 登记业务链码所在的通道，消息跨通道投递
+	// args[0] 业务链码名
+	// args[1] 通道名，空字符串表示取消登记
+	case "setReceiverChannel":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setReceiverChannel", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setReceiverChannel(stub, args)
+
+	// 查询业务链码所在的通道
+	// args[0] 业务链码名
+	case "queryReceiverChannel":
+		return bs.queryReceiverChannel(stub, args)
+
+	// 配置业务链码的机密消息集合，消息内容写入集合，公共状态只保存摘要
+	// args[0] 业务链码名
+	// args[1] 私有数据集合名，空字符串表示取消
+	case "setReceiverCollection":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setReceiverCollection", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setReceiverCollection(stub, args)
+
+	// 查询机密消息的投递记录
+	// args[0] sha256(消息内容)(hex)
+	case "queryPayload":
+		return bs.queryPayload(stub, args)
+
+	// 设置跨链事件版本，v2事件格式见crossevent包
+	// args[0] 1或2
+	case "setEventVersion":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setEventVersion", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setEventVersion(stub, args)
+
+	// 查询跨链事件版本
+	case "queryEventVersion":
+		return bs.queryEventVersion(stub, args)
+
+	// 设置目的域名的跨链手续费，每条消息从交易提交者的账户扣除
+	// args[0] 目的域名
+	// args[1] 每条消息的手续费，0表示不收费
+	case "setFeeSchedule":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setFeeSchedule", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setFeeSchedule(stub, args)
+
+	// 为手续费账户充值
+	// args[0] 提交者证书sha256(hex)
+	// args[1] 充值数额
+	case "depositFee":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("depositFee", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.depositFee(stub, args)
+
+	// 跨链桥运营方从手续费池提取
+	// args[0] 提取数额
+	// args[1] 链下结算凭证
+	case "withdrawFee":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("withdrawFee", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.withdrawFee(stub, args)
+
+	// 查询手续费账户
+	// args[0] 提交者证书sha256(hex)
+	case "queryFeeAccount":
+		return bs.queryFeeAccount(stub, args)
+
+	// 查询目的域名的手续费
+	// args[0] 目的域名
+	case "queryFeeSchedule":
+		return bs.queryFeeSchedule(stub, args)
+
+	// 查询手续费池
+	case "queryFeePool":
+		return bs.queryFeePool(stub, args)
+
+	// 设置有序消息的默认有效期
+	// args[0] 有效期(秒)，0表示不过期
+	case "setMessageExpiry":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setMessageExpiry", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setMessageExpiry(stub, args)
+
+	// 查询有序消息的默认有效期
+	case "queryMessageExpiry":
+		return bs.queryMessageExpiry(stub, args)
+
+	// 发送方业务链码取消自己已过期且未被转发的消息，回退序号并退还手续费
+	// args[0] 消息key
+	case "cancelMessage":
+		return bs.cancelMessage(stub, args)
+
+	// 管理员批量取消发往目的域名的过期消息
+	// args[0] 目的域名
+	// args[1] 最多取消的条数(可选)
+	case "cleanupExpired":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("cleanupExpired", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.cleanupExpired(stub, args)
+
+	// 查询发往目的域名、设置了有效期的待转发消息
+	// args[0] 目的域名
+	// args[1] 每页条数(可选)
+	// args[2] 书签(可选)
+	case "queryPending":
+		return bs.queryPending(stub, args)
+
+	// 设置来源域名的限流
+	// args[0] 来源域名
+	// args[1] 每个窗口最多接收的消息条数，0表示取消限流
+	// args[2] 窗口长度(秒)
+	case "setRateLimit":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRateLimit", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRateLimit(stub, args)
+
+	// 查询来源域名的限流及当前窗口的计数
+	// args[0] 来源域名
+	case "queryRateLimit":
+		return bs.queryRateLimit(stub, args)
+
+	// 设置接收消息的执行限制，两项都为0时取消限制
+	// args[0] 消息内容最大字节数，0表示不限制
+	// args[1] 一笔交易中回调业务链码的最多次数，0表示不限制
+	case "setExecLimit":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setExecLimit", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setExecLimit(stub, args)
+
+	// 查询接收消息的执行限制
+	case "queryExecLimit":
+		return bs.queryExecLimit(stub, args)
+
+	// 管理员或接收消息的业务链码擦除已投递的消息内容，保留摘要回执
+	// args[0] 类型: dispute/optimistic/private
+	// args[1] 消息id
+	// args[2] 来源域名，仅private需要
+	case "erasePayload":
+		re := bs.erasePayload(stub, args)
+		if re.Status != shim.OK {
+			return errorResponse("erasePayload", crosserr.FromMessage(crosserr.CodeInternal, re.Message))
+		}
+		return re
+
+	// 查询擦除回执
+	// args[0] 类型
+	// args[1] 消息id
+	case "queryEraseReceipt":
+		return bs.queryEraseReceipt(stub, args)
+
+	// 设置来源域名的消息记录保留策略
+	// args[0] 来源域名
+	// args[1] 保留时长(秒)，0表示不按时长删除
+	// args[2] 保留数量，0表示不按数量删除
+	case "setRetentionPolicy":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRetentionPolicy", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRetentionPolicy(stub, args)
+
+	// 查询来源域名的消息记录保留策略
+	// args[0] 来源域名
+	case "queryRetentionPolicy":
+		return bs.queryRetentionPolicy(stub, args)
+
+	// 导出跨链状态的审计快照及其承诺哈希
+	case "exportSnapshot":
+		return bs.exportSnapshot(stub, args)
+
+	// 读取发送消息记录及其承诺，用于核对发送事件
+	// args[0] 消息记录的key
+	case "queryOutboundMessage":
+		return bs.queryOutboundMessage(stub, args)
+
+	// 中继重建时按页查询发往目的域名、不小于指定序号的消息
+	// args[0] 目的域名
+	// args[1] 起始序号
+	// args[2] 每页条数(可选)
+	// args[3] 书签(可选)
+	case "queryOutboundBacklog":
+		return bs.queryOutboundBacklog(stub, args)
+
+	// 设置或轮换本链域名证书，窗口内新旧证书都被接受
+	// args[0] 新证书(PEM)
+	// args[1] 旧证书继续有效的时长(秒)，首次设置时不需要
+	case "rotateDomainCert":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("rotateDomainCert", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.rotateDomainCert(stub, args)
+
+	// 不带参数时查询当前被接受的本链域名证书，带域名时查询登记的对端域名
+	// args[0] 对端域名(可选)
+	case "queryDomainCert":
+		if len(args) == 1 {
+			return bs.queryDomainRecord(stub, args)
+		}
+		return bs.queryDomainCert(stub, args)
+
+	// 登记对端域名的BCDNS域名证书和所有者
+	// args[0] 域名
+	// args[1] 域名证书(PEM)
+	// args[2] 所有者证书sha256(hex)
+	// args[3..] 签发域名证书的域名空间证书(PEM，可选)
+	case "registerDomain":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("registerDomain", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.registerDomain(stub, args)
+
+	// 更新对端域名证书，管理员或所有者调用
+	// args[0] 域名
+	// args[1] 新证书(PEM)
+	// args[2..] 签发新证书的域名空间证书(PEM，可选)
+	case "updateDomainCert":
+		return bs.updateDomainCert(stub, args)
+
+	// 设置BCDNS信任根证书，设置后收消息时校验来源域名的PTC证书链
+	// args[0] 信任根证书(PEM)
+	case "setBCDNSTrustRoot":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setBCDNSTrustRoot", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setBCDNSTrustRoot(stub, args)
+
+	// 查询BCDNS信任根证书
+	case "queryBCDNSTrustRoot":
+		return bs.queryBCDNSTrustRoot(stub, args)
+
+	// 登记为来源域名出具证明的PTC证书
+	// args[0] 来源域名
+	// args[1] PTC证书(PEM)
+	case "setDomainPTCCert":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setDomainPTCCert", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setDomainPTCCert(stub, args)
+
+	// 查询来源域名的PTC证书
+	// args[0] 来源域名
+	case "queryDomainPTCCert":
+		return bs.queryDomainPTCCert(stub, args)
+
+	// 检查证书当前是否被接受
+	// args[0] 证书sha256(hex)
+	case "checkDomainCert":
+		return bs.checkDomainCert(stub, args)
+
+	// 查询通道序号和回执的修改时间线，用于事故取证
+	// args[0] recv/send
+	// args[1] 来源域名(recv)或目的域名(send)
+	// args[2] 发送方身份, byte32 hexstring
+	// args[3] 接收方身份, byte32 hexstring
+	// args[4] 每页条数
+	// args[5] 书签，第一页为空字符串
+	// args[6..] 回执的key(可选)
+	case "querySeqTimeline":
+		return bs.querySeqTimeline(stub, args)
+
+	// 打开或关闭调试追踪
+	// args[0] on/off
+	// args[1] 缓冲区容量(仅on)
+	// args[2] 有效区块数(仅on)
+	// args[3] 出块间隔(秒)(仅on)
+	case "setDebugTrace":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setDebugTrace", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setDebugTrace(stub, args)
+
+	// 查询调试追踪配置和记录
+	case "queryDebugTrace":
+		if ret := bs.checkRole(stub, ROLE_AUDITOR); ret.Status != shim.OK {
+			return errorResponse("queryDebugTrace", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.queryDebugTrace(stub, args)
+
+	// 设置节点装饰策略
+	// args[0] DecorationPolicy, json
+	case "setDecorationPolicy":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setDecorationPolicy", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setDecorationPolicy(stub, args)
+
+	// 查询节点装饰策略
+	case "queryDecorationPolicy":
+		return bs.queryDecorationPolicy(stub, args)
+
+	// 设置中继者令牌开关
+	// args[0] true/false
+	// args[1] 令牌最长有效期(秒)
+	case "setRelayerTokenConfig":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRelayerTokenConfig", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRelayerTokenConfig(stub, args)
+
+	// 登记中继者签发令牌的公钥
+	// args[0] 中继者证书sha256(hex)
+	// args[1] 公钥(PEM)
+	case "setRelayerTokenKey":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRelayerTokenKey", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRelayerTokenKey(stub, args)
+
+	// 查询中继者登记的令牌公钥
+	// args[0] 中继者证书sha256(hex)
+	case "queryRelayerTokenKey":
+		return bs.queryRelayerTokenKey(stub, args)
+
+	// 开启或关闭中继者白名单
+	// args[0] true/false
+	case "setRelayerACLConfig":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRelayerACLConfig", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRelayerACLConfig(stub, args)
+
+	// 加入或移出中继者白名单
+	// args[0] msp/cert
+	// args[1] MSP ID或中继者证书sha256(hex)
+	// args[2] true/false
+	case "setRelayerACL":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRelayerACL", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRelayerACL(stub, args)
+
+	// 查询中继者白名单表项
+	// args[0] msp/cert
+	// args[1] MSP ID或中继者证书sha256(hex)
+	case "queryRelayerACL":
+		return bs.queryRelayerACL(stub, args)
+
+	// 创建逻辑跨链桥，见bridge.go
+	// args[0] 桥id
+	// args[1] 桥管理员证书，x509公钥证书
+	case "createBridge":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("createBridge", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if _, ok := stub.(*bridgeStub); ok {
+			return errorResponse("createBridge", crosserr.New(crosserr.CodeInvalidArgs, "bridge can only be created in the default bridge"))
+		}
+		return bs.createBridge(stub, args)
+
+	// 没有跨链消息时更新高度信标，见height_beacon.go
+	case "touch":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("touch", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return shim.Success(nil)
+
+	// 查询高度信标
+	case "queryHeightBeacon":
+		return bs.queryHeightBeacon(stub, args)
+
+	// 查询逻辑跨链桥
+	// args[0] 桥id(可选)
+	case "queryBridges":
+		return bs.queryBridges(stub, args)
+
+	// 设置按证书属性认定角色的规则，见role.go
+	// args[0] 角色，admin/relayer/auditor
+	// args[1] 组织MSP ID
+	// args[2] attr/ou，none表示删除该组织的规则
+	// args[3] 属性名或OU
+	// args[4] 属性值，按OU认定时不填
+	case "setRoleAttribute":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRoleAttribute", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRoleAttribute(stub, args)
+
+	// 查询角色的认定规则
+	// args[0] 角色(可选)
+	case "queryRoleAttributes":
+		return bs.queryRoleAttributes(stub, args)
+
+	// 查询交易发起者具有的角色
+	case "queryMyRoles":
+		return bs.queryMyRoles(stub, args)
+
+	// 设置治理管理员，开启治理后需要审批
+	// args[0] 治理管理员证书sha256(json数组)，空数组表示关闭治理
+	// args[1] 批准门限
+	// args[2] 提案有效期(秒)
+	case "setGovernance":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setGovernance", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setGovernance(stub, args)
+
+	// 治理管理员发起需要审批的操作，返回提案id
+	// args[0] 函数名
+	// args[1..] 函数参数
+	case "proposeAdminAction":
+		return bs.proposeAdminAction(stub, args)
+
+	// 治理管理员批准提案
+	// args[0] 提案id
+	case "approveAdminAction":
+		return bs.approveAdminAction(stub, args)
+
+	// 执行批准数达到门限的提案，执行者需要满足被调用函数自身的权限
+	// args[0] 提案id
+	case "executeAdminAction":
+		return bs.executeAdminAction(stub, args)
+
+	// 查询提案
+	// args[0] 提案id
+	case "queryAdminProposal":
+		return bs.queryAdminProposal(stub, args)
+
+	// 查询治理配置
+	case "queryGovernance":
+		return bs.queryGovernance(stub, args)
+
+	// 暂停跨链消息收发
+	// args[0] 暂停原因(可选)
+	case "pause":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("pause", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.pause(stub, args)
+
+	// 恢复跨链消息收发，开启治理后需要审批
+	case "unpause":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("unpause", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.unpause(stub, args)
+
+	// 查询暂停状态
+	case "queryPauseState":
+		return bs.queryPauseState(stub, args)
+
+	// 设置可靠中继队列
+	// args[0] true/false
+	// args[1] 认领超时时间(秒)
+	case "setRelayConfig":
+		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+			return errorResponse("setRelayConfig", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.setRelayConfig(stub, args)
+
+	// 按页查询待转发的消息
+	// args[0] 目的域名
+	// args[1] 每页条数(可选)
+	// args[2] 书签(可选)
+	case "queryRelayQueue":
+		return bs.queryRelayQueue(stub, args)
+
+	// 中继认领待转发的消息
+	// args[0..] 消息key
+	case "confirmRelay":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("confirmRelay", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("confirmRelay", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.confirmRelay(stub, args)
+
+	// 中继确认消息已在目的链上链
+	// args[0..] 消息key
+	case "ackRelay":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("ackRelay", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		if ret := bs.checkRelayer(stub); ret.Status != shim.OK {
+			return errorResponse("ackRelay", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.ackRelay(stub, args)
+
+	// 查询SDP通道已投递的序号
+	// args[0] 来源域名
+	// args[1] 发送方身份, byte32 hexstring
+	// args[2] 接收方身份, byte32 hexstring
+	case "querySDPSeq":
+		return bs.querySDPSeq(stub, args)
+
+	// 查询原子请求的回执状态
+	// args[0] SDP v2消息id(hex)
+	case "queryAckStatus":
+		return bs.queryAckStatus(stub, args)
+
+	// 查询暂存中的分片组
+	// args[0] 来源域名
+	// args[1] 发送方身份, byte32 hexstring
+	// args[2] 分片组id
+	case "queryChunkAssembly":
+		return bs.queryChunkAssembly(stub, args)
+
+	// 查询sendMessageWithResponse发出的请求及其响应
+	// args[0] SDP v2消息id(hex)
+	case "queryPendingRequest":
+		return bs.queryPendingRequest(stub, args)
+
+	// 保存调用者的分页扫描书签
+	// args[0] 扫描名
+	// args[1] 书签，空字符串表示清除
+	case "saveScanBookmark":
+		if ret := bs.checkRole(stub, ROLE_RELAYER); ret.Status != shim.OK {
+			return errorResponse("saveScanBookmark", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+		}
+		return bs.saveScanBookmark(stub, args)
+
+	// 查询调用者保存的分页扫描书签
+	// args[0] 扫描名
+	case "queryScanBookmark":
+		return bs.queryScanBookmark(stub, args)
+
 	//////////////////////////////////////////////////////////////////////////////
 	//////////////////////////////////////////////////////////////////////////////
 
+	// 查询状态的schema版本
+	case "querySchemaVersion":
+		return bs.querySchemaVersion(stub, args)
+
+	// 查询接收回执
+	// args[0] 来源域名
+	// args[1] sha256(AM报文)(hex)
+	case "queryReceipt":
+		return bs.queryReceipt(stub, args)
+
+	// 富查询消息记录，仅CouchDB，见message_record.go
+	// args[0] CouchDB查询或selector
+	// args[1] 每页条数(可选)
+	// args[2] 书签(可选)
+	case "queryMessages":
+		return bs.queryMessages(stub, args)
+
+	// 查询消息记录的修改历史，需要节点开启历史库
+	// args[0] 发送消息的key，或接收消息的来源域名
+	// args[1] 接收消息的sha256(AM报文)(hex)，查询发送消息时不填
+	case "queryMessageHistory":
+		return bs.queryMessageHistory(stub, args)
+
 	// 测试回调biz链码
 	case "testCallbackBizChaincode":
 		if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
@@ -150,24 +1447,24 @@ func (bs *CrossChain) Invoke(originStub shim.ChaincodeStubInterface) pb.Response
 		return bs.callbackBizChaincode(stub, []byte(args[0]))
 
 	default:
-		return shim.Error("Method not found")
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "Method not found: %s", fn))
 	}
 }
 
 func (bs *CrossChain) setDomainParser(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) != 2 {
-		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
 	}
 
 	senderDomain := args[0]
 	parser := args[1]
 
 	if senderDomain == "" || parser == "" {
-		return shim.Error(fmt.Sprintf("Wrong args: %s, %s", senderDomain, parser))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", senderDomain, parser))
 	}
 
 	if err := bs.Os.PutState(stub, false, fmt.Sprintf("%s_%s", oraclelogic.KMychainParserInfo, senderDomain), []byte(parser)); err != nil {
-		return shim.Error(fmt.Sprintf("failed to put parser: %v", err))
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put parser"))
 	}
 
 	return shim.Success(nil)
@@ -178,10 +1475,10 @@ func (bs *CrossChain) sendMessage(stub shim.ChaincodeStubInterface, args []strin
 	/**************************/
 	/*      USER DEFINE       */
 	/**************************/
-	// 构造对外发送的消息，准备目的域名、接收账号、消息内容, nounce
-	if len(args) != 4 && len(args) != 3 {
+	// 构造对外发送的消息，准备目的域名、接收账号、消息内容, nounce, 有效期
+	if len(args) < 3 || len(args) > 5 {
 		fmt.Println("Unexpected args len")
-		return shim.Error(fmt.Sprintf("Unexpected args len: %d", len(args)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Unexpected args len: %d", len(args)))
 	}
 	var (
 		destDomain    = args[0]
@@ -190,17 +1487,27 @@ func (bs *CrossChain) sendMessage(stub shim.ChaincodeStubInterface, args []strin
 	)
 
 	if err != nil {
-		return shim.Error(fmt.Sprintf("receiver(%s) format error: %v", args[1], err))
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "receiver(%s) format error", args[1]))
 	}
 
 	if len(msg) > oraclelogic.K_SEND_MESSAGE_LENGTH_LIMIT {
-		return shim.Error(fmt.Sprintf(" message exceed length limit (%d)", len(msg)))
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "message exceed length limit (%d)", len(msg)))
 	}
 
-	msgnounce := ""
-	if len(args) == 4 {
+	msgnounce, expiry := "", ""
+	if len(args) >= 4 {
 		msgnounce = args[3]
 	}
+	if len(args) == 5 {
+		if msgType != oraclelogic.K_MSG_TYPE_ORDERED {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "only ordered message can expire"))
+		}
+		expiry = args[4]
+	}
+	ttl, err := bs.messageTTL(stub, expiry)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid expiry"))
+	}
 
 	collection := "" // 非隐私消息，应该使用空字符串
 
@@ -213,9 +1520,27 @@ func (bs *CrossChain) sendMessage(stub shim.ChaincodeStubInterface, args []strin
 		fmt.Printf("Orale SendMessage failed, message:%s\n", res.Message)
 		return res
 	}
+	client, fee, err := bs.chargeFee(stub, destDomain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to charge fee"))
+	}
+	if err := bs.indexOutboundMessage(stub, res.Payload); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to index outbound message"))
+	}
+	if err := bs.enqueueRelay(stub, res.Payload); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to enqueue relay"))
+	}
+	if msgType == oraclelogic.K_MSG_TYPE_ORDERED && ttl > 0 {
+		if err := bs.trackExpiry(stub, res.Payload, ttl, client, fee); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to track expiry"))
+		}
+	}
 
-	fmt.Printf("sendMessage success\n")
-	return shim.Success(nil)
+	var sent oraclelogic.OutboundMessage
+	_ = json.Unmarshal(res.Payload, &sent)
+	txLogger(stub).Info("message sent", zap.String("traceId", packageTraceId(sent.Package)),
+		zap.String("key", sent.Key), zap.String("destDomain", destDomain), zap.String("msgType", msgType))
+	return res
 }
 
 func (bs *CrossChain) recvMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
@@ -228,14 +1553,12 @@ func (bs *CrossChain) recvMessage(stub shim.ChaincodeStubInterface, args []strin
 	//           args[1]: 提交的TEE验证过的原始信息
 	//
 	// 隐私消息：
-	//           args只传oracle service id一个参数，其他参数通过transient map传递
-	if len(args) == 1 {
-		// args[0] : oralce service id
-		// 从transient中获取机密信息
-		trans, _ := stub.GetTransient()
-		rawdata := trans["rawdata"]
-		args = []string{args[0], string(rawdata)}
-		fmt.Printf("recvMessage with transient data : %s ", rawdata)
+	//           args[0]: oracle service id
+	//           args[1]: sha256(原始信息)(hex)，可选
+	//           原始信息通过transient map的rawdata传递，见transient.go
+	args, err := transientRecvArgs(stub, args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid rawdata"))
 	}
 
 	//  调用跨链接收消息接口     */
@@ -244,10 +1567,16 @@ func (bs *CrossChain) recvMessage(stub shim.ChaincodeStubInterface, args []strin
 	recvmsg := bs.Os.RecvBatchMychainMessage(stub, args)
 	if recvmsg.Status != shim.OK {
 		// 返回错误信息
-		return recvmsg
+		return errorResponse("", crosserr.FromMessage(crosserr.CodeVerify, recvmsg.Message))
+	}
+	var parsed oraclelogic.RecvAuthMessages
+	_ = json.Unmarshal(recvmsg.Payload, &parsed)
+	if err := bs.checkPTCBypass(stub, parsed.Message); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check PTC committee"))
+	}
+	if err := bs.checkPTCCertChain(stub, parsed.Message); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check PTC certificate"))
 	}
-
-	fmt.Printf("Crosschain recevive message:%s\n", recvmsg.Payload) // json结构，src domain/ src id/ msg
 
 	return bs.callbackBizChaincode(stub, recvmsg.Payload)
 }
@@ -256,43 +1585,201 @@ func (bs *CrossChain) callbackBizChaincode(stub shim.ChaincodeStubInterface, mes
 	// 解析收到的消息
 	var msgs oraclelogic.RecvAuthMessages
 	_ = json.Unmarshal(messages, &msgs)
+	return bs.deliverInbound(stub, msgs.Message, nil)
+}
+
+// 已通过校验的消息的投递流程：回执、修饰策略、限流、隐私路由、争议窗口、回调业务链码、消息记录和事件，
+// callbackBizChaincode、recvBatchMessages和乐观模式的finalizeOptimisticMessage共用。
+// batchResult为recvBatchMessages的结果，随v2事件发出，其他调用方为nil
+func (bs *CrossChain) deliverInbound(stub shim.ChaincodeStubInterface, messages []oraclelogic.RecvAuthMessage, batchResult []byte) pb.Response {
+	msgs := oraclelogic.RecvAuthMessages{Message: messages}
+	if err := bs.checkReceipts(stub, msgs.Message); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check receipts"))
+	}
+	if err := bs.applyDecorationPolicy(stub, msgs.Message); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "rejected by decoration policy"))
+	}
+	tracer, err := bs.newDebugTracer(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to start debug trace"))
+	}
 
+	held := make(map[int]bool)
 	for i := 0; i < len(msgs.Message); i++ {
 		msg := msgs.Message[i]
-		cc_hash := msg.Receiver
-		cc_name := bs.Os.QuerySha256Invert(stub, []string{hex.EncodeToString(cc_hash[:])})
-		if cc_name.Status != shim.OK {
-			return shim.Error(fmt.Sprintf("receiver chaincode(recHash: %s) not exist!", hex.EncodeToString(cc_hash[:])))
-		}
-
-		// 回调用户合约
-		var (
-			bizcc = string(cc_name.Payload) // 收到消息的链码
-		)
-		var cbFn string
-		if msg.MsgType == oraclelogic.K_MSG_TYPE_ORDERED {
-			cbFn = "recvMessage"
-		} else if msg.MsgType == oraclelogic.K_MSG_TYPE_UNORDERED {
-			cbFn = "recvUnorderedMessage"
-		}
-
-		var args_cb = [][]byte{
-			[]byte(cbFn), // 接收消息的客户合约要实现一个接口
-			//      recvMessage(
-			//             sourceDomain stirng,   // 消息来源区块链的域名
-			//             sourceIdentity string, // 消息发送者身份
-			//             message string)        // 消息内容
-			//      pb.Response                   // 回调用户连码返回值
-			[]byte(msg.From), // source domain
-			[]byte(hex.EncodeToString(msg.Identity[:])), // source identity  hex串
-			[]byte(msg.Content),                         // message
-		}
-		re := stub.InvokeChaincode(bizcc, args_cb, stub.GetChannelID())
-		if re.Status != shim.OK {
-			fmt.Printf("call %s.%s failed: %s\n", bizcc, cbFn, re.Message)
-			return shim.Error(fmt.Sprintf("recv message and callback chaincode %s failed", bizcc))
+		tracer.parsed(msg)
+		logger := txLogger(stub).With(zap.String("traceId", recvTraceId(msg)))
+		logger.Info("message received", zap.String("from", msg.From), zap.Uint32("seq", msg.Seq), zap.String("msgType", msg.MsgType))
+
+		// 来源域名设置了限流时计数，超出时整笔交易失败
+		if err := bs.consumeRateLimit(stub, msg.From); err != nil {
+			tracer.step(TRACE_STEP_ACL, false, "%v", err)
+			return errorResponse("", err)
+		}
+
+		// 来源域名配置了隐私路由时，消息内容写入私有数据集合
+		route, err := bs.getPrivateRoute(stub, msg.From)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get private route"))
+		}
+		if route != nil {
+			if err := bs.storePrivateMessage(stub, route, msg, i); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to store private message"))
+			}
+			tracer.step(TRACE_STEP_DELIVERY, true, "stored in collection %s", route.Collection)
+		}
+
+		// 来源域名配置了争议窗口时，消息暂存，窗口期结束后再回调
+		window, err := bs.getDisputeWindow(stub, msg.From)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get dispute window"))
 		}
-		fmt.Printf("call %s.%s success: %s\n", bizcc, cbFn, re.Message)
+		if window > 0 {
+			if err := bs.checkPublicHold(stub, msg); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check payload collection"))
+			}
+			msgId, err := bs.holdMessage(stub, msg, i, window)
+			if err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to hold message"))
+			}
+			logger.Info("message held in dispute window", zap.String("messageId", msgId))
+			tracer.step(TRACE_STEP_DELIVERY, true, "held in dispute window as %s", msgId)
+			held[i] = true
+			continue
+		}
+
+		if re := bs.deliverMessage(stub, msg, tracer); re.Status != shim.OK {
+			logger.Warn("message delivery failed", zap.String("error", re.Message))
+			return re
+		}
+	}
+
+	if err := bs.writeReceipts(stub, msgs.Message); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to write receipts"))
+	}
+	if err := bs.recordInboundMessages(stub, msgs.Message, held); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to write message records"))
+	}
+
+	// 顺带删除过期的消息记录
+	pruned := make(map[string]bool)
+	for _, msg := range msgs.Message {
+		if pruned[msg.From] {
+			continue
+		}
+		pruned[msg.From] = true
+		if err := bs.pruneRetention(stub, msg.From); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to prune expired records"))
+		}
+	}
+	if err := bs.flushDebugTrace(stub, tracer); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to write debug trace"))
+	}
+	if err := bs.emitRecvEventV2(stub, msgs.Message, batchResult); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
 	}
 	return shim.Success([]byte("callback biz chaincode success"))
 }
+
+// 回调接收消息的业务链码
+func (bs *CrossChain) deliverMessage(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, tracer *debugTracer) pb.Response {
+	if err := bs.checkSDPSeq(stub, msg); err != nil {
+		tracer.step(TRACE_STEP_VERIFY, false, "%v", err)
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check sdp seq"))
+	}
+	if msg.ProtocolType != oraclelogic.P2P_MSG_PROTOCOL_TYPE {
+		// 其他上层协议的消息交给登记的协议链码，见protocol.go
+		return bs.deliverProtocolMessage(stub, msg, tracer)
+	}
+	if isAckMessage(msg) {
+		// 回执回到请求方，不回调recvMessage
+		return bs.resolveRequest(stub, msg, tracer)
+	}
+	if header, err := decodeChunk(msg.Content); err != nil {
+		tracer.step(TRACE_STEP_VERIFY, false, "%v", err)
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid chunk"))
+	} else if header != nil {
+		// 分片暂存，收齐后以完整的消息内容回调
+		payload, err := bs.assembleChunk(stub, msg, header)
+		if err != nil {
+			tracer.step(TRACE_STEP_VERIFY, false, "%v", err)
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to assemble chunk"))
+		}
+		if payload == nil {
+			tracer.step(TRACE_STEP_DELIVERY, true, "stored chunk %d/%d of %s", header.Index+1, header.Total, header.ChunkId)
+			return shim.Success(nil)
+		}
+		msg.Content = payload
+	}
+	if err := bs.checkExecLimit(stub, msg.Content, true); err != nil {
+		tracer.step(TRACE_STEP_DELIVERY, false, "%v", err)
+		if !needAck(msg) || crosserr.CodeOf(err) != crosserr.CodeExecLimit {
+			return errorResponse("", err)
+		}
+		// 原子请求回复ACK_ERROR，回执不带超限的消息内容
+		limited := msg
+		limited.Content = nil
+		if _, ackErr := bs.ackMessage(stub, limited, err, nil); ackErr != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, ackErr, "ack message"))
+		}
+		return shim.Success(nil)
+	}
+	bizcc, err := bs.routeReceiver(stub, msg) // 收到消息的链码
+	if err != nil {
+		tracer.step(TRACE_STEP_ACL, false, "%v", err)
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "route receiver"))
+	}
+	channel, err := bs.receiverChannel(stub, bizcc)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "get receiver channel"))
+	}
+	if channel != stub.GetChannelID() {
+		tracer.step(TRACE_STEP_ACL, true, "receiver resolved to %s on channel %s", bizcc, channel)
+	} else {
+		tracer.step(TRACE_STEP_ACL, true, "receiver resolved to %s", bizcc)
+	}
+	if err := bs.storePayload(stub, bizcc, msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to store payload"))
+	}
+
+	// 回调用户合约
+	var cbFn string
+	if msg.MsgType == oraclelogic.K_MSG_TYPE_ORDERED {
+		cbFn = "recvMessage"
+	} else if msg.MsgType == oraclelogic.K_MSG_TYPE_UNORDERED {
+		cbFn = "recvUnorderedMessage"
+	}
+
+	var args_cb = [][]byte{
+		[]byte(cbFn), // 接收消息的客户合约要实现一个接口
+		//      recvMessage(
+		//             sourceDomain stirng,   // 消息来源区块链的域名
+		//             sourceIdentity string, // 消息发送者身份
+		//             message string)        // 消息内容
+		//      pb.Response                   // 回调用户连码返回值
+		[]byte(msg.From), // source domain
+		[]byte(hex.EncodeToString(msg.Identity[:])), // source identity  hex串
+		[]byte(msg.Content),                         // message
+	}
+	re := stub.InvokeChaincode(bizcc, args_cb, channel)
+	if needAck(msg) {
+		// 原子请求不论成功与否都回复回执，交易不回滚
+		var callErr error
+		if re.Status != shim.OK {
+			callErr = fmt.Errorf("%s", re.Message)
+		}
+		status, err := bs.ackMessage(stub, msg, callErr, re.Payload)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "ack message"))
+		}
+		tracer.step(TRACE_STEP_DELIVERY, callErr == nil, "call %s.%s: %s, acked with flag %d", bizcc, cbFn, re.Message, status.AtomicFlag)
+		return shim.Success(nil)
+	}
+	if re.Status != shim.OK {
+		fmt.Printf("call %s.%s failed: %s\n", bizcc, cbFn, re.Message)
+		return errorResponse("", crosserr.New(crosserr.CodeCallback, "recv message and callback chaincode %s failed: %s", bizcc, re.Message))
+	}
+	fmt.Printf("call %s.%s success: %s\n", bizcc, cbFn, re.Message)
+	tracer.step(TRACE_STEP_DELIVERY, true, "call %s.%s: %s", bizcc, cbFn, re.Message)
+	return shim.Success(nil)
+}
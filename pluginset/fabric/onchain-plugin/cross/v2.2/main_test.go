@@ -2,19 +2,24 @@ package main
 
 import (
 	"chaincodepb"
+	"crosserr"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	comm "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	"github.com/hyperledger/fabric-protos-go/msp"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"oraclelogic/v2.2"
 	"strings"
 	"testing"
+	"unicode/utf8"
+	"wrapstub/v2.2"
 )
 
 var (
@@ -77,6 +82,185 @@ func MockSignedProposal(ccname string, sp *pb.SignedProposal) {
 	sp.ProposalBytes = bt
 }
 
+// 测试用的管理员证书和中继者证书
+const (
+	TEST_ADMIN_CERT   = "-----BEGIN CERTIFICATE-----\nMIICjzCCAjWgAwIBAgIUVHR3Y4gykapStwdAEwY8POZJyYwwCgYIKoZIzj0EAwIwczELMAkGA1UEBhMCVVMxEzARBgNVBAgTCkNhbGlmb3JuaWExFjAUBgNVBAcTDVNhbiBGcmFuY2lzY28xGTAXBgNVBAoTEG9yZzEuZXhhbXBsZS5jb20xHDAaBgNVBAMTE2NhLm9yZzEuZXhhbXBsZS5jb20wHhcNMTkwNjE3MDk1MzAwWhcNMjAwNjE2MDk1ODAwWjBCMTAwDQYDVQQLEwZjbGllbnQwCwYDVQQLEwRvcmcxMBIGA1UECxMLZGVwYXJ0bWVudDExDjAMBgNVBAMTBXVzZXIxMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEyk8ZD3Pa3QkfqRkRXhqINLkFB4gO05iDk6IiUr8YxkRf7CFyZ/4Q7yfxJuGtj7ja0v62HDjKjTk4GtByRVo0BKOB1zCB1DAOBgNVHQ8BAf8EBAMCB4AwDAYDVR0TAQH/BAIwADAdBgNVHQ4EFgQU4Mo/HEjC/z2gRpa0YQUu6s66bzYwKwYDVR0jBCQwIoAgscw0w/LQz4B4aPo6GhGHTSBBMIRf2O6zbS5ZRNd2dxwwaAYIKgMEBQYHCAEEXHsiYXR0cnMiOnsiaGYuQWZmaWxpYXRpb24iOiJvcmcxLmRlcGFydG1lbnQxIiwiaGYuRW5yb2xsbWVudElEIjoidXNlcjEiLCJoZi5UeXBlIjoiY2xpZW50In19MAoGCCqGSM49BAMCA0gAMEUCIQChD3K9EDlkRmKJPWS/tvQUKl32HHsyh1ESEh9Zc4BAoAIgNaBr4XHaLc2uQZJ+S/EBvztOczag2hekEtqJU21hpuk=\n-----END CERTIFICATE-----\n"
+	TEST_RELAYER_CERT = "-----BEGIN CERTIFICATE-----\nMIICTjCCAfWgAwIBAgIUArdFaN6TDO1h2x7eY0nF9MDaZCowCgYIKoZIzj0EAwIwajELMAkGA1UEBhMCQ04xETAPBgNVBAgTCFpoZWppYW5nMREwDwYDVQQHEwhIYW5nemhvdTENMAsGA1UEChMEb3JnMDEmMCQGA1UEAxMdb3JnMCBDbGllbnQgSW50ZXJtZWRpYXRlIENlcnQwHhcNMTkwNzExMDcwNTAwWhcNMjAwNzEwMDcxMDAwWjAkMQ8wDQYDVQQLEwZjbGllbnQxETAPBgNVBAMTCHVzZXJvcmcxMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE9mssLj1ozolhdV+cfrqIEd3dfGhscTOP3EhjRg39OqxHmTYGILOO/TS7kiW1Q/zhiKPeL7pxH5AF1LZ0U8W+MKOBvjCBuzAOBgNVHQ8BAf8EBAMCB4AwDAYDVR0TAQH/BAIwADAdBgNVHQ4EFgQUhTDn0WNutsKFm2k2S9yD7KBdhKwwHwYDVR0jBBgwFoAUDjPsY9xRxy3Xj3cSCTBEpzhA0DAwWwYIKgMEBQYHCAEET3siYXR0cnMiOnsiaGYuQWZmaWxpYXRpb24iOiIiLCJoZi5FbnJvbGxtZW50SUQiOiJ1c2Vyb3JnMSIsImhmLlR5cGUiOiJjbGllbnQifX0wCgYIKoZIzj0EAwIDRwAwRAIgA0sd/kl37iHTyijmk/+m2/yO8VIkpLIH0kC6uLlPpWoCIDW3FEVrseoM2A1CFIR+ku3AaBovj0hmUIBFzV3o+p9m\n-----END CERTIFICATE-----"
+)
+
+func MockCreator(cert string) []byte {
+	var creator msp.SerializedIdentity
+	creator.IdBytes = []byte(cert)
+	creator.Mspid = ""
+	bt, _ := proto.Marshal(&creator)
+	return bt
+}
+
+// 构造已设置管理员(TEST_ADMIN_CERT)的跨链链码
+func NewAdminCrossChainStub(t *testing.T) (*shimtest.MockStub, *pb.SignedProposal) {
+	stub := shimtest.NewMockStub("crosschain", new(CrossChain))
+	var sp pb.SignedProposal
+	MockSignedProposal("crosscc", &sp)
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+
+	doInit(t, stub, [][]byte{[]byte("Init")}, &sp)
+	if res := InvokeChaincode(t, stub, [][]byte{[]byte("setAdmin"), []byte(TEST_ADMIN_CERT)}, &sp); res.Status != shim.OK {
+		t.FailNow()
+	}
+	return stub, &sp
+}
+
+func InvokeWithStrings(t *testing.T, stub *shimtest.MockStub, sp *pb.SignedProposal, args ...string) pb.Response {
+	var bargs [][]byte
+	for _, arg := range args {
+		bargs = append(bargs, []byte(arg))
+	}
+	return InvokeChaincode(t, stub, bargs, sp)
+}
+
+// 构造已设置管理员、并注册了业务链码bizcc的跨链链码
+func NewBizCrossChainStubs(t *testing.T) (*shimtest.MockStub, *pb.SignedProposal, *shimtest.MockStub, *pb.SignedProposal) {
+	stub, sp := NewAdminCrossChainStub(t)
+
+	var bizsp pb.SignedProposal
+	MockSignedProposal("bizcc", &bizsp)
+	stubbiz := shimtest.NewMockStub("bizcc", new(CrossChainTest))
+	stub.MockPeerChaincode("bizcc", stubbiz, "")
+	stubbiz.MockPeerChaincode("crosscc", stub, "")
+
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "registerSha256Invert", "bizcc"); res.Status != shim.OK {
+		t.FailNow()
+	}
+	return stub, sp, stubbiz, &bizsp
+}
+
+// 以指定的交易时间戳(秒)直接调用链码内部函数，用于测试时间窗口
+func CallWithTimestamp(stub *shimtest.MockStub, seconds int64, fn func(stub shim.ChaincodeStubInterface) pb.Response) pb.Response {
+	stub.MockTransactionStart(txid)
+	defer stub.MockTransactionEnd(txid)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+	return fn(wrapstub.NewMockWrapStub(stub))
+}
+
+// MockStub不支持分页查询，测试中按MockStub的有序key列表实现
+type pagedStub struct {
+	*shimtest.MockStub
+}
+
+type sliceIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *sliceIterator) HasNext() bool { return len(it.kvs) > 0 }
+
+func (it *sliceIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func (it *sliceIterator) Close() error { return nil }
+
+func (s *pagedStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	it := &sliceIterator{}
+	next := ""
+	for elem := s.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		if int32(len(it.kvs)) == pageSize {
+			next = key
+			break
+		}
+		it.kvs = append(it.kvs, &queryresult.KV{Key: key, Value: s.State[key]})
+	}
+	return it, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(it.kvs)), Bookmark: next}, nil
+}
+
+func (s *pagedStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	start, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.GetStateByRangeWithPagination(start, start+string(utf8.MaxRune), pageSize, bookmark)
+}
+
+// 同CallWithTimestamp，stub支持分页查询
+func CallPagedWithTimestamp(stub *shimtest.MockStub, seconds int64, fn func(stub shim.ChaincodeStubInterface) pb.Response) pb.Response {
+	stub.MockTransactionStart(txid)
+	defer stub.MockTransactionEnd(txid)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+	return fn(&pagedStub{MockStub: stub})
+}
+
+// 用独立的发送方跨链链码依次发送消息，返回发往destDomain的AM报文(hex)
+// 有序消息的序号从0开始递增
+func MockAMPackages(t *testing.T, destDomain string, receiver [32]byte, msgType string, contents ...string) []string {
+	sender := shimtest.NewMockStub("sender", new(CrossChain))
+	var sp pb.SignedProposal
+	MockSignedProposal("sendercc", &sp)
+
+	fn := "sendMessage"
+	if msgType == oraclelogic.K_MSG_TYPE_UNORDERED {
+		fn = "sendUnorderedMessage"
+	}
+	key := oraclelogic.K_CROSSCHAIN_MSG_PREFIX + txid + "_n"
+	if msgType == oraclelogic.K_MSG_TYPE_UNORDERED {
+		key = oraclelogic.UnorderedMessageKey(txid, sha256.Sum256([]byte("sendercc")), "n")
+	}
+	var pkgs []string
+	for _, content := range contents {
+		if res := InvokeWithStrings(t, sender, &sp, fn, destDomain, hex.EncodeToString(receiver[:]), content, "n"); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		pkgs = append(pkgs, hex.EncodeToString(sender.State[key]))
+	}
+	return pkgs
+}
+
+// 无序消息只写入由txid和发送方身份决定的key
+func TestUnorderedSendWriteSet(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	before := make(map[string]string)
+	for k, v := range stub.State {
+		before[k] = string(v)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "batchSendUnorderedMessage", "dest.com", hex.EncodeToString(make([]byte, 32)), "m1", "m2", "m3"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	author := sha256.Sum256([]byte("crosscc"))
+	prefix := oraclelogic.UnorderedMessageKey(txid, author, "")
+	written := 0
+	for k, v := range stub.State {
+		if old, has := before[k]; has && old == string(v) {
+			continue
+		}
+		// 积压查询的索引也只与单条消息有关
+		if strings.HasPrefix(k, "\x00"+K_OUTBOUND_OBJECT_TYPE) {
+			if _, attrs, err := stub.SplitCompositeKey(k); err == nil && strings.HasPrefix(attrs[2], prefix) {
+				continue
+			}
+		}
+		// 消息记录同样只与单条消息有关
+		if strings.HasPrefix(k, outboundRecordKey(prefix)) {
+			continue
+		}
+		if !strings.HasPrefix(k, prefix) {
+			t.Fatalf("unordered send should not write shared key %s", k)
+		}
+		written++
+	}
+	if written != 3 {
+		t.Fatalf("expected 3 messages written, got %d", written)
+	}
+}
+
 func Test_Integration(t *testing.T) {
 	crosscc := new(CrossChain)
 	stub := shimtest.NewMockStub("crosschain", crosscc)
@@ -517,6 +701,31 @@ func Test_Integration(t *testing.T) {
 		t.FailNow()
 	}
 
+	// 校验通过的信任材料已缓存，域名服务配置变化后缓存失效
+	var trustCachePkHash string
+	for k := range stub.State {
+		if strings.HasPrefix(k, oraclelogic.K_TRUST_CACHE_PREFIX) {
+			trustCachePkHash = strings.TrimPrefix(k, oraclelogic.K_TRUST_CACHE_PREFIX)
+		}
+	}
+	var queryTrustCacheArgs = [][]byte{
+		[]byte("oracleAdminManage"),
+		[]byte("queryTrustCache"),
+		[]byte(trustCachePkHash),
+	}
+	result = InvokeChaincode(t, stub, queryTrustCacheArgs, &crosscc_sp)
+	if shim.OK != result.Status {
+		t.Fatalf("trust cache should exist: %s", result.Message)
+	}
+	result = InvokeChaincode(t, stub, setDomainServiceIdArgs, &crosscc_sp)
+	if shim.OK != result.Status {
+		t.FailNow()
+	}
+	result = InvokeChaincode(t, stub, queryTrustCacheArgs, &crosscc_sp)
+	if shim.OK == result.Status {
+		t.Fatal("trust cache should expire after trust epoch bumped")
+	}
+
 	// 提交拒绝消息
 	msgnounce = "nounce2"
 	var recvRejectMessageArgs = [][]byte{
@@ -792,14 +1001,14 @@ func Test_CallbackBizChaincode(t *testing.T) {
 
 	var msgs oraclelogic.RecvAuthMessages
 	// 追加ordered消息
-	msg := oraclelogic.RecvAuthMessage{srcDomain, sender,
-		content_ordered, receiver, oraclelogic.K_MSG_TYPE_ORDERED}
+	msg := oraclelogic.RecvAuthMessage{From: srcDomain, Identity: sender,
+		Content: content_ordered, Receiver: receiver, MsgType: oraclelogic.K_MSG_TYPE_ORDERED, Seq: 0}
 	messages := append(msgs.Message, msg)
 	msgs.Message = messages
 
 	// 追加unordered消息
-	msg = oraclelogic.RecvAuthMessage{srcDomain, sender,
-		content_unordered, receiver, oraclelogic.K_MSG_TYPE_UNORDERED}
+	msg = oraclelogic.RecvAuthMessage{From: srcDomain, Identity: sender,
+		Content: content_unordered, Receiver: receiver, MsgType: oraclelogic.K_MSG_TYPE_UNORDERED, Seq: oraclelogic.K_UNORDERED_MSG_SEQ}
 	messages = append(msgs.Message, msg)
 	msgs.Message = messages
 
@@ -1029,3 +1238,69 @@ func Test_CallbackBizChaincode(t *testing.T) {
 //	}
 //
 //}
+
+func TestErrorCodes(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	expect := func(res pb.Response, fn string, code crosserr.Code) {
+		t.Helper()
+		parsed, _, ok := crosserr.Parse(res.Message)
+		if res.Status != crosserr.STATUS_ERROR || !ok || parsed != code {
+			t.Fatalf("expected %s, got %d %s", code, res.Status, res.Message)
+		}
+		if fn != "" && !strings.HasPrefix(res.Message, "["+fn+"] ") {
+			t.Fatalf("expected prefix [%s], got %s", fn, res.Message)
+		}
+	}
+	expect(InvokeWithStrings(t, stub, sp, "noSuchMethod"), "", crosserr.CodeNotFound)
+	expect(InvokeWithStrings(t, stub, sp, "setDomainParser", MYCHAIN_DOMAIN), "", crosserr.CodeInvalidArgs)
+	expect(InvokeWithStrings(t, stub, sp, "batchSendUnorderedMessage", "a", "b"), "batchSendUnorderedMessage", crosserr.CodeInvalidArgs)
+	// 各模块的入口，参数错误保留下层的错误码
+	for _, fn := range []string{"postBond", "challengeMessage", "submitFraudProof", "submitZKBatch", "recvEthMessage", "recvTMMessage", "recvBTCMessage"} {
+		expect(InvokeWithStrings(t, stub, sp, fn, "src.com"), fn, crosserr.CodeInvalidArgs)
+	}
+	expect(InvokeWithStrings(t, stub, sp, "submitZKBatch", "src.com", "00", "1", "00"), "submitZKBatch", crosserr.CodeInvalidArgs)
+	// 证明校验失败
+	registerZKProofVerifier("test-hash", &hashZKVerifier{})
+	defer delete(zkProofVerifiers, "test-hash")
+	if res := InvokeWithStrings(t, stub, sp, "setZKRoute", "src.com", "test-hash", "01"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	expect(InvokeWithStrings(t, stub, sp, "submitZKBatch", "src.com", strings.Repeat("00", 32), "1", "00"), "submitZKBatch", crosserr.CodeVerify)
+	// 未初始化的模块
+	expect(InvokeWithStrings(t, stub, sp, "submitBTCHeaders", "src.com", "00"), "submitBTCHeaders", crosserr.CodeNotFound)
+	expect(InvokeWithStrings(t, stub, sp, "nobridge"+BRIDGE_FN_SEPARATOR+"sendMessage"), "nobridge"+BRIDGE_FN_SEPARATOR+"sendMessage", crosserr.CodeNotFound)
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	expect(InvokeWithStrings(t, stub, sp, "recvMessage", MYCHAIN_DOMAIN, RECVPKGFROMRELAYER), "recvMessage", crosserr.CodeUnauthorized)
+	for _, fn := range []string{"setBondConfig", "setDisputeWindow", "setOptimisticMode", "setZKRoute", "recvZKBatchMessages", "setEthForkVersion", "submitTMHeader", "initBTCSPV", "setPTCCommittee", "setFeeSchedule"} {
+		expect(InvokeWithStrings(t, stub, sp, fn, "src.com"), fn, crosserr.CodeUnauthorized)
+	}
+}
+
+func TestRecvErrorCodes(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "seq 0", "seq 1")
+	expect := func(rawdata string, code crosserr.Code, retryable bool) {
+		t.Helper()
+		res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", rawdata)
+		parsed, _, ok := crosserr.Parse(res.Message)
+		if res.Status != crosserr.STATUS_ERROR || !ok || parsed != code || parsed.Retryable() != retryable {
+			t.Fatalf("expected %s, got %d %s", code.Symbol(), res.Status, res.Message)
+		}
+		// 包装后错误码只出现一次
+		if strings.Count(res.Message, fmt.Sprintf("E%d", code)) != 1 {
+			t.Fatalf("unexpected message: %s", res.Message)
+		}
+	}
+	// 前序消息未投递，可以重试
+	expect(mockRecvRawData(t, "src.com", pkgs[1]), crosserr.CodeSeqAhead, true)
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", pkgs[0])); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 已投递的消息不能重试
+	expect(mockRecvRawData(t, "src.com", pkgs[0]), crosserr.CodeSequence, false)
+	expect("zz", crosserr.CodeInvalidArgs, false)
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"am"
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 消息记录
+// 发送和接收的每条消息都登记一条JSON文档，状态变化时更新。状态库为CouchDB时，运维可以用queryMessages按任意字段富查询，
+// 如发往某个域名、早于某个时间仍未确认的消息。LevelDB不支持富查询，queryMessages返回错误，记录照常写入。
+//   - 发送的消息：发送后为pending，中继ackRelay后为delivered，过期取消后为cancelled
+//   - 接收的消息：回调业务链码后为delivered，进入争议窗口的为held，窗口结束投递后为delivered
+//
+// 不经过AM报文解析的接收消息没有报文哈希，与回执一样不登记。常用查询的索引见META-INF/statedb/couchdb/indexes。
+//
+// 每次修改记录都带上交易id和发起者，queryMessageHistory按GetHistoryForKey返回记录的全部修改，审计时可以还原
+// 每次状态变化的时间和发起者。与querySeqTimeline一样需要节点开启历史库，只能用于查询。
+const (
+	// crosschain_msgrecord_outbound_${msgKey} -> MessageRecord
+	// crosschain_msgrecord_inbound_${srcDomain}_${packetHash} -> MessageRecord
+	K_MESSAGE_RECORD_PREFIX = CROSSCHAIN_PREFIX + "msgrecord_"
+
+	// 富查询时限定只返回消息记录
+	K_MESSAGE_RECORD_DOC_TYPE = "crosschain_message"
+
+	MESSAGE_DIRECTION_OUTBOUND = "outbound"
+	MESSAGE_DIRECTION_INBOUND  = "inbound"
+
+	MESSAGE_STATUS_PENDING   = "pending"
+	MESSAGE_STATUS_HELD      = "held"
+	MESSAGE_STATUS_DELIVERED = "delivered"
+	MESSAGE_STATUS_CANCELLED = "cancelled"
+)
+
+type MessageRecord struct {
+	DocType   string `json:"docType"`
+	Direction string `json:"direction"`
+	Status    string `json:"status"`
+	// 发送消息为消息key，接收消息为sha256(AM报文)(hex)
+	Key        string `json:"key"`
+	SrcDomain  string `json:"srcDomain"`
+	DestDomain string `json:"destDomain"`
+	// 无序消息为K_UNORDERED_MSG_SEQ
+	Seq     uint32 `json:"seq"`
+	MsgType string `json:"msgType"`
+	// 发送方、接收方身份(hex)
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	// 最近一次修改的交易
+	TxId      string `json:"txId"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	// 最近一次修改的交易发起者MSP ID和证书sha256(hex)，身份不是x509证书时为空
+	UpdatedByMSP string `json:"updatedByMSP,omitempty"`
+	UpdatedBy    string `json:"updatedBy,omitempty"`
+}
+
+type MessageHistoryEntry struct {
+	TxId      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+	Nanos     int32  `json:"nanos,omitempty"`
+	IsDelete  bool   `json:"isDelete,omitempty"`
+	// 本次修改后的记录，删除时为空
+	Record *MessageRecord `json:"record,omitempty"`
+}
+
+type MessageHistory struct {
+	Key     string                `json:"key"`
+	Entries []MessageHistoryEntry `json:"entries"`
+}
+
+type MessageRecordPage struct {
+	Messages []MessageRecord `json:"messages"`
+	Bookmark string          `json:"bookmark"`
+}
+
+func outboundRecordKey(msgKey string) string {
+	return K_MESSAGE_RECORD_PREFIX + MESSAGE_DIRECTION_OUTBOUND + "_" + msgKey
+}
+
+func inboundRecordKey(srcDomain string, packetHash string) string {
+	return K_MESSAGE_RECORD_PREFIX + MESSAGE_DIRECTION_INBOUND + "_" + srcDomain + "_" + packetHash
+}
+
+// 写入消息记录，记下本次修改的交易和发起者
+func putMessageRecord(stub shim.ChaincodeStubInterface, key string, record *MessageRecord) error {
+	record.TxId = stub.GetTxID()
+	record.UpdatedByMSP, record.UpdatedBy, _ = getCreatorIdentity(stub)
+	return putJSONState(stub, key, record)
+}
+
+// 登记发送的消息
+func (bs *CrossChain) recordOutboundMessage(stub shim.ChaincodeStubInterface, msg oraclelogic.OutboundMessage) error {
+	amMsg, err := am.Decode(msg.Package)
+	if err != nil {
+		return fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+	}
+	sdpMsg, err := oraclelogic.DecodeSDPMessage(amMsg.GetPayload())
+	if err != nil {
+		return fmt.Errorf("failed to decode outbound message %s: %v", msg.Key, err)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	msgType := oraclelogic.K_MSG_TYPE_ORDERED
+	if sdpMsg.Sequence == oraclelogic.K_UNORDERED_MSG_SEQ {
+		msgType = oraclelogic.K_MSG_TYPE_UNORDERED
+	}
+	author := amMsg.GetAuthor()
+	return putMessageRecord(stub, outboundRecordKey(msg.Key), &MessageRecord{
+		DocType:    K_MESSAGE_RECORD_DOC_TYPE,
+		Direction:  MESSAGE_DIRECTION_OUTBOUND,
+		Status:     MESSAGE_STATUS_PENDING,
+		Key:        msg.Key,
+		SrcDomain:  bs.localDomain(stub),
+		DestDomain: sdpMsg.TargetDomain,
+		Seq:        sdpMsg.Sequence,
+		MsgType:    msgType,
+		Sender:     hex.EncodeToString(author[:]),
+		Receiver:   hex.EncodeToString(sdpMsg.TargetIdentity[:]),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
+
+// 登记接收的消息，held为进入争议窗口的消息下标
+func (bs *CrossChain) recordInboundMessages(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage, held map[int]bool) error {
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	local := bs.localDomain(stub)
+	for i, msg := range msgs {
+		if msg.PacketHash == "" {
+			continue
+		}
+		status := MESSAGE_STATUS_DELIVERED
+		if held[i] {
+			status = MESSAGE_STATUS_HELD
+		}
+		record := &MessageRecord{
+			DocType:    K_MESSAGE_RECORD_DOC_TYPE,
+			Direction:  MESSAGE_DIRECTION_INBOUND,
+			Status:     status,
+			Key:        msg.PacketHash,
+			SrcDomain:  msg.From,
+			DestDomain: local,
+			Seq:        msg.Seq,
+			MsgType:    msg.MsgType,
+			Sender:     hex.EncodeToString(msg.Identity[:]),
+			Receiver:   hex.EncodeToString(msg.Receiver[:]),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := putMessageRecord(stub, inboundRecordKey(msg.From, msg.PacketHash), record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 更新消息记录的状态，没有登记的消息(如升级前发送的消息)跳过
+func (bs *CrossChain) updateMessageRecord(stub shim.ChaincodeStubInterface, key string, status string) error {
+	var record MessageRecord
+	if has, err := getJSONState(stub, key, &record); err != nil || !has {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	record.Status, record.UpdatedAt = status, now
+	return putMessageRecord(stub, key, &record)
+}
+
+// 富查询消息记录，仅CouchDB
+// args[0] CouchDB查询，可以只有selector，如{"direction":"outbound","status":"pending","createdAt":{"$lt":1700000000}}，
+// 也可以是带selector、sort、use_index的完整查询，查询条件自动加上docType，只返回消息记录
+// args[1] 每页条数(可选)
+// args[2] 书签(可选)
+func (bs *CrossChain) queryMessages(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	query, err := messageRecordQuery(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid query"))
+	}
+	pageSize, bookmark, err := parsePageArgs(args[1:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+	iter, meta, err := stub.GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to query messages, rich query requires CouchDB"))
+	}
+	defer iter.Close()
+
+	page := MessageRecordPage{Messages: []MessageRecord{}}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to iterate state"))
+		}
+		var record MessageRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse message record %s", kv.Key))
+		}
+		page.Messages = append(page.Messages, record)
+	}
+	if meta != nil && meta.GetFetchedRecordsCount() >= pageSize {
+		page.Bookmark = meta.GetBookmark()
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
+
+// 把调用方的查询限定为消息记录
+func messageRecordQuery(raw string) (string, error) {
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &query); err != nil || query == nil {
+		return "", fmt.Errorf("query(%s) format error: must be a json object", raw)
+	}
+	selector, full := query["selector"]
+	if !full {
+		selector, query = query, map[string]interface{}{}
+	}
+	// 只返回完整的文档，分页由参数控制
+	delete(query, "fields")
+	delete(query, "limit")
+	delete(query, "skip")
+	query["selector"] = map[string]interface{}{
+		"$and": []interface{}{map[string]interface{}{"docType": K_MESSAGE_RECORD_DOC_TYPE}, selector},
+	}
+	bz, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return string(bz), nil
+}
+
+// 查询消息记录的修改历史，按提交顺序返回
+// args[0] 发送消息的key，或接收消息的来源域名
+// args[1] 接收消息的sha256(AM报文)(hex)，查询发送消息时不填
+func (bs *CrossChain) queryMessageHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var key string
+	switch len(args) {
+	case 1:
+		key = outboundRecordKey(args[0])
+	case 2:
+		key = inboundRecordKey(args[0], args[1])
+	default:
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	entries, err := readKeyHistory(stub, key, TIMELINE_KIND_MESSAGE)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read key history"))
+	}
+	history := MessageHistory{Key: key, Entries: []MessageHistoryEntry{}}
+	for _, e := range entries {
+		entry := MessageHistoryEntry{TxId: e.TxId, Timestamp: e.Timestamp, Nanos: e.Nanos, IsDelete: e.IsDelete}
+		if !e.IsDelete {
+			entry.Record = new(MessageRecord)
+			if err := json.Unmarshal(e.Value, entry.Record); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse message record of tx %s", e.TxId))
+			}
+		}
+		history.Entries = append(history.Entries, entry)
+	}
+	if len(history.Entries) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no history of message record %s", key))
+	}
+	raw, _ := json.Marshal(history)
+	return shim.Success(raw)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+// MockStub不支持富查询，测试中只按selector中字符串字段的相等条件过滤消息记录
+type richQueryStub struct {
+	*shimtest.MockStub
+	query string
+}
+
+func (s *richQueryStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	s.query = query
+	var q struct {
+		Selector struct {
+			And []map[string]interface{} `json:"$and"`
+		} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, nil, err
+	}
+	it := &sliceIterator{}
+	for elem := s.Keys.Front(); elem != nil; elem = elem.Next() {
+		var doc map[string]interface{}
+		if json.Unmarshal(s.State[elem.Value.(string)], &doc) != nil {
+			continue
+		}
+		matched := true
+		for _, cond := range q.Selector.And {
+			for field, value := range cond {
+				if v, ok := value.(string); ok && doc[field] != v {
+					matched = false
+				}
+			}
+		}
+		if matched {
+			it.kvs = append(it.kvs, &queryresult.KV{Key: elem.Value.(string), Value: s.State[elem.Value.(string)]})
+		}
+	}
+	return it, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(it.kvs))}, nil
+}
+
+func TestMessageRecords(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	for _, args := range [][]string{
+		{"oracleAdminManage", "setExpectedDomain", "fabric.test"},
+		{"oracleAdminManage", "registerSha256Invert", "bizcc"},
+		{"setRelayConfig", "true", "60"},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, args...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	stub.MockPeerChaincode("bizcc", shimtest.NewMockStub("bizcc", new(CrossChainTest)), "")
+
+	receiver := sha256.Sum256([]byte("dest"))
+	var keys []string
+	for _, fn := range []string{"sendMessage", "sendUnorderedMessage"} {
+		res := InvokeWithStrings(t, stub, sp, fn, "dest.com", hex.EncodeToString(receiver[:]), "hello", "n")
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		var msg oraclelogic.OutboundMessage
+		_ = json.Unmarshal(res.Payload, &msg)
+		keys = append(keys, msg.Key)
+	}
+	getRecord := func(key string) MessageRecord {
+		var record MessageRecord
+		if err := json.Unmarshal(stub.State[key], &record); err != nil {
+			t.Fatalf("no record %s: %v", key, err)
+		}
+		return record
+	}
+	ordered := getRecord(outboundRecordKey(keys[0]))
+	if ordered.DocType != K_MESSAGE_RECORD_DOC_TYPE || ordered.Direction != MESSAGE_DIRECTION_OUTBOUND || ordered.Status != MESSAGE_STATUS_PENDING ||
+		ordered.SrcDomain != "fabric.test" || ordered.DestDomain != "dest.com" || ordered.Seq != 0 || ordered.MsgType != oraclelogic.K_MSG_TYPE_ORDERED ||
+		ordered.Receiver != hex.EncodeToString(receiver[:]) || ordered.Key != keys[0] {
+		t.Fatalf("unexpected record: %+v", ordered)
+	}
+	if unordered := getRecord(outboundRecordKey(keys[1])); unordered.Seq != oraclelogic.K_UNORDERED_MSG_SEQ || unordered.MsgType != oraclelogic.K_MSG_TYPE_UNORDERED {
+		t.Fatalf("unexpected record: %+v", unordered)
+	}
+
+	bs := NewCrossChain()
+	if res := CallWithTimestamp(stub, 2000, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.ackRelay(stub, []string{keys[0]})
+	}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if record := getRecord(outboundRecordKey(keys[0])); record.Status != MESSAGE_STATUS_DELIVERED || record.UpdatedAt != 2000 || record.CreatedAt != ordered.CreatedAt ||
+		record.TxId != txid || record.UpdatedBy != testCertHash(TEST_ADMIN_CERT) {
+		t.Fatalf("unexpected acked record: %+v", record)
+	}
+
+	bizcc := sha256.Sum256([]byte("bizcc"))
+	pkgs := MockAMPackages(t, "fabric.test", bizcc, oraclelogic.K_MSG_TYPE_ORDERED, "inbound")
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", pkgs[0])); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkt, _ := hex.DecodeString(pkgs[0])
+	inbound := getRecord(inboundRecordKey("src.com", sha256Hex(pkt)))
+	if inbound.Direction != MESSAGE_DIRECTION_INBOUND || inbound.Status != MESSAGE_STATUS_DELIVERED || inbound.SrcDomain != "src.com" ||
+		inbound.DestDomain != "fabric.test" || inbound.Receiver != hex.EncodeToString(bizcc[:]) || inbound.Seq != 0 || inbound.MsgType != oraclelogic.K_MSG_TYPE_ORDERED {
+		t.Fatalf("unexpected inbound record: %+v", inbound)
+	}
+
+	query := func(args ...string) (MessageRecordPage, *richQueryStub, pb.Response) {
+		rich := &richQueryStub{MockStub: stub}
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		res := bs.queryMessages(rich, args)
+		var page MessageRecordPage
+		_ = json.Unmarshal(res.Payload, &page)
+		return page, rich, res
+	}
+	page, rich, res := query(`{"direction":"outbound","status":"pending"}`)
+	if res.Status != shim.OK || len(page.Messages) != 1 || page.Messages[0].Key != keys[1] {
+		t.Fatalf("unexpected pending messages: %+v %s", page, res.Message)
+	}
+	if !strings.Contains(rich.query, `{"docType":"`+K_MESSAGE_RECORD_DOC_TYPE+`"}`) {
+		t.Fatalf("query should be limited to message records: %s", rich.query)
+	}
+	_, rich, res = query(`{"selector":{"srcDomain":"src.com"},"sort":[{"createdAt":"asc"}],"fields":["key"],"limit":1}`, "10")
+	if res.Status != shim.OK || strings.Contains(rich.query, "fields") || strings.Contains(rich.query, "limit") || !strings.Contains(rich.query, `"sort"`) {
+		t.Fatalf("unexpected query: %s %s", rich.query, res.Message)
+	}
+	for _, args := range [][]string{{}, {"[]"}, {"null"}, {"{}", "0"}} {
+		if _, _, res := query(args...); res.Status == shim.OK {
+			t.Fatalf("%v should be rejected", args)
+		}
+	}
+}
+
+func TestMessageHistory(t *testing.T) {
+	mock := shimtest.NewMockStub("crosschain", new(CrossChain))
+	stub := &historyStub{MockStub: mock, history: map[string][]*queryresult.KeyModification{}}
+	bs := NewCrossChain()
+
+	record := MessageRecord{DocType: K_MESSAGE_RECORD_DOC_TYPE, Direction: MESSAGE_DIRECTION_OUTBOUND, Status: MESSAGE_STATUS_PENDING, Key: "m1", TxId: "send"}
+	pending, _ := json.Marshal(&record)
+	record.Status, record.TxId, record.UpdatedBy = MESSAGE_STATUS_DELIVERED, "ack", "relayer"
+	delivered, _ := json.Marshal(&record)
+	stub.history[outboundRecordKey("m1")] = []*queryresult.KeyModification{
+		{TxId: "send", Value: pending, Timestamp: &timestamp.Timestamp{Seconds: 10}},
+		{TxId: "ack", Value: delivered, Timestamp: &timestamp.Timestamp{Seconds: 20}},
+		{TxId: "prune", IsDelete: true, Timestamp: &timestamp.Timestamp{Seconds: 30}},
+	}
+	stub.history[inboundRecordKey("src.com", "hash")] = []*queryresult.KeyModification{
+		{TxId: "recv", Value: []byte("{"), Timestamp: &timestamp.Timestamp{Seconds: 10}},
+	}
+
+	res := bs.queryMessageHistory(stub, []string{"m1"})
+	var history MessageHistory
+	if err := json.Unmarshal(res.Payload, &history); err != nil {
+		t.Fatal(res.Message)
+	}
+	if e := history.Entries; history.Key != outboundRecordKey("m1") || len(e) != 3 ||
+		e[0].Record.Status != MESSAGE_STATUS_PENDING || e[0].Timestamp != 10 ||
+		e[1].Record.Status != MESSAGE_STATUS_DELIVERED || e[1].Record.UpdatedBy != "relayer" || e[1].TxId != "ack" ||
+		!e[2].IsDelete || e[2].Record != nil {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	for _, args := range [][]string{{}, {"missing"}, {"src.com", "hash"}, {"a", "b", "c"}} {
+		if res := bs.queryMessageHistory(stub, args); res.Status == shim.OK {
+			t.Fatalf("%v should be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"chaincodepb"
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 状态迁移
+// 从旧版本跨链链码升级时，在升级交易的Init中调用 migrate(fromVersion)，按版本依次执行迁移步骤，
+// 完成后在状态中记录当前的schema版本。已记录的版本与fromVersion不一致时拒绝迁移，
+// 同一版本不会迁移两次。
+//
+// v1(v1.4链码)只有AM层的收发序号 oraclelogic_recv_seq_${seqId}/oraclelogic_send_seq_${seqId}
+// 和来源域名的parser oraclelogic_parser_info_${domain}。这些key由oraclelogic继续读写，迁移时不改动，
+// 而是逐个写入复合key的通道表和parser表，记录迁移时的序号；接收通道同时用AM层的期望序号初始化
+// SDP层的投递序号，升级后不会投递序号小于升级前的消息。
+const (
+	// crosschain_schema_version -> 十进制版本号
+	K_SCHEMA_VERSION = CROSSCHAIN_PREFIX + "schema_version"
+
+	// ${K_CHANNEL_OBJECT_TYPE}~${recv|send}~${seqId} -> ChannelRecord
+	K_CHANNEL_OBJECT_TYPE = CROSSCHAIN_PREFIX + "channel"
+
+	// ${K_PARSER_OBJECT_TYPE}~${domain} -> ParserRecord
+	K_PARSER_OBJECT_TYPE = CROSSCHAIN_PREFIX + "parser"
+
+	SCHEMA_VERSION_V1 = 1
+	SCHEMA_VERSION    = 2
+)
+
+type ChannelRecord struct {
+	Direction string `json:"direction"`
+	SeqId     string `json:"seqId"`
+	// 迁移时AM层的下一个序号
+	Seqno      uint32 `json:"seqno"`
+	MigratedAt int64  `json:"migratedAt"`
+}
+
+type ParserRecord struct {
+	Domain     string `json:"domain"`
+	Parser     string `json:"parser"`
+	MigratedAt int64  `json:"migratedAt"`
+}
+
+type MigrationResult struct {
+	From     int `json:"from"`
+	To       int `json:"to"`
+	Channels int `json:"channels"`
+	Parsers  int `json:"parsers"`
+}
+
+// 迁移步骤，key为迁移前的版本
+var migrationSteps = map[int]func(bs *CrossChain, stub shim.ChaincodeStubInterface, result *MigrationResult) error{
+	SCHEMA_VERSION_V1: (*CrossChain).migrateFromV1,
+}
+
+// 未记录版本时返回0
+func getSchemaVersion(stub shim.ChaincodeStubInterface) (int, error) {
+	raw, err := stub.GetState(K_SCHEMA_VERSION)
+	if err != nil || len(raw) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
+
+// 按key前缀遍历状态
+func scanPrefix(stub shim.ChaincodeStubInterface, prefix string, fn func(key string, value []byte) error) error {
+	iter, err := stub.GetStateByRange(prefix, prefix+string(utf8.MaxRune))
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		if err := fn(kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *CrossChain) migrateFromV1(stub shim.ChaincodeStubInterface, result *MigrationResult) error {
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	for _, channel := range [][2]string{
+		{TIMELINE_DIRECTION_RECV, oraclelogic.K_RECV_SEQ_PREFIX},
+		{TIMELINE_DIRECTION_SEND, oraclelogic.K_SEND_SEQ_PREFIX},
+	} {
+		direction, prefix := channel[0], channel[1]
+		err := scanPrefix(stub, prefix, func(key string, value []byte) error {
+			var nounce chaincodepb.MsgNounce
+			if err := proto.Unmarshal(value, &nounce); err != nil {
+				return fmt.Errorf("failed to decode seq %s: %v", key, err)
+			}
+			seqId := strings.TrimPrefix(key, prefix)
+			record := &ChannelRecord{Direction: direction, SeqId: seqId, Seqno: nounce.Seqno, MigratedAt: now}
+			recordKey, err := stub.CreateCompositeKey(K_CHANNEL_OBJECT_TYPE, []string{direction, seqId})
+			if err != nil {
+				return err
+			}
+			if err := putJSONState(stub, recordKey, record); err != nil {
+				return err
+			}
+			// 接收通道的seqId与SDP通道的key使用相同的哈希
+			if direction == TIMELINE_DIRECTION_RECV {
+				var seq SDPSeq
+				if has, err := getJSONState(stub, K_SDP_SEQ_PREFIX+seqId, &seq); err != nil {
+					return err
+				} else if !has {
+					seq.Next, seq.UpdatedAt = nounce.Seqno, now
+					if err := putJSONState(stub, K_SDP_SEQ_PREFIX+seqId, &seq); err != nil {
+						return err
+					}
+				}
+			}
+			result.Channels++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	parserPrefix := oraclelogic.KMychainParserInfo + "_"
+	return scanPrefix(stub, parserPrefix, func(key string, value []byte) error {
+		domain := strings.TrimPrefix(key, parserPrefix)
+		recordKey, err := stub.CreateCompositeKey(K_PARSER_OBJECT_TYPE, []string{domain})
+		if err != nil {
+			return err
+		}
+		if err := putJSONState(stub, recordKey, &ParserRecord{Domain: domain, Parser: string(value), MigratedAt: now}); err != nil {
+			return err
+		}
+		result.Parsers++
+		return nil
+	})
+}
+
+// 从旧版本迁移状态，在升级交易的Init中调用
+// args[0] 迁移前的schema版本
+func (bs *CrossChain) migrate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if ret := bs.Os.AdminManage(stub, "checkAdmin", []string{}); ret.Status != shim.OK {
+		return errorResponse("migrate", crosserr.FromMessage(crosserr.CodeUnauthorized, ret.Message))
+	}
+	from, err := strconv.Atoi(args[0])
+	if err != nil || from < SCHEMA_VERSION_V1 || from >= SCHEMA_VERSION {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid schema version to migrate from: %s", args[0]))
+	}
+	current, err := getSchemaVersion(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get schema version"))
+	}
+	if current != 0 && current != from {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "schema version is %d, can not migrate from %d", current, from))
+	}
+
+	result := &MigrationResult{From: from, To: SCHEMA_VERSION}
+	for v := from; v < SCHEMA_VERSION; v++ {
+		if err := migrationSteps[v](bs, stub, result); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to migrate from version %d", v))
+		}
+	}
+	if err := stub.PutState(K_SCHEMA_VERSION, []byte(strconv.Itoa(SCHEMA_VERSION))); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	bz, _ := json.Marshal(result)
+	return shim.Success(bz)
+}
+
+// 查询状态的schema版本，未迁移过的链码返回0
+func (bs *CrossChain) querySchemaVersion(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	version, err := getSchemaVersion(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get schema version"))
+	}
+	return shim.Success([]byte(strconv.Itoa(version)))
+}
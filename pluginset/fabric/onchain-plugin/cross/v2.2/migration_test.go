@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"strings"
+	"testing"
+)
+
+func TestMigrateFromV1(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	author, receiver := sha256.Sum256([]byte("author")), sha256.Sum256([]byte("receiver"))
+	authorHex, receiverHex := hex.EncodeToString(author[:]), hex.EncodeToString(receiver[:])
+
+	// 构造v1链码的状态
+	for _, args := range [][]string{
+		{"oracleAdminManage", "setRecvP2PMsgSeq", "src.com", authorHex, receiverHex, "7"},
+		{"oracleAdminManage", "setSendP2PMsgSeq", "dst.com", authorHex, receiverHex, "3"},
+		{"setDomainParser", "src.com", "fabric_14"},
+	} {
+		if res := InvokeWithStrings(t, stub, sp, args...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "querySchemaVersion"); res.Status != shim.OK || string(res.Payload) != "0" {
+		t.Fatalf("unexpected schema version: %s %s", res.Payload, res.Message)
+	}
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := stub.MockInit(txid, [][]byte{[]byte("migrate"), []byte("1")}); res.Status == shim.OK {
+		t.Fatal("non-admin migration should be rejected")
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	for _, from := range []string{"0", "2", "v1"} {
+		if res := stub.MockInit(txid, [][]byte{[]byte("migrate"), []byte(from)}); res.Status == shim.OK {
+			t.Fatalf("migration from %s should be rejected", from)
+		}
+	}
+	res := stub.MockInit(txid, [][]byte{[]byte("migrate"), []byte("1")})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var result MigrationResult
+	if err := json.Unmarshal(res.Payload, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result != (MigrationResult{From: 1, To: SCHEMA_VERSION, Channels: 2, Parsers: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "querySchemaVersion"); string(res.Payload) != "2" {
+		t.Fatalf("unexpected schema version: %s", res.Payload)
+	}
+
+	// AM层的序号不变，SDP层从AM层的期望序号开始
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "queryRecvP2PMsgSeq", "src.com", authorHex, receiverHex); string(res.Payload) != `{"result":7}` {
+		t.Fatalf("unexpected recv seq: %s", res.Payload)
+	}
+	res = InvokeWithStrings(t, stub, sp, "querySDPSeq", "src.com", authorHex, receiverHex)
+	var seq SDPSeq
+	if err := json.Unmarshal(res.Payload, &seq); err != nil {
+		t.Fatal(err)
+	}
+	if seq.Next != 7 || seq.SenderDomain != "src.com" || seq.SenderID != authorHex {
+		t.Fatalf("unexpected sdp seq: %+v", seq)
+	}
+
+	seqId := strings.TrimPrefix(NewCrossChain().Os.SendSeqKey("dst.com", author, receiver), "oraclelogic_send_seq_")
+	key, _ := stub.CreateCompositeKey(K_CHANNEL_OBJECT_TYPE, []string{"send", seqId})
+	var channel ChannelRecord
+	if has, err := getJSONState(stub, key, &channel); err != nil || !has || channel.Seqno != 3 {
+		t.Fatalf("unexpected channel record: %+v %v", channel, err)
+	}
+	key, _ = stub.CreateCompositeKey(K_PARSER_OBJECT_TYPE, []string{"src.com"})
+	var parser ParserRecord
+	if has, err := getJSONState(stub, key, &parser); err != nil || !has || parser.Parser != "fabric_14" {
+		t.Fatalf("unexpected parser record: %+v %v", parser, err)
+	}
+
+	// 不能重复迁移
+	if res := stub.MockInit(txid, [][]byte{[]byte("migrate"), []byte("1")}); res.Status == shim.OK || !strings.Contains(res.Message, "schema version is 2") {
+		t.Fatalf("repeated migration should be rejected: %s", res.Message)
+	}
+	// 普通Init不受影响
+	if res := stub.MockInit(txid, [][]byte{[]byte("Init")}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
@@ -0,0 +1,64 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHistoryQueryIterator is an autogenerated mock type for the HistoryQueryIteratorInterface type
+type MockHistoryQueryIterator struct {
+	mock.Mock
+}
+
+// Close provides a mock function with given fields:
+func (_m *MockHistoryQueryIterator) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// HasNext provides a mock function with given fields:
+func (_m *MockHistoryQueryIterator) HasNext() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Next provides a mock function with given fields:
+func (_m *MockHistoryQueryIterator) Next() (*queryresult.KeyModification, error) {
+	ret := _m.Called()
+
+	var r0 *queryresult.KeyModification
+	if rf, ok := ret.Get(0).(func() *queryresult.KeyModification); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*queryresult.KeyModification)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,64 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStateQueryIterator is an autogenerated mock type for the StateQueryIteratorInterface type
+type MockStateQueryIterator struct {
+	mock.Mock
+}
+
+// Close provides a mock function with given fields:
+func (_m *MockStateQueryIterator) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// HasNext provides a mock function with given fields:
+func (_m *MockStateQueryIterator) HasNext() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Next provides a mock function with given fields:
+func (_m *MockStateQueryIterator) Next() (*queryresult.KV, error) {
+	ret := _m.Called()
+
+	var r0 *queryresult.KV
+	if rf, ok := ret.Get(0).(func() *queryresult.KV); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*queryresult.KV)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
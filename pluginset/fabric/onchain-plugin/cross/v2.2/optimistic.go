@@ -0,0 +1,351 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+	"strings"
+	"tlv"
+)
+
+// 乐观验证模块
+// 对开启乐观模式的来源域名，中继提交的消息不做完整的证明校验，只解析AM报文后暂存为待确认声明(claim)。
+// 窗口期内任何人都可以提交欺诈证明，默认为同一序号冲突消息的证明，链码校验成立后撤销该消息并罚没提交者的保证金；
+// 窗口期结束后消息才会检查序号，并与直接提交的消息走同一投递流程。
+const (
+	// crosschain_optimistic_window_${domain} -> 窗口长度(秒)
+	K_OPTIMISTIC_WINDOW_PREFIX = CROSSCHAIN_PREFIX + "optimistic_window_"
+
+	// composite key: crosschain_optimistic~${claimId}
+	K_OPTIMISTIC_OBJECT_TYPE = CROSSCHAIN_PREFIX + "optimistic"
+
+	CLAIM_STATUS_PENDING   = "PENDING"
+	CLAIM_STATUS_FINALIZED = "FINALIZED"
+	CLAIM_STATUS_REVERTED  = "REVERTED"
+)
+
+type OptimisticClaim struct {
+	ClaimId     string                      `json:"claimId"`
+	ServiceId   string                      `json:"serviceId"`
+	SrcDomain   string                      `json:"srcDomain"`
+	AMPackage   string                      `json:"amPackage"` // hex
+	Proof       string                      `json:"proof"`     // hex，未校验
+	Hint        string                      `json:"hint"`
+	Message     oraclelogic.RecvAuthMessage `json:"message"`
+	Submitter   string                      `json:"submitter"` // 提交者证书sha256(hex)
+	Status      string                      `json:"status"`
+	SubmittedAt int64                       `json:"submittedAt"`
+	Deadline    int64                       `json:"deadline"`
+	Reverted    bool                        `json:"reverted"` // 消息效果已撤销
+	Challenger  string                      `json:"challenger"`
+	Penalized   bool                        `json:"penalized"`
+	Erased      bool                        `json:"erased,omitempty"` // 报文和消息内容已擦除，摘要见擦除回执
+}
+
+// 欺诈证明校验，返回nil表示欺诈成立
+type fraudProofVerifier func(bs *CrossChain, stub shim.ChaincodeStubInterface, claim *OptimisticClaim, fraudProof []byte) error
+
+// 按来源域名注册的欺诈证明校验，未注册时使用verifyConflictProof
+var fraudProofVerifiers = map[string]fraudProofVerifier{}
+
+func registerFraudProofVerifier(domain string, verifier fraudProofVerifier) {
+	fraudProofVerifiers[domain] = verifier
+}
+
+// 默认的欺诈证明格式：来源链上同一发送方、同一接收方、同一序号但报文不同的有序消息的完整证明
+type ConflictProof struct {
+	Proof []byte `tlv:"0"`
+	Hint  string `tlv:"1,omitempty"`
+}
+
+// 默认的欺诈证明校验：冲突消息的证明必须通过完整校验，且与声明的消息占用同一有序通道的同一序号。
+// 无序消息没有序号可以冲突，只能由注册的校验规则挑战
+func verifyConflictProof(bs *CrossChain, stub shim.ChaincodeStubInterface, claim *OptimisticClaim, fraudProof []byte) error {
+	if claim.Message.MsgType != oraclelogic.K_MSG_TYPE_ORDERED {
+		return fmt.Errorf("no fraud proof verifier for %s message from %s", claim.Message.MsgType, claim.SrcDomain)
+	}
+	var conflict ConflictProof
+	if err := tlv.Unmarshal(fraudProof, &conflict); err != nil {
+		return crosserr.Wrap(crosserr.CodeInvalidArgs, err, "fraud proof format error")
+	}
+	if len(conflict.Proof) == 0 {
+		return errors.New("fraud proof carries no conflicting proof")
+	}
+	domain, amPkt, ret := bs.Os.VerifyMychainProof(stub, claim.ServiceId, conflict.Proof, conflict.Hint)
+	if ret.Status != shim.OK {
+		return fmt.Errorf("conflicting proof verify failed: %s", ret.Message)
+	}
+	if domain != claim.SrcDomain {
+		return fmt.Errorf("conflicting proof is from domain %s, claim is from %s", domain, claim.SrcDomain)
+	}
+	if strings.EqualFold(amPkt, claim.AMPackage) {
+		return errors.New("conflicting proof carries the claimed AM package")
+	}
+	msg, _, ret := bs.Os.ParseAMPackage(stub, domain, amPkt)
+	if ret.Status != shim.OK {
+		return fmt.Errorf("conflicting AM package parse failed: %s", ret.Message)
+	}
+	if msg.MsgType != oraclelogic.K_MSG_TYPE_ORDERED || msg.Seq != claim.Message.Seq ||
+		msg.Identity != claim.Message.Identity || msg.Receiver != claim.Message.Receiver {
+		return errors.New("conflicting message does not take the claimed sequence")
+	}
+	return nil
+}
+
+func (bs *CrossChain) getOptimisticWindow(stub shim.ChaincodeStubInterface, domain string) (int64, error) {
+	raw, err := stub.GetState(K_OPTIMISTIC_WINDOW_PREFIX + domain)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(raw), 10, 64)
+}
+
+func (bs *CrossChain) getOptimisticClaim(stub shim.ChaincodeStubInterface, claimId string) (*OptimisticClaim, string, error) {
+	key, err := stub.CreateCompositeKey(K_OPTIMISTIC_OBJECT_TYPE, []string{claimId})
+	if err != nil {
+		return nil, "", err
+	}
+	var claim OptimisticClaim
+	has, err := getJSONState(stub, key, &claim)
+	if err != nil || !has {
+		return nil, key, err
+	}
+	return &claim, key, nil
+}
+
+// 设置来源域名的乐观验证模式
+// args[0] 来源域名
+// args[1] 挑战窗口长度(秒)，0表示关闭
+func (bs *CrossChain) setOptimisticMode(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	window, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || window < 0 || args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", args[0], args[1]))
+	}
+
+	if window == 0 {
+		err = stub.DelState(K_OPTIMISTIC_WINDOW_PREFIX + args[0])
+	} else {
+		err = stub.PutState(K_OPTIMISTIC_WINDOW_PREFIX+args[0], []byte(args[1]))
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put optimistic window"))
+	}
+	return shim.Success(nil)
+}
+
+// 以乐观模式提交跨链消息，返回声明id
+// args[0] oracle service id
+// args[1] 来源域名
+// args[2] AM报文(hex)
+// args[3] 证明(hex)，提交时不校验
+// args[4] 证明提示信息
+func (bs *CrossChain) recvOptimisticMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var (
+		serviceId = args[0]
+		srcDomain = args[1]
+		amPkt     = args[2]
+		proof     = args[3]
+		hint      = args[4]
+	)
+	if _, err := hex.DecodeString(proof); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "proof format error"))
+	}
+
+	window, err := bs.getOptimisticWindow(stub, srcDomain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic window"))
+	}
+	if window == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "optimistic mode is not enabled for domain %s", srcDomain))
+	}
+
+	msg, _, ret := bs.Os.ParseAMPackage(stub, srcDomain, amPkt)
+	if ret.Status != shim.OK {
+		return ret
+	}
+	// 与recvMessage相同：登记了委员会的域名不能绕过背书，设置了信任根时PTC证书必须链到信任根
+	if err := bs.checkPTCBypass(stub, []oraclelogic.RecvAuthMessage{msg}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check PTC committee"))
+	}
+	if err := bs.checkPTCCertChain(stub, []oraclelogic.RecvAuthMessage{msg}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check PTC certificate"))
+	}
+
+	_, submitter, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	h := sha256.Sum256([]byte(stub.GetTxID() + "_" + amPkt))
+	claimId := hex.EncodeToString(h[:])
+	claim, key, err := bs.getOptimisticClaim(stub, claimId)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic claim"))
+	}
+	if claim != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "claim %s already exists", claimId))
+	}
+
+	claim = &OptimisticClaim{
+		ClaimId:     claimId,
+		ServiceId:   serviceId,
+		SrcDomain:   srcDomain,
+		AMPackage:   amPkt,
+		Proof:       proof,
+		Hint:        hint,
+		Message:     msg,
+		Submitter:   submitter,
+		Status:      CLAIM_STATUS_PENDING,
+		SubmittedAt: now,
+		Deadline:    now + window,
+	}
+	if err := putJSONState(stub, key, claim); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put claim"))
+	}
+	if err := bs.trackRetention(stub, srcDomain, &RetentionEntry{Kind: ERASE_KIND_OPTIMISTIC, Key: key}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to track retention"))
+	}
+	if err := bs.pruneRetention(stub, srcDomain); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to prune expired records"))
+	}
+	return shim.Success([]byte(claimId))
+}
+
+// 提交欺诈证明，任何人都可以在窗口期内调用
+// args[0] 声明id
+// args[1] 欺诈证明(hex)，默认校验规则下为TLV编码的ConflictProof
+func (bs *CrossChain) submitFraudProof(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	fraudProof, err := hex.DecodeString(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "fraud proof format error"))
+	}
+	if len(fraudProof) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "fraud proof is empty"))
+	}
+
+	claim, key, err := bs.getOptimisticClaim(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic claim"))
+	}
+	if claim == nil || claim.Status != CLAIM_STATUS_PENDING {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "claim %s is not pending", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if now >= claim.Deadline {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "challenge window of claim %s has closed", args[0]))
+	}
+
+	verifier, ok := fraudProofVerifiers[claim.SrcDomain]
+	if !ok {
+		verifier = verifyConflictProof
+	}
+	if err := verifier(bs, stub, claim, fraudProof); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "fraud proof rejected"))
+	}
+
+	_, challenger, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	claim.Status = CLAIM_STATUS_REVERTED
+	claim.Reverted = true
+	claim.Challenger = challenger
+
+	// 罚没提交者的全部保证金
+	bond, bondKey, err := bs.getRelayerBond(stub, claim.Submitter)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond != nil && bond.Status != BOND_STATUS_WITHDRAWN && bond.Amount > 0 {
+		evidence := append([]byte(claim.ClaimId), fraudProof...)
+		if err := bs.applyBondSlash(stub, bond, bondKey, bond.Amount, SLASH_REASON_FRAUD_PROOF, evidence); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to slash bond"))
+		}
+		claim.Penalized = true
+	}
+
+	if err := putJSONState(stub, key, claim); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put claim"))
+	}
+	return shim.Success(nil)
+}
+
+// 窗口期结束后确认消息：检查序号并按deliverInbound的流程投递，任何人都可以调用
+// args[0] 声明id
+func (bs *CrossChain) finalizeOptimisticMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	claim, key, err := bs.getOptimisticClaim(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic claim"))
+	}
+	if claim == nil || claim.Status != CLAIM_STATUS_PENDING {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "claim %s is not pending", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if now < claim.Deadline {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "challenge window of claim %s is still open until %d", args[0], claim.Deadline))
+	}
+
+	// 有序消息在确认时才消耗序号，被撤销的消息不会影响后续消息；
+	// 之后与relayer直接提交的消息走同一投递流程，回执、限流、隐私路由、争议窗口和事件都在确认时生效
+	if ret := bs.Os.RecvAMPackage(stub, claim.SrcDomain, claim.AMPackage); ret.Status != shim.OK {
+		return ret
+	}
+	if ret := bs.deliverInbound(stub, []oraclelogic.RecvAuthMessage{claim.Message}, nil); ret.Status != shim.OK {
+		return ret
+	}
+
+	claim.Status = CLAIM_STATUS_FINALIZED
+	if err := putJSONState(stub, key, claim); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put claim"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询乐观模式提交的声明
+// args[0] 声明id
+func (bs *CrossChain) queryOptimisticClaim(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	claim, _, err := bs.getOptimisticClaim(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get optimistic claim"))
+	}
+	if claim == nil {
+		return shim.Success(nil)
+	}
+	raw, _ := json.Marshal(claim)
+	return shim.Success(raw)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"tlv"
+)
+
+// 构造oracle节点签名的证明，节点公钥以来源域名写入信任缓存
+func mockSignedProof(t *testing.T, stub *shimtest.MockStub, key *rsa.PrivateKey, domain string, pkg string) []byte {
+	amPkt, err := hex.DecodeString(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pkHash := sha256.Sum256(der)
+	entry, _ := json.Marshal(&oraclelogic.TrustCacheEntry{DomainName: domain, RsaPubKey: der})
+	stub.State[oraclelogic.K_TRUST_CACHE_PREFIX+hex.EncodeToString(pkHash[:])] = entry
+
+	body := make([]byte, 12)
+	binary.LittleEndian.PutUint32(body[8:], uint32(len(amPkt)))
+	items := []tlv.Item{{Tag: 0, Value: pkHash[:]}, {Tag: 5, Value: append(body, amPkt...)}}
+	digest := sha256.Sum256(tlv.EncodeItems(items))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	items = append(items, tlv.Item{Tag: 6, Value: sig})
+	return append(make([]byte, tlv.PACKET_HEADER_SIZE), tlv.EncodeItems(items)...)
+}
+
+func TestOptimisticMode(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	relayer := testCertHash(TEST_ADMIN_CERT)
+
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "hello")
+
+	// 未开启乐观模式时拒绝
+	if res := InvokeWithStrings(t, stub, sp, "recvOptimisticMessage", "svc", "src.com", pkgs[0], "00", ""); res.Status == shim.OK {
+		t.Fatal("optimistic message should be rejected when mode is off")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setOptimisticMode", "src.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "100", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "confirmBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 伪造的声明，与来源链上序号0的消息内容不同
+	forgedPkg := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "forged")[0]
+	res := InvokeWithStrings(t, stub, sp, "recvOptimisticMessage", "svc", "src.com", forgedPkg, "00", "")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	forged := string(res.Payload)
+
+	// 空的欺诈证明、不冲突的证明都不成立
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	srcKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	sameProof, _ := tlv.Marshal(&ConflictProof{Proof: mockSignedProof(t, stub, srcKey, "src.com", forgedPkg)})
+	otherSeq := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "first", "second")[1]
+	otherSeqProof, _ := tlv.Marshal(&ConflictProof{Proof: mockSignedProof(t, stub, srcKey, "src.com", otherSeq)})
+	otherDomainProof, _ := tlv.Marshal(&ConflictProof{Proof: mockSignedProof(t, stub, otherKey, "other.com", pkgs[0])})
+	unsigned := mockSignedProof(t, stub, srcKey, "src.com", pkgs[0])
+	unsigned[len(unsigned)-1] ^= 1
+	unsignedProof, _ := tlv.Marshal(&ConflictProof{Proof: unsigned})
+	for _, fraudProof := range [][]byte{nil, sameProof, otherSeqProof, otherDomainProof, unsignedProof} {
+		if res := InvokeWithStrings(t, stub, sp, "submitFraudProof", forged, hex.EncodeToString(fraudProof)); res.Status == shim.OK {
+			t.Fatalf("fraud proof %x should be rejected", fraudProof)
+		}
+	}
+
+	// 同一序号冲突消息的证明成立，消息撤销并罚没保证金
+	conflictProof, _ := tlv.Marshal(&ConflictProof{Proof: mockSignedProof(t, stub, srcKey, "src.com", pkgs[0])})
+	if res := InvokeWithStrings(t, stub, sp, "submitFraudProof", forged, hex.EncodeToString(conflictProof)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+
+	var claim OptimisticClaim
+	res = InvokeWithStrings(t, stub, sp, "queryOptimisticClaim", forged)
+	_ = json.Unmarshal(res.Payload, &claim)
+	if claim.Status != CLAIM_STATUS_REVERTED || !claim.Reverted || !claim.Penalized {
+		t.Fatalf("unexpected claim: %+v", claim)
+	}
+	var bond RelayerBond
+	res = InvokeWithStrings(t, stub, sp, "queryBond", relayer)
+	_ = json.Unmarshal(res.Payload, &bond)
+	if bond.Amount != 0 || bond.Slashes[0].Reason != SLASH_REASON_FRAUD_PROOF {
+		t.Fatalf("unexpected bond: %+v", bond)
+	}
+
+	// 被撤销的消息没有消耗序号，重新提交附带同一报文的声明仍可确认
+	txid = "456"
+	res = InvokeWithStrings(t, stub, sp, "recvOptimisticMessage", "svc", "src.com", pkgs[0], "00", "")
+	txid = "123"
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	honest := string(res.Payload)
+	res = InvokeWithStrings(t, stub, sp, "queryOptimisticClaim", honest)
+	_ = json.Unmarshal(res.Payload, &claim)
+
+	bs := NewCrossChain()
+	res = CallWithTimestamp(stub, claim.Deadline-1, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeOptimisticMessage(stub, []string{honest})
+	})
+	if res.Status == shim.OK {
+		t.Fatal("finalize inside challenge window should fail")
+	}
+	res = CallWithTimestamp(stub, claim.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeOptimisticMessage(stub, []string{honest})
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "hello") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+
+	// 窗口期结束后不能再提交欺诈证明
+	res = CallWithTimestamp(stub, claim.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.submitFraudProof(stub, []string{honest, hex.EncodeToString(conflictProof)})
+	})
+	if res.Status == shim.OK {
+		t.Fatal("fraud proof after finalization should fail")
+	}
+}
+
+// 确认时与直接提交的消息走同一投递流程，提交时同样检查PTC委员会
+func TestOptimisticFinalizePipeline(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "hello")
+	if res := InvokeWithStrings(t, stub, sp, "setOptimisticMode", "src.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "src.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	res := InvokeWithStrings(t, stub, sp, "recvOptimisticMessage", "svc", "src.com", pkgs[0], "00", "")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	claimId := string(res.Payload)
+	var claim OptimisticClaim
+	res = InvokeWithStrings(t, stub, sp, "queryOptimisticClaim", claimId)
+	_ = json.Unmarshal(res.Payload, &claim)
+
+	bs := NewCrossChain()
+	res = CallWithTimestamp(stub, claim.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.finalizeOptimisticMessage(stub, []string{claimId})
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 来源域名配置了争议窗口，确认后的消息同样暂存，不直接回调业务链码
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); len(res.Payload) != 0 {
+		t.Fatalf("message should be held, got %s", res.Payload)
+	}
+	var record MessageRecord
+	if has, err := getJSONState(stub, inboundRecordKey("src.com", claim.Message.PacketHash), &record); err != nil || !has || record.Status != MESSAGE_STATUS_HELD {
+		t.Fatalf("unexpected message record: %+v", record)
+	}
+
+	// 登记了委员会的域名不能通过乐观模式绕过背书
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	nodes := []PTCCommitteeNode{{NodeId: "node0", PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}}
+	if res := InvokeWithStrings(t, stub, sp, "setPTCCommittee", "src.com", "committee", "1", mustJSON(nodes)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	txid = "456"
+	res = InvokeWithStrings(t, stub, sp, "recvOptimisticMessage", "svc", "src.com", pkgs[0], "00", "")
+	txid = "123"
+	if res.Status == shim.OK || !strings.Contains(res.Message, "PTC committee") {
+		t.Fatalf("optimistic message should require committee endorsement, got %s", res.Message)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"am"
+	"bytes"
+	"cctest"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"oracleprotocol"
+	"strings"
+	"testing"
+	"tlv"
+	"upperprotocol"
+)
+
+// 测试用的业务链码：经预言机请求读取远端状态，保存回调的结果，并向预言机开放自己的状态
+type oracleApp struct{}
+
+func (oracleApp) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (oracleApp) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	switch fn {
+	case "request":
+		ccArgs := [][]byte{[]byte(oracleprotocol.FN_REQUEST_REMOTE_STATE)}
+		for _, arg := range args {
+			ccArgs = append(ccArgs, []byte(arg))
+		}
+		return stub.InvokeChaincode("oraclecc", ccArgs, "")
+	case "put":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "onResult":
+		if err := stub.PutState("result_"+args[0], []byte(strings.Join(args[1:], ":"))); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case oracleprotocol.FN_ORACLE_READ:
+		if args[2] == "secret" {
+			return shim.Error("access denied")
+		}
+		value, err := stub.GetState(args[2])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	}
+	return shim.Error("unknown function " + fn)
+}
+
+type oracleChain struct {
+	cross, oracle, app *cctest.MockStub
+}
+
+func newOracleChain(t *testing.T) *oracleChain {
+	cross, _ := newCCTestStubs(t)
+	c := &oracleChain{
+		cross:  cross,
+		oracle: cctest.NewMockStub("oraclecc", oracleprotocol.NewChaincode("oraclecc", "crosscc")),
+		app:    cctest.NewMockStub("appcc", oracleApp{}),
+	}
+	c.cross.AddPeer(c.oracle)
+	c.oracle.AddPeer(c.cross)
+	c.oracle.AddPeer(c.app)
+	c.app.AddPeer(c.oracle)
+	if res := cross.InvokeWithStrings(upperprotocol.RegisterArgs(oracleprotocol.PROTOCOL_TYPE, "oraclecc")...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	return c
+}
+
+func (c *oracleChain) setTime(seconds int64) {
+	for _, stub := range []*cctest.MockStub{c.cross, c.oracle, c.app} {
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+	}
+}
+
+// 跨链链码保存的发出的预言机消息
+func (c *oracleChain) outbound(t *testing.T, requestId string, msgType uint8) []byte {
+	for key, value := range c.cross.State {
+		if !strings.HasPrefix(key, oraclelogic.K_CROSSCHAIN_MSG_PREFIX) {
+			continue
+		}
+		msg, err := am.Decode(value)
+		if err != nil || msg.GetProtocolType() != oracleprotocol.PROTOCOL_TYPE {
+			continue
+		}
+		var oracleMsg oracleprotocol.Message
+		if err := tlv.Unmarshal(msg.GetPayload(), &oracleMsg); err == nil && oracleMsg.Type == msgType && hex.EncodeToString(oracleMsg.RequestId) == requestId {
+			return value
+		}
+	}
+	t.Fatalf("oracle message %d of request %s not found", msgType, requestId)
+	return nil
+}
+
+func (c *oracleChain) request(t *testing.T, args ...string) *oracleprotocol.Request {
+	res := c.app.InvokeWithStrings(append([]string{"request"}, args...)...)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var request oracleprotocol.Request
+	if err := json.Unmarshal(res.Payload, &request); err != nil {
+		t.Fatal(err)
+	}
+	return &request
+}
+
+func (c *oracleChain) query(t *testing.T, requestId string) *oracleprotocol.Request {
+	res := c.oracle.InvokeWithStrings(oracleprotocol.FN_QUERY_REQUEST, requestId)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var request oracleprotocol.Request
+	if err := json.Unmarshal(res.Payload, &request); err != nil {
+		t.Fatal(err)
+	}
+	return &request
+}
+
+// 请求、回复和过期的规则见vendor/oracleprotocol，这里检查两条链经跨链链码收发请求和回复的完整流程
+func TestOracleProtocol(t *testing.T) {
+	a, b := newOracleChain(t), newOracleChain(t)
+	a.setTime(1000)
+	b.setTime(1000)
+	if res := b.app.InvokeWithStrings("put", "price", "42"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// a上的业务链码请求读取b上appcc的price，请求方为业务链码
+	request := a.request(t, "chainb.test", "appcc", "price", "60", "onResult")
+	if request.Status != oracleprotocol.STATUS_PENDING || request.Requester != "appcc" || request.Deadline != 1060 || len(request.RequestId) != 64 {
+		t.Fatalf("unexpected request: %+v", request)
+	}
+	pkg := a.outbound(t, request.RequestId, oracleprotocol.MSG_TYPE_REQUEST)
+	if msg, _ := am.Decode(pkg); msg.GetAuthor() != sha256.Sum256([]byte("appcc")) {
+		t.Fatal("request should be sent by the requesting chaincode")
+	}
+
+	// b收到请求，重复投递的请求只记录一次
+	for i := 0; i < 2; i++ {
+		if res := b.cross.InvokeWithStrings(cctest.RecvMessageArgs("chaina.test", pkg)...); res.Status != shim.OK && i == 0 {
+			t.Fatal(res.Message)
+		}
+	}
+	res := b.oracle.InvokeWithStrings(oracleprotocol.FN_QUERY_INBOUND_REQUESTS)
+	var inbound []*oracleprotocol.InboundRequest
+	if err := json.Unmarshal(res.Payload, &inbound); err != nil || len(inbound) != 1 || inbound[0].Key != "price" {
+		t.Fatalf("unexpected inbound requests: %v %s", err, res.Payload)
+	}
+	appId := sha256.Sum256([]byte("appcc"))
+	if inbound[0].Requester != hex.EncodeToString(appId[:]) {
+		t.Fatalf("unexpected requester: %s", inbound[0].Requester)
+	}
+
+	if res := b.oracle.InvokeWithStrings(oracleprotocol.FN_RESPOND_REMOTE_STATE, "chaina.test", request.RequestId); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res = b.oracle.InvokeWithStrings(oracleprotocol.FN_QUERY_INBOUND_REQUESTS, "chaina.test")
+	if string(res.Payload) != "[]" {
+		t.Fatalf("responded request should not be pending: %s", res.Payload)
+	}
+
+	// 来源域名不符的回复被拒绝，a收到回复后回调业务链码
+	response := b.outbound(t, request.RequestId, oracleprotocol.MSG_TYPE_RESPONSE)
+	if res := a.cross.InvokeWithStrings(cctest.RecvMessageArgs("other.test", response)...); res.Status == shim.OK {
+		t.Fatal("response from unexpected domain should be rejected")
+	}
+	a.setTime(1010)
+	if res := a.cross.InvokeWithStrings(cctest.RecvMessageArgs("chainb.test", response)...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	result := a.query(t, request.RequestId)
+	if result.Status != oracleprotocol.STATUS_SUCCESS || !bytes.Equal(result.Value, []byte("42")) || !strings.HasPrefix(result.RemoteTxId, "oraclecc-tx") || result.RemoteTimestamp != 1000 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if got := string(a.app.State["result_"+request.RequestId]); got != "SUCCESS:42:" {
+		t.Fatalf("unexpected callback: %s", got)
+	}
+
+	// 目标链码拒绝读取时回复错误
+	denied := a.request(t, "chainb.test", "appcc", "secret", "60", "onResult")
+	if res := b.cross.InvokeWithStrings(cctest.RecvMessageArgs("chaina.test", a.outbound(t, denied.RequestId, oracleprotocol.MSG_TYPE_REQUEST))...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := b.oracle.InvokeWithStrings(oracleprotocol.FN_RESPOND_REMOTE_STATE, "chaina.test", denied.RequestId); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := a.cross.InvokeWithStrings(cctest.RecvMessageArgs("chainb.test", b.outbound(t, denied.RequestId, oracleprotocol.MSG_TYPE_RESPONSE))...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if got := string(a.app.State["result_"+denied.RequestId]); got != "ERROR::access denied" {
+		t.Fatalf("unexpected callback: %s", got)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crosserr"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 消息队列的分页扫描
+// 发送积压、可靠中继队列和争议消息的列表查询都按页扫描，单页不超过MAX_PAGE_SIZE条，避免
+// 超过节点的totalQueryLimit。查询返回下一页的书签，空字符串表示扫描结束。
+//
+// Fabric只允许在只读交易中使用分页查询，书签不能在查询交易里写入状态。中继处理完一页后调用
+// saveScanBookmark把书签保存在链上，崩溃重启后用queryScanBookmark取回并继续翻页。
+const (
+	// crosschain_scan_bookmark_${relayer}_${scan} -> bookmark
+	K_SCAN_BOOKMARK_PREFIX = CROSSCHAIN_PREFIX + "scan_bookmark_"
+
+	DEFAULT_PAGE_SIZE = 100
+	MAX_PAGE_SIZE     = 1000
+)
+
+// 解析分页参数
+// args[0] 每页条数(可选)，默认DEFAULT_PAGE_SIZE
+// args[1] 书签(可选)，第一页为空字符串
+func parsePageArgs(args []string) (int32, string, error) {
+	if len(args) > 2 {
+		return 0, "", fmt.Errorf("Wrong length of page args: %v", len(args))
+	}
+	pageSize, bookmark := int32(DEFAULT_PAGE_SIZE), ""
+	if len(args) > 0 && args[0] != "" {
+		size, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil || size <= 0 || size > MAX_PAGE_SIZE {
+			return 0, "", fmt.Errorf("invalid page size: %s", args[0])
+		}
+		pageSize = int32(size)
+	}
+	if len(args) > 1 {
+		bookmark = args[1]
+	}
+	return pageSize, bookmark, nil
+}
+
+// 分页扫描复合key，对每条记录调用fn，返回下一页的书签
+func scanCompositeKeyPage(stub shim.ChaincodeStubInterface, objectType string, keys []string, pageSize int32, bookmark string,
+	fn func(key string, value []byte) error) (string, error) {
+	iter, meta, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, keys, pageSize, bookmark)
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return "", err
+		}
+		if err := fn(kv.Key, kv.Value); err != nil {
+			return "", err
+		}
+	}
+	// 不足一页说明已经扫描到末尾
+	if meta == nil || meta.GetFetchedRecordsCount() < pageSize {
+		return "", nil
+	}
+	return meta.GetBookmark(), nil
+}
+
+func scanBookmarkKey(relayer string, scan string) string {
+	return K_SCAN_BOOKMARK_PREFIX + relayer + "_" + scan
+}
+
+// 保存调用者的扫描书签
+// args[0] 扫描名，如 backlog:${destDomain}
+// args[1] 书签，空字符串表示清除
+func (bs *CrossChain) saveScanBookmark(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty scan name"))
+	}
+	_, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	if args[1] == "" {
+		err = stub.DelState(scanBookmarkKey(relayer, args[0]))
+	} else {
+		err = stub.PutState(scanBookmarkKey(relayer, args[0]), []byte(args[1]))
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询调用者保存的扫描书签，没有保存时返回空
+// args[0] 扫描名
+func (bs *CrossChain) queryScanBookmark(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	_, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	bookmark, err := stub.GetState(scanBookmarkKey(relayer, args[0]))
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	return shim.Success(bookmark)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func TestPageArgs(t *testing.T) {
+	if size, bookmark, err := parsePageArgs(nil); err != nil || size != DEFAULT_PAGE_SIZE || bookmark != "" {
+		t.Fatalf("unexpected default page args: %d %s %v", size, bookmark, err)
+	}
+	if size, bookmark, err := parsePageArgs([]string{"5", "bm"}); err != nil || size != 5 || bookmark != "bm" {
+		t.Fatalf("unexpected page args: %d %s %v", size, bookmark, err)
+	}
+	for _, size := range []string{"0", "-1", "1001", "abc"} {
+		if _, _, err := parsePageArgs([]string{size}); err == nil {
+			t.Fatalf("page size %s should be rejected", size)
+		}
+	}
+	if _, _, err := parsePageArgs([]string{"1", "bm", "extra"}); err == nil {
+		t.Fatal("extra page args should be rejected")
+	}
+}
+
+func TestBacklogPagination(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	receiver := hex.EncodeToString(make([]byte, 32))
+	for _, nounce := range []string{"n0", "n1", "n2", "n3", "n4"} {
+		if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", receiver, "ordered", nounce); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+
+	var seqs []uint32
+	bookmark, pages := "", 0
+	for {
+		res := CallPagedWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().queryOutboundBacklog(stub, []string{"dest.com", "0", "2", bookmark})
+		})
+		var page BacklogPage
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			t.Fatal(res.Message)
+		}
+		if len(page.Messages) > 2 {
+			t.Fatalf("page should not exceed page size: %+v", page.Messages)
+		}
+		for _, msg := range page.Messages {
+			seqs = append(seqs, msg.Seq)
+		}
+		pages++
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if pages != 3 || len(seqs) != 5 {
+		t.Fatalf("unexpected scan: %d pages, seqs %v", pages, seqs)
+	}
+	for i, seq := range seqs {
+		if seq != uint32(i) {
+			t.Fatalf("unexpected seqs: %v", seqs)
+		}
+	}
+}
+
+func TestScanBookmark(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	if res := InvokeWithStrings(t, stub, sp, "saveScanBookmark", "backlog:dest.com", "bm1"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryScanBookmark", "backlog:dest.com"); string(res.Payload) != "bm1" {
+		t.Fatalf("unexpected bookmark: %s", res.Payload)
+	}
+	// 书签按调用者隔离
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "queryScanBookmark", "backlog:dest.com"); len(res.Payload) != 0 {
+		t.Fatalf("bookmark of another relayer should not be visible: %s", res.Payload)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "saveScanBookmark", "backlog:dest.com", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryScanBookmark", "backlog:dest.com"); len(res.Payload) != 0 {
+		t.Fatalf("bookmark should be cleared: %s", res.Payload)
+	}
+}
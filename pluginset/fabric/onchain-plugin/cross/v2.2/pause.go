@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 跨链熔断
+// 出现安全事件时管理员调用pause暂停跨链消息的收发，不需要下线或升级链码；处理完成后调用unpause恢复。
+// 暂停期间发送、接收以及投递挂起消息的接口都返回E1008，查询和管理接口不受影响。
+//
+// 暂停由单个管理员即可执行，开启多管理员治理后恢复需要审批。
+const (
+	// crosschain_pause_state -> PauseState
+	K_PAUSE_STATE = CROSSCHAIN_PREFIX + "pause_state"
+
+	// 暂停、恢复时发出的事件，payload为PauseState
+	K_PAUSED_EVENT   = "PAUSED"
+	K_UNPAUSED_EVENT = "UNPAUSED"
+)
+
+// 暂停期间拒绝的接口
+var pausableFunctions = map[string]bool{
+	"sendMessage":               true,
+	"sendUnorderedMessage":      true,
+	"batchSendUnorderedMessage": true,
+	"sendMessageWithResponse":   true,
+	"sendChunkedMessage":        true,
+	"sendAuthMessage":           true,
+	"recvMessage":               true,
+	"recvBatchMessages":         true,
+	"recvOptimisticMessage":     true,
+	"finalizeOptimisticMessage": true,
+	"finalizeMessage":           true,
+	"recvZKMessage":             true,
+	"recvZKBatchMessages":       true,
+	"recvEthMessage":            true,
+	"recvTMMessage":             true,
+	"recvBTCMessage":            true,
+	"recvPTCMessage":            true,
+}
+
+type PauseState struct {
+	Paused bool   `json:"paused"`
+	Reason string `json:"reason,omitempty"`
+	// 最近一次暂停或恢复的操作者证书sha256、时间和交易
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt int64  `json:"updatedAt"`
+	TxId      string `json:"txId"`
+}
+
+// 暂停期间拒绝跨链消息的收发
+func (bs *CrossChain) checkPaused(stub shim.ChaincodeStubInterface, fn string) error {
+	if !pausableFunctions[fn] {
+		return nil
+	}
+	var state PauseState
+	if _, err := getJSONState(stub, K_PAUSE_STATE, &state); err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to get pause state")
+	}
+	if state.Paused {
+		return crosserr.New(crosserr.CodePaused, "cross-chain message flow is paused: %s", state.Reason)
+	}
+	return nil
+}
+
+func (bs *CrossChain) setPaused(stub shim.ChaincodeStubInterface, paused bool, reason string) pb.Response {
+	var state PauseState
+	if _, err := getJSONState(stub, K_PAUSE_STATE, &state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if state.Paused == paused {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "already paused: %v", paused))
+	}
+	_, operator, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	state = PauseState{Paused: paused, Reason: reason, UpdatedBy: operator, UpdatedAt: now, TxId: stub.GetTxID()}
+	if err := putJSONState(stub, K_PAUSE_STATE, &state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	bz, _ := json.Marshal(&state)
+	event := K_PAUSED_EVENT
+	if !paused {
+		event = K_UNPAUSED_EVENT
+	}
+	if err := stub.SetEvent(event, bz); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+	}
+	return shim.Success(bz)
+}
+
+// 暂停跨链消息收发
+// args[0] 暂停原因(可选)
+func (bs *CrossChain) pause(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) > 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	reason := ""
+	if len(args) == 1 {
+		reason = args[0]
+	}
+	return bs.setPaused(stub, true, reason)
+}
+
+// 恢复跨链消息收发
+func (bs *CrossChain) unpause(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	return bs.setPaused(stub, false, "")
+}
+
+// 查询暂停状态
+func (bs *CrossChain) queryPauseState(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var state PauseState
+	if _, err := getJSONState(stub, K_PAUSE_STATE, &state); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	raw, _ := json.Marshal(&state)
+	return shim.Success(raw)
+}
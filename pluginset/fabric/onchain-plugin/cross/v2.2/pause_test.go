@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	receiver := hex.EncodeToString(make([]byte, 32))
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "pause", "incident"); res.Status == shim.OK {
+		t.Fatal("non-admin should not pause")
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+
+	res := InvokeWithStrings(t, stub, sp, "pause", "incident")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := <-stub.ChaincodeEventsChannel
+	if event.EventName != K_PAUSED_EVENT || string(event.Payload) != string(res.Payload) {
+		t.Fatalf("unexpected event: %s %s", event.EventName, event.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "pause"); res.Status == shim.OK {
+		t.Fatal("pause twice should be rejected")
+	}
+
+	// 暂停期间收发都被拒绝，查询不受影响
+	for _, args := range [][]string{
+		{"sendMessage", "dest.com", receiver, "m1"},
+		{"batchSendUnorderedMessage", "dest.com", receiver, "m1", "m2"},
+		{"recvMessage", "svc", "00"},
+	} {
+		res := InvokeWithStrings(t, stub, sp, args...)
+		if code, _, ok := crosserr.Parse(res.Message); res.Status == shim.OK || !ok || code != crosserr.CodePaused {
+			t.Fatalf("%s should be paused: %s", args[0], res.Message)
+		}
+	}
+	var state PauseState
+	if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryPauseState").Payload, &state); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Paused || state.Reason != "incident" || state.UpdatedBy != testCertHash(TEST_ADMIN_CERT) {
+		t.Fatalf("unexpected pause state: %+v", state)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "unpause"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if event := <-stub.ChaincodeEventsChannel; event.EventName != K_UNPAUSED_EVENT {
+		t.Fatalf("unexpected event %s", event.EventName)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", receiver, "m1"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 接收方的机密消息集合
+// 管理员可以为业务链码配置私有数据集合，投递给该业务链码的消息内容写入集合，
+// 公共状态中只保存内容的sha256和投递记录，审计方可以用集合成员节点上的原文核对。
+// 与按来源域名配置的隐私路由(见private_route.go)不同，这里按接收方配置，适合同一通道上只有部分
+// 业务链码需要保密的场景。
+//
+// 消息应通过transient提交(见recvMessage)，避免内容出现在交易参数中；争议窗口会把消息暂存在
+// 公共状态中，因此发往配置了集合的业务链码的消息不能进入争议窗口。
+const (
+	// crosschain_payload_collection_${sha256(ccName)} -> ReceiverCollection
+	K_PAYLOAD_COLLECTION_PREFIX = CROSSCHAIN_PREFIX + "payload_collection_"
+
+	// 公共状态: crosschain_payload_record_${sha256(消息内容)} -> PayloadRecord
+	K_PAYLOAD_RECORD_PREFIX = CROSSCHAIN_PREFIX + "payload_record_"
+
+	// 集合内: crosschain_private_payload_${sha256(消息内容)} -> 消息内容
+	K_PRIVATE_PAYLOAD_PREFIX = CROSSCHAIN_PREFIX + "private_payload_"
+)
+
+type ReceiverCollection struct {
+	Chaincode  string `json:"chaincode"`
+	Collection string `json:"collection"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+type PayloadRecord struct {
+	PayloadHash string `json:"payloadHash"`
+	Collection  string `json:"collection"`
+	Receiver    string `json:"receiver"` // 业务链码名
+	From        string `json:"from"`
+	TxId        string `json:"txId"`
+	StoredAt    int64  `json:"storedAt"`
+}
+
+func payloadCollectionKey(ccName string) string {
+	return K_PAYLOAD_COLLECTION_PREFIX + sha256Hex([]byte(ccName))
+}
+
+// 未配置集合时返回空字符串
+func (bs *CrossChain) receiverCollection(stub shim.ChaincodeStubInterface, ccName string) (string, error) {
+	var config ReceiverCollection
+	if _, err := getRegistryJSON(stub, payloadCollectionKey(ccName), &config); err != nil {
+		return "", err
+	}
+	return config.Collection, nil
+}
+
+// 消息进入争议窗口前检查接收方未配置集合，接收方无法解析时留到投递时报错
+func (bs *CrossChain) checkPublicHold(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage) error {
+	bizcc, err := bs.routeReceiver(stub, msg)
+	if err != nil {
+		return nil
+	}
+	collection, err := bs.receiverCollection(stub, bizcc)
+	if err != nil {
+		return err
+	}
+	if collection != "" {
+		return crosserr.New(crosserr.CodeConflict, "receiver %s stores payloads in collection %s, dispute window is not allowed", bizcc, collection)
+	}
+	return nil
+}
+
+// 接收方配置了集合时，把消息内容写入集合，公共状态保存摘要
+func (bs *CrossChain) storePayload(stub shim.ChaincodeStubInterface, bizcc string, msg oraclelogic.RecvAuthMessage) error {
+	collection, err := bs.receiverCollection(stub, bizcc)
+	if err != nil || collection == "" {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256Hex(msg.Content)
+	if err := stub.PutPrivateData(collection, K_PRIVATE_PAYLOAD_PREFIX+payloadHash, msg.Content); err != nil {
+		return fmt.Errorf("failed to put private data to %s: %v", collection, err)
+	}
+	return putJSONState(stub, K_PAYLOAD_RECORD_PREFIX+payloadHash, &PayloadRecord{
+		PayloadHash: payloadHash, Collection: collection, Receiver: bizcc, From: msg.From, TxId: stub.GetTxID(), StoredAt: now,
+	})
+}
+
+// 配置业务链码的机密消息集合
+// args[0] 业务链码名
+// args[1] 私有数据集合名，空字符串表示取消
+func (bs *CrossChain) setReceiverCollection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty chaincode name"))
+	}
+	if args[1] == "" {
+		if err := delRegistryState(stub, payloadCollectionKey(args[0])); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	config := &ReceiverCollection{Chaincode: args[0], Collection: args[1], UpdatedAt: now}
+	if err := putRegistryJSON(stub, payloadCollectionKey(args[0]), config); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put config"))
+	}
+	bz, _ := json.Marshal(config)
+	return shim.Success(bz)
+}
+
+// 查询机密消息的投递记录，集合成员节点上同时返回消息内容
+// args[0] sha256(消息内容)(hex)
+func (bs *CrossChain) queryPayload(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var record PayloadRecord
+	if has, err := getJSONState(stub, K_PAYLOAD_RECORD_PREFIX+args[0], &record); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "payload %s not found", args[0]))
+	}
+	resp := struct {
+		PayloadRecord
+		Content []byte `json:"content,omitempty"`
+	}{PayloadRecord: record}
+	// 非集合成员节点读取失败，只返回记录
+	if content, err := stub.GetPrivateData(record.Collection, K_PRIVATE_PAYLOAD_PREFIX+args[0]); err == nil {
+		resp.Content = content
+	}
+	bz, _ := json.Marshal(&resp)
+	return shim.Success(bz)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestReceiverCollection(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	callback := func(from string, content string) string {
+		msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+			{From: from, Identity: sha256.Sum256([]byte("sender")), Content: []byte(content),
+				Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+		}}
+		raw, _ := json.Marshal(msgs)
+		return InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)).Message
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverCollection", "", "bizCollection"); res.Status == shim.OK {
+		t.Fatal("empty chaincode name should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverCollection", "bizcc", "bizCollection"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	callback("src.com", "secret")
+	// 业务链码照常收到内容，跨链链码只在集合中保存内容
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastUnorderedMsg"); !strings.Contains(string(res.Payload), "secret") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	hash := sha256Hex([]byte("secret"))
+	if string(stub.PvtState["bizCollection"][K_PRIVATE_PAYLOAD_PREFIX+hash]) != "secret" {
+		t.Fatal("payload should be stored in collection")
+	}
+	for key, value := range stub.State {
+		if strings.Contains(string(value), "secret") || strings.Contains(string(value), "c2VjcmV0") {
+			t.Fatalf("payload found in public state %s", key)
+		}
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryPayload", hash)
+	var record struct {
+		PayloadRecord
+		Content []byte `json:"content"`
+	}
+	if err := json.Unmarshal(res.Payload, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Receiver != "bizcc" || record.Collection != "bizCollection" || record.From != "src.com" || string(record.Content) != "secret" {
+		t.Fatalf("unexpected payload record: %s", res.Payload)
+	}
+
+	// 配置了集合的接收方不能使用争议窗口
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "held.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if msg := callback("held.com", "held"); !strings.Contains(msg, "dispute window is not allowed") {
+		t.Fatalf("held payload should be rejected: %s", msg)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverCollection", "bizcc", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	callback("src.com", "public")
+	if res := InvokeWithStrings(t, stub, sp, "queryPayload", sha256Hex([]byte("public"))); res.Status == shim.OK {
+		t.Fatal("payload should not be recorded without collection")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 隐私路由
+// 对配置了隐私路由的来源域名，收到的消息内容写入私有数据集合(collection)，并按该路由配置的组织
+// 设置集合内key级背书策略，使机密路由可以使用与公共通道不同的信任集合。
+// 隐私路由的消息应通过transient提交(见recvMessage)，避免消息内容出现在交易参数中；
+// 争议窗口会把消息暂存在公共状态中，因此不能与隐私路由同时配置。
+const (
+	// crosschain_private_route_${domain} -> PrivateRoute
+	K_PRIVATE_ROUTE_PREFIX = CROSSCHAIN_PREFIX + "private_route_"
+
+	// 集合内的消息: crosschain_private_msg_${txid}_${index} -> RecvAuthMessage
+	K_PRIVATE_MSG_PREFIX = CROSSCHAIN_PREFIX + "private_msg_"
+)
+
+type PrivateRoute struct {
+	Collection  string             `json:"collection"`
+	Endorsement *EndorsementPolicy `json:"endorsement"`
+}
+
+func privateMessageKey(txid string, index int) string {
+	return K_PRIVATE_MSG_PREFIX + txid + "_" + strconv.Itoa(index)
+}
+
+// 未配置隐私路由时返回nil
+func (bs *CrossChain) getPrivateRoute(stub shim.ChaincodeStubInterface, domain string) (*PrivateRoute, error) {
+	var route PrivateRoute
+	has, err := getJSONState(stub, K_PRIVATE_ROUTE_PREFIX+domain, &route)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// 配置来源域名的隐私路由
+// args[0] 来源域名
+// args[1] 私有数据集合名，空字符串表示取消隐私路由
+// args[2] 至少需要多少个组织背书
+// args[3..] 组织MSP ID
+func (bs *CrossChain) setPrivateRoute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) == 2 && args[1] == "" {
+		if err := stub.DelState(K_PRIVATE_ROUTE_PREFIX + args[0]); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	}
+	if len(args) < 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" || args[1] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", args[0], args[1]))
+	}
+	policy, err := parseEndorsementPolicyArgs(args[2:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid endorsement policy"))
+	}
+	if window, err := bs.getDisputeWindow(stub, args[0]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get dispute window"))
+	} else if window > 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "domain %s has dispute window, private route is not allowed", args[0]))
+	}
+	route := &PrivateRoute{Collection: args[1], Endorsement: policy}
+	if err := putJSONState(stub, K_PRIVATE_ROUTE_PREFIX+args[0], route); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put route"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询来源域名的隐私路由
+// args[0] 来源域名
+func (bs *CrossChain) queryPrivateRoute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	route, err := bs.getPrivateRoute(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get private route"))
+	}
+	if route == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no private route for domain %s", args[0]))
+	}
+	bz, _ := json.Marshal(route)
+	return shim.Success(bz)
+}
+
+// 把消息写入隐私路由的集合，并设置该key的背书策略
+func (bs *CrossChain) storePrivateMessage(stub shim.ChaincodeStubInterface, route *PrivateRoute, msg oraclelogic.RecvAuthMessage, index int) error {
+	bz, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	key := privateMessageKey(stub.GetTxID(), index)
+	if err := stub.PutPrivateData(route.Collection, key, bz); err != nil {
+		return fmt.Errorf("failed to put private data to %s: %v", route.Collection, err)
+	}
+	ep, err := buildEndorsementPolicy(route.Endorsement.Threshold, route.Endorsement.Orgs)
+	if err != nil {
+		return err
+	}
+	if err := stub.SetPrivateDataValidationParameter(route.Collection, key, ep); err != nil {
+		return err
+	}
+	return bs.trackRetention(stub, msg.From, &RetentionEntry{Kind: ERASE_KIND_PRIVATE, Key: key, Collection: route.Collection})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestPrivateRoute(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+
+	if res := InvokeWithStrings(t, stub, sp, "setPrivateRoute", "private.com", "bridgeCollection", "3", "Org1MSP", "Org2MSP"); res.Status == shim.OK {
+		t.Fatal("threshold larger than orgs should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setPrivateRoute", "private.com", "bridgeCollection", "1", "Org1MSP", "Org2MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "private.com", "600"); res.Status == shim.OK {
+		t.Fatal("dispute window should not be allowed on private route")
+	}
+	var route PrivateRoute
+	res := InvokeWithStrings(t, stub, sp, "queryPrivateRoute", "private.com")
+	if err := json.Unmarshal(res.Payload, &route); err != nil {
+		t.Fatal(err)
+	}
+	if route.Collection != "bridgeCollection" || route.Endorsement.Threshold != 1 || len(route.Endorsement.Orgs) != 2 {
+		t.Fatalf("unexpected private route: %s", res.Payload)
+	}
+
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "public.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("public"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+		{From: "private.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("secret"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+	}}
+	raw, _ := json.Marshal(msgs)
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastUnorderedMsg"); !strings.Contains(string(res.Payload), "secret") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+
+	// 只有隐私路由的消息写入集合，并带有路由配置的背书策略
+	if _, has := stub.PvtState["bridgeCollection"][privateMessageKey(txid, 0)]; has {
+		t.Fatal("message of public route should not be stored in collection")
+	}
+	var stored oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(stub.PvtState["bridgeCollection"][privateMessageKey(txid, 1)], &stored); err != nil {
+		t.Fatal(err)
+	}
+	if string(stored.Content) != "secret" {
+		t.Fatalf("unexpected private message: %+v", stored)
+	}
+	ep, _ := stub.GetPrivateDataValidationParameter("bridgeCollection", privateMessageKey(txid, 1))
+	policy, err := parseKeyEndorsementPolicy(privateMessageKey(txid, 1), ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Threshold != 1 || len(policy.Orgs) != 2 || policy.Orgs[0] != "Org1MSP" {
+		t.Fatalf("unexpected collection endorsement policy: %+v", policy)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setPrivateRoute", "private.com", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryPrivateRoute", "private.com"); res.Status == shim.OK {
+		t.Fatal("private route should be removed")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"go.uber.org/zap"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 上层协议登记表
+// AM报文的上层协议类型为0时是SDP，由跨链链码自己处理。管理员可以为其他协议类型登记协议链码，
+// 同一通道上SDP和自定义协议并存：
+//   - 收到的AM报文按协议类型分发，SDP消息照常回调业务链码，其他协议的消息以
+//     recvAuthMessage(来源域名, 发送者身份hex, payload)回调登记的协议链码，未登记的协议类型被拒绝(E1003)
+//   - 链码调用sendAuthMessage发送已登记协议的消息，与SDP相同，发送者身份为sha256(交易提案调用的链码名)
+//
+// 其他协议的消息按无序消息处理，跨链链码只做AM层的证明校验、防重放(相同的AM报文只投递一次)、限流和执行限制，
+// 目标域名、序号和接收方由协议链码自己检查。发送的消息不进入SDP的积压索引、中继队列和有效期管理。
+const (
+	// crosschain_protocol_${type} -> Protocol
+	K_PROTOCOL_PREFIX = CROSSCHAIN_PREFIX + "protocol_"
+	// 按协议类型查询的复合key的对象类型
+	K_PROTOCOL_OBJECT_TYPE = CROSSCHAIN_PREFIX + "protocol"
+
+	// 协议链码接收消息的方法
+	PROTOCOL_RECV_FN = "recvAuthMessage"
+)
+
+type Protocol struct {
+	Type      uint32 `json:"type"`
+	Chaincode string `json:"chaincode"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+func protocolKey(protocolType uint32) string {
+	return K_PROTOCOL_PREFIX + strconv.FormatUint(uint64(protocolType), 10)
+}
+
+func parseProtocolType(arg string) (uint32, error) {
+	t, err := strconv.ParseUint(arg, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid protocol type: %s", arg)
+	}
+	if uint32(t) == oraclelogic.P2P_MSG_PROTOCOL_TYPE {
+		return 0, fmt.Errorf("protocol type %d is reserved for sdp", t)
+	}
+	return uint32(t), nil
+}
+
+func (bs *CrossChain) getProtocol(stub shim.ChaincodeStubInterface, protocolType uint32) (*Protocol, error) {
+	var protocol Protocol
+	has, err := getJSONState(stub, protocolKey(protocolType), &protocol)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &protocol, nil
+}
+
+// 登记上层协议的协议链码
+// args[0] 协议类型，大于0
+// args[1] 协议链码名，为空时删除登记
+func (bs *CrossChain) setProtocol(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	protocolType, err := parseProtocolType(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid protocol type"))
+	}
+	index, err := stub.CreateCompositeKey(K_PROTOCOL_OBJECT_TYPE, []string{fmt.Sprintf("%010d", protocolType)})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	if args[1] == "" {
+		for _, key := range []string{protocolKey(protocolType), index} {
+			if err := stub.DelState(key); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+			}
+		}
+		return shim.Success(nil)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	protocol := &Protocol{Type: protocolType, Chaincode: args[1], UpdatedAt: now}
+	if err := putJSONState(stub, protocolKey(protocolType), protocol); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put protocol"))
+	}
+	if err := stub.PutState(index, []byte{0x01}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	bz, _ := json.Marshal(protocol)
+	return shim.Success(bz)
+}
+
+// 查询已登记的上层协议，按协议类型排序
+func (bs *CrossChain) queryProtocols(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	iter, err := stub.GetStateByPartialCompositeKey(K_PROTOCOL_OBJECT_TYPE, []string{})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	defer iter.Close()
+	protocols := []*Protocol{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to iterate state"))
+		}
+		_, attrs, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil || len(attrs) != 1 {
+			continue
+		}
+		protocolType, err := strconv.ParseUint(attrs[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		protocol, err := bs.getProtocol(stub, uint32(protocolType))
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get protocol"))
+		}
+		if protocol != nil {
+			protocols = append(protocols, protocol)
+		}
+	}
+	bz, _ := json.Marshal(protocols)
+	return shim.Success(bz)
+}
+
+// 发送已登记的上层协议的消息
+// args[0] 协议类型
+// args[1] 目的地的域名
+// args[2] 协议消息，hexstring
+// args[3] nounce(可选)，同一笔交易发送多条消息时区分
+func (bs *CrossChain) sendAuthMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 3 || len(args) > 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Unexpected args len: %d", len(args)))
+	}
+	protocolType, err := parseProtocolType(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "send auth message"))
+	}
+	payload, err := hex.DecodeString(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "payload format error"))
+	}
+	msgnounce := ""
+	if len(args) == 4 {
+		msgnounce = args[3]
+	}
+	if protocol, err := bs.getProtocol(stub, protocolType); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get protocol %d", protocolType))
+	} else if protocol == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "protocol %d is not registered", protocolType))
+	}
+	sender, err := getProposalChaincode(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "failed to get sender"))
+	}
+	res := bs.Os.SendAuthMessage(stub, sha256.Sum256([]byte(sender)), protocolType, args[1], payload, msgnounce)
+	if res.Status != shim.OK {
+		return errorResponse("", crosserr.FromMessage(crosserr.CodeInvalidArgs, res.Message))
+	}
+	if _, _, err := bs.chargeFee(stub, args[1]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to charge fee"))
+	}
+
+	var sent oraclelogic.OutboundMessage
+	_ = json.Unmarshal(res.Payload, &sent)
+	txLogger(stub).Info("message sent", zap.String("traceId", packageTraceId(sent.Package)),
+		zap.String("key", sent.Key), zap.String("destDomain", args[1]), zap.Uint32("protocolType", protocolType))
+	return res
+}
+
+// 把SDP之外的上层协议的消息交给登记的协议链码
+func (bs *CrossChain) deliverProtocolMessage(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, tracer *debugTracer) pb.Response {
+	protocol, err := bs.getProtocol(stub, msg.ProtocolType)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get protocol %d", msg.ProtocolType))
+	}
+	if protocol == nil {
+		tracer.step(TRACE_STEP_ACL, false, "protocol %d is not registered", msg.ProtocolType)
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "protocol %d is not registered", msg.ProtocolType))
+	}
+	if err := bs.checkExecLimit(stub, msg.Content, true); err != nil {
+		tracer.step(TRACE_STEP_DELIVERY, false, "%v", err)
+		return errorResponse("", err)
+	}
+	channel, err := bs.receiverChannel(stub, protocol.Chaincode)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "get receiver channel"))
+	}
+	re := stub.InvokeChaincode(protocol.Chaincode, [][]byte{
+		[]byte(PROTOCOL_RECV_FN),
+		[]byte(msg.From),
+		[]byte(hex.EncodeToString(msg.Identity[:])),
+		msg.Content,
+	}, channel)
+	if re.Status != shim.OK {
+		tracer.step(TRACE_STEP_DELIVERY, false, "call %s.%s: %s", protocol.Chaincode, PROTOCOL_RECV_FN, re.Message)
+		return errorResponse("", crosserr.New(crosserr.CodeCallback, "protocol %d callback chaincode %s failed: %s", msg.ProtocolType, protocol.Chaincode, re.Message))
+	}
+	tracer.step(TRACE_STEP_DELIVERY, true, "call %s.%s: %s", protocol.Chaincode, PROTOCOL_RECV_FN, re.Message)
+	return shim.Success(nil)
+}
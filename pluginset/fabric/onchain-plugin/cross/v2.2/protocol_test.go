@@ -0,0 +1,113 @@
+package main
+
+import (
+	"am"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+// 记录收到的协议消息的协议链码
+type recordingProtocol struct{}
+
+func (recordingProtocol) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (recordingProtocol) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	if fn != PROTOCOL_RECV_FN || len(args) != 3 {
+		return shim.Error("unexpected call " + fn)
+	}
+	if err := stub.PutState("last", []byte(strings.Join(args, "|"))); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func TestUpperProtocols(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	protocc := shimtest.NewMockStub("protocc", recordingProtocol{})
+	stub.MockPeerChaincode("protocc", protocc, "")
+	if res := InvokeWithStrings(t, stub, sp, "setProtocol", "0", "protocc"); res.Status == shim.OK {
+		t.Fatal("protocol type of sdp should be reserved")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setProtocol", "7", "protocc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var protocols []Protocol
+	if res := InvokeWithStrings(t, stub, sp, "queryProtocols"); json.Unmarshal(res.Payload, &protocols) != nil {
+		t.Fatal(res.Message)
+	}
+	if len(protocols) != 1 || protocols[0].Type != 7 || protocols[0].Chaincode != "protocc" {
+		t.Fatalf("unexpected protocols: %+v", protocols)
+	}
+
+	// 发送已登记协议的消息，发送者身份为交易提案调用的链码
+	var sendersp pb.SignedProposal
+	MockSignedProposal("sendercc", &sendersp)
+	if res := InvokeWithStrings(t, stub, &sendersp, "sendAuthMessage", "8", "dest.com", hex.EncodeToString([]byte("ping"))); res.Status == shim.OK {
+		t.Fatal("unregistered protocol should be rejected")
+	}
+	res := InvokeWithStrings(t, stub, &sendersp, "sendAuthMessage", "7", "dest.com", hex.EncodeToString([]byte("ping")), "n")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var sent oraclelogic.OutboundMessage
+	if err := json.Unmarshal(res.Payload, &sent); err != nil || sent.DestDomain != "dest.com" {
+		t.Fatalf("unexpected outbound message: %v %+v", err, sent)
+	}
+	msg, err := am.Decode(stub.State[oraclelogic.UnorderedMessageKey(txid, sha256.Sum256([]byte("sendercc")), "n")])
+	if err != nil || msg.GetProtocolType() != 7 || msg.GetAuthor() != sha256.Sum256([]byte("sendercc")) || string(msg.GetPayload()) != "ping" {
+		t.Fatalf("unexpected auth message: %v %+v", err, msg)
+	}
+
+	// 暂停期间不能发送协议消息
+	if res := InvokeWithStrings(t, stub, sp, "pause", "incident"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res = InvokeWithStrings(t, stub, &sendersp, "sendAuthMessage", "7", "dest.com", hex.EncodeToString([]byte("paused")), "p")
+	if code, _, ok := crosserr.Parse(res.Message); res.Status == shim.OK || !ok || code != crosserr.CodePaused {
+		t.Fatalf("sendAuthMessage should be paused: %s", res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "unpause"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 收到的消息按协议类型分发给协议链码
+	recv := func(protocolType uint32, payload string) pb.Response {
+		raw := (&am.AuthMessageV1{Author: sha256.Sum256([]byte("sender")), ProtocolType: protocolType, Payload: []byte(payload)}).Encode()
+		return InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(raw)))
+	}
+	if res := recv(7, "pong"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	sender := sha256.Sum256([]byte("sender"))
+	if last := string(protocc.State["last"]); last != "src.com|"+hex.EncodeToString(sender[:])+"|pong" {
+		t.Fatalf("unexpected protocol message: %s", last)
+	}
+	if res := recv(7, "pong"); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+	if res := recv(9, "pong"); res.Status == shim.OK || !strings.Contains(res.Message, "E1003") {
+		t.Fatalf("unregistered protocol should be rejected: %s", res.Message)
+	}
+
+	// 删除登记
+	if res := InvokeWithStrings(t, stub, sp, "setProtocol", "7", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryProtocols"); string(res.Payload) != "[]" {
+		t.Fatalf("unexpected protocols: %s", res.Payload)
+	}
+	if res := recv(7, "pong again"); res.Status == shim.OK {
+		t.Fatal("removed protocol should be rejected")
+	}
+}
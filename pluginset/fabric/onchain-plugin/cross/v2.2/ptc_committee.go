@@ -0,0 +1,272 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"sigverify"
+	"strconv"
+	"tlv"
+)
+
+// PTC委员会背书校验
+// 管理员为来源域名登记PTC委员会(节点公钥及门限)，中继通过recvPTCMessage提交AM报文及委员会背书，
+// 链码校验至少threshold个登记节点的签名后按普通消息的流程检查序号并回调业务链码。
+// 登记了委员会的来源域名不再接受recvMessage/recvBatchMessages提交的消息。
+//
+// 节点签名的消息为TLV(PTCEndorseBody)，绑定来源域名、委员会id、委员会轮次和AM报文的sha256，
+// 背书不能用于其他域名或其他报文。每次登记或轮换委员会轮次加1，旧轮次的背书随之失效。
+// 每个签名带算法字节，摘要和签名算法见sigverify包：默认为SHA-256加ECDSA P-256/Ed25519，
+// 另外支持Keccak-256加secp256k1、SM3加SM2以及标准Ed25519，节点公钥的类型必须与算法一致。
+const (
+	// crosschain_ptc_committee_${domain} -> PTCCommittee
+	K_PTC_COMMITTEE_PREFIX = CROSSCHAIN_PREFIX + "ptc_committee_"
+)
+
+type PTCCommitteeNode struct {
+	NodeId    string `json:"nodeId"`
+	PublicKey string `json:"publicKey"` // PEM
+}
+
+type PTCCommittee struct {
+	Domain      string             `json:"domain"`
+	CommitteeId string             `json:"committeeId"`
+	Epoch       uint64             `json:"epoch"`
+	Threshold   int                `json:"threshold"`
+	Nodes       []PTCCommitteeNode `json:"nodes"`
+	UpdatedAt   int64              `json:"updatedAt"`
+}
+
+// 节点签名的内容
+type PTCEndorseBody struct {
+	SrcDomain   string `tlv:"0"`
+	CommitteeId string `tlv:"1"`
+	Epoch       uint64 `tlv:"2"`
+	AMHash      []byte `tlv:"3"` // sha256(AM报文)
+}
+
+type PTCNodeSignature struct {
+	NodeId    string `tlv:"0"`
+	Signature []byte `tlv:"1"`
+	// sigverify.SIGN_ALGO_*
+	SignAlgo uint8 `tlv:"2,omitempty"`
+}
+
+// 中继提交的委员会背书
+type PTCEndorsement struct {
+	CommitteeId string             `tlv:"0"`
+	Epoch       uint64             `tlv:"1"`
+	Signatures  []PTCNodeSignature `tlv:"2"`
+}
+
+func ptcEndorseBody(srcDomain string, committee *PTCCommittee, pkg []byte) ([]byte, error) {
+	amHash := sha256.Sum256(pkg)
+	return tlv.Marshal(&PTCEndorseBody{SrcDomain: srcDomain, CommitteeId: committee.CommitteeId, Epoch: committee.Epoch, AMHash: amHash[:]})
+}
+
+func (bs *CrossChain) getPTCCommittee(stub shim.ChaincodeStubInterface, domain string) (*PTCCommittee, error) {
+	var committee PTCCommittee
+	has, err := getJSONState(stub, K_PTC_COMMITTEE_PREFIX+domain, &committee)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &committee, nil
+}
+
+// 登记了委员会的来源域名只能通过recvPTCMessage提交消息
+func (bs *CrossChain) checkPTCBypass(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage) error {
+	for _, msg := range msgs {
+		committee, err := bs.getPTCCommittee(stub, msg.From)
+		if err != nil {
+			return err
+		}
+		if committee != nil {
+			return crosserr.New(crosserr.CodeVerify, "messages from domain %s require PTC committee endorsement", msg.From)
+		}
+	}
+	return nil
+}
+
+// 校验委员会背书
+func (bs *CrossChain) verifyPTCEndorsement(stub shim.ChaincodeStubInterface, srcDomain string, pkg []byte, raw []byte) error {
+	committee, err := bs.getPTCCommittee(stub, srcDomain)
+	if err != nil {
+		return err
+	}
+	if committee == nil {
+		return fmt.Errorf("no PTC committee for domain %s", srcDomain)
+	}
+	var endorsement PTCEndorsement
+	if err := tlv.Unmarshal(raw, &endorsement); err != nil {
+		return fmt.Errorf("endorsement format error: %v", err)
+	}
+	if endorsement.CommitteeId != committee.CommitteeId || endorsement.Epoch != committee.Epoch {
+		return fmt.Errorf("endorsement of committee %s epoch %d does not match committee %s epoch %d",
+			endorsement.CommitteeId, endorsement.Epoch, committee.CommitteeId, committee.Epoch)
+	}
+	body, err := ptcEndorseBody(srcDomain, committee, pkg)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]string, len(committee.Nodes))
+	for _, node := range committee.Nodes {
+		keys[node.NodeId] = node.PublicKey
+	}
+	signed := make(map[string]bool)
+	items := make([]sigverify.BatchItem, 0, len(endorsement.Signatures))
+	for _, sig := range endorsement.Signatures {
+		pemKey, ok := keys[sig.NodeId]
+		if !ok {
+			return fmt.Errorf("node %s is not in committee", sig.NodeId)
+		}
+		if signed[sig.NodeId] {
+			return fmt.Errorf("duplicated signature of node %s", sig.NodeId)
+		}
+		key, err := sigverify.ParsePublicKeyPEM(pemKey)
+		if err != nil {
+			return err
+		}
+		items = append(items, sigverify.BatchItem{Algo: sig.SignAlgo, Key: key, Msg: body, Sig: sig.Signature})
+		signed[sig.NodeId] = true
+	}
+	// 委员会的签名一起校验，secp256k1签名带恢复位时批量校验
+	for i, err := range sigverify.VerifyBatch(items) {
+		if err != nil {
+			return fmt.Errorf("node %s: %v", endorsement.Signatures[i].NodeId, err)
+		}
+	}
+	if len(signed) < committee.Threshold {
+		return fmt.Errorf("endorsed by %d nodes, %d required", len(signed), committee.Threshold)
+	}
+	return nil
+}
+
+// 登记或轮换来源域名的PTC委员会
+// args[0] 来源域名
+// args[1] 委员会id
+// args[2] 签名门限
+// args[3] 节点列表(json)，每个节点为{"nodeId","publicKey"(PEM)}，公钥支持ECDSA P-256、Ed25519、secp256k1和SM2
+func (bs *CrossChain) setPTCCommittee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" || args[1] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain or committee id"))
+	}
+	var nodes []PTCCommitteeNode
+	if err := json.Unmarshal([]byte(args[3]), &nodes); err != nil || len(nodes) == 0 {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "nodes format error"))
+	}
+	threshold, err := strconv.Atoi(args[2])
+	if err != nil || threshold <= 0 || threshold > len(nodes) {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid threshold: %s", args[2]))
+	}
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		if node.NodeId == "" || seen[node.NodeId] {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid or duplicated node id: %s", node.NodeId))
+		}
+		seen[node.NodeId] = true
+		if _, err := sigverify.ParsePublicKeyPEM(node.PublicKey); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "node %s", node.NodeId))
+		}
+	}
+
+	old, err := bs.getPTCCommittee(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get PTC committee"))
+	}
+	epoch := uint64(1)
+	if old != nil {
+		epoch = old.Epoch + 1
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	committee := &PTCCommittee{Domain: args[0], CommitteeId: args[1], Epoch: epoch, Threshold: threshold, Nodes: nodes, UpdatedAt: now}
+	if err := putJSONState(stub, K_PTC_COMMITTEE_PREFIX+args[0], committee); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put committee"))
+	}
+	if err := bs.protectKeys(stub, KEY_SCOPE_COMMITTEE, K_PTC_COMMITTEE_PREFIX+args[0]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect keys"))
+	}
+	raw, _ := json.Marshal(committee)
+	return shim.Success(raw)
+}
+
+// 删除来源域名的PTC委员会，该域名恢复通过recvMessage接收消息
+// args[0] 来源域名
+func (bs *CrossChain) removePTCCommittee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if committee, err := bs.getPTCCommittee(stub, args[0]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get PTC committee"))
+	} else if committee == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no PTC committee for domain %s", args[0]))
+	}
+	if err := stub.DelState(K_PTC_COMMITTEE_PREFIX + args[0]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询来源域名的PTC委员会
+// args[0] 来源域名
+func (bs *CrossChain) queryPTCCommittee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	committee, err := bs.getPTCCommittee(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get PTC committee"))
+	}
+	if committee == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no PTC committee for domain %s", args[0]))
+	}
+	raw, _ := json.Marshal(committee)
+	return shim.Success(raw)
+}
+
+// 接收PTC委员会背书的跨链消息
+// args[0] 来源域名
+// args[1] AM报文(hex)
+// args[2] 委员会背书(hex)，TLV编码的PTCEndorsement
+func (bs *CrossChain) recvPTCMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	pkg, err := hex.DecodeString(args[1])
+	if err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "am package format error: %v", err))
+	}
+	endorsement, err := hex.DecodeString(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "endorsement format error: %v", err))
+	}
+	if err := bs.verifyPTCEndorsement(stub, srcDomain, pkg, endorsement); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "endorsement verify failed"))
+	}
+
+	ret := bs.Os.RecvAMPackage(stub, srcDomain, args[1])
+	if ret.Status != shim.OK {
+		return errorResponse("", crosserr.FromMessage(crosserr.CodeVerify, ret.Message))
+	}
+	var msg oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+	}
+	if err := bs.checkPTCCertChain(stub, []oraclelogic.RecvAuthMessage{msg}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "check PTC certificate"))
+	}
+	msgs, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{msg}})
+	return bs.callbackBizChaincode(stub, msgs)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"math/big"
+	"oraclelogic/v2.2"
+	"sigverify"
+	"strings"
+	"testing"
+	"tlv"
+)
+
+// 测试用的SM2密钥和签名
+func testSM2Key() (*big.Int, string) {
+	curve := sigverify.SM2P256V1
+	d, _ := rand.Int(rand.Reader, new(big.Int).Sub(curve.N, big.NewInt(2)))
+	d.Add(d, big.NewInt(1))
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	return d, sigverify.MarshalPublicKeyPEM(&sigverify.ECPublicKey{Curve: curve, X: x, Y: y})
+}
+
+func testSM2Sign(d *big.Int, msg []byte) []byte {
+	curve := sigverify.SM2P256V1
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	za := sigverify.SM2ZA(&sigverify.ECPublicKey{Curve: curve, X: x, Y: y}, sigverify.SM2_DEFAULT_UID)
+	e := sigverify.SM3(append(za[:], msg...))
+	k, _ := rand.Int(rand.Reader, new(big.Int).Sub(curve.N, big.NewInt(1)))
+	k.Add(k, big.NewInt(1))
+	x1, _ := curve.ScalarBaseMult(k.Bytes())
+	// r = e + x1, s = (1+d)^-1 * (k - r*d)
+	r := new(big.Int).SetBytes(e[:])
+	r.Add(r, x1).Mod(r, curve.N)
+	s := new(big.Int).Mul(r, d)
+	s.Sub(k, s).Mul(s, new(big.Int).ModInverse(new(big.Int).Add(d, big.NewInt(1)), curve.N)).Mod(s, curve.N)
+	sig, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return sig
+}
+
+func TestPTCCommittee(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 两个ECDSA节点、一个Ed25519节点和一个SM2节点
+	signers := map[string]crypto.Signer{}
+	sm2Key, sm2PEM := testSM2Key()
+	var nodes []PTCCommitteeNode
+	for _, id := range []string{"node0", "node1", "node2"} {
+		var signer crypto.Signer
+		if id == "node2" {
+			_, signer, _ = ed25519.GenerateKey(rand.Reader)
+		} else {
+			signer, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		}
+		der, _ := x509.MarshalPKIXPublicKey(signer.Public())
+		signers[id] = signer
+		nodes = append(nodes, PTCCommitteeNode{NodeId: id, PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))})
+	}
+	nodes = append(nodes, PTCCommitteeNode{NodeId: "node3", PublicKey: sm2PEM})
+	setCommittee := func(threshold string) PTCCommittee {
+		res := InvokeWithStrings(t, stub, sp, "setPTCCommittee", "src.com", "committee", threshold, mustJSON(nodes))
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		var committee PTCCommittee
+		_ = json.Unmarshal(res.Payload, &committee)
+		return committee
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setPTCCommittee", "src.com", "committee", "5", mustJSON(nodes)); res.Status == shim.OK {
+		t.Fatal("threshold larger than committee should be rejected")
+	}
+	if committee := setCommittee("2"); committee.Epoch != 1 {
+		t.Fatalf("unexpected committee: %+v", committee)
+	}
+
+	packet := func(payload string, seq uint32) []byte {
+		msg := testSDPMessageV2(payload, seq)
+		msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+		sdp, _ := msg.Encode()
+		return oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+	}
+	// 为true时node2按SIGN_ALGO_ED25519直接对消息签名
+	pureEd25519 := false
+	endorse := func(domain string, epoch uint64, pkg []byte, ids ...string) string {
+		committee := &PTCCommittee{CommitteeId: "committee", Epoch: epoch}
+		body, _ := ptcEndorseBody(domain, committee, pkg)
+		digest := sha256.Sum256(body)
+		endorsement := PTCEndorsement{CommitteeId: "committee", Epoch: epoch}
+		for _, id := range ids {
+			if id == "node3" {
+				sig := PTCNodeSignature{NodeId: id, Signature: testSM2Sign(sm2Key, body), SignAlgo: sigverify.SIGN_ALGO_SM3_WITH_SM2}
+				endorsement.Signatures = append(endorsement.Signatures, sig)
+				continue
+			}
+			if id == "node2" && pureEd25519 {
+				sig, _ := signers[id].Sign(rand.Reader, body, crypto.Hash(0))
+				endorsement.Signatures = append(endorsement.Signatures, PTCNodeSignature{NodeId: id, Signature: sig, SignAlgo: sigverify.SIGN_ALGO_ED25519})
+				continue
+			}
+			opts := crypto.SignerOpts(crypto.SHA256)
+			if id == "node2" {
+				opts = crypto.Hash(0)
+			}
+			signer, ok := signers[id]
+			if !ok {
+				signer = signers["node0"]
+			}
+			sig, _ := signer.Sign(rand.Reader, digest[:], opts)
+			endorsement.Signatures = append(endorsement.Signatures, PTCNodeSignature{NodeId: id, Signature: sig})
+		}
+		raw, _ := tlv.Marshal(&endorsement)
+		return hex.EncodeToString(raw)
+	}
+	recv := func(pkg []byte, endorsement string) string {
+		res := InvokeWithStrings(t, stub, sp, "recvPTCMessage", "src.com", hex.EncodeToString(pkg), endorsement)
+		if res.Status != shim.OK {
+			return res.Message
+		}
+		return ""
+	}
+
+	pkg := packet("endorsed", 0)
+	for _, c := range []struct {
+		endorsement string
+		err         string
+	}{
+		{endorse("src.com", 1, pkg, "node0"), "1 nodes, 2 required"},
+		{endorse("src.com", 1, pkg, "node0", "node0"), "duplicated"},
+		{endorse("src.com", 1, pkg, "node0", "nodeX"), "not in committee"},
+		{endorse("other.com", 1, pkg, "node0", "node2"), "invalid signature"},
+		{endorse("other.com", 1, pkg, "node0", "node3"), "invalid signature"},
+		{endorse("src.com", 1, packet("other", 0), "node0", "node2"), "invalid signature"},
+		{endorse("src.com", 2, pkg, "node0", "node2"), "does not match"},
+		{"00", "format error"},
+	} {
+		if err := recv(pkg, c.endorsement); !strings.Contains(err, c.err) {
+			t.Fatalf("expected error %q, got %q", c.err, err)
+		}
+	}
+	// SM2签名声明为默认算法时拒绝
+	raw, _ := hex.DecodeString(endorse("src.com", 1, pkg, "node3", "node0"))
+	var mismatched PTCEndorsement
+	_ = tlv.Unmarshal(raw, &mismatched)
+	mismatched.Signatures[0].SignAlgo = sigverify.SIGN_ALGO_DEFAULT
+	raw, _ = tlv.Marshal(&mismatched)
+	if err := recv(pkg, hex.EncodeToString(raw)); !strings.Contains(err, "does not match sign algorithm") {
+		t.Fatalf("mismatched algorithm should be rejected: %s", err)
+	}
+	if err := recv(pkg, endorse("src.com", 1, pkg, "node3", "node0")); err != "" {
+		t.Fatal(err)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.HasSuffix(string(res.Payload), ":endorsed") {
+		t.Fatalf("unexpected last message: %s", res.Payload)
+	}
+
+	// 轮换后旧轮次的背书失效
+	if committee := setCommittee("3"); committee.Epoch != 2 || committee.Threshold != 3 {
+		t.Fatalf("unexpected committee: %+v", committee)
+	}
+	pkg = packet("rotated", 1)
+	if err := recv(pkg, endorse("src.com", 1, pkg, "node0", "node1", "node3")); !strings.Contains(err, "does not match") {
+		t.Fatalf("endorsement of old epoch should be rejected: %s", err)
+	}
+	pureEd25519 = true
+	if err := recv(pkg, endorse("src.com", 2, pkg, "node0", "node2", "node3")); err != "" {
+		t.Fatal(err)
+	}
+
+	// 登记了委员会的域名不能绕过背书
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(packet("bypass", 2)))); res.Status == shim.OK {
+		t.Fatal("recvMessage should be rejected for domain with PTC committee")
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "removePTCCommittee", "src.com"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryPTCCommittee", "src.com"); res.Status == shim.OK {
+		t.Fatal("removed committee should not be found")
+	}
+	// MockStub不回滚失败的交易，被拒绝的消息已占用序号2
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(packet("plain", 3)))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
+
+// 测试用的secp256k1签名，r||s||v
+func testSecp256k1Sign(d *big.Int, msg []byte) []byte {
+	curve := sigverify.SECP256K1
+	k, _ := rand.Int(rand.Reader, new(big.Int).Sub(curve.N, big.NewInt(1)))
+	k.Add(k, big.NewInt(1))
+	x1, y1 := curve.ScalarBaseMult(k.Bytes())
+	// r = x1, s = k^-1 * (e + r*d)
+	r := new(big.Int).Mod(x1, curve.N)
+	s := new(big.Int).Mul(r, d)
+	s.Add(s, new(big.Int).SetBytes(sigverify.Keccak256(msg))).Mul(s, new(big.Int).ModInverse(k, curve.N)).Mod(s, curve.N)
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = 27 + byte(y1.Bit(0))
+	return sig
+}
+
+// secp256k1委员会的签名批量校验，无效签名仍能定位到节点
+func TestPTCCommitteeSecp256k1Batch(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	curve := sigverify.SECP256K1
+	keys := map[string]*big.Int{}
+	var nodes []PTCCommitteeNode
+	for _, id := range []string{"node0", "node1", "node2", "node3"} {
+		d, _ := rand.Int(rand.Reader, new(big.Int).Sub(curve.N, big.NewInt(2)))
+		d.Add(d, big.NewInt(1))
+		x, y := curve.ScalarBaseMult(d.Bytes())
+		keys[id] = d
+		nodes = append(nodes, PTCCommitteeNode{NodeId: id, PublicKey: sigverify.MarshalPublicKeyPEM(&sigverify.ECPublicKey{Curve: curve, X: x, Y: y})})
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setPTCCommittee", "src.com", "committee", "3", mustJSON(nodes)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	msg := testSDPMessageV2("batch", 0)
+	msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+	sdp, _ := msg.Encode()
+	pkg := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+	body, _ := ptcEndorseBody("src.com", &PTCCommittee{CommitteeId: "committee", Epoch: 1}, pkg)
+	endorse := func(bad string) string {
+		endorsement := PTCEndorsement{CommitteeId: "committee", Epoch: 1}
+		for _, id := range []string{"node0", "node1", "node2", "node3"} {
+			signed := body
+			if id == bad {
+				signed = []byte("other body")
+			}
+			endorsement.Signatures = append(endorsement.Signatures, PTCNodeSignature{
+				NodeId: id, Signature: testSecp256k1Sign(keys[id], signed), SignAlgo: sigverify.SIGN_ALGO_KECCAK256_WITH_SECP256K1,
+			})
+		}
+		raw, _ := tlv.Marshal(&endorsement)
+		return hex.EncodeToString(raw)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvPTCMessage", "src.com", hex.EncodeToString(pkg), endorse("node2")); !strings.Contains(res.Message, "node node2: invalid signature") {
+		t.Fatalf("invalid signature of node2 should be reported: %s", res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvPTCMessage", "src.com", hex.EncodeToString(pkg), endorse("")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
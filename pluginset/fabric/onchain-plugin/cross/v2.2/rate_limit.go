@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 按来源域名限流
+// 管理员为来源域名设置时间窗口内最多接收的消息条数，对端链被攻破或出错时不能用大量消息占满本链的接收通道。
+// 链码内无法获取区块高度，窗口按交易时间戳划分为固定长度的时间段，每个时间段重新计数。
+// 一个报文中的每条消息都计数，超出限流时整笔交易失败(错误码E1010)，中继应当在下一个窗口重新提交。
+// 争议窗口、乐观投递暂存的消息在接收时计数，窗口结束后投递时不再计数。
+//
+// 同一来源域名的计数器在每笔收消息的交易中都会写入，同一区块内的多笔交易会产生读写冲突，
+// 中继同一来源域名的消息应尽量合并为一个报文提交(见recv_batch.go)。
+const (
+	// crosschain_rate_limit_${domain} -> RateLimit
+	K_RATE_LIMIT_PREFIX = CROSSCHAIN_PREFIX + "rate_limit_"
+	// crosschain_rate_counter_${domain} -> RateCounter
+	K_RATE_COUNTER_PREFIX = CROSSCHAIN_PREFIX + "rate_counter_"
+)
+
+type RateLimit struct {
+	Domain string `json:"domain"`
+	// 每个窗口最多接收的消息条数
+	MaxMessages uint64 `json:"maxMessages"`
+	// 窗口长度(秒)
+	Window    int64 `json:"window"`
+	UpdatedAt int64 `json:"updatedAt"`
+}
+
+type RateCounter struct {
+	WindowStart int64  `json:"windowStart"`
+	Count       uint64 `json:"count"`
+}
+
+type RateLimitStatus struct {
+	Limit   *RateLimit   `json:"limit"`
+	Counter *RateCounter `json:"counter,omitempty"`
+}
+
+func (bs *CrossChain) getRateLimit(stub shim.ChaincodeStubInterface, domain string) (*RateLimit, error) {
+	var limit RateLimit
+	has, err := getJSONState(stub, K_RATE_LIMIT_PREFIX+domain, &limit)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// 计入来源域名的一条消息，超出限流时返回错误
+func (bs *CrossChain) consumeRateLimit(stub shim.ChaincodeStubInterface, domain string) error {
+	limit, err := bs.getRateLimit(stub, domain)
+	if err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to get rate limit of %s", domain)
+	}
+	if limit == nil {
+		return nil
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to get tx timestamp")
+	}
+	var counter RateCounter
+	if _, err := getJSONState(stub, K_RATE_COUNTER_PREFIX+domain, &counter); err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to get rate counter of %s", domain)
+	}
+	if start := now - now%limit.Window; counter.WindowStart != start {
+		counter = RateCounter{WindowStart: start}
+	}
+	if counter.Count >= limit.MaxMessages {
+		return crosserr.New(crosserr.CodeRateLimited, "messages from %s exceed %d per %ds, retry after %d",
+			domain, limit.MaxMessages, limit.Window, counter.WindowStart+limit.Window)
+	}
+	counter.Count++
+	if err := putJSONState(stub, K_RATE_COUNTER_PREFIX+domain, &counter); err != nil {
+		return crosserr.Wrap(crosserr.CodeLedger, err, "failed to put rate counter of %s", domain)
+	}
+	return nil
+}
+
+// 设置来源域名的限流
+// args[0] 来源域名
+// args[1] 每个窗口最多接收的消息条数，0表示取消限流
+// args[2] 窗口长度(秒)
+func (bs *CrossChain) setRateLimit(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	max, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max messages: %s", args[1]))
+	}
+	if max == 0 {
+		for _, key := range []string{K_RATE_LIMIT_PREFIX + args[0], K_RATE_COUNTER_PREFIX + args[0]} {
+			if err := stub.DelState(key); err != nil {
+				return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+			}
+		}
+		return shim.Success(nil)
+	}
+	window, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || window <= 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid window: %s", args[2]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	limit := &RateLimit{Domain: args[0], MaxMessages: max, Window: window, UpdatedAt: now}
+	if err := putJSONState(stub, K_RATE_LIMIT_PREFIX+args[0], limit); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put rate limit"))
+	}
+	bz, _ := json.Marshal(limit)
+	return shim.Success(bz)
+}
+
+// 查询来源域名的限流及当前窗口的计数
+// args[0] 来源域名
+func (bs *CrossChain) queryRateLimit(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	limit, err := bs.getRateLimit(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get rate limit"))
+	}
+	if limit == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "rate limit of %s not found", args[0]))
+	}
+	status := RateLimitStatus{Limit: limit}
+	var counter RateCounter
+	if has, err := getJSONState(stub, K_RATE_COUNTER_PREFIX+args[0], &counter); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if has {
+		status.Counter = &counter
+	}
+	bz, _ := json.Marshal(&status)
+	return shim.Success(bz)
+}
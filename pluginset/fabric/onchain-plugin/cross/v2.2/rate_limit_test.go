@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "setRateLimit", "from.com", "3", "100"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRateLimit", "from.com", "3", "0"); res.Status == shim.OK {
+		t.Fatal("window should be positive")
+	}
+
+	// 以指定时间戳接收来源域名的count条消息
+	sent := 0
+	recv := func(seconds int64, from string, count int) error {
+		var msgs oraclelogic.RecvAuthMessages
+		for i := 0; i < count; i++ {
+			sent++
+			msgs.Message = append(msgs.Message, oraclelogic.RecvAuthMessage{
+				From: from, Identity: sha256.Sum256([]byte("sender")), Content: []byte(fmt.Sprintf("hello %d", sent)),
+				Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED,
+			})
+		}
+		raw, _ := json.Marshal(msgs)
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+		if res := NewCrossChain().callbackBizChaincode(wrapStub(stub), raw); res.Status != shim.OK {
+			return crosserr.FromMessage(crosserr.CodeInternal, res.Message)
+		}
+		return nil
+	}
+
+	if err := recv(1000, "from.com", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := recv(1099, "from.com", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 超出限流
+	if err := recv(1099, "from.com", 1); crosserr.CodeOf(err) != crosserr.CodeRateLimited {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 其他来源域名不受影响，新窗口重新计数
+	if err := recv(1099, "other.com", 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := recv(1100, "from.com", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	var status RateLimitStatus
+	if res := InvokeWithStrings(t, stub, sp, "queryRateLimit", "from.com"); json.Unmarshal(res.Payload, &status) != nil {
+		t.Fatal(res.Message)
+	}
+	if status.Limit.MaxMessages != 3 || status.Counter == nil || status.Counter.WindowStart != 1100 || status.Counter.Count != 3 {
+		t.Fatalf("unexpected status: %+v %+v", status.Limit, status.Counter)
+	}
+
+	// 取消限流
+	if res := InvokeWithStrings(t, stub, sp, "setRateLimit", "from.com", "0", "0"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := recv(1101, "from.com", 10); err != nil {
+		t.Fatal(err)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryRateLimit", "from.com"); res.Status == shim.OK {
+		t.Fatal("rate limit should be removed")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 接收回执索引
+// 每条成功接收的AM报文按 ${objectType}~${来源域名}~${sha256(AM报文)} 写入回执，
+// 回调业务链码前检查回执，重放的报文直接拒绝，不依赖通道序号等可能随链码升级变化的状态。
+// 同一交易中重复的报文同样拒绝。不经过AM报文解析的消息没有报文哈希，无序消息以消息摘要代替(见unordered.go)，
+// 有序消息不写回执。乐观模式的声明在确认投递时检查和写入回执，每条消息只有这一份回执。
+const (
+	// ${K_RECEIPT_OBJECT_TYPE}~${domain}~${packetHash} -> MessageReceipt
+	K_RECEIPT_OBJECT_TYPE = CROSSCHAIN_PREFIX + "receipt"
+)
+
+type MessageReceipt struct {
+	From       string `json:"from"`
+	PacketHash string `json:"packetHash"`
+	MsgType    string `json:"msgType"`
+	Seq        uint32 `json:"seq"`
+	Sender     string `json:"sender"`
+	Receiver   string `json:"receiver"`
+	TxId       string `json:"txId"`
+	ReceivedAt int64  `json:"receivedAt"`
+}
+
+// 回执的报文哈希，没有报文哈希的无序消息为消息摘要，其他消息为空
+func receiptHash(msg oraclelogic.RecvAuthMessage) string {
+	if msg.PacketHash == "" && msg.MsgType == oraclelogic.K_MSG_TYPE_UNORDERED {
+		return unorderedMessageDigest(msg)
+	}
+	return msg.PacketHash
+}
+
+// 回调前检查消息是否已接收过
+func (bs *CrossChain) checkReceipts(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage) error {
+	seen := make(map[string]bool)
+	for _, msg := range msgs {
+		hash := receiptHash(msg)
+		if hash == "" {
+			continue
+		}
+		key, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, []string{msg.From, hash})
+		if err != nil {
+			return err
+		}
+		if seen[key] {
+			return crosserr.New(crosserr.CodeConflict, "duplicated packet %s from %s in transaction", hash, msg.From)
+		}
+		seen[key] = true
+		var receipt MessageReceipt
+		if has, err := getJSONState(stub, key, &receipt); err != nil {
+			return err
+		} else if has {
+			return crosserr.New(crosserr.CodeConflict, "replayed packet %s from %s, received in tx %s", hash, msg.From, receipt.TxId)
+		}
+	}
+	return nil
+}
+
+// 回调成功后写入回执
+func (bs *CrossChain) writeReceipts(stub shim.ChaincodeStubInterface, msgs []oraclelogic.RecvAuthMessage) error {
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		hash := receiptHash(msg)
+		if hash == "" {
+			continue
+		}
+		key, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, []string{msg.From, hash})
+		if err != nil {
+			return err
+		}
+		receipt := &MessageReceipt{
+			From:       msg.From,
+			PacketHash: hash,
+			MsgType:    msg.MsgType,
+			Seq:        msg.Seq,
+			Sender:     hex.EncodeToString(msg.Identity[:]),
+			Receiver:   hex.EncodeToString(msg.Receiver[:]),
+			TxId:       stub.GetTxID(),
+			ReceivedAt: now,
+		}
+		if err := putJSONState(stub, key, receipt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 查询接收回执
+// args[0] 来源域名
+// args[1] sha256(AM报文)(hex)，没有报文的无序消息为消息摘要
+func (bs *CrossChain) queryReceipt(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	key, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	var receipt MessageReceipt
+	if has, err := getJSONState(stub, key, &receipt); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no receipt of packet %s from %s", args[1], args[0]))
+	}
+	bz, _ := json.Marshal(&receipt)
+	return shim.Success(bz)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestMessageReceipt(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 有序消息的重放先被序号检查拒绝，这里用无序消息
+	msg := testSDPMessageV2("receipt", oraclelogic.K_UNORDERED_MSG_SEQ)
+	msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+	sdp, _ := msg.Encode()
+	am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+	packetHash := sha256.Sum256(am)
+	hash := hex.EncodeToString(packetHash[:])
+
+	if res := InvokeWithStrings(t, stub, sp, "queryReceipt", "src.com", hash); res.Status == shim.OK {
+		t.Fatal("receipt should not exist before receiving")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryReceipt", "src.com", hash)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var receipt MessageReceipt
+	if err := json.Unmarshal(res.Payload, &receipt); err != nil {
+		t.Fatal(err)
+	}
+	if receipt.From != "src.com" || receipt.PacketHash != hash || receipt.MsgType != oraclelogic.K_MSG_TYPE_UNORDERED || receipt.TxId == "" {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryReceipt", "other.com", hash); res.Status == shim.OK {
+		t.Fatal("receipt is bound to source domain")
+	}
+
+	// 重放的报文被拒绝
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am))); res.Status == shim.OK || !strings.Contains(res.Message, "replayed packet "+hash) {
+		t.Fatalf("replayed packet should be rejected: %s", res.Message)
+	}
+
+	// 同一交易中重复的报文被拒绝
+	bs := NewCrossChain()
+	res = CallWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+		dup := oraclelogic.RecvAuthMessage{From: "src.com", PacketHash: sha256Hex([]byte("dup"))}
+		if err := bs.checkReceipts(stub, []oraclelogic.RecvAuthMessage{dup, dup}); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	})
+	if res.Status == shim.OK || !strings.Contains(res.Message, "duplicated packet") {
+		t.Fatalf("duplicated packet should be rejected: %s", res.Message)
+	}
+}
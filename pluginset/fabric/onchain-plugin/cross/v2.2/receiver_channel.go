@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 跨通道投递
+// 业务链码部署在同一Fabric网络的其他通道上时，管理员登记其所在通道，投递消息时
+// 以该通道调用业务链码。未登记的业务链码在跨链链码所在通道上调用。
+//
+// Fabric跨通道调用时被调用链码的写操作不会提交到账本，只返回执行结果，
+// 其他通道上的业务链码只能用消息做校验或查询，需要落账的业务链码应部署在同一通道。
+// 登记表与接收方登记表一样，配置私有数据集合后保存在集合中(见registry.go)。
+const (
+	// crosschain_channel_route_${sha256(ccName)} -> ReceiverChannel
+	K_CHANNEL_ROUTE_PREFIX = CROSSCHAIN_PREFIX + "channel_route_"
+)
+
+type ReceiverChannel struct {
+	Chaincode string `json:"chaincode"`
+	Channel   string `json:"channel"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+func channelRouteKey(ccName string) string {
+	return K_CHANNEL_ROUTE_PREFIX + sha256Hex([]byte(ccName))
+}
+
+// 业务链码所在的通道，未登记时为跨链链码所在通道
+func (bs *CrossChain) receiverChannel(stub shim.ChaincodeStubInterface, ccName string) (string, error) {
+	var route ReceiverChannel
+	has, err := getRegistryJSON(stub, channelRouteKey(ccName), &route)
+	if err != nil {
+		return "", err
+	}
+	if !has {
+		return stub.GetChannelID(), nil
+	}
+	return route.Channel, nil
+}
+
+// 登记业务链码所在的通道
+// args[0] 业务链码名
+// args[1] 通道名，空字符串或本通道表示取消登记
+func (bs *CrossChain) setReceiverChannel(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty chaincode name"))
+	}
+	if args[1] == "" || args[1] == stub.GetChannelID() {
+		if err := delRegistryState(stub, channelRouteKey(args[0])); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	route := &ReceiverChannel{Chaincode: args[0], Channel: args[1], UpdatedAt: now}
+	if err := putRegistryJSON(stub, channelRouteKey(args[0]), route); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put route"))
+	}
+	bz, _ := json.Marshal(route)
+	return shim.Success(bz)
+}
+
+// 查询业务链码所在的通道
+// args[0] 业务链码名
+func (bs *CrossChain) queryReceiverChannel(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	channel, err := bs.receiverChannel(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get receiver channel"))
+	}
+	bz, _ := json.Marshal(&ReceiverChannel{Chaincode: args[0], Channel: channel})
+	return shim.Success(bz)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+func TestReceiverChannel(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 部署在其他通道上的bizcc
+	otherbiz := shimtest.NewMockStub("bizcc", new(CrossChainTest))
+	stub.MockPeerChaincode("bizcc", otherbiz, "otherchannel")
+	recv := func(seq uint32) pb.Response {
+		msg := testSDPMessageV2("channel", seq)
+		msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+		return InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am)))
+	}
+	query := func() ReceiverChannel {
+		var route ReceiverChannel
+		res := InvokeWithStrings(t, stub, sp, "queryReceiverChannel", "bizcc")
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if err := json.Unmarshal(res.Payload, &route); err != nil {
+			t.Fatal(err)
+		}
+		return route
+	}
+
+	if res := recv(0); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if len(otherbiz.State) != 0 {
+		t.Fatal("message should be delivered on own channel")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverChannel", "", "otherchannel"); res.Status == shim.OK {
+		t.Fatal("empty chaincode name should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverChannel", "bizcc", "otherchannel"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if route := query(); route.Channel != "otherchannel" {
+		t.Fatalf("unexpected channel: %+v", route)
+	}
+	// MockStub不区分通道提交，这里只检查调用到了其他通道上的链码
+	if res := recv(1); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if len(otherbiz.State) == 0 {
+		t.Fatal("message should be delivered on other channel")
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverChannel", "bizcc", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if route := query(); route.Channel != stub.GetChannelID() {
+		t.Fatalf("unexpected channel: %+v", route)
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setReceiverChannel", "bizcc", "otherchannel"); res.Status == shim.OK {
+		t.Fatal("non-admin should not set receiver channel")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 批量接收消息
+// 一笔交易提交多条报文，只需要一轮背书。每条报文单独校验，校验失败的报文不影响其他报文，
+// 结果记录在交易事件中(v1为K_RECV_BATCH_EVENT事件，v2为接收事件的batchResult字段)；校验通过的报文按顺序一起回调业务链码。
+//
+// 校验失败的报文在结果中带有错误码(见crosserr)，中继据此判断是否重新提交。
+//
+// 报文校验通过后已经推进了AM层的序号，回调业务链码失败时整笔交易失败，不能只丢弃失败的报文。
+// 每个条目的格式与recvMessage的rawdata相同，但只能包含一条报文，否则前面的报文推进序号后
+// 后面的报文校验失败会丢失消息。
+const (
+	K_RECV_BATCH_EVENT = CROSSCHAIN_PREFIX + "recv_batch"
+
+	MAX_RECV_BATCH_SIZE = 500
+
+	RECV_BATCH_STATUS_OK     = "ok"
+	RECV_BATCH_STATUS_FAILED = "failed"
+)
+
+type RecvBatchStatus struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// 仅校验失败的报文
+	Error *crosserr.Detail `json:"error,omitempty"`
+	// 仅校验通过的报文
+	From       string `json:"from,omitempty"`
+	MsgType    string `json:"msgType,omitempty"`
+	Seq        uint32 `json:"seq,omitempty"`
+	PacketHash string `json:"packetHash,omitempty"`
+}
+
+type RecvBatchResult struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Results   []RecvBatchStatus `json:"results"`
+}
+
+// rawdata由若干 uint32(len(hint)) || hint || uint32(len(proof)) || proof 组成，返回报文条数
+func countRawDataPackets(rawdata []byte) (int, error) {
+	count, offset := 0, 0
+	for offset < len(rawdata) {
+		for i := 0; i < 2; i++ {
+			if offset+4 > len(rawdata) {
+				return 0, fmt.Errorf("truncated length at offset %d", offset)
+			}
+			l := int(binary.BigEndian.Uint32(rawdata[offset:]))
+			offset += 4
+			if l < 0 || offset+l > len(rawdata) {
+				return 0, fmt.Errorf("truncated data at offset %d", offset)
+			}
+			offset += l
+		}
+		count++
+	}
+	return count, nil
+}
+
+// 校验一条报文，返回解析出的消息
+func (bs *CrossChain) recvBatchItem(stub shim.ChaincodeStubInterface, serviceId string, item string) (oraclelogic.RecvAuthMessage, error) {
+	rawdata, err := hex.DecodeString(item)
+	if err != nil {
+		return oraclelogic.RecvAuthMessage{}, crosserr.New(crosserr.CodeInvalidArgs, "rawdata format error")
+	}
+	if n, err := countRawDataPackets(rawdata); err != nil {
+		return oraclelogic.RecvAuthMessage{}, crosserr.Wrap(crosserr.CodeInvalidArgs, err, "rawdata format error")
+	} else if n != 1 {
+		return oraclelogic.RecvAuthMessage{}, crosserr.New(crosserr.CodeInvalidArgs, "item must contain exactly one packet, got %d", n)
+	}
+	ret := bs.Os.RecvBatchMychainMessage(stub, []string{serviceId, item})
+	if ret.Status != shim.OK {
+		return oraclelogic.RecvAuthMessage{}, crosserr.FromMessage(crosserr.CodeVerify, ret.Message)
+	}
+	var msgs oraclelogic.RecvAuthMessages
+	if err := json.Unmarshal(ret.Payload, &msgs); err != nil || len(msgs.Message) != 1 {
+		return oraclelogic.RecvAuthMessage{}, crosserr.New(crosserr.CodeInternal, "unexpected message: %s", ret.Payload)
+	}
+	if err := bs.checkPTCBypass(stub, msgs.Message); err != nil {
+		return oraclelogic.RecvAuthMessage{}, crosserr.Wrap(crosserr.CodeLedger, err, "check PTC committee")
+	}
+	if err := bs.checkPTCCertChain(stub, msgs.Message); err != nil {
+		return oraclelogic.RecvAuthMessage{}, crosserr.Wrap(crosserr.CodeLedger, err, "check PTC certificate")
+	}
+	return msgs.Message[0], nil
+}
+
+// 批量接收消息
+// args[0] oracle service id
+// args[1] 报文(json数组)，每个元素为一条报文的rawdata(hex)
+func (bs *CrossChain) recvBatchMessages(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var items []string
+	if err := json.Unmarshal([]byte(args[1]), &items); err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "items format error: %v", err))
+	}
+	if len(items) == 0 || len(items) > MAX_RECV_BATCH_SIZE {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid batch size %d", len(items)))
+	}
+
+	result := RecvBatchResult{Total: len(items), Results: []RecvBatchStatus{}}
+	var msgs oraclelogic.RecvAuthMessages
+	for i, item := range items {
+		msg, err := bs.recvBatchItem(stub, args[0], item)
+		if err != nil {
+			result.Results = append(result.Results, RecvBatchStatus{Index: i, Status: RECV_BATCH_STATUS_FAILED, Message: err.Error(), Error: crosserr.DetailOf(err)})
+			continue
+		}
+		msgs.Message = append(msgs.Message, msg)
+		result.Succeeded++
+		result.Results = append(result.Results, RecvBatchStatus{
+			Index: i, Status: RECV_BATCH_STATUS_OK, From: msg.From, MsgType: msg.MsgType, Seq: msg.Seq, PacketHash: msg.PacketHash,
+		})
+	}
+
+	// v2下结果随接收事件发出，没有校验通过的报文时事件中只有结果
+	bz, _ := json.Marshal(result)
+	if len(msgs.Message) > 0 {
+		if re := bs.deliverInbound(stub, msgs.Message, bz); re.Status != shim.OK {
+			return re
+		}
+	} else if err := bs.emitRecvEventV2(stub, nil, bz); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+	}
+	version, err := getEventVersion(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get event version"))
+	}
+	if version == EVENT_VERSION_2 {
+		return shim.Success(bz)
+	}
+	if err := stub.SetEvent(K_RECV_BATCH_EVENT, bz); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to emit event"))
+	}
+	return shim.Success(bz)
+}
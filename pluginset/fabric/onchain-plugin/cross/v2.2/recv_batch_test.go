@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crossevent"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func appendTLV(buf []byte, t uint16, v []byte) []byte {
+	var hdr [6]byte
+	binary.LittleEndian.PutUint16(hdr[:2], t)
+	binary.LittleEndian.PutUint32(hdr[2:], uint32(len(v)))
+	return append(append(buf, hdr[:]...), v...)
+}
+
+func appendLengthPrefixed(buf []byte, v []byte) []byte {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(v)))
+	return append(append(buf, l[:]...), v...)
+}
+
+// 构造不带hint的rawdata(hex)，报文不经过oracle签名校验，直接按来源域名解析
+func mockRecvRawData(t *testing.T, domain string, pkgs ...string) string {
+	var rawdata []byte
+	for _, pkg := range pkgs {
+		amPkt, err := hex.DecodeString(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := appendTLV(make([]byte, 6), 1, nil)
+		body = appendTLV(body, 2, amPkt)
+		body = appendTLV(body, 3, []byte{0, 0})
+		proof := appendTLV(make([]byte, 6), 9, []byte(domain))
+		proof = appendTLV(proof, 4, body)
+		rawdata = appendLengthPrefixed(rawdata, nil)
+		rawdata = appendLengthPrefixed(rawdata, proof)
+	}
+	return hex.EncodeToString(rawdata)
+}
+
+func TestRecvBatchMessages(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "batch 0", "batch 1", "batch 2")
+	items := []string{
+		mockRecvRawData(t, "src.com", pkgs[0]),
+		mockRecvRawData(t, "src.com", pkgs[2]), // 序号不连续
+		"zz",
+		mockRecvRawData(t, "src.com", pkgs[1]),
+		mockRecvRawData(t, "src.com", pkgs[1], pkgs[2]), // 一个条目多条报文
+	}
+	raw, _ := json.Marshal(items)
+	res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", string(raw))
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var result RecvBatchResult
+	if err := json.Unmarshal(res.Payload, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 5 || result.Succeeded != 2 || len(result.Results) != 5 {
+		t.Fatalf("unexpected batch result: %s", res.Payload)
+	}
+	for i, status := range []string{RECV_BATCH_STATUS_OK, RECV_BATCH_STATUS_FAILED, RECV_BATCH_STATUS_FAILED, RECV_BATCH_STATUS_OK, RECV_BATCH_STATUS_FAILED} {
+		if result.Results[i].Index != i || result.Results[i].Status != status {
+			t.Fatalf("unexpected status of item %d: %+v", i, result.Results[i])
+		}
+	}
+	pkt, _ := hex.DecodeString(pkgs[1])
+	if ok := result.Results[3]; ok.From != "src.com" || ok.Seq != 1 || ok.PacketHash != sha256Hex(pkt) {
+		t.Fatalf("unexpected result: %+v", ok)
+	}
+	if !strings.Contains(result.Results[4].Message, "exactly one packet") {
+		t.Fatalf("unexpected message: %s", result.Results[4].Message)
+	}
+	// 失败原因带错误码，序号超前的报文可以重试
+	for i, symbol := range map[int]string{1: "ERR_SEQ_AHEAD", 2: "ERR_INVALID_ARGS", 4: "ERR_INVALID_ARGS"} {
+		detail := result.Results[i].Error
+		if detail == nil || detail.Symbol != symbol || detail.Retryable != (symbol == "ERR_SEQ_AHEAD") {
+			t.Fatalf("unexpected error of item %d: %+v", i, detail)
+		}
+	}
+	if result.Results[0].Error != nil {
+		t.Fatalf("unexpected error of item 0: %+v", result.Results[0].Error)
+	}
+
+	event := <-stub.ChaincodeEventsChannel
+	if event.EventName != K_RECV_BATCH_EVENT || string(event.Payload) != string(res.Payload) {
+		t.Fatalf("unexpected event: %s %s", event.EventName, event.Payload)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.HasSuffix(string(res.Payload), ":batch 1") {
+		t.Fatalf("unexpected last message: %s", res.Payload)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", "[]"); res.Status == shim.OK {
+		t.Fatal("empty batch should be rejected")
+	}
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", string(raw)); res.Status == shim.OK {
+		t.Fatal("non-admin should be rejected")
+	}
+}
+
+// v2下批量结果作为接收事件的字段发出，每笔交易只有一个事件
+func TestRecvBatchEventV2(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "batch 0")
+
+	for _, c := range []struct {
+		items  []string
+		events int
+	}{
+		{[]string{mockRecvRawData(t, "src.com", pkgs[0]), "zz"}, 1},
+		// 没有校验通过的报文时仍然发出结果
+		{[]string{"zz"}, 0},
+	} {
+		raw, _ := json.Marshal(c.items)
+		res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", string(raw))
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		event := <-stub.ChaincodeEventsChannel
+		if event.EventName != crossevent.EVENT_RECEIVED_MESSAGE || len(stub.ChaincodeEventsChannel) != 0 {
+			t.Fatalf("unexpected event: %s", event.EventName)
+		}
+		events, err := crossevent.DecodeEvents(event.Payload)
+		if err != nil || len(events.Events) != c.events || string(events.BatchResult) != string(res.Payload) {
+			t.Fatalf("unexpected events: %+v %v", events, err)
+		}
+		var result RecvBatchResult
+		if err := json.Unmarshal(events.BatchResult, &result); err != nil || result.Total != len(c.items) || result.Succeeded != c.events {
+			t.Fatalf("unexpected batch result: %s", events.BatchResult)
+		}
+	}
+}
+
+// 格式错误的rawdata返回错误，不能panic
+func TestRecvMalformedRawData(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkgs := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "m")
+	valid, _ := hex.DecodeString(mockRecvRawData(t, "src.com", pkgs[0]))
+
+	// tag 5为回复packet，只有头没有item
+	shortResp := appendTLV(make([]byte, 6), 5, make([]byte, 8))
+	shortResp = appendTLV(shortResp, 9, []byte("src.com"))
+	for name, raw := range map[string][]byte{
+		"truncated hint length":  {0, 0},
+		"hint exceeds data":      {0xff, 0xff, 0xff, 0xff, 0},
+		"missing proof":          {0, 0, 0, 0},
+		"proof exceeds data":     append([]byte{0, 0, 0, 0}, 0x7f, 0xff, 0xff, 0xff),
+		"truncated second item":  append(append([]byte{}, valid...), 0, 0, 0),
+		"short response body":    appendLengthPrefixed(appendLengthPrefixed(nil, nil), shortResp),
+		"truncated proof packet": appendLengthPrefixed(appendLengthPrefixed(nil, nil), []byte{0, 0, 10}),
+	} {
+		if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", hex.EncodeToString(raw)); res.Status == shim.OK {
+			t.Fatalf("%s: malformed rawdata should be rejected", name)
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", hex.EncodeToString(valid)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 授权与路由表的存储
+// 中继者保证金、挑战者授权和接收方登记表默认保存在公共状态中。管理员配置私有数据集合后，
+// 这些表项改为写入集合，公共状态中只保留 sha256(key) -> sha256(value) 摘要，
+// 非跨链运营方的通道成员看不到授权关系，但可以用已知的表项核对摘要。
+//
+// 配置集合后只有集合成员的节点可以背书读取这些表项的交易(收消息、挑战等)。
+// 配置前写入的公共表项仍可读取，下次修改时迁移到集合中。
+const (
+	// 保存授权与路由表的私有数据集合名，未配置时使用公共状态
+	K_REGISTRY_COLLECTION = CROSSCHAIN_PREFIX + "registry_collection"
+
+	// crosschain_registry_digest_${sha256(key)} -> sha256(value)
+	K_REGISTRY_DIGEST_PREFIX = CROSSCHAIN_PREFIX + "registry_digest_"
+
+	// crosschain_receiver_${sha256(ccName)} -> ccName
+	K_RECEIVER_PREFIX = CROSSCHAIN_PREFIX + "receiver_"
+)
+
+func registryDigestKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return K_REGISTRY_DIGEST_PREFIX + hex.EncodeToString(h[:])
+}
+
+func getRegistryCollection(stub shim.ChaincodeStubInterface) (string, error) {
+	collection, err := stub.GetState(K_REGISTRY_COLLECTION)
+	return string(collection), err
+}
+
+// 读取表项，不存在时返回nil
+func getRegistryState(stub shim.ChaincodeStubInterface, key string) ([]byte, error) {
+	collection, err := getRegistryCollection(stub)
+	if err != nil {
+		return nil, err
+	}
+	if collection == "" {
+		return stub.GetState(key)
+	}
+	digest, err := stub.GetState(registryDigestKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(digest) == 0 {
+		return stub.GetState(key)
+	}
+	value, err := stub.GetPrivateData(collection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get private data from %s: %v", collection, err)
+	}
+	h := sha256.Sum256(value)
+	if len(value) == 0 || !bytes.Equal(h[:], digest) {
+		return nil, fmt.Errorf("registry entry is not available on this peer, collection %s", collection)
+	}
+	return value, nil
+}
+
+func putRegistryState(stub shim.ChaincodeStubInterface, key string, value []byte) error {
+	collection, err := getRegistryCollection(stub)
+	if err != nil {
+		return err
+	}
+	if collection == "" {
+		return stub.PutState(key, value)
+	}
+	if err := stub.PutPrivateData(collection, key, value); err != nil {
+		return fmt.Errorf("failed to put private data to %s: %v", collection, err)
+	}
+	h := sha256.Sum256(value)
+	if err := stub.PutState(registryDigestKey(key), h[:]); err != nil {
+		return err
+	}
+	// 清除配置集合前写入的公共表项
+	return stub.DelState(key)
+}
+
+func delRegistryState(stub shim.ChaincodeStubInterface, key string) error {
+	collection, err := getRegistryCollection(stub)
+	if err != nil {
+		return err
+	}
+	if collection != "" {
+		if err := stub.DelPrivateData(collection, key); err != nil {
+			return fmt.Errorf("failed to delete private data from %s: %v", collection, err)
+		}
+		if err := stub.DelState(registryDigestKey(key)); err != nil {
+			return err
+		}
+	}
+	return stub.DelState(key)
+}
+
+func getRegistryJSON(stub shim.ChaincodeStubInterface, key string, v interface{}) (bool, error) {
+	raw, err := getRegistryState(stub, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get registry %s: %v", key, err)
+	}
+	if len(raw) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal registry %s: %v", key, err)
+	}
+	return true, nil
+}
+
+func putRegistryJSON(stub shim.ChaincodeStubInterface, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry %s: %v", key, err)
+	}
+	if err := putRegistryState(stub, key, raw); err != nil {
+		return fmt.Errorf("failed to put registry %s: %v", key, err)
+	}
+	return nil
+}
+
+// 配置保存授权与路由表的私有数据集合
+// args[0] 集合名，空字符串表示使用公共状态
+func (bs *CrossChain) setRegistryCollection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var err error
+	if args[0] == "" {
+		err = stub.DelState(K_REGISTRY_COLLECTION)
+	} else {
+		err = stub.PutState(K_REGISTRY_COLLECTION, []byte(args[0]))
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put registry collection"))
+	}
+	if args[0] != "" {
+		if err := bs.protectKeys(stub, KEY_SCOPE_REGISTRY, K_REGISTRY_COLLECTION); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect keys"))
+		}
+	}
+	return shim.Success(nil)
+}
+
+// 登记接收消息的业务链码
+// args[0] 业务链码名
+func (bs *CrossChain) registerReceiver(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 || args[0] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	h := sha256.Sum256([]byte(args[0]))
+	key := K_RECEIVER_PREFIX + hex.EncodeToString(h[:])
+	if err := putRegistryState(stub, key, []byte(args[0])); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	publicKey, err := registryPublicKey(stub, key)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get registry collection"))
+	}
+	if err := bs.protectKeys(stub, KEY_SCOPE_REGISTRY, publicKey); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect keys"))
+	}
+	return shim.Success(nil)
+}
+
+// 按接收方身份(业务链码名的sha256)查找业务链码名
+// 先查接收方登记表，再查oraclelogic的sha256原像表
+func (bs *CrossChain) resolveReceiver(stub shim.ChaincodeStubInterface, receiver [32]byte) (string, error) {
+	receiverHex := hex.EncodeToString(receiver[:])
+	name, err := getRegistryState(stub, K_RECEIVER_PREFIX+receiverHex)
+	if err != nil {
+		return "", err
+	}
+	if len(name) != 0 {
+		return string(name), nil
+	}
+	ret := bs.Os.QuerySha256Invert(stub, []string{receiverHex})
+	if ret.Status != shim.OK {
+		return "", crosserr.New(crosserr.CodeNotFound, "receiver chaincode(recHash: %s) not exist!", receiverHex)
+	}
+	return string(ret.Payload), nil
+}
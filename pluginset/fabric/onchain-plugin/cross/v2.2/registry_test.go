@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestPrivateRegistry(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+	challenger := testCertHash(TEST_RELAYER_CERT)
+
+	// 配置集合前登记的保证金保存在公共状态中
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "150", "escrow-001"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	bondKey, _ := stub.CreateCompositeKey(K_BOND_OBJECT_TYPE, []string{relayer})
+	if _, has := stub.State[bondKey]; !has {
+		t.Fatal("bond should be stored in public state")
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "setRegistryCollection", "bridgeOperators"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryBond", relayer); res.Status != shim.OK {
+		t.Fatal("bond stored before collection configured should be readable")
+	}
+	// 修改后迁移到集合中，公共状态只保留摘要
+	if res := InvokeWithStrings(t, stub, sp, "confirmBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if _, has := stub.State[bondKey]; has {
+		t.Fatal("bond should be removed from public state")
+	}
+	raw := stub.PvtState["bridgeOperators"][bondKey]
+	digest := sha256.Sum256(raw)
+	if len(raw) == 0 || string(stub.State[registryDigestKey(bondKey)]) != string(digest[:]) {
+		t.Fatal("bond should be stored in collection with digest on public state")
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryBond", relayer)
+	var bond RelayerBond
+	if err := json.Unmarshal(res.Payload, &bond); err != nil || bond.Status != BOND_STATUS_ACTIVE {
+		t.Fatalf("unexpected bond: %s", res.Payload)
+	}
+
+	// 挑战者授权保存在集合中
+	if res := InvokeWithStrings(t, stub, sp, "setChallenger", challenger, "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	challengerKey, _ := stub.CreateCompositeKey(K_CHALLENGER_OBJECT_TYPE, []string{challenger})
+	if _, has := stub.State[challengerKey]; has {
+		t.Fatal("challenger should not be stored in public state")
+	}
+	stub.MockTransactionStart(txid)
+	ok, err := NewCrossChain().isChallenger(stub, challenger)
+	stub.MockTransactionEnd(txid)
+	if err != nil || !ok {
+		t.Fatalf("challenger should be authorized: %v", err)
+	}
+	// 集合中的表项与摘要不一致时拒绝读取
+	stub.PvtState["bridgeOperators"][challengerKey] = []byte{0x02}
+	stub.MockTransactionStart(txid)
+	_, err = NewCrossChain().isChallenger(stub, challenger)
+	stub.MockTransactionEnd(txid)
+	if err == nil {
+		t.Fatal("tampered registry entry should be rejected")
+	}
+
+	// 接收方登记表保存在集合中，回调时按登记表查找业务链码
+	var bizsp pb.SignedProposal
+	MockSignedProposal("bizcc", &bizsp)
+	stubbiz := shimtest.NewMockStub("bizcc", new(CrossChainTest))
+	stub.MockPeerChaincode("bizcc", stubbiz, "")
+	stubbiz.MockPeerChaincode("crosscc", stub, "")
+	if res := InvokeWithStrings(t, stub, sp, "registerReceiver", "bizcc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("registered"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+	}}
+	msgsRaw, _ := json.Marshal(msgs)
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(msgsRaw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, &bizsp, "getLastUnorderedMsg"); !strings.Contains(string(res.Payload), "registered") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	for k, v := range stub.State {
+		if strings.Contains(string(v), "bizcc") {
+			t.Fatalf("receiver should not be visible in public state, key %s", k)
+		}
+	}
+}
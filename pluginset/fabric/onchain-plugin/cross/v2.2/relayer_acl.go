@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 中继者白名单
+// 开启后只有白名单中的中继者才能提交跨链消息。白名单表项可以是整个组织(MSP ID)，
+// 也可以是单个中继者证书的sha256，交易发起者满足任意一项即可。
+// 白名单与接收方登记表一样保存在授权表中，配置私有数据集合后写入集合。
+//
+// 开启前需要先把提交消息的身份加入白名单，否则所有收消息的交易都会被拒绝。
+const (
+	K_RELAYER_ACL_CONFIG = CROSSCHAIN_PREFIX + "relayer_acl_config"
+	// composite key: crosschain_relayer_acl~${kind}~${value}
+	K_RELAYER_ACL_OBJECT_TYPE = CROSSCHAIN_PREFIX + "relayer_acl"
+
+	RELAYER_ACL_KIND_MSP  = "msp"
+	RELAYER_ACL_KIND_CERT = "cert"
+)
+
+type RelayerACLConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+type RelayerACLEntry struct {
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Allowed bool   `json:"allowed"`
+}
+
+func relayerACLKey(stub shim.ChaincodeStubInterface, kind, value string) (string, error) {
+	switch kind {
+	case RELAYER_ACL_KIND_MSP:
+		if value == "" {
+			return "", fmt.Errorf("empty msp id")
+		}
+	case RELAYER_ACL_KIND_CERT:
+		if id, err := hex.DecodeString(value); err != nil || len(id) != sha256.Size {
+			return "", fmt.Errorf("relayer(%s) format error", value)
+		}
+	default:
+		return "", crosserr.New(crosserr.CodeInvalidArgs, "unknown acl kind: %s", kind)
+	}
+	return stub.CreateCompositeKey(K_RELAYER_ACL_OBJECT_TYPE, []string{kind, value})
+}
+
+func (bs *CrossChain) isRelayerACLAllowed(stub shim.ChaincodeStubInterface, kind, value string) (bool, error) {
+	key, err := relayerACLKey(stub, kind, value)
+	if err != nil {
+		return false, err
+	}
+	raw, err := getRegistryState(stub, key)
+	if err != nil {
+		return false, err
+	}
+	return len(raw) != 0, nil
+}
+
+// 检查交易发起者是否在中继者白名单中，未开启时直接通过
+func (bs *CrossChain) checkRelayerACL(stub shim.ChaincodeStubInterface) error {
+	var config RelayerACLConfig
+	if has, err := getJSONState(stub, K_RELAYER_ACL_CONFIG, &config); err != nil || !has || !config.Enabled {
+		return err
+	}
+	mspId, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return err
+	}
+	if mspId != "" {
+		if ok, err := bs.isRelayerACLAllowed(stub, RELAYER_ACL_KIND_MSP, mspId); err != nil || ok {
+			return err
+		}
+	}
+	if ok, err := bs.isRelayerACLAllowed(stub, RELAYER_ACL_KIND_CERT, relayer); err != nil || ok {
+		return err
+	}
+	return fmt.Errorf("relayer %s of %s is not in relayer acl", relayer, mspId)
+}
+
+// 开启或关闭中继者白名单
+// args[0] true/false
+func (bs *CrossChain) setRelayerACLConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	enabled, err := strconv.ParseBool(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "enabled(%s) format error", args[0]))
+	}
+	if err := putJSONState(stub, K_RELAYER_ACL_CONFIG, &RelayerACLConfig{Enabled: enabled}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 加入或移出中继者白名单
+// args[0] msp/cert
+// args[1] MSP ID或中继者证书sha256(hex)
+// args[2] true/false
+func (bs *CrossChain) setRelayerACL(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	key, err := relayerACLKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	allowed, err := strconv.ParseBool(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "allowed(%s) format error", args[2]))
+	}
+	if allowed {
+		err = putRegistryState(stub, key, []byte{0x01})
+	} else {
+		err = delRegistryState(stub, key)
+	}
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put relayer acl"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询中继者白名单表项
+// args[0] msp/cert
+// args[1] MSP ID或中继者证书sha256(hex)
+func (bs *CrossChain) queryRelayerACL(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	allowed, err := bs.isRelayerACLAllowed(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to check relayer acl"))
+	}
+	raw, _ := json.Marshal(&RelayerACLEntry{Kind: args[0], Value: args[1], Allowed: allowed})
+	return shim.Success(raw)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strings"
+	"testing"
+)
+
+func TestRelayerACL(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+	check := func(creator []byte) error {
+		stub.Creator = creator
+		defer func() { stub.Creator = MockCreator(TEST_ADMIN_CERT) }()
+		var err error
+		CallWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+			err = NewCrossChain().checkRelayerACL(stub)
+			return shim.Success(nil)
+		})
+		return err
+	}
+	orgCreator := func(cert, mspId string) []byte {
+		bt, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: mspId, IdBytes: []byte(cert)})
+		return bt
+	}
+
+	// 未开启时不检查
+	if err := check(MockCreator(TEST_RELAYER_CERT)); err != nil {
+		t.Fatal(err)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACLConfig", "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := check(MockCreator(TEST_RELAYER_CERT)); err == nil {
+		t.Fatal("relayer not in acl should be rejected")
+	}
+	res := InvokeWithStrings(t, stub, sp, "recvBatchMessages", "svc", "[]")
+	if res.Status == shim.OK || !strings.Contains(res.Message, "not in relayer acl") {
+		t.Fatalf("admin not in acl should be rejected, got %s", res.Message)
+	}
+
+	for _, args := range [][]string{{"cert", "00", "true"}, {"msp", "", "true"}, {"org", "Org1MSP", "true"}, {"msp", "Org1MSP", "yes"}} {
+		if res := InvokeWithStrings(t, stub, sp, "setRelayerACL", args[0], args[1], args[2]); res.Status == shim.OK {
+			t.Fatalf("invalid acl entry %v should be rejected", args)
+		}
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACL", "cert", relayer, "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := check(MockCreator(TEST_RELAYER_CERT)); err != nil {
+		t.Fatal(err)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACL", "msp", "Org1MSP", "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := check(orgCreator(TEST_ADMIN_CERT, "Org1MSP")); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(orgCreator(TEST_ADMIN_CERT, "Org2MSP")); err == nil {
+		t.Fatal("organization not in acl should be rejected")
+	}
+
+	var entry RelayerACLEntry
+	if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryRelayerACL", "cert", relayer).Payload, &entry); err != nil || !entry.Allowed {
+		t.Fatalf("unexpected acl entry: %+v", entry)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACL", "cert", relayer, "false"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := check(MockCreator(TEST_RELAYER_CERT)); err == nil {
+		t.Fatal("removed relayer should be rejected")
+	}
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerACLConfig", "false"); res.Status == shim.OK {
+		t.Fatal("non-admin should not change relayer acl")
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 中继者保证金(bond)模块
+// 中继者在链上登记保证金，管理员确认后生效；管理员在证明中继者作恶后可以罚没保证金。
+// 已生效的保证金追加时，追加部分单独记为待确认数额，确认前原有保证金保持有效。
+// 模块开启后，只有保证金有效的中继者才允许提交跨链消息。
+const (
+	K_BOND_CONFIG = CROSSCHAIN_PREFIX + "bond_config"
+
+	// composite key: crosschain_bond~${relayer}
+	K_BOND_OBJECT_TYPE = CROSSCHAIN_PREFIX + "bond"
+
+	BOND_STATUS_PENDING   = "PENDING"
+	BOND_STATUS_ACTIVE    = "ACTIVE"
+	BOND_STATUS_SLASHED   = "SLASHED"
+	BOND_STATUS_WITHDRAWN = "WITHDRAWN"
+
+	SLASH_REASON_INVALID_PROOF = "INVALID_PROOF"
+	SLASH_REASON_FRAUD_PROOF   = "FRAUD_PROOF"
+)
+
+type BondConfig struct {
+	Enabled bool   `json:"enabled"`
+	MinBond uint64 `json:"minBond"`
+}
+
+type BondSlash struct {
+	Amount       uint64 `json:"amount"`
+	Reason       string `json:"reason"`
+	EvidenceHash string `json:"evidenceHash"`
+	TxId         string `json:"txId"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+type RelayerBond struct {
+	Relayer   string      `json:"relayer"` // 中继者证书sha256(hex)
+	MspId     string      `json:"mspId"`
+	Amount    uint64      `json:"amount"`    // 剩余有效保证金
+	Reference string      `json:"reference"` // 链下托管凭证
+	Status    string      `json:"status"`
+	UpdatedAt int64       `json:"updatedAt"`
+	Slashes   []BondSlash `json:"slashes"`
+
+	PendingAmount    uint64 `json:"pendingAmount,omitempty"`    // 待管理员确认的数额
+	PendingReference string `json:"pendingReference,omitempty"` // 待确认部分的链下托管凭证
+}
+
+// 罚没证据校验钩子，返回nil表示证据成立
+// 按罚没原因注册，未注册校验钩子的原因由管理员直接裁定
+type slashEvidenceVerifier func(bs *CrossChain, stub shim.ChaincodeStubInterface, bond *RelayerBond, evidence []byte) error
+
+var slashEvidenceVerifiers = map[string]slashEvidenceVerifier{}
+
+func registerSlashEvidenceVerifier(reason string, verifier slashEvidenceVerifier) {
+	slashEvidenceVerifiers[reason] = verifier
+}
+
+func (bs *CrossChain) getBondConfig(stub shim.ChaincodeStubInterface) (*BondConfig, error) {
+	var config BondConfig
+	if _, err := getJSONState(stub, K_BOND_CONFIG, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (bs *CrossChain) getRelayerBond(stub shim.ChaincodeStubInterface, relayer string) (*RelayerBond, string, error) {
+	key, err := stub.CreateCompositeKey(K_BOND_OBJECT_TYPE, []string{relayer})
+	if err != nil {
+		return nil, "", err
+	}
+	var bond RelayerBond
+	has, err := getRegistryJSON(stub, key, &bond)
+	if err != nil {
+		return nil, key, err
+	}
+	if !has {
+		return nil, key, nil
+	}
+	return &bond, key, nil
+}
+
+// 设置保证金模块配置
+// args[0] 是否开启, true/false
+// args[1] 最低有效保证金
+func (bs *CrossChain) setBondConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	enabled, err := strconv.ParseBool(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "enabled(%s) format error", args[0]))
+	}
+	minBond, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "minBond(%s) format error", args[1]))
+	}
+
+	if err := putJSONState(stub, K_BOND_CONFIG, &BondConfig{Enabled: enabled, MinBond: minBond}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 中继者登记保证金，可多次调用追加
+// args[0] 保证金数额
+// args[1] 链下托管凭证
+func (bs *CrossChain) postBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	amount, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil || amount == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "amount(%s) format error", args[0]))
+	}
+
+	mspId, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	bond, key, err := bs.getRelayerBond(stub, relayer)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond == nil || bond.Status == BOND_STATUS_WITHDRAWN {
+		bond = &RelayerBond{Relayer: relayer, MspId: mspId, Status: BOND_STATUS_PENDING}
+	}
+	// 追加的保证金需要管理员确认，确认前不计入有效保证金，也不影响已生效的状态
+	bond.PendingAmount += amount
+	bond.PendingReference = args[1]
+	bond.UpdatedAt = now
+
+	if err := putRegistryJSON(stub, key, bond); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put bond"))
+	}
+	return shim.Success([]byte(relayer))
+}
+
+// 有待确认数额的保证金，PENDING状态表示尚无已确认的数额
+// 升级前登记的PENDING保证金，待确认的数额已计入Amount
+func hasPendingBond(bond *RelayerBond) bool {
+	return bond != nil && (bond.PendingAmount > 0 || bond.Status == BOND_STATUS_PENDING)
+}
+
+// 管理员确认保证金已到账，待确认的数额计入有效保证金
+// args[0] 中继者证书sha256(hex)
+func (bs *CrossChain) confirmBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bond, key, err := bs.getRelayerBond(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if !hasPendingBond(bond) {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no pending bond for relayer %s", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	bond.Amount += bond.PendingAmount
+	if bond.PendingAmount > 0 {
+		bond.Reference = bond.PendingReference
+	}
+	bond.PendingAmount = 0
+	bond.PendingReference = ""
+	bond.Status = BOND_STATUS_ACTIVE
+	bond.UpdatedAt = now
+	if err := putRegistryJSON(stub, key, bond); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put bond"))
+	}
+	return shim.Success(nil)
+}
+
+// 管理员拒绝待确认的保证金，已确认的数额和状态不变；
+// 没有已确认数额的保证金被拒绝后视为已释放
+// args[0] 中继者证书sha256(hex)
+func (bs *CrossChain) rejectBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bond, key, err := bs.getRelayerBond(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if !hasPendingBond(bond) {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no pending bond for relayer %s", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	if bond.Status == BOND_STATUS_PENDING {
+		bond.Amount = 0
+		bond.Status = BOND_STATUS_WITHDRAWN
+	}
+	bond.PendingAmount = 0
+	bond.PendingReference = ""
+	bond.UpdatedAt = now
+	if err := putRegistryJSON(stub, key, bond); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put bond"))
+	}
+	return shim.Success(nil)
+}
+
+// 管理员罚没中继者保证金
+// args[0] 中继者证书sha256(hex)
+// args[1] 罚没数额
+// args[2] 罚没原因，如INVALID_PROOF
+// args[3] 作恶证据(hex)，注册了校验钩子的原因必须提供
+func (bs *CrossChain) slashBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	relayer, reason := args[0], args[2]
+	amount, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil || amount == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "amount(%s) format error", args[1]))
+	}
+	evidence, err := hex.DecodeString(args[3])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "evidence format error"))
+	}
+	if reason == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty slash reason"))
+	}
+
+	bond, key, err := bs.getRelayerBond(stub, relayer)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond == nil || bond.Status == BOND_STATUS_WITHDRAWN {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no bond for relayer %s", relayer))
+	}
+
+	if verifier, ok := slashEvidenceVerifiers[reason]; ok {
+		if err := verifier(bs, stub, bond, evidence); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "slash evidence rejected"))
+		}
+	}
+
+	if err := bs.applyBondSlash(stub, bond, key, amount, reason, evidence); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to slash bond"))
+	}
+	return shim.Success(nil)
+}
+
+// 扣减保证金并记录罚没历史，证据由调用方负责校验
+func (bs *CrossChain) applyBondSlash(stub shim.ChaincodeStubInterface, bond *RelayerBond, key string,
+	amount uint64, reason string, evidence []byte) error {
+	config, err := bs.getBondConfig(stub)
+	if err != nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+
+	if amount > bond.Amount {
+		amount = bond.Amount
+	}
+	evidenceHash := sha256.Sum256(evidence)
+	bond.Amount -= amount
+	bond.Slashes = append(bond.Slashes, BondSlash{
+		Amount:       amount,
+		Reason:       reason,
+		EvidenceHash: hex.EncodeToString(evidenceHash[:]),
+		TxId:         stub.GetTxID(),
+		Timestamp:    now,
+	})
+	if bond.Amount < config.MinBond || bond.Amount == 0 {
+		bond.Status = BOND_STATUS_SLASHED
+	}
+	bond.UpdatedAt = now
+
+	return putRegistryJSON(stub, key, bond)
+}
+
+// 管理员释放中继者保证金
+// args[0] 中继者证书sha256(hex)
+func (bs *CrossChain) withdrawBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bond, key, err := bs.getRelayerBond(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond == nil || bond.Status == BOND_STATUS_WITHDRAWN {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no bond for relayer %s", args[0]))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	bond.Amount = 0
+	bond.PendingAmount = 0
+	bond.PendingReference = ""
+	bond.Status = BOND_STATUS_WITHDRAWN
+	bond.UpdatedAt = now
+	if err := putRegistryJSON(stub, key, bond); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put bond"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询中继者保证金
+// args[0] 中继者证书sha256(hex)
+func (bs *CrossChain) queryBond(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bond, _, err := bs.getRelayerBond(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond == nil {
+		return shim.Success(nil)
+	}
+	raw, _ := json.Marshal(bond)
+	return shim.Success(raw)
+}
+
+// 检查当前提交者的保证金是否有效，保证金模块未开启时直接通过
+func (bs *CrossChain) checkRelayerBond(stub shim.ChaincodeStubInterface) pb.Response {
+	config, err := bs.getBondConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get bond config"))
+	}
+	if !config.Enabled {
+		return shim.Success(nil)
+	}
+
+	_, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	bond, _, err := bs.getRelayerBond(stub, relayer)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relayer bond"))
+	}
+	if bond == nil || bond.Status != BOND_STATUS_ACTIVE {
+		return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "relayer %s has no active bond", relayer))
+	}
+	if bond.Amount < config.MinBond {
+		return errorResponse("", crosserr.New(crosserr.CodeUnauthorized, "relayer %s bond %d is below minimum %d", relayer, bond.Amount, config.MinBond))
+	}
+	return shim.Success(nil)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func testCertHash(cert string) string {
+	block, _ := pem.Decode([]byte(cert))
+	h := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(h[:])
+}
+
+func TestRelayerBond(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+
+	// 非管理员不能配置保证金模块
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setBondConfig", "true", "100"); res.Status == shim.OK {
+		t.Fatal("non-admin should not set bond config")
+	}
+
+	// 中继者登记保证金
+	res := InvokeWithStrings(t, stub, sp, "postBond", "150", "escrow-001")
+	if res.Status != shim.OK || string(res.Payload) != relayer {
+		t.Fatalf("postBond failed: %s", res.Message)
+	}
+
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setBondConfig", "true", "100"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 保证金未确认前，中继者不能提交消息
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	stub.MockTransactionStart(txid)
+	if res := NewCrossChain().checkRelayerBond(stub); res.Status == shim.OK {
+		t.Fatal("pending bond should not pass")
+	}
+	stub.MockTransactionEnd(txid)
+
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "confirmBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	checkBond := func() pb.Response {
+		stub.Creator = MockCreator(TEST_RELAYER_CERT)
+		defer func() { stub.Creator = MockCreator(TEST_ADMIN_CERT) }()
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		return NewCrossChain().checkRelayerBond(stub)
+	}
+	queryBond := func() RelayerBond {
+		var bond RelayerBond
+		res := InvokeWithStrings(t, stub, sp, "queryBond", relayer)
+		if err := json.Unmarshal(res.Payload, &bond); err != nil {
+			t.Fatal(err)
+		}
+		return bond
+	}
+	if res := checkBond(); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 追加保证金，确认前原有保证金保持有效
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "50", "escrow-002"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := checkBond(); res.Status != shim.OK {
+		t.Fatalf("top-up should not suspend active bond: %s", res.Message)
+	}
+	if bond := queryBond(); bond.Status != BOND_STATUS_ACTIVE || bond.Amount != 150 || bond.PendingAmount != 50 ||
+		bond.Reference != "escrow-001" || bond.PendingReference != "escrow-002" {
+		t.Fatalf("unexpected bond: %+v", bond)
+	}
+	// 拒绝追加部分，已确认的数额不变
+	if res := InvokeWithStrings(t, stub, sp, "rejectBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if bond := queryBond(); bond.Status != BOND_STATUS_ACTIVE || bond.Amount != 150 || bond.PendingAmount != 0 || bond.Reference != "escrow-001" {
+		t.Fatalf("unexpected bond: %+v", bond)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "rejectBond", relayer); res.Status == shim.OK {
+		t.Fatal("reject without pending bond should fail")
+	}
+
+	// 罚没后剩余保证金低于下限，状态变为SLASHED
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "slashBond", relayer, "60", SLASH_REASON_INVALID_PROOF, "abcd"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	if bond := queryBond(); bond.Amount != 90 || bond.Status != BOND_STATUS_SLASHED || len(bond.Slashes) != 1 {
+		t.Fatalf("unexpected bond: %+v", bond)
+	}
+
+	if res := checkBond(); res.Status == shim.OK {
+		t.Fatal("slashed bond should not pass")
+	}
+
+	// 补足保证金并确认后恢复有效
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "20", "escrow-003"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "confirmBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if bond := queryBond(); bond.Status != BOND_STATUS_ACTIVE || bond.Amount != 110 || bond.PendingAmount != 0 || bond.Reference != "escrow-003" {
+		t.Fatalf("unexpected bond: %+v", bond)
+	}
+	if res := checkBond(); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
+
+// 拒绝尚未确认过的保证金，视为已释放
+func TestRejectPendingBond(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "10", "escrow-001"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "rejectBond", relayer); res.Status == shim.OK {
+		t.Fatal("non-admin should not reject bond")
+	}
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "rejectBond", relayer); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var bond RelayerBond
+	res := InvokeWithStrings(t, stub, sp, "queryBond", relayer)
+	if err := json.Unmarshal(res.Payload, &bond); err != nil || bond.Status != BOND_STATUS_WITHDRAWN || bond.Amount != 0 || bond.PendingAmount != 0 {
+		t.Fatalf("unexpected bond: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "confirmBond", relayer); res.Status == shim.OK {
+		t.Fatal("rejected bond should not be confirmed")
+	}
+}
+
+func TestSlashEvidenceVerifier(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+
+	registerSlashEvidenceVerifier("TEST_REASON", func(bs *CrossChain, stub shim.ChaincodeStubInterface, bond *RelayerBond, evidence []byte) error {
+		if string(evidence) != "proof" {
+			return errors.New("bad evidence")
+		}
+		return nil
+	})
+	defer delete(slashEvidenceVerifiers, "TEST_REASON")
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "postBond", "10", ""); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "slashBond", relayer, "5", "TEST_REASON", hex.EncodeToString([]byte("fake"))); res.Status == shim.OK {
+		t.Fatal("slash with bad evidence should fail")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "slashBond", relayer, "5", "TEST_REASON", hex.EncodeToString([]byte("proof"))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
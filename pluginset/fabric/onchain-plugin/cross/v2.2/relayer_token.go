@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 中继者交易令牌
+// 在交易签名之外的第二个认证因素：中继者用单独登记的密钥对本笔交易签发短期令牌，通过transient map
+// 传入，令牌不会出现在写集和区块中。令牌绑定中继者证书和交易id，不能在其他交易中重放。
+//
+// 令牌签名内容为 sha256("crosschain_relayer_token\n" + 中继者证书sha256(hex) + "\n" + txId + "\n" + issuedAt + "\n" + expiresAt)，
+// 登记的公钥支持ECDSA(签名为ASN.1编码)和Ed25519。
+const (
+	K_RELAYER_TOKEN_CONFIG = CROSSCHAIN_PREFIX + "relayer_token_config"
+	// crosschain_relayer_token_key_${relayer} -> RelayerTokenKey
+	K_RELAYER_TOKEN_KEY_PREFIX = CROSSCHAIN_PREFIX + "relayer_token_key_"
+
+	TRANSIENT_RELAYER_TOKEN = "relayer_token"
+
+	// 允许令牌签发时间超前交易时间的秒数
+	RELAYER_TOKEN_CLOCK_SKEW = 30
+)
+
+type RelayerTokenConfig struct {
+	Enabled bool `json:"enabled"`
+	// 令牌最长有效期(秒)
+	MaxLifetime int64 `json:"maxLifetime"`
+}
+
+type RelayerTokenKey struct {
+	Relayer   string `json:"relayer"`
+	PublicKey string `json:"publicKey"` // PEM
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type RelayerToken struct {
+	TxId      string `json:"txId"`
+	IssuedAt  int64  `json:"issuedAt"`
+	ExpiresAt int64  `json:"expiresAt"`
+	Signature []byte `json:"signature"`
+}
+
+func relayerTokenDigest(relayer string, token *RelayerToken) []byte {
+	content := "crosschain_relayer_token\n" + relayer + "\n" + token.TxId + "\n" +
+		strconv.FormatInt(token.IssuedAt, 10) + "\n" + strconv.FormatInt(token.ExpiresAt, 10)
+	h := sha256.Sum256([]byte(content))
+	return h[:]
+}
+
+// 校验transient map中的中继者令牌，未开启时直接通过
+func (bs *CrossChain) checkRelayerToken(stub shim.ChaincodeStubInterface) error {
+	var config RelayerTokenConfig
+	if has, err := getJSONState(stub, K_RELAYER_TOKEN_CONFIG, &config); err != nil || !has || !config.Enabled {
+		return err
+	}
+	_, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return err
+	}
+	var registered RelayerTokenKey
+	if has, err := getJSONState(stub, K_RELAYER_TOKEN_KEY_PREFIX+relayer, &registered); err != nil {
+		return err
+	} else if !has {
+		return fmt.Errorf("relayer %s has no token key", relayer)
+	}
+
+	trans, err := stub.GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to get transient: %v", err)
+	}
+	raw, ok := trans[TRANSIENT_RELAYER_TOKEN]
+	if !ok {
+		return fmt.Errorf("missing relayer token")
+	}
+	var token RelayerToken
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return fmt.Errorf("relayer token format error: %v", err)
+	}
+	if token.TxId != stub.GetTxID() {
+		return fmt.Errorf("relayer token is issued for another transaction")
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	if token.IssuedAt > now+RELAYER_TOKEN_CLOCK_SKEW || token.ExpiresAt <= now {
+		return fmt.Errorf("relayer token is not valid at %d", now)
+	}
+	if token.ExpiresAt-token.IssuedAt > config.MaxLifetime {
+		return fmt.Errorf("relayer token lifetime exceeds %d seconds", config.MaxLifetime)
+	}
+	key, err := parsePublicKeyPEM(registered.PublicKey)
+	if err != nil {
+		return err
+	}
+	if !verifySignature(key, relayerTokenDigest(relayer, &token), token.Signature) {
+		return fmt.Errorf("invalid relayer token signature")
+	}
+	return nil
+}
+
+// 中继者提交消息前的检查：白名单、保证金和交易令牌
+func (bs *CrossChain) checkRelayer(stub shim.ChaincodeStubInterface) pb.Response {
+	if err := bs.checkRelayerACL(stub); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "check relayer acl failed"))
+	}
+	if ret := bs.checkRelayerBond(stub); ret.Status != shim.OK {
+		return ret
+	}
+	if err := bs.checkRelayerToken(stub); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "check relayer token failed"))
+	}
+	return shim.Success(nil)
+}
+
+// 设置中继者令牌开关
+// args[0] true/false
+// args[1] 令牌最长有效期(秒)
+func (bs *CrossChain) setRelayerTokenConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	enabled, err := strconv.ParseBool(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "enabled(%s) format error", args[0]))
+	}
+	maxLifetime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || maxLifetime <= 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max lifetime: %s", args[1]))
+	}
+	if err := putJSONState(stub, K_RELAYER_TOKEN_CONFIG, &RelayerTokenConfig{Enabled: enabled, MaxLifetime: maxLifetime}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 登记中继者签发令牌的公钥
+// args[0] 中继者证书sha256(hex)
+// args[1] 公钥(PEM)
+func (bs *CrossChain) setRelayerTokenKey(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if id, err := hex.DecodeString(args[0]); err != nil || len(id) != sha256.Size {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "relayer(%s) format error", args[0]))
+	}
+	if _, err := parsePublicKeyPEM(args[1]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "public key format error"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	key := &RelayerTokenKey{Relayer: args[0], PublicKey: args[1], UpdatedAt: now}
+	if err := putJSONState(stub, K_RELAYER_TOKEN_KEY_PREFIX+args[0], key); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put token key"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询中继者登记的令牌公钥
+// args[0] 中继者证书sha256(hex)
+func (bs *CrossChain) queryRelayerTokenKey(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	bz, err := stub.GetState(K_RELAYER_TOKEN_KEY_PREFIX + args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(bz) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "relayer %s has no token key", args[0]))
+	}
+	return shim.Success(bz)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"testing"
+)
+
+// MockStub的GetTransient总是返回nil，测试中用固定的transient map代替
+type transientStub struct {
+	*shimtest.MockStub
+	transient map[string][]byte
+}
+
+func (s *transientStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func TestRelayerToken(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	relayer := testCertHash(TEST_RELAYER_CERT)
+
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerTokenKey", relayer, "not a key"); res.Status == shim.OK {
+		t.Fatal("invalid public key should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerTokenKey", relayer, pubPEM); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRelayerTokenConfig", "true", "60"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	sign := func(token RelayerToken) []byte {
+		token.Signature, _ = ecdsa.SignASN1(rand.Reader, priv, relayerTokenDigest(relayer, &token))
+		bz, _ := json.Marshal(token)
+		return bz
+	}
+	check := func(tx string, now int64, token []byte) error {
+		stub.Creator = MockCreator(TEST_RELAYER_CERT)
+		stub.MockTransactionStart(tx)
+		defer stub.MockTransactionEnd(tx)
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: now}
+		ts := &transientStub{MockStub: stub, transient: map[string][]byte{}}
+		if token != nil {
+			ts.transient[TRANSIENT_RELAYER_TOKEN] = token
+		}
+		return NewCrossChain().checkRelayerToken(ts)
+	}
+
+	valid := RelayerToken{TxId: "tx1", IssuedAt: 1000, ExpiresAt: 1030}
+	if err := check("tx1", 1010, sign(valid)); err != nil {
+		t.Fatal(err)
+	}
+	if err := check("tx1", 1010, nil); err == nil {
+		t.Fatal("missing token should be rejected")
+	}
+	if err := check("tx2", 1010, sign(valid)); err == nil {
+		t.Fatal("token of another transaction should be rejected")
+	}
+	if err := check("tx1", 1030, sign(valid)); err == nil {
+		t.Fatal("expired token should be rejected")
+	}
+	if err := check("tx3", 1010, sign(RelayerToken{TxId: "tx3", IssuedAt: 1000, ExpiresAt: 1100})); err == nil {
+		t.Fatal("token lifetime over limit should be rejected")
+	}
+	tampered := valid
+	tampered.Signature, _ = ecdsa.SignASN1(rand.Reader, priv, relayerTokenDigest(relayer, &valid))
+	tampered.ExpiresAt = 1040
+	bz, _ := json.Marshal(tampered)
+	if err := check("tx1", 1010, bz); err == nil {
+		t.Fatal("tampered token should be rejected")
+	}
+
+	// 未登记公钥的中继者
+	stub.Creator = MockCreator(TEST_ADMIN_CERT)
+	stub.MockTransactionStart("tx4")
+	if err := NewCrossChain().checkRelayerToken(&transientStub{MockStub: stub}); err == nil {
+		t.Fatal("relayer without token key should be rejected")
+	}
+	stub.MockTransactionEnd("tx4")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+)
+
+// 可靠中继队列
+// 开启后，发送的每条消息都在队列中登记，直到中继确认目的链已经收到(ack)才删除：
+//   - 中继从queryRelayQueue取待转发的消息，转发前调用confirmRelay认领
+//   - 目的链上链后调用ackRelay，消息出队
+//   - 认领后超过超时时间仍未ack的消息(如中继崩溃)重新出现在queryRelayQueue中，可以被任意中继重试
+//
+// 队列按目的域名和序号排序，与发送积压索引的顺序一致；重复转发由目的链的防重放检查拒绝。
+const (
+	K_RELAY_CONFIG = CROSSCHAIN_PREFIX + "relay_config"
+	// 复合key: crosschain_relay ${destDomain} ${seq} ${msgKey}
+	K_RELAY_OBJECT_TYPE = CROSSCHAIN_PREFIX + "relay"
+)
+
+type RelayConfig struct {
+	Enabled bool `json:"enabled"`
+	// 认领后多久未ack重新出队(秒)
+	Timeout int64 `json:"timeout"`
+}
+
+type RelayEntry struct {
+	CreatedAt int64 `json:"createdAt"`
+	Attempts  int64 `json:"attempts"`
+	// 最近一次认领的中继和时间
+	Relayer     string `json:"relayer,omitempty"`
+	ConfirmedAt int64  `json:"confirmedAt,omitempty"`
+}
+
+type RelayQueueMessage struct {
+	Seq   uint32                      `json:"seq"`
+	Entry RelayEntry                  `json:"entry"`
+	Event oraclelogic.OutboundMessage `json:"event"`
+}
+
+type RelayQueuePage struct {
+	Messages []RelayQueueMessage `json:"messages"`
+	Bookmark string              `json:"bookmark"`
+}
+
+func (bs *CrossChain) getRelayConfig(stub shim.ChaincodeStubInterface) (*RelayConfig, error) {
+	var config RelayConfig
+	if _, err := getJSONState(stub, K_RELAY_CONFIG, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// 消息在队列中的key
+func relayEntryKey(stub shim.ChaincodeStubInterface, msgKey string, ammsg []byte) (string, error) {
+	destDomain, seq, err := oraclelogic.ParseOutboundPackage(ammsg)
+	if err != nil {
+		return "", err
+	}
+	return stub.CreateCompositeKey(K_RELAY_OBJECT_TYPE, []string{destDomain, fmt.Sprintf("%010d", seq), msgKey})
+}
+
+// 发送的消息入队，未开启时不入队
+func (bs *CrossChain) enqueueRelay(stub shim.ChaincodeStubInterface, payload []byte) error {
+	config, err := bs.getRelayConfig(stub)
+	if err != nil || !config.Enabled {
+		return err
+	}
+	var msg oraclelogic.OutboundMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("failed to parse outbound message: %v", err)
+	}
+	key, err := relayEntryKey(stub, msg.Key, msg.Package)
+	if err != nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return putJSONState(stub, key, &RelayEntry{CreatedAt: now})
+}
+
+// 读取消息在队列中的记录，不在队列中时entry为nil
+func (bs *CrossChain) getRelayEntry(stub shim.ChaincodeStubInterface, msgKey string) (*RelayEntry, string, error) {
+	ammsg, err := stub.GetState(msgKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(ammsg) == 0 {
+		return nil, "", crosserr.New(crosserr.CodeNotFound, "message %s not found", msgKey)
+	}
+	key, err := relayEntryKey(stub, msgKey, ammsg)
+	if err != nil {
+		return nil, "", err
+	}
+	var entry RelayEntry
+	has, err := getJSONState(stub, key, &entry)
+	if err != nil || !has {
+		return nil, key, err
+	}
+	return &entry, key, nil
+}
+
+// 设置可靠中继队列
+// args[0] true/false
+// args[1] 超时时间(秒)
+func (bs *CrossChain) setRelayConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	enabled, err := strconv.ParseBool(args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "enabled(%s) format error", args[0]))
+	}
+	timeout, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || timeout <= 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid timeout: %s", args[1]))
+	}
+	if err := putJSONState(stub, K_RELAY_CONFIG, &RelayConfig{Enabled: enabled, Timeout: timeout}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 按页查询发往目的域名的待转发消息：未认领或认领已超时
+// 每页扫描pageSize条队列记录，只返回其中待转发的，返回条数可能少于pageSize
+// args[0] 目的域名
+// args[1] 每页条数(可选)
+// args[2] 书签(可选)
+func (bs *CrossChain) queryRelayQueue(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	pageSize, bookmark, err := parsePageArgs(args[1:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+	config, err := bs.getRelayConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relay config"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	page := RelayQueuePage{Messages: []RelayQueueMessage{}}
+	page.Bookmark, err = scanCompositeKeyPage(stub, K_RELAY_OBJECT_TYPE, []string{args[0]}, pageSize, bookmark, func(key string, value []byte) error {
+		var entry RelayEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return err
+		}
+		if entry.Attempts > 0 && now < entry.ConfirmedAt+config.Timeout {
+			return nil
+		}
+		_, attrs, err := stub.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		seq, err := strconv.ParseUint(attrs[1], 10, 32)
+		if err != nil {
+			return err
+		}
+		ammsg, err := stub.GetState(attrs[2])
+		if err != nil {
+			return err
+		}
+		page.Messages = append(page.Messages, RelayQueueMessage{
+			Seq:   uint32(seq),
+			Entry: entry,
+			Event: oraclelogic.OutboundMessage{Key: attrs[2], Package: ammsg, Commitment: oraclelogic.MessageCommitment(attrs[2], ammsg)},
+		})
+		return nil
+	})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
+
+// 中继认领待转发的消息，认领未超时的消息不能被再次认领
+// args[0..] 消息key
+func (bs *CrossChain) confirmRelay(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	config, err := bs.getRelayConfig(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relay config"))
+	}
+	_, relayer, err := getCreatorIdentity(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeUnauthorized, err, "failed to get creator identity"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	for _, msgKey := range args {
+		entry, key, err := bs.getRelayEntry(stub, msgKey)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relay entry"))
+		}
+		if entry == nil {
+			return errorResponse("", crosserr.New(crosserr.CodeNotFound, "message %s is not in relay queue", msgKey))
+		}
+		if entry.Attempts > 0 && now < entry.ConfirmedAt+config.Timeout {
+			return errorResponse("", crosserr.New(crosserr.CodeConflict, "message %s is claimed by %s until %d", msgKey, entry.Relayer, entry.ConfirmedAt+config.Timeout))
+		}
+		// 过期的消息等待取消，不再转发
+		if err := bs.checkNotExpired(stub, msgKey, now); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeConflict, err, "message expired"))
+		}
+		entry.Attempts++
+		entry.Relayer, entry.ConfirmedAt = relayer, now
+		if err := putJSONState(stub, key, entry); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put relay entry"))
+		}
+	}
+	return shim.Success(nil)
+}
+
+// 中继确认消息已经在目的链上链，消息出队
+// args[0..] 消息key
+func (bs *CrossChain) ackRelay(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	for _, msgKey := range args {
+		entry, key, err := bs.getRelayEntry(stub, msgKey)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get relay entry"))
+		}
+		if entry == nil {
+			return errorResponse("", crosserr.New(crosserr.CodeNotFound, "message %s is not in relay queue", msgKey))
+		}
+		if err := stub.DelState(key); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		if err := bs.settleExpiry(stub, msgKey); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to settle expiry"))
+		}
+		if err := bs.updateMessageRecord(stub, outboundRecordKey(msgKey), MESSAGE_STATUS_DELIVERED); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to update message record"))
+		}
+	}
+	return shim.Success(nil)
+}
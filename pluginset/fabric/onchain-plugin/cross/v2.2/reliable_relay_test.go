@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"testing"
+)
+
+func TestReliableRelay(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	receiver := hex.EncodeToString(make([]byte, 32))
+	send := func(nounce string) {
+		if res := InvokeWithStrings(t, stub, sp, "sendMessage", "dest.com", receiver, "ordered", nounce); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	// 开启前发送的消息不入队
+	send("n0")
+	if res := InvokeWithStrings(t, stub, sp, "setRelayConfig", "true", "60"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	send("n1")
+	send("n2")
+
+	bs := NewCrossChain()
+	call := func(now int64, fn func(stub shim.ChaincodeStubInterface, args []string) pb.Response, args ...string) pb.Response {
+		return CallWithTimestamp(stub, now, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return fn(stub, args)
+		})
+	}
+	queue := func(now int64) []RelayQueueMessage {
+		var page RelayQueuePage
+		res := CallPagedWithTimestamp(stub, now, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return bs.queryRelayQueue(stub, []string{"dest.com", "10"})
+		})
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			t.Fatal(res.Message)
+		}
+		return page.Messages
+	}
+
+	msgs := queue(1000)
+	if len(msgs) != 2 || msgs[0].Seq != 1 || msgs[1].Seq != 2 {
+		t.Fatalf("unexpected queue: %+v", msgs)
+	}
+	first, second := msgs[0].Event.Key, msgs[1].Event.Key
+
+	if res := call(1000, bs.confirmRelay, first, second); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if len(queue(1030)) != 0 {
+		t.Fatal("claimed messages should be hidden before timeout")
+	}
+	if res := call(1030, bs.confirmRelay, first); res.Status == shim.OK {
+		t.Fatal("claimed message should not be claimed again before timeout")
+	}
+	if res := call(1030, bs.ackRelay, first); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 第二条消息的中继没有ack，超时后重新出队
+	msgs = queue(1060)
+	if len(msgs) != 1 || msgs[0].Event.Key != second || msgs[0].Entry.Attempts != 1 {
+		t.Fatalf("unacknowledged message should be exposed for retry: %+v", msgs)
+	}
+	if res := call(1060, bs.confirmRelay, second); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := call(1070, bs.ackRelay, second); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := call(1070, bs.ackRelay, second); res.Status == shim.OK {
+		t.Fatal("acknowledged message should leave the queue")
+	}
+	if len(queue(2000)) != 0 {
+		t.Fatal("queue should be empty")
+	}
+}
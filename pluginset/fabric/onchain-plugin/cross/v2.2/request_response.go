@@ -0,0 +1,233 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 跨链请求-响应
+// 业务链码通过sendMessageWithResponse发送SDP v2原子请求(无序)，跨链链码记录一条待响应的请求。
+// 接收链投递请求后回复回执(见ack.go)，接收方业务链码成功返回的payload作为响应内容放在回执中。
+// 回执回到本链时不再回调业务链码的recvMessage，而是核对请求记录(目标域名、目标身份、请求方)，
+// 把请求置为SUCCESS或ERROR并保存响应；请求方发送时指定了回调函数的，同一交易内回调:
+//
+//	callback(messageId string, status string, response string, errorMsg string)
+//
+// 回调返回错误时整笔交易失败，由中继重新提交回执。未指定回调的请求方通过queryPendingRequest查询结果。
+const (
+	// crosschain_request_${messageId} -> PendingRequest
+	K_PENDING_REQUEST_PREFIX = CROSSCHAIN_PREFIX + "request_"
+
+	REQUEST_STATUS_PENDING = "PENDING"
+	REQUEST_STATUS_SUCCESS = "SUCCESS"
+	REQUEST_STATUS_ERROR   = "ERROR"
+)
+
+type PendingRequest struct {
+	// SDP v2消息id(hex)
+	MessageId string `json:"messageId"`
+	// 发送请求的业务链码
+	Requester string `json:"requester"`
+	// sha256(请求方链码名)(hex)，即请求报文的发送方身份
+	Sender     string `json:"sender"`
+	DestDomain string `json:"destDomain"`
+	Receiver   string `json:"receiver"`
+	Nonce      uint64 `json:"nonce"`
+	// 收到回执后回调请求方的函数，为空时不回调
+	Callback    string `json:"callback,omitempty"`
+	OutboundKey string `json:"outboundKey"`
+	Status      string `json:"status"`
+	Response    string `json:"response,omitempty"`
+	ErrorMsg    string `json:"errorMsg,omitempty"`
+	RequestTxId string `json:"requestTxId"`
+	RequestedAt int64  `json:"requestedAt"`
+	ResolveTxId string `json:"resolveTxId,omitempty"`
+	ResolvedAt  int64  `json:"resolvedAt,omitempty"`
+}
+
+// 回执报文
+func isAckMessage(msg oraclelogic.RecvAuthMessage) bool {
+	return msg.SDPVersion >= oraclelogic.SDP_VERSION_2 && msg.AtomicFlag >= oraclelogic.SDP_ATOMIC_ACK_SUCCESS
+}
+
+// 发送原子请求
+// args[0] 目的地的域名
+// args[1] 目的地账号，byte32 hexstring
+// args[2] 消息内容
+// args[3] 消息nounce(可选)，区分同一笔交易内发送多个请求
+// args[4] 回调函数(可选)，收到回执后回调请求方
+// 返回值为发送积压中的消息记录(OutboundMessage)和请求记录
+func (bs *CrossChain) sendMessageWithResponse(stub shim.ChaincodeStubInterface, args []string) ([]byte, *PendingRequest, error) {
+	if len(args) < 3 || len(args) > 5 {
+		return nil, nil, fmt.Errorf("Wrong length of args: %v", len(args))
+	}
+	destDomain, msg := args[0], []byte(args[2])
+	receiver, err := hex.DecodeString(args[1])
+	if err != nil || len(receiver) != 32 {
+		return nil, nil, fmt.Errorf("receiver(%s) format error", args[1])
+	}
+	if len(msg) > oraclelogic.K_SEND_MESSAGE_LENGTH_LIMIT {
+		return nil, nil, fmt.Errorf("message exceed length limit (%d)", len(msg))
+	}
+	var msgnounce, callback string
+	if len(args) > 3 {
+		msgnounce = args[3]
+	}
+	if len(args) > 4 {
+		callback = args[4]
+	}
+
+	requester, err := getProposalChaincode(stub)
+	if err != nil {
+		return nil, nil, err
+	}
+	author := sha256.Sum256([]byte(requester))
+	// 消息id由交易、nounce和请求方决定，nonce取消息id的前8个字节
+	messageId := sha256.Sum256([]byte(stub.GetTxID() + "_" + msgnounce + "_" + requester))
+	sdp := &oraclelogic.SDPMessage{
+		Version:        oraclelogic.SDP_VERSION_2,
+		TargetDomain:   destDomain,
+		TargetIdentity: oraclelogic.CopySliceToByte32(receiver),
+		Sequence:       oraclelogic.K_UNORDERED_MSG_SEQ,
+		Payload:        msg,
+		MessageId:      messageId,
+		AtomicFlag:     oraclelogic.SDP_ATOMIC_REQUEST,
+		Nonce:          binary.BigEndian.Uint64(messageId[:8]),
+	}
+	id := hex.EncodeToString(messageId[:])
+	if has, err := getJSONState(stub, K_PENDING_REQUEST_PREFIX+id, &PendingRequest{}); err != nil {
+		return nil, nil, err
+	} else if has {
+		return nil, nil, fmt.Errorf("request %s already exists", id)
+	}
+
+	res := bs.Os.SendSDPMessage(stub, author, sdp, "request_"+msgnounce)
+	if res.Status != shim.OK {
+		return nil, nil, fmt.Errorf("failed to send request: %s", res.Message)
+	}
+	if _, _, err := bs.chargeFee(stub, destDomain); err != nil {
+		return nil, nil, fmt.Errorf("failed to charge fee: %v", err)
+	}
+	if err := bs.indexOutboundMessage(stub, res.Payload); err != nil {
+		return nil, nil, err
+	}
+	if err := bs.enqueueRelay(stub, res.Payload); err != nil {
+		return nil, nil, err
+	}
+	var outbound oraclelogic.OutboundMessage
+	_ = json.Unmarshal(res.Payload, &outbound)
+
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return nil, nil, err
+	}
+	request := &PendingRequest{
+		MessageId:   id,
+		Requester:   requester,
+		Sender:      hex.EncodeToString(author[:]),
+		DestDomain:  destDomain,
+		Receiver:    hex.EncodeToString(receiver),
+		Nonce:       sdp.Nonce,
+		Callback:    callback,
+		OutboundKey: outbound.Key,
+		Status:      REQUEST_STATUS_PENDING,
+		RequestTxId: stub.GetTxID(),
+		RequestedAt: now,
+	}
+	if err := putJSONState(stub, K_PENDING_REQUEST_PREFIX+id, request); err != nil {
+		return nil, nil, err
+	}
+	return res.Payload, request, nil
+}
+
+// 收到回执，更新请求记录并回调请求方
+func (bs *CrossChain) resolveRequest(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage, tracer *debugTracer) pb.Response {
+	var request PendingRequest
+	if has, err := getJSONState(stub, K_PENDING_REQUEST_PREFIX+msg.MessageId, &request); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		tracer.step(TRACE_STEP_VERIFY, false, "request %s not found", msg.MessageId)
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "ack for unknown request %s", msg.MessageId))
+	}
+	if request.Status != REQUEST_STATUS_PENDING {
+		tracer.step(TRACE_STEP_VERIFY, false, "request %s is already %s", msg.MessageId, request.Status)
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "request %s is already resolved", msg.MessageId))
+	}
+	// 回执须由请求的接收方从目标域名回复给请求方
+	if msg.From != request.DestDomain || hex.EncodeToString(msg.Identity[:]) != request.Receiver ||
+		hex.EncodeToString(msg.Receiver[:]) != request.Sender {
+		tracer.step(TRACE_STEP_ACL, false, "ack does not match request %s", msg.MessageId)
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "ack does not match request %s", msg.MessageId))
+	}
+
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	request.Status = REQUEST_STATUS_SUCCESS
+	if msg.AtomicFlag != oraclelogic.SDP_ATOMIC_ACK_SUCCESS {
+		request.Status, request.ErrorMsg = REQUEST_STATUS_ERROR, msg.ErrorMsg
+	}
+	request.Response = string(msg.Content)
+	// 超出执行限制的回执记为失败，不保存内容，也不回调请求方
+	callback := request.Callback
+	if err := bs.checkExecLimit(stub, msg.Content, callback != ""); err != nil {
+		if crosserr.CodeOf(err) != crosserr.CodeExecLimit {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to check exec limit"))
+		}
+		tracer.step(TRACE_STEP_DELIVERY, false, "%v", err)
+		request.Status, request.ErrorMsg, request.Response = REQUEST_STATUS_ERROR, err.Error(), ""
+		callback = ""
+	}
+	request.ResolveTxId = stub.GetTxID()
+	request.ResolvedAt = now
+	if err := putJSONState(stub, K_PENDING_REQUEST_PREFIX+msg.MessageId, &request); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put request"))
+	}
+
+	if callback == "" {
+		tracer.step(TRACE_STEP_DELIVERY, true, "request %s resolved as %s", msg.MessageId, request.Status)
+		return shim.Success(nil)
+	}
+	channel, err := bs.receiverChannel(stub, request.Requester)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get receiver channel"))
+	}
+	re := stub.InvokeChaincode(request.Requester, [][]byte{
+		[]byte(request.Callback),
+		[]byte(request.MessageId),
+		[]byte(request.Status),
+		[]byte(request.Response),
+		[]byte(request.ErrorMsg),
+	}, channel)
+	if re.Status != shim.OK {
+		tracer.step(TRACE_STEP_DELIVERY, false, "call %s.%s: %s", request.Requester, request.Callback, re.Message)
+		return errorResponse("", crosserr.New(crosserr.CodeCallback, "response callback %s.%s failed: %s", request.Requester, request.Callback, re.Message))
+	}
+	tracer.step(TRACE_STEP_DELIVERY, true, "request %s resolved as %s, call %s.%s: %s",
+		msg.MessageId, request.Status, request.Requester, request.Callback, re.Message)
+	return shim.Success(nil)
+}
+
+// 查询请求记录
+// args[0] SDP v2消息id(hex)
+func (bs *CrossChain) queryPendingRequest(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var request PendingRequest
+	if has, err := getJSONState(stub, K_PENDING_REQUEST_PREFIX+args[0], &request); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "request %s not found", args[0]))
+	}
+	raw, _ := json.Marshal(&request)
+	return shim.Success(raw)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+)
+
+// 记录调用的业务链码，接收消息时返回响应
+type responderChaincode struct {
+	calls [][]string
+}
+
+func (cc *responderChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (cc *responderChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	cc.calls = append(cc.calls, append([]string{fn}, args...))
+	return shim.Success([]byte("pong"))
+}
+
+func TestSendMessageWithResponse(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	responder := &responderChaincode{}
+	stub.MockPeerChaincode("readercc", shimtest.NewMockStub("readercc", responder), "")
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "registerSha256Invert", "readercc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var readersp pb.SignedProposal
+	MockSignedProposal("readercc", &readersp)
+	remote := sha256.Sum256([]byte("remote"))
+
+	send := func(nonce, callback string) PendingRequest {
+		res := InvokeWithStrings(t, stub, &readersp, "sendMessageWithResponse", "src.com", hex.EncodeToString(remote[:]), "ping", nonce, callback)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		<-stub.ChaincodeEventsChannel
+		var request PendingRequest
+		if err := json.Unmarshal(res.Payload, &request); err != nil {
+			t.Fatal(err)
+		}
+		return request
+	}
+	recv := func(sender [32]byte, msg *oraclelogic.SDPMessage) pb.Response {
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sender, sdp)
+		return InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am)))
+	}
+	ack := func(request PendingRequest, flag uint8, payload, errorMsg string) *oraclelogic.SDPMessage {
+		id, _ := hex.DecodeString(request.MessageId)
+		return &oraclelogic.SDPMessage{
+			Version:        oraclelogic.SDP_VERSION_2,
+			TargetDomain:   "fabric.test",
+			TargetIdentity: sha256.Sum256([]byte("readercc")),
+			Sequence:       oraclelogic.K_UNORDERED_MSG_SEQ,
+			Payload:        []byte(payload),
+			MessageId:      oraclelogic.CopySliceToByte32(id),
+			AtomicFlag:     flag,
+			Nonce:          request.Nonce,
+			ErrorMsg:       errorMsg,
+		}
+	}
+	query := func(id string) PendingRequest {
+		var request PendingRequest
+		res := InvokeWithStrings(t, stub, sp, "queryPendingRequest", id)
+		if err := json.Unmarshal(res.Payload, &request); err != nil {
+			t.Fatal(res.Message)
+		}
+		return request
+	}
+
+	// 请求为无序的SDP v2原子请求，发送方为请求方链码
+	request := send("r1", "onResponse")
+	author, p2p, _ := oraclelogic.TestRecvAuthMessage(stub.State[request.OutboundKey])
+	sdp, err := oraclelogic.DecodeSDPMessage(p2p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := sha256.Sum256([]byte("readercc"))
+	if string(author) != string(reader[:]) || sdp.AtomicFlag != oraclelogic.SDP_ATOMIC_REQUEST || sdp.Sequence != oraclelogic.K_UNORDERED_MSG_SEQ ||
+		sdp.MessageIdHex() != request.MessageId || sdp.Nonce != request.Nonce || string(sdp.Payload) != "ping" {
+		t.Fatalf("unexpected request message: %+v", sdp)
+	}
+	if request.Status != REQUEST_STATUS_PENDING || request.Requester != "readercc" {
+		t.Fatalf("unexpected request: %+v", request)
+	}
+
+	// 回执回到请求方，回调并保存响应
+	if res := recv(remote, ack(request, oraclelogic.SDP_ATOMIC_ACK_SUCCESS, "100", "")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if len(responder.calls) != 1 || responder.calls[0][0] != "onResponse" || responder.calls[0][1] != request.MessageId ||
+		responder.calls[0][2] != REQUEST_STATUS_SUCCESS || responder.calls[0][3] != "100" {
+		t.Fatalf("unexpected callback: %v", responder.calls)
+	}
+	if resolved := query(request.MessageId); resolved.Status != REQUEST_STATUS_SUCCESS || resolved.Response != "100" {
+		t.Fatalf("unexpected request: %+v", resolved)
+	}
+	if res := recv(remote, ack(request, oraclelogic.SDP_ATOMIC_ACK_SUCCESS, "101", "")); res.Status == shim.OK {
+		t.Fatal("resolved request should not be acked again")
+	}
+
+	// 未指定回调时只更新请求记录；回执须来自请求的接收方
+	request = send("r2", "")
+	other := sha256.Sum256([]byte("other"))
+	if res := recv(other, ack(request, oraclelogic.SDP_ATOMIC_ACK_SUCCESS, "100", "")); res.Status == shim.OK {
+		t.Fatal("ack from other sender should be rejected")
+	}
+	if res := recv(remote, ack(request, oraclelogic.SDP_ATOMIC_ACK_ERROR, "ping", "no such account")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if resolved := query(request.MessageId); resolved.Status != REQUEST_STATUS_ERROR || resolved.ErrorMsg != "no such account" || len(responder.calls) != 1 {
+		t.Fatalf("unexpected request: %+v", resolved)
+	}
+	request.MessageId = hex.EncodeToString(make([]byte, 32))
+	if res := recv(remote, ack(request, oraclelogic.SDP_ATOMIC_ACK_SUCCESS, "", "")); res.Status == shim.OK {
+		t.Fatal("ack for unknown request should be rejected")
+	}
+
+	// 接收方返回的payload作为响应放在回执中
+	msg := testSDPMessageV2("ping", oraclelogic.K_UNORDERED_MSG_SEQ)
+	msg.TargetIdentity = reader
+	if res := recv(remote, msg); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var status AckStatus
+	if res := InvokeWithStrings(t, stub, sp, "queryAckStatus", msg.MessageIdHex()); json.Unmarshal(res.Payload, &status) != nil {
+		t.Fatal(res.Message)
+	}
+	_, p2p, _ = oraclelogic.TestRecvAuthMessage(stub.State[status.AckKey])
+	if sdp, err := oraclelogic.DecodeSDPMessage(p2p); err != nil || string(sdp.Payload) != "pong" {
+		t.Fatalf("unexpected ack: %+v %v", sdp, err)
+	}
+}
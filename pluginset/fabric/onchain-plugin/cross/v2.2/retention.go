@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+// 消息记录保留策略
+// 收消息时链码会留下消息记录：争议窗口暂存的消息、乐观模式的声明、隐私路由集合中的消息。
+// 按来源域名配置保留时长和保留数量后，新记录按创建顺序登记到队列 [TailSeq, HeadSeq) 中，
+// 之后每笔收消息交易顺带删除最多RETENTION_MAX_PRUNE条过期记录，不需要单独的维护交易。
+//
+// 尚未投递(未结束争议窗口或挑战窗口)的记录不会删除，并阻塞其后的记录，直到它投递完成。
+// 防重放的消费标记和擦除回执不属于消息记录，不会被删除。
+const (
+	// crosschain_retention_${domain} -> RetentionPolicy
+	K_RETENTION_PREFIX = CROSSCHAIN_PREFIX + "retention_"
+	// crosschain_retention_entry_${domain}_${seq} -> RetentionEntry
+	K_RETENTION_ENTRY_PREFIX = CROSSCHAIN_PREFIX + "retention_entry_"
+
+	RETENTION_MAX_PRUNE = 16
+)
+
+type RetentionPolicy struct {
+	// 记录保留时长(秒)，0表示不按时长删除
+	MaxAge int64 `json:"maxAge"`
+	// 最多保留多少条记录，0表示不按数量删除
+	MaxCount int64 `json:"maxCount"`
+	HeadSeq  int64 `json:"headSeq"`
+	TailSeq  int64 `json:"tailSeq"`
+}
+
+type RetentionEntry struct {
+	Kind       string `json:"kind"` // 与擦除类型相同: dispute/optimistic/private
+	Key        string `json:"key"`
+	Collection string `json:"collection,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+func retentionEntryKey(domain string, seq int64) string {
+	return K_RETENTION_ENTRY_PREFIX + domain + "_" + strconv.FormatInt(seq, 10)
+}
+
+// 未配置保留策略时返回nil
+func (bs *CrossChain) getRetentionPolicy(stub shim.ChaincodeStubInterface, domain string) (*RetentionPolicy, error) {
+	var policy RetentionPolicy
+	has, err := getJSONState(stub, K_RETENTION_PREFIX+domain, &policy)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// 设置来源域名的保留策略
+// args[0] 来源域名
+// args[1] 保留时长(秒)，0表示不按时长删除
+// args[2] 保留数量，0表示不按数量删除
+func (bs *CrossChain) setRetentionPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	maxAge, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || maxAge < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max age: %s", args[1]))
+	}
+	maxCount, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || maxCount < 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid max count: %s", args[2]))
+	}
+	policy, err := bs.getRetentionPolicy(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get retention policy"))
+	}
+	if policy == nil {
+		policy = &RetentionPolicy{}
+	}
+	policy.MaxAge, policy.MaxCount = maxAge, maxCount
+	if err := putJSONState(stub, K_RETENTION_PREFIX+args[0], policy); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put policy"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询来源域名的保留策略
+// args[0] 来源域名
+func (bs *CrossChain) queryRetentionPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	policy, err := bs.getRetentionPolicy(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get retention policy"))
+	}
+	if policy == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no retention policy for domain %s", args[0]))
+	}
+	bz, _ := json.Marshal(policy)
+	return shim.Success(bz)
+}
+
+// 登记新创建的消息记录，未配置保留策略时不登记
+func (bs *CrossChain) trackRetention(stub shim.ChaincodeStubInterface, domain string, entry *RetentionEntry) error {
+	policy, err := bs.getRetentionPolicy(stub, domain)
+	if err != nil || policy == nil {
+		return err
+	}
+	if entry.CreatedAt, err = getTxTimestamp(stub); err != nil {
+		return err
+	}
+	if err := putJSONState(stub, retentionEntryKey(domain, policy.HeadSeq), entry); err != nil {
+		return err
+	}
+	policy.HeadSeq++
+	return putJSONState(stub, K_RETENTION_PREFIX+domain, policy)
+}
+
+// 记录是否已经投递完成，可以删除
+func (bs *CrossChain) retentionRecordSettled(stub shim.ChaincodeStubInterface, entry *RetentionEntry) (bool, error) {
+	switch entry.Kind {
+	case ERASE_KIND_DISPUTE:
+		var dm DisputedMessage
+		if has, err := getJSONState(stub, entry.Key, &dm); err != nil || !has {
+			return !has, err
+		}
+		return dm.Status == DISPUTE_STATUS_FINALIZED || dm.Status == DISPUTE_STATUS_REJECTED, nil
+	case ERASE_KIND_OPTIMISTIC:
+		var claim OptimisticClaim
+		if has, err := getJSONState(stub, entry.Key, &claim); err != nil || !has {
+			return !has, err
+		}
+		return claim.Status != CLAIM_STATUS_PENDING, nil
+	}
+	return true, nil
+}
+
+// 删除来源域名下过期的消息记录，每次最多RETENTION_MAX_PRUNE条
+func (bs *CrossChain) pruneRetention(stub shim.ChaincodeStubInterface, domain string) error {
+	policy, err := bs.getRetentionPolicy(stub, domain)
+	if err != nil || policy == nil {
+		return err
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	tail := policy.TailSeq
+	for pruned := 0; pruned < RETENTION_MAX_PRUNE && policy.TailSeq < policy.HeadSeq; pruned++ {
+		var entry RetentionEntry
+		if _, err := getJSONState(stub, retentionEntryKey(domain, policy.TailSeq), &entry); err != nil {
+			return err
+		}
+		expired := (policy.MaxAge > 0 && now-entry.CreatedAt >= policy.MaxAge) ||
+			(policy.MaxCount > 0 && policy.HeadSeq-policy.TailSeq > policy.MaxCount)
+		if !expired {
+			break
+		}
+		if settled, err := bs.retentionRecordSettled(stub, &entry); err != nil {
+			return err
+		} else if !settled {
+			break
+		}
+		if entry.Collection != "" {
+			err = stub.DelPrivateData(entry.Collection, entry.Key)
+		} else {
+			err = stub.DelState(entry.Key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete expired record %s: %v", entry.Key, err)
+		}
+		if err := stub.DelState(retentionEntryKey(domain, policy.TailSeq)); err != nil {
+			return err
+		}
+		policy.TailSeq++
+	}
+	if policy.TailSeq == tail {
+		return nil
+	}
+	return putJSONState(stub, K_RETENTION_PREFIX+domain, policy)
+}
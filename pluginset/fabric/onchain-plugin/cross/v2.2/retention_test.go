@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+	"wrapstub/v2.2"
+)
+
+// 以指定的交易id和时间戳回调业务链码
+func testCallbackInTx(t *testing.T, stub *shimtest.MockStub, tx string, seconds int64, content string) {
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte(content),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+	}}
+	raw, _ := json.Marshal(msgs)
+	stub.MockTransactionStart(tx)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+	res := NewCrossChain().callbackBizChaincode(wrapstub.NewMockWrapStub(stub), raw)
+	stub.MockTransactionEnd(tx)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "from.com", "100"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setRetentionPolicy", "from.com", "1000", "1"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	testCallbackInTx(t, stub, "tx1", 1000, "first")
+	testCallbackInTx(t, stub, "tx2", 1010, "second")
+	_, firstKey, _ := NewCrossChain().getDisputedMessage(stub, testDisputeMsgId("tx1"))
+	_, secondKey, _ := NewCrossChain().getDisputedMessage(stub, testDisputeMsgId("tx2"))
+
+	// 超出保留数量，但第一条消息还在争议窗口中，不能删除
+	if _, has := stub.State[firstKey]; !has {
+		t.Fatal("pending record should not be pruned")
+	}
+	res := CallWithTimestamp(stub, 1100, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return NewCrossChain().finalizeMessage(stub, []string{testDisputeMsgId("tx1")})
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 下一笔收消息交易顺带删除已投递的过期记录
+	testCallbackInTx(t, stub, "tx3", 1120, "third")
+	if _, has := stub.State[firstKey]; has {
+		t.Fatal("settled record beyond max count should be pruned")
+	}
+	if _, has := stub.State[secondKey]; !has {
+		t.Fatal("pending record should be kept")
+	}
+	var policy RetentionPolicy
+	if err := json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryRetentionPolicy", "from.com").Payload, &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.TailSeq != 1 || policy.HeadSeq != 3 {
+		t.Fatalf("unexpected retention policy: %+v", policy)
+	}
+
+	// 只按时长删除
+	if res := InvokeWithStrings(t, stub, sp, "setRetentionPolicy", "from.com", "1000", "0"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	for _, tx := range []string{"tx2", "tx3"} {
+		res := CallWithTimestamp(stub, 1220, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().finalizeMessage(stub, []string{testDisputeMsgId(tx)})
+		})
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	testCallbackInTx(t, stub, "tx4", 2015, "fourth")
+	if _, has := stub.State[secondKey]; has {
+		t.Fatal("record older than max age should be pruned")
+	}
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "queryRetentionPolicy", "from.com").Payload, &policy)
+	if policy.TailSeq != 2 || policy.HeadSeq != 4 {
+		t.Fatalf("unexpected retention policy: %+v", policy)
+	}
+}
+
+func testDisputeMsgId(tx string) string {
+	return sha256Hex([]byte(tx + "_0"))
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crosserr"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 按证书属性授予的角色
+// 除了与管理员证书逐字节比对，管理员还可以按组织配置角色的认定规则：交易发起者属于该组织，并且证书带有指定的
+// Fabric CA属性(如role=relayer)或OU时获得角色，更换证书不需要修改链码配置。
+//   - admin：与管理员证书等同，可以调用所有管理接口
+//   - relayer：可以提交收到的消息和确认转发，不需要管理员证书，仍受中继者白名单、保证金和令牌限制
+//   - auditor：可以查询调试追踪记录
+//
+// 管理员证书始终具有全部角色。规则按组织配置，其它组织的CA签发的同名属性不会获得角色。
+const (
+	// composite key: crosschain_role~${role}~${mspId} -> RoleAttribute
+	K_ROLE_OBJECT_TYPE = CROSSCHAIN_PREFIX + "role"
+
+	ROLE_ADMIN   = "admin"
+	ROLE_RELAYER = "relayer"
+	ROLE_AUDITOR = "auditor"
+
+	ROLE_RULE_ATTR = "attr"
+	ROLE_RULE_OU   = "ou"
+)
+
+var roles = map[string]bool{ROLE_ADMIN: true, ROLE_RELAYER: true, ROLE_AUDITOR: true}
+
+type RoleAttribute struct {
+	Role  string `json:"role"`
+	MSPID string `json:"mspId"`
+	// attr按证书属性认定，ou按证书OU认定
+	Kind string `json:"kind"`
+	// 属性名，按OU认定时为OU
+	Name string `json:"name"`
+	// 属性值，按OU认定时为空
+	Value     string `json:"value,omitempty"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// oraclelogic内部的管理员检查和收消息检查同样认可按属性授予的角色
+func init() {
+	oraclelogic.SetRoleCheckers(
+		func(stub shim.ChaincodeStubInterface) bool { return hasRole(stub, ROLE_ADMIN) },
+		func(stub shim.ChaincodeStubInterface) bool { return hasRole(stub, ROLE_RELAYER) },
+	)
+}
+
+func roleKey(stub shim.ChaincodeStubInterface, role string, mspId string) (string, error) {
+	if !roles[role] {
+		return "", crosserr.New(crosserr.CodeInvalidArgs, "unknown role: %s", role)
+	}
+	if mspId == "" {
+		return "", fmt.Errorf("empty msp id")
+	}
+	return stub.CreateCompositeKey(K_ROLE_OBJECT_TYPE, []string{role, mspId})
+}
+
+// 交易发起者是否按属性获得了角色，读取或解析失败时视为没有
+func hasRole(stub shim.ChaincodeStubInterface, role string) bool {
+	mspId, err := cid.GetMSPID(stub)
+	if err != nil || mspId == "" {
+		return false
+	}
+	key, err := roleKey(stub, role, mspId)
+	if err != nil {
+		return false
+	}
+	var rule RoleAttribute
+	if has, err := getJSONState(stub, key, &rule); err != nil || !has {
+		return false
+	}
+	switch rule.Kind {
+	case ROLE_RULE_ATTR:
+		return cid.AssertAttributeValue(stub, rule.Name, rule.Value) == nil
+	case ROLE_RULE_OU:
+		ok, err := cid.HasOUValue(stub, rule.Name)
+		return err == nil && ok
+	}
+	return false
+}
+
+// 检查交易发起者是否具有角色，管理员具有全部角色
+func (bs *CrossChain) checkRole(stub shim.ChaincodeStubInterface, role string) pb.Response {
+	if role != ROLE_ADMIN && hasRole(stub, role) {
+		return shim.Success(nil)
+	}
+	return bs.Os.AdminManage(stub, "checkAdmin", []string{})
+}
+
+// 设置角色的认定规则
+// args[0] 角色，admin/relayer/auditor
+// args[1] 组织MSP ID
+// args[2] attr/ou，none表示删除该组织的规则
+// args[3] 属性名或OU
+// args[4] 属性值，按OU认定时不填
+func (bs *CrossChain) setRoleAttribute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	key, err := roleKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	rule := RoleAttribute{Role: args[0], MSPID: args[1], Kind: args[2]}
+	switch {
+	case rule.Kind == "none" && len(args) == 3:
+		if err := stub.DelState(key); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+		}
+		return shim.Success(nil)
+	case rule.Kind == ROLE_RULE_ATTR && len(args) == 5:
+		rule.Name, rule.Value = args[3], args[4]
+	case rule.Kind == ROLE_RULE_OU && len(args) == 4:
+		rule.Name = args[3]
+	default:
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "role rule(%v) format error: expect none, attr <name> <value> or ou <ou>", args[2:]))
+	}
+	if rule.Name == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty attribute name or ou"))
+	}
+	if rule.UpdatedAt, err = getTxTimestamp(stub); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	if err := putJSONState(stub, key, &rule); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put role rule"))
+	}
+	// 规则与管理员证书等同，受admin范围的key级背书策略保护
+	if err := bs.protectKeys(stub, KEY_SCOPE_ADMIN, key); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to protect keys"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询角色的认定规则
+// args[0] 角色，不填时返回全部规则
+func (bs *CrossChain) queryRoleAttributes(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) > 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if len(args) == 1 && !roles[args[0]] {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown role: %s", args[0]))
+	}
+	iter, err := stub.GetStateByPartialCompositeKey(K_ROLE_OBJECT_TYPE, args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	defer iter.Close()
+	rules := []RoleAttribute{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to iterate state"))
+		}
+		var rule RoleAttribute
+		if err := json.Unmarshal(kv.Value, &rule); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to parse role rule %s", kv.Key))
+		}
+		rules = append(rules, rule)
+	}
+	raw, _ := json.Marshal(rules)
+	return shim.Success(raw)
+}
+
+// 查询交易发起者具有的角色
+func (bs *CrossChain) queryMyRoles(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	granted := []string{}
+	for _, role := range []string{ROLE_ADMIN, ROLE_RELAYER, ROLE_AUDITOR} {
+		if ret := bs.checkRole(stub, role); ret.Status == shim.OK {
+			granted = append(granted, role)
+		}
+	}
+	raw, _ := json.Marshal(granted)
+	return shim.Success(raw)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"oraclelogic/v2.2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoleAttributes(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	admin := stub.Creator
+	orgCreator := func(cert, mspId string) []byte {
+		bt, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: mspId, IdBytes: []byte(cert)})
+		return bt
+	}
+	// TEST_RELAYER_CERT带有hf.Type=client、hf.EnrollmentID=userorg1属性，OU为client
+	relayer := orgCreator(TEST_RELAYER_CERT, "Org1MSP")
+	invokeAs := func(creator []byte, args ...string) (bool, string, []byte) {
+		stub.Creator = creator
+		defer func() { stub.Creator = admin }()
+		res := InvokeWithStrings(t, stub, sp, args...)
+		return res.Status == shim.OK, res.Message, res.Payload
+	}
+	myRoles := func(creator []byte) []string {
+		_, _, payload := invokeAs(creator, "queryMyRoles")
+		var granted []string
+		_ = json.Unmarshal(payload, &granted)
+		return granted
+	}
+	for _, args := range [][]string{
+		{"oracleAdminManage", "setExpectedDomain", "fabric.test"},
+		{"oracleAdminManage", "registerSha256Invert", "bizcc"},
+	} {
+		if ok, msg, _ := invokeAs(admin, args...); !ok {
+			t.Fatal(msg)
+		}
+	}
+	stub.MockPeerChaincode("bizcc", shimtest.NewMockStub("bizcc", new(CrossChainTest)), "")
+
+	if granted := myRoles(relayer); len(granted) != 0 {
+		t.Fatalf("no role should be granted before configured: %v", granted)
+	}
+	if ok, _, _ := invokeAs(relayer, "saveScanBookmark", "scan", "bm"); ok {
+		t.Fatal("relayer without role should be rejected")
+	}
+	if ok, _, _ := invokeAs(relayer, "queryDebugTrace"); ok {
+		t.Fatal("auditor without role should be rejected")
+	}
+	// 所有中继提交报文的入口都要求relayer角色
+	for _, fn := range []string{
+		"recvMessage", "recvBatchMessages", "recvPTCMessage", "submitZKBatch", "recvZKBatchMessages",
+		"submitEthUpdate", "recvEthMessage", "submitTMHeader", "recvTMMessage", "submitBTCHeaders", "recvBTCMessage",
+	} {
+		if ok, msg, _ := invokeAs(relayer, fn, "src.com", "00", "00"); ok || !strings.Contains(msg, "not oracle service admin") {
+			t.Fatalf("%s: relayer without role should be rejected: %s", fn, msg)
+		}
+	}
+	if granted := myRoles(admin); !reflect.DeepEqual(granted, []string{ROLE_ADMIN, ROLE_RELAYER, ROLE_AUDITOR}) {
+		t.Fatalf("admin cert should have all roles: %v", granted)
+	}
+
+	for _, args := range [][]string{
+		{"setRoleAttribute", ROLE_RELAYER, "Org1MSP", ROLE_RULE_ATTR, "hf.Type", "client"},
+		{"setRoleAttribute", ROLE_AUDITOR, "Org1MSP", ROLE_RULE_OU, "client"},
+	} {
+		if ok, msg, _ := invokeAs(admin, args...); !ok {
+			t.Fatal(msg)
+		}
+	}
+	if granted := myRoles(relayer); !reflect.DeepEqual(granted, []string{ROLE_RELAYER, ROLE_AUDITOR}) {
+		t.Fatalf("unexpected roles: %v", granted)
+	}
+	// 其它组织的同名属性不获得角色
+	if granted := myRoles(orgCreator(TEST_RELAYER_CERT, "Org2MSP")); len(granted) != 0 {
+		t.Fatalf("roles should be scoped to msp: %v", granted)
+	}
+	if ok, msg, _ := invokeAs(relayer, "saveScanBookmark", "scan", "bm"); !ok {
+		t.Fatal(msg)
+	}
+	if ok, msg, _ := invokeAs(relayer, "queryDebugTrace"); !ok {
+		t.Fatal(msg)
+	}
+	// oraclelogic内部的收消息检查同样认可relayer角色
+	bizcc := sha256.Sum256([]byte("bizcc"))
+	pkgs := MockAMPackages(t, "fabric.test", bizcc, oraclelogic.K_MSG_TYPE_ORDERED, "by role")
+	if ok, msg, _ := invokeAs(relayer, "recvMessage", "svc", mockRecvRawData(t, "src.com", pkgs[0])); !ok {
+		t.Fatal(msg)
+	}
+	if ok, _, _ := invokeAs(relayer, "setRelayConfig", "true", "60"); ok {
+		t.Fatal("relayer should not call admin functions")
+	}
+
+	// admin角色可以调用oraclelogic管理接口
+	if ok, msg, _ := invokeAs(admin, "setRoleAttribute", ROLE_ADMIN, "Org1MSP", ROLE_RULE_ATTR, "hf.EnrollmentID", "userorg1"); !ok {
+		t.Fatal(msg)
+	}
+	for _, args := range [][]string{
+		{"oracleAdminManage", "registerSha256Invert", "othercc"},
+		{"setRelayConfig", "true", "60"},
+	} {
+		if ok, msg, _ := invokeAs(relayer, args...); !ok {
+			t.Fatalf("%v: %s", args, msg)
+		}
+	}
+	h := sha256.Sum256([]byte("othercc"))
+	if string(stub.State[hex.EncodeToString(h[:])]) != "othercc" {
+		t.Fatal("sha256 invert should be registered by admin role")
+	}
+
+	var rules []RoleAttribute
+	_, _, payload := invokeAs(relayer, "queryRoleAttributes", ROLE_RELAYER)
+	if err := json.Unmarshal(payload, &rules); err != nil || len(rules) != 1 || rules[0].Name != "hf.Type" || rules[0].Value != "client" || rules[0].MSPID != "Org1MSP" {
+		t.Fatalf("unexpected relayer rules: %s", payload)
+	}
+	_, _, payload = invokeAs(relayer, "queryRoleAttributes")
+	if err := json.Unmarshal(payload, &rules); err != nil || len(rules) != 3 {
+		t.Fatalf("unexpected rules: %s", payload)
+	}
+
+	// 删除规则
+	for _, role := range []string{ROLE_ADMIN, ROLE_RELAYER} {
+		if ok, msg, _ := invokeAs(admin, "setRoleAttribute", role, "Org1MSP", "none"); !ok {
+			t.Fatal(msg)
+		}
+	}
+	if granted := myRoles(relayer); !reflect.DeepEqual(granted, []string{ROLE_AUDITOR}) {
+		t.Fatalf("unexpected roles after removed: %v", granted)
+	}
+
+	for _, args := range [][]string{
+		{"setRoleAttribute", "operator", "Org1MSP", ROLE_RULE_OU, "client"},
+		{"setRoleAttribute", ROLE_RELAYER, "", ROLE_RULE_OU, "client"},
+		{"setRoleAttribute", ROLE_RELAYER, "Org1MSP", ROLE_RULE_ATTR, "hf.Type"},
+		{"setRoleAttribute", ROLE_RELAYER, "Org1MSP", ROLE_RULE_OU, ""},
+		{"setRoleAttribute", ROLE_RELAYER, "Org1MSP", "cn", "client"},
+		{"queryRoleAttributes", "operator"},
+	} {
+		if ok, _, _ := invokeAs(admin, args...); ok {
+			t.Fatalf("%v should be rejected", args)
+		}
+	}
+	if ok, _, _ := invokeAs(relayer, "setRoleAttribute", ROLE_ADMIN, "Org1MSP", ROLE_RULE_OU, "client"); ok {
+		t.Fatal("non-admin should not set role attribute")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 按域名路由
+// 同一通道上部署多个业务链码时，管理员为来源域名配置可以接收其消息的本地业务链码。
+// 来源域名配置了路由后，消息的接收方身份只在该域名的路由中查找，不在路由中的接收方拒绝投递；
+// 未配置路由的域名仍按接收方登记表查找(见registry.go)。
+const (
+	// ${K_ROUTE_OBJECT_TYPE}~${domain}~${ccName} -> Route
+	K_ROUTE_OBJECT_TYPE = CROSSCHAIN_PREFIX + "route"
+)
+
+type Route struct {
+	Domain    string `json:"domain"`
+	Chaincode string `json:"chaincode"`
+	// sha256(业务链码名)(hex)，即消息中的接收方身份
+	Receiver  string `json:"receiver"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+type RoutePage struct {
+	Routes   []Route `json:"routes"`
+	Bookmark string  `json:"bookmark"`
+}
+
+// 按路由查找接收消息的业务链码，来源域名未配置路由时查接收方登记表
+func (bs *CrossChain) routeReceiver(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage) (string, error) {
+	iter, err := stub.GetStateByPartialCompositeKey(K_ROUTE_OBJECT_TYPE, []string{msg.From})
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+	routed := false
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return "", err
+		}
+		routed = true
+		_, attrs, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			return "", err
+		}
+		if sha256.Sum256([]byte(attrs[1])) == msg.Receiver {
+			return attrs[1], nil
+		}
+	}
+	if routed {
+		return "", crosserr.New(crosserr.CodeNotFound, "no route from %s to receiver %s", msg.From, hex.EncodeToString(msg.Receiver[:]))
+	}
+	return bs.resolveReceiver(stub, msg.Receiver)
+}
+
+// 添加路由
+// args[0] 来源域名
+// args[1] 业务链码名
+func (bs *CrossChain) setRoute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" || args[1] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong args: %s, %s", args[0], args[1]))
+	}
+	key, err := stub.CreateCompositeKey(K_ROUTE_OBJECT_TYPE, args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+	route := &Route{Domain: args[0], Chaincode: args[1], Receiver: sha256Hex([]byte(args[1])), UpdatedAt: now}
+	if err := putJSONState(stub, key, route); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put route"))
+	}
+	bz, _ := json.Marshal(route)
+	return shim.Success(bz)
+}
+
+// 删除路由，域名的最后一条路由删除后恢复按接收方登记表查找
+// args[0] 来源域名
+// args[1] 业务链码名
+func (bs *CrossChain) deleteRoute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	key, err := stub.CreateCompositeKey(K_ROUTE_OBJECT_TYPE, args)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to create composite key"))
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	}
+	if len(raw) == 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no route from %s to %s", args[0], args[1]))
+	}
+	if err := stub.DelState(key); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete state"))
+	}
+	return shim.Success(nil)
+}
+
+// 按页查询路由
+// args[0] 来源域名，空字符串表示全部域名
+// args[1] 每页条数(可选)
+// args[2] 书签(可选)
+func (bs *CrossChain) queryRoutes(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	pageSize, bookmark, err := parsePageArgs(args[1:])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid page args"))
+	}
+	keys := []string{}
+	if args[0] != "" {
+		keys = append(keys, args[0])
+	}
+	page := RoutePage{Routes: []Route{}}
+	page.Bookmark, err = scanCompositeKeyPage(stub, K_ROUTE_OBJECT_TYPE, keys, pageSize, bookmark, func(_ string, value []byte) error {
+		var route Route
+		if err := json.Unmarshal(value, &route); err != nil {
+			return err
+		}
+		page.Routes = append(page.Routes, route)
+		return nil
+	})
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to query routes"))
+	}
+	raw, _ := json.Marshal(page)
+	return shim.Success(raw)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	recv := func(domain string, seq uint32) pb.Response {
+		msg := testSDPMessageV2("route", seq)
+		msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+		return InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, domain, hex.EncodeToString(am)))
+	}
+	queryRoutes := func(args ...string) RoutePage {
+		var page RoutePage
+		res := CallPagedWithTimestamp(stub, 1000, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return NewCrossChain().queryRoutes(stub, args)
+		})
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	for _, args := range [][]string{{"a.com"}, {"", "bizcc"}, {"a.com", ""}} {
+		if res := InvokeWithStrings(t, stub, sp, append([]string{"setRoute"}, args...)...); res.Status == shim.OK {
+			t.Fatalf("invalid route should be rejected: %v", args)
+		}
+	}
+	for _, args := range [][]string{{"a.com", "bizcc"}, {"a.com", "othercc"}, {"b.com", "othercc"}} {
+		if res := InvokeWithStrings(t, stub, sp, append([]string{"setRoute"}, args...)...); res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+	if page := queryRoutes(""); len(page.Routes) != 3 || page.Bookmark != "" {
+		t.Fatalf("unexpected routes: %+v", page)
+	}
+	page := queryRoutes("a.com", "1")
+	if len(page.Routes) != 1 || page.Routes[0].Chaincode != "bizcc" || page.Routes[0].Receiver != sha256Hex([]byte("bizcc")) || page.Bookmark == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	if page := queryRoutes("a.com", "1", page.Bookmark); len(page.Routes) != 1 || page.Routes[0].Chaincode != "othercc" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	// 路由中的接收方可以收到消息，未配置路由的域名按登记表查找
+	if res := recv("a.com", 0); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := recv("c.com", 0); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "deleteRoute", "b.com", "bizcc"); res.Status == shim.OK {
+		t.Fatal("deleting unknown route should be rejected")
+	}
+	// MockStub不回滚失败的交易，被拒绝的消息仍占用序号0
+	if res := recv("b.com", 0); res.Status == shim.OK || !strings.Contains(res.Message, "no route from b.com") {
+		t.Fatalf("receiver not in route should be rejected: %s", res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "deleteRoute", "b.com", "othercc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := recv("b.com", 1); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	stub.Creator = MockCreator(TEST_RELAYER_CERT)
+	if res := InvokeWithStrings(t, stub, sp, "setRoute", "d.com", "bizcc"); res.Status == shim.OK {
+		t.Fatal("non-admin should not set route")
+	}
+}
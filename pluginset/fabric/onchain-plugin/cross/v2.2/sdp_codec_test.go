@@ -0,0 +1,134 @@
+package main
+
+import (
+	"am"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSDPMessageV2(payload string, seq uint32) *oraclelogic.SDPMessage {
+	return &oraclelogic.SDPMessage{
+		Version:        oraclelogic.SDP_VERSION_2,
+		TargetDomain:   "fabric.test",
+		TargetIdentity: sha256.Sum256([]byte("bizcc")),
+		Sequence:       seq,
+		Payload:        []byte(payload),
+		MessageId:      sha256.Sum256([]byte(payload)),
+		AtomicFlag:     oraclelogic.SDP_ATOMIC_REQUEST,
+		Nonce:          42,
+	}
+}
+
+func TestSDPCodec(t *testing.T) {
+	be32 := func(v uint32) []byte {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], v)
+		return b[:]
+	}
+	// 按AntChain Bridge v2报文格式逐字段拼接
+	msg := testSDPMessageV2("hello", 7)
+	msg.AtomicFlag, msg.ErrorMsg = oraclelogic.SDP_ATOMIC_ACK_ERROR, "revert"
+	expected := bytes.Join([][]byte{
+		[]byte("revert"), be32(6),
+		[]byte("hello"), be32(5),
+		be32(7),
+		{0, 0, 0, 0, 0, 0, 0, 42},
+		{oraclelogic.SDP_ATOMIC_ACK_ERROR},
+		msg.TargetIdentity[:],
+		[]byte("fabric.test"), be32(11),
+		msg.MessageId[:],
+		{0xff, 0, 0, 2},
+	}, nil)
+	raw, err := msg.Encode()
+	if err != nil || !bytes.Equal(raw, expected) {
+		t.Fatalf("unexpected sdp v2 encoding: %x", raw)
+	}
+	decoded, err := oraclelogic.DecodeSDPMessage(raw)
+	if err != nil || !reflect.DeepEqual(decoded, msg) {
+		t.Fatalf("unexpected sdp v2 decoding: %+v %v", decoded, err)
+	}
+
+	// v1报文与发送消息时构造的报文一致
+	v1 := &oraclelogic.SDPMessage{Version: oraclelogic.SDP_VERSION_1, TargetDomain: "dest.com", Sequence: 3, Payload: []byte(strings.Repeat("x", 40))}
+	raw, _ = v1.Encode()
+	if version, _ := oraclelogic.DecodeSDPVersion(raw); version != oraclelogic.SDP_VERSION_1 {
+		t.Fatalf("unexpected version %d", version)
+	}
+	destDomain, content, _, seq, _ := oraclelogic.TestParseP2PMessage(raw)
+	if string(destDomain) != v1.TargetDomain || string(content) != string(v1.Payload) || seq != 3 {
+		t.Fatal("sdp v1 encoding mismatch")
+	}
+	if decoded, err := oraclelogic.DecodeSDPMessage(raw); err != nil || !reflect.DeepEqual(decoded, v1) {
+		t.Fatalf("unexpected sdp v1 decoding: %+v %v", decoded, err)
+	}
+
+	// 畸形报文返回错误而不是越界
+	raw, _ = testSDPMessageV2("hello", 0).Encode()
+	for _, bad := range [][]byte{
+		raw[1:],
+		append(append([]byte{}, raw[:len(raw)-1]...), 3),
+		raw[len(raw)-40:],
+		make([]byte, 31),
+		append(make([]byte, 28), 0, 0, 1, 0),
+	} {
+		if _, err := oraclelogic.DecodeSDPMessage(bad); err == nil {
+			t.Fatalf("malformed sdp message should be rejected: %x", bad)
+		}
+	}
+}
+
+// 中继提交承载SDP v2报文的AM报文
+func TestRecvSDPv2Message(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	sdp, _ := testSDPMessageV2("sdp v2", 0).Encode()
+	am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+
+	msg, seq, ret := NewCrossChain().Os.ParseAMPackage(stub, "src.com", hex.EncodeToString(am))
+	if ret.Status != shim.OK {
+		t.Fatal(ret.Message)
+	}
+	id := sha256.Sum256([]byte("sdp v2"))
+	if seq != 0 || msg.SDPVersion != oraclelogic.SDP_VERSION_2 || msg.Nonce != 42 || msg.AtomicFlag != oraclelogic.SDP_ATOMIC_REQUEST ||
+		msg.MessageId != hex.EncodeToString(id[:]) || string(msg.Content) != "sdp v2" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(am))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.HasSuffix(string(res.Payload), ":sdp v2") {
+		t.Fatalf("unexpected last message: %s", res.Payload)
+	}
+}
+
+// 中继提交AM v2报文
+func TestRecvAMv2Message(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	sdp, _ := testSDPMessageV2("am v2", 0).Encode()
+	raw := (&am.AuthMessageV2{Author: sha256.Sum256([]byte("sender")), Payload: sdp}).Encode()
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(raw))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.HasSuffix(string(res.Payload), ":am v2") {
+		t.Fatalf("unexpected last message: %s", res.Payload)
+	}
+
+	// 未登记协议的AM报文被拒绝
+	raw = (&am.AuthMessageV2{ProtocolType: 1, Payload: sdp}).Encode()
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", hex.EncodeToString(raw))); res.Status == shim.OK {
+		t.Fatal("AM message of unknown protocol should be rejected")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// SDP有序消息序号
+// AM层在接收报文时检查序号连续，但消息经过争议窗口、乐观验证等暂存后再投递，投递顺序由调用方决定。
+// SDP层对每个(来源域名, 发送方, 接收方)通道记录已投递的最大序号，投递有序消息时要求序号严格递增，
+// 重复投递和乱序投递都会被拒绝，不会覆盖业务链码的状态。
+//
+// 被驳回的争议消息不会投递，之后的消息可以跳过它的序号继续投递。
+const (
+	// crosschain_sdp_seq_${sha256(senderDomain + senderID + receiverID)} -> SDPSeq
+	K_SDP_SEQ_PREFIX = CROSSCHAIN_PREFIX + "sdp_seq_"
+)
+
+type SDPSeq struct {
+	// 从v1迁移的通道只有哈希，不记录以下字段
+	SenderDomain string `json:"senderDomain,omitempty"`
+	SenderID     string `json:"senderID,omitempty"`
+	ReceiverID   string `json:"receiverID,omitempty"`
+	// 下一条可以投递的最小序号
+	Next      uint32 `json:"next"`
+	Delivered uint64 `json:"delivered"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+func sdpSeqKey(senderDomain string, sender [32]byte, receiver [32]byte) string {
+	c := make([]byte, 0, len(senderDomain)+64)
+	c = append(c, senderDomain...)
+	c = append(c, sender[:]...)
+	c = append(c, receiver[:]...)
+	h := sha256.Sum256(c)
+	return K_SDP_SEQ_PREFIX + hex.EncodeToString(h[:])
+}
+
+func (bs *CrossChain) getSDPSeq(stub shim.ChaincodeStubInterface, senderDomain string, sender [32]byte, receiver [32]byte) (*SDPSeq, error) {
+	seq := SDPSeq{
+		SenderDomain: senderDomain,
+		SenderID:     hex.EncodeToString(sender[:]),
+		ReceiverID:   hex.EncodeToString(receiver[:]),
+	}
+	if _, err := getJSONState(stub, sdpSeqKey(senderDomain, sender, receiver), &seq); err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// 投递有序消息前检查并推进通道序号，无序消息不检查
+func (bs *CrossChain) checkSDPSeq(stub shim.ChaincodeStubInterface, msg oraclelogic.RecvAuthMessage) error {
+	if msg.MsgType != oraclelogic.K_MSG_TYPE_ORDERED {
+		return nil
+	}
+	if msg.Seq == oraclelogic.K_UNORDERED_MSG_SEQ {
+		return crosserr.New(crosserr.CodeInvalidArgs, "ordered message carries unordered seq")
+	}
+	seq, err := bs.getSDPSeq(stub, msg.From, msg.Identity, msg.Receiver)
+	if err != nil {
+		return err
+	}
+	if msg.Seq < seq.Next {
+		return crosserr.New(crosserr.CodeSequence, "out-of-order message from %s: seq %d, expected at least %d", msg.From, msg.Seq, seq.Next)
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	seq.Next = msg.Seq + 1
+	seq.Delivered++
+	seq.UpdatedAt = now
+	return putJSONState(stub, sdpSeqKey(msg.From, msg.Identity, msg.Receiver), seq)
+}
+
+// 查询SDP通道序号
+// args[0] 来源域名
+// args[1] 发送方身份, byte32 hexstring
+// args[2] 接收方身份, byte32 hexstring
+func (bs *CrossChain) querySDPSeq(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var ids [2][32]byte
+	for i, arg := range args[1:] {
+		id, err := hex.DecodeString(arg)
+		if err != nil || len(id) != 32 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "identity(%s) format error", arg))
+		}
+		copy(ids[i][:], id)
+	}
+	seq, err := bs.getSDPSeq(stub, args[0], ids[0], ids[1])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get SDP seq"))
+	}
+	raw, _ := json.Marshal(seq)
+	return shim.Success(raw)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func testOrderedMessage(content string, seq uint32) oraclelogic.RecvAuthMessage {
+	return oraclelogic.RecvAuthMessage{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte(content),
+		Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_ORDERED, Seq: seq}
+}
+
+func TestSDPSeq(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	deliver := func(msgs ...oraclelogic.RecvAuthMessage) pb.Response {
+		raw, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: msgs})
+		return InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw))
+	}
+	if res := deliver(testOrderedMessage("m0", 0)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := deliver(testOrderedMessage("dup", 0)); res.Status == shim.OK {
+		t.Fatal("duplicate message should be rejected")
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.HasSuffix(string(res.Payload), ":m0") {
+		t.Fatalf("duplicate message should not overwrite state, got %s", res.Payload)
+	}
+	// 跳过的序号不能再投递
+	if res := deliver(testOrderedMessage("m2", 2)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := deliver(testOrderedMessage("m1", 1)); res.Status == shim.OK {
+		t.Fatal("out-of-order message should be rejected")
+	}
+
+	sender, receiver := sha256.Sum256([]byte("sender")), sha256.Sum256([]byte("bizcc"))
+	res := InvokeWithStrings(t, stub, sp, "querySDPSeq", "from.com", hex.EncodeToString(sender[:]), hex.EncodeToString(receiver[:]))
+	var seq SDPSeq
+	if err := json.Unmarshal(res.Payload, &seq); err != nil {
+		t.Fatal(res.Message)
+	}
+	if seq.Next != 3 || seq.Delivered != 2 {
+		t.Fatalf("unexpected sdp seq: %+v", seq)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "querySDPSeq", "from.com", "00", hex.EncodeToString(receiver[:])); res.Status == shim.OK {
+		t.Fatal("invalid identity should be rejected")
+	}
+
+	// 同一批内乱序
+	if res := deliver(testOrderedMessage("m4", 4), testOrderedMessage("m3", 3)); res.Status == shim.OK {
+		t.Fatal("out-of-order message in batch should be rejected")
+	}
+}
+
+func TestSDPSeqAfterDisputeWindow(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "from.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	raw, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		testOrderedMessage("first", 0), testOrderedMessage("second", 1),
+	}})
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	bs := NewCrossChain()
+	res := CallPagedWithTimestamp(stub, 0, func(stub shim.ChaincodeStubInterface) pb.Response {
+		return bs.queryPendingDisputes(stub, nil)
+	})
+	var page DisputePage
+	if err := json.Unmarshal(res.Payload, &page); err != nil || len(page.Messages) != 2 {
+		t.Fatalf("unexpected pending disputes: %s", res.Payload)
+	}
+	held := map[string]DisputedMessage{}
+	for _, dm := range page.Messages {
+		held[string(dm.Message.Content)] = dm
+	}
+
+	// 先投递后一条，前一条变为乱序
+	finalize := func(dm DisputedMessage) pb.Response {
+		return CallWithTimestamp(stub, dm.Deadline, func(stub shim.ChaincodeStubInterface) pb.Response {
+			return bs.finalizeMessage(stub, []string{dm.MsgId})
+		})
+	}
+	if res := finalize(held["second"]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := finalize(held["first"]); res.Status == shim.OK {
+		t.Fatal("message finalized out of order should be rejected")
+	}
+}
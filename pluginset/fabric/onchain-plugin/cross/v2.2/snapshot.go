@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"sort"
+	"unicode/utf8"
+)
+
+// 审计快照
+// 把跨链相关的公共状态按固定的分组和key顺序序列化，并给出承诺哈希，不同组织的审计方
+// 各自从本组织节点查询后可以逐字节比对。
+//   - sequences: 有序消息的收发序号
+//   - acl: 管理员、中继者保证金和令牌公钥、挑战者授权、接收方登记表及其私有集合摘要、节点装饰策略、手续费账户
+//   - trustRoots: 预言机集群、域名公钥、本链域名证书、轻客户端和头同步配置、背书策略
+//   - receipts: 暂存消息、乐观声明、擦除回执和防重放标记，只给出值的sha256摘要
+//
+// 快照只读取公共状态，私有集合中的表项以公共摘要的形式出现。
+const (
+	SNAPSHOT_SECTION_SEQUENCES   = "sequences"
+	SNAPSHOT_SECTION_ACL         = "acl"
+	SNAPSHOT_SECTION_TRUST_ROOTS = "trustRoots"
+	SNAPSHOT_SECTION_RECEIPTS    = "receipts"
+)
+
+type SnapshotEntry struct {
+	Key    string `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+type SnapshotSection struct {
+	Name    string          `json:"name"`
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+type Snapshot struct {
+	Sections   []SnapshotSection `json:"sections"`
+	Commitment string            `json:"commitment"`
+}
+
+// 快照中的一类状态：单个key、key前缀或复合key的对象类型
+type snapshotSource struct {
+	key        string
+	prefix     string
+	objectType string
+}
+
+type snapshotSectionSpec struct {
+	name    string
+	sources []snapshotSource
+	// 只保留值的摘要
+	digestOnly bool
+}
+
+var snapshotSpecs = []snapshotSectionSpec{
+	{name: SNAPSHOT_SECTION_SEQUENCES, sources: []snapshotSource{
+		{prefix: oraclelogic.K_RECV_SEQ_PREFIX},
+		{prefix: oraclelogic.K_SEND_SEQ_PREFIX},
+		{prefix: K_SDP_SEQ_PREFIX},
+		{objectType: K_CHANNEL_OBJECT_TYPE},
+	}},
+	{name: SNAPSHOT_SECTION_ACL, sources: []snapshotSource{
+		{key: oraclelogic.K_ADMIN_CERT},
+		{key: K_BOND_CONFIG},
+		{objectType: K_BOND_OBJECT_TYPE},
+		{objectType: K_CHALLENGER_OBJECT_TYPE},
+		{prefix: K_RECEIVER_PREFIX},
+		{objectType: K_ROUTE_OBJECT_TYPE},
+		{prefix: K_CHANNEL_ROUTE_PREFIX},
+		{prefix: K_PAYLOAD_COLLECTION_PREFIX},
+		{key: K_REGISTRY_COLLECTION},
+		{prefix: K_REGISTRY_DIGEST_PREFIX},
+		{prefix: K_PRIVATE_ROUTE_PREFIX},
+		{key: K_DECORATION_POLICY},
+		{key: K_RELAYER_TOKEN_CONFIG},
+		{prefix: K_RELAYER_TOKEN_KEY_PREFIX},
+		{key: K_RELAYER_ACL_CONFIG},
+		{objectType: K_RELAYER_ACL_OBJECT_TYPE},
+		{objectType: K_ROLE_OBJECT_TYPE},
+		{key: K_GOVERNANCE_CONFIG},
+		{key: K_PAUSE_STATE},
+		{key: K_SCHEMA_VERSION},
+		{key: K_EVENT_VERSION},
+		{objectType: K_FEE_SCHEDULE_OBJECT_TYPE},
+		{objectType: K_FEE_ACCOUNT_OBJECT_TYPE},
+		{key: K_FEE_POOL},
+		{key: K_MESSAGE_EXPIRY},
+		{prefix: K_RATE_LIMIT_PREFIX},
+	}},
+	{name: SNAPSHOT_SECTION_TRUST_ROOTS, sources: []snapshotSource{
+		{key: oraclelogic.K_ORACLE_CLUSTERS},
+		{key: oraclelogic.K_ORACLE_NODE_PKS},
+		{key: oraclelogic.K_PK_DOMAINS},
+		{prefix: oraclelogic.K_DOMAIN_SERVICE_IDS},
+		{key: K_ENDORSEMENT_POLICY},
+		{prefix: K_KEY_ENDORSEMENT_PREFIX},
+		{key: K_DOMAIN_CERT},
+		{prefix: K_DOMAIN_REGISTRY_PREFIX},
+		{objectType: K_PARSER_OBJECT_TYPE},
+		{key: K_BCDNS_TRUST_ROOT},
+		{prefix: K_DOMAIN_PTC_CERT_PREFIX},
+		{prefix: K_HEADER_SYNC_PREFIX},
+		{prefix: K_TM_CLIENT_PREFIX},
+		{prefix: K_TM_AM_STORE_PREFIX},
+		{prefix: K_ETH_LC_PREFIX},
+		{prefix: K_ETH_AM_CONTRACT_PREFIX},
+		{prefix: K_BTC_SPV_PREFIX},
+		{prefix: K_ZK_ROUTE_PREFIX},
+		{prefix: K_PTC_COMMITTEE_PREFIX},
+	}},
+	{name: SNAPSHOT_SECTION_RECEIPTS, digestOnly: true, sources: []snapshotSource{
+		{objectType: K_DISPUTE_OBJECT_TYPE},
+		{objectType: K_OPTIMISTIC_OBJECT_TYPE},
+		{prefix: K_ERASE_RECEIPT_PREFIX},
+		{objectType: K_RECEIPT_OBJECT_TYPE},
+		{prefix: K_PAYLOAD_RECORD_PREFIX},
+		{prefix: K_ACK_STATUS_PREFIX},
+		{prefix: K_PENDING_REQUEST_PREFIX},
+		{objectType: K_EXPIRY_OBJECT_TYPE},
+		{objectType: K_CHUNK_OBJECT_TYPE},
+		{objectType: K_CHUNK_FRAGMENT_OBJECT_TYPE},
+		{prefix: K_TM_CONSUMED_PREFIX},
+		{prefix: K_ETH_CONSUMED_PREFIX},
+		{prefix: K_BTC_CONSUMED_PREFIX},
+		{prefix: K_ZK_BATCH_CONSUMED_PREFIX},
+	}},
+}
+
+func readSnapshotSource(stub shim.ChaincodeStubInterface, src snapshotSource, entries map[string][]byte) error {
+	if src.key != "" {
+		value, err := stub.GetState(src.key)
+		if err != nil {
+			return err
+		}
+		if len(value) != 0 {
+			entries[src.key] = value
+		}
+		return nil
+	}
+	var iter shim.StateQueryIteratorInterface
+	var err error
+	if src.objectType != "" {
+		iter, err = stub.GetStateByPartialCompositeKey(src.objectType, []string{})
+	} else {
+		iter, err = stub.GetStateByRange(src.prefix, src.prefix+string(utf8.MaxRune))
+	}
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		entries[kv.Key] = kv.Value
+	}
+	return nil
+}
+
+func (bs *CrossChain) buildSnapshot(stub shim.ChaincodeStubInterface) (*Snapshot, error) {
+	snapshot := &Snapshot{Sections: []SnapshotSection{}}
+	for _, spec := range snapshotSpecs {
+		// 同一个key只出现一次，并按key排序，与各节点的迭代实现无关
+		values := map[string][]byte{}
+		for _, src := range spec.sources {
+			if err := readSnapshotSource(stub, src, values); err != nil {
+				return nil, err
+			}
+		}
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		section := SnapshotSection{Name: spec.name, Entries: []SnapshotEntry{}}
+		for _, k := range keys {
+			entry := SnapshotEntry{Key: k}
+			if spec.digestOnly {
+				entry.Digest = sha256Hex(values[k])
+			} else {
+				entry.Value = values[k]
+			}
+			section.Entries = append(section.Entries, entry)
+		}
+		snapshot.Sections = append(snapshot.Sections, section)
+	}
+	commitment, err := snapshotCommitment(snapshot.Sections)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Commitment = commitment
+	return snapshot, nil
+}
+
+// 承诺哈希为 sha256(json(sections))
+func snapshotCommitment(sections []SnapshotSection) (string, error) {
+	bz, err := json.Marshal(sections)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(bz)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// 导出审计快照
+func (bs *CrossChain) exportSnapshot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	snapshot, err := bs.buildSnapshot(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to build snapshot"))
+	}
+	bz, _ := json.Marshal(snapshot)
+	return shim.Success(bz)
+}
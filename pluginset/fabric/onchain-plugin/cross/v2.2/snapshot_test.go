@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"sort"
+	"testing"
+)
+
+func TestExportSnapshot(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	if res := InvokeWithStrings(t, stub, sp, "setChallenger", testCertHash(TEST_RELAYER_CERT), "true"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "registerReceiver", "bizcc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEndorsementPolicy", "1", "Org1MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setDisputeWindow", "from.com", "600"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	msgs := oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{
+		{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte("held"),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED},
+	}}
+	raw, _ := json.Marshal(msgs)
+	if res := InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	first := InvokeWithStrings(t, stub, sp, "exportSnapshot")
+	if first.Status != shim.OK {
+		t.Fatal(first.Message)
+	}
+	if second := InvokeWithStrings(t, stub, sp, "exportSnapshot"); !bytes.Equal(first.Payload, second.Payload) {
+		t.Fatal("snapshot should be deterministic")
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(first.Payload, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	commitment, _ := snapshotCommitment(snapshot.Sections)
+	if commitment != snapshot.Commitment {
+		t.Fatalf("commitment mismatch: %s != %s", commitment, snapshot.Commitment)
+	}
+
+	sections := map[string]SnapshotSection{}
+	for _, section := range snapshot.Sections {
+		if !sort.SliceIsSorted(section.Entries, func(i, j int) bool { return section.Entries[i].Key < section.Entries[j].Key }) {
+			t.Fatalf("entries of %s are not sorted", section.Name)
+		}
+		sections[section.Name] = section
+	}
+	if len(sections[SNAPSHOT_SECTION_ACL].Entries) != 3 {
+		t.Fatalf("unexpected acl entries: %+v", sections[SNAPSHOT_SECTION_ACL].Entries)
+	}
+	if len(sections[SNAPSHOT_SECTION_TRUST_ROOTS].Entries) != 1 || sections[SNAPSHOT_SECTION_TRUST_ROOTS].Entries[0].Key != K_ENDORSEMENT_POLICY {
+		t.Fatalf("unexpected trust roots: %+v", sections[SNAPSHOT_SECTION_TRUST_ROOTS].Entries)
+	}
+	// 暂存消息的争议记录和接收回执
+	receipts := sections[SNAPSHOT_SECTION_RECEIPTS].Entries
+	if len(receipts) != 2 {
+		t.Fatalf("unexpected receipts: %+v", receipts)
+	}
+	for _, entry := range receipts {
+		if entry.Value != nil || entry.Digest != sha256Hex(stub.State[entry.Key]) {
+			t.Fatalf("unexpected receipt entry: %+v", entry)
+		}
+	}
+
+	// 授权变化后承诺哈希随之变化
+	if res := InvokeWithStrings(t, stub, sp, "setChallenger", testCertHash(TEST_RELAYER_CERT), "false"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var changed Snapshot
+	_ = json.Unmarshal(InvokeWithStrings(t, stub, sp, "exportSnapshot").Payload, &changed)
+	if changed.Commitment == snapshot.Commitment {
+		t.Fatal("commitment should change with acl")
+	}
+}
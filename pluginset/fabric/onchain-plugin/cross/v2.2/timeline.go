@@ -0,0 +1,178 @@
+package main
+
+import (
+	"chaincodepb"
+	"crosserr"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 通道序号审计时间线
+// 对某个收发通道的序号key以及调查方指定的回执key(争议记录、乐观声明、擦除回执、防重放标记等)
+// 读取GetHistoryForKey，按交易时间合并成一条时间线，用于事故取证。
+// 节点需要开启core.ledger.history.enableHistoryDatabase；历史查询不会在验证阶段重新执行，
+// 只能用于查询。
+//
+// 长期运行的通道历史很长，按页返回，书签为下一页在时间线中的偏移。
+// 新的修改总是追加在时间线末尾，翻页期间不影响已返回的部分。
+const (
+	TIMELINE_DIRECTION_RECV = "recv"
+	TIMELINE_DIRECTION_SEND = "send"
+
+	TIMELINE_KIND_SEQ     = "seq"
+	TIMELINE_KIND_RECEIPT = "receipt"
+	TIMELINE_KIND_MESSAGE = "message"
+
+	TIMELINE_MAX_PAGE_SIZE = 100
+)
+
+type TimelineEntry struct {
+	Key       string  `json:"key"`
+	Kind      string  `json:"kind"`
+	Seq       *uint32 `json:"seq,omitempty"` // 仅序号key，删除时为空
+	Value     []byte  `json:"value,omitempty"`
+	IsDelete  bool    `json:"isDelete,omitempty"`
+	TxId      string  `json:"txId"`
+	Timestamp int64   `json:"timestamp"`
+	Nanos     int32   `json:"nanos,omitempty"`
+}
+
+type TimelinePage struct {
+	Entries []TimelineEntry `json:"entries"`
+	// 下一页的书签，空字符串表示没有更多记录
+	Bookmark string `json:"bookmark"`
+}
+
+// 只允许查询跨链链码自己的key
+func isTimelineKey(key string) bool {
+	return strings.HasPrefix(key, CROSSCHAIN_PREFIX) ||
+		strings.HasPrefix(key, "\x00"+CROSSCHAIN_PREFIX) ||
+		strings.HasPrefix(key, oraclelogic.PREFIX)
+}
+
+// 读取key的全部修改历史
+func readKeyHistory(stub shim.ChaincodeStubInterface, key string, kind string) ([]TimelineEntry, error) {
+	iter, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history for %s: %v", key, err)
+	}
+	defer iter.Close()
+
+	entries := []TimelineEntry{}
+	for iter.HasNext() {
+		km, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		entry := TimelineEntry{
+			Key:       key,
+			Kind:      kind,
+			Value:     km.GetValue(),
+			IsDelete:  km.GetIsDelete(),
+			TxId:      km.GetTxId(),
+			Timestamp: km.GetTimestamp().GetSeconds(),
+			Nanos:     km.GetTimestamp().GetNanos(),
+		}
+		if kind == TIMELINE_KIND_SEQ && !entry.IsDelete {
+			var nounce chaincodepb.MsgNounce
+			if err := proto.Unmarshal(entry.Value, &nounce); err != nil {
+				return nil, fmt.Errorf("failed to parse seq of %s: %v", key, err)
+			}
+			seq := nounce.GetSeqno()
+			entry.Seq = &seq
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// 查询通道序号和回执的修改时间线
+// args[0] recv/send
+// args[1] 来源域名(recv)或目的域名(send)
+// args[2] 发送方身份, byte32 hexstring
+// args[3] 接收方身份, byte32 hexstring
+// args[4] 每页条数，不超过TIMELINE_MAX_PAGE_SIZE
+// args[5] 书签，第一页为空字符串
+// args[6..] 回执的key(可选)
+func (bs *CrossChain) querySeqTimeline(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) < 6 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	var ids [2][32]byte
+	for i, arg := range args[2:4] {
+		id, err := hex.DecodeString(arg)
+		if err != nil || len(id) != 32 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "identity(%s) format error", arg))
+		}
+		copy(ids[i][:], id)
+	}
+	var seqKey string
+	switch args[0] {
+	case TIMELINE_DIRECTION_RECV:
+		seqKey = bs.Os.RecvSeqKey(args[1], ids[0], ids[1])
+	case TIMELINE_DIRECTION_SEND:
+		seqKey = bs.Os.SendSeqKey(args[1], ids[0], ids[1])
+	default:
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "unknown direction: %s", args[0]))
+	}
+	pageSize, err := strconv.Atoi(args[4])
+	if err != nil || pageSize <= 0 || pageSize > TIMELINE_MAX_PAGE_SIZE {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid page size: %s", args[4]))
+	}
+	offset := 0
+	if args[5] != "" {
+		if offset, err = strconv.Atoi(args[5]); err != nil || offset < 0 {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid bookmark: %s", args[5]))
+		}
+	}
+
+	timeline, err := readKeyHistory(stub, seqKey, TIMELINE_KIND_SEQ)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read key history"))
+	}
+	for _, key := range args[6:] {
+		if !isTimelineKey(key) {
+			return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid receipt key: %q", key))
+		}
+		entries, err := readKeyHistory(stub, key, TIMELINE_KIND_RECEIPT)
+		if err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read key history"))
+		}
+		timeline = append(timeline, entries...)
+	}
+	// 同一笔交易内的修改按key排序，保证各节点返回的顺序一致
+	sort.SliceStable(timeline, func(i, j int) bool {
+		a, b := timeline[i], timeline[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp < b.Timestamp
+		}
+		if a.Nanos != b.Nanos {
+			return a.Nanos < b.Nanos
+		}
+		if a.TxId != b.TxId {
+			return a.TxId < b.TxId
+		}
+		return a.Key < b.Key
+	})
+
+	page := TimelinePage{Entries: []TimelineEntry{}}
+	if offset < len(timeline) {
+		end := offset + pageSize
+		if end < len(timeline) {
+			page.Bookmark = strconv.Itoa(end)
+		} else {
+			end = len(timeline)
+		}
+		page.Entries = timeline[offset:end]
+	}
+	bz, _ := json.Marshal(page)
+	return shim.Success(bz)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"chaincodepb"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"testing"
+)
+
+// MockStub未实现GetHistoryForKey，测试中用固定的历史记录代替
+type historyStub struct {
+	*shimtest.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+func (s *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{mods: s.history[key]}, nil
+}
+
+type historyIterator struct {
+	mods []*queryresult.KeyModification
+}
+
+func (it *historyIterator) HasNext() bool { return len(it.mods) > 0 }
+func (it *historyIterator) Close() error  { return nil }
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	km := it.mods[0]
+	it.mods = it.mods[1:]
+	return km, nil
+}
+
+func TestSeqTimeline(t *testing.T) {
+	mock := shimtest.NewMockStub("crosschain", new(CrossChain))
+	stub := &historyStub{MockStub: mock, history: map[string][]*queryresult.KeyModification{}}
+	bs := NewCrossChain()
+
+	author, receiver := sha256.Sum256([]byte("sender")), sha256.Sum256([]byte("bizcc"))
+	seqKey := bs.Os.RecvSeqKey("from.com", author, receiver)
+	for i, ts := range []int64{10, 20, 30} {
+		value, _ := proto.Marshal(&chaincodepb.MsgNounce{Seqno: uint32(i)})
+		stub.history[seqKey] = append(stub.history[seqKey], &queryresult.KeyModification{
+			TxId: "seq" + string(rune('a'+i)), Value: value, Timestamp: &timestamp.Timestamp{Seconds: ts}})
+	}
+	receiptKey := eraseReceiptKey(ERASE_KIND_DISPUTE, "m1")
+	stub.history[receiptKey] = []*queryresult.KeyModification{
+		{TxId: "erase", Value: []byte("{}"), Timestamp: &timestamp.Timestamp{Seconds: 15}},
+		{TxId: "prune", IsDelete: true, Timestamp: &timestamp.Timestamp{Seconds: 25}},
+	}
+
+	query := func(bookmark string, receipts ...string) (TimelinePage, string) {
+		args := append([]string{TIMELINE_DIRECTION_RECV, "from.com", hex.EncodeToString(author[:]), hex.EncodeToString(receiver[:]), "2", bookmark}, receipts...)
+		res := bs.querySeqTimeline(stub, args)
+		var page TimelinePage
+		_ = json.Unmarshal(res.Payload, &page)
+		return page, res.Message
+	}
+
+	var txs []string
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		page, msg := query(bookmark, receiptKey)
+		if msg != "" {
+			t.Fatal(msg)
+		}
+		for _, e := range page.Entries {
+			txs = append(txs, e.TxId)
+			if e.Kind == TIMELINE_KIND_SEQ && (e.Seq == nil || *e.Seq != uint32(e.TxId[3]-'a')) {
+				t.Fatalf("unexpected seq entry: %+v", e)
+			}
+		}
+		if bookmark = page.Bookmark; bookmark == "" {
+			if pages != 2 {
+				t.Fatalf("expected 3 pages, got %d", pages+1)
+			}
+			break
+		}
+	}
+	if len(txs) != 5 || txs[0] != "seqa" || txs[1] != "erase" || txs[3] != "prune" || txs[4] != "seqc" {
+		t.Fatalf("unexpected timeline: %v", txs)
+	}
+
+	if _, msg := query("", "bizcc_counter"); msg == "" {
+		t.Fatal("keys of other chaincodes should be rejected")
+	}
+	if _, msg := query("x"); msg == "" {
+		t.Fatal("invalid bookmark should be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strconv"
+	"time"
+	"tmlightclient"
+)
+
+// Tendermint轻客户端模块
+// 中继提交Cosmos SDK链的区块头及验证人集合，链码校验签名后保存各高度的AppHash；
+// 接收消息时中继提交AM报文承诺在对方链存储中的ICS-23证明(IAVL存储证明和multistore证明)，
+// 链码校验证明能推导到已保存的AppHash后按普通消息的流程检查序号并回调业务链码。
+//
+// 对方链的AM模块在存储${storeName}中以${keyPrefix}开头的key保存sha256(AM报文)。
+const (
+	// crosschain_tm_client_${domain} -> tmlightclient.ClientState
+	K_TM_CLIENT_PREFIX = CROSSCHAIN_PREFIX + "tm_client_"
+	// crosschain_tm_consensus_${domain}_${height} -> tmlightclient.ConsensusState
+	K_TM_CONSENSUS_PREFIX = CROSSCHAIN_PREFIX + "tm_consensus_"
+	// crosschain_tm_am_${domain} -> TMAMStore
+	K_TM_AM_STORE_PREFIX = CROSSCHAIN_PREFIX + "tm_am_"
+	// crosschain_tm_consumed_${domain}_${key} -> 1
+	K_TM_CONSUMED_PREFIX = CROSSCHAIN_PREFIX + "tm_consumed_"
+)
+
+type TMAMStore struct {
+	StoreName string `json:"storeName"`
+	KeyPrefix string `json:"keyPrefix"` // hex
+}
+
+type TMLightClientStatus struct {
+	ChainID      string    `json:"chainId"`
+	LatestHeight int64     `json:"latestHeight"`
+	LatestTime   time.Time `json:"latestTime"`
+}
+
+func tmConsensusKey(domain string, height int64) string {
+	return K_TM_CONSENSUS_PREFIX + domain + "_" + strconv.FormatInt(height, 10)
+}
+
+func (bs *CrossChain) getTMClient(stub shim.ChaincodeStubInterface, domain string) (*tmlightclient.ClientState, error) {
+	var cs tmlightclient.ClientState
+	has, err := getJSONState(stub, K_TM_CLIENT_PREFIX+domain, &cs)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, crosserr.New(crosserr.CodeNotFound, "tendermint light client for domain %s not initialized", domain)
+	}
+	return &cs, nil
+}
+
+// 初始化来源域名的Tendermint轻客户端
+// args[0] 来源域名
+// args[1] chain id
+// args[2] 信任期(秒)
+// args[3] 可信区块头(json)
+// args[4] 可信区块头的下一个验证人集合(json)
+func (bs *CrossChain) initTMLightClient(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	if domain == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	trustingPeriod, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid trusting period"))
+	}
+	var header tmlightclient.Header
+	if err := json.Unmarshal([]byte(args[3]), &header); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid header"))
+	}
+	var nextVals tmlightclient.ValidatorSet
+	if err := json.Unmarshal([]byte(args[4]), &nextVals); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid validator set"))
+	}
+
+	cs, consensus, err := tmlightclient.NewClientState(args[1], trustingPeriod, &header, &nextVals)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid client state"))
+	}
+	if err := putJSONState(stub, K_TM_CLIENT_PREFIX+domain, cs); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put client state"))
+	}
+	if err := putJSONState(stub, tmConsensusKey(domain, consensus.Height), consensus); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	if err := bs.initHeaderSync(stub, domain, HEADER_SYNC_CLIENT_TM, consensus.Height, hex.EncodeToString(cs.NextValidators.Hash())); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to init header sync"))
+	}
+	return shim.Success(nil)
+}
+
+// 提交区块头
+// args[0] 来源域名
+// args[1] 区块头及commit(json)
+// args[2] 区块的验证人集合(json)
+// args[3] 区块的下一个验证人集合(json)
+func (bs *CrossChain) submitTMHeader(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 4 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain := args[0]
+	cs, err := bs.getTMClient(stub, domain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get TM client"))
+	}
+	var sh tmlightclient.SignedHeader
+	if err := json.Unmarshal([]byte(args[1]), &sh); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid signed header"))
+	}
+	var vals, nextVals tmlightclient.ValidatorSet
+	if err := json.Unmarshal([]byte(args[2]), &vals); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid validator set"))
+	}
+	if err := json.Unmarshal([]byte(args[3]), &nextVals); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid next validator set"))
+	}
+	now, err := getTxTimestamp(stub)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "failed to get tx timestamp"))
+	}
+
+	consensus, err := cs.Update(&sh, &vals, &nextVals, time.Unix(now, 0))
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "header verify failed"))
+	}
+	if err := putJSONState(stub, K_TM_CLIENT_PREFIX+domain, cs); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put client state"))
+	}
+	if err := putJSONState(stub, tmConsensusKey(domain, consensus.Height), consensus); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	if err := bs.recordSyncedHeader(stub, domain, consensus.Height, hex.EncodeToString(cs.NextValidators.Hash())); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to record synced header"))
+	}
+	return shim.Success(nil)
+}
+
+// 设置对方链AM模块的存储位置
+// args[0] 来源域名
+// args[1] 存储名称
+// args[2] key前缀(hex)
+func (bs *CrossChain) setTMAMStore(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" || args[1] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain or store name"))
+	}
+	if _, err := hex.DecodeString(args[2]); err != nil {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "invalid key prefix"))
+	}
+	if err := putJSONState(stub, K_TM_AM_STORE_PREFIX+args[0], &TMAMStore{StoreName: args[1], KeyPrefix: args[2]}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 查询轻客户端状态
+// args[0] 来源域名
+func (bs *CrossChain) queryTMLightClient(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	cs, err := bs.getTMClient(stub, args[0])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get TM client"))
+	}
+	status, _ := json.Marshal(&TMLightClientStatus{ChainID: cs.ChainID, LatestHeight: cs.LatestHeight, LatestTime: cs.LatestTime})
+	return shim.Success(status)
+}
+
+// 接收Cosmos SDK链的跨链消息
+// args[0] 来源域名
+// args[1] 证明对应的区块高度
+// args[2] AM报文在存储中的key(hex)
+// args[3] AM报文(hex)
+// args[4] ICS-23证明数组(json)，依次为IAVL存储证明和multistore证明
+func (bs *CrossChain) recvTMMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain := args[0]
+	height, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid height"))
+	}
+	key, err := hex.DecodeString(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "invalid key"))
+	}
+	pkg, err := hex.DecodeString(args[3])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "am package format error"))
+	}
+	var proofs []*tmlightclient.ExistenceProof
+	if err := json.Unmarshal([]byte(args[4]), &proofs); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "invalid proofs"))
+	}
+
+	var store TMAMStore
+	if has, err := getJSONState(stub, K_TM_AM_STORE_PREFIX+srcDomain, &store); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no am store for domain %s", srcDomain))
+	}
+	prefix, _ := hex.DecodeString(store.KeyPrefix)
+	if !bytes.HasPrefix(key, prefix) {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "key is not in am store"))
+	}
+
+	consumedKey := K_TM_CONSUMED_PREFIX + srcDomain + "_" + args[2]
+	if consumed, err := stub.GetState(consumedKey); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if len(consumed) != 0 {
+		return errorResponse("", crosserr.New(crosserr.CodeConflict, "message already received"))
+	}
+
+	var consensus tmlightclient.ConsensusState
+	if has, err := getJSONState(stub, tmConsensusKey(srcDomain, height), &consensus); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to read state"))
+	} else if !has {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no consensus state at height %d of domain %s", height, srcDomain))
+	}
+
+	commitment := sha256.Sum256(pkg)
+	if err := tmlightclient.VerifyChainedMembership(
+		[]*tmlightclient.ProofSpec{tmlightclient.IavlSpec, tmlightclient.TendermintSpec},
+		consensus.AppHash, proofs, [][]byte{key, []byte(store.StoreName)}, commitment[:]); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "commitment proof verify failed"))
+	}
+
+	ret := bs.Os.RecvAMPackage(stub, srcDomain, args[3])
+	if ret.Status != shim.OK {
+		return ret
+	}
+	if err := stub.PutState(consumedKey, []byte{1}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	var msg oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+	}
+	msgs, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{msg}})
+	return bs.callbackBizChaincode(stub, msgs)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+	"time"
+	"tmlightclient"
+)
+
+func testTMValidators(n int) ([]ed25519.PrivateKey, *tmlightclient.ValidatorSet) {
+	keys := make([]ed25519.PrivateKey, n)
+	vals := &tmlightclient.ValidatorSet{}
+	for i := range keys {
+		seed := sha256.Sum256([]byte{byte(i)})
+		keys[i] = ed25519.NewKeyFromSeed(seed[:])
+		pubkey := keys[i].Public().(ed25519.PublicKey)
+		address := sha256.Sum256(pubkey)
+		vals.Validators = append(vals.Validators, tmlightclient.Validator{
+			Address:     address[:20],
+			PubKey:      tmlightclient.PubKey{Type: tmlightclient.ED25519_PUBKEY_TYPE, Value: pubkey},
+			VotingPower: 10,
+		})
+	}
+	return keys, vals
+}
+
+func testTMHeader(height int64, ts time.Time, vals *tmlightclient.ValidatorSet, appHash []byte) tmlightclient.Header {
+	return tmlightclient.Header{
+		Version:            tmlightclient.Version{Block: 11},
+		ChainID:            "cosmos-test",
+		Height:             height,
+		Time:               ts,
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		AppHash:            appHash,
+	}
+}
+
+// signers[i]为true的验证人对区块签名
+func testTMSignedHeader(header tmlightclient.Header, keys []ed25519.PrivateKey, vals *tmlightclient.ValidatorSet, signers ...bool) *tmlightclient.SignedHeader {
+	sh := &tmlightclient.SignedHeader{Header: header}
+	sh.Commit.Height = header.Height
+	sh.Commit.BlockID = tmlightclient.BlockID{Hash: header.Hash(), PartSetHeader: tmlightclient.PartSetHeader{Total: 1, Hash: make([]byte, 32)}}
+	for i, key := range keys {
+		sig := tmlightclient.CommitSig{BlockIDFlag: tmlightclient.BLOCK_ID_FLAG_ABSENT}
+		if signers[i] {
+			sig.BlockIDFlag = tmlightclient.BLOCK_ID_FLAG_COMMIT
+			sig.ValidatorAddress = vals.Validators[i].Address
+			sig.Timestamp = header.Time.Add(time.Second)
+			signBytes := tmlightclient.VoteSignBytes(header.ChainID, tmlightclient.PRECOMMIT_TYPE, header.Height, 0, &sh.Commit.BlockID, sig.Timestamp)
+			sig.Signature = ed25519.Sign(key, signBytes)
+		}
+		sh.Commit.Signatures = append(sh.Commit.Signatures, sig)
+	}
+	return sh
+}
+
+// 构造两级ICS-23证明: IAVL存储证明和multistore证明，返回AppHash
+func testICS23Proofs(t *testing.T, storeName string, key, value []byte) ([]byte, []*tmlightclient.ExistenceProof) {
+	sibling := sha256.Sum256([]byte("sibling"))
+	iavl := &tmlightclient.ExistenceProof{
+		Key:   key,
+		Value: value,
+		Leaf:  tmlightclient.IavlSpec.LeafSpec,
+		Path: []tmlightclient.InnerOp{{
+			Hash:   tmlightclient.HASH_OP_SHA256,
+			Prefix: []byte{0x02, 0x04, 0x02, 0x20},
+			Suffix: append([]byte{0x20}, sibling[:]...),
+		}},
+	}
+	iavl.Leaf.Prefix = []byte{0x00, 0x02, 0x02}
+	storeRoot, err := iavl.Calculate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multistore := &tmlightclient.ExistenceProof{
+		Key:   []byte(storeName),
+		Value: storeRoot,
+		Leaf:  tmlightclient.TendermintSpec.LeafSpec,
+		Path: []tmlightclient.InnerOp{{
+			Hash:   tmlightclient.HASH_OP_SHA256,
+			Prefix: append([]byte{0x01}, sibling[:]...),
+		}},
+	}
+	appHash, err := multistore.Calculate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return appHash, []*tmlightclient.ExistenceProof{iavl, multistore}
+}
+
+func mustJSON(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+func TestTMLightClient(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkg := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "cosmos hello")[0]
+	rawPkg, _ := hex.DecodeString(pkg)
+	commitment := sha256.Sum256(rawPkg)
+	storeKey := []byte("am/seq/1")
+	appHash, proofs := testICS23Proofs(t, "crosschain", storeKey, commitment[:])
+
+	keys, vals := testTMValidators(4)
+	now := time.Now().UTC().Truncate(time.Second)
+	trusted := testTMHeader(10, now.Add(-100*time.Second), vals, nil)
+	if res := InvokeWithStrings(t, stub, sp, "initTMLightClient", "cosmos.test", "cosmos-test", "86400", mustJSON(&trusted), mustJSON(vals)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setTMAMStore", "cosmos.test", "crosschain", hex.EncodeToString([]byte("am/"))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	header := testTMHeader(11, now.Add(-50*time.Second), vals, appHash)
+	if res := InvokeWithStrings(t, stub, sp, "recvTMMessage", "cosmos.test", "11", hex.EncodeToString(storeKey), pkg, mustJSON(proofs)); res.Status == shim.OK {
+		t.Fatal("message at unknown height should be rejected")
+	}
+
+	// 2/4签名不足
+	weak := testTMSignedHeader(header, keys, vals, true, true, false, false)
+	if res := InvokeWithStrings(t, stub, sp, "submitTMHeader", "cosmos.test", mustJSON(weak), mustJSON(vals), mustJSON(vals)); res.Status == shim.OK {
+		t.Fatal("header without 2/3 signatures should be rejected")
+	}
+	// 篡改已签名的区块头
+	forged := testTMSignedHeader(header, keys, vals, true, true, true, false)
+	forged.Header.AppHash = make([]byte, 32)
+	if res := InvokeWithStrings(t, stub, sp, "submitTMHeader", "cosmos.test", mustJSON(forged), mustJSON(vals), mustJSON(vals)); res.Status == shim.OK {
+		t.Fatal("forged header should be rejected")
+	}
+	signed := testTMSignedHeader(header, keys, vals, true, true, true, false)
+	if res := InvokeWithStrings(t, stub, sp, "submitTMHeader", "cosmos.test", mustJSON(signed), mustJSON(vals), mustJSON(vals)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := InvokeWithStrings(t, stub, sp, "queryTMLightClient", "cosmos.test")
+	if !strings.Contains(string(res.Payload), `"latestHeight":11`) {
+		t.Fatalf("unexpected status: %s", res.Payload)
+	}
+
+	// 错误的key和报文
+	if res := InvokeWithStrings(t, stub, sp, "recvTMMessage", "cosmos.test", "11", hex.EncodeToString([]byte("am/seq/2")), pkg, mustJSON(proofs)); res.Status == shim.OK {
+		t.Fatal("proof for another key should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvTMMessage", "cosmos.test", "11", hex.EncodeToString(storeKey), pkg+"00", mustJSON(proofs)); res.Status == shim.OK {
+		t.Fatal("tampered package should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvTMMessage", "cosmos.test", "11", hex.EncodeToString(storeKey), pkg, mustJSON(proofs)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "cosmos hello") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvTMMessage", "cosmos.test", "11", hex.EncodeToString(storeKey), pkg, mustJSON(proofs)); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+}
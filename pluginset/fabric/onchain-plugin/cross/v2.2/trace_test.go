@@ -0,0 +1,181 @@
+package main
+
+import (
+	"am"
+	"bytes"
+	"crossevent"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"reflect"
+	"testing"
+	"tracecontext"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// 带transient和签名提案的stub，模拟业务链码的客户端在提案中带上traceparent
+type tracedStub struct {
+	*shimtest.MockStub
+	transient map[string][]byte
+	sp        *pb.SignedProposal
+}
+
+func (s *tracedStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *tracedStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return s.sp, nil
+}
+
+func TestSDPExtension(t *testing.T) {
+	ext := &oraclelogic.SDPExtension{TraceParent: testTraceParent}
+	v1 := &oraclelogic.SDPMessage{Version: oraclelogic.SDP_VERSION_1, TargetDomain: "dest.com", Sequence: 3, Payload: []byte("v1"), Extension: ext}
+	v2 := testSDPMessageV2("v2", 5)
+	v2.Extension = ext
+	for _, msg := range []*oraclelogic.SDPMessage{v1, v2} {
+		raw, err := msg.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version, _ := oraclelogic.DecodeSDPVersion(raw); version != oraclelogic.SDP_VERSION_EXTENDED {
+			t.Fatalf("unexpected version %d", version)
+		}
+		decoded, err := oraclelogic.DecodeSDPMessage(raw)
+		if err != nil || !reflect.DeepEqual(decoded, msg) || decoded.TraceParent() != testTraceParent {
+			t.Fatalf("unexpected sdp decoding: %+v %v", decoded, err)
+		}
+		// 去掉扩展即为原报文
+		plain := *msg
+		plain.Extension = nil
+		inner, _ := plain.Encode()
+		if !bytes.HasPrefix(raw, inner) {
+			t.Fatal("extended sdp message should start with the inner message")
+		}
+
+		// 嵌套的扩展、截断的扩展和空扩展
+		nested := append(append([]byte{}, raw...), raw[len(inner):]...)
+		for _, bad := range [][]byte{
+			nested,
+			raw[len(raw)-8:],
+			append(append([]byte{}, inner...), 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff),
+		} {
+			if _, err := oraclelogic.DecodeSDPMessage(bad); err == nil {
+				t.Fatalf("malformed extended sdp message should be rejected: %x", bad)
+			}
+		}
+	}
+}
+
+// 发送消息时把提案中的traceparent派生为消息的span写入SDP扩展字段，接收时带到接收事件中
+func TestTraceParentPropagation(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setEventVersion", "2"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	bs := NewCrossChain()
+	receiver := sha256.Sum256([]byte("dest"))
+	send := func(transient map[string][]byte) (oraclelogic.OutboundMessage, *crossevent.CrossChainEventV2) {
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		ts := &tracedStub{MockStub: stub, transient: transient, sp: sp}
+		res := bs.sendMessage(ts, []string{"dest.com", hex.EncodeToString(receiver[:]), "traced"}, oraclelogic.K_MSG_TYPE_ORDERED)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		if err := bs.emitOutboundEvent(ts, res.Payload); err != nil {
+			t.Fatal(err)
+		}
+		var msg oraclelogic.OutboundMessage
+		_ = json.Unmarshal(res.Payload, &msg)
+		events, err := crossevent.Decode((<-stub.ChaincodeEventsChannel).Payload)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("unexpected events: %v %v", events, err)
+		}
+		return msg, events[0]
+	}
+	sdpOf := func(pkg []byte) *oraclelogic.SDPMessage {
+		amMsg, err := am.Decode(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sdp, err := oraclelogic.DecodeSDPMessage(amMsg.GetPayload())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sdp
+	}
+
+	// 没有traceparent(或不合法)时仍发送不带扩展的v1报文
+	for _, transient := range []map[string][]byte{nil, {tracecontext.TRANSIENT_KEY: []byte("garbage")}} {
+		msg, event := send(transient)
+		if sdp := sdpOf(msg.Package); sdp.Version != oraclelogic.SDP_VERSION_1 || sdp.Extension != nil || event.TraceParent != "" {
+			t.Fatalf("unexpected untraced message: %+v %+v", sdp, event)
+		}
+	}
+
+	parent, _ := tracecontext.Parse(testTraceParent)
+	msg, event := send(map[string][]byte{tracecontext.TRANSIENT_KEY: []byte(testTraceParent)})
+	sdp := sdpOf(msg.Package)
+	span := parent.Child([]byte(txid), []byte(msg.Key)).String()
+	if sdp.Version != oraclelogic.SDP_VERSION_1 || string(sdp.Payload) != "traced" || sdp.TraceParent() != span || span == testTraceParent {
+		t.Fatalf("unexpected traced message: %+v", sdp)
+	}
+	if event.TraceParent != span {
+		t.Fatalf("unexpected send event traceparent %s", event.TraceParent)
+	}
+
+	// 对端链发来的带扩展的报文
+	in := testSDPMessageV2("traced", 0)
+	in.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+	in.Extension = &oraclelogic.SDPExtension{TraceParent: span}
+	raw, _ := in.Encode()
+	pkg := hex.EncodeToString(oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), raw))
+	parsed, _, ret := bs.Os.ParseAMPackage(stub, "src.com", pkg)
+	if ret.Status != shim.OK || parsed.TraceParent != span {
+		t.Fatalf("unexpected parsed message: %+v %s", parsed, ret.Message)
+	}
+	relay := parent.Child([]byte("relay")).String()
+	other := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	for _, c := range []struct {
+		transient map[string][]byte
+		want      string
+	}{
+		// 中继延续了报文中的链路
+		{map[string][]byte{tracecontext.TRANSIENT_KEY: []byte(relay)}, relay},
+		// 中继的span属于另一条链路时保留报文中的链路
+		{map[string][]byte{tracecontext.TRANSIENT_KEY: []byte(other)}, span},
+		{nil, span},
+	} {
+		stub.MockTransactionStart(txid)
+		got, err := bs.recvEvent(&tracedStub{MockStub: stub, transient: c.transient, sp: sp}, parsed)
+		stub.MockTransactionEnd(txid)
+		if err != nil || got.TraceParent != c.want {
+			t.Fatalf("unexpected recv event traceparent %s, want %s", got.TraceParent, c.want)
+		}
+	}
+
+	// 报文不带链路时使用中继的span
+	parsed.TraceParent = ""
+	got, _ := bs.recvEvent(&tracedStub{MockStub: stub, transient: map[string][]byte{tracecontext.TRANSIENT_KEY: []byte(other)}}, parsed)
+	if got.TraceParent != other {
+		t.Fatalf("unexpected recv event traceparent %s", got.TraceParent)
+	}
+
+	// 接收端完整处理带扩展的报文
+	if res := InvokeWithStrings(t, stub, sp, "recvMessage", "svc", mockRecvRawData(t, "src.com", pkg)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	events, err := crossevent.Decode((<-stub.ChaincodeEventsChannel).Payload)
+	if err != nil || len(events) != 1 || events[0].TraceParent != span {
+		t.Fatalf("unexpected recv events: %v %v", events, err)
+	}
+}
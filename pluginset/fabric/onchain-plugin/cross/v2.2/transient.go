@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// 通过transient提交报文
+// recvMessage的原始报文可以放在transient map的rawdata中提交，报文不会出现在区块的提案载荷里。
+// 交易参数中带上 sha256(rawdata)(hex) 时，链码校验transient中的报文与之一致，区块中留下的
+// 摘要由背书节点一同签名，事后可以用报文原文核对；只传oracle service id的旧用法不做绑定。
+const (
+	TRANSIENT_RAWDATA = "rawdata"
+)
+
+// 取出recvMessage的报文，返回 [oracle service id, rawdata]
+func transientRecvArgs(stub shim.ChaincodeStubInterface, args []string) ([]string, error) {
+	trans, err := stub.GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient: %v", err)
+	}
+	rawdata, ok := trans[TRANSIENT_RAWDATA]
+	if !ok {
+		if len(args) == 1 {
+			return nil, fmt.Errorf("missing %s in transient", TRANSIENT_RAWDATA)
+		}
+		// 报文在交易参数中
+		return args, nil
+	}
+	h := sha256.Sum256(rawdata)
+	switch len(args) {
+	case 1:
+	case 2:
+		if args[1] != hex.EncodeToString(h[:]) {
+			return nil, fmt.Errorf("transient %s does not match hash %s", TRANSIENT_RAWDATA, args[1])
+		}
+	default:
+		return nil, fmt.Errorf("Wrong length of args: %v", len(args))
+	}
+	fmt.Printf("recvMessage with transient data, sha256: %x\n", h)
+	return []string{args[0], string(rawdata)}, nil
+}
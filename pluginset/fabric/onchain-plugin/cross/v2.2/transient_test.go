@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestTransientRecvMessage(t *testing.T) {
+	stub, sp, _, _ := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	rawdata := func(seq uint32) []byte {
+		msg := testSDPMessageV2("transient", seq)
+		msg.AtomicFlag = oraclelogic.SDP_ATOMIC_NONE
+		sdp, _ := msg.Encode()
+		am := oraclelogic.TestBuildAuthMessage(sha256.Sum256([]byte("sender")), sdp)
+		return []byte(mockRecvRawData(t, "src.com", hex.EncodeToString(am)))
+	}
+	recv := func(transient map[string][]byte, args ...string) pb.Response {
+		stub.MockTransactionStart(txid)
+		defer stub.MockTransactionEnd(txid)
+		return NewCrossChain().recvMessage(&transientStub{MockStub: stub, transient: transient}, args)
+	}
+
+	raw := rawdata(0)
+	h := sha256.Sum256(raw)
+	hash := hex.EncodeToString(h[:])
+	for _, c := range []struct {
+		transient map[string][]byte
+		args      []string
+		err       string
+	}{
+		{map[string][]byte{TRANSIENT_RAWDATA: raw}, []string{"svc", sha256Hex([]byte("other"))}, "does not match"},
+		{map[string][]byte{TRANSIENT_RAWDATA: raw}, []string{"svc", hash, ""}, "Wrong length"},
+		{map[string][]byte{}, []string{"svc"}, "missing rawdata"},
+	} {
+		if res := recv(c.transient, c.args...); res.Status == shim.OK || !strings.Contains(res.Message, c.err) {
+			t.Fatalf("expected error %q, got %s", c.err, res.Message)
+		}
+	}
+	if res := recv(map[string][]byte{TRANSIENT_RAWDATA: raw}, "svc", hash); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 只传oracle service id的旧用法
+	if res := recv(map[string][]byte{TRANSIENT_RAWDATA: rawdata(1)}, "svc"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	// 没有transient时报文在交易参数中
+	if res := recv(nil, "svc", string(rawdata(2))); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/hex"
+	"oraclelogic/v2.2"
+	"strings"
+)
+
+// 无序消息投递
+// 序号为0xffffffff的无序消息不检查通道序号，业务链码通过recvUnorderedMessage接收。
+// 无序消息按报文去重，与有序消息共用receipt.go的接收回执，同一报文再次提交时拒绝。
+// 不经过AM报文解析的消息没有报文哈希，回执以消息摘要代替报文哈希，通过queryReceipt查询。
+//
+// AM报文不含随机数，同一发送方向同一接收方发送内容相同的无序消息会被当作重复消息，
+// 需要重复发送时由业务在内容中加入随机数。
+
+// 消息摘要 sha256(from + "\n" + sender(hex) + "\n" + receiver(hex) + "\n" + content)
+func unorderedMessageDigest(msg oraclelogic.RecvAuthMessage) string {
+	return sha256Hex([]byte(strings.Join([]string{
+		msg.From, hex.EncodeToString(msg.Identity[:]), hex.EncodeToString(msg.Receiver[:]), string(msg.Content),
+	}, "\n")))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+func TestUnorderedDelivery(t *testing.T) {
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	unordered := func(content string) oraclelogic.RecvAuthMessage {
+		return oraclelogic.RecvAuthMessage{From: "from.com", Identity: sha256.Sum256([]byte("sender")), Content: []byte(content),
+			Receiver: sha256.Sum256([]byte("bizcc")), MsgType: oraclelogic.K_MSG_TYPE_UNORDERED, Seq: oraclelogic.K_UNORDERED_MSG_SEQ}
+	}
+	deliver := func(msgs ...oraclelogic.RecvAuthMessage) string {
+		raw, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: msgs})
+		return InvokeWithStrings(t, stub, sp, "testCallbackBizChaincode", string(raw)).Message
+	}
+
+	// 不检查序号，有序消息的通道序号不受影响
+	if msg := deliver(unordered("u1")); msg != "" {
+		t.Fatal(msg)
+	}
+	if msg := deliver(unordered("u2"), testOrderedMessage("m0", 0)); msg != "" {
+		t.Fatal(msg)
+	}
+	// 有序消息没有报文哈希时不写回执
+	if res := InvokeWithStrings(t, stub, sp, "queryReceipt", "from.com", unorderedMessageDigest(testOrderedMessage("m0", 0))); res.Status == shim.OK {
+		t.Fatal("ordered message without packet hash should not have a receipt")
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastUnorderedMsg"); !strings.HasSuffix(string(res.Payload), ":u2") {
+		t.Fatalf("unexpected last unordered message: %s", res.Payload)
+	}
+
+	if msg := deliver(unordered("u1")); !strings.Contains(msg, "replayed packet") {
+		t.Fatalf("duplicate unordered message should be rejected, got %q", msg)
+	}
+	if msg := deliver(unordered("u3"), unordered("u3")); !strings.Contains(msg, "duplicated packet") {
+		t.Fatalf("duplicate unordered message in one transaction should be rejected, got %q", msg)
+	}
+
+	// 没有报文哈希的无序消息以消息摘要写入接收回执
+	digest := unorderedMessageDigest(unordered("u1"))
+	res := InvokeWithStrings(t, stub, sp, "queryReceipt", "from.com", digest)
+	var receipt MessageReceipt
+	if err := json.Unmarshal(res.Payload, &receipt); err != nil {
+		t.Fatal(res.Message)
+	}
+	sender := sha256.Sum256([]byte("sender"))
+	if receipt.PacketHash != digest || receipt.Sender != hex.EncodeToString(sender[:]) || receipt.MsgType != oraclelogic.K_MSG_TYPE_UNORDERED || receipt.TxId == "" {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+	if res := InvokeWithStrings(t, stub, sp, "queryReceipt", "from.com", "unknown"); res.Status == shim.OK {
+		t.Fatal("unknown receipt should not be found")
+	}
+}
+
+func TestUnorderedPacketHash(t *testing.T) {
+	stub, sp := NewAdminCrossChainStub(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkg := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_UNORDERED, "hello")[0]
+	stub.MockTransactionStart("tx")
+	msg, seq, ret := NewCrossChain().Os.ParseAMPackage(stub, "src.com", pkg)
+	stub.MockTransactionEnd("tx")
+	if ret.Status != shim.OK {
+		t.Fatal(ret.Message)
+	}
+	raw, _ := hex.DecodeString(pkg)
+	if seq != oraclelogic.K_UNORDERED_MSG_SEQ || msg.MsgType != oraclelogic.K_MSG_TYPE_UNORDERED || msg.PacketHash != sha256Hex(raw) {
+		t.Fatalf("unexpected unordered message: %+v", msg)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"am"
+	"cctest"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+	"testing"
+	"upperprotocol"
+)
+
+// 测试用的上层协议：协议消息为文本，收到后写入状态
+type echoProtocol struct{}
+
+func (echoProtocol) ProtocolType() uint32 { return 7 }
+
+func (echoProtocol) OnAMReceive(stub shim.ChaincodeStubInterface, senderDomain string, sender [32]byte, payload []byte) pb.Response {
+	if string(payload) == "fail" {
+		return shim.Error("rejected by protocol")
+	}
+	if err := stub.PutState("last", []byte(fmt.Sprintf("%s:%x:%s", senderDomain, sender, payload))); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// args[0] 目的域名，args[1] 文本
+func (echoProtocol) BuildAMPayload(stub shim.ChaincodeStubInterface, args []string) (string, []byte, error) {
+	if len(args) != 2 {
+		return "", nil, fmt.Errorf("wrong length of args: %d", len(args))
+	}
+	return args[0], []byte(args[1]), nil
+}
+
+// 协议链码的调用约定见vendor/upperprotocol，这里检查经跨链链码登记、发送和投递的完整流程
+func TestUpperProtocolChaincode(t *testing.T) {
+	cross, _ := newCCTestStubs(t)
+	protocc := cctest.NewMockStub("protocc", upperprotocol.NewChaincode(echoProtocol{}, "crosscc"))
+	cross.AddPeer(protocc)
+	protocc.AddPeer(cross)
+	if res := cross.InvokeWithStrings(upperprotocol.RegisterArgs(7, "protocc")...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// 协议链码编码后经跨链链码发出，发送者身份为协议链码
+	res := protocc.InvokeWithStrings("sendAuthMessage", "dest.com", "hello")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var sent oraclelogic.OutboundMessage
+	if err := json.Unmarshal(res.Payload, &sent); err != nil || sent.DestDomain != "dest.com" {
+		t.Fatalf("unexpected outbound message: %v %s", err, res.Payload)
+	}
+	msg, err := am.Decode(sent.Package)
+	if err != nil || msg.GetProtocolType() != 7 || msg.GetAuthor() != sha256.Sum256([]byte("protocc")) || string(msg.GetPayload()) != "hello" {
+		t.Fatalf("unexpected auth message: %v %+v", err, msg)
+	}
+	if string(cross.State[sent.Key]) != string(sent.Package) {
+		t.Fatal("auth message should be saved by cross chaincode")
+	}
+
+	// 跨链链码收到的协议消息交给协议链码
+	sender := sha256.Sum256([]byte("sender"))
+	if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("src.com", cctest.BuildProtocolAMPackage(sender, 7, []byte("world")))...); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if got := string(protocc.State["last"]); got != "src.com:"+hex.EncodeToString(sender[:])+":world" {
+		t.Fatalf("unexpected protocol message: %s", got)
+	}
+	if res := cross.InvokeWithStrings(cctest.RecvMessageArgs("src.com", cctest.BuildProtocolAMPackage(sender, 7, []byte("fail")))...); res.Status == shim.OK {
+		t.Fatal("message rejected by protocol should fail")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 链码逻辑只通过shim.ChaincodeStubInterface访问账本，它是shim中Handler(与peer之间的gRPC流)的抽象。
+// 单元测试除了shimtest.MockStub，也可以使用下面生成的testify mock逐个设置调用期望，
+// 不需要gRPC流，也不需要MockStub维护的内存状态。
+//go:generate mockery --srcpkg github.com/hyperledger/fabric-chaincode-go/shim --name ChaincodeStubInterface --structname MockChaincodeStub --output . --outpkg main --filename mock_chaincode_stub_test.go
+//go:generate mockery --srcpkg github.com/hyperledger/fabric-chaincode-go/shim --name StateQueryIteratorInterface --structname MockStateQueryIterator --output . --outpkg main --filename mock_state_query_iterator_test.go
+//go:generate mockery --srcpkg github.com/hyperledger/fabric-chaincode-go/shim --name HistoryQueryIteratorInterface --structname MockHistoryQueryIterator --output . --outpkg main --filename mock_history_query_iterator_test.go
+
+const (
+	// 跨链链码自身维护的状态key前缀，与oraclelogic的"oraclelogic_"前缀区分
+	CROSSCHAIN_PREFIX = "crosschain_"
+)
+
+// 获取交易发起者的身份
+// 返回值: MSP ID, 证书sha256哈希(hex)
+func getCreatorIdentity(stub shim.ChaincodeStubInterface) (string, string, error) {
+	ci, err := cid.New(stub)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse creator: %v", err)
+	}
+	mspId, err := ci.GetMSPID()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get creator msp id: %v", err)
+	}
+	cert, err := ci.GetX509Certificate()
+	if err != nil || cert == nil {
+		return "", "", errors.New("creator is not identified by a x509 certificate")
+	}
+	certHash := sha256.Sum256(cert.Raw)
+	return mspId, hex.EncodeToString(certHash[:]), nil
+}
+
+// 获取交易提案直接调用的链码名
+// 业务链码调用跨链链码时，返回的是业务链码的名字
+func getProposalChaincode(stub shim.ChaincodeStubInterface) (string, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	var proposal pb.Proposal
+	if err := proto.Unmarshal(signedProposal.GetProposalBytes(), &proposal); err != nil {
+		return "", fmt.Errorf("failed to parse proposal: %v", err)
+	}
+	var header comm.Header
+	if err := proto.Unmarshal(proposal.GetHeader(), &header); err != nil {
+		return "", fmt.Errorf("failed to parse proposal header: %v", err)
+	}
+	var channelHeader comm.ChannelHeader
+	if err := proto.Unmarshal(header.GetChannelHeader(), &channelHeader); err != nil {
+		return "", fmt.Errorf("failed to parse channel header: %v", err)
+	}
+	var ext pb.ChaincodeHeaderExtension
+	if err := proto.Unmarshal(channelHeader.GetExtension(), &ext); err != nil {
+		return "", fmt.Errorf("failed to parse chaincode header extension: %v", err)
+	}
+	if ext.GetChaincodeId().GetName() == "" {
+		return "", errors.New("proposal chaincode not found")
+	}
+	return ext.GetChaincodeId().GetName(), nil
+}
+
+// 获取交易时间戳(秒)
+// 链码内无法获取区块高度，所有时间窗口统一以交易时间戳计算
+func getTxTimestamp(stub shim.ChaincodeStubInterface) (int64, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	return ts.GetSeconds(), nil
+}
+
+// 读取json格式的state，key不存在时返回false
+func getJSONState(stub shim.ChaincodeStubInterface, key string, v interface{}) (bool, error) {
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get state %s: %v", key, err)
+	}
+	if len(raw) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal state %s: %v", key, err)
+	}
+	return true, nil
+}
+
+// 以json格式写入state
+func putJSONState(stub shim.ChaincodeStubInterface, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state %s: %v", key, err)
+	}
+	if err := stub.PutState(key, raw); err != nil {
+		return fmt.Errorf("failed to put state %s: %v", key, err)
+	}
+	return nil
+}
+
+// 构造带错误码的错误响应，见crosserr
+func errorResponse(fn string, err error) pb.Response {
+	status, msg := crosserr.Response(fn, err)
+	return pb.Response{Status: status, Message: msg}
+}
+
+// 解析PEM格式的公钥，支持ECDSA和Ed25519
+func parsePublicKeyPEM(pemKey string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// 校验签名，ECDSA签名为ASN.1编码
+func verifySignature(key interface{}, digest []byte, signature []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest, signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, digest, signature)
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+var (
+	_ shim.ChaincodeStubInterface        = (*MockChaincodeStub)(nil)
+	_ shim.StateQueryIteratorInterface   = (*MockStateQueryIterator)(nil)
+	_ shim.HistoryQueryIteratorInterface = (*MockHistoryQueryIterator)(nil)
+)
+
+func TestJSONStateWithStubMock(t *testing.T) {
+	stub := new(MockChaincodeStub)
+	stub.On("GetState", "missing").Return(nil, nil)
+	stub.On("GetState", "broken").Return([]byte("{"), nil)
+	stub.On("GetState", "failed").Return(nil, errors.New("ledger unavailable"))
+	stub.On("PutState", "key", []byte(`{"maxAge":1,"maxCount":2,"headSeq":0,"tailSeq":0}`)).Return(nil)
+
+	var policy RetentionPolicy
+	if has, err := getJSONState(stub, "missing", &policy); has || err != nil {
+		t.Fatalf("missing key: %v %v", has, err)
+	}
+	if _, err := getJSONState(stub, "broken", &policy); err == nil {
+		t.Fatal("invalid json should fail")
+	}
+	if _, err := getJSONState(stub, "failed", &policy); err == nil {
+		t.Fatal("ledger error should be returned")
+	}
+	if err := putJSONState(stub, "key", &RetentionPolicy{MaxAge: 1, MaxCount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	stub.AssertExpectations(t)
+}
+
+// 不使用MockStub，直接设置保留策略登记的读写期望
+func TestTrackRetentionWithStubMock(t *testing.T) {
+	stub := new(MockChaincodeStub)
+	policy, _ := json.Marshal(&RetentionPolicy{MaxCount: 10, HeadSeq: 4, TailSeq: 2})
+	stub.On("GetState", K_RETENTION_PREFIX+"from.com").Return(policy, nil)
+	stub.On("GetState", K_RETENTION_PREFIX+"other.com").Return(nil, nil)
+	stub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 100}, nil)
+	stub.On("PutState", retentionEntryKey("from.com", 4), mock.MatchedBy(func(raw []byte) bool {
+		var entry RetentionEntry
+		return json.Unmarshal(raw, &entry) == nil && entry.Key == "k" && entry.CreatedAt == 100
+	})).Return(nil).Once()
+	stub.On("PutState", K_RETENTION_PREFIX+"from.com", mock.MatchedBy(func(raw []byte) bool {
+		var updated RetentionPolicy
+		return json.Unmarshal(raw, &updated) == nil && updated.HeadSeq == 5 && updated.TailSeq == 2
+	})).Return(nil).Once()
+
+	bs := NewCrossChain()
+	if err := bs.trackRetention(stub, "from.com", &RetentionEntry{Kind: ERASE_KIND_DISPUTE, Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	// 未配置保留策略时不写入
+	if err := bs.trackRetention(stub, "other.com", &RetentionEntry{Kind: ERASE_KIND_DISPUTE, Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	stub.AssertExpectations(t)
+	stub.AssertNumberOfCalls(t, "PutState", 2)
+}
+
+func TestReadKeyHistoryWithIteratorMock(t *testing.T) {
+	iter := new(MockHistoryQueryIterator)
+	iter.On("HasNext").Return(true).Once()
+	iter.On("HasNext").Return(false).Once()
+	iter.On("Next").Return(&queryresult.KeyModification{TxId: "tx", IsDelete: true, Timestamp: &timestamp.Timestamp{Seconds: 7}}, nil).Once()
+	iter.On("Close").Return(nil)
+	stub := new(MockChaincodeStub)
+	stub.On("GetHistoryForKey", "k").Return(iter, nil)
+
+	entries, err := readKeyHistory(stub, "k", TIMELINE_KIND_SEQ)
+	if err != nil || len(entries) != 1 || entries[0].TxId != "tx" || entries[0].Seq != nil || entries[0].Timestamp != 7 {
+		t.Fatalf("unexpected history: %+v %v", entries, err)
+	}
+	iter.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crosserr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"oraclelogic/v2.2"
+)
+
+// 零知识证明校验模块
+// PTC输出zk有效性证明的来源域名可以配置zk路由，中继提交AM报文和证明，
+// 链码调用注册的校验器校验证明后，再按普通消息的流程检查序号并回调业务链码。
+//
+// 接入具体的证明系统(如BN254上的Groth16)时，在本包内新增文件实现zkProofVerifier，
+// 并在init()中调用registerZKProofVerifier注册，不需要修改接收流程：
+//
+//	func init() {
+//		registerZKProofVerifier("groth16-bn254", &groth16Verifier{})
+//	}
+const (
+	// crosschain_zk_route_${domain} -> ZKRoute
+	K_ZK_ROUTE_PREFIX = CROSSCHAIN_PREFIX + "zk_route_"
+)
+
+type ZKRoute struct {
+	Scheme       string `json:"scheme"`       // 证明系统，对应注册的校验器
+	VerifyingKey string `json:"verifyingKey"` // hex
+}
+
+// 零知识证明校验器
+// publicInputs依次为: sha256(AM报文), 来源域名
+// 批量证明的publicInputs见batch_proof.go
+type zkProofVerifier interface {
+	Verify(vk []byte, proof []byte, publicInputs [][]byte) error
+}
+
+var zkProofVerifiers = map[string]zkProofVerifier{}
+
+func registerZKProofVerifier(scheme string, verifier zkProofVerifier) {
+	zkProofVerifiers[scheme] = verifier
+}
+
+func (bs *CrossChain) getZKRoute(stub shim.ChaincodeStubInterface, domain string) (*ZKRoute, error) {
+	var route ZKRoute
+	has, err := getJSONState(stub, K_ZK_ROUTE_PREFIX+domain, &route)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// 设置来源域名的zk路由
+// args[0] 来源域名
+// args[1] 证明系统，为空表示删除路由
+// args[2] 验证密钥(hex)
+func (bs *CrossChain) setZKRoute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	domain, scheme := args[0], args[1]
+	if domain == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "empty domain"))
+	}
+	if scheme == "" {
+		if err := stub.DelState(K_ZK_ROUTE_PREFIX + domain); err != nil {
+			return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to delete zk route"))
+		}
+		return shim.Success(nil)
+	}
+
+	if _, ok := zkProofVerifiers[scheme]; !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "zk proof scheme %s is not supported", scheme))
+	}
+	if _, err := hex.DecodeString(args[2]); err != nil || args[2] == "" {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "verifying key format error"))
+	}
+	if err := putJSONState(stub, K_ZK_ROUTE_PREFIX+domain, &ZKRoute{Scheme: scheme, VerifyingKey: args[2]}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to put state"))
+	}
+	return shim.Success(nil)
+}
+
+// 接收附带zk有效性证明的跨链消息
+// args[0] 来源域名
+// args[1] AM报文(hex)
+// args[2] zk证明(hex)
+func (bs *CrossChain) recvZKMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse("", crosserr.New(crosserr.CodeInvalidArgs, "Wrong length of args: %v", len(args)))
+	}
+	srcDomain, amPkt := args[0], args[1]
+	pkg, err := hex.DecodeString(amPkt)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "am package format error"))
+	}
+	proof, err := hex.DecodeString(args[2])
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInvalidArgs, err, "proof format error"))
+	}
+
+	route, err := bs.getZKRoute(stub, srcDomain)
+	if err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeLedger, err, "failed to get ZK route"))
+	}
+	if route == nil {
+		return errorResponse("", crosserr.New(crosserr.CodeNotFound, "no zk route for domain %s", srcDomain))
+	}
+	verifier, ok := zkProofVerifiers[route.Scheme]
+	if !ok {
+		return errorResponse("", crosserr.New(crosserr.CodeVerify, "zk proof scheme %s is not supported", route.Scheme))
+	}
+	vk, _ := hex.DecodeString(route.VerifyingKey)
+
+	pkgHash := sha256.Sum256(pkg)
+	if err := verifier.Verify(vk, proof, [][]byte{pkgHash[:], []byte(srcDomain)}); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeVerify, err, "zk proof verify failed"))
+	}
+
+	ret := bs.Os.RecvAMPackage(stub, srcDomain, amPkt)
+	if ret.Status != shim.OK {
+		return ret
+	}
+	var msg oraclelogic.RecvAuthMessage
+	if err := json.Unmarshal(ret.Payload, &msg); err != nil {
+		return errorResponse("", crosserr.Wrap(crosserr.CodeInternal, err, "unexpected message"))
+	}
+	msgs, _ := json.Marshal(oraclelogic.RecvAuthMessages{Message: []oraclelogic.RecvAuthMessage{msg}})
+	return bs.callbackBizChaincode(stub, msgs)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"oraclelogic/v2.2"
+	"strings"
+	"testing"
+)
+
+// 测试用的校验器：证明为sha256(vk || publicInputs...)
+type hashZKVerifier struct{}
+
+func (v *hashZKVerifier) Verify(vk []byte, proof []byte, publicInputs [][]byte) error {
+	if !bytes.Equal(hashZKProof(vk, publicInputs), proof) {
+		return errors.New("invalid proof")
+	}
+	return nil
+}
+
+func hashZKProof(vk []byte, publicInputs [][]byte) []byte {
+	h := sha256.New()
+	h.Write(vk)
+	for _, input := range publicInputs {
+		h.Write(input)
+	}
+	return h.Sum(nil)
+}
+
+func TestRecvZKMessage(t *testing.T) {
+	registerZKProofVerifier("test-hash", &hashZKVerifier{})
+	defer delete(zkProofVerifiers, "test-hash")
+
+	stub, sp, stubbiz, bizsp := NewBizCrossChainStubs(t)
+	if res := InvokeWithStrings(t, stub, sp, "oracleAdminManage", "setExpectedDomain", "fabric.test"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	pkg := MockAMPackages(t, "fabric.test", sha256.Sum256([]byte("bizcc")), oraclelogic.K_MSG_TYPE_ORDERED, "zk hello")[0]
+
+	if res := InvokeWithStrings(t, stub, sp, "setZKRoute", "src.com", "groth16-bn254", "01"); res.Status == shim.OK {
+		t.Fatal("unregistered scheme should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "setZKRoute", "src.com", "test-hash", "01"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	raw, _ := hex.DecodeString(pkg)
+	pkgHash := sha256.Sum256(raw)
+	proof := hashZKProof([]byte{0x01}, [][]byte{pkgHash[:], []byte("src.com")})
+
+	if res := InvokeWithStrings(t, stub, sp, "recvZKMessage", "src.com", pkg, "00"); res.Status == shim.OK {
+		t.Fatal("invalid proof should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvZKMessage", "other.com", pkg, hex.EncodeToString(proof)); res.Status == shim.OK {
+		t.Fatal("domain without zk route should be rejected")
+	}
+	if res := InvokeWithStrings(t, stub, sp, "recvZKMessage", "src.com", pkg, hex.EncodeToString(proof)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := InvokeWithStrings(t, stubbiz, bizsp, "getLastMsg"); !strings.Contains(string(res.Payload), "zk hello") {
+		t.Fatalf("unexpected last msg: %s", res.Payload)
+	}
+
+	// 序号已消耗，重放失败
+	if res := InvokeWithStrings(t, stub, sp, "recvZKMessage", "src.com", pkg, hex.EncodeToString(proof)); res.Status == shim.OK {
+		t.Fatal("replayed message should be rejected")
+	}
+}
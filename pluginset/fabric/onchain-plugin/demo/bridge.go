@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ${K_REMOTE_BRIDGE_OBJECT_TYPE}~${domain} -> 对端代币桥身份(hex)
+	K_REMOTE_BRIDGE_OBJECT_TYPE = PREFIX + "remote_bridge"
+
+	// ${K_WRAPPED_OBJECT_TYPE}~${domain}~${account} -> 来源于domain的包装代币数额
+	K_WRAPPED_OBJECT_TYPE = PREFIX + "wrapped"
+
+	// ${K_WRAPPED_SUPPLY_OBJECT_TYPE}~${domain} -> 来源于domain的包装代币总量
+	K_WRAPPED_SUPPLY_OBJECT_TYPE = PREFIX + "wrapped_supply"
+
+	// ${K_LOCKED_OBJECT_TYPE}~${domain} -> 转往domain后锁定的原生代币
+	K_LOCKED_OBJECT_TYPE = PREFIX + "locked"
+
+	// ${K_RECEIPT_OBJECT_TYPE}~${domain}~${nonce} -> Receipt
+	K_RECEIPT_OBJECT_TYPE = PREFIX + "receipt"
+
+	// 与跨链链码的发送事件同名，转发跨链链码返回的消息记录
+	// 业务链码调用跨链链码时Fabric只保留业务链码的事件
+	K_OUTBOUND_EVENT = "crosschain_outbound"
+
+	OP_MINT   = "MINT"
+	OP_UNLOCK = "UNLOCK"
+)
+
+// 代币桥之间的报文，作为SDP消息内容
+type TransferPacket struct {
+	Op       string `json:"op"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+	// 发送交易的txid，与来源域名一起唯一确定报文
+	Nonce string `json:"nonce"`
+}
+
+type Receipt struct {
+	Domain   string `json:"domain"`
+	Nonce    string `json:"nonce"`
+	Op       string `json:"op"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+	TxId     string `json:"txId"`
+}
+
+// 获取交易提案直接调用的链码名，跨链链码回调时为跨链链码
+func getProposalChaincode(stub shim.ChaincodeStubInterface) (string, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	var proposal pb.Proposal
+	if err := proto.Unmarshal(signedProposal.GetProposalBytes(), &proposal); err != nil {
+		return "", fmt.Errorf("failed to parse proposal: %v", err)
+	}
+	var header comm.Header
+	if err := proto.Unmarshal(proposal.GetHeader(), &header); err != nil {
+		return "", fmt.Errorf("failed to parse proposal header: %v", err)
+	}
+	var channelHeader comm.ChannelHeader
+	if err := proto.Unmarshal(header.GetChannelHeader(), &channelHeader); err != nil {
+		return "", fmt.Errorf("failed to parse channel header: %v", err)
+	}
+	var ext pb.ChaincodeHeaderExtension
+	if err := proto.Unmarshal(channelHeader.GetExtension(), &ext); err != nil {
+		return "", fmt.Errorf("failed to parse chaincode header extension: %v", err)
+	}
+	if ext.GetChaincodeId().GetName() == "" {
+		return "", errors.New("proposal chaincode not found")
+	}
+	return ext.GetChaincodeId().GetName(), nil
+}
+
+func (tb *TokenBridge) getRemoteBridge(stub shim.ChaincodeStubInterface, domain string) (string, error) {
+	key, err := stub.CreateCompositeKey(K_REMOTE_BRIDGE_OBJECT_TYPE, []string{domain})
+	if err != nil {
+		return "", err
+	}
+	remote, err := stub.GetState(key)
+	if err != nil {
+		return "", err
+	}
+	if len(remote) == 0 {
+		return "", fmt.Errorf("no token bridge registered for domain %s", domain)
+	}
+	return string(remote), nil
+}
+
+func (tb *TokenBridge) setRemoteBridge(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return shim.Error("empty domain")
+	}
+	if err := checkAccount(args[1]); err != nil {
+		return shim.Error(fmt.Sprintf("invalid bridge identity: %s", args[1]))
+	}
+	key, err := stub.CreateCompositeKey(K_REMOTE_BRIDGE_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState(key, []byte(strings.ToLower(args[1]))); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 经跨链链码向对端代币桥发送报文，并转发发送事件
+func (tb *TokenBridge) sendPacket(stub shim.ChaincodeStubInterface, domain string, packet *TransferPacket) pb.Response {
+	remote, err := tb.getRemoteBridge(stub, domain)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	crosscc, err := stub.GetState(K_CROSS_CHAINCODE)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	bz, _ := json.Marshal(packet)
+	re := stub.InvokeChaincode(string(crosscc), [][]byte{
+		[]byte("sendMessage"), // 有序消息
+		[]byte(domain),
+		[]byte(remote),
+		bz,
+	}, stub.GetChannelID())
+	if re.Status != shim.OK {
+		return shim.Error(fmt.Sprintf("failed to send message: %s", re.Message))
+	}
+	if err := stub.SetEvent(K_OUTBOUND_EVENT, []byte("["+string(re.Payload)+"]")); err != nil {
+		return shim.Error(err.Error())
+	}
+	return re
+}
+
+// 解析跨链转账的参数
+func parseTransferArgs(stub shim.ChaincodeStubInterface, args []string) (string, uint64, error) {
+	if len(args) != 3 {
+		return "", 0, fmt.Errorf("Wrong length of args: %v", len(args))
+	}
+	if err := checkAccount(args[1]); err != nil {
+		return "", 0, err
+	}
+	amount, err := parseAmount(args[2])
+	if err != nil {
+		return "", 0, err
+	}
+	sender, err := clientAccount(stub)
+	if err != nil {
+		return "", 0, err
+	}
+	return sender, amount, nil
+}
+
+// 锁定原生代币，对端铸造包装代币
+func (tb *TokenBridge) crossChainTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	sender, amount, err := parseTransferArgs(stub, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := balanceKey(stub, sender)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, key, amount, true); err != nil {
+		return shim.Error(fmt.Sprintf("balance of %s: %v", sender, err))
+	}
+	lockedKey, err := stub.CreateCompositeKey(K_LOCKED_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, lockedKey, amount, false); err != nil {
+		return shim.Error(err.Error())
+	}
+	return tb.sendPacket(stub, args[0], &TransferPacket{
+		Op: OP_MINT, Sender: sender, Receiver: strings.ToLower(args[1]), Amount: amount, Nonce: stub.GetTxID(),
+	})
+}
+
+// 销毁包装代币，来源链解锁原生代币
+func (tb *TokenBridge) crossChainReturn(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	sender, amount, err := parseTransferArgs(stub, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := stub.CreateCompositeKey(K_WRAPPED_OBJECT_TYPE, []string{args[0], sender})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, key, amount, true); err != nil {
+		return shim.Error(fmt.Sprintf("wrapped balance of %s: %v", sender, err))
+	}
+	supplyKey, err := stub.CreateCompositeKey(K_WRAPPED_SUPPLY_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, supplyKey, amount, true); err != nil {
+		return shim.Error(err.Error())
+	}
+	return tb.sendPacket(stub, args[0], &TransferPacket{
+		Op: OP_UNLOCK, Sender: sender, Receiver: strings.ToLower(args[1]), Amount: amount, Nonce: stub.GetTxID(),
+	})
+}
+
+// 接收对端代币桥的报文
+func (tb *TokenBridge) recvMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	domain, identity := args[0], strings.ToLower(args[1])
+	fmt.Printf("TokenBridge recv message from domain:%s, identity:%s, msg:%s\n", domain, identity, args[2])
+
+	crosscc, err := stub.GetState(K_CROSS_CHAINCODE)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	caller, err := getProposalChaincode(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if caller != string(crosscc) {
+		return shim.Error(fmt.Sprintf("message must be delivered by %s, got %s", crosscc, caller))
+	}
+	remote, err := tb.getRemoteBridge(stub, domain)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if remote != identity {
+		return shim.Error(fmt.Sprintf("unknown sender %s from domain %s", identity, domain))
+	}
+
+	var packet TransferPacket
+	if err := json.Unmarshal([]byte(args[2]), &packet); err != nil {
+		return shim.Error(fmt.Sprintf("invalid packet: %v", err))
+	}
+	if err := checkAccount(packet.Receiver); err != nil {
+		return shim.Error(err.Error())
+	}
+	if packet.Amount == 0 || packet.Nonce == "" {
+		return shim.Error(fmt.Sprintf("invalid packet: %s", args[2]))
+	}
+
+	// 回执防重放
+	receiptKey, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, []string{domain, packet.Nonce})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if raw, err := stub.GetState(receiptKey); err != nil {
+		return shim.Error(err.Error())
+	} else if len(raw) != 0 {
+		return shim.Error(fmt.Sprintf("packet %s from %s is already processed", packet.Nonce, domain))
+	}
+
+	switch packet.Op {
+	case OP_MINT:
+		err = tb.mintWrapped(stub, domain, packet.Receiver, packet.Amount)
+	case OP_UNLOCK:
+		err = tb.unlock(stub, domain, packet.Receiver, packet.Amount)
+	default:
+		err = fmt.Errorf("unknown op %s", packet.Op)
+	}
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bz, _ := json.Marshal(&Receipt{
+		Domain: domain, Nonce: packet.Nonce, Op: packet.Op, Receiver: packet.Receiver, Amount: packet.Amount, TxId: stub.GetTxID(),
+	})
+	if err := stub.PutState(receiptKey, bz); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func (tb *TokenBridge) mintWrapped(stub shim.ChaincodeStubInterface, domain, receiver string, amount uint64) error {
+	supplyKey, err := stub.CreateCompositeKey(K_WRAPPED_SUPPLY_OBJECT_TYPE, []string{domain})
+	if err != nil {
+		return err
+	}
+	if err := addAmount(stub, supplyKey, amount, false); err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(K_WRAPPED_OBJECT_TYPE, []string{domain, receiver})
+	if err != nil {
+		return err
+	}
+	return addAmount(stub, key, amount, false)
+}
+
+// 解锁的数额不能超过此前转往该域名锁定的数额
+func (tb *TokenBridge) unlock(stub shim.ChaincodeStubInterface, domain, receiver string, amount uint64) error {
+	lockedKey, err := stub.CreateCompositeKey(K_LOCKED_OBJECT_TYPE, []string{domain})
+	if err != nil {
+		return err
+	}
+	if err := addAmount(stub, lockedKey, amount, true); err != nil {
+		return fmt.Errorf("locked amount for %s: %v", domain, err)
+	}
+	key, err := balanceKey(stub, receiver)
+	if err != nil {
+		return err
+	}
+	return addAmount(stub, key, amount, false)
+}
+
+func (tb *TokenBridge) wrappedBalanceOf(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	return tb.queryAmount(stub, K_WRAPPED_OBJECT_TYPE, args)
+}
+
+func (tb *TokenBridge) lockedOf(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	return tb.queryAmount(stub, K_LOCKED_OBJECT_TYPE, args)
+}
+
+func (tb *TokenBridge) queryAmount(stub shim.ChaincodeStubInterface, objectType string, attrs []string) pb.Response {
+	key, err := stub.CreateCompositeKey(objectType, attrs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	amount, err := getAmount(stub, key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte(strconv.FormatUint(amount, 10)))
+}
+
+func (tb *TokenBridge) queryReceipt(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	key, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	receipt, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(receipt) == 0 {
+		return shim.Error(fmt.Sprintf("receipt of %s from %s not found", args[1], args[0]))
+	}
+	return shim.Success(receipt)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 记录发送消息的跨链链码
+type mockCrossChaincode struct {
+	sent [][]string
+}
+
+func (cc *mockCrossChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (cc *mockCrossChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	if fn != "sendMessage" {
+		return shim.Error("Method not found")
+	}
+	cc.sent = append(cc.sent, args)
+	return shim.Success([]byte(`{"key":"msg_` + strconv.Itoa(len(cc.sent)) + `"}`))
+}
+
+// 自签名证书的creator，返回creator和账户
+func mockCreator(t *testing.T, name string) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{
+		Mspid: "Org1MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	h := sha256.Sum256(der)
+	return creator, hex.EncodeToString(h[:])
+}
+
+func mockSignedProposal(ccname string) *pb.SignedProposal {
+	ext, _ := proto.Marshal(&pb.ChaincodeHeaderExtension{ChaincodeId: &pb.ChaincodeID{Name: ccname}})
+	channelHeader, _ := proto.Marshal(&comm.ChannelHeader{Extension: ext})
+	header, _ := proto.Marshal(&comm.Header{ChannelHeader: channelHeader})
+	proposal, _ := proto.Marshal(&pb.Proposal{Header: header})
+	return &pb.SignedProposal{ProposalBytes: proposal}
+}
+
+type bridgeChain struct {
+	t     *testing.T
+	stub  *shimtest.MockStub
+	cross *mockCrossChaincode
+	txid  int
+}
+
+func newBridgeChain(t *testing.T, name string, admin []byte) *bridgeChain {
+	c := &bridgeChain{t: t, stub: shimtest.NewMockStub(name, NewTokenBridge()), cross: &mockCrossChaincode{}}
+	c.stub.MockPeerChaincode("cross", shimtest.NewMockStub("cross", c.cross), "")
+	c.stub.Creator = admin
+	if res := c.stub.MockInit("init", [][]byte{[]byte("init"), []byte("Token " + name), []byte("T"), []byte("cross")}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	return c
+}
+
+func (c *bridgeChain) invoke(creator []byte, caller string, args ...string) pb.Response {
+	var bargs [][]byte
+	for _, arg := range args {
+		bargs = append(bargs, []byte(arg))
+	}
+	c.txid++
+	c.stub.Creator = creator
+	return c.stub.MockInvokeWithSignedProposal("tx"+strconv.Itoa(c.txid), bargs, mockSignedProposal(caller))
+}
+
+func (c *bridgeChain) query(args ...string) string {
+	res := c.invoke(c.stub.Creator, "tokenbridge", args...)
+	if res.Status != shim.OK {
+		c.t.Fatal(res.Message)
+	}
+	return string(res.Payload)
+}
+
+// 最后一次发送消息的参数: 目的域名、接收方、报文
+func (c *bridgeChain) lastPacket() []string {
+	return c.cross.sent[len(c.cross.sent)-1]
+}
+
+func TestTokenBridge(t *testing.T) {
+	alice, aliceAccount := mockCreator(t, "alice")
+	bob, bobAccount := mockCreator(t, "bob")
+	a, b := newBridgeChain(t, "tokenA", alice), newBridgeChain(t, "tokenB", bob)
+	bridgeA, bridgeB := sha256.Sum256([]byte("tokenA")), sha256.Sum256([]byte("tokenB"))
+	if res := a.invoke(alice, "tokenA", "setRemoteBridge", "chainB", hex.EncodeToString(bridgeB[:])); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := b.invoke(bob, "tokenB", "setRemoteBridge", "chainA", hex.EncodeToString(bridgeA[:])); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := a.invoke(alice, "tokenA", "mint", aliceAccount, "100"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if event := <-a.stub.ChaincodeEventsChannel; event.EventName != EVENT_TRANSFER {
+		t.Fatalf("unexpected event: %s", event.EventName)
+	}
+	if res := a.invoke(bob, "tokenA", "mint", bobAccount, "100"); res.Status == shim.OK {
+		t.Fatal("non-admin should not mint")
+	}
+
+	// A链锁定，B链铸造包装代币
+	if res := a.invoke(alice, "tokenA", "crossChainTransfer", "chainB", bobAccount, "30"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	sent := a.lastPacket()
+	if sent[0] != "chainB" || sent[1] != hex.EncodeToString(bridgeB[:]) {
+		t.Fatalf("unexpected message: %v", sent)
+	}
+	if event := <-a.stub.ChaincodeEventsChannel; event.EventName != K_OUTBOUND_EVENT || string(event.Payload) != `[{"key":"msg_1"}]` {
+		t.Fatalf("unexpected event: %s %s", event.EventName, event.Payload)
+	}
+	if a.query("balanceOf", aliceAccount) != "70" || a.query("lockedOf", "chainB") != "30" || a.query("totalSupply") != "100" {
+		t.Fatal("unexpected balance after lock")
+	}
+	if res := b.invoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), sent[2]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if b.query("wrappedBalanceOf", "chainA", bobAccount) != "30" {
+		t.Fatal("unexpected wrapped balance")
+	}
+	var packet TransferPacket
+	_ = json.Unmarshal([]byte(sent[2]), &packet)
+	var receipt Receipt
+	if err := json.Unmarshal([]byte(b.query("queryReceipt", "chainA", packet.Nonce)), &receipt); err != nil || receipt.Op != OP_MINT || receipt.Amount != 30 {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+
+	// 重放、非跨链链码调用、未登记的发送方
+	if res := b.invoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), sent[2]); res.Status == shim.OK {
+		t.Fatal("replayed packet should be rejected")
+	}
+	if res := b.invoke(bob, "tokenB", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), sent[2]); res.Status == shim.OK {
+		t.Fatal("packet should be delivered by cross chaincode")
+	}
+	if res := b.invoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeB[:]), sent[2]); res.Status == shim.OK {
+		t.Fatal("unknown sender should be rejected")
+	}
+
+	// B链销毁包装代币，A链解锁
+	if res := b.invoke(bob, "tokenB", "crossChainReturn", "chainA", aliceAccount, "10"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if b.query("wrappedBalanceOf", "chainA", bobAccount) != "20" {
+		t.Fatal("unexpected wrapped balance after burn")
+	}
+	if res := a.invoke(alice, "cross", "recvUnorderedMessage", "chainB", hex.EncodeToString(bridgeB[:]), b.lastPacket()[2]); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if a.query("balanceOf", aliceAccount) != "80" || a.query("lockedOf", "chainB") != "20" {
+		t.Fatal("unexpected balance after unlock")
+	}
+
+	// 解锁不能超过锁定的数额
+	forged, _ := json.Marshal(&TransferPacket{Op: OP_UNLOCK, Receiver: bobAccount, Amount: 21, Nonce: "forged"})
+	if res := a.invoke(alice, "cross", "recvMessage", "chainB", hex.EncodeToString(bridgeB[:]), string(forged)); res.Status == shim.OK {
+		t.Fatal("unlock should not exceed locked amount")
+	}
+	if res := b.invoke(bob, "tokenB", "crossChainReturn", "chainA", aliceAccount, "21"); res.Status == shim.OK {
+		t.Fatal("burn should not exceed wrapped balance")
+	}
+}
+
+func TestTokenTransfer(t *testing.T) {
+	alice, aliceAccount := mockCreator(t, "alice")
+	bob, bobAccount := mockCreator(t, "bob")
+	a := newBridgeChain(t, "tokenA", alice)
+	if res := a.invoke(alice, "tokenA", "mint", aliceAccount, "50"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := a.invoke(alice, "tokenA", "transfer", bobAccount, "20"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := a.invoke(bob, "tokenA", "approve", aliceAccount, "5"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := a.invoke(alice, "tokenA", "transferFrom", bobAccount, aliceAccount, "5"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if a.query("balanceOf", aliceAccount) != "35" || a.query("balanceOf", bobAccount) != "15" || a.query("allowance", bobAccount, aliceAccount) != "0" {
+		t.Fatal("unexpected balance after transfer")
+	}
+	if res := a.invoke(alice, "tokenA", "transferFrom", bobAccount, aliceAccount, "1"); res.Status == shim.OK {
+		t.Fatal("transfer should not exceed allowance")
+	}
+	if res := a.invoke(bob, "tokenA", "transfer", aliceAccount, "16"); res.Status == shim.OK {
+		t.Fatal("transfer should not exceed balance")
+	}
+}
@@ -0,0 +1,7 @@
+module demo
+
+go 1.16
+
+require (
+
+)
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 跨链代币桥示例链码
+// 同一份链码部署在两条链上，每条链有自己的原生代币(ERC20风格)。
+//   - 转出原生代币: 锁定在本链，经跨链链码发送MINT报文，对端为接收方铸造本链的包装代币
+//   - 转回包装代币: 销毁包装代币，发送UNLOCK报文，对端解锁此前锁定的原生代币
+//
+// 账户为交易提交者证书的sha256(hex)，与SDP报文中32字节身份的长度一致。
+// 接收报文时校验调用来自跨链链码、来源为登记的对端代币桥，并按(来源域名, nonce)记录回执，
+// 同一报文不会重复入账。
+func main() {
+	if err := shim.Start(NewTokenBridge()); err != nil {
+		fmt.Printf("Error starting token bridge chaincode: %s", err)
+	}
+}
+
+type TokenBridge struct {
+}
+
+func NewTokenBridge() *TokenBridge {
+	return &TokenBridge{}
+}
+
+// 初始化
+// args[0] 代币名称
+// args[1] 代币符号
+// args[2] 跨链链码名
+func (tb *TokenBridge) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) == 0 {
+		// 升级时不带参数，保留原有配置
+		return shim.Success(nil)
+	}
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	admin, err := clientAccount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, kv := range [][2]string{{K_NAME, args[0]}, {K_SYMBOL, args[1]}, {K_CROSS_CHAINCODE, args[2]}, {K_ADMIN, admin}} {
+		if err := stub.PutState(kv[0], []byte(kv[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (tb *TokenBridge) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	fmt.Printf("TokenBridge invoked func %s\n", fn)
+
+	switch fn {
+
+	// 代币名称、符号和本链原生代币总量
+	case "name":
+		return tb.getConfig(stub, K_NAME)
+	case "symbol":
+		return tb.getConfig(stub, K_SYMBOL)
+	case "totalSupply":
+		return tb.totalSupply(stub, args)
+
+	// 当前提交者的账户
+	case "clientAccountID":
+		account, err := clientAccount(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(account))
+
+	// args[0] 账户
+	case "balanceOf":
+		return tb.balanceOf(stub, args)
+
+	// args[0] 接收方账户
+	// args[1] 数额
+	case "transfer":
+		return tb.transfer(stub, args)
+
+	// args[0] 被授权账户
+	// args[1] 数额
+	case "approve":
+		return tb.approve(stub, args)
+
+	// args[0] 授权账户
+	// args[1] 被授权账户
+	case "allowance":
+		return tb.allowance(stub, args)
+
+	// args[0] 转出账户
+	// args[1] 接收方账户
+	// args[2] 数额
+	case "transferFrom":
+		return tb.transferFrom(stub, args)
+
+	// 管理员铸造原生代币
+	// args[0] 接收方账户
+	// args[1] 数额
+	case "mint":
+		if err := checkAdmin(stub); err != nil {
+			return shim.Error(err.Error())
+		}
+		return tb.mint(stub, args)
+
+	// 管理员登记对端链上的代币桥
+	// args[0] 对端域名
+	// args[1] 对端代币桥身份，32字节hex。对端为Fabric时是代币桥链码名的sha256
+	case "setRemoteBridge":
+		if err := checkAdmin(stub); err != nil {
+			return shim.Error(err.Error())
+		}
+		return tb.setRemoteBridge(stub, args)
+
+	// 锁定原生代币并转出到对端链
+	// args[0] 对端域名
+	// args[1] 对端的接收方账户
+	// args[2] 数额
+	case "crossChainTransfer":
+		return tb.crossChainTransfer(stub, args)
+
+	// 销毁包装代币并转回来源链
+	// args[0] 包装代币的来源域名
+	// args[1] 来源链的接收方账户
+	// args[2] 数额
+	case "crossChainReturn":
+		return tb.crossChainReturn(stub, args)
+
+	// 跨链链码回调，接收对端代币桥的报文
+	// args[0] 来源域名
+	// args[1] 来源身份(hex)
+	// args[2] 报文
+	case "recvMessage", "recvUnorderedMessage":
+		return tb.recvMessage(stub, args)
+
+	// args[0] 来源域名
+	// args[1] 账户
+	case "wrappedBalanceOf":
+		return tb.wrappedBalanceOf(stub, args)
+
+	// 转往对端域名后锁定在本链的原生代币
+	// args[0] 对端域名
+	case "lockedOf":
+		return tb.lockedOf(stub, args)
+
+	// 查询报文的入账回执
+	// args[0] 来源域名
+	// args[1] 报文nonce
+	case "queryReceipt":
+		return tb.queryReceipt(stub, args)
+
+	default:
+		return shim.Error("Method not found")
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	PREFIX = "nftbridge_"
+
+	K_CROSS_CHAINCODE = PREFIX + "cross_chaincode"
+	K_ADMIN           = PREFIX + "admin"
+
+	// ${K_ASSET_OBJECT_TYPE}~${id} -> Asset，在本链流通的资产
+	K_ASSET_OBJECT_TYPE = PREFIX + "asset"
+
+	// ${K_ESCROW_OBJECT_TYPE}~${id} -> Escrow，转出到其他链的资产
+	K_ESCROW_OBJECT_TYPE = PREFIX + "escrow"
+
+	EVENT_TRANSFER = "Transfer"
+
+	ACTION_MINT      = "MINT"
+	ACTION_TRANSFER  = "TRANSFER"
+	ACTION_CROSS_OUT = "CROSS_OUT"
+	ACTION_CROSS_IN  = "CROSS_IN"
+)
+
+// 资产的一次流转
+type Provenance struct {
+	Action string `json:"action"`
+	// 跨链流转时为对端域名
+	Domain    string `json:"domain,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to"`
+	TxId      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type Asset struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	// 铸造时给定的JSON对象，跨链时原样携带
+	Metadata json.RawMessage `json:"metadata"`
+	// 铸造资产的域名，本链铸造时为空
+	Origin  string        `json:"origin,omitempty"`
+	History []*Provenance `json:"history"`
+}
+
+type Escrow struct {
+	// 资产转往的域名，只接受从该域名转回
+	Domain string `json:"domain"`
+	Asset  *Asset `json:"asset"`
+}
+
+// 交易提交者的账户: 证书sha256(hex)
+func clientAccount(stub shim.ChaincodeStubInterface) (string, error) {
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil || cert == nil {
+		return "", errors.New("creator is not identified by a x509 certificate")
+	}
+	h := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(h[:]), nil
+}
+
+func checkAdmin(stub shim.ChaincodeStubInterface) error {
+	account, err := clientAccount(stub)
+	if err != nil {
+		return err
+	}
+	admin, err := stub.GetState(K_ADMIN)
+	if err != nil {
+		return err
+	}
+	if string(admin) != account {
+		return fmt.Errorf("%s is not admin", account)
+	}
+	return nil
+}
+
+// 账户为32字节hex
+func checkAccount(account string) error {
+	raw, err := hex.DecodeString(account)
+	if err != nil || len(raw) != 32 {
+		return fmt.Errorf("invalid account: %s", account)
+	}
+	return nil
+}
+
+// 元数据须为JSON对象
+func checkMetadata(metadata []byte) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(metadata, &obj); err != nil || obj == nil {
+		return fmt.Errorf("metadata must be a json object: %s", metadata)
+	}
+	return nil
+}
+
+// 读取JSON记录，不存在时返回false
+func getRecord(stub shim.ChaincodeStubInterface, objectType, id string, v interface{}) (bool, error) {
+	key, err := stub.CreateCompositeKey(objectType, []string{id})
+	if err != nil {
+		return false, err
+	}
+	raw, err := stub.GetState(key)
+	if err != nil || len(raw) == 0 {
+		return false, err
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+func putRecord(stub shim.ChaincodeStubInterface, objectType, id string, v interface{}) error {
+	key, err := stub.CreateCompositeKey(objectType, []string{id})
+	if err != nil {
+		return err
+	}
+	bz, _ := json.Marshal(v)
+	return stub.PutState(key, bz)
+}
+
+func delRecord(stub shim.ChaincodeStubInterface, objectType, id string) error {
+	key, err := stub.CreateCompositeKey(objectType, []string{id})
+	if err != nil {
+		return err
+	}
+	return stub.DelState(key)
+}
+
+// 本链流通的资产，不存在时报错
+func getAsset(stub shim.ChaincodeStubInterface, id string) (*Asset, error) {
+	var asset Asset
+	found, err := getRecord(stub, K_ASSET_OBJECT_TYPE, id, &asset)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("asset %s not found", id)
+	}
+	return &asset, nil
+}
+
+// 资产ID在本链是否已被占用，包括转出托管中的资产
+func assetExists(stub shim.ChaincodeStubInterface, id string) (bool, error) {
+	for _, objectType := range []string{K_ASSET_OBJECT_TYPE, K_ESCROW_OBJECT_TYPE} {
+		key, err := stub.CreateCompositeKey(objectType, []string{id})
+		if err != nil {
+			return false, err
+		}
+		raw, err := stub.GetState(key)
+		if err != nil {
+			return false, err
+		}
+		if len(raw) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 追加流转记录
+func appendProvenance(stub shim.ChaincodeStubInterface, asset *Asset, p *Provenance) error {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	p.TxId = stub.GetTxID()
+	p.Timestamp = ts.GetSeconds()
+	asset.History = append(asset.History, p)
+	return nil
+}
+
+// 写入资产并设置转移事件
+func putAsset(stub shim.ChaincodeStubInterface, asset *Asset) error {
+	if err := putRecord(stub, K_ASSET_OBJECT_TYPE, asset.ID, asset); err != nil {
+		return err
+	}
+	bz, _ := json.Marshal(asset.History[len(asset.History)-1])
+	return stub.SetEvent(EVENT_TRANSFER, bz)
+}
+
+func (nb *NFTBridge) mintAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return shim.Error("empty asset id")
+	}
+	if err := checkAccount(args[1]); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkMetadata([]byte(args[2])); err != nil {
+		return shim.Error(err.Error())
+	}
+	exists, err := assetExists(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if exists {
+		return shim.Error(fmt.Sprintf("asset %s already exists", args[0]))
+	}
+	asset := &Asset{ID: args[0], Owner: args[1], Metadata: json.RawMessage(args[2])}
+	if err := appendProvenance(stub, asset, &Provenance{Action: ACTION_MINT, To: args[1]}); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := putAsset(stub, asset); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 取出提交者持有的资产
+func ownedAsset(stub shim.ChaincodeStubInterface, id string) (*Asset, error) {
+	owner, err := clientAccount(stub)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := getAsset(stub, id)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Owner != owner {
+		return nil, fmt.Errorf("asset %s is not owned by %s", id, owner)
+	}
+	return asset, nil
+}
+
+func (nb *NFTBridge) transferAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if err := checkAccount(args[1]); err != nil {
+		return shim.Error(err.Error())
+	}
+	asset, err := ownedAsset(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := appendProvenance(stub, asset, &Provenance{Action: ACTION_TRANSFER, From: asset.Owner, To: args[1]}); err != nil {
+		return shim.Error(err.Error())
+	}
+	asset.Owner = args[1]
+	if err := putAsset(stub, asset); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func (nb *NFTBridge) queryAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	asset, err := getAsset(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	bz, _ := json.Marshal(asset)
+	return shim.Success(bz)
+}
+
+func (nb *NFTBridge) queryEscrow(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	var escrow Escrow
+	found, err := getRecord(stub, K_ESCROW_OBJECT_TYPE, args[0], &escrow)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !found {
+		return shim.Error(fmt.Sprintf("escrow of asset %s not found", args[0]))
+	}
+	bz, _ := json.Marshal(&escrow)
+	return shim.Success(bz)
+}
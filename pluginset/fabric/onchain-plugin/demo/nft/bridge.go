@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strings"
+)
+
+const (
+	// ${K_REMOTE_BRIDGE_OBJECT_TYPE}~${domain} -> 对端资产桥身份(hex)
+	K_REMOTE_BRIDGE_OBJECT_TYPE = PREFIX + "remote_bridge"
+
+	// ${K_RECEIPT_OBJECT_TYPE}~${domain}~${nonce} -> Receipt
+	K_RECEIPT_OBJECT_TYPE = PREFIX + "receipt"
+
+	// 与跨链链码的发送事件同名，转发跨链链码返回的消息记录
+	// 业务链码调用跨链链码时Fabric只保留业务链码的事件
+	K_OUTBOUND_EVENT = "crosschain_outbound"
+)
+
+// 资产桥之间的报文，作为SDP消息内容
+type AssetPacket struct {
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	// 转出时的资产快照，包括元数据和流转历史
+	Asset *Asset `json:"asset"`
+	// 发送交易的txid，与来源域名一起唯一确定报文
+	Nonce string `json:"nonce"`
+}
+
+type Receipt struct {
+	Domain   string `json:"domain"`
+	Nonce    string `json:"nonce"`
+	AssetId  string `json:"assetId"`
+	Receiver string `json:"receiver"`
+	// 资产由本链托管中释放，而不是在本链重建
+	Released bool   `json:"released"`
+	TxId     string `json:"txId"`
+}
+
+// 获取交易提案直接调用的链码名，跨链链码回调时为跨链链码
+func getProposalChaincode(stub shim.ChaincodeStubInterface) (string, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	var proposal pb.Proposal
+	if err := proto.Unmarshal(signedProposal.GetProposalBytes(), &proposal); err != nil {
+		return "", fmt.Errorf("failed to parse proposal: %v", err)
+	}
+	var header comm.Header
+	if err := proto.Unmarshal(proposal.GetHeader(), &header); err != nil {
+		return "", fmt.Errorf("failed to parse proposal header: %v", err)
+	}
+	var channelHeader comm.ChannelHeader
+	if err := proto.Unmarshal(header.GetChannelHeader(), &channelHeader); err != nil {
+		return "", fmt.Errorf("failed to parse channel header: %v", err)
+	}
+	var ext pb.ChaincodeHeaderExtension
+	if err := proto.Unmarshal(channelHeader.GetExtension(), &ext); err != nil {
+		return "", fmt.Errorf("failed to parse chaincode header extension: %v", err)
+	}
+	if ext.GetChaincodeId().GetName() == "" {
+		return "", errors.New("proposal chaincode not found")
+	}
+	return ext.GetChaincodeId().GetName(), nil
+}
+
+func (nb *NFTBridge) getRemoteBridge(stub shim.ChaincodeStubInterface, domain string) (string, error) {
+	key, err := stub.CreateCompositeKey(K_REMOTE_BRIDGE_OBJECT_TYPE, []string{domain})
+	if err != nil {
+		return "", err
+	}
+	remote, err := stub.GetState(key)
+	if err != nil {
+		return "", err
+	}
+	if len(remote) == 0 {
+		return "", fmt.Errorf("no nft bridge registered for domain %s", domain)
+	}
+	return string(remote), nil
+}
+
+func (nb *NFTBridge) setRemoteBridge(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if args[0] == "" {
+		return shim.Error("empty domain")
+	}
+	if err := checkAccount(args[1]); err != nil {
+		return shim.Error(fmt.Sprintf("invalid bridge identity: %s", args[1]))
+	}
+	key, err := stub.CreateCompositeKey(K_REMOTE_BRIDGE_OBJECT_TYPE, []string{args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState(key, []byte(strings.ToLower(args[1]))); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 托管资产，向对端资产桥发送资产快照
+func (nb *NFTBridge) crossChainTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	domain, receiver := args[0], strings.ToLower(args[1])
+	if err := checkAccount(receiver); err != nil {
+		return shim.Error(err.Error())
+	}
+	remote, err := nb.getRemoteBridge(stub, domain)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	asset, err := ownedAsset(stub, args[2])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	sender := asset.Owner
+	if err := appendProvenance(stub, asset, &Provenance{Action: ACTION_CROSS_OUT, Domain: domain, From: sender, To: receiver}); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := delRecord(stub, K_ASSET_OBJECT_TYPE, asset.ID); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := putRecord(stub, K_ESCROW_OBJECT_TYPE, asset.ID, &Escrow{Domain: domain, Asset: asset}); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	crosscc, err := stub.GetState(K_CROSS_CHAINCODE)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	bz, _ := json.Marshal(&AssetPacket{Sender: sender, Receiver: receiver, Asset: asset, Nonce: stub.GetTxID()})
+	re := stub.InvokeChaincode(string(crosscc), [][]byte{
+		[]byte("sendMessage"), // 有序消息
+		[]byte(domain),
+		[]byte(remote),
+		bz,
+	}, stub.GetChannelID())
+	if re.Status != shim.OK {
+		return shim.Error(fmt.Sprintf("failed to send message: %s", re.Message))
+	}
+	if err := stub.SetEvent(K_OUTBOUND_EVENT, []byte("["+string(re.Payload)+"]")); err != nil {
+		return shim.Error(err.Error())
+	}
+	return re
+}
+
+// 接收对端资产桥的报文
+func (nb *NFTBridge) recvMessage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	domain, identity := args[0], strings.ToLower(args[1])
+	fmt.Printf("NFTBridge recv message from domain:%s, identity:%s, msg:%s\n", domain, identity, args[2])
+
+	crosscc, err := stub.GetState(K_CROSS_CHAINCODE)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	caller, err := getProposalChaincode(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if caller != string(crosscc) {
+		return shim.Error(fmt.Sprintf("message must be delivered by %s, got %s", crosscc, caller))
+	}
+	remote, err := nb.getRemoteBridge(stub, domain)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if remote != identity {
+		return shim.Error(fmt.Sprintf("unknown sender %s from domain %s", identity, domain))
+	}
+
+	var packet AssetPacket
+	if err := json.Unmarshal([]byte(args[2]), &packet); err != nil {
+		return shim.Error(fmt.Sprintf("invalid packet: %v", err))
+	}
+	if err := checkAccount(packet.Receiver); err != nil {
+		return shim.Error(err.Error())
+	}
+	if packet.Nonce == "" || packet.Asset == nil || packet.Asset.ID == "" {
+		return shim.Error(fmt.Sprintf("invalid packet: %s", args[2]))
+	}
+	if err := checkMetadata(packet.Asset.Metadata); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// 回执防重放
+	receiptKey, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, []string{domain, packet.Nonce})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if raw, err := stub.GetState(receiptKey); err != nil {
+		return shim.Error(err.Error())
+	} else if len(raw) != 0 {
+		return shim.Error(fmt.Sprintf("packet %s from %s is already processed", packet.Nonce, domain))
+	}
+
+	asset, released, err := nb.arrive(stub, domain, packet.Asset)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := appendProvenance(stub, asset, &Provenance{Action: ACTION_CROSS_IN, Domain: domain, From: packet.Sender, To: packet.Receiver}); err != nil {
+		return shim.Error(err.Error())
+	}
+	asset.Owner = packet.Receiver
+	if err := putAsset(stub, asset); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	bz, _ := json.Marshal(&Receipt{
+		Domain: domain, Nonce: packet.Nonce, AssetId: asset.ID, Receiver: packet.Receiver, Released: released, TxId: stub.GetTxID(),
+	})
+	if err := stub.PutState(receiptKey, bz); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 资产到达本链: 转往来源域名的托管资产释放，保留本链的元数据，采用报文中更完整的流转历史；
+// 其他资产按快照重建，ID不能与本链资产冲突
+func (nb *NFTBridge) arrive(stub shim.ChaincodeStubInterface, domain string, snapshot *Asset) (*Asset, bool, error) {
+	var escrow Escrow
+	found, err := getRecord(stub, K_ESCROW_OBJECT_TYPE, snapshot.ID, &escrow)
+	if err != nil {
+		return nil, false, err
+	}
+	if found {
+		if escrow.Domain != domain {
+			return nil, false, fmt.Errorf("asset %s is escrowed for domain %s, not %s", snapshot.ID, escrow.Domain, domain)
+		}
+		if err := delRecord(stub, K_ESCROW_OBJECT_TYPE, snapshot.ID); err != nil {
+			return nil, false, err
+		}
+		asset := escrow.Asset
+		if len(snapshot.History) > len(asset.History) {
+			asset.History = snapshot.History
+		}
+		return asset, true, nil
+	}
+
+	exists, err := assetExists(stub, snapshot.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		return nil, false, fmt.Errorf("asset %s already exists", snapshot.ID)
+	}
+	asset := &Asset{ID: snapshot.ID, Metadata: snapshot.Metadata, Origin: snapshot.Origin, History: snapshot.History}
+	if asset.Origin == "" {
+		asset.Origin = domain
+	}
+	return asset, false, nil
+}
+
+func (nb *NFTBridge) queryReceipt(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	key, err := stub.CreateCompositeKey(K_RECEIPT_OBJECT_TYPE, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	receipt, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(receipt) == 0 {
+		return shim.Error(fmt.Sprintf("receipt of %s from %s not found", args[1], args[0]))
+	}
+	return shim.Success(receipt)
+}
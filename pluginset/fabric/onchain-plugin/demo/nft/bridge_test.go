@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	comm "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 记录发送消息的跨链链码
+type mockCrossChaincode struct {
+	sent [][]string
+}
+
+func (cc *mockCrossChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (cc *mockCrossChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	if fn != "sendMessage" {
+		return shim.Error("Method not found")
+	}
+	cc.sent = append(cc.sent, args)
+	return shim.Success([]byte(`{"key":"msg_` + strconv.Itoa(len(cc.sent)) + `"}`))
+}
+
+// 自签名证书的creator，返回creator和账户
+func mockCreator(t *testing.T, name string) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{
+		Mspid: "Org1MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	h := sha256.Sum256(der)
+	return creator, hex.EncodeToString(h[:])
+}
+
+func mockSignedProposal(ccname string) *pb.SignedProposal {
+	ext, _ := proto.Marshal(&pb.ChaincodeHeaderExtension{ChaincodeId: &pb.ChaincodeID{Name: ccname}})
+	channelHeader, _ := proto.Marshal(&comm.ChannelHeader{Extension: ext})
+	header, _ := proto.Marshal(&comm.Header{ChannelHeader: channelHeader})
+	proposal, _ := proto.Marshal(&pb.Proposal{Header: header})
+	return &pb.SignedProposal{ProposalBytes: proposal}
+}
+
+type nftChain struct {
+	t     *testing.T
+	stub  *shimtest.MockStub
+	cross *mockCrossChaincode
+	txid  int
+}
+
+func newNFTChain(t *testing.T, name string, admin []byte) *nftChain {
+	c := &nftChain{t: t, stub: shimtest.NewMockStub(name, NewNFTBridge()), cross: &mockCrossChaincode{}}
+	c.stub.MockPeerChaincode("cross", shimtest.NewMockStub("cross", c.cross), "")
+	c.stub.Creator = admin
+	if res := c.stub.MockInit("init", [][]byte{[]byte("init"), []byte("cross")}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	return c
+}
+
+func (c *nftChain) invoke(creator []byte, caller string, args ...string) pb.Response {
+	var bargs [][]byte
+	for _, arg := range args {
+		bargs = append(bargs, []byte(arg))
+	}
+	c.txid++
+	c.stub.Creator = creator
+	return c.stub.MockInvokeWithSignedProposal("tx"+strconv.Itoa(c.txid), bargs, mockSignedProposal(caller))
+}
+
+func (c *nftChain) mustInvoke(creator []byte, caller string, args ...string) {
+	if res := c.invoke(creator, caller, args...); res.Status != shim.OK {
+		c.t.Fatal(res.Message)
+	}
+}
+
+func (c *nftChain) asset(id string) *Asset {
+	res := c.invoke(c.stub.Creator, "nft", "queryAsset", id)
+	if res.Status != shim.OK {
+		c.t.Fatal(res.Message)
+	}
+	var asset Asset
+	if err := json.Unmarshal(res.Payload, &asset); err != nil {
+		c.t.Fatal(err)
+	}
+	return &asset
+}
+
+// 最后一次发送的报文
+func (c *nftChain) lastPacket() string {
+	return c.cross.sent[len(c.cross.sent)-1][2]
+}
+
+func actions(asset *Asset) string {
+	var s string
+	for _, p := range asset.History {
+		s += p.Action + " "
+	}
+	return s
+}
+
+func TestNFTBridge(t *testing.T) {
+	alice, aliceAccount := mockCreator(t, "alice")
+	bob, bobAccount := mockCreator(t, "bob")
+	carol, carolAccount := mockCreator(t, "carol")
+	a, b := newNFTChain(t, "nftA", alice), newNFTChain(t, "nftB", bob)
+	bridgeA, bridgeB, bridgeC := sha256.Sum256([]byte("nftA")), sha256.Sum256([]byte("nftB")), sha256.Sum256([]byte("nftC"))
+	a.mustInvoke(alice, "nftA", "setRemoteBridge", "chainB", hex.EncodeToString(bridgeB[:]))
+	a.mustInvoke(alice, "nftA", "setRemoteBridge", "chainC", hex.EncodeToString(bridgeC[:]))
+	b.mustInvoke(bob, "nftB", "setRemoteBridge", "chainA", hex.EncodeToString(bridgeA[:]))
+
+	metadata := `{"name":"Kitty #1","uri":"ipfs://kitty/1"}`
+	a.mustInvoke(alice, "nftA", "mintAsset", "kitty1", aliceAccount, metadata)
+	if res := a.invoke(alice, "nftA", "mintAsset", "kitty1", aliceAccount, metadata); res.Status == shim.OK {
+		t.Fatal("asset id should be unique")
+	}
+	if res := a.invoke(bob, "nftA", "mintAsset", "kitty2", bobAccount, metadata); res.Status == shim.OK {
+		t.Fatal("non-admin should not mint")
+	}
+	if res := a.invoke(alice, "nftA", "mintAsset", "kitty2", aliceAccount, `"kitty"`); res.Status == shim.OK {
+		t.Fatal("metadata should be a json object")
+	}
+
+	// A链托管，B链重建
+	if res := a.invoke(bob, "nftA", "crossChainTransfer", "chainB", bobAccount, "kitty1"); res.Status == shim.OK {
+		t.Fatal("only owner can transfer asset")
+	}
+	a.mustInvoke(alice, "nftA", "crossChainTransfer", "chainB", bobAccount, "kitty1")
+	if res := a.invoke(alice, "nftA", "queryAsset", "kitty1"); res.Status == shim.OK {
+		t.Fatal("escrowed asset should not circulate")
+	}
+	if res := a.invoke(alice, "nftA", "queryEscrow", "kitty1"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	out := a.lastPacket()
+	b.mustInvoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), out)
+	asset := b.asset("kitty1")
+	if asset.Owner != bobAccount || asset.Origin != "chainA" || string(asset.Metadata) != metadata || actions(asset) != "MINT CROSS_OUT CROSS_IN " {
+		t.Fatalf("unexpected asset: %+v", asset)
+	}
+
+	// 重放、非跨链链码调用、未登记的发送方
+	if res := b.invoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), out); res.Status == shim.OK {
+		t.Fatal("replayed packet should be rejected")
+	}
+	if res := b.invoke(bob, "nftB", "recvMessage", "chainA", hex.EncodeToString(bridgeA[:]), out); res.Status == shim.OK {
+		t.Fatal("packet should be delivered by cross chaincode")
+	}
+	if res := b.invoke(bob, "cross", "recvMessage", "chainA", hex.EncodeToString(bridgeB[:]), out); res.Status == shim.OK {
+		t.Fatal("unknown sender should be rejected")
+	}
+
+	// 托管中的资产只能从转往的域名转回
+	var forged AssetPacket
+	_ = json.Unmarshal([]byte(out), &forged)
+	forged.Nonce = "forged"
+	bz, _ := json.Marshal(&forged)
+	if res := a.invoke(alice, "cross", "recvMessage", "chainC", hex.EncodeToString(bridgeC[:]), string(bz)); res.Status == shim.OK {
+		t.Fatal("escrowed asset should only return from its destination")
+	}
+
+	// B链内转移后转回A链，A链释放托管
+	b.mustInvoke(bob, "nftB", "transferAsset", "kitty1", carolAccount)
+	if res := b.invoke(bob, "nftB", "crossChainTransfer", "chainA", aliceAccount, "kitty1"); res.Status == shim.OK {
+		t.Fatal("previous owner should not transfer asset")
+	}
+	b.mustInvoke(carol, "nftB", "crossChainTransfer", "chainA", aliceAccount, "kitty1")
+	a.mustInvoke(alice, "cross", "recvUnorderedMessage", "chainB", hex.EncodeToString(bridgeB[:]), b.lastPacket())
+	asset = a.asset("kitty1")
+	if asset.Owner != aliceAccount || asset.Origin != "" || actions(asset) != "MINT CROSS_OUT CROSS_IN TRANSFER CROSS_OUT CROSS_IN " {
+		t.Fatalf("unexpected asset: %+v", asset)
+	}
+	if res := a.invoke(alice, "nftA", "queryEscrow", "kitty1"); res.Status == shim.OK {
+		t.Fatal("escrow should be released")
+	}
+	var receipt Receipt
+	res := a.invoke(alice, "nftA", "queryReceipt", "chainB", "tx"+strconv.Itoa(b.txid))
+	if err := json.Unmarshal(res.Payload, &receipt); err != nil || !receipt.Released || receipt.AssetId != "kitty1" {
+		t.Fatalf("unexpected receipt: %s %s", res.Payload, res.Message)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// 跨链NFT(非同质化资产)示例链码
+// 同一份链码部署在多条链上，资产以ID唯一标识，记录元数据和流转历史。
+//   - 转出资产: 资产从本链资产表移入托管表，经跨链链码发送带有资产快照(含元数据和历史)的报文
+//   - 接收资产: 托管在本链且转往来源域名的资产原样释放，否则按报文中的快照在本链重建
+//
+// 账户为交易提交者证书的sha256(hex)，与SDP报文中32字节身份的长度一致。
+// 接收报文时校验调用来自跨链链码、来源为登记的对端资产桥，并按(来源域名, nonce)记录回执，
+// 同一报文不会重复入账。
+func main() {
+	if err := shim.Start(NewNFTBridge()); err != nil {
+		fmt.Printf("Error starting nft bridge chaincode: %s", err)
+	}
+}
+
+type NFTBridge struct {
+}
+
+func NewNFTBridge() *NFTBridge {
+	return &NFTBridge{}
+}
+
+// 初始化
+// args[0] 跨链链码名
+func (nb *NFTBridge) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) == 0 {
+		// 升级时不带参数，保留原有配置
+		return shim.Success(nil)
+	}
+	if len(args) != 1 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	admin, err := clientAccount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, kv := range [][2]string{{K_CROSS_CHAINCODE, args[0]}, {K_ADMIN, admin}} {
+		if err := stub.PutState(kv[0], []byte(kv[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (nb *NFTBridge) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	fmt.Printf("NFTBridge invoked func %s\n", fn)
+
+	switch fn {
+
+	// 当前提交者的账户
+	case "clientAccountID":
+		account, err := clientAccount(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(account))
+
+	// 管理员铸造资产
+	// args[0] 资产ID
+	// args[1] 所有者账户
+	// args[2] 元数据(JSON对象)
+	case "mintAsset":
+		if err := checkAdmin(stub); err != nil {
+			return shim.Error(err.Error())
+		}
+		return nb.mintAsset(stub, args)
+
+	// 本链内转移资产
+	// args[0] 资产ID
+	// args[1] 接收方账户
+	case "transferAsset":
+		return nb.transferAsset(stub, args)
+
+	// args[0] 资产ID
+	case "queryAsset":
+		return nb.queryAsset(stub, args)
+
+	// 托管中的资产，即已转出到其他链的资产
+	// args[0] 资产ID
+	case "queryEscrow":
+		return nb.queryEscrow(stub, args)
+
+	// 管理员登记对端链上的资产桥
+	// args[0] 对端域名
+	// args[1] 对端资产桥身份，32字节hex。对端为Fabric时是资产桥链码名的sha256
+	case "setRemoteBridge":
+		if err := checkAdmin(stub); err != nil {
+			return shim.Error(err.Error())
+		}
+		return nb.setRemoteBridge(stub, args)
+
+	// 托管资产并转出到对端链
+	// args[0] 对端域名
+	// args[1] 对端的接收方账户
+	// args[2] 资产ID
+	case "crossChainTransfer":
+		return nb.crossChainTransfer(stub, args)
+
+	// 跨链链码回调，接收对端资产桥的报文
+	// args[0] 来源域名
+	// args[1] 来源身份(hex)
+	// args[2] 报文
+	case "recvMessage", "recvUnorderedMessage":
+		return nb.recvMessage(stub, args)
+
+	// 查询报文的入账回执
+	// args[0] 来源域名
+	// args[1] 报文nonce
+	case "queryReceipt":
+		return nb.queryReceipt(stub, args)
+
+	default:
+		return shim.Error("Method not found")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+const (
+	PREFIX = "tokenbridge_"
+
+	K_NAME            = PREFIX + "name"
+	K_SYMBOL          = PREFIX + "symbol"
+	K_CROSS_CHAINCODE = PREFIX + "cross_chaincode"
+	K_ADMIN           = PREFIX + "admin"
+	K_TOTAL_SUPPLY    = PREFIX + "total_supply"
+
+	// ${K_BALANCE_OBJECT_TYPE}~${account} -> 十进制数额
+	K_BALANCE_OBJECT_TYPE = PREFIX + "balance"
+
+	// ${K_ALLOWANCE_OBJECT_TYPE}~${owner}~${spender} -> 十进制数额
+	K_ALLOWANCE_OBJECT_TYPE = PREFIX + "allowance"
+
+	EVENT_TRANSFER = "Transfer"
+)
+
+type TransferEvent struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount uint64 `json:"amount"`
+}
+
+// 交易提交者的账户: 证书sha256(hex)
+func clientAccount(stub shim.ChaincodeStubInterface) (string, error) {
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil || cert == nil {
+		return "", errors.New("creator is not identified by a x509 certificate")
+	}
+	h := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(h[:]), nil
+}
+
+func checkAdmin(stub shim.ChaincodeStubInterface) error {
+	account, err := clientAccount(stub)
+	if err != nil {
+		return err
+	}
+	admin, err := stub.GetState(K_ADMIN)
+	if err != nil {
+		return err
+	}
+	if string(admin) != account {
+		return fmt.Errorf("%s is not admin", account)
+	}
+	return nil
+}
+
+// 账户为32字节hex
+func checkAccount(account string) error {
+	raw, err := hex.DecodeString(account)
+	if err != nil || len(raw) != 32 {
+		return fmt.Errorf("invalid account: %s", account)
+	}
+	return nil
+}
+
+func parseAmount(s string) (uint64, error) {
+	amount, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || amount == 0 {
+		return 0, fmt.Errorf("invalid amount: %s", s)
+	}
+	return amount, nil
+}
+
+// 读取十进制数额，不存在时为0
+func getAmount(stub shim.ChaincodeStubInterface, key string) (uint64, error) {
+	raw, err := stub.GetState(key)
+	if err != nil || len(raw) == 0 {
+		return 0, err
+	}
+	return strconv.ParseUint(string(raw), 10, 64)
+}
+
+func putAmount(stub shim.ChaincodeStubInterface, key string, amount uint64) error {
+	if amount == 0 {
+		return stub.DelState(key)
+	}
+	return stub.PutState(key, []byte(strconv.FormatUint(amount, 10)))
+}
+
+// 在数额上加减，减到负数或加到溢出时报错
+func addAmount(stub shim.ChaincodeStubInterface, key string, delta uint64, sub bool) error {
+	amount, err := getAmount(stub, key)
+	if err != nil {
+		return err
+	}
+	if sub {
+		if amount < delta {
+			return fmt.Errorf("insufficient amount %d, need %d", amount, delta)
+		}
+		return putAmount(stub, key, amount-delta)
+	}
+	if amount+delta < amount {
+		return errors.New("amount overflows")
+	}
+	return putAmount(stub, key, amount+delta)
+}
+
+func balanceKey(stub shim.ChaincodeStubInterface, account string) (string, error) {
+	return stub.CreateCompositeKey(K_BALANCE_OBJECT_TYPE, []string{account})
+}
+
+func (tb *TokenBridge) getConfig(stub shim.ChaincodeStubInterface, key string) pb.Response {
+	value, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(value)
+}
+
+// 本链账户之间转移原生代币
+func (tb *TokenBridge) move(stub shim.ChaincodeStubInterface, from, to string, amount uint64) error {
+	if err := checkAccount(to); err != nil {
+		return err
+	}
+	fromKey, err := balanceKey(stub, from)
+	if err != nil {
+		return err
+	}
+	if err := addAmount(stub, fromKey, amount, true); err != nil {
+		return fmt.Errorf("balance of %s: %v", from, err)
+	}
+	toKey, err := balanceKey(stub, to)
+	if err != nil {
+		return err
+	}
+	if err := addAmount(stub, toKey, amount, false); err != nil {
+		return err
+	}
+	bz, _ := json.Marshal(&TransferEvent{From: from, To: to, Amount: amount})
+	return stub.SetEvent(EVENT_TRANSFER, bz)
+}
+
+func (tb *TokenBridge) totalSupply(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	amount, err := getAmount(stub, K_TOTAL_SUPPLY)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte(strconv.FormatUint(amount, 10)))
+}
+
+func (tb *TokenBridge) balanceOf(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	key, err := balanceKey(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	amount, err := getAmount(stub, key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte(strconv.FormatUint(amount, 10)))
+}
+
+func (tb *TokenBridge) transfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	from, err := clientAccount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := tb.move(stub, from, args[0], amount); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func (tb *TokenBridge) approve(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if err := checkAccount(args[0]); err != nil {
+		return shim.Error(err.Error())
+	}
+	amount, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("invalid amount: %s", args[1]))
+	}
+	owner, err := clientAccount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := stub.CreateCompositeKey(K_ALLOWANCE_OBJECT_TYPE, []string{owner, args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := putAmount(stub, key, amount); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func (tb *TokenBridge) allowance(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	key, err := stub.CreateCompositeKey(K_ALLOWANCE_OBJECT_TYPE, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	amount, err := getAmount(stub, key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte(strconv.FormatUint(amount, 10)))
+}
+
+func (tb *TokenBridge) transferFrom(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	amount, err := parseAmount(args[2])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	spender, err := clientAccount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := stub.CreateCompositeKey(K_ALLOWANCE_OBJECT_TYPE, []string{args[0], spender})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, key, amount, true); err != nil {
+		return shim.Error(fmt.Sprintf("allowance of %s: %v", spender, err))
+	}
+	if err := tb.move(stub, args[0], args[1], amount); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func (tb *TokenBridge) mint(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error(fmt.Sprintf("Wrong length of args: %v", len(args)))
+	}
+	if err := checkAccount(args[0]); err != nil {
+		return shim.Error(err.Error())
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, K_TOTAL_SUPPLY, amount, false); err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := balanceKey(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := addAmount(stub, key, amount, false); err != nil {
+		return shim.Error(err.Error())
+	}
+	bz, _ := json.Marshal(&TransferEvent{To: args[0], Amount: amount})
+	if err := stub.SetEvent(EVENT_TRANSFER, bz); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}